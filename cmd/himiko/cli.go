@@ -0,0 +1,280 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/blubskye/himiko/internal/bot"
+	"github.com/blubskye/himiko/internal/config"
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/blubskye/himiko/internal/updater"
+	"github.com/bwmarrin/discordgo"
+)
+
+// cliCommand is a maintenance task run instead of the bot
+type cliCommand struct {
+	usage       string
+	description string
+	run         func(cfg *config.Config, args []string) error
+}
+
+var cliCommands = map[string]cliCommand{
+	"migrate": {
+		usage:       "migrate",
+		description: "Apply database schema migrations and exit",
+		run:         cliMigrate,
+	},
+	"backup": {
+		usage:       "backup [file]",
+		description: "Write a consistent copy of the database (default: himiko-backup-<time>.db)",
+		run:         cliBackup,
+	},
+	"export-guild": {
+		usage:       "export-guild <guild id> [file]",
+		description: "Export every stored row for a server as JSON (default: stdout)",
+		run:         cliExportGuild,
+	},
+	"encrypt-migrate": {
+		usage:       "encrypt-migrate",
+		description: "Encrypt existing sensitive fields with the configured encryption key",
+		run:         cliEncryptMigrate,
+	},
+	"healthcheck": {
+		usage:       "healthcheck",
+		description: "Check the config, database, Discord token and dashboard; exits non-zero on failure",
+		run:         cliHealthcheck,
+	},
+	"register-commands": {
+		usage:       "register-commands",
+		description: "Push the slash command list to Discord without starting the bot",
+		run:         cliRegisterCommands,
+	},
+}
+
+// cliOrder keeps the help output stable
+var cliOrder = []string{"migrate", "backup", "export-guild", "encrypt-migrate", "healthcheck", "register-commands"}
+
+// runCLI runs a maintenance subcommand and returns the process exit code
+func runCLI(name string, args []string) int {
+	if name == "help" || name == "-h" || name == "--help" {
+		printCLIUsage()
+		return 0
+	}
+	if name == "version" || name == "--version" {
+		fmt.Println("himiko v" + updater.GetCurrentVersion())
+		return 0
+	}
+
+	cmd, ok := cliCommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printCLIUsage()
+		return 2
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	if err := cmd.run(cfg, args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func printCLIUsage() {
+	fmt.Println("Usage: himiko [command]")
+	fmt.Println()
+	fmt.Println("Runs the bot when no command is given. Maintenance commands:")
+	for _, name := range cliOrder {
+		cmd := cliCommands[name]
+		fmt.Printf("  %-32s %s\n", cmd.usage, cmd.description)
+	}
+}
+
+// openDatabase opens the configured database, applying schema migrations
+func openDatabase(cfg *config.Config) (*database.DB, error) {
+	var encryptionKey string
+	if cfg.Encryption.Enabled {
+		if cfg.Encryption.Key == "" {
+			return nil, fmt.Errorf("encryption is enabled but no encryption key is set in config")
+		}
+		encryptionKey = cfg.Encryption.Key
+	}
+	return database.NewWithEncryption(cfg.DatabasePath, encryptionKey)
+}
+
+func cliMigrate(cfg *config.Config, args []string) error {
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fmt.Printf("Database %s is up to date\n", cfg.DatabasePath)
+	return nil
+}
+
+func cliBackup(cfg *config.Config, args []string) error {
+	dest := fmt.Sprintf("himiko-backup-%s.db", time.Now().Format("20060102-150405"))
+	if len(args) > 0 {
+		dest = args[0]
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.Backup(dest); err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %s to %s\n", cfg.DatabasePath, dest)
+	return nil
+}
+
+func cliExportGuild(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: himiko export-guild <guild id> [file]")
+	}
+	guildID := args[0]
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tables, err := db.ExportGuild(guildID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"guild_id":    guildID,
+		"exported_at": time.Now().UTC(),
+		"version":     updater.GetCurrentVersion(),
+		"tables":      tables,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if len(args) < 2 {
+		fmt.Println(string(data))
+		return nil
+	}
+	if err := os.WriteFile(args[1], data, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d tables for guild %s to %s\n", len(tables), guildID, args[1])
+	return nil
+}
+
+func cliEncryptMigrate(cfg *config.Config, args []string) error {
+	if !cfg.Encryption.Enabled {
+		return fmt.Errorf("encryption is not enabled in config")
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if db.IsDataMigrated() {
+		fmt.Println("Data is already encrypted")
+		return nil
+	}
+	if err := db.MigrateToEncrypted(); err != nil {
+		return err
+	}
+	fmt.Println("Sensitive fields are now encrypted")
+	return nil
+}
+
+func cliHealthcheck(cfg *config.Config, args []string) error {
+	failed := false
+	report := func(name string, err error) {
+		if err != nil {
+			failed = true
+			fmt.Printf("FAIL  %-10s %v\n", name, err)
+			return
+		}
+		fmt.Printf("OK    %s\n", name)
+	}
+
+	report("config", nil)
+
+	db, err := openDatabase(cfg)
+	if err == nil {
+		err = db.Ping()
+		db.Close()
+	}
+	report("database", err)
+
+	session, err := discordgo.New("Bot " + cfg.Token)
+	if err == nil {
+		session.Client.Timeout = 10 * time.Second
+		_, err = session.User("@me")
+	}
+	report("discord", err)
+
+	if cfg.WebServer.Enabled {
+		host := cfg.WebServer.Host
+		if host == "" || host == "0.0.0.0" {
+			host = "127.0.0.1"
+		}
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get(fmt.Sprintf("http://%s:%d/api/status", host, cfg.WebServer.Port))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("status %d", resp.StatusCode)
+			}
+		}
+		report("dashboard", err)
+	}
+
+	if failed {
+		return fmt.Errorf("unhealthy")
+	}
+	return nil
+}
+
+func cliRegisterCommands(cfg *config.Config, args []string) error {
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	b, err := bot.New(cfg, db)
+	if err != nil {
+		return err
+	}
+	return b.SyncCommands()
+}
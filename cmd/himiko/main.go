@@ -24,34 +24,35 @@ import (
 
 	"github.com/blubskye/himiko/internal/bot"
 	"github.com/blubskye/himiko/internal/config"
-	"github.com/blubskye/himiko/internal/database"
 )
 
+const configPath = "config.json"
+
 func main() {
+	// Maintenance commands run instead of the bot
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1], os.Args[2:]))
+	}
+
 	log.Println("Starting Himiko Bot...")
 
 	// Load configuration
-	cfg, err := config.Load("config.json")
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
 	// Initialize database with optional encryption
-	var encryptionKey string
-	if cfg.Encryption.Enabled {
-		if cfg.Encryption.Key == "" {
-			log.Fatalf("Encryption is enabled but no encryption key is set in config")
-		}
-		encryptionKey = cfg.Encryption.Key
-		log.Println("Field-level encryption is enabled")
-	}
-
-	db, err := database.NewWithEncryption(cfg.DatabasePath, encryptionKey)
+	db, err := openDatabase(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
 
+	if cfg.Encryption.Enabled {
+		log.Println("Field-level encryption is enabled")
+	}
+
 	// Run encryption migration if encryption is enabled and data isn't migrated yet
 	if cfg.Encryption.Enabled && !db.IsDataMigrated() {
 		log.Println("Running encryption migration for existing data...")
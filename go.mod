@@ -3,11 +3,14 @@ module github.com/blubskye/himiko
 go 1.25.4
 
 require (
-	github.com/bwmarrin/discordgo v0.29.0 // indirect
-	github.com/gorilla/websocket v1.4.2 // indirect
-	github.com/jonas747/dca v0.0.0-20210930103944-155f5e5f0cc7 // indirect
+	github.com/bwmarrin/discordgo v0.29.0
+	github.com/gorilla/websocket v1.4.2
+	github.com/jonas747/dca v0.0.0-20210930103944-155f5e5f0cc7
+	github.com/mattn/go-sqlite3 v1.14.32
+	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b
+)
+
+require (
 	github.com/jonas747/ogg v0.0.0-20161220051205-b4f6f4cf3757 // indirect
-	github.com/mattn/go-sqlite3 v1.14.32 // indirect
-	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
 	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68 // indirect
 )
@@ -0,0 +1,152 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Stats that achievements can be earned on
+const (
+	statMessages     = "messages"
+	statCommands     = "commands"
+	statVoiceMinutes = "voice_minutes"
+	statSongs        = "songs"
+	statLevel        = "level"
+)
+
+// achievementStats describes each stat for display
+var achievementStats = map[string]string{
+	statMessages:     "Messages sent",
+	statCommands:     "Commands used",
+	statVoiceMinutes: "Minutes in voice",
+	statSongs:        "Songs requested",
+	statLevel:        "Level",
+}
+
+type achievement struct {
+	ID          string
+	Name        string
+	Description string
+	Emoji       string
+	Stat        string
+	Threshold   int64
+	RoleID      string
+}
+
+var builtinAchievements = []achievement{
+	{"first_message", "Hello World", "Send your first message", "👋", statMessages, 1, ""},
+	{"messages_1000", "Chatterbox", "Send 1,000 messages", "💬", statMessages, 1000, ""},
+	{"messages_10000", "Never Stops Talking", "Send 10,000 messages", "📢", statMessages, 10000, ""},
+	{"level_5", "Getting Started", "Reach level 5", "⭐", statLevel, 5, ""},
+	{"level_10", "Regular", "Reach level 10", "🌟", statLevel, 10, ""},
+	{"level_25", "Veteran", "Reach level 25", "💫", statLevel, 25, ""},
+	{"level_50", "Legend", "Reach level 50", "👑", statLevel, 50, ""},
+	{"commands_10", "Curious", "Use 10 commands", "🔧", statCommands, 10, ""},
+	{"commands_100", "Power User", "Use 100 commands", "⚙️", statCommands, 100, ""},
+	{"voice_1h", "Say Something", "Spend an hour in voice", "🎙️", statVoiceMinutes, 60, ""},
+	{"voice_10h", "Voice Regular", "Spend 10 hours in voice", "🎧", statVoiceMinutes, 600, ""},
+	{"voice_100h", "Lives in Voice", "Spend 100 hours in voice", "📻", statVoiceMinutes, 6000, ""},
+	{"songs_1", "DJ", "Request your first song", "🎵", statSongs, 1, ""},
+	{"songs_100", "Music Lover", "Request 100 songs", "🎶", statSongs, 100, ""},
+}
+
+// guildAchievements returns the built-in and custom achievements for a guild
+func (b *Bot) guildAchievements(guildID string) []achievement {
+	achievements := append([]achievement(nil), builtinAchievements...)
+	custom, err := b.DB.GetCustomAchievements(guildID)
+	if err != nil {
+		return achievements
+	}
+	for _, c := range custom {
+		emoji := c.Emoji
+		if emoji == "" {
+			emoji = "🏅"
+		}
+		achievements = append(achievements, achievement{
+			ID:          fmt.Sprintf("custom:%d", c.ID),
+			Name:        c.Name,
+			Description: c.Description,
+			Emoji:       emoji,
+			Stat:        c.Stat,
+			Threshold:   c.Threshold,
+			RoleID:      c.RoleID,
+		})
+	}
+	return achievements
+}
+
+// trackAchievementStat adds to a counting stat and awards any achievements it unlocks.
+// channelID is where the member was active, used for announcements; it may be empty.
+func (b *Bot) trackAchievementStat(guildID, userID, stat string, delta int64, channelID string) {
+	if guildID == "" || delta <= 0 {
+		return
+	}
+	value, err := b.DB.IncrementAchievementStat(guildID, userID, stat, delta)
+	if err != nil {
+		return
+	}
+	b.checkAchievements(guildID, userID, stat, value-delta, value, channelID)
+}
+
+// raiseAchievementStat records a high-water-mark stat such as level
+func (b *Bot) raiseAchievementStat(guildID, userID, stat string, value int64, channelID string) {
+	if guildID == "" {
+		return
+	}
+	previous, err := b.DB.RaiseAchievementStat(guildID, userID, stat, value)
+	if err != nil || value <= previous {
+		return
+	}
+	b.checkAchievements(guildID, userID, stat, previous, value, channelID)
+}
+
+// checkAchievements awards achievements whose threshold was crossed going from old to new
+func (b *Bot) checkAchievements(guildID, userID, stat string, old, new int64, channelID string) {
+	cfg, err := b.DB.GetAchievementConfig(guildID)
+	if err != nil || !cfg.Enabled {
+		return
+	}
+
+	for _, a := range b.guildAchievements(guildID) {
+		if a.Stat != stat || a.Threshold > new || a.Threshold <= old {
+			continue
+		}
+		earned, err := b.DB.AwardAchievement(guildID, userID, a.ID)
+		if err != nil || !earned {
+			continue
+		}
+		if a.RoleID != "" {
+			b.Session.GuildMemberRoleAdd(guildID, userID, a.RoleID)
+		}
+		if cfg.Announce {
+			announceChannel := cfg.ChannelID
+			if announceChannel == "" {
+				announceChannel = channelID
+			}
+			if announceChannel != "" {
+				b.Session.ChannelMessageSendEmbed(announceChannel, &discordgo.MessageEmbed{
+					Title:       "🏅 Achievement Unlocked",
+					Description: fmt.Sprintf("<@%s> earned %s **%s**\n%s", userID, a.Emoji, a.Name, a.Description),
+					Color:       0xF1C40F,
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// activityHourRetention is how long per-channel hourly counts are kept. Daily totals
+// and per-member counts are kept for good.
+const activityHourRetention = 90 * 24 * time.Hour
+
+// activityDayFormat is how rollup days are written, matching SQLite's date()
+const activityDayFormat = "2006-01-02"
+
+type activityHourKey struct {
+	guildID, channelID, day string
+	hour                    int
+}
+
+type activityDayKey struct {
+	guildID, day string
+}
+
+type activityMemberKey struct {
+	guildID, userID, day string
+}
+
+// activityCounter counts messages, joins and leaves in memory so chatty servers cost
+// one write per flush rather than one per message
+type activityCounter struct {
+	mu      sync.Mutex
+	hours   map[activityHourKey]int
+	days    map[activityDayKey]*database.ActivityDay
+	members map[activityMemberKey]int
+}
+
+var serverActivity = newActivityCounter()
+
+func newActivityCounter() *activityCounter {
+	return &activityCounter{
+		hours:   make(map[activityHourKey]int),
+		days:    make(map[activityDayKey]*database.ActivityDay),
+		members: make(map[activityMemberKey]int),
+	}
+}
+
+// day returns the running totals for a guild's day, the counter must be locked
+func (c *activityCounter) day(guildID, day string) *database.ActivityDay {
+	key := activityDayKey{guildID, day}
+	totals, ok := c.days[key]
+	if !ok {
+		totals = &database.ActivityDay{GuildID: guildID, Day: day}
+		c.days[key] = totals
+	}
+	return totals
+}
+
+func (c *activityCounter) addMessage(guildID, channelID, userID string, at time.Time) {
+	at = at.UTC()
+	day := at.Format(activityDayFormat)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hours[activityHourKey{guildID, channelID, day, at.Hour()}]++
+	c.members[activityMemberKey{guildID, userID, day}]++
+	c.day(guildID, day).Messages++
+}
+
+func (c *activityCounter) addJoin(guildID string, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.day(guildID, at.UTC().Format(activityDayFormat)).Joins++
+}
+
+func (c *activityCounter) addLeave(guildID string, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.day(guildID, at.UTC().Format(activityDayFormat)).Leaves++
+}
+
+// take empties the counter and returns what it held
+func (c *activityCounter) take() *database.ActivityBatch {
+	c.mu.Lock()
+	hours, days, members := c.hours, c.days, c.members
+	c.hours = make(map[activityHourKey]int)
+	c.days = make(map[activityDayKey]*database.ActivityDay)
+	c.members = make(map[activityMemberKey]int)
+	c.mu.Unlock()
+
+	batch := &database.ActivityBatch{}
+	for key, messages := range hours {
+		batch.Hours = append(batch.Hours, database.ActivityHour{
+			GuildID: key.guildID, ChannelID: key.channelID, Day: key.day, Hour: key.hour, Messages: messages,
+		})
+	}
+	for _, totals := range days {
+		batch.Days = append(batch.Days, *totals)
+	}
+	for key, messages := range members {
+		batch.Members = append(batch.Members, database.ActivityMemberDay{
+			GuildID: key.guildID, UserID: key.userID, Day: key.day, Messages: messages,
+		})
+	}
+	return batch
+}
+
+// countMessageActivity counts a message towards its channel, or the parent channel
+// for messages in threads
+func (b *Bot) countMessageActivity(m *discordgo.MessageCreate) {
+	channelID := m.ChannelID
+	if channel, err := b.Session.State.Channel(channelID); err == nil && channel.IsThread() {
+		channelID = channel.ParentID
+	}
+	serverActivity.addMessage(m.GuildID, channelID, m.Author.ID, time.Now())
+}
+
+// flushActivity writes the activity counted since the last flush to the database
+func (b *Bot) flushActivity() {
+	batch := serverActivity.take()
+	if len(batch.Hours) == 0 && len(batch.Days) == 0 && len(batch.Members) == 0 {
+		return
+	}
+	if err := b.DB.FlushActivity(batch); err != nil {
+		log.Printf("Failed to save server activity: %v", err)
+	}
+}
+
+// cleanOldActivity drops hourly counts past the retention period
+func (b *Bot) cleanOldActivity() {
+	b.DB.CleanOldActivityHours(time.Now().UTC().Add(-activityHourRetention).Format(activityDayFormat))
+}
+
+// sparklineBars are the block characters used to draw sparklines, lowest first
+var sparklineBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as a row of block characters scaled to the largest value
+func sparkline(values []int) string {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		level := 0
+		if peak > 0 {
+			level = v * (len(sparklineBars) - 1) / peak
+		}
+		sb.WriteRune(sparklineBars[level])
+	}
+	return sb.String()
+}
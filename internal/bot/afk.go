@@ -0,0 +1,148 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// afkNickPrefix is put in front of a member's nickname while they're AFK
+const afkNickPrefix = "[AFK] "
+
+// applyAFKNickname prefixes a member's nickname with [AFK], reporting whether Discord
+// allowed it. The server owner and members above the bot can't be renamed.
+func applyAFKNickname(s *discordgo.Session, guildID string, member *discordgo.Member, original string) bool {
+	display := original
+	if display == "" {
+		display = member.User.GlobalName
+	}
+	if display == "" {
+		display = member.User.Username
+	}
+
+	nick := []rune(afkNickPrefix + display)
+	if len(nick) > 32 {
+		nick = nick[:32]
+	}
+	return s.GuildMemberNickname(guildID, member.User.ID, string(nick)) == nil
+}
+
+// restoreAFKNickname puts back the nickname a member had before going AFK
+func restoreAFKNickname(s *discordgo.Session, afk *database.AFKStatus) {
+	if afk.NickGuildID == "" {
+		return
+	}
+	s.GuildMemberNickname(afk.NickGuildID, afk.UserID, afk.OriginalNick)
+}
+
+func (b *Bot) checkAFKMentions(s *discordgo.Session, m *discordgo.MessageCreate) {
+	for _, mention := range m.Mentions {
+		if mention.ID == m.Author.ID {
+			continue
+		}
+		afk, err := b.DB.GetAFK(mention.ID)
+		if err != nil || afk == nil {
+			continue
+		}
+		// Members AFK in one server are around everywhere else
+		if afk.GuildID != "" && afk.GuildID != m.GuildID {
+			continue
+		}
+
+		if m.GuildID != "" {
+			b.DB.AddAFKMention(&database.AFKMention{
+				UserID:    mention.ID,
+				GuildID:   m.GuildID,
+				ChannelID: m.ChannelID,
+				MessageID: m.ID,
+				AuthorID:  m.Author.ID,
+				Content:   truncate(m.Content, 500),
+			})
+		}
+
+		msg := mention.Username + " is AFK"
+		if afk.Message != nil {
+			msg += ": " + *afk.Message
+		}
+		msg += " (since <t:" + formatUnixTime(afk.SetAt) + ":R>)"
+
+		s.ChannelMessageSendReply(m.ChannelID, msg, m.Reference())
+	}
+}
+
+func (b *Bot) checkAFKReturn(s *discordgo.Session, m *discordgo.MessageCreate) {
+	afk, err := b.DB.GetAFK(m.Author.ID)
+	if err != nil || afk == nil {
+		return
+	}
+	if afk.GuildID != "" && afk.GuildID != m.GuildID {
+		return
+	}
+
+	b.DB.RemoveAFK(m.Author.ID)
+	restoreAFKNickname(s, afk)
+
+	reply := "Welcome back! I've removed your AFK status."
+	mentions, err := b.DB.GetAFKMentions(m.Author.ID)
+	if err == nil && len(mentions) > 0 {
+		b.DB.ClearAFKMentions(m.Author.ID)
+
+		times := "once"
+		if len(mentions) > 1 {
+			times = fmt.Sprintf("%d times", len(mentions))
+		}
+		reply += fmt.Sprintf(" You were mentioned %s while away", times)
+		if channel, err := s.UserChannelCreate(m.Author.ID); err == nil {
+			if _, err := s.ChannelMessageSendEmbed(channel.ID, afkMentionsEmbed(mentions)); err == nil {
+				reply += ", I've DMed you the links"
+			}
+		}
+		reply += "."
+	}
+	s.ChannelMessageSendReply(m.ChannelID, reply, m.Reference())
+}
+
+// afkMentionsEmbed lists who mentioned a member while they were away, as many as fit
+func afkMentionsEmbed(mentions []database.AFKMention) *discordgo.MessageEmbed {
+	var description strings.Builder
+	shown := 0
+	for _, am := range mentions {
+		line := fmt.Sprintf("<@%s> in <#%s> <t:%d:R> · [Jump](https://discord.com/channels/%s/%s/%s)\n> %s\n\n",
+			am.AuthorID, am.ChannelID, am.CreatedAt.Unix(), am.GuildID, am.ChannelID, am.MessageID,
+			truncate(strings.ReplaceAll(am.Content, "\n", " "), 150))
+		if description.Len()+len(line) > 3900 {
+			break
+		}
+		description.WriteString(line)
+		shown++
+	}
+	if shown < len(mentions) {
+		description.WriteString(fmt.Sprintf("…and %d more", len(mentions)-shown))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "While You Were AFK",
+		Description: description.String(),
+		Color:       0x5865F2,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+}
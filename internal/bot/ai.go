@@ -0,0 +1,340 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+)
+
+// aiTimeout bounds a single completion; local models can be slow to answer
+const aiTimeout = 90 * time.Second
+
+var aiClient = &http.Client{Timeout: aiTimeout}
+
+// errAINotConfigured is returned when neither the guild nor the config has
+// what the chosen provider needs
+var errAINotConfigured = errors.New("AI provider not configured")
+
+// AIProvider answers prompts through one vendor's chat API
+type AIProvider interface {
+	// Name is the provider's display name
+	Name() string
+
+	// Complete returns the model's reply to the request's conversation
+	Complete(ctx context.Context, req AIRequest) (string, error)
+}
+
+// AIRequest is a prompt, optionally following on from earlier messages
+type AIRequest struct {
+	Model       string
+	System      string
+	History     []AIMessage // Earlier turns, oldest first, alternating user and assistant
+	Prompt      string
+	MaxTokens   int
+	Temperature float64
+}
+
+// AIMessage is one turn of a conversation
+type AIMessage struct {
+	Role    string // "user" or "assistant"
+	Content string
+}
+
+// messages returns the conversation as chat messages, with the system prompt
+// first when withSystem is set
+func (req AIRequest) messages(withSystem bool) []map[string]string {
+	var messages []map[string]string
+	if withSystem {
+		messages = append(messages, map[string]string{"role": "system", "content": req.System})
+	}
+	for _, m := range req.History {
+		messages = append(messages, map[string]string{"role": m.Role, "content": m.Content})
+	}
+	return append(messages, map[string]string{"role": "user", "content": req.Prompt})
+}
+
+// aiProviderNames labels the providers for replies
+var aiProviderNames = map[string]string{
+	database.AIProviderOpenAI:    "OpenAI",
+	database.AIProviderAnthropic: "Anthropic",
+	database.AIProviderOllama:    "Ollama",
+}
+
+// aiProvider resolves the provider and model for a guild's settings. Guild
+// settings win, then the config's defaults; a guild without its own key uses
+// the config's key.
+func (b *Bot) aiProvider(settings *database.AISettings) (AIProvider, string, error) {
+	apis := b.Config().APIs
+	provider := settings.Provider
+	if provider == "" {
+		provider = apis.AIProvider
+	}
+	key := settings.APIKey
+
+	switch provider {
+	case database.AIProviderAnthropic:
+		if key == "" {
+			key = apis.AnthropicKey
+		}
+		if key == "" {
+			return nil, "", errAINotConfigured
+		}
+		return &anthropicProvider{baseURL: apis.AnthropicBaseURL, apiKey: key}, aiModel(settings.Model, apis.AnthropicModel), nil
+
+	case database.AIProviderOllama:
+		if apis.OllamaURL == "" {
+			return nil, "", errAINotConfigured
+		}
+		return &ollamaProvider{baseURL: apis.OllamaURL}, aiModel(settings.Model, apis.OllamaModel), nil
+
+	default:
+		if key == "" {
+			key = apis.OpenAIKey
+		}
+		if key == "" {
+			return nil, "", errAINotConfigured
+		}
+		return &openAIProvider{baseURL: apis.OpenAIBaseURL, apiKey: key}, aiModel(settings.Model, apis.OpenAIModel), nil
+	}
+}
+
+// aiUsageDay returns today's day key for AI usage, which resets at midnight UTC
+func aiUsageDay() string {
+	return time.Now().UTC().Format(activityDayFormat)
+}
+
+// aiBudgetRefusal returns a friendly message when the guild or member has used
+// up today's AI token budget, "" when they can go ahead
+func (b *Bot) aiBudgetRefusal(settings *database.AISettings, userID string) string {
+	day := aiUsageDay()
+	reset := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Unix()
+
+	if settings.DailyTokenBudget > 0 {
+		usage, err := b.DB.GetAIUsageTotal(settings.GuildID, day)
+		if err == nil && usage.Tokens >= settings.DailyTokenBudget {
+			return fmt.Sprintf("This server has used up today's AI budget, so I need a little rest~ It resets <t:%d:R>.", reset)
+		}
+	}
+	if settings.UserDailyTokenBudget > 0 {
+		usage, err := b.DB.GetAIUserUsageTotal(settings.GuildID, userID, day)
+		if err == nil && usage.Tokens >= settings.UserDailyTokenBudget {
+			return fmt.Sprintf("You've used up your AI budget for today! It resets <t:%d:R>, talk to you then~", reset)
+		}
+	}
+	return ""
+}
+
+// recordAIUsage counts a request against the guild's and member's daily usage
+func (b *Bot) recordAIUsage(guildID, userID, feature string, tokens int) {
+	day := aiUsageDay()
+	b.DB.AddAIUsage(guildID, day, feature, tokens)
+	b.DB.AddAIUserUsage(guildID, userID, day, tokens)
+}
+
+// openAIKey returns the OpenAI key for features that only OpenAI offers, such as
+// moderation and speech: the guild's own key when its AI provider is OpenAI,
+// otherwise the bot's
+func (b *Bot) openAIKey(guildID string) string {
+	if settings, err := b.DB.GetAISettings(guildID); err == nil &&
+		settings.Provider == database.AIProviderOpenAI && settings.APIKey != "" {
+		return settings.APIKey
+	}
+	return b.Config().APIs.OpenAIKey
+}
+
+// estimateTokens approximates a text's token count for usage budgets
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+func aiModel(guildModel, defaultModel string) string {
+	if guildModel != "" {
+		return guildModel
+	}
+	return defaultModel
+}
+
+// postAIJSON sends a JSON request and decodes the JSON reply into out. Error
+// replies are decoded too, so providers can surface the API's own message.
+func postAIJSON(ctx context.Context, url string, headers map[string]string, body, out interface{}) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := aiClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact AI service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse AI response (HTTP %d)", resp.StatusCode)
+	}
+	return nil
+}
+
+// openAIProvider talks to the OpenAI chat completions API or a compatible endpoint
+type openAIProvider struct {
+	baseURL string
+	apiKey  string
+}
+
+func (p *openAIProvider) Name() string { return "OpenAI" }
+
+func (p *openAIProvider) Complete(ctx context.Context, req AIRequest) (string, error) {
+	body := map[string]interface{}{
+		"model":       req.Model,
+		"messages":    req.messages(true),
+		"max_tokens":  req.MaxTokens,
+		"temperature": req.Temperature,
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err := postAIJSON(ctx, strings.TrimSuffix(p.baseURL, "/")+"/chat/completions",
+		map[string]string{"Authorization": "Bearer " + p.apiKey}, body, &response)
+	if err != nil {
+		return "", err
+	}
+
+	if response.Error.Message != "" {
+		return "", errors.New(response.Error.Message)
+	}
+	if len(response.Choices) == 0 {
+		return "", errors.New("no response from AI")
+	}
+	return response.Choices[0].Message.Content, nil
+}
+
+// anthropicProvider talks to the Anthropic Messages API
+type anthropicProvider struct {
+	baseURL string
+	apiKey  string
+}
+
+func (p *anthropicProvider) Name() string { return "Anthropic" }
+
+func (p *anthropicProvider) Complete(ctx context.Context, req AIRequest) (string, error) {
+	body := map[string]interface{}{
+		"model":       req.Model,
+		"system":      req.System,
+		"messages":    req.messages(false),
+		"max_tokens":  req.MaxTokens,
+		"temperature": req.Temperature,
+	}
+
+	var response struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err := postAIJSON(ctx, strings.TrimSuffix(p.baseURL, "/")+"/v1/messages", map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": "2023-06-01",
+	}, body, &response)
+	if err != nil {
+		return "", err
+	}
+
+	if response.Error.Message != "" {
+		return "", errors.New(response.Error.Message)
+	}
+	var text strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", errors.New("no response from AI")
+	}
+	return text.String(), nil
+}
+
+// ollamaProvider talks to a local Ollama server
+type ollamaProvider struct {
+	baseURL string
+}
+
+func (p *ollamaProvider) Name() string { return "Ollama" }
+
+func (p *ollamaProvider) Complete(ctx context.Context, req AIRequest) (string, error) {
+	body := map[string]interface{}{
+		"model":    req.Model,
+		"messages": req.messages(true),
+		"stream":   false,
+		"options": map[string]interface{}{
+			"num_predict": req.MaxTokens,
+			"temperature": req.Temperature,
+		},
+	}
+
+	var response struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Error string `json:"error"`
+	}
+	err := postAIJSON(ctx, strings.TrimSuffix(p.baseURL, "/")+"/api/chat", nil, body, &response)
+	if err != nil {
+		return "", err
+	}
+
+	if response.Error != "" {
+		return "", errors.New(response.Error)
+	}
+	if response.Message.Content == "" {
+		return "", errors.New("no response from AI")
+	}
+	return response.Message.Content, nil
+}
@@ -0,0 +1,243 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// aiModerationModel is the OpenAI moderation model messages are scored with
+const aiModerationModel = "omni-moderation-latest"
+
+// aiModerationMinLength skips messages too short to say anything harmful
+const aiModerationMinLength = 4
+
+// aiModerationSlots caps concurrent moderation requests; messages arriving while
+// every slot is busy go unscored rather than queueing up behind a slow API
+var aiModerationSlots = make(chan struct{}, 8)
+
+// aiModerationScore is one category's score for a message
+type aiModerationScore struct {
+	Category string
+	Score    float64
+}
+
+// checkAIModeration scores a guild message in the background and acts on it
+// when a score crosses one of the guild's thresholds
+func (b *Bot) checkAIModeration(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" || len(strings.TrimSpace(m.Content)) < aiModerationMinLength {
+		return
+	}
+
+	cfg, err := b.DB.GetAIModerationConfig(m.GuildID)
+	if err != nil || !cfg.Enabled {
+		return
+	}
+
+	// Staff are trusted not to need scoring
+	if isModerator(s, m.GuildID, m.Author.ID) {
+		return
+	}
+
+	apiKey := b.openAIKey(m.GuildID)
+	if apiKey == "" {
+		return
+	}
+
+	select {
+	case aiModerationSlots <- struct{}{}:
+	default:
+		return
+	}
+
+	go func() {
+		defer func() { <-aiModerationSlots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		scores, err := b.scoreModeration(ctx, apiKey, m.Content)
+		if err != nil {
+			log.Printf("[AIMod] Failed to score message %s in %s: %v", m.ID, m.GuildID, err)
+			return
+		}
+		b.handleAIModeration(s, m, cfg, filterModerationScores(scores, cfg.Categories))
+	}()
+}
+
+// scoreModeration asks the moderation model to score text, returning every
+// category's score, highest first
+func (b *Bot) scoreModeration(ctx context.Context, apiKey, text string) ([]aiModerationScore, error) {
+	body := map[string]interface{}{
+		"model": aiModerationModel,
+		"input": text,
+	}
+
+	var response struct {
+		Results []struct {
+			CategoryScores map[string]float64 `json:"category_scores"`
+		} `json:"results"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err := postAIJSON(ctx, strings.TrimSuffix(b.Config().APIs.OpenAIBaseURL, "/")+"/moderations",
+		map[string]string{"Authorization": "Bearer " + apiKey}, body, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error.Message != "" {
+		return nil, errors.New(response.Error.Message)
+	}
+	if len(response.Results) == 0 {
+		return nil, errors.New("no moderation result")
+	}
+
+	var scores []aiModerationScore
+	for category, score := range response.Results[0].CategoryScores {
+		scores = append(scores, aiModerationScore{Category: category, Score: score})
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	return scores, nil
+}
+
+// filterModerationScores keeps the scores in the guild's chosen categories,
+// matching subcategories like "hate/threatening" under "hate"
+func filterModerationScores(scores []aiModerationScore, categories string) []aiModerationScore {
+	if categories == "" {
+		return scores
+	}
+	enabled := strings.Split(categories, ",")
+
+	var kept []aiModerationScore
+	for _, sc := range scores {
+		group, _, _ := strings.Cut(sc.Category, "/")
+		if slices.Contains(enabled, group) {
+			kept = append(kept, sc)
+		}
+	}
+	return kept
+}
+
+// aiModerationAction returns the strongest action a score triggers, "" for none
+func aiModerationAction(cfg *database.AIModerationConfig, score float64) string {
+	switch {
+	case cfg.WarnThreshold > 0 && score >= cfg.WarnThreshold:
+		return "warn"
+	case cfg.DeleteThreshold > 0 && score >= cfg.DeleteThreshold:
+		return "delete"
+	case cfg.FlagThreshold > 0 && score >= cfg.FlagThreshold:
+		return "flag"
+	}
+	return ""
+}
+
+// handleAIModeration applies the action a message's top score calls for,
+// records it and reports it to staff
+func (b *Bot) handleAIModeration(s *discordgo.Session, m *discordgo.MessageCreate, cfg *database.AIModerationConfig, scores []aiModerationScore) {
+	if len(scores) == 0 {
+		return
+	}
+	top := scores[0]
+	action := aiModerationAction(cfg, top.Score)
+	if action == "" {
+		return
+	}
+
+	outcome := "Flagged for review"
+	if action == "delete" || action == "warn" {
+		if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
+			outcome = "Failed to delete message (check permissions)"
+		} else {
+			outcome = "Message deleted"
+		}
+	}
+
+	if action == "warn" {
+		reason := fmt.Sprintf("AI moderation: %s (%.0f%%)", top.Category, top.Score*100)
+		botID := s.State.User.ID
+		if err := b.DB.AddWarning(m.GuildID, m.Author.ID, botID, reason); err == nil {
+			b.DB.AddModAction(m.GuildID, botID, m.Author.ID, "warn", &reason, time.Now().UnixMilli())
+			outcome += ", user warned"
+		}
+
+		if channel, err := s.UserChannelCreate(m.Author.ID); err == nil {
+			guildName := m.GuildID
+			if guild, err := s.State.Guild(m.GuildID); err == nil {
+				guildName = guild.Name
+			}
+			s.ChannelMessageSend(channel.ID, fmt.Sprintf(
+				"You have been warned in **%s**: your message was removed by automatic moderation (%s).",
+				guildName, top.Category))
+		}
+	}
+
+	b.DB.AddAIModerationLog(&database.AIModerationLog{
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		MessageID: m.ID,
+		UserID:    m.Author.ID,
+		Content:   truncate(m.Content, 1000),
+		Category:  top.Category,
+		Score:     top.Score,
+		Action:    action,
+	})
+
+	var scoreLines []string
+	for _, sc := range scores[:min(3, len(scores))] {
+		scoreLines = append(scoreLines, fmt.Sprintf("%s: **%.0f%%**", sc.Category, sc.Score*100))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "AI Moderation Flag",
+		Description: fmt.Sprintf("Message by %s in <#%s>", m.Author.Mention(), m.ChannelID),
+		Color:       0xFFA500,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Scores", Value: strings.Join(scoreLines, "\n"), Inline: true},
+			{Name: "Outcome", Value: outcome, Inline: true},
+			{Name: "Content", Value: truncate(m.Content, 1024), Inline: false},
+		},
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: avatarURL(m.Author),
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("User ID: %s • Message ID: %s", m.Author.ID, m.ID),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if action == "flag" {
+		embed.Description += fmt.Sprintf(" ([jump](https://discord.com/channels/%s/%s/%s))", m.GuildID, m.ChannelID, m.ID)
+	} else {
+		embed.Color = 0xFF0000
+	}
+
+	if cfg.FlagChannelID == "" {
+		b.sendAutomodLog(s, m.GuildID, embed)
+		return
+	}
+	s.ChannelMessageSendEmbed(cfg.FlagChannelID, embed)
+}
@@ -189,11 +189,6 @@ func (b *Bot) HandleSpamAction(s *discordgo.Session, m *discordgo.MessageCreate,
 
 // LogSpamAction logs a spam action to the mod log
 func (b *Bot) LogSpamAction(s *discordgo.Session, guildID string, user *discordgo.User, action string, pressure float64) {
-	settings, err := b.DB.GetGuildSettings(guildID)
-	if err != nil || settings.ModLogChannel == nil {
-		return
-	}
-
 	embed := &discordgo.MessageEmbed{
 		Title:       "Anti-Spam Action",
 		Description: fmt.Sprintf("User %s was %s for spam", user.Mention(), action),
@@ -205,5 +200,5 @@ func (b *Bot) LogSpamAction(s *discordgo.Session, guildID string, user *discordg
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
-	s.ChannelMessageSendEmbed(*settings.ModLogChannel, embed)
+	b.sendAutomodLog(s, guildID, embed)
 }
@@ -0,0 +1,218 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+var attachmentClient = &http.Client{Timeout: 30 * time.Second}
+
+const (
+	// Total size of archived attachments re-uploaded with one log or snipe,
+	// kept under the upload limit of servers without boosts
+	maxReuploadBytes = 8 << 20
+
+	// Most files Discord accepts on one message
+	maxReuploadFiles = 10
+)
+
+// archivePath returns the local cache path for a message attachment
+func (b *Bot) archivePath(guildID, messageID string, index int, filename string) string {
+	name := fmt.Sprintf("%s_%d_%s", messageID, index, filepath.Base(filename))
+	return filepath.Join(b.Config().Archive.Dir, guildID, name)
+}
+
+// cacheAttachments downloads a message's attachments if the guild archives them,
+// so they are still available after the message (and its CDN copy) is deleted
+func (b *Bot) cacheAttachments(m *discordgo.MessageCreate) {
+	if m.GuildID == "" || len(m.Attachments) == 0 {
+		return
+	}
+
+	cfg := b.getLogConfig(m.GuildID, m.ChannelID)
+	if cfg == nil || !cfg.MessageDelete || !cfg.ArchiveAttachments {
+		return
+	}
+
+	maxSize := b.Config().Archive.MaxFileMB * 1024 * 1024
+	for idx, att := range m.Attachments {
+		if att.Size > maxSize {
+			continue
+		}
+		go b.downloadAttachment(att.URL, b.archivePath(m.GuildID, m.ID, idx, att.Filename))
+	}
+}
+
+// downloadAttachment saves an attachment to the archive directory
+func (b *Bot) downloadAttachment(url, path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	resp, err := attachmentClient.Get(url)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	maxSize := int64(b.Config().Archive.MaxFileMB) * 1024 * 1024
+	if _, err := io.Copy(f, io.LimitReader(resp.Body, maxSize)); err != nil {
+		f.Close()
+		os.Remove(path)
+	}
+}
+
+// deletedAttachments builds attachment metadata for a deleted message, noting any archived copies
+func (b *Bot) deletedAttachments(guildID string, msg *discordgo.Message) []database.DeletedAttachment {
+	var attachments []database.DeletedAttachment
+	for idx, att := range msg.Attachments {
+		da := database.DeletedAttachment{
+			Filename:    att.Filename,
+			URL:         att.URL,
+			ContentType: att.ContentType,
+			Size:        att.Size,
+		}
+		if guildID != "" {
+			path := b.archivePath(guildID, msg.ID, idx, att.Filename)
+			if _, err := os.Stat(path); err == nil {
+				da.ArchivePath = path
+			}
+		}
+		attachments = append(attachments, da)
+	}
+	return attachments
+}
+
+// deletedStickers returns the names of the stickers on a deleted message
+func deletedStickers(msg *discordgo.Message) []string {
+	var names []string
+	for _, sticker := range msg.StickerItems {
+		names = append(names, sticker.Name)
+	}
+	return names
+}
+
+// deletedEmbeds summarizes each embed on a deleted message in one line
+func deletedEmbeds(msg *discordgo.Message) []string {
+	var summaries []string
+	for _, embed := range msg.Embeds {
+		var parts []string
+		if embed.Author != nil && embed.Author.Name != "" {
+			parts = append(parts, embed.Author.Name)
+		}
+		if embed.Title != "" {
+			parts = append(parts, embed.Title)
+		}
+		if embed.Description != "" {
+			parts = append(parts, truncate(strings.Join(strings.Fields(embed.Description), " "), 150))
+		}
+		// Link previews of images and GIFs have nothing but the link
+		if len(parts) == 0 || embed.Type == discordgo.EmbedTypeImage || embed.Type == discordgo.EmbedTypeGifv {
+			if embed.URL != "" {
+				parts = append(parts, embed.URL)
+			}
+		}
+		if len(parts) > 0 {
+			summaries = append(summaries, strings.Join(parts, " — "))
+		}
+	}
+	return summaries
+}
+
+// archivedFiles opens archived attachments for re-upload, up to maxBytes in total.
+// Archived attachments that don't fit are returned as skipped so callers can link them instead.
+// Callers must close the returned files.
+func archivedFiles(attachments []database.DeletedAttachment, maxBytes int64) (files []*discordgo.File, handles []*os.File, skipped []database.DeletedAttachment) {
+	var total int64
+	for _, att := range attachments {
+		if att.ArchivePath == "" {
+			continue
+		}
+		info, err := os.Stat(att.ArchivePath)
+		if err != nil {
+			continue
+		}
+		if len(files) >= maxReuploadFiles || total+info.Size() > maxBytes {
+			skipped = append(skipped, att)
+			continue
+		}
+		f, err := os.Open(att.ArchivePath)
+		if err != nil {
+			continue
+		}
+		total += info.Size()
+		handles = append(handles, f)
+		files = append(files, &discordgo.File{
+			Name:        att.Filename,
+			ContentType: att.ContentType,
+			Reader:      f,
+		})
+	}
+	return files, handles, skipped
+}
+
+// firstImageFile returns the name of the first image among files being uploaded, empty if there is none
+func firstImageFile(files []*discordgo.File) string {
+	for _, f := range files {
+		if strings.HasPrefix(f.ContentType, "image/") {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// cleanAttachmentArchive removes cached attachments older than the retention limit
+func (b *Bot) cleanAttachmentArchive() {
+	cutoff := time.Now().Add(-time.Duration(b.Config().Archive.RetentionHours) * time.Hour)
+
+	filepath.Walk(b.Config().Archive.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		// Attachments of scheduled messages are kept until they're sent
+		if info.IsDir() && path == b.scheduledAttachmentDir() {
+			return filepath.SkipDir
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if info.ModTime().Before(cutoff) {
+			os.Remove(path)
+		}
+		return nil
+	})
+}
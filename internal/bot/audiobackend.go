@@ -0,0 +1,255 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jonas747/dca"
+)
+
+// errVoiceLost is returned by a stream whose voice connection went away mid-track
+var errVoiceLost = errors.New("voice connection lost")
+
+// AudioBackend is what music players send audio through. The default runs
+// yt-dlp and ffmpeg in-process; a Lavalink node can take that load off big
+// deployments instead.
+type AudioBackend interface {
+	// Join connects to a voice channel, moving any existing connection in the guild
+	Join(s *discordgo.Session, guildID, channelID string) error
+
+	// Leave disconnects from voice in a guild
+	Leave(s *discordgo.Session, guildID string) error
+
+	// Ready reports whether the guild's voice connection can carry audio
+	Ready(guildID string) bool
+
+	// Play starts streaming a track to the guild's voice connection
+	Play(guildID string, track *Track, opts StreamOptions) (AudioStream, error)
+}
+
+// StreamOptions controls how a backend plays a track
+type StreamOptions struct {
+	StartAt   int // Seconds into the track
+	Volume    int // 0-100
+	Filters   AudioFilters
+	Normalize bool // Apply loudness normalization
+}
+
+// AudioStream is a track a backend is playing
+type AudioStream interface {
+	// Done receives once when the stream ends: nil when the track finished or
+	// was stopped, errVoiceLost when the connection dropped
+	Done() <-chan error
+
+	// Stop ends the stream early; Done still receives
+	Stop()
+
+	// SetPaused pauses or resumes the stream
+	SetPaused(paused bool)
+
+	// Position returns how far into the track playback is
+	Position() time.Duration
+}
+
+// ffmpegBackend streams through discordgo voice connections, encoding with ffmpeg
+type ffmpegBackend struct {
+	youtubeAPIKey       string
+	soundcloudAuthToken string
+	mu                  sync.RWMutex
+	conns               map[string]*discordgo.VoiceConnection
+}
+
+// NewFFmpegBackend creates the in-process yt-dlp/ffmpeg audio backend
+func NewFFmpegBackend(youtubeAPIKey, soundcloudAuthToken string) AudioBackend {
+	return &ffmpegBackend{
+		youtubeAPIKey:       youtubeAPIKey,
+		soundcloudAuthToken: soundcloudAuthToken,
+		conns:               make(map[string]*discordgo.VoiceConnection),
+	}
+}
+
+func (b *ffmpegBackend) Join(s *discordgo.Session, guildID, channelID string) error {
+	vc, err := s.ChannelVoiceJoin(guildID, channelID, false, true)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.conns[guildID] = vc
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *ffmpegBackend) Leave(s *discordgo.Session, guildID string) error {
+	b.mu.Lock()
+	vc := b.conns[guildID]
+	delete(b.conns, guildID)
+	b.mu.Unlock()
+
+	if vc == nil {
+		return nil
+	}
+	return vc.Disconnect()
+}
+
+func (b *ffmpegBackend) Ready(guildID string) bool {
+	b.mu.RLock()
+	vc := b.conns[guildID]
+	b.mu.RUnlock()
+
+	if vc == nil {
+		return false
+	}
+	vc.RLock()
+	defer vc.RUnlock()
+	return vc.Ready
+}
+
+func (b *ffmpegBackend) Play(guildID string, track *Track, opts StreamOptions) (AudioStream, error) {
+	b.mu.RLock()
+	vc := b.conns[guildID]
+	b.mu.RUnlock()
+	if vc == nil {
+		return nil, errors.New("not connected to voice channel")
+	}
+
+	options := dca.StdEncodeOptions
+	options.RawOutput = true
+	options.Bitrate = 128
+	options.Application = "audio"
+	options.Volume = opts.Volume
+
+	// ffmpeg seeks in the filtered output, which runs at a different speed when
+	// the filters retune or time-stretch the track
+	filterChain, rate := opts.Filters.Chain()
+	if opts.Normalize {
+		// Normalize before the effects so they don't skew the measurement
+		filterChain = strings.TrimSuffix(loudnormFilter+","+filterChain, ",")
+	}
+	options.AudioFilter = filterChain
+	options.StartTime = int(float64(opts.StartAt) / rate)
+
+	stream := &ffmpegStream{
+		startOffset: opts.StartAt,
+		rate:        rate,
+		done:        make(chan error, 1),
+	}
+
+	if track.IsLocal {
+		encodeSession, err := dca.EncodeFile(track.URL, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode local file: %w", err)
+		}
+		stream.encoding = encodeSession
+	} else if track.IsLive {
+		// ffmpeg reads radio streams and HLS manifests itself and keeps up with
+		// the live edge better than yt-dlp piping the download through
+		encodeSession, err := dca.EncodeFile(track.URL, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode live stream: %w", err)
+		}
+		stream.encoding = encodeSession
+	} else {
+		// Handle online URLs with yt-dlp
+		args := []string{
+			"--format", "bestaudio",
+			"--output", "-",
+			"--no-playlist",
+		}
+		args = append(args, ytdlpAuthArgs(b.youtubeAPIKey, b.soundcloudAuthToken)...)
+		args = append(args, track.URL)
+
+		cmd := exec.Command("yt-dlp", args...)
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start yt-dlp: %w", err)
+		}
+
+		encodeSession, err := dca.EncodeMem(stdout, options)
+		if err != nil {
+			cmd.Process.Kill()
+			return nil, fmt.Errorf("failed to encode audio: %w", err)
+		}
+		stream.cmd = cmd
+		stream.encoding = encodeSession
+	}
+
+	streamDone := make(chan error)
+	stream.streaming = dca.NewStream(stream.encoding, vc, streamDone)
+	go stream.wait(streamDone)
+	return stream, nil
+}
+
+// ffmpegStream is a track being encoded by ffmpeg and sent over a discordgo voice connection
+type ffmpegStream struct {
+	cmd         *exec.Cmd // yt-dlp feeding the encoder, nil for local files and live streams
+	encoding    *dca.EncodeSession
+	streaming   *dca.StreamingSession
+	startOffset int     // Seconds into the track the stream started at
+	rate        float64 // Playback speed relative to the track
+	done        chan error
+}
+
+func (st *ffmpegStream) wait(streamDone chan error) {
+	err := <-streamDone
+	st.encoding.Cleanup()
+	if st.cmd != nil {
+		// yt-dlp is still writing if the track was cut short; nothing reads it anymore
+		st.cmd.Process.Kill()
+		st.cmd.Wait()
+	}
+
+	switch {
+	case err == nil || err == io.EOF:
+		st.done <- nil
+	case errors.Is(err, dca.ErrVoiceConnClosed):
+		st.done <- errVoiceLost
+	default:
+		st.done <- fmt.Errorf("streaming error: %w", err)
+	}
+}
+
+func (st *ffmpegStream) Done() <-chan error {
+	return st.done
+}
+
+func (st *ffmpegStream) Stop() {
+	// Ending the encoder makes the stream report done. A paused stream isn't
+	// reading, so wake it up to notice.
+	st.encoding.Cleanup()
+	st.streaming.SetPaused(false)
+}
+
+func (st *ffmpegStream) SetPaused(paused bool) {
+	st.streaming.SetPaused(paused)
+}
+
+func (st *ffmpegStream) Position() time.Duration {
+	played := st.streaming.PlaybackPosition()
+	return time.Duration(st.startOffset)*time.Second + time.Duration(float64(played)*st.rate)
+}
@@ -0,0 +1,197 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// loudnormFilter evens out loudness between tracks, aiming for the EBU R128
+// streaming target so quiet local files and loud rips end up close together
+const loudnormFilter = "loudnorm=I=-16:TP=-1.5:LRA=11"
+
+// AudioFilters is a guild's active ffmpeg filter settings. It's stored in
+// music_settings as a comma separated spec, e.g. "bassboost,speed=1.25,eq=3:0:-2".
+type AudioFilters struct {
+	BassBoost bool
+	Nightcore bool
+	Vaporwave bool
+	EightD    bool
+	Speed     float64 // atempo factor, 0 or 1 for normal speed
+	EQ        [3]int  // bass, mid and treble gain in dB
+}
+
+// audioFilterPresets are the toggleable presets offered by /filter preset
+var audioFilterPresets = []struct {
+	Name  string
+	Label string
+}{
+	{"bassboost", "Bass Boost"},
+	{"nightcore", "Nightcore"},
+	{"vaporwave", "Vaporwave"},
+	{"8d", "8D Audio"},
+}
+
+// ParseAudioFilters reads a stored filter spec, ignoring anything it doesn't recognize
+func ParseAudioFilters(spec string) AudioFilters {
+	var f AudioFilters
+	for _, part := range strings.Split(spec, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "bassboost":
+			f.BassBoost = true
+		case "nightcore":
+			f.Nightcore = true
+		case "vaporwave":
+			f.Vaporwave = true
+		case "8d":
+			f.EightD = true
+		case "speed":
+			if speed, err := strconv.ParseFloat(value, 64); err == nil && speed >= 0.5 && speed <= 2 {
+				f.Speed = speed
+			}
+		case "eq":
+			for idx, gain := range strings.SplitN(value, ":", 3) {
+				if g, err := strconv.Atoi(gain); err == nil {
+					f.EQ[idx] = max(min(g, 10), -10)
+				}
+			}
+		}
+	}
+	return f
+}
+
+// String returns the spec stored in the database
+func (f AudioFilters) String() string {
+	var parts []string
+	if f.BassBoost {
+		parts = append(parts, "bassboost")
+	}
+	if f.Nightcore {
+		parts = append(parts, "nightcore")
+	}
+	if f.Vaporwave {
+		parts = append(parts, "vaporwave")
+	}
+	if f.EightD {
+		parts = append(parts, "8d")
+	}
+	if f.Speed != 0 && f.Speed != 1 {
+		parts = append(parts, "speed="+strconv.FormatFloat(f.Speed, 'f', -1, 64))
+	}
+	if f.EQ != [3]int{} {
+		parts = append(parts, fmt.Sprintf("eq=%d:%d:%d", f.EQ[0], f.EQ[1], f.EQ[2]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// SetPreset toggles a preset by name. Nightcore and vaporwave both retune the
+// track, so turning one on turns the other off.
+func (f *AudioFilters) SetPreset(name string, on bool) bool {
+	switch name {
+	case "bassboost":
+		f.BassBoost = on
+	case "nightcore":
+		f.Nightcore = on
+		if on {
+			f.Vaporwave = false
+		}
+	case "vaporwave":
+		f.Vaporwave = on
+		if on {
+			f.Nightcore = false
+		}
+	case "8d":
+		f.EightD = on
+	default:
+		return false
+	}
+	return true
+}
+
+// Preset reports whether a preset is on
+func (f AudioFilters) Preset(name string) bool {
+	switch name {
+	case "bassboost":
+		return f.BassBoost
+	case "nightcore":
+		return f.Nightcore
+	case "vaporwave":
+		return f.Vaporwave
+	case "8d":
+		return f.EightD
+	}
+	return false
+}
+
+// Chain returns the ffmpeg -af filter chain and how much faster than normal the
+// result plays, which seeking and position tracking need to account for
+func (f AudioFilters) Chain() (string, float64) {
+	var chain []string
+	rate := 1.0
+
+	eqBands := [3]int{100, 1000, 8000}
+	for idx, gain := range f.EQ {
+		if gain != 0 {
+			chain = append(chain, fmt.Sprintf("equalizer=f=%d:t=o:w=2:g=%d", eqBands[idx], gain))
+		}
+	}
+	if f.BassBoost {
+		chain = append(chain, "bass=g=8")
+	}
+
+	// Resample first so the retune is relative to a known rate whatever the source was
+	switch {
+	case f.Nightcore:
+		chain = append(chain, "aresample=48000,asetrate=48000*1.25,aresample=48000")
+		rate *= 1.25
+	case f.Vaporwave:
+		chain = append(chain, "aresample=48000,asetrate=48000*0.8,aresample=48000")
+		rate *= 0.8
+	}
+	if f.Speed != 0 && f.Speed != 1 {
+		chain = append(chain, "atempo="+strconv.FormatFloat(f.Speed, 'f', -1, 64))
+		rate *= f.Speed
+	}
+
+	if f.EightD {
+		chain = append(chain, "apulsator=hz=0.08")
+	}
+	return strings.Join(chain, ","), rate
+}
+
+// Describe lists the active filters for display
+func (f AudioFilters) Describe() string {
+	var active []string
+	for _, p := range audioFilterPresets {
+		if f.Preset(p.Name) {
+			active = append(active, p.Label)
+		}
+	}
+	if f.Speed != 0 && f.Speed != 1 {
+		active = append(active, fmt.Sprintf("Speed %sx", strconv.FormatFloat(f.Speed, 'f', -1, 64)))
+	}
+	if f.EQ != [3]int{} {
+		active = append(active, fmt.Sprintf("EQ (bass %+d, mid %+d, treble %+d dB)", f.EQ[0], f.EQ[1], f.EQ[2]))
+	}
+	if len(active) == 0 {
+		return "None"
+	}
+	return strings.Join(active, ", ")
+}
@@ -0,0 +1,101 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// autoplayHistorySize is how many recently played tracks autoplay avoids repeating
+const autoplayHistorySize = 50
+
+// autoplayCandidates is how many related tracks are considered per lookup
+const autoplayCandidates = 15
+
+var youtubeIDRegex = regexp.MustCompile(`(?:[?&]v=|youtu\.be/|/shorts/)([A-Za-z0-9_-]{11})`)
+
+// autoplayTrack finds a track related to the last one played that hasn't been
+// heard recently, or returns nil if autoplay is off or nothing suitable turns up
+func (b *Bot) autoplayTrack(guildID string, last *Track) *Track {
+	settings, err := b.DB.GetMusicSettings(guildID)
+	if err != nil || !settings.Autoplay || last.IsLocal {
+		return nil
+	}
+
+	recent := make(map[string]bool)
+	if history, err := b.DB.GetMusicHistory(guildID, autoplayHistorySize); err == nil {
+		for _, h := range history {
+			recent[normalizeTrackTitle(h.Title)] = true
+		}
+	}
+	recent[normalizeTrackTitle(last.Title)] = true
+
+	// YouTube mixes give the best related picks; anything else falls back to a search
+	source := fmt.Sprintf("ytsearch%d:%s", autoplayCandidates, cleanLyricsQuery(last.Title))
+	lastID := ""
+	if m := youtubeIDRegex.FindStringSubmatch(last.PageURL); m != nil {
+		lastID = m[1]
+		source = "https://www.youtube.com/watch?v=" + lastID + "&list=RD" + lastID
+	}
+
+	entries, err := ExtractFlatEntries(source, autoplayCandidates)
+	if err != nil {
+		log.Printf("[Music] Autoplay lookup failed in guild %s: %v", guildID, err)
+		return nil
+	}
+
+	for _, entry := range entries {
+		if entry.URL == "" || recent[normalizeTrackTitle(entry.Title)] {
+			continue
+		}
+		if m := youtubeIDRegex.FindStringSubmatch(entry.URL); m != nil && m[1] == lastID {
+			continue
+		}
+
+		info, err := ExtractInfo(entry.URL, b.Config().APIs.YouTubeAPIKey, b.Config().APIs.SoundCloudAuthToken)
+		// A live stream would never end and hand back to autoplay
+		if err != nil || info.IsLive {
+			continue
+		}
+
+		botID := ""
+		if b.Session.State.User != nil {
+			botID = b.Session.State.User.ID
+		}
+		b.DB.AddToMusicHistory(guildID, botID, info.Title, info.URL)
+
+		return &Track{
+			Title:     info.Title,
+			URL:       info.URL,
+			Duration:  info.Duration,
+			Thumbnail: info.Thumbnail,
+			Requester: "Autoplay",
+			Source:    info.Source,
+			PageURL:   info.WebpageURL,
+		}
+	}
+	return nil
+}
+
+// normalizeTrackTitle reduces a title to what identifies the song, so re-uploads and
+// "official video" variants count as the same track
+func normalizeTrackTitle(title string) string {
+	return strings.ToLower(cleanLyricsQuery(title))
+}
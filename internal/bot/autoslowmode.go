@@ -0,0 +1,227 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// autoSlowmodeWindow is how far back message rates are measured
+	autoSlowmodeWindow = time.Minute
+	// Minimum time between changes, so a change has a chance to take effect
+	autoSlowmodeRaiseHold = 30 * time.Second
+	autoSlowmodeLowerHold = 2 * time.Minute
+	// autoSlowmodeManualPause is how long /slowmode takes over from automatic slowmode
+	autoSlowmodeManualPause = time.Hour
+)
+
+// slowmodeSteps are the slowmode values automatic slowmode moves between, in seconds
+var slowmodeSteps = []int{2, 5, 10, 15, 30, 60, 120, 300, 600, 900, 1800, 3600, 7200, 21600}
+
+// slowmodeActivity is the recent messages in one channel with automatic slowmode
+type slowmodeActivity struct {
+	guildID  string
+	messages []time.Time
+	changed  time.Time // When the bot last changed the slowmode
+}
+
+// SlowmodeTracker counts messages in channels with automatic slowmode
+type SlowmodeTracker struct {
+	mu       sync.Mutex
+	channels map[string]*slowmodeActivity
+}
+
+// NewSlowmodeTracker creates a new slowmode tracker
+func NewSlowmodeTracker() *SlowmodeTracker {
+	return &SlowmodeTracker{channels: make(map[string]*slowmodeActivity)}
+}
+
+// Global slowmode tracker
+var slowmodeTracker = NewSlowmodeTracker()
+
+// slowmodeSample is a channel's message rate at one check
+type slowmodeSample struct {
+	guildID   string
+	channelID string
+	rate      int // Messages in the last autoSlowmodeWindow
+	changed   time.Time
+}
+
+func (t *SlowmodeTracker) record(guildID, channelID string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a, ok := t.channels[channelID]
+	if !ok {
+		a = &slowmodeActivity{guildID: guildID}
+		t.channels[channelID] = a
+	}
+	a.messages = append(pruneBefore(a.messages, at.Add(-autoSlowmodeWindow)), at)
+}
+
+// samples drops messages older than the window and returns every tracked channel's rate
+func (t *SlowmodeTracker) samples(now time.Time) []slowmodeSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	samples := make([]slowmodeSample, 0, len(t.channels))
+	for channelID, a := range t.channels {
+		a.messages = pruneBefore(a.messages, now.Add(-autoSlowmodeWindow))
+		samples = append(samples, slowmodeSample{
+			guildID:   a.guildID,
+			channelID: channelID,
+			rate:      len(a.messages),
+			changed:   a.changed,
+		})
+	}
+	return samples
+}
+
+func (t *SlowmodeTracker) markChanged(channelID string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if a, ok := t.channels[channelID]; ok {
+		a.changed = at
+	}
+}
+
+func (t *SlowmodeTracker) forget(channelID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.channels, channelID)
+}
+
+// pruneBefore drops the leading times before cutoff from a sorted slice
+func pruneBefore(times []time.Time, cutoff time.Time) []time.Time {
+	n := 0
+	for n < len(times) && times[n].Before(cutoff) {
+		n++
+	}
+	return times[n:]
+}
+
+// checkAutoSlowmode counts a message towards its channel's rate when the channel has automatic slowmode
+func (b *Bot) checkAutoSlowmode(m *discordgo.MessageCreate) {
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot {
+		return
+	}
+	cfg, err := b.DB.GetAutoSlowmodeChannel(m.GuildID, m.ChannelID)
+	if err != nil || cfg == nil {
+		return
+	}
+	slowmodeTracker.record(m.GuildID, m.ChannelID, time.Now())
+}
+
+// adjustAutoSlowmode raises or lowers slowmode in channels whose message rate
+// has crossed their threshold
+func (b *Bot) adjustAutoSlowmode() {
+	now := time.Now()
+	for _, sample := range slowmodeTracker.samples(now) {
+		cfg, err := b.DB.GetAutoSlowmodeChannel(sample.guildID, sample.channelID)
+		if err != nil {
+			continue
+		}
+		if cfg == nil {
+			slowmodeTracker.forget(sample.channelID)
+			continue
+		}
+		if cfg.Paused() {
+			continue
+		}
+
+		channel, err := b.Session.State.Channel(sample.channelID)
+		if err != nil {
+			continue
+		}
+		current := channel.RateLimitPerUser
+
+		next, ok := nextSlowmode(cfg, current, sample.rate, now.Sub(sample.changed))
+		if !ok {
+			// Forget quiet channels that are back to normal until their next message
+			if sample.rate == 0 && current <= cfg.BaseSeconds {
+				slowmodeTracker.forget(sample.channelID)
+			}
+			continue
+		}
+
+		_, err = b.Session.ChannelEdit(sample.channelID, &discordgo.ChannelEdit{RateLimitPerUser: &next},
+			discordgo.WithAuditLogReason(fmt.Sprintf("Automatic slowmode: %d messages in the last minute", sample.rate)))
+		if err != nil {
+			continue
+		}
+		slowmodeTracker.markChanged(sample.channelID, now)
+		b.logAutoSlowmode(cfg, current, next, sample.rate)
+	}
+}
+
+// nextSlowmode picks the slowmode for a channel given its message rate. It moves
+// one step at a time: up while the rate is at or above the threshold, and back
+// down towards the base once it falls below half of it.
+func nextSlowmode(cfg *database.AutoSlowmodeChannel, current, rate int, sinceChange time.Duration) (int, bool) {
+	switch {
+	case rate >= cfg.Threshold && current < cfg.MaxSeconds && sinceChange >= autoSlowmodeRaiseHold:
+		for _, step := range slowmodeSteps {
+			if step > current {
+				return min(step, cfg.MaxSeconds), true
+			}
+		}
+		return cfg.MaxSeconds, true
+	case rate < cfg.Threshold/2 && current > cfg.BaseSeconds && sinceChange >= autoSlowmodeLowerHold:
+		next := cfg.BaseSeconds
+		for _, step := range slowmodeSteps {
+			if step < current {
+				next = max(step, cfg.BaseSeconds)
+			}
+		}
+		return next, true
+	}
+	return current, false
+}
+
+// logAutoSlowmode tells the mod log that automatic slowmode changed a channel
+func (b *Bot) logAutoSlowmode(cfg *database.AutoSlowmodeChannel, from, to, rate int) {
+	title, color := "Slowmode Raised", 0xE67E22
+	if to < from {
+		title, color = "Slowmode Lowered", 0x57F287
+	}
+	b.sendAutomodLog(b.Session, cfg.GuildID, &discordgo.MessageEmbed{
+		Title: title,
+		Description: fmt.Sprintf("Slowmode in <#%s> is now **%s** (was %s) with %d messages in the last minute.",
+			cfg.ChannelID, slowmodeLabel(to), slowmodeLabel(from), rate),
+		Color:  color,
+		Footer: &discordgo.MessageEmbedFooter{Text: "Use /autoslowmode pause to take manual control"},
+	})
+}
+
+// slowmodeLabel formats a slowmode in seconds, like 30s, 5m or 2h
+func slowmodeLabel(seconds int) string {
+	switch {
+	case seconds <= 0:
+		return "off"
+	case seconds%3600 == 0:
+		return fmt.Sprintf("%dh", seconds/3600)
+	case seconds%60 == 0:
+		return fmt.Sprintf("%dm", seconds/60)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
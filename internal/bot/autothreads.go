@@ -0,0 +1,85 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultAutoThreadName is used when a channel has no name template of its own
+const defaultAutoThreadName = "{nickname}: {content}"
+
+// checkAutoThread opens a thread on a new message in an auto-thread channel
+func (b *Bot) checkAutoThread(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" || (m.Type != discordgo.MessageTypeDefault && m.Type != discordgo.MessageTypeReply) {
+		return
+	}
+
+	cfg, err := b.DB.GetAutoThreadChannel(m.GuildID, m.ChannelID)
+	if err != nil || cfg == nil {
+		return
+	}
+	if cfg.Mode == database.AutoThreadAttachments && len(m.Attachments) == 0 {
+		return
+	}
+
+	s.MessageThreadStartComplex(m.ChannelID, m.ID, &discordgo.ThreadStart{
+		Name:                autoThreadName(s, cfg, m),
+		AutoArchiveDuration: cfg.ArchiveMinutes,
+	})
+}
+
+// autoThreadName fills in a channel's thread name template for a message. On top of
+// the usual template placeholders it takes {content}, the first line of the message
+// (or the first attachment's file name), and {date}.
+func autoThreadName(s *discordgo.Session, cfg *database.AutoThreadChannel, m *discordgo.MessageCreate) string {
+	template := cfg.NameTemplate
+	if template == "" {
+		template = defaultAutoThreadName
+	}
+
+	// The message goes in last so placeholders typed into it are left alone
+	name := renderTemplate(template, &templateContext{
+		Session:   s,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		User:      m.Author,
+		Member:    m.Member,
+	})
+	name = strings.ReplaceAll(name, "{date}", time.Now().UTC().Format("2006-01-02"))
+
+	content, _, _ := strings.Cut(strings.TrimSpace(m.ContentWithMentionsReplaced()), "\n")
+	if content == "" && len(m.Attachments) > 0 {
+		content = strings.TrimSuffix(m.Attachments[0].Filename, path.Ext(m.Attachments[0].Filename))
+	}
+	if content == "" {
+		content = "Discussion"
+	}
+	name = strings.ReplaceAll(name, "{content}", truncate(content, 80))
+
+	// Thread names are a single line of at most 100 characters
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "Discussion"
+	}
+	return truncate(name, 100)
+}
@@ -0,0 +1,147 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultBirthdayMessage = "🎂 Happy birthday {user}!"
+
+// checkBirthdays announces birthdays that have started in each member's timezone
+func (b *Bot) checkBirthdays() {
+	now := time.Now().UTC()
+
+	// Every timezone is within a day of UTC, so only look at the surrounding months
+	var months []int
+	for _, t := range []time.Time{now.AddDate(0, 0, -1), now, now.AddDate(0, 0, 1)} {
+		month := int(t.Month())
+		if len(months) == 0 || months[len(months)-1] != month {
+			months = append(months, month)
+		}
+	}
+
+	birthdays, err := b.DB.GetBirthdaysInMonths(months)
+	if err != nil {
+		return
+	}
+
+	configs := make(map[string]*database.BirthdayConfig)
+	for _, bd := range birthdays {
+		local := now
+		if bd.Timezone != "" {
+			if loc, err := time.LoadLocation(bd.Timezone); err == nil {
+				local = now.In(loc)
+			}
+		}
+		if bd.LastAnnounced >= local.Year() || !isBirthdayOn(bd, local) {
+			continue
+		}
+
+		cfg, ok := configs[bd.GuildID]
+		if !ok {
+			if cfg, err = b.DB.GetBirthdayConfig(bd.GuildID); err != nil {
+				continue
+			}
+			configs[bd.GuildID] = cfg
+		}
+
+		b.announceBirthday(cfg, bd, local)
+		b.DB.MarkBirthdayAnnounced(bd.GuildID, bd.UserID, local.Year())
+	}
+}
+
+// isBirthdayOn reports whether the birthday falls on the given date;
+// February 29 birthdays are celebrated on February 28 in common years
+func isBirthdayOn(bd database.Birthday, date time.Time) bool {
+	if int(date.Month()) == bd.Month && date.Day() == bd.Day {
+		return true
+	}
+	if bd.Month == 2 && bd.Day == 29 && date.Month() == time.February && date.Day() == 28 {
+		return time.Date(date.Year(), time.February, 29, 0, 0, 0, 0, time.UTC).Day() != 29
+	}
+	return false
+}
+
+// announceBirthday posts the announcement and grants the birthday role for a day
+func (b *Bot) announceBirthday(cfg *database.BirthdayConfig, bd database.Birthday, local time.Time) {
+	member, err := b.Session.GuildMember(bd.GuildID, bd.UserID)
+	if err != nil {
+		return
+	}
+
+	if cfg.ChannelID != "" {
+		message := cfg.Message
+		if message == "" {
+			message = defaultBirthdayMessage
+		}
+		age := ""
+		if bd.Year > 0 {
+			age = strconv.Itoa(local.Year() - bd.Year)
+		}
+		message = strings.NewReplacer(
+			"{user}", "<@"+bd.UserID+">",
+			"{username}", member.User.Username,
+			"{age}", age,
+		).Replace(message)
+
+		b.Session.ChannelMessageSendComplex(cfg.ChannelID, &discordgo.MessageSend{
+			Content: message,
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{bd.UserID},
+			},
+		})
+	}
+
+	if cfg.RoleID != "" {
+		if err := b.Session.GuildMemberRoleAdd(bd.GuildID, bd.UserID, cfg.RoleID); err == nil {
+			target := bd.UserID + ":" + cfg.RoleID
+			b.DB.DeleteScheduledEventByTarget(bd.GuildID, eventTempRole, target)
+			b.DB.AddScheduledEvent(bd.GuildID, eventTempRole, target, time.Now().Add(24*time.Hour).UnixMilli())
+		}
+	}
+}
+
+// nextBirthday returns the next occurrence of a birthday on or after the given day
+func nextBirthday(bd database.Birthday, from time.Time) time.Time {
+	today := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	for year := today.Year(); ; year++ {
+		date := time.Date(year, time.Month(bd.Month), bd.Day, 0, 0, 0, 0, time.UTC)
+		if date.Day() != bd.Day {
+			// February 29 in a common year
+			date = time.Date(year, time.February, 28, 0, 0, 0, 0, time.UTC)
+		}
+		if !date.Before(today) {
+			return date
+		}
+	}
+}
+
+// formatBirthday formats a birthday as "March 14" or "March 14, 1998"
+func formatBirthday(bd database.Birthday) string {
+	s := fmt.Sprintf("%s %d", time.Month(bd.Month), bd.Day)
+	if bd.Year > 0 {
+		s += fmt.Sprintf(", %d", bd.Year)
+	}
+	return s
+}
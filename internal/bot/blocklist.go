@@ -0,0 +1,163 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// blocklistAlertCooldown is the minimum gap between alerts about the same user in a guild,
+// so a user the bot fails to remove doesn't trigger an alert on every message
+const blocklistAlertCooldown = 10 * time.Minute
+
+// blocklistAlerts holds guildID:userID -> time of the last alert sent
+var blocklistAlerts sync.Map
+
+// CheckBlocklistJoin acts on a blocklisted user joining an opted-in guild.
+// Returns true if the member was removed.
+func (b *Bot) CheckBlocklistJoin(s *discordgo.Session, m *discordgo.GuildMemberAdd) bool {
+	cfg, err := b.DB.GetBlocklistConfig(m.GuildID)
+	if err != nil || !cfg.Enabled {
+		return false
+	}
+
+	entry, err := b.DB.GetBlocklistEntry(m.User.ID)
+	if err != nil || entry == nil {
+		return false
+	}
+
+	removed := b.applyBlocklistAction(s, m.GuildID, m.User.ID, cfg, entry)
+	b.notifyBlocklistHit(s, m.User, cfg, entry, "Joined the server", removed)
+	return removed
+}
+
+// CheckBlocklistMessage acts on a message from a blocklisted user in an opted-in guild.
+// The message is only deleted when the configured action removed the user.
+// Returns true if the message was handled and should not be processed further.
+func (b *Bot) CheckBlocklistMessage(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if m.GuildID == "" {
+		return false
+	}
+
+	cfg, err := b.DB.GetBlocklistConfig(m.GuildID)
+	if err != nil || !cfg.Enabled {
+		return false
+	}
+
+	entry, err := b.DB.GetBlocklistEntry(m.Author.ID)
+	if err != nil || entry == nil {
+		return false
+	}
+
+	removed := b.applyBlocklistAction(s, m.GuildID, m.Author.ID, cfg, entry)
+	if removed {
+		s.ChannelMessageDelete(m.ChannelID, m.ID)
+	}
+	b.notifyBlocklistHit(s, m.Author, cfg, entry, fmt.Sprintf("Sent a message in <#%s>", m.ChannelID), removed)
+	return removed
+}
+
+// applyBlocklistAction applies the guild's configured action to a blocklisted user
+func (b *Bot) applyBlocklistAction(s *discordgo.Session, guildID, userID string, cfg *database.BlocklistConfig, entry *database.BotBan) bool {
+	reason := fmt.Sprintf("Global blocklist (%s): %s", entry.Category, entry.Reason)
+	botID := s.State.User.ID
+
+	switch cfg.Action {
+	case "kick":
+		if err := s.GuildMemberDeleteWithReason(guildID, userID, truncate(reason, 512)); err != nil {
+			return false
+		}
+		b.DB.AddModAction(guildID, botID, userID, "kick", &reason, time.Now().UnixMilli())
+		return true
+
+	case "ban":
+		if err := s.GuildBanCreateWithReason(guildID, userID, truncate(reason, 512), 1); err != nil {
+			return false
+		}
+		b.DB.AddModAction(guildID, botID, userID, "ban", &reason, time.Now().UnixMilli())
+		return true
+	}
+
+	return false
+}
+
+// cleanBlocklistAlerts forgets alert times whose cooldown has run out
+func cleanBlocklistAlerts() {
+	now := time.Now()
+	blocklistAlerts.Range(func(key, value interface{}) bool {
+		if now.Sub(value.(time.Time)) >= blocklistAlertCooldown {
+			blocklistAlerts.Delete(key)
+		}
+		return true
+	})
+}
+
+// notifyBlocklistHit alerts guild staff that a blocklisted user was seen
+func (b *Bot) notifyBlocklistHit(s *discordgo.Session, user *discordgo.User, cfg *database.BlocklistConfig, entry *database.BotBan, trigger string, removed bool) {
+	// Removals always alert; repeat sightings of a user still in the guild are rate limited
+	key := cfg.GuildID + ":" + user.ID
+	if last, ok := blocklistAlerts.Load(key); ok && !removed && time.Since(last.(time.Time)) < blocklistAlertCooldown {
+		return
+	}
+	blocklistAlerts.Store(key, time.Now())
+
+	outcome := "Logged only"
+	switch {
+	case removed && cfg.Action == "kick":
+		outcome = "User kicked"
+	case removed && cfg.Action == "ban":
+		outcome = "User banned"
+	case cfg.Action != "log":
+		outcome = fmt.Sprintf("Failed to %s (check permissions)", cfg.Action)
+	}
+
+	alertText := ""
+	if cfg.AlertRoleID != "" {
+		alertText = fmt.Sprintf("<@&%s> ", cfg.AlertRoleID)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Blocklisted User Detected",
+		Description: fmt.Sprintf("%s (`%s`) is on the global blocklist", user.Mention(), user.ID),
+		Color:       0xFF0000,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Trigger", Value: trigger, Inline: true},
+			{Name: "Category", Value: entry.Category, Inline: true},
+			{Name: "Outcome", Value: outcome, Inline: true},
+			{Name: "Reason", Value: truncate(entry.Reason, 1024), Inline: false},
+		},
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: avatarURL(user),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if cfg.LogChannelID == "" {
+		b.sendAutomodAlert(s, cfg.GuildID, alertText, embed)
+		return
+	}
+
+	s.ChannelMessageSendComplex(cfg.LogChannelID, &discordgo.MessageSend{
+		Content: alertText,
+		Embed:   embed,
+	})
+}
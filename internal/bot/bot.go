@@ -17,10 +17,16 @@
 package bot
 
 import (
+	"fmt"
 	"log"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/blubskye/himiko/internal/cache"
 	"github.com/blubskye/himiko/internal/config"
 	"github.com/blubskye/himiko/internal/database"
 	"github.com/blubskye/himiko/internal/updater"
@@ -33,13 +39,24 @@ var BotStartTime = time.Now()
 
 type Bot struct {
 	Session      *discordgo.Session
-	Config       *config.Config
 	DB           *database.DB
 	Commands     *CommandHandler
 	MusicManager *MusicManager
+	Spotify      *SpotifyClient
 	Debug        *DebugLogger
 	WebServer    *webserver.Server
+	Cache        *cache.Cache // Optional Redis layer, nil when disabled
 	stopChan     chan struct{}
+
+	config atomic.Pointer[config.Config] // Swapped whole by /owner reload, never copied into
+
+	updateMu        sync.Mutex // Held while checking for or installing an update
+	updateNotified  string     // Last version owners were told about
+	updateCheckOnce sync.Once
+
+	presenceMu    sync.Mutex
+	presenceIndex int // Next entry in the status rotation
+	presenceOnce  sync.Once
 }
 
 func New(cfg *config.Config, db *database.DB) (*Bot, error) {
@@ -51,15 +68,41 @@ func New(cfg *config.Config, db *database.DB) (*Bot, error) {
 	// Set intents
 	session.Identify.Intents = discordgo.IntentsAll
 
+	// Cache recent messages so deletes can be sniped and logged
+	session.State.MaxMessageCount = 100
+
+	// Offload audio to a Lavalink node if configured, otherwise encode in-process
+	var backend AudioBackend
+	if strings.EqualFold(cfg.Music.Backend, "lavalink") && cfg.Music.LavalinkHost != "" {
+		backend = NewLavalinkBackend(session, cfg.Music.LavalinkHost, cfg.Music.LavalinkPassword, cfg.Music.LavalinkSecure)
+		log.Printf("Using Lavalink node at %s for music", cfg.Music.LavalinkHost)
+	} else {
+		backend = NewFFmpegBackend(cfg.APIs.YouTubeAPIKey, cfg.APIs.SoundCloudAuthToken)
+	}
+
 	b := &Bot{
 		Session:      session,
-		Config:       cfg,
 		DB:           db,
-		MusicManager: NewMusicManager(cfg.APIs.YouTubeAPIKey, cfg.APIs.SoundCloudAuthToken),
+		MusicManager: NewMusicManager(backend),
+		Spotify:      NewSpotifyClient(cfg.APIs.SpotifyID, cfg.APIs.SpotifySecret),
 		Debug:        NewDebugLogger(cfg.Features.DebugMode),
 		WebServer:    webserver.New(cfg, db, session),
 		stopChan:     make(chan struct{}),
 	}
+	b.config.Store(cfg)
+
+	// Share settings and cooldowns with other processes through Redis if configured
+	if cfg.Redis.Enabled {
+		c, err := cache.New(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB, cfg.Redis.Prefix)
+		if err != nil {
+			log.Printf("Warning: Redis unavailable, continuing without it: %v", err)
+		} else {
+			log.Printf("Using Redis at %s for shared settings and cooldowns", cfg.Redis.Addr)
+			b.Cache = c
+			db.SetCache(c)
+			sharedCooldowns = c
+		}
+	}
 
 	if cfg.Features.DebugMode {
 		log.Println("[DEBUG] Debug mode enabled - verbose logging and stack traces active")
@@ -68,17 +111,78 @@ func New(cfg *config.Config, db *database.DB) (*Bot, error) {
 	// Initialize command handler
 	b.Commands = NewCommandHandler(b)
 
+	// Let dashboard edits re-render posted role panels
+	b.WebServer.SetRolePanelPublisher(b.PublishRolePanel)
+
+	// Count played songs towards achievements
+	b.MusicManager.OnTrackStart = func(guildID string, track *Track) {
+		if track.RequesterID != "" && !track.IsSpeech {
+			b.trackAchievementStat(guildID, track.RequesterID, statSongs, 1, "")
+		}
+	}
+
+	// Leave idle voice channels unless the guild has 24/7 mode on
+	b.MusicManager.OnQueueEnd = b.onMusicQueueEnd
+	b.MusicManager.OnAutoplay = b.autoplayTrack
+
+	// Carry the guild's audio filters and normalization over to new players
+	b.MusicManager.OnPlayerCreated = func(player *MusicPlayer) {
+		if settings, err := b.DB.GetMusicSettings(player.guildID); err == nil {
+			player.SetFilters(ParseAudioFilters(settings.AudioFilters))
+			player.SetNormalize(settings.Normalize)
+			player.SetSponsorBlock(sponsorBlockFor(settings))
+		}
+	}
+
 	// Register event handlers
 	session.AddHandler(b.onReady)
 	session.AddHandler(b.onInteractionCreate)
 	session.AddHandler(b.onMessageCreate)
+	session.AddHandler(b.onMessageUpdate)
 	session.AddHandler(b.onMessageDelete)
 	session.AddHandler(b.onGuildMemberAdd)
+	session.AddHandler(b.onGuildMemberRemove)
+	session.AddHandler(b.onGuildMemberUpdate)
+	session.AddHandler(b.onGuildBanAdd)
+	session.AddHandler(b.onGuildBanRemove)
+	session.AddHandler(b.onVoiceStateUpdate)
+	session.AddHandler(b.onMessageReactionAdd)
+	session.AddHandler(b.onMessageReactionRemove)
+	session.AddHandler(b.onInviteCreate)
+	session.AddHandler(b.onInviteDelete)
+	session.AddHandler(b.onGuildScheduledEventCreate)
+	session.AddHandler(b.onGuildScheduledEventUpdate)
+	session.AddHandler(b.onGuildScheduledEventDelete)
+	session.AddHandler(b.onGuildScheduledEventUserAdd)
+	session.AddHandler(b.onGuildScheduledEventUserRemove)
+	session.AddHandler(b.onGuildCreate)
+	session.AddHandler(b.onGuildMembersChunk)
 
 	return b, nil
 }
 
+// Config returns the current configuration. A reload swaps in a new one, so
+// look it up again rather than holding on to it across long-running work.
+func (b *Bot) Config() *config.Config {
+	return b.config.Load()
+}
+
+// saveConfig writes an edited config to disk and swaps it in. Edit a Clone of the
+// current config, never the one Config returns, since other goroutines read it.
+func (b *Bot) saveConfig(cfg *config.Config) error {
+	if err := cfg.Save("config.json"); err != nil {
+		return err
+	}
+	b.config.Store(cfg)
+	return nil
+}
+
 func (b *Bot) Start() error {
+	// Refuse to start rather than leave commands Discord won't accept unregistered
+	if _, _, _, err := b.Commands.buildSlashCommands(); err != nil {
+		return fmt.Errorf("invalid slash commands: %w", err)
+	}
+
 	if err := b.Session.Open(); err != nil {
 		return err
 	}
@@ -93,9 +197,10 @@ func (b *Bot) Start() error {
 
 	// Start background tasks
 	go b.runScheduledTasks()
+	go b.scanMusicLibraries()
 
 	// Start web server if enabled
-	if b.Config.WebServer.Enabled {
+	if b.Config().WebServer.Enabled {
 		if err := b.WebServer.Start(); err != nil {
 			log.Printf("Warning: Failed to start web server: %v", err)
 		}
@@ -104,9 +209,23 @@ func (b *Bot) Start() error {
 	return nil
 }
 
+// SyncCommands pushes the slash command list to Discord over REST, without
+// connecting to the gateway
+func (b *Bot) SyncCommands() error {
+	user, err := b.Session.User("@me")
+	if err != nil {
+		return err
+	}
+	b.Session.State.User = user
+	return b.Commands.RegisterCommands()
+}
+
 func (b *Bot) Stop() {
 	close(b.stopChan)
 
+	// Save activity counted since the last flush
+	b.flushActivity()
+
 	// Stop web server if running
 	if b.WebServer.IsRunning() {
 		b.WebServer.Stop()
@@ -116,20 +235,36 @@ func (b *Bot) Stop() {
 	// b.Commands.UnregisterCommands()
 
 	b.Session.Close()
+
+	b.Cache.Close()
 }
 
 func (b *Bot) onReady(s *discordgo.Session, r *discordgo.Ready) {
 	log.Printf("Logged in as %s#%s", r.User.Username, r.User.Discriminator)
 	log.Printf("Connected to %d guilds", len(r.Guilds))
 
-	// Set status
-	s.UpdateGameStatus(0, "/help | Prefix: /")
+	// Set status, Discord forgets it whenever the gateway reconnects
+	b.applyPresence()
+	b.presenceOnce.Do(func() {
+		go b.rotatePresence()
+	})
+
+	// Snapshot invite uses for join attribution and catch up on reaction roles
+	go func() {
+		for _, guild := range r.Guilds {
+			b.snapshotInvites(s, guild.ID)
+			b.syncReactionRoles(s, guild.ID)
+		}
+	}()
 
-	// Check for updates in background
-	go b.CheckAndNotifyUpdate()
+	// Check for updates once per process, Ready also fires after reconnects
+	b.updateCheckOnce.Do(func() {
+		go b.announceCompletedUpdate()
+		go b.CheckAndNotifyUpdate()
 
-	// Start periodic update checker
-	b.StartPeriodicUpdateCheck()
+		// Start periodic update checker
+		b.StartPeriodicUpdateCheck()
+	})
 }
 
 func (b *Bot) onInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -137,6 +272,8 @@ func (b *Bot) onInteractionCreate(s *discordgo.Session, i *discordgo.Interaction
 		b.Commands.HandleSlashCommand(s, i)
 	} else if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
 		b.Commands.HandleAutocomplete(s, i)
+	} else if i.Type == discordgo.InteractionMessageComponent || i.Type == discordgo.InteractionModalSubmit {
+		b.Commands.HandleComponent(s, i)
 	}
 }
 
@@ -151,18 +288,44 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 		b.WebServer.IncrementMessage()
 	}
 
+	// Check global blocklist
+	if b.CheckBlocklistMessage(s, m) {
+		return
+	}
+
+	// Cache attachments for deleted message logs
+	b.cacheAttachments(m)
+
 	// Track user activity and aliases
 	b.trackUserActivity(m)
 
+	// Award XP and currency for chatting
+	b.awardMessageXP(m)
+	b.awardChatCurrency(m)
+
 	// Check anti-spam
 	b.CheckSpam(s, m)
 
+	// Count the message towards automatic slowmode
+	b.checkAutoSlowmode(m)
+
+	// Score the message with AI moderation
+	b.checkAIModeration(s, m)
+
 	// Check for AFK mentions
 	b.checkAFKMentions(s, m)
 
 	// Check if user is AFK and remove status
 	b.checkAFKReturn(s, m)
 
+	// Counting and word-chain channels
+	if b.handleGameMessage(s, m) {
+		return
+	}
+
+	// Open threads in auto-thread channels
+	b.checkAutoThread(s, m)
+
 	// Check keyword notifications
 	b.checkKeywordNotifications(s, m)
 
@@ -175,7 +338,7 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 
 func (b *Bot) handlePrefixCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Get prefix for this guild
-	prefix := b.Config.Prefix
+	prefix := b.Config().Prefix
 	if m.GuildID != "" {
 		settings, err := b.DB.GetGuildSettings(m.GuildID)
 		if err == nil && settings.Prefix != "" {
@@ -198,16 +361,18 @@ func (b *Bot) handlePrefixCommand(s *discordgo.Session, m *discordgo.MessageCrea
 	cmdName := strings.ToLower(parts[0])
 	args := parts[1:]
 
-	// Find the command
+	// Find the command, falling back to the guild's custom commands
 	cmd, exists := b.Commands.commands[cmdName]
 	if !exists {
+		if m.GuildID != "" {
+			b.runCustomCommand(s, m, cmdName, args)
+		}
 		return
 	}
 
 	// Check if this command is available via prefix
 	// Commands in prefix-only categories or explicitly marked PrefixOnly can be used
-	isPrefixAvailable := cmd.PrefixOnly || prefixOnlyCategories[cmd.Category]
-	if !isPrefixAvailable {
+	if !cmd.isPrefix() {
 		// Slash-only commands should use /command
 		return
 	}
@@ -228,9 +393,6 @@ func (b *Bot) handlePrefixCommand(s *discordgo.Session, m *discordgo.MessageCrea
 }
 
 func (b *Bot) executePrefixCommand(s *discordgo.Session, m *discordgo.MessageCreate, cmd *Command, args []string, prefix string) {
-	// Log command usage
-	b.DB.LogCommand(m.GuildID, m.ChannelID, m.Author.ID, cmd.Name, strings.Join(args, " "))
-
 	// Create a prefix command context
 	ctx := &PrefixContext{
 		Session:   s,
@@ -246,13 +408,93 @@ func (b *Bot) executePrefixCommand(s *discordgo.Session, m *discordgo.MessageCre
 
 	// Execute the prefix handler if available
 	if cmd.PrefixHandler != nil {
-		cmd.PrefixHandler(ctx)
+		start := time.Now()
+		failed := runCommand(cmd.Name, func() { cmd.PrefixHandler(ctx) })
+		if failed {
+			s.ChannelMessageSend(m.ChannelID, "Something went wrong running this command.")
+		}
+		b.DB.LogCommand(m.GuildID, m.ChannelID, m.Author.ID, cmd.Name, strings.Join(args, " "), time.Since(start), failed)
 	} else if cmd.Handler != nil {
 		// No prefix handler - show usage help
 		s.ChannelMessageSend(m.ChannelID, "Usage: `"+prefix+cmd.Name+" <args>`\nThis command is prefix-only. Use `/help command:"+cmd.Name+"` for details.")
 	}
 }
 
+// runCustomCommand answers a prefix invocation of one of the guild's custom commands
+func (b *Bot) runCustomCommand(s *discordgo.Session, m *discordgo.MessageCreate, name string, args []string) {
+	cc, err := b.DB.GetCustomCommand(m.GuildID, name)
+	if err != nil || cc == nil {
+		return
+	}
+	if !customCommandAllowed(s, cc, m) {
+		return
+	}
+	cooldown := time.Duration(cc.CooldownSeconds) * time.Second
+	if customCommandCooldowns.take(m.GuildID+":"+cc.Name+":"+m.Author.ID, cooldown) > 0 {
+		s.MessageReactionAdd(m.ChannelID, m.ID, "⏳")
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		b.DB.LogCommand(m.GuildID, m.ChannelID, m.Author.ID, cc.Name, strings.Join(args, " "), time.Since(start), false)
+	}()
+	b.DB.IncrementCommandUse(m.GuildID, cc.Name)
+
+	tctx := &templateContext{
+		Session:   s,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		User:      m.Author,
+		Member:    m.Member,
+		Args:      args,
+	}
+	response := renderTemplate(cc.Response, tctx)
+	var embeds []*discordgo.MessageEmbed
+	if embed := renderResponseEmbed(cc.Embed, tctx); embed != nil {
+		embeds = append(embeds, embed)
+	}
+	if strings.TrimSpace(response) == "" && len(embeds) == 0 {
+		return
+	}
+	s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content: response,
+		Embeds:  embeds,
+		// Arguments end up in the response, so don't let them ping roles or everyone
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
+		},
+	})
+
+	if cc.DeleteInvocation {
+		s.ChannelMessageDelete(m.ChannelID, m.ID)
+	}
+}
+
+// customCommandAllowed checks a custom command's role and channel restrictions.
+// A thread counts as its parent channel.
+func customCommandAllowed(s *discordgo.Session, cc *database.CustomCommand, m *discordgo.MessageCreate) bool {
+	if len(cc.AllowedChannels) > 0 && !slices.Contains(cc.AllowedChannels, m.ChannelID) {
+		channel, err := s.State.Channel(m.ChannelID)
+		if err != nil || !channel.IsThread() || !slices.Contains(cc.AllowedChannels, channel.ParentID) {
+			return false
+		}
+	}
+
+	if len(cc.AllowedRoles) > 0 {
+		if m.Member == nil {
+			return false
+		}
+		for _, roleID := range m.Member.Roles {
+			if hasRole(cc.AllowedRoles, roleID) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
 func (b *Bot) trackUserActivity(m *discordgo.MessageCreate) {
 	// Track username alias
 	b.DB.RecordAlias(m.Author.ID, m.Author.Username, "username")
@@ -265,132 +507,77 @@ func (b *Bot) trackUserActivity(m *discordgo.MessageCreate) {
 	// Update user activity
 	if m.GuildID != "" {
 		b.DB.UpdateUserActivity(m.GuildID, m.Author.ID, true)
+		b.countMessageActivity(m)
+		b.trackAchievementStat(m.GuildID, m.Author.ID, statMessages, 1, m.ChannelID)
 	}
 }
 
-func (b *Bot) onMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
-	// Log deleted message for snipe command
-	if m.BeforeDelete != nil && m.BeforeDelete.Content != "" {
-		guildID := ""
-		if m.GuildID != "" {
-			guildID = m.GuildID
-		}
-		b.DB.LogDeletedMessage(guildID, m.ChannelID, m.BeforeDelete.Author.ID, m.BeforeDelete.Content)
+func (b *Bot) onMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Embed unfurls also fire updates; only content changes are edits
+	if m.BeforeUpdate == nil || m.Author == nil || m.Author.Bot || m.BeforeUpdate.Content == m.Content {
+		return
 	}
-}
 
-func (b *Bot) onGuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
-	// Track username alias on join
-	b.DB.RecordAlias(m.User.ID, m.User.Username, "username")
+	// Store edit history for editsnipe
+	b.DB.LogEditedMessage(m.GuildID, m.ChannelID, m.ID, m.Author.ID, m.BeforeUpdate.Content, m.Content)
 
-	// Track initial activity (join, not message)
-	b.DB.UpdateUserActivity(m.GuildID, m.User.ID, false)
-
-	// Check anti-raid
-	b.CheckRaid(s, m)
+	// Send to the guild's log channel
+	b.logMessageEdit(s, m)
+}
 
-	// Send welcome message if configured
-	settings, err := b.DB.GetGuildSettings(m.GuildID)
-	if err != nil {
-		return
+func (b *Bot) onMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
+	// Drop reaction role bindings for the message
+	if m.GuildID != "" {
+		b.DB.DeleteReactionRoleMessage(m.ID)
 	}
 
-	if settings.WelcomeChannel != nil && settings.WelcomeMessage != nil {
-		msg := *settings.WelcomeMessage
-		// Replace placeholders
-		msg = replacePlaceholders(msg, m.User, m.GuildID)
-		s.ChannelMessageSend(*settings.WelcomeChannel, msg)
+	if m.BeforeDelete == nil || m.BeforeDelete.Author == nil {
+		return
 	}
 
-	// Send join DM if configured
-	if settings.JoinDMTitle != nil || settings.JoinDMMessage != nil {
-		channel, err := s.UserChannelCreate(m.User.ID)
-		if err != nil {
-			return
-		}
-
-		embed := &discordgo.MessageEmbed{
-			Color: 0xFF69B4,
-		}
+	attachments := b.deletedAttachments(m.GuildID, m.BeforeDelete)
+	stickers := deletedStickers(m.BeforeDelete)
+	embeds := deletedEmbeds(m.BeforeDelete)
 
-		if settings.JoinDMTitle != nil {
-			embed.Title = replacePlaceholders(*settings.JoinDMTitle, m.User, m.GuildID)
-		}
-
-		if settings.JoinDMMessage != nil {
-			embed.Description = replacePlaceholders(*settings.JoinDMMessage, m.User, m.GuildID)
-		}
-
-		s.ChannelMessageSendEmbed(channel.ID, embed)
+	// Log deleted message for snipe command
+	if m.BeforeDelete.Content != "" || len(attachments) > 0 || len(stickers) > 0 || len(embeds) > 0 {
+		b.DB.LogDeletedMessageDetails(m.GuildID, m.ChannelID, m.BeforeDelete.Author.ID, m.BeforeDelete.Content, attachments, stickers, embeds)
 	}
-}
-
-func (b *Bot) checkAFKMentions(s *discordgo.Session, m *discordgo.MessageCreate) {
-	for _, mention := range m.Mentions {
-		afk, err := b.DB.GetAFK(mention.ID)
-		if err != nil || afk == nil {
-			continue
-		}
-
-		msg := mention.Username + " is AFK"
-		if afk.Message != nil {
-			msg += ": " + *afk.Message
-		}
-		msg += " (since <t:" + formatUnixTime(afk.SetAt) + ":R>)"
 
-		s.ChannelMessageSendReply(m.ChannelID, msg, m.Reference())
-	}
+	// Send to the guild's log channel
+	b.logMessageDelete(s, m, attachments)
 }
 
-func (b *Bot) checkAFKReturn(s *discordgo.Session, m *discordgo.MessageCreate) {
-	afk, err := b.DB.GetAFK(m.Author.ID)
-	if err != nil || afk == nil {
-		return
-	}
+func (b *Bot) onGuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	// Track username alias on join
+	b.DB.RecordAlias(m.User.ID, m.User.Username, "username")
 
-	b.DB.RemoveAFK(m.Author.ID)
-	s.ChannelMessageSendReply(m.ChannelID, "Welcome back! I've removed your AFK status.", m.Reference())
-}
+	// Track initial activity (join, not message)
+	b.DB.UpdateUserActivity(m.GuildID, m.User.ID, false)
+	serverActivity.addJoin(m.GuildID, time.Now())
 
-func (b *Bot) checkKeywordNotifications(s *discordgo.Session, m *discordgo.MessageCreate) {
-	notifications, err := b.DB.GetAllKeywordNotifications()
-	if err != nil {
+	// Check global blocklist before anything else greets the member
+	if b.CheckBlocklistJoin(s, m) {
 		return
 	}
 
-	for _, n := range notifications {
-		// Don't notify user of their own messages
-		if n.UserID == m.Author.ID {
-			continue
-		}
+	// Work out which invite was used
+	inviteCode, inviterID := b.trackMemberInvite(s, m)
 
-		// Check guild filter
-		if n.GuildID != nil && *n.GuildID != m.GuildID {
-			continue
-		}
-
-		// Check if keyword is in message
-		if containsWord(m.Content, n.Keyword) {
-			// Send DM notification
-			channel, err := s.UserChannelCreate(n.UserID)
-			if err != nil {
-				continue
-			}
+	// Check anti-raid
+	b.CheckRaid(s, m)
 
-			embed := &discordgo.MessageEmbed{
-				Title:       "Keyword Alert: " + n.Keyword,
-				Description: m.Content,
-				Color:       0x5865F2,
-				Fields: []*discordgo.MessageEmbedField{
-					{Name: "Author", Value: m.Author.Username, Inline: true},
-					{Name: "Channel", Value: "<#" + m.ChannelID + ">", Inline: true},
-				},
-				Timestamp: m.Timestamp.Format(time.RFC3339),
-			}
+	// Give rejoining members their previous roles back
+	b.restoreStickyRoles(s, m)
 
-			s.ChannelMessageSendEmbed(channel.ID, embed)
-		}
+	// Send welcome message if configured
+	settings, err := b.DB.GetGuildSettings(m.GuildID)
+	if err != nil {
+		return
 	}
+
+	b.sendWelcome(s, m.GuildID, m.User, settings, inviterID, inviteCode)
+	b.sendJoinDM(s, m.GuildID, m.User, settings, inviterID, inviteCode)
 }
 
 func (b *Bot) runScheduledTasks() {
@@ -404,6 +591,15 @@ func (b *Bot) runScheduledTasks() {
 	cleanupTicker := time.NewTicker(1 * time.Hour)
 	defer cleanupTicker.Stop()
 
+	libraryTicker := time.NewTicker(libraryRescanInterval)
+	defer libraryTicker.Stop()
+
+	ticketTicker := time.NewTicker(ticketInactivityCheckInterval)
+	defer ticketTicker.Stop()
+
+	statsChannelTicker := time.NewTicker(statsChannelInterval)
+	defer statsChannelTicker.Stop()
+
 	for {
 		select {
 		case <-b.stopChan:
@@ -411,28 +607,43 @@ func (b *Bot) runScheduledTasks() {
 		case <-fastTicker.C:
 			b.CheckLockdownExpiry(b.Session)
 			b.processScheduledEvents()
+			b.adjustAutoSlowmode()
 		case <-ticker.C:
 			b.processScheduledMessages()
 			b.processReminders()
+			b.flushKeywordDigests()
+			b.flushActivity()
+			b.checkBirthdays()
+			b.awardVoiceXP()
+			b.checkXPSeasons()
+			b.postDailyQuotes()
 		case <-cleanupTicker.C:
 			// Clean up old deleted messages (older than 24 hours)
 			b.DB.CleanOldDeletedMessages(24 * time.Hour)
+			b.DB.CleanOldEditedMessages(24 * time.Hour)
+			b.DB.CleanOldRemovedReactions(reactionSnipeRetention)
+			b.DB.CleanOldCommandHistory(time.Duration(b.Config().Features.CommandHistoryDays) * 24 * time.Hour)
+			b.DB.CleanOldVoiceXPDaily()
+			b.DB.CleanOldLyricsCache(lyricsCacheTTL)
+			b.DB.CleanOldAIConversations()
+			cleanTTSFiles()
+			b.DB.CleanOldAIUsage(time.Now().UTC().AddDate(0, 0, -30).Format(activityDayFormat))
+			// Expire cached attachments past the retention limit
+			b.cleanAttachmentArchive()
+			b.cleanOldActivity()
+			cleanBlocklistAlerts()
+		case <-libraryTicker.C:
+			// Probing a large library takes a while, so don't hold up the other tasks
+			go b.scanMusicLibraries()
+		case <-ticketTicker.C:
+			b.checkInactiveTickets()
+		case <-statsChannelTicker.C:
+			// Renames across many guilds take a while, so don't hold up the other tasks
+			go b.updateStatsChannels()
 		}
 	}
 }
 
-func (b *Bot) processScheduledMessages() {
-	messages, err := b.DB.GetPendingScheduledMessages()
-	if err != nil {
-		return
-	}
-
-	for _, msg := range messages {
-		b.Session.ChannelMessageSend(msg.ChannelID, msg.Message)
-		b.DB.MarkScheduledMessageExecuted(msg.ID)
-	}
-}
-
 func (b *Bot) processReminders() {
 	reminders, err := b.DB.GetPendingReminders()
 	if err != nil {
@@ -460,6 +671,14 @@ func (b *Bot) processScheduledEvents() {
 			if err == nil && cfg.SilentRoleID != "" {
 				b.Session.GuildMemberRoleRemove(event.GuildID, event.TargetID, cfg.SilentRoleID)
 			}
+		case eventTempRole:
+			b.removeTempRole(event.GuildID, event.TargetID)
+		case eventGuildEventReminder:
+			b.sendGuildEventReminder(event.GuildID, event.TargetID)
+		case eventPollClose:
+			if id, err := strconv.ParseInt(event.TargetID, 10, 64); err == nil {
+				b.closePoll(id)
+			}
 		}
 		b.DB.DeleteScheduledEvent(event.ID)
 	}
@@ -484,13 +703,19 @@ func (b *Bot) checkMentionResponses(s *discordgo.Session, m *discordgo.MessageCr
 		return
 	}
 
-	// Check each response trigger
-	contentLower := strings.ToLower(m.Content)
+	// Match against what was said alongside the mention
+	text := strings.TrimSpace(strings.NewReplacer(botMention, "", botMentionNick, "").Replace(m.Content))
 	for _, resp := range responses {
-		if strings.Contains(contentLower, resp.TriggerText) {
+		if mentionResponseMatches(&resp, text) {
+			cooldown := time.Duration(resp.CooldownSeconds) * time.Second
+			key := m.GuildID + ":" + strconv.FormatInt(resp.ID, 10) + ":" + m.ChannelID
+			if mentionResponseCooldowns.take(key, cooldown) > 0 {
+				return
+			}
+
 			// Send response
 			embed := &discordgo.MessageEmbed{
-				Description: resp.Response,
+				Description: pickMentionResponse(resp.Response),
 				Color:       0xFF69B4,
 			}
 
@@ -0,0 +1,175 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Past this many changes a single bulk overwrite is cheaper than one request per
+// command, and it doesn't eat into Discord's daily command create limit
+const maxIncrementalCommandChanges = 10
+
+// commandSyncResult counts what a sync changed
+type commandSyncResult struct {
+	created, updated, deleted, unchanged int
+	bulk                                 bool
+}
+
+// syncCommands brings the registered commands in line with want, touching only
+// the commands that differ. guildID is empty for global commands.
+func (ch *CommandHandler) syncCommands(appID, guildID string, want []*discordgo.ApplicationCommand) (*commandSyncResult, error) {
+	s := ch.bot.Session
+
+	existing, err := s.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch registered commands: %w", err)
+	}
+
+	registered := make(map[commandKey]*discordgo.ApplicationCommand, len(existing))
+	for _, cmd := range existing {
+		registered[keyOf(cmd)] = cmd
+	}
+
+	result := &commandSyncResult{}
+	var toCreate, toEdit []*discordgo.ApplicationCommand
+	wanted := make(map[commandKey]bool, len(want))
+	for _, cmd := range want {
+		wanted[keyOf(cmd)] = true
+		current, ok := registered[keyOf(cmd)]
+		switch {
+		case !ok:
+			toCreate = append(toCreate, cmd)
+		case !commandsEqual(current, cmd):
+			toEdit = append(toEdit, cmd)
+		default:
+			result.unchanged++
+		}
+	}
+
+	var toDelete []*discordgo.ApplicationCommand
+	for key, cmd := range registered {
+		if !wanted[key] {
+			toDelete = append(toDelete, cmd)
+		}
+	}
+
+	changes := len(toCreate) + len(toEdit) + len(toDelete)
+	if changes == 0 {
+		return result, nil
+	}
+
+	if changes > maxIncrementalCommandChanges {
+		if _, err := s.ApplicationCommandBulkOverwrite(appID, guildID, want); err != nil {
+			return nil, err
+		}
+		return &commandSyncResult{
+			created:   len(toCreate),
+			updated:   len(toEdit),
+			deleted:   len(toDelete),
+			unchanged: result.unchanged,
+			bulk:      true,
+		}, nil
+	}
+
+	for _, cmd := range toDelete {
+		if err := s.ApplicationCommandDelete(appID, guildID, cmd.ID); err != nil {
+			return result, fmt.Errorf("failed to delete /%s: %w", cmd.Name, err)
+		}
+		result.deleted++
+	}
+	for _, cmd := range toEdit {
+		if _, err := s.ApplicationCommandEdit(appID, guildID, registered[keyOf(cmd)].ID, cmd); err != nil {
+			return result, fmt.Errorf("failed to update /%s: %w", cmd.Name, err)
+		}
+		result.updated++
+	}
+	for _, cmd := range toCreate {
+		if _, err := s.ApplicationCommandCreate(appID, guildID, cmd); err != nil {
+			return result, fmt.Errorf("failed to create /%s: %w", cmd.Name, err)
+		}
+		result.created++
+	}
+	return result, nil
+}
+
+// commandKey identifies a command; a slash command and a context menu entry may share a name
+type commandKey struct {
+	typ  discordgo.ApplicationCommandType
+	name string
+}
+
+func keyOf(cmd *discordgo.ApplicationCommand) commandKey {
+	typ := cmd.Type
+	if typ == 0 {
+		typ = discordgo.ChatApplicationCommand
+	}
+	return commandKey{typ: typ, name: cmd.Name}
+}
+
+// commandsEqual compares the parts of a command we set, ignoring the IDs,
+// versions and defaults Discord fills in
+func commandsEqual(a, b *discordgo.ApplicationCommand) bool {
+	return a.Description == b.Description && optionsEqual(a.Options, b.Options)
+}
+
+func optionsEqual(a, b []*discordgo.ApplicationCommandOption) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !optionEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func optionEqual(a, b *discordgo.ApplicationCommandOption) bool {
+	if a.Type != b.Type || a.Name != b.Name || a.Description != b.Description ||
+		a.Required != b.Required || a.Autocomplete != b.Autocomplete ||
+		a.MaxValue != b.MaxValue || a.MaxLength != b.MaxLength {
+		return false
+	}
+	if !ptrEqual(a.MinValue, b.MinValue) || !ptrEqual(a.MinLength, b.MinLength) {
+		return false
+	}
+	if !slices.Equal(a.ChannelTypes, b.ChannelTypes) {
+		return false
+	}
+	if len(a.Choices) != len(b.Choices) {
+		return false
+	}
+	for i := range a.Choices {
+		// Values come back from Discord as float64 or string, compare them as text
+		if a.Choices[i].Name != b.Choices[i].Name ||
+			fmt.Sprint(a.Choices[i].Value) != fmt.Sprint(b.Choices[i].Value) {
+			return false
+		}
+	}
+	return optionsEqual(a.Options, b.Options)
+}
+
+func ptrEqual[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
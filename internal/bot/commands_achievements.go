@@ -0,0 +1,333 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerAchievementCommands() {
+	statChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Messages sent", Value: statMessages},
+		{Name: "Commands used", Value: statCommands},
+		{Name: "Minutes in voice", Value: statVoiceMinutes},
+		{Name: "Songs requested", Value: statSongs},
+		{Name: "Level", Value: statLevel},
+	}
+
+	// Badges profile
+	ch.Register(&Command{
+		Name:        "badges",
+		Description: "Show earned achievements and progress",
+		Category:    "Achievements",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "Member to show (defaults to yourself)",
+				Required:    false,
+			},
+		},
+		Handler: ch.badgesHandler,
+	})
+
+	// Achievement management (Admin)
+	ch.Register(&Command{
+		Name:        "achievements",
+		Description: "Manage server achievements",
+		Category:    "Achievements",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List all achievements",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Create a custom achievement",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Achievement name",
+						Required:    true,
+						MaxLength:   64,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "stat",
+						Description: "What it's earned for",
+						Required:    true,
+						Choices:     statChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "threshold",
+						Description: "Value of the stat needed to earn it",
+						Required:    true,
+						MinValue:    floatPtr(1),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "Description",
+						Required:    false,
+						MaxLength:   200,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "Badge emoji",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to give when earned",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a custom achievement",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "id",
+						Description: "Custom achievement ID (from /achievements list)",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "settings",
+				Description: "Configure achievements",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Award achievements",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "announce",
+						Description: "Announce unlocks publicly",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Announcement channel (default: where the unlock happened)",
+						Required:    false,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+						},
+					},
+				},
+			},
+		},
+		Handler: ch.achievementsHandler,
+	})
+}
+
+func (ch *CommandHandler) badgesHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := getUserOption(i, "user")
+	if user == nil {
+		user = i.Member.User
+	}
+
+	earned, err := ch.bot.DB.GetUserAchievements(i.GuildID, user.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get achievements.")
+		return
+	}
+	stats, err := ch.bot.DB.GetAchievementStats(i.GuildID, user.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get achievements.")
+		return
+	}
+
+	earnedAt := make(map[string]string, len(earned))
+	for _, e := range earned {
+		earnedAt[e.AchievementID] = formatUnixTime(e.EarnedAt)
+	}
+
+	all := ch.bot.guildAchievements(i.GuildID)
+	var badges, lines []string
+	var next *achievement
+	for idx, a := range all {
+		if ts, ok := earnedAt[a.ID]; ok {
+			badges = append(badges, a.Emoji)
+			lines = append(lines, fmt.Sprintf("%s **%s** — %s (<t:%s:R>)", a.Emoji, a.Name, a.Description, ts))
+			continue
+		}
+		// Closest unearned achievement by progress
+		if next == nil || float64(stats[a.Stat])/float64(a.Threshold) > float64(stats[next.Stat])/float64(next.Threshold) {
+			next = &all[idx]
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("%s's Badges", user.Username),
+		Color:     0xF1C40F,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: avatarURL(user)},
+		Footer:    &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d/%d achievements earned", len(lines), len(all))},
+	}
+	if len(badges) > 0 {
+		embed.Description = strings.Join(badges, " ") + "\n\n" + truncate(strings.Join(lines, "\n"), 3800)
+	} else {
+		embed.Description = "No achievements yet."
+	}
+	if next != nil {
+		percent := float64(stats[next.Stat]) / float64(next.Threshold) * 100
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Next Up",
+			Value: fmt.Sprintf("%s **%s** — %s\n%s %d/%d", next.Emoji, next.Name, next.Description,
+				createProgressBar(percent, 10), stats[next.Stat], next.Threshold),
+		})
+	}
+
+	var statLines []string
+	for _, stat := range []string{statMessages, statCommands, statVoiceMinutes, statSongs, statLevel} {
+		statLines = append(statLines, fmt.Sprintf("%s: **%d**", achievementStats[stat], stats[stat]))
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Stats", Value: strings.Join(statLines, "\n")})
+
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) achievementsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := getSubcommandName(i)
+	if subcommand == "list" {
+		ch.achievementsListHandler(s, i)
+		return
+	}
+
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage achievements.")
+		return
+	}
+
+	switch subcommand {
+	case "create":
+		a := &database.CustomAchievement{
+			GuildID:     i.GuildID,
+			Name:        getStringOption(i, "name"),
+			Description: getStringOption(i, "description"),
+			Emoji:       getStringOption(i, "emoji"),
+			Stat:        getStringOption(i, "stat"),
+			Threshold:   getIntOption(i, "threshold"),
+		}
+		if role := getRoleOption(i, "role"); role != nil {
+			a.RoleID = role.ID
+		}
+		id, err := ch.bot.DB.CreateCustomAchievement(a)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to create achievement.")
+			return
+		}
+		desc := fmt.Sprintf("**%s** (ID %d) is earned at %d %s", a.Name, id, a.Threshold,
+			strings.ToLower(achievementStats[a.Stat]))
+		if a.RoleID != "" {
+			desc += fmt.Sprintf("\nReward: <@&%s>", a.RoleID)
+		}
+		desc += "\nMembers already past the threshold earn it the next time the stat changes."
+		respondEmbed(s, i, successEmbed("Achievement Created", desc))
+
+	case "delete":
+		deleted, err := ch.bot.DB.DeleteCustomAchievement(i.GuildID, getIntOption(i, "id"))
+		if err != nil {
+			respondEphemeral(s, i, "Failed to delete achievement.")
+			return
+		}
+		if !deleted {
+			respondEphemeral(s, i, "Custom achievement not found.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Achievement Deleted", "The achievement and its awards have been removed"))
+
+	case "settings":
+		cfg, err := ch.bot.DB.GetAchievementConfig(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get achievement settings.")
+			return
+		}
+		for _, opt := range getOptions(i) {
+			switch opt.Name {
+			case "enabled":
+				cfg.Enabled = opt.BoolValue()
+			case "announce":
+				cfg.Announce = opt.BoolValue()
+			case "channel":
+				cfg.ChannelID = opt.ChannelValue(nil).ID
+			}
+		}
+		if err := ch.bot.DB.SetAchievementConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save achievement settings.")
+			return
+		}
+
+		statusEmoji := func(enabled bool) string {
+			if enabled {
+				return "✅"
+			}
+			return "❌"
+		}
+		channel := "Where the unlock happened"
+		if cfg.ChannelID != "" {
+			channel = "<#" + cfg.ChannelID + ">"
+		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title: "Achievement Settings",
+			Color: 0xF1C40F,
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Enabled", Value: statusEmoji(cfg.Enabled), Inline: true},
+				{Name: "Announce", Value: statusEmoji(cfg.Announce), Inline: true},
+				{Name: "Channel", Value: channel, Inline: true},
+			},
+		})
+	}
+}
+
+func (ch *CommandHandler) achievementsListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var lines []string
+	for _, a := range ch.bot.guildAchievements(i.GuildID) {
+		line := fmt.Sprintf("%s **%s** — %s", a.Emoji, a.Name, a.Description)
+		if id, ok := strings.CutPrefix(a.ID, "custom:"); ok {
+			line += fmt.Sprintf(" *(custom, ID %s)*", id)
+		}
+		if a.RoleID != "" {
+			line += fmt.Sprintf(" → <@&%s>", a.RoleID)
+		}
+		lines = append(lines, line)
+	}
+
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "🏅 Achievements",
+		Description: truncate(strings.Join(lines, "\n"), 4096),
+		Color:       0xF1C40F,
+	})
+}
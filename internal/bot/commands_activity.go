@@ -0,0 +1,183 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerActivityCommands() {
+	ch.Register(&Command{
+		Name:        "activity",
+		Description: "Show message, join and leave trends for the server",
+		Category:    "Info",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "days",
+				Description: "How far back to look (default: 14 days)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "7 days", Value: 7},
+					{Name: "14 days", Value: 14},
+					{Name: "30 days", Value: 30},
+					{Name: "90 days", Value: 90},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionChannel,
+				Name:        "channel",
+				Description: "Only count messages in this channel",
+				Required:    false,
+			},
+		},
+		Handler: ch.activityHandler,
+	})
+}
+
+func (ch *CommandHandler) activityHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	days := int(getIntOption(i, "days"))
+	if days == 0 {
+		days = 14
+	}
+	channelID := ""
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		channelID = channel.ID
+	}
+
+	// Include what's been counted since the last flush
+	ch.bot.flushActivity()
+
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -(days - 1)).Format(activityDayFormat)
+	dayLabels := make([]string, days)
+	for n := range dayLabels {
+		dayLabels[n] = now.AddDate(0, 0, n-(days-1)).Format(activityDayFormat)
+	}
+
+	messages := make([]int, days)
+	joins := make([]int, days)
+	leaves := make([]int, days)
+	if channelID == "" {
+		rows, err := ch.bot.DB.GetActivityDays(i.GuildID, since)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get server activity.")
+			return
+		}
+		byDay := make(map[string]int, len(rows))
+		for n, row := range rows {
+			byDay[row.Day] = n
+		}
+		for n, day := range dayLabels {
+			if idx, ok := byDay[day]; ok {
+				messages[n], joins[n], leaves[n] = rows[idx].Messages, rows[idx].Joins, rows[idx].Leaves
+			}
+		}
+	} else {
+		byDay, err := ch.bot.DB.GetChannelActivityDays(i.GuildID, channelID, since)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get channel activity.")
+			return
+		}
+		for n, day := range dayLabels {
+			messages[n] = byDay[day]
+		}
+	}
+
+	hours, err := ch.bot.DB.GetActivityHours(i.GuildID, channelID, since)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get server activity.")
+		return
+	}
+
+	totalMessages, totalJoins, totalLeaves := sumInts(messages), sumInts(joins), sumInts(leaves)
+	if totalMessages == 0 && totalJoins == 0 && totalLeaves == 0 {
+		respondEphemeral(s, i, "No activity recorded for that period yet.")
+		return
+	}
+
+	busiest := 0
+	for hour, count := range hours {
+		if count > hours[busiest] {
+			busiest = hour
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Server Activity",
+		Description: fmt.Sprintf("Last %d days, times in UTC", days),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Messages", Value: fmt.Sprintf("%d", totalMessages), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%s → %s", dayLabels[0], dayLabels[days-1])},
+	}
+	if channelID != "" {
+		embed.Title = "Channel Activity"
+		embed.Description = fmt.Sprintf("<#%s>, last %d days, times in UTC", channelID, days)
+	} else {
+		active, _ := ch.bot.DB.CountActiveMembers(i.GuildID, since)
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: "Active Members", Value: fmt.Sprintf("%d", active), Inline: true},
+			&discordgo.MessageEmbedField{Name: "Joins / Leaves", Value: fmt.Sprintf("+%d / -%d", totalJoins, totalLeaves), Inline: true},
+		)
+	}
+
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{Name: "Messages per Day", Value: "`" + sparkline(messages) + "`"},
+		&discordgo.MessageEmbedField{
+			Name:  "Messages by Hour",
+			Value: fmt.Sprintf("`%s`\n00:00 → 23:00, busiest at **%02d:00**", sparkline(hours[:]), busiest),
+		},
+	)
+	if channelID == "" {
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: "Joins per Day", Value: "`" + sparkline(joins) + "`", Inline: true},
+			&discordgo.MessageEmbedField{Name: "Leaves per Day", Value: "`" + sparkline(leaves) + "`", Inline: true},
+		)
+
+		if top, err := ch.bot.DB.GetTopActivityChannels(i.GuildID, since, 5); err == nil && len(top) > 0 {
+			var sb strings.Builder
+			for _, c := range top {
+				sb.WriteString(fmt.Sprintf("<#%s> — %d\n", c.ID, c.Messages))
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Top Channels", Value: sb.String(), Inline: true})
+		}
+		if top, err := ch.bot.DB.GetTopActivityMembers(i.GuildID, since, 5); err == nil && len(top) > 0 {
+			var sb strings.Builder
+			for _, m := range top {
+				sb.WriteString(fmt.Sprintf("<@%s> — %d\n", m.ID, m.Messages))
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Top Members", Value: sb.String(), Inline: true})
+		}
+	}
+
+	respondEmbed(s, i, embed)
+}
+
+// sumInts adds up a slice of counts
+func sumInts(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -18,9 +18,12 @@ package bot
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -38,13 +41,15 @@ func (ch *CommandHandler) registerAdminCommands() {
 				Required:    true,
 			},
 			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "reason",
-				Description: "Reason for kick",
-				Required:    false,
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "reason",
+				Description:  "Reason for kick",
+				Required:     false,
+				Autocomplete: true,
 			},
 		},
-		Handler: ch.kickHandler,
+		Handler:      ch.kickHandler,
+		Autocomplete: ch.reasonPresetAutocomplete,
 	})
 
 	// Ban command
@@ -60,10 +65,11 @@ func (ch *CommandHandler) registerAdminCommands() {
 				Required:    true,
 			},
 			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "reason",
-				Description: "Reason for ban",
-				Required:    false,
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "reason",
+				Description:  "Reason for ban",
+				Required:     false,
+				Autocomplete: true,
 			},
 			{
 				Type:        discordgo.ApplicationCommandOptionInteger,
@@ -74,7 +80,8 @@ func (ch *CommandHandler) registerAdminCommands() {
 				MaxValue:    7,
 			},
 		},
-		Handler: ch.banHandler,
+		Handler:      ch.banHandler,
+		Autocomplete: ch.reasonPresetAutocomplete,
 	})
 
 	// Unban command
@@ -120,13 +127,15 @@ func (ch *CommandHandler) registerAdminCommands() {
 				MaxValue:    40320, // 28 days max
 			},
 			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "reason",
-				Description: "Reason for timeout",
-				Required:    false,
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "reason",
+				Description:  "Reason for timeout",
+				Required:     false,
+				Autocomplete: true,
 			},
 		},
-		Handler: ch.timeoutHandler,
+		Handler:      ch.timeoutHandler,
+		Autocomplete: ch.reasonPresetAutocomplete,
 	})
 
 	// Remove timeout command
@@ -160,10 +169,10 @@ func (ch *CommandHandler) registerAdminCommands() {
 			{
 				Type:        discordgo.ApplicationCommandOptionInteger,
 				Name:        "amount",
-				Description: "Number of messages to delete (1-100)",
+				Description: "Number of messages to delete (1-5000, over 100 runs with progress and a cancel button)",
 				Required:    true,
 				MinValue:    floatPtr(1),
-				MaxValue:    100,
+				MaxValue:    maxPurgeMessages,
 			},
 			{
 				Type:        discordgo.ApplicationCommandOptionUser,
@@ -180,6 +189,7 @@ func (ch *CommandHandler) registerAdminCommands() {
 		},
 		Handler: ch.purgeHandler,
 	})
+	ch.RegisterComponent("purge", ch.handlePurgeComponent)
 
 	// Slowmode command
 	ch.Register(&Command{
@@ -212,13 +222,15 @@ func (ch *CommandHandler) registerAdminCommands() {
 				Required:    true,
 			},
 			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "reason",
-				Description: "Reason for warning",
-				Required:    true,
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "reason",
+				Description:  "Reason for warning",
+				Required:     true,
+				Autocomplete: true,
 			},
 		},
-		Handler: ch.warnHandler,
+		Handler:      ch.warnHandler,
+		Autocomplete: ch.reasonPresetAutocomplete,
 	})
 
 	// Warnings command
@@ -253,6 +265,52 @@ func (ch *CommandHandler) registerAdminCommands() {
 		Handler: ch.clearWarningsHandler,
 	})
 
+	// Edit or delete a single warning
+	ch.Register(&Command{
+		Name:        "warning",
+		Description: "Edit or delete a warning",
+		Category:    "Administration",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "edit",
+				Description: "Change a warning's reason",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "id",
+						Description: "Warning ID, shown in /warnings",
+						Required:    true,
+						MinValue:    floatPtr(1),
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "reason",
+						Description:  "New reason",
+						Required:     true,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a warning",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "id",
+						Description: "Warning ID, shown in /warnings",
+						Required:    true,
+						MinValue:    floatPtr(1),
+					},
+				},
+			},
+		},
+		Handler:      ch.warningHandler,
+		Autocomplete: ch.reasonPresetAutocomplete,
+	})
+
 	// Lock channel
 	ch.Register(&Command{
 		Name:        "lock",
@@ -328,13 +386,15 @@ func (ch *CommandHandler) registerAdminCommands() {
 				Required:    true,
 			},
 			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "reason",
-				Description: "Reason for softban",
-				Required:    false,
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "reason",
+				Description:  "Reason for softban",
+				Required:     false,
+				Autocomplete: true,
 			},
 		},
-		Handler: ch.softbanHandler,
+		Handler:      ch.softbanHandler,
+		Autocomplete: ch.reasonPresetAutocomplete,
 	})
 
 	// Mass add role
@@ -444,6 +504,42 @@ func (ch *CommandHandler) registerAdminCommands() {
 		},
 		Handler: ch.syncPermsHandler,
 	})
+
+	// Clone channel command
+	ch.Register(&Command{
+		Name:        "clonechannel",
+		Description: "Recreate a channel with the same settings and permissions",
+		Category:    "Administration",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionChannel,
+				Name:        "channel",
+				Description: "Channel to clone (default: current)",
+				Required:    false,
+				ChannelTypes: []discordgo.ChannelType{
+					discordgo.ChannelTypeGuildText,
+					discordgo.ChannelTypeGuildNews,
+					discordgo.ChannelTypeGuildVoice,
+					discordgo.ChannelTypeGuildStageVoice,
+					discordgo.ChannelTypeGuildForum,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Name for the clone (default: same name)",
+				Required:    false,
+				MaxLength:   100,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "delete_original",
+				Description: "Delete the original channel and all its messages",
+				Required:    false,
+			},
+		},
+		Handler: ch.cloneChannelHandler,
+	})
 }
 
 func (ch *CommandHandler) kickHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -453,7 +549,7 @@ func (ch *CommandHandler) kickHandler(s *discordgo.Session, i *discordgo.Interac
 	}
 
 	user := getUserOption(i, "member")
-	reason := getStringOption(i, "reason")
+	reason := ch.resolveReason(i.GuildID, getStringOption(i, "reason"))
 	if reason == "" {
 		reason = "No reason provided"
 	}
@@ -462,15 +558,21 @@ func (ch *CommandHandler) kickHandler(s *discordgo.Session, i *discordgo.Interac
 		respondEphemeral(s, i, "Please specify a member to kick.")
 		return
 	}
+	if problem := checkModerationTarget(s, i.GuildID, i.Member.User.ID, user.ID, "kick"); problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
 
+	dmStatus := ch.bot.sendModDM(s, i.GuildID, user, "kick", reason, 0)
 	err := s.GuildMemberDeleteWithReason(i.GuildID, user.ID, reason)
 	if err != nil {
 		respondEphemeral(s, i, "Failed to kick member: "+err.Error())
 		return
 	}
+	ch.bot.recordModCase(i.GuildID, i.Member.User.ID, user.ID, "kick", reason, dmStatus)
 
 	embed := successEmbed("Member Kicked",
-		fmt.Sprintf("**%s** has been kicked.\n**Reason:** %s", user.Username, reason))
+		fmt.Sprintf("**%s** has been kicked.\n**Reason:** %s%s", user.Username, reason, modDMNote(dmStatus)))
 	respondEmbed(s, i, embed)
 }
 
@@ -481,7 +583,7 @@ func (ch *CommandHandler) banHandler(s *discordgo.Session, i *discordgo.Interact
 	}
 
 	user := getUserOption(i, "member")
-	reason := getStringOption(i, "reason")
+	reason := ch.resolveReason(i.GuildID, getStringOption(i, "reason"))
 	deleteDays := int(getIntOption(i, "delete_days"))
 
 	if reason == "" {
@@ -492,15 +594,21 @@ func (ch *CommandHandler) banHandler(s *discordgo.Session, i *discordgo.Interact
 		respondEphemeral(s, i, "Please specify a member to ban.")
 		return
 	}
+	if problem := checkModerationTarget(s, i.GuildID, i.Member.User.ID, user.ID, "ban"); problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
 
+	dmStatus := ch.bot.sendModDM(s, i.GuildID, user, "ban", reason, 0)
 	err := s.GuildBanCreateWithReason(i.GuildID, user.ID, reason, deleteDays)
 	if err != nil {
 		respondEphemeral(s, i, "Failed to ban member: "+err.Error())
 		return
 	}
+	ch.bot.recordModCase(i.GuildID, i.Member.User.ID, user.ID, "ban", reason, dmStatus)
 
 	embed := successEmbed("Member Banned",
-		fmt.Sprintf("**%s** has been banned.\n**Reason:** %s", user.Username, reason))
+		fmt.Sprintf("**%s** has been banned.\n**Reason:** %s%s", user.Username, reason, modDMNote(dmStatus)))
 	respondEmbed(s, i, embed)
 }
 
@@ -530,25 +638,33 @@ func (ch *CommandHandler) timeoutHandler(s *discordgo.Session, i *discordgo.Inte
 
 	user := getUserOption(i, "member")
 	minutes := getIntOption(i, "minutes")
-	reason := getStringOption(i, "reason")
+	reason := ch.resolveReason(i.GuildID, getStringOption(i, "reason"))
 
 	if user == nil {
 		respondEphemeral(s, i, "Please specify a member to timeout.")
 		return
 	}
+	if problem := checkModerationTarget(s, i.GuildID, i.Member.User.ID, user.ID, "time out"); problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
 
-	until := time.Now().Add(time.Duration(minutes) * time.Minute)
+	duration := time.Duration(minutes) * time.Minute
+	until := time.Now().Add(duration)
 
 	err := s.GuildMemberTimeout(i.GuildID, user.ID, &until)
 	if err != nil {
 		respondEphemeral(s, i, "Failed to timeout member: "+err.Error())
 		return
 	}
+	dmStatus := ch.bot.sendModDM(s, i.GuildID, user, "timeout", reason, duration)
+	ch.bot.recordModCase(i.GuildID, i.Member.User.ID, user.ID, "timeout", reason, dmStatus)
 
 	desc := fmt.Sprintf("**%s** has been timed out for %d minutes.", user.Username, minutes)
 	if reason != "" {
 		desc += fmt.Sprintf("\n**Reason:** %s", reason)
 	}
+	desc += modDMNote(dmStatus)
 
 	embed := successEmbed("Member Timed Out", desc)
 	respondEmbed(s, i, embed)
@@ -590,6 +706,11 @@ func (ch *CommandHandler) purgeHandler(s *discordgo.Session, i *discordgo.Intera
 
 	respondDeferredEphemeral(s, i)
 
+	if amount > 100 {
+		ch.extendedPurge(s, i, amount, filterUser, contains)
+		return
+	}
+
 	messages, err := s.ChannelMessages(i.ChannelID, amount+1, "", "", "")
 	if err != nil {
 		followUp(s, i, "Failed to fetch messages: "+err.Error())
@@ -657,10 +778,19 @@ func (ch *CommandHandler) slowmodeHandler(s *discordgo.Session, i *discordgo.Int
 		return
 	}
 
+	// Setting slowmode by hand takes over from automatic slowmode for a while
+	note := ""
+	if cfg, _ := ch.bot.DB.GetAutoSlowmodeChannel(i.GuildID, i.ChannelID); cfg != nil {
+		until := time.Now().Add(autoSlowmodeManualPause)
+		if ch.bot.DB.PauseAutoSlowmode(i.GuildID, i.ChannelID, &until) == nil {
+			note = fmt.Sprintf("\nAutomatic slowmode is paused until <t:%d:t>.", until.Unix())
+		}
+	}
+
 	if seconds == 0 {
-		respond(s, i, "Slowmode has been disabled.")
+		respond(s, i, "Slowmode has been disabled."+note)
 	} else {
-		respond(s, i, fmt.Sprintf("Slowmode set to %d seconds.", seconds))
+		respond(s, i, fmt.Sprintf("Slowmode set to %d seconds.", seconds)+note)
 	}
 }
 
@@ -671,25 +801,34 @@ func (ch *CommandHandler) warnHandler(s *discordgo.Session, i *discordgo.Interac
 	}
 
 	user := getUserOption(i, "member")
-	reason := getStringOption(i, "reason")
+	reason := ch.resolveReason(i.GuildID, getStringOption(i, "reason"))
 
 	if user == nil {
 		respondEphemeral(s, i, "Please specify a member to warn.")
 		return
 	}
+	if problem := checkModerationTarget(s, i.GuildID, i.Member.User.ID, user.ID, "warn"); problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
 
-	err := ch.bot.DB.AddWarning(i.GuildID, user.ID, i.Member.User.ID, reason)
+	warningID, err := ch.bot.DB.CreateWarning(i.GuildID, user.ID, i.Member.User.ID, reason)
 	if err != nil {
 		respondEphemeral(s, i, "Failed to add warning: "+err.Error())
 		return
 	}
 
+	dmStatus := ch.bot.sendModDM(s, i.GuildID, user, "warn", reason, 0)
+	if caseID := ch.bot.recordModCase(i.GuildID, i.Member.User.ID, user.ID, "warn", reason, dmStatus); caseID != 0 {
+		ch.bot.DB.SetModActionWarningID(caseID, warningID)
+	}
+
 	// Get total warnings
 	warnings, _ := ch.bot.DB.GetWarnings(i.GuildID, user.ID)
 
 	embed := successEmbed("Warning Issued",
-		fmt.Sprintf("**%s** has been warned.\n**Reason:** %s\n**Total Warnings:** %d",
-			user.Username, reason, len(warnings)))
+		fmt.Sprintf("**%s** has been warned.\n**Reason:** %s\n**Total Warnings:** %d%s",
+			user.Username, reason, len(warnings), modDMNote(dmStatus)))
 	respondEmbed(s, i, embed)
 }
 
@@ -717,20 +856,49 @@ func (ch *CommandHandler) warningsHandler(s *discordgo.Session, i *discordgo.Int
 		Color: 0xFEE75C,
 	}
 
-	for i, w := range warnings {
-		reason := "No reason"
-		if w.Reason != nil {
-			reason = *w.Reason
+	// Edits and deletions are shown so changes to the record stay accountable
+	edits, _ := ch.bot.DB.GetWarningEdits(i.GuildID, user.ID)
+	trail := make(map[int64][]string)
+	var deleted []string
+	for _, e := range edits {
+		switch e.Action {
+		case database.WarningEdited:
+			trail[e.WarningID] = append(trail[e.WarningID], fmt.Sprintf("✏️ <@%s> <t:%d:R>, was: %s",
+				e.EditorID, e.EditedAt.Unix(), truncate(warningReason(e.OldReason), 100)))
+		case database.WarningDeleted:
+			deleted = append(deleted, fmt.Sprintf("#%d by <@%s> <t:%d:R>, was: %s",
+				e.WarningID, e.EditorID, e.EditedAt.Unix(), truncate(warningReason(e.OldReason), 100)))
+		}
+	}
+
+	for _, w := range warnings {
+		value := fmt.Sprintf("**Reason:** %s\n**By:** <@%s>", warningReason(w.Reason), w.ModeratorID)
+		if lines := trail[w.ID]; len(lines) > 0 {
+			value += "\n**Edits:**\n" + strings.Join(lines, "\n")
 		}
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:  fmt.Sprintf("#%d - <t:%s:R>", i+1, formatUnixTime(w.CreatedAt)),
-			Value: fmt.Sprintf("**Reason:** %s\n**By:** <@%s>", reason, w.ModeratorID),
+			Name:  fmt.Sprintf("#%d - <t:%s:R>", w.ID, formatUnixTime(w.CreatedAt)),
+			Value: truncate(value, 1024),
+		})
+	}
+	if len(deleted) > 0 && len(embed.Fields) < 25 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Deleted Warnings",
+			Value: truncate(strings.Join(deleted, "\n"), 1024),
 		})
 	}
 
 	respondEmbed(s, i, embed)
 }
 
+// warningReason returns a warning's reason for display
+func warningReason(reason *string) string {
+	if reason == nil || *reason == "" {
+		return "No reason"
+	}
+	return *reason
+}
+
 func (ch *CommandHandler) clearWarningsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if !isModerator(s, i.GuildID, i.Member.User.ID) {
 		respondEphemeral(s, i, "You don't have permission to clear warnings.")
@@ -755,6 +923,45 @@ func (ch *CommandHandler) clearWarningsHandler(s *discordgo.Session, i *discordg
 	respondEmbed(s, i, embed)
 }
 
+func (ch *CommandHandler) warningHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You don't have permission to change warnings.")
+		return
+	}
+
+	id := getIntOption(i, "id")
+	warning, err := ch.bot.DB.GetWarning(i.GuildID, id)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to fetch the warning: "+err.Error())
+		return
+	}
+	if warning == nil {
+		respondEphemeral(s, i, fmt.Sprintf("There's no warning #%d in this server.", id))
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "edit":
+		reason := ch.resolveReason(i.GuildID, getStringOption(i, "reason"))
+		if err := ch.bot.DB.EditWarning(warning, reason, i.Member.User.ID); err != nil {
+			respondEphemeral(s, i, "Failed to edit the warning: "+err.Error())
+			return
+		}
+		respondEmbed(s, i, successEmbed("Warning Edited",
+			fmt.Sprintf("Warning #%d for <@%s> has been changed.\n**Old Reason:** %s\n**New Reason:** %s",
+				warning.ID, warning.UserID, warningReason(warning.Reason), reason)))
+
+	case "delete":
+		if err := ch.bot.DB.RemoveWarning(warning, i.Member.User.ID); err != nil {
+			respondEphemeral(s, i, "Failed to delete the warning: "+err.Error())
+			return
+		}
+		respondEmbed(s, i, successEmbed("Warning Deleted",
+			fmt.Sprintf("Warning #%d for <@%s> has been deleted.\n**Reason was:** %s",
+				warning.ID, warning.UserID, warningReason(warning.Reason))))
+	}
+}
+
 func (ch *CommandHandler) lockHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if !hasPermission(s, i.GuildID, i.Member.User.ID, discordgo.PermissionManageChannels) {
 		respondEphemeral(s, i, "You don't have permission to manage channels.")
@@ -857,6 +1064,10 @@ func (ch *CommandHandler) hackbanHandler(s *discordgo.Session, i *discordgo.Inte
 		respondEphemeral(s, i, "Invalid user ID.")
 		return
 	}
+	if problem := checkModerationTarget(s, i.GuildID, i.Member.User.ID, userID, "ban"); problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
 
 	err := s.GuildBanCreateWithReason(i.GuildID, userID, reason, 0)
 	if err != nil {
@@ -864,6 +1075,8 @@ func (ch *CommandHandler) hackbanHandler(s *discordgo.Session, i *discordgo.Inte
 		return
 	}
 
+	ch.bot.recordModCase(i.GuildID, i.Member.User.ID, userID, "ban", reason, "")
+
 	embed := successEmbed("User Banned",
 		fmt.Sprintf("User `%s` has been banned.\n**Reason:** %s", userID, reason))
 	respondEmbed(s, i, embed)
@@ -876,7 +1089,7 @@ func (ch *CommandHandler) softbanHandler(s *discordgo.Session, i *discordgo.Inte
 	}
 
 	user := getUserOption(i, "member")
-	reason := getStringOption(i, "reason")
+	reason := ch.resolveReason(i.GuildID, getStringOption(i, "reason"))
 	if reason == "" {
 		reason = "Softban"
 	}
@@ -885,6 +1098,12 @@ func (ch *CommandHandler) softbanHandler(s *discordgo.Session, i *discordgo.Inte
 		respondEphemeral(s, i, "Please specify a member.")
 		return
 	}
+	if problem := checkModerationTarget(s, i.GuildID, i.Member.User.ID, user.ID, "ban"); problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
+
+	dmStatus := ch.bot.sendModDM(s, i.GuildID, user, "softban", reason, 0)
 
 	// Ban with message deletion
 	err := s.GuildBanCreateWithReason(i.GuildID, user.ID, reason, 7)
@@ -900,8 +1119,10 @@ func (ch *CommandHandler) softbanHandler(s *discordgo.Session, i *discordgo.Inte
 		return
 	}
 
+	ch.bot.recordModCase(i.GuildID, i.Member.User.ID, user.ID, "softban", reason, dmStatus)
+
 	embed := successEmbed("Member Softbanned",
-		fmt.Sprintf("**%s** has been softbanned (messages deleted).\n**Reason:** %s", user.Username, reason))
+		fmt.Sprintf("**%s** has been softbanned (messages deleted).\n**Reason:** %s%s", user.Username, reason, modDMNote(dmStatus)))
 	respondEmbed(s, i, embed)
 }
 
@@ -932,15 +1153,14 @@ func (ch *CommandHandler) massRoleHandler(s *discordgo.Session, i *discordgo.Int
 	respondDeferred(s, i)
 
 	// Get all guild members
-	members, err := s.GuildMembers(i.GuildID, "", 1000)
+	members, err := fetchGuildMembers(s, i.GuildID)
 	if err != nil {
 		editResponse(s, i, "Failed to get guild members: "+err.Error())
 		return
 	}
 
-	var affected int
-	var errors int
-
+	// Work out who needs changing first, so progress can be shown against a real total
+	var targets []*discordgo.Member
 	for _, member := range members {
 		// Skip bots
 		if member.User.Bot {
@@ -948,56 +1168,47 @@ func (ch *CommandHandler) massRoleHandler(s *discordgo.Session, i *discordgo.Int
 		}
 
 		// If filter role specified, check if member has it
-		if filterRoleID != "" {
-			hasFilter := false
-			for _, r := range member.Roles {
-				if r == filterRoleID {
-					hasFilter = true
-					break
-				}
-			}
-			if !hasFilter {
-				continue
-			}
+		if filterRoleID != "" && !slices.Contains(member.Roles, filterRoleID) {
+			continue
 		}
 
-		switch subCmd {
-		case "add":
-			// Check if they already have the role
-			hasRole := false
-			for _, r := range member.Roles {
-				if r == roleID {
-					hasRole = true
-					break
-				}
-			}
-			if !hasRole {
-				err := s.GuildMemberRoleAdd(i.GuildID, member.User.ID, roleID)
-				if err != nil {
-					errors++
-				} else {
-					affected++
-				}
-			}
+		hasRole := slices.Contains(member.Roles, roleID)
+		if (subCmd == "add" && !hasRole) || (subCmd == "remove" && hasRole) {
+			targets = append(targets, member)
+		}
+	}
 
-		case "remove":
-			// Check if they have the role
-			hasRole := false
-			for _, r := range member.Roles {
-				if r == roleID {
-					hasRole = true
-					break
-				}
-			}
-			if hasRole {
-				err := s.GuildMemberRoleRemove(i.GuildID, member.User.ID, roleID)
-				if err != nil {
-					errors++
-				} else {
-					affected++
-				}
-			}
+	// Big servers can take longer than the interaction token lasts, so report in the channel
+	progress, err := postProgress(s, i.ChannelID, fmt.Sprintf("Updating roles... 0/%d members", len(targets)), nil)
+	if err != nil {
+		editResponse(s, i, "Failed to post the progress message: "+err.Error())
+		return
+	}
+	editResponse(s, i, fmt.Sprintf("Updating roles for %d members. Progress is shown below.", len(targets)))
+
+	var affected int
+	var errors int
+	lastProgress := time.Now()
+
+	for idx, member := range targets {
+		var err error
+		if subCmd == "add" {
+			err = s.GuildMemberRoleAdd(i.GuildID, member.User.ID, roleID)
+		} else {
+			err = s.GuildMemberRoleRemove(i.GuildID, member.User.ID, roleID)
+		}
+		if err != nil {
+			errors++
+		} else {
+			affected++
+		}
+
+		// Role edits are rate limited, so large servers take a while
+		if time.Since(lastProgress) >= memberProgressInterval {
+			progress.update(fmt.Sprintf("Updating roles... %d/%d members (%d errors)", idx+1, len(targets), errors), nil)
+			lastProgress = time.Now()
 		}
+		time.Sleep(massRolePace)
 	}
 
 	action := "added to"
@@ -1010,8 +1221,7 @@ func (ch *CommandHandler) massRoleHandler(s *discordgo.Session, i *discordgo.Int
 		msg += fmt.Sprintf(" (%d errors)", errors)
 	}
 
-	embed := successEmbed("Mass Role Complete", msg)
-	editResponseEmbed(s, i, embed)
+	progress.finish(successEmbed("Mass Role Complete", msg))
 }
 
 func (ch *CommandHandler) chanLockdownHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -1171,3 +1381,75 @@ func (ch *CommandHandler) syncPermsHandler(s *discordgo.Session, i *discordgo.In
 	embed := successEmbed("Permissions Synced", msg)
 	editResponseEmbed(s, i, embed)
 }
+
+func (ch *CommandHandler) cloneChannelHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasPermission(s, i.GuildID, i.Member.User.ID, discordgo.PermissionManageChannels) {
+		respondEphemeral(s, i, "You don't have permission to manage channels.")
+		return
+	}
+
+	channelID := i.ChannelID
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		channelID = channel.ID
+	}
+	deleteOriginal := getBoolOption(i, "delete_original")
+
+	respondDeferredEphemeral(s, i)
+
+	// Get the channel with full details
+	original, err := s.Channel(channelID)
+	if err != nil {
+		editResponse(s, i, "Failed to get channel: "+err.Error())
+		return
+	}
+	if original.IsThread() || original.Type == discordgo.ChannelTypeGuildCategory {
+		editResponse(s, i, "Threads and categories can't be cloned.")
+		return
+	}
+
+	name := getStringOption(i, "name")
+	if name == "" {
+		name = original.Name
+	}
+
+	clone, err := s.GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
+		Name:                 name,
+		Type:                 original.Type,
+		Topic:                original.Topic,
+		Bitrate:              original.Bitrate,
+		UserLimit:            original.UserLimit,
+		RateLimitPerUser:     original.RateLimitPerUser,
+		Position:             original.Position,
+		PermissionOverwrites: original.PermissionOverwrites,
+		ParentID:             original.ParentID,
+		NSFW:                 original.NSFW,
+	})
+	if err != nil {
+		editResponse(s, i, "Failed to clone channel: "+err.Error())
+		return
+	}
+
+	// Channels are created at the bottom of their category, so move it next to the original
+	position := original.Position
+	s.ChannelEdit(clone.ID, &discordgo.ChannelEdit{Position: &position})
+
+	if !deleteOriginal {
+		editResponseEmbed(s, i, successEmbed("Channel Cloned",
+			fmt.Sprintf("<#%s> has been cloned to <#%s> with the same topic, slowmode, NSFW setting and permissions.", original.ID, clone.ID)))
+		return
+	}
+
+	// Reply before deleting, since the original may be where this was run
+	editResponseEmbed(s, i, successEmbed("Channel Recreated",
+		fmt.Sprintf("**#%s** is being replaced by <#%s>.", original.Name, clone.ID)))
+	if _, err := s.ChannelDelete(original.ID); err != nil {
+		editResponse(s, i, fmt.Sprintf("Cloned to <#%s>, but failed to delete the original: %s", clone.ID, err.Error()))
+		return
+	}
+	if clone.Type == discordgo.ChannelTypeGuildText || clone.Type == discordgo.ChannelTypeGuildNews {
+		s.ChannelMessageSendEmbed(clone.ID, &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("This channel was recreated by <@%s>.", i.Member.User.ID),
+			Color:       0x5865F2,
+		})
+	}
+}
@@ -17,15 +17,25 @@
 package bot
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
+	"time"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
 )
 
+// defaultAISystemPrompt is used when neither the guild nor the user gives one
+const defaultAISystemPrompt = "You are a helpful assistant. Keep responses concise and under 2000 characters."
+
+// Limits on conversation memory a guild can choose
+const (
+	aiMaxMemorySize  = 50
+	aiMaxMemoryHours = 168
+)
+
 func (ch *CommandHandler) registerAICommands() {
 	// AI Ask
 	ch.Register(&Command{
@@ -48,94 +58,559 @@ func (ch *CommandHandler) registerAICommands() {
 		},
 		Handler: ch.askAIHandler,
 	})
+
+	// AI settings and conversation memory
+	ch.Register(&Command{
+		Name:        "ai",
+		Description: "Configure /ask and its conversation memory",
+		Category:    "AI",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "provider",
+				Description: "Set the AI provider, model and API key",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "provider",
+						Description: "AI provider",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Bot default", Value: "default"},
+							{Name: "OpenAI", Value: database.AIProviderOpenAI},
+							{Name: "Anthropic", Value: database.AIProviderAnthropic},
+							{Name: "Ollama (local)", Value: database.AIProviderOllama},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "model",
+						Description: "Model name (default: the bot's default for the provider)",
+						Required:    false,
+						MaxLength:   100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "api_key",
+						Description: "This server's own API key (default: the bot's key, if it has one)",
+						Required:    false,
+						MaxLength:   200,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "system",
+				Description: "Set the system prompt /ask uses in this server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "prompt",
+						Description: "System prompt (leave out to go back to the built-in one)",
+						Required:    false,
+						MaxLength:   2000,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "memory",
+				Description: "Set how much of a channel's conversation /ask remembers",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "exchanges",
+						Description: "Past questions and answers sent as context (0 turns memory off)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    aiMaxMemorySize,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hours",
+						Description: "Forget exchanges older than this many hours",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    aiMaxMemoryHours,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "summaries",
+				Description: "Set who can use /summarize and its daily budgets",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role that can summarize besides moderators (@everyone for all members)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "moderators_only",
+						Description: "Only let moderators summarize",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "daily_limit",
+						Description: "Summaries per day across the server (0 for unlimited)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    1000,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "token_budget",
+						Description: "Estimated AI tokens per day across the server (0 for unlimited)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    10000000,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "budgets",
+				Description: "Set daily AI token budgets for the server and each member",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "server",
+						Description: "Estimated tokens per day across the server (0 for unlimited)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    100000000,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "member",
+						Description: "Estimated tokens per day for each member (0 for unlimited)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    100000000,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Make /ask forget the conversation in this channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "all",
+						Description: "Forget conversations in every channel (admin only)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show this server's AI settings",
+			},
+		},
+		Handler: ch.aiSettingsHandler,
+	})
 }
 
 func (ch *CommandHandler) askAIHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	question := getStringOption(i, "question")
 	systemPrompt := getStringOption(i, "system")
 
-	// Check if AI is configured
-	if ch.bot.Config.APIs.OpenAIKey == "" {
-		respondEphemeral(s, i, "AI is not configured. Please set an OpenAI API key in the config.")
+	settings, err := ch.bot.DB.GetAISettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI settings.")
+		return
+	}
+
+	provider, model, err := ch.bot.aiProvider(settings)
+	if errors.Is(err, errAINotConfigured) {
+		respondEphemeral(s, i, "AI is not configured. Ask an admin to set a provider with `/ai provider`, or set an API key in the config.")
+		return
+	}
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI settings.")
+		return
+	}
+
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+	if refusal := ch.bot.aiBudgetRefusal(settings, user.ID); refusal != "" {
+		respondEphemeral(s, i, refusal)
 		return
 	}
 
 	respondDeferred(s, i)
 
 	if systemPrompt == "" {
-		systemPrompt = "You are a helpful assistant. Keep responses concise and under 2000 characters."
+		systemPrompt = settings.SystemPrompt
+	}
+	if systemPrompt == "" {
+		systemPrompt = defaultAISystemPrompt
+	}
+	userName := user.Username
+	if i.Member != nil && i.Member.Nick != "" {
+		userName = i.Member.Nick
+	} else if user.GlobalName != "" {
+		userName = user.GlobalName
 	}
 
-	// Prepare the request
-	requestBody := map[string]interface{}{
-		"model": ch.bot.Config.APIs.OpenAIModel,
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": question},
-		},
-		"max_tokens":  1000,
-		"temperature": 0.7,
+	// Earlier exchanges in the channel go first, each question labelled with who asked it
+	var history []AIMessage
+	if settings.MemorySize > 0 {
+		since := time.Now().Add(-time.Duration(settings.MemoryHours) * time.Hour)
+		exchanges, err := ch.bot.DB.GetAIExchanges(i.ChannelID, settings.MemorySize, since)
+		if err != nil {
+			followUp(s, i, "Failed to load conversation history.")
+			return
+		}
+		for _, ex := range exchanges {
+			history = append(history,
+				AIMessage{Role: "user", Content: ex.UserName + ": " + ex.Prompt},
+				AIMessage{Role: "assistant", Content: ex.Reply})
+		}
 	}
 
-	jsonBody, _ := json.Marshal(requestBody)
+	ctx, cancel := context.WithTimeout(context.Background(), aiTimeout)
+	defer cancel()
 
-	req, err := http.NewRequest("POST", ch.bot.Config.APIs.OpenAIBaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	prompt := question
+	if settings.MemorySize > 0 {
+		prompt = userName + ": " + question
+	}
+	req := AIRequest{
+		Model:       model,
+		System:      systemPrompt,
+		History:     history,
+		Prompt:      prompt,
+		MaxTokens:   1000,
+		Temperature: 0.7,
+	}
+	answer, err := provider.Complete(ctx, req)
 	if err != nil {
-		followUp(s, i, "Failed to create request.")
+		followUp(s, i, "AI Error: "+err.Error())
+		return
+	}
+
+	tokens := estimateTokens(req.System+req.Prompt) + estimateTokens(answer)
+	for _, m := range history {
+		tokens += estimateTokens(m.Content)
+	}
+	ch.bot.recordAIUsage(i.GuildID, user.ID, database.AIFeatureAsk, tokens)
+
+	if len(answer) > 2000 {
+		answer = answer[:1997] + "..."
+	}
+
+	if settings.MemorySize > 0 {
+		ch.bot.DB.AddAIExchange(&database.AIExchange{
+			GuildID:   i.GuildID,
+			ChannelID: i.ChannelID,
+			UserID:    user.ID,
+			UserName:  userName,
+			Prompt:    question,
+			Reply:     answer,
+		}, aiMaxMemorySize)
+	}
+
+	footer := fmt.Sprintf("Model: %s • %s", model, provider.Name())
+	if len(history) > 0 {
+		footer += fmt.Sprintf(" • Remembering %d earlier exchanges", len(history)/2)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "AI Response",
+		Description: answer,
+		Color:       0x10A37F,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: footer,
+		},
+	}
+
+	followUpEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) aiSettingsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := getSubcommandName(i)
+
+	// Moderators can clear a channel's conversation, everything else is for admins
+	if subcommand == "reset" && !getBoolOption(i, "all") {
+		if !isModerator(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "You need moderator permission to reset the AI conversation.")
+			return
+		}
+	} else if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to change AI settings.")
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+ch.bot.Config.APIs.OpenAIKey)
+	if subcommand == "reset" {
+		ch.aiReset(s, i)
+		return
+	}
 
-	resp, err := httpClient.Do(req)
+	settings, err := ch.bot.DB.GetAISettings(i.GuildID)
 	if err != nil {
-		followUp(s, i, "Failed to contact AI service.")
+		respondEphemeral(s, i, "Failed to get AI settings.")
 		return
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	switch subcommand {
+	case "provider":
+		ch.aiSetProvider(s, i, settings)
+	case "system":
+		settings.SystemPrompt = strings.TrimSpace(getStringOption(i, "prompt"))
+		settings.UpdatedBy = i.Member.User.ID
+		if err := ch.bot.DB.SetAISettings(settings); err != nil {
+			respondEphemeral(s, i, "Failed to save AI settings.")
+			return
+		}
+		if settings.SystemPrompt == "" {
+			respondEmbed(s, i, successEmbed("System Prompt Reset", "`/ask` now uses the built-in system prompt."))
+			return
+		}
+		respondEmbed(s, i, successEmbed("System Prompt Set", "`/ask` now uses:\n>>> "+truncate(settings.SystemPrompt, 3900)))
+	case "memory":
+		ch.aiSetMemory(s, i, settings)
+	case "summaries":
+		ch.aiSetSummaries(s, i, settings)
+	case "budgets":
+		for _, opt := range getOptions(i) {
+			switch opt.Name {
+			case "server":
+				settings.DailyTokenBudget = int(opt.IntValue())
+			case "member":
+				settings.UserDailyTokenBudget = int(opt.IntValue())
+			}
+		}
+		settings.UpdatedBy = i.Member.User.ID
+		if err := ch.bot.DB.SetAISettings(settings); err != nil {
+			respondEphemeral(s, i, "Failed to save AI settings.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("AI Budgets Updated", aiBudgetSettings(settings)))
+	case "status":
+		ch.aiStatus(s, i, settings)
+	}
+}
 
-	var response struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-		Error struct {
-			Message string `json:"message"`
-		} `json:"error"`
+func (ch *CommandHandler) aiSetProvider(s *discordgo.Session, i *discordgo.InteractionCreate, settings *database.AISettings) {
+	provider := getStringOption(i, "provider")
+	if provider == "default" {
+		provider = ""
+	}
+	if provider == database.AIProviderOllama && ch.bot.Config().APIs.OllamaURL == "" {
+		respondEphemeral(s, i, "Ollama isn't available: the bot owner hasn't set an Ollama server in the config.")
+		return
 	}
 
-	if err := json.Unmarshal(body, &response); err != nil {
-		followUp(s, i, "Failed to parse AI response.")
+	// A key belongs to one provider, so switching provider drops it unless a new one is given
+	if provider != settings.Provider {
+		settings.APIKey = ""
+		settings.Model = ""
+	}
+	settings.Provider = provider
+	settings.UpdatedBy = i.Member.User.ID
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "model":
+			settings.Model = strings.TrimSpace(opt.StringValue())
+		case "api_key":
+			settings.APIKey = strings.TrimSpace(opt.StringValue())
+		}
+	}
+	if provider == database.AIProviderOllama {
+		settings.APIKey = ""
+	}
+
+	if err := ch.bot.DB.SetAISettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save AI settings.")
 		return
 	}
 
-	if response.Error.Message != "" {
-		followUp(s, i, "AI Error: "+response.Error.Message)
+	description := "`/ask` now uses the bot's default AI provider."
+	if provider != "" {
+		description = fmt.Sprintf("`/ask` now uses **%s** in this server.", aiProviderNames[provider])
+	}
+	embed := successEmbed("AI Provider Set", description)
+	embed.Fields = ch.aiSettingsFields(settings)
+	// Ephemeral, so nobody else sees which key source the server uses
+	respondEmbedEphemeral(s, i, embed)
+}
+
+func (ch *CommandHandler) aiSetMemory(s *discordgo.Session, i *discordgo.InteractionCreate, settings *database.AISettings) {
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "exchanges":
+			settings.MemorySize = int(opt.IntValue())
+		case "hours":
+			settings.MemoryHours = int(opt.IntValue())
+		}
+	}
+	settings.UpdatedBy = i.Member.User.ID
+
+	if err := ch.bot.DB.SetAISettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save AI settings.")
 		return
 	}
 
-	if len(response.Choices) == 0 {
-		followUp(s, i, "No response from AI.")
+	if settings.MemorySize == 0 {
+		respondEmbed(s, i, successEmbed("AI Memory Off", "Each `/ask` question is now answered on its own."))
 		return
 	}
+	respondEmbed(s, i, successEmbed("AI Memory Set",
+		fmt.Sprintf("`/ask` now remembers the last **%d** exchanges in each channel, for up to **%d** hours.",
+			settings.MemorySize, settings.MemoryHours)))
+}
 
-	answer := response.Choices[0].Message.Content
-	if len(answer) > 2000 {
-		answer = answer[:1997] + "..."
+func (ch *CommandHandler) aiSetSummaries(s *discordgo.Session, i *discordgo.InteractionCreate, settings *database.AISettings) {
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "role":
+			settings.SummaryRoleID = opt.RoleValue(nil, "").ID
+		case "moderators_only":
+			if opt.BoolValue() {
+				settings.SummaryRoleID = ""
+			}
+		case "daily_limit":
+			settings.SummaryDailyLimit = int(opt.IntValue())
+		case "token_budget":
+			settings.SummaryTokenBudget = int(opt.IntValue())
+		}
+	}
+	settings.UpdatedBy = i.Member.User.ID
+
+	if err := ch.bot.DB.SetAISettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save AI settings.")
+		return
+	}
+
+	respondEmbed(s, i, successEmbed("Summary Settings Updated", aiSummarySettings(i.GuildID, settings)))
+}
+
+// aiBudgetSettings describes the server's and each member's daily token budgets
+func aiBudgetSettings(settings *database.AISettings) string {
+	return fmt.Sprintf("Server: %s tokens per day\nEach member: %s tokens per day",
+		aiBudgetValue(settings.DailyTokenBudget), aiBudgetValue(settings.UserDailyTokenBudget))
+}
+
+func aiBudgetValue(n int) string {
+	if n == 0 {
+		return "Unlimited"
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// aiSummarySettings describes who can use /summarize and its daily budgets
+func aiSummarySettings(guildID string, settings *database.AISettings) string {
+	who := "Moderators"
+	switch settings.SummaryRoleID {
+	case "":
+	case guildID:
+		who = "Everyone"
+	default:
+		who = fmt.Sprintf("Moderators and <@&%s>", settings.SummaryRoleID)
+	}
+
+	return fmt.Sprintf("Who: %s\nSummaries per day: %s\nTokens per day: %s",
+		who, aiBudgetValue(settings.SummaryDailyLimit), aiBudgetValue(settings.SummaryTokenBudget))
+}
+
+func (ch *CommandHandler) aiReset(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channelID := i.ChannelID
+	if getBoolOption(i, "all") {
+		channelID = ""
+	}
+
+	n, err := ch.bot.DB.ClearAIConversation(i.GuildID, channelID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to reset the AI conversation.")
+		return
+	}
+
+	where := "this channel"
+	if channelID == "" {
+		where = "every channel"
+	}
+	respondEmbed(s, i, successEmbed("AI Conversation Reset",
+		fmt.Sprintf("Forgot %d exchanges in %s.", n, where)))
+}
+
+func (ch *CommandHandler) aiStatus(s *discordgo.Session, i *discordgo.InteractionCreate, settings *database.AISettings) {
+	memory := "Off"
+	if settings.MemorySize > 0 {
+		memory = fmt.Sprintf("Last %d exchanges, up to %d hours", settings.MemorySize, settings.MemoryHours)
+	}
+	system := "Built-in"
+	if settings.SystemPrompt != "" {
+		system = truncate(settings.SystemPrompt, 1000)
+	}
+
+	summaries := aiSummarySettings(i.GuildID, settings)
+	if usage, err := ch.bot.DB.GetAIUsage(i.GuildID, aiUsageDay(), database.AIFeatureSummarize); err == nil {
+		summaries += fmt.Sprintf("\nUsed today: %d summaries, ~%d tokens", usage.Requests, usage.Tokens)
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "AI Response",
-		Description: answer,
-		Color:       0x10A37F,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Model: %s", ch.bot.Config.APIs.OpenAIModel),
-		},
+		Title: "AI Settings",
+		Color: 0x10A37F,
+		Fields: append(ch.aiSettingsFields(settings),
+			&discordgo.MessageEmbedField{Name: "Memory", Value: memory},
+			&discordgo.MessageEmbedField{Name: "System Prompt", Value: system},
+			&discordgo.MessageEmbedField{Name: "Summaries", Value: summaries},
+			&discordgo.MessageEmbedField{Name: "Budgets", Value: aiBudgetSettings(settings)}),
+	}
+	if _, _, err := ch.bot.aiProvider(settings); errors.Is(err, errAINotConfigured) {
+		embed.Description = "⚠️ `/ask` won't work until an API key is set with `/ai provider`."
 	}
+	respondEmbedEphemeral(s, i, embed)
+}
 
-	followUpEmbed(s, i, embed)
+// aiSettingsFields describes a guild's provider, model and where its API key comes from
+func (ch *CommandHandler) aiSettingsFields(settings *database.AISettings) []*discordgo.MessageEmbedField {
+	apis := ch.bot.Config().APIs
+	provider := settings.Provider
+	source := "Server"
+	if provider == "" {
+		provider = apis.AIProvider
+		source = "Bot default"
+	}
+
+	defaultModel, defaultKey := apis.OpenAIModel, apis.OpenAIKey
+	switch provider {
+	case database.AIProviderAnthropic:
+		defaultModel, defaultKey = apis.AnthropicModel, apis.AnthropicKey
+	case database.AIProviderOllama:
+		defaultModel, defaultKey = apis.OllamaModel, ""
+	}
+
+	model := settings.Model
+	if model == "" {
+		model = defaultModel + " (default)"
+	}
+
+	key := "None"
+	switch {
+	case provider == database.AIProviderOllama:
+		key = "Not needed"
+	case settings.APIKey != "":
+		key = "Server key (stored encrypted)"
+	case defaultKey != "":
+		key = "Bot's key"
+	}
+
+	name := aiProviderNames[provider]
+	if name == "" {
+		name = provider
+	}
+	return []*discordgo.MessageEmbedField{
+		{Name: "Provider", Value: fmt.Sprintf("%s (%s)", name, source), Inline: true},
+		{Name: "Model", Value: "`" + model + "`", Inline: true},
+		{Name: "API Key", Value: key, Inline: true},
+	}
 }
@@ -0,0 +1,345 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerAIModerationCommands() {
+	thresholdOption := func(name, description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionNumber,
+			Name:        name,
+			Description: description,
+			Required:    false,
+			MinValue:    floatPtr(0),
+			MaxValue:    1,
+		}
+	}
+
+	ch.Register(&Command{
+		Name:        "aimod",
+		Description: "Score messages with an AI moderation model",
+		Category:    "Moderation",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "enable",
+				Description: "Start scoring messages",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Stop scoring messages",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "thresholds",
+				Description: "Set the scores (0-1) that trigger each action, 0 turns an action off",
+				Options: []*discordgo.ApplicationCommandOption{
+					thresholdOption("flag", "Report the message to staff (default: 0.5)"),
+					thresholdOption("delete", "Delete the message (default: 0.8)"),
+					thresholdOption("warn", "Delete the message and warn its author (default: off)"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Set where flagged messages are reported",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Staff channel (leave out to use the automod log)",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "categories",
+				Description: "Choose which kinds of content are scored",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "categories",
+						Description: "Comma-separated: harassment, hate, self-harm, sexual, violence, illicit, or all",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "test",
+				Description: "Score some text and show what would happen",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "text",
+						Description: "Text to score",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "log",
+				Description: "Show recent AI moderation actions",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Only show actions against this user",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show AI moderation settings",
+			},
+		},
+		Handler: ch.aiModerationHandler,
+	})
+}
+
+func (ch *CommandHandler) aiModerationHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := getSubcommandName(i)
+
+	// Reviewing is for moderators, changing settings is for admins
+	if subcommand == "log" || subcommand == "test" || subcommand == "status" {
+		if !isModerator(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "You need moderator permission to use this command.")
+			return
+		}
+	} else if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure AI moderation.")
+		return
+	}
+
+	cfg, err := ch.bot.DB.GetAIModerationConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI moderation settings.")
+		return
+	}
+
+	switch subcommand {
+	case "enable":
+		if ch.bot.openAIKey(i.GuildID) == "" {
+			respondEphemeral(s, i, "AI moderation needs an OpenAI API key: set one with `/ai provider` or in the config.")
+			return
+		}
+		cfg.Enabled = true
+		ch.saveAIModerationConfig(s, i, cfg, "AI Moderation Enabled", "New messages will be scored by the moderation model.")
+	case "disable":
+		cfg.Enabled = false
+		ch.saveAIModerationConfig(s, i, cfg, "AI Moderation Disabled", "Messages are no longer scored.")
+	case "thresholds":
+		for _, opt := range getOptions(i) {
+			switch opt.Name {
+			case "flag":
+				cfg.FlagThreshold = opt.FloatValue()
+			case "delete":
+				cfg.DeleteThreshold = opt.FloatValue()
+			case "warn":
+				cfg.WarnThreshold = opt.FloatValue()
+			}
+		}
+		ch.saveAIModerationConfig(s, i, cfg, "AI Moderation Thresholds Updated", aiModerationThresholds(cfg))
+	case "channel":
+		cfg.FlagChannelID = ""
+		description := "Flagged messages will be reported in the automod log."
+		if channel := getChannelOption(i, "channel"); channel != nil {
+			cfg.FlagChannelID = channel.ID
+			description = fmt.Sprintf("Flagged messages will be reported in <#%s>.", channel.ID)
+		}
+		ch.saveAIModerationConfig(s, i, cfg, "AI Moderation Channel Set", description)
+	case "categories":
+		categories, ok := parseAIModerationCategories(getStringOption(i, "categories"))
+		if !ok {
+			respondEphemeral(s, i, "Unknown category. Choose from: "+strings.Join(database.AIModerationCategories, ", ")+", or `all`.")
+			return
+		}
+		cfg.Categories = categories
+		ch.saveAIModerationConfig(s, i, cfg, "AI Moderation Categories Set", "Scoring: "+aiModerationCategoryNames(cfg))
+	case "test":
+		ch.aiModerationTest(s, i, cfg)
+	case "log":
+		ch.aiModerationLog(s, i)
+	case "status":
+		status := "Disabled"
+		if cfg.Enabled {
+			status = "Enabled"
+		}
+		embed := &discordgo.MessageEmbed{
+			Title: "AI Moderation",
+			Color: 0x5865F2,
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Status", Value: status, Inline: true},
+				{Name: "Reports", Value: aiModerationChannelName(cfg), Inline: true},
+				{Name: "Categories", Value: aiModerationCategoryNames(cfg), Inline: false},
+				{Name: "Thresholds", Value: aiModerationThresholds(cfg), Inline: false},
+			},
+		}
+		if ch.bot.openAIKey(i.GuildID) == "" {
+			embed.Description = "⚠️ No OpenAI API key is available, so messages can't be scored."
+		}
+		respondEmbedEphemeral(s, i, embed)
+	}
+}
+
+func (ch *CommandHandler) saveAIModerationConfig(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *database.AIModerationConfig, title, description string) {
+	if err := ch.bot.DB.SetAIModerationConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to save AI moderation settings.")
+		return
+	}
+	respondEmbed(s, i, successEmbed(title, description))
+}
+
+func (ch *CommandHandler) aiModerationTest(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *database.AIModerationConfig) {
+	apiKey := ch.bot.openAIKey(i.GuildID)
+	if apiKey == "" {
+		respondEphemeral(s, i, "AI moderation needs an OpenAI API key: set one with `/ai provider` or in the config.")
+		return
+	}
+
+	respondDeferredEphemeral(s, i)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	scores, err := ch.bot.scoreModeration(ctx, apiKey, getStringOption(i, "text"))
+	if err != nil {
+		editResponse(s, i, "Failed to score text: "+err.Error())
+		return
+	}
+	scores = filterModerationScores(scores, cfg.Categories)
+
+	var lines []string
+	for _, sc := range scores[:min(5, len(scores))] {
+		lines = append(lines, fmt.Sprintf("`%-24s` %5.1f%%", sc.Category, sc.Score*100))
+	}
+
+	outcome := "No action"
+	if len(scores) > 0 {
+		switch aiModerationAction(cfg, scores[0].Score) {
+		case "flag":
+			outcome = "Flagged for review"
+		case "delete":
+			outcome = "Message deleted and reported"
+		case "warn":
+			outcome = "Message deleted, author warned and reported"
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "AI Moderation Test",
+		Description: strings.Join(lines, "\n"),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Outcome", Value: outcome, Inline: false},
+		},
+	}
+	editResponseEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) aiModerationLog(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := ""
+	if user := getUserOption(i, "user"); user != nil {
+		userID = user.ID
+	}
+
+	entries, err := ch.bot.DB.GetAIModerationLog(i.GuildID, userID, 10)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI moderation log.")
+		return
+	}
+	if len(entries) == 0 {
+		respondEphemeral(s, i, "No AI moderation actions recorded.")
+		return
+	}
+
+	var description strings.Builder
+	for _, e := range entries {
+		description.WriteString(fmt.Sprintf("**%s** <@%s> in <#%s> <t:%d:R>\n", strings.ToUpper(e.Action), e.UserID, e.ChannelID, e.CreatedAt.Unix()))
+		description.WriteString(fmt.Sprintf("├ %s: %.0f%%\n", e.Category, e.Score*100))
+		description.WriteString(fmt.Sprintf("└ %s\n\n", truncate(strings.ReplaceAll(e.Content, "\n", " "), 150)))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "AI Moderation Log",
+		Description: truncate(description.String(), 4096),
+		Color:       0x5865F2,
+	}
+	respondEmbedEphemeral(s, i, embed)
+}
+
+// parseAIModerationCategories validates a comma-separated category list,
+// returning "" for all categories
+func parseAIModerationCategories(input string) (string, bool) {
+	var categories []string
+	for _, c := range strings.Split(input, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "all" {
+			return "", true
+		}
+		if c == "" || slices.Contains(categories, c) {
+			continue
+		}
+		if !slices.Contains(database.AIModerationCategories, c) {
+			return "", false
+		}
+		categories = append(categories, c)
+	}
+	return strings.Join(categories, ","), true
+}
+
+func aiModerationCategoryNames(cfg *database.AIModerationConfig) string {
+	if cfg.Categories == "" {
+		return "All (" + strings.Join(database.AIModerationCategories, ", ") + ")"
+	}
+	return strings.ReplaceAll(cfg.Categories, ",", ", ")
+}
+
+func aiModerationChannelName(cfg *database.AIModerationConfig) string {
+	if cfg.FlagChannelID == "" {
+		return "Automod log"
+	}
+	return "<#" + cfg.FlagChannelID + ">"
+}
+
+// aiModerationThresholds describes the score each action needs
+func aiModerationThresholds(cfg *database.AIModerationConfig) string {
+	format := func(threshold float64) string {
+		if threshold <= 0 {
+			return "Off"
+		}
+		return fmt.Sprintf("%.0f%%", threshold*100)
+	}
+	return fmt.Sprintf("Flag: **%s**\nDelete: **%s**\nWarn: **%s**",
+		format(cfg.FlagThreshold), format(cfg.DeleteThreshold), format(cfg.WarnThreshold))
+}
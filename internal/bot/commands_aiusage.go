@@ -0,0 +1,179 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerAIUsageCommands() {
+	ch.Register(&Command{
+		Name:        "aiusage",
+		Description: "Show how much of the AI budget has been used",
+		Category:    "AI",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "days",
+				Description: "How far back to report (default: 7 days)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Today", Value: 1},
+					{Name: "7 days", Value: 7},
+					{Name: "30 days", Value: 30},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "Show a member's usage (moderators only for other members)",
+				Required:    false,
+			},
+		},
+		Handler: ch.aiUsageHandler,
+	})
+}
+
+func (ch *CommandHandler) aiUsageHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	days := 7
+	if n := getIntOption(i, "days"); n > 0 {
+		days = int(n)
+	}
+	since := time.Now().UTC().AddDate(0, 0, 1-days).Format(activityDayFormat)
+
+	settings, err := ch.bot.DB.GetAISettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI settings.")
+		return
+	}
+
+	// Members see their own usage, moderators can see anyone's and the server's
+	moderator := isModerator(s, i.GuildID, i.Member.User.ID)
+	user := getUserOption(i, "user")
+	if user == nil && !moderator {
+		user = i.Member.User
+	}
+	if user != nil && user.ID != i.Member.User.ID && !moderator {
+		respondEphemeral(s, i, "You can only see your own AI usage.")
+		return
+	}
+
+	period := "Today"
+	if days > 1 {
+		period = fmt.Sprintf("Last %d days", days)
+	}
+
+	if user != nil {
+		ch.aiUserUsageReport(s, i, settings, user, since, period)
+		return
+	}
+
+	total, err := ch.bot.DB.GetAIUsageTotal(i.GuildID, since)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI usage.")
+		return
+	}
+	today, err := ch.bot.DB.GetAIUsageTotal(i.GuildID, aiUsageDay())
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI usage.")
+		return
+	}
+	features, err := ch.bot.DB.GetAIUsageByFeature(i.GuildID, since)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI usage.")
+		return
+	}
+	top, err := ch.bot.DB.GetTopAIUsers(i.GuildID, since, 10)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI usage.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "AI Usage",
+		Description: fmt.Sprintf("%s: **%d** requests, ~**%d** tokens", period, total.Requests, total.Tokens),
+		Color:       0x10A37F,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Today's Budget", Value: aiBudgetUsage(today.Tokens, settings.DailyTokenBudget), Inline: false},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Token counts are estimates • Budgets reset at midnight UTC",
+		},
+	}
+
+	if len(features) > 0 {
+		var lines []string
+		for _, f := range features {
+			lines = append(lines, fmt.Sprintf("`/%s` %d requests, ~%d tokens", f.Feature, f.Requests, f.Tokens))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "By Command", Value: strings.Join(lines, "\n")})
+	}
+
+	if len(top) > 0 {
+		var lines []string
+		for n, u := range top {
+			lines = append(lines, fmt.Sprintf("%d. <@%s> %d requests, ~%d tokens", n+1, u.UserID, u.Requests, u.Tokens))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Top Members", Value: truncate(strings.Join(lines, "\n"), 1024)})
+	}
+
+	respondEmbedEphemeral(s, i, embed)
+}
+
+func (ch *CommandHandler) aiUserUsageReport(s *discordgo.Session, i *discordgo.InteractionCreate, settings *database.AISettings, user *discordgo.User, since, period string) {
+	total, err := ch.bot.DB.GetAIUserUsageTotal(i.GuildID, user.ID, since)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI usage.")
+		return
+	}
+	today, err := ch.bot.DB.GetAIUserUsageTotal(i.GuildID, user.ID, aiUsageDay())
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI usage.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("AI Usage for %s", user.Username),
+		Description: fmt.Sprintf("%s: **%d** requests, ~**%d** tokens", period, total.Requests, total.Tokens),
+		Color:       0x10A37F,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Today's Budget", Value: aiBudgetUsage(today.Tokens, settings.UserDailyTokenBudget), Inline: false},
+		},
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: avatarURL(user),
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Token counts are estimates • Budgets reset at midnight UTC",
+		},
+	}
+	respondEmbedEphemeral(s, i, embed)
+}
+
+// aiBudgetUsage shows tokens used against a daily budget as a progress bar
+func aiBudgetUsage(used, budget int) string {
+	if budget == 0 {
+		return fmt.Sprintf("~%d tokens used (no limit)", used)
+	}
+	filled := min(10, used*10/budget)
+	return fmt.Sprintf("%s%s ~%d / %d tokens (%d%%)",
+		strings.Repeat("█", filled), strings.Repeat("░", 10-filled), used, budget, min(100, used*100/budget))
+}
@@ -459,7 +459,7 @@ func (ch *CommandHandler) silenceHandler(s *discordgo.Session, i *discordgo.Inte
 
 	cfg, err := ch.bot.DB.GetAntiRaidConfig(i.GuildID)
 	if err != nil || cfg.SilentRoleID == "" {
-		respondEphemeral(s, i, "Silent role not configured. Use `/antiraid setrole` first.")
+		respondEphemeral(s, i, "Silent role not configured. Use `/raid antiraid setrole` first.")
 		return
 	}
 
@@ -0,0 +1,430 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultArchiveMessages is how many messages /archive exports without a count or since
+const defaultArchiveMessages = 100
+
+// archiveExport is the JSON form of a channel transcript
+type archiveExport struct {
+	GuildID      string           `json:"guild_id"`
+	Guild        string           `json:"guild"`
+	ChannelID    string           `json:"channel_id"`
+	Channel      string           `json:"channel"`
+	ExportedBy   string           `json:"exported_by"`
+	ExportedAt   time.Time        `json:"exported_at"`
+	MessageCount int              `json:"message_count"`
+	Messages     []archiveMessage `json:"messages"`
+}
+
+type archiveMessage struct {
+	ID          string              `json:"id"`
+	AuthorID    string              `json:"author_id"`
+	Author      string              `json:"author"`
+	Bot         bool                `json:"bot,omitempty"`
+	Timestamp   time.Time           `json:"timestamp"`
+	EditedAt    *time.Time          `json:"edited_at,omitempty"`
+	ReplyTo     string              `json:"reply_to,omitempty"`
+	Content     string              `json:"content,omitempty"`
+	Embeds      []string            `json:"embeds,omitempty"`
+	Attachments []archiveAttachment `json:"attachments,omitempty"`
+}
+
+type archiveAttachment struct {
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+	Size     int    `json:"size"`
+	Path     string `json:"path,omitempty"` // Set when the file is bundled in the zip
+}
+
+func (ch *CommandHandler) registerArchiveCommands() {
+	ch.Register(&Command{
+		Name:        "archive",
+		Description: "Export a channel's messages to an HTML or JSON transcript",
+		Category:    "Moderation",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionChannel,
+				Name:        "channel",
+				Description: "Channel to export",
+				Required:    true,
+				ChannelTypes: []discordgo.ChannelType{
+					discordgo.ChannelTypeGuildText,
+					discordgo.ChannelTypeGuildNews,
+					discordgo.ChannelTypeGuildVoice,
+					discordgo.ChannelTypeGuildPublicThread,
+					discordgo.ChannelTypeGuildPrivateThread,
+					discordgo.ChannelTypeGuildNewsThread,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: fmt.Sprintf("Number of recent messages to export (default %d)", defaultArchiveMessages),
+				Required:    false,
+				MinValue:    floatPtr(1),
+				MaxValue:    maxTranscriptMessages,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "since",
+				Description: "Only export messages from this far back (e.g., 6h, 2d, 1w)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "format",
+				Description: "Transcript format (default HTML)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "HTML", Value: "html"},
+					{Name: "JSON", Value: "json"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "attachments",
+				Description: "Bundle attachments with the transcript in a zip file",
+				Required:    false,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "send_to",
+				Description:  "Staff channel to post the transcript in (default: only you)",
+				Required:     false,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+			},
+		},
+		Handler: ch.archiveHandler,
+	})
+}
+
+// uploadLimit returns the largest file the bot can upload in a guild, which grows with its boost tier
+func uploadLimit(guild *discordgo.Guild) int {
+	if guild != nil {
+		switch guild.PremiumTier {
+		case discordgo.PremiumTier3:
+			return 100 << 20
+		case discordgo.PremiumTier2:
+			return 50 << 20
+		}
+	}
+	return 10 << 20
+}
+
+// canUseChannel reports whether userID has all of perms in a channel
+func canUseChannel(s *discordgo.Session, userID, channelID string, perms int64) bool {
+	p, err := s.UserChannelPermissions(userID, channelID)
+	return err == nil && p&perms == perms
+}
+
+func (ch *CommandHandler) archiveHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasPermission(s, i.GuildID, i.Member.User.ID, discordgo.PermissionManageMessages) {
+		respondEphemeral(s, i, "You need Manage Messages permission to archive channels.")
+		return
+	}
+
+	channel := getChannelOption(i, "channel")
+	if channel == nil {
+		respondEphemeral(s, i, "Please choose a channel to archive.")
+		return
+	}
+	readPerms := int64(discordgo.PermissionViewChannel | discordgo.PermissionReadMessageHistory)
+	if !canUseChannel(s, i.Member.User.ID, channel.ID, readPerms) {
+		respondEphemeral(s, i, "You can't read the message history of that channel.")
+		return
+	}
+	if !canUseChannel(s, s.State.User.ID, channel.ID, readPerms) {
+		respondEphemeral(s, i, fmt.Sprintf("I can't read the message history of <#%s>.", channel.ID))
+		return
+	}
+
+	destination := getChannelOption(i, "send_to")
+	if destination != nil {
+		if !canUseChannel(s, i.Member.User.ID, destination.ID, discordgo.PermissionViewChannel|discordgo.PermissionSendMessages) {
+			respondEphemeral(s, i, "You can't send messages in that channel.")
+			return
+		}
+		if !canUseChannel(s, s.State.User.ID, destination.ID, discordgo.PermissionViewChannel|discordgo.PermissionSendMessages|discordgo.PermissionAttachFiles) {
+			respondEphemeral(s, i, fmt.Sprintf("I need permission to send messages and attach files in <#%s>.", destination.ID))
+			return
+		}
+	}
+
+	var since time.Time
+	if input := getStringOption(i, "since"); input != "" {
+		d, err := parseDuration(input)
+		if err != nil || d <= 0 {
+			respondEphemeral(s, i, "Invalid duration. Use something like `6h`, `2d` or `1w`.")
+			return
+		}
+		since = time.Now().Add(-d)
+	}
+	limit := int(getIntOption(i, "count"))
+	if limit == 0 {
+		limit = defaultArchiveMessages
+		if !since.IsZero() {
+			limit = maxTranscriptMessages
+		}
+	}
+	format := getStringOption(i, "format")
+	if format == "" {
+		format = "html"
+	}
+	bundle := getBoolOption(i, "attachments")
+
+	respondDeferredEphemeral(s, i)
+
+	messages, err := fetchChannelHistory(s, channel.ID, limit, since)
+	if err != nil {
+		followUp(s, i, "Failed to fetch messages: "+err.Error())
+		return
+	}
+	if len(messages) == 0 {
+		followUp(s, i, "No messages found to archive.")
+		return
+	}
+
+	guild, err := s.State.Guild(i.GuildID)
+	if err != nil {
+		guild, _ = s.Guild(i.GuildID)
+	}
+	guildName := i.GuildID
+	if guild != nil {
+		guildName = guild.Name
+	}
+	maxSize := uploadLimit(guild)
+
+	// Attachments are bundled oldest first until they would push the zip past the upload limit,
+	// leaving headroom for the transcript itself; the rest stay as links
+	bundled := make(map[string]string)
+	var bundleSize int
+	if bundle {
+		budget := maxSize - maxSize/10
+		for _, m := range messages {
+			for idx, a := range m.Attachments {
+				if bundleSize+a.Size > budget {
+					continue
+				}
+				bundled[a.ID] = fmt.Sprintf("attachments/%s_%d_%s", m.ID, idx, filepath.Base(a.Filename))
+				bundleSize += a.Size
+			}
+		}
+	}
+
+	base := fmt.Sprintf("%s-%s", channel.Name, time.Now().UTC().Format("20060102-1504"))
+	var transcript []byte
+	var contentType string
+	if format == "json" {
+		transcript, err = archiveJSON(i.GuildID, guildName, channel, i.Member.User, messages, bundled)
+		if err != nil {
+			followUp(s, i, "Failed to create JSON transcript.")
+			return
+		}
+		contentType = "application/json"
+	} else {
+		title := "#" + channel.Name
+		subtitle := fmt.Sprintf("%s · exported %s by %s · %d messages", guildName,
+			time.Now().UTC().Format("2006-01-02 15:04 MST"), i.Member.User.Username, len(messages))
+		transcript = []byte(transcriptPage(title, subtitle, messages, func(a *discordgo.MessageAttachment) string {
+			if path, ok := bundled[a.ID]; ok {
+				return path
+			}
+			return a.URL
+		}))
+		contentType = "text/html"
+	}
+
+	file := &discordgo.File{Name: base + "." + format, ContentType: contentType, Reader: bytes.NewReader(transcript)}
+	size := len(transcript)
+	skipped := 0
+	if bundle {
+		zipped, failed, err := archiveZip(file.Name, transcript, messages, bundled, maxSize)
+		if err != nil {
+			followUp(s, i, "Failed to bundle attachments.")
+			return
+		}
+		file = &discordgo.File{Name: base + ".zip", ContentType: "application/zip", Reader: bytes.NewReader(zipped)}
+		size = len(zipped)
+		skipped = countAttachments(messages) - len(bundled) + failed
+	}
+	if size > maxSize {
+		followUp(s, i, fmt.Sprintf("The transcript is %.1f MB, over this server's %d MB upload limit. Try a smaller count or a shorter time range.",
+			float64(size)/(1<<20), maxSize>>20))
+		return
+	}
+
+	summary := fmt.Sprintf("Archived **%d** messages from <#%s> (<t:%d:f> to <t:%d:f>).", len(messages), channel.ID,
+		messages[0].Timestamp.Unix(), messages[len(messages)-1].Timestamp.Unix())
+	if skipped > 0 {
+		summary += fmt.Sprintf("\n%d attachments didn't fit in the upload limit and are linked instead.", skipped)
+	}
+
+	if destination == nil {
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: summary,
+			Files:   []*discordgo.File{file},
+		})
+		if err != nil {
+			followUp(s, i, "Failed to send the transcript: "+err.Error())
+		}
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Channel Archive",
+		Description: summary,
+		Color:       0x5865F2,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Exported by " + i.Member.User.Username},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	msg, err := s.ChannelMessageSendComplex(destination.ID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Files:  []*discordgo.File{file},
+	})
+	if err != nil {
+		followUp(s, i, "Failed to post the transcript: "+err.Error())
+		return
+	}
+	followUp(s, i, fmt.Sprintf("Transcript posted in <#%s>: https://discord.com/channels/%s/%s/%s",
+		destination.ID, i.GuildID, msg.ChannelID, msg.ID))
+}
+
+// archiveJSON renders messages as an indented JSON transcript
+func archiveJSON(guildID, guildName string, channel *discordgo.Channel, exporter *discordgo.User, messages []*discordgo.Message, bundled map[string]string) ([]byte, error) {
+	export := archiveExport{
+		GuildID:      guildID,
+		Guild:        guildName,
+		ChannelID:    channel.ID,
+		Channel:      channel.Name,
+		ExportedBy:   exporter.ID,
+		ExportedAt:   time.Now().UTC(),
+		MessageCount: len(messages),
+		Messages:     make([]archiveMessage, 0, len(messages)),
+	}
+	for _, m := range messages {
+		if m.Author == nil {
+			continue
+		}
+		entry := archiveMessage{
+			ID:        m.ID,
+			AuthorID:  m.Author.ID,
+			Author:    m.Author.Username,
+			Bot:       m.Author.Bot,
+			Timestamp: m.Timestamp.UTC(),
+			Content:   m.Content,
+		}
+		if m.EditedTimestamp != nil {
+			edited := m.EditedTimestamp.UTC()
+			entry.EditedAt = &edited
+		}
+		if m.MessageReference != nil {
+			entry.ReplyTo = m.MessageReference.MessageID
+		}
+		for _, e := range m.Embeds {
+			if text := transcriptEmbedText(e); text != "" {
+				entry.Embeds = append(entry.Embeds, text)
+			}
+		}
+		for _, a := range m.Attachments {
+			entry.Attachments = append(entry.Attachments, archiveAttachment{
+				Filename: a.Filename,
+				URL:      a.URL,
+				Size:     a.Size,
+				Path:     bundled[a.ID],
+			})
+		}
+		export.Messages = append(export.Messages, entry)
+	}
+
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// archiveZip bundles a transcript with the attachments chosen in bundled,
+// returning how many of those couldn't be downloaded
+func archiveZip(name string, transcript []byte, messages []*discordgo.Message, bundled map[string]string, maxSize int) ([]byte, int, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := w.Write(transcript); err != nil {
+		return nil, 0, err
+	}
+
+	failed := 0
+	for _, m := range messages {
+		for _, a := range m.Attachments {
+			path, ok := bundled[a.ID]
+			if !ok {
+				continue
+			}
+			if err := addZipAttachment(zw, path, a.URL, maxSize); err != nil {
+				failed++
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), failed, nil
+}
+
+// addZipAttachment downloads an attachment into the zip at path
+func addZipAttachment(zw *zip.Writer, path, url string, maxSize int) error {
+	resp, err := attachmentClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	w, err := zw.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, io.LimitReader(resp.Body, int64(maxSize)))
+	return err
+}
+
+// countAttachments returns the number of attachments across messages
+func countAttachments(messages []*discordgo.Message) int {
+	n := 0
+	for _, m := range messages {
+		n += len(m.Attachments)
+	}
+	return n
+}
@@ -0,0 +1,277 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerAutoSlowmodeCommands() {
+	ch.Register(&Command{
+		Name:        "autoslowmode",
+		Description: "Raise and lower a channel's slowmode automatically with its activity",
+		Category:    "Administration",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Turn on automatic slowmode for a channel, or change its settings",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to watch",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "threshold",
+						Description: "Messages per minute that start raising slowmode (default: 30)",
+						Required:    false,
+						MinValue:    floatPtr(5),
+						MaxValue:    1000,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "max",
+						Description: "Highest slowmode in seconds it will set (default: 30)",
+						Required:    false,
+						MinValue:    floatPtr(2),
+						MaxValue:    21600,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Turn off automatic slowmode and restore the channel's normal slowmode",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to stop watching",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "pause",
+				Description: "Stop automatic changes for a while so you can set slowmode by hand",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to pause",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "minutes",
+						Description: "How long to pause for (default: 60)",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    10080,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "resume",
+				Description: "Resume automatic changes in a paused channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to resume",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List channels with automatic slowmode",
+			},
+		},
+		Handler: ch.autoSlowmodeHandler,
+	})
+}
+
+func (ch *CommandHandler) autoSlowmodeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !hasPermission(s, i.GuildID, i.Member.User.ID, discordgo.PermissionManageChannels) {
+		respondEphemeral(s, i, "You need the Manage Channels permission to configure automatic slowmode.")
+		return
+	}
+
+	sub := getSubcommandName(i)
+	switch sub {
+	case "add":
+		ch.autoSlowmodeAdd(s, i)
+		return
+	case "list":
+		ch.autoSlowmodeList(s, i)
+		return
+	}
+
+	channel := getChannelOption(i, "channel")
+	cfg, err := ch.bot.DB.GetAutoSlowmodeChannel(i.GuildID, channel.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get automatic slowmode settings.")
+		return
+	}
+	if cfg == nil {
+		respondEphemeral(s, i, fmt.Sprintf("<#%s> doesn't have automatic slowmode.", channel.ID))
+		return
+	}
+
+	switch sub {
+	case "remove":
+		if _, err := ch.bot.DB.RemoveAutoSlowmodeChannel(i.GuildID, channel.ID); err != nil {
+			respondEphemeral(s, i, "Failed to remove automatic slowmode.")
+			return
+		}
+		slowmodeTracker.forget(channel.ID)
+		if channelSlowmode(s, channel.ID) != cfg.BaseSeconds {
+			s.ChannelEdit(channel.ID, &discordgo.ChannelEdit{RateLimitPerUser: &cfg.BaseSeconds})
+		}
+		respondEmbed(s, i, successEmbed("Automatic Slowmode Removed",
+			fmt.Sprintf("<#%s> is back to its normal slowmode (%s).", channel.ID, slowmodeLabel(cfg.BaseSeconds))))
+	case "pause":
+		minutes := getIntOption(i, "minutes")
+		if minutes <= 0 {
+			minutes = 60
+		}
+		until := time.Now().Add(time.Duration(minutes) * time.Minute)
+		if err := ch.bot.DB.PauseAutoSlowmode(i.GuildID, channel.ID, &until); err != nil {
+			respondEphemeral(s, i, "Failed to pause automatic slowmode.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Automatic Slowmode Paused",
+			fmt.Sprintf("Slowmode in <#%s> won't change automatically until <t:%d:t>. Set it yourself with `/slowmode`.", channel.ID, until.Unix())))
+	case "resume":
+		if err := ch.bot.DB.PauseAutoSlowmode(i.GuildID, channel.ID, nil); err != nil {
+			respondEphemeral(s, i, "Failed to resume automatic slowmode.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Automatic Slowmode Resumed",
+			fmt.Sprintf("Slowmode in <#%s> will follow its activity again.", channel.ID)))
+	}
+}
+
+func (ch *CommandHandler) autoSlowmodeAdd(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channel := getChannelOption(i, "channel")
+
+	// Only the options given change an existing channel's settings
+	cfg, err := ch.bot.DB.GetAutoSlowmodeChannel(i.GuildID, channel.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get automatic slowmode settings.")
+		return
+	}
+	if cfg == nil {
+		// Whatever slowmode the channel has now is what it returns to
+		cfg = &database.AutoSlowmodeChannel{
+			GuildID:     i.GuildID,
+			ChannelID:   channel.ID,
+			Threshold:   30,
+			MaxSeconds:  30,
+			BaseSeconds: channelSlowmode(s, channel.ID),
+			CreatedBy:   i.Member.User.ID,
+		}
+	}
+
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "threshold":
+			cfg.Threshold = int(opt.IntValue())
+		case "max":
+			cfg.MaxSeconds = int(opt.IntValue())
+		}
+	}
+	if cfg.MaxSeconds <= cfg.BaseSeconds {
+		respondEphemeral(s, i, fmt.Sprintf("The maximum has to be above the channel's normal slowmode (%s).", slowmodeLabel(cfg.BaseSeconds)))
+		return
+	}
+
+	if err := ch.bot.DB.SetAutoSlowmodeChannel(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to save automatic slowmode.")
+		return
+	}
+
+	embed := successEmbed("Automatic Slowmode Enabled",
+		fmt.Sprintf("Slowmode in <#%s> will rise when it gets busy and drop again once it calms down. Changes are posted to the mod log.", channel.ID))
+	embed.Fields = autoSlowmodeFields(cfg)
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) autoSlowmodeList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channels, err := ch.bot.DB.GetAutoSlowmodeChannels(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get automatic slowmode channels.")
+		return
+	}
+	if len(channels) == 0 {
+		respondEphemeral(s, i, "No channels have automatic slowmode.")
+		return
+	}
+
+	var description strings.Builder
+	for _, c := range channels {
+		fields := autoSlowmodeFields(c)
+		description.WriteString(fmt.Sprintf("<#%s>\n", c.ChannelID))
+		description.WriteString(fmt.Sprintf("├ Threshold: %s\n", fields[0].Value))
+		description.WriteString(fmt.Sprintf("├ Range: %s\n", fields[1].Value))
+		description.WriteString(fmt.Sprintf("└ Status: %s\n\n", fields[2].Value))
+	}
+
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Automatic Slowmode Channels (%d)", len(channels)),
+		Description: truncate(description.String(), 4096),
+		Color:       0x5865F2,
+	})
+}
+
+// channelSlowmode returns a channel's current slowmode in seconds. Channels in
+// interaction options are partial, so this reads the full channel.
+func channelSlowmode(s *discordgo.Session, channelID string) int {
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		if channel, err = s.Channel(channelID); err != nil {
+			return 0
+		}
+	}
+	return channel.RateLimitPerUser
+}
+
+// autoSlowmodeFields describes a channel's settings: threshold, slowmode range and whether it's paused
+func autoSlowmodeFields(c *database.AutoSlowmodeChannel) []*discordgo.MessageEmbedField {
+	status := "Active"
+	if c.Paused() {
+		status = fmt.Sprintf("Paused until <t:%d:t>", c.PausedUntil.Unix())
+	}
+	return []*discordgo.MessageEmbedField{
+		{Name: "Threshold", Value: fmt.Sprintf("%d messages/minute", c.Threshold), Inline: true},
+		{Name: "Range", Value: fmt.Sprintf("%s to %s", slowmodeLabel(c.BaseSeconds), slowmodeLabel(c.MaxSeconds)), Inline: true},
+		{Name: "Status", Value: status, Inline: true},
+	}
+}
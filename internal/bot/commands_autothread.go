@@ -0,0 +1,225 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// autoThreadArchiveNames labels the auto-archive durations Discord allows
+var autoThreadArchiveNames = map[int]string{
+	60:    "1 hour",
+	1440:  "1 day",
+	4320:  "3 days",
+	10080: "1 week",
+}
+
+func (ch *CommandHandler) registerAutoThreadCommands() {
+	ch.Register(&Command{
+		Name:        "autothread",
+		Description: "Open a thread on every new message in a channel",
+		Category:    "AutoThread",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Turn on auto-threads for a channel, or change its settings",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to open threads in",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "Which messages get a thread (default: all)",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Every message", Value: database.AutoThreadAll},
+							{Name: "Messages with attachments", Value: database.AutoThreadAttachments},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Thread name template ({nickname}, {username}, {content}, {date})",
+						Required:    false,
+						MaxLength:   100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "archive",
+						Description: "Hide the thread after this long without messages (default: 1 day)",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "1 hour", Value: 60},
+							{Name: "1 day", Value: 1440},
+							{Name: "3 days", Value: 4320},
+							{Name: "1 week", Value: 10080},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Turn off auto-threads for a channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to stop opening threads in",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List auto-thread channels",
+			},
+		},
+		Handler: ch.autoThreadHandler,
+	})
+}
+
+func (ch *CommandHandler) autoThreadHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage auto-threads.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "add":
+		ch.autoThreadAdd(s, i)
+	case "remove":
+		channel := getChannelOption(i, "channel")
+		removed, err := ch.bot.DB.RemoveAutoThreadChannel(i.GuildID, channel.ID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to remove auto-thread channel.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, fmt.Sprintf("<#%s> isn't an auto-thread channel.", channel.ID))
+			return
+		}
+		respondEmbed(s, i, successEmbed("Auto-Thread Removed",
+			fmt.Sprintf("New messages in <#%s> will no longer get a thread.", channel.ID)))
+	case "list":
+		ch.autoThreadList(s, i)
+	}
+}
+
+func (ch *CommandHandler) autoThreadAdd(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channel := getChannelOption(i, "channel")
+
+	// Only the options given change an existing channel's settings
+	cfg, err := ch.bot.DB.GetAutoThreadChannel(i.GuildID, channel.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get auto-thread settings.")
+		return
+	}
+	if cfg == nil {
+		cfg = &database.AutoThreadChannel{
+			GuildID:        i.GuildID,
+			ChannelID:      channel.ID,
+			Mode:           database.AutoThreadAll,
+			ArchiveMinutes: 1440,
+			CreatedBy:      i.Member.User.ID,
+		}
+	}
+
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "mode":
+			cfg.Mode = opt.StringValue()
+		case "name":
+			cfg.NameTemplate = strings.TrimSpace(opt.StringValue())
+		case "archive":
+			cfg.ArchiveMinutes = int(opt.IntValue())
+		}
+	}
+
+	if err := ch.bot.DB.SetAutoThreadChannel(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to save auto-thread channel.")
+		return
+	}
+
+	embed := successEmbed("Auto-Thread Enabled",
+		fmt.Sprintf("New messages in <#%s> will get their own thread.", channel.ID))
+	embed.Fields = autoThreadFields(cfg)
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) autoThreadList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channels, err := ch.bot.DB.GetAutoThreadChannels(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get auto-thread channels.")
+		return
+	}
+
+	if len(channels) == 0 {
+		respondEphemeral(s, i, "No auto-thread channels configured.")
+		return
+	}
+
+	var description strings.Builder
+	for _, c := range channels {
+		fields := autoThreadFields(c)
+		description.WriteString(fmt.Sprintf("<#%s>\n", c.ChannelID))
+		description.WriteString(fmt.Sprintf("├ Messages: %s\n", fields[0].Value))
+		description.WriteString(fmt.Sprintf("├ Name: %s\n", fields[1].Value))
+		description.WriteString(fmt.Sprintf("└ Archive after: %s\n\n", fields[2].Value))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Auto-Thread Channels (%d)", len(channels)),
+		Description: truncate(description.String(), 4096),
+		Color:       0x5865F2,
+	}
+
+	respondEmbed(s, i, embed)
+}
+
+// autoThreadFields describes a channel's settings: which messages, name and archive time
+func autoThreadFields(c *database.AutoThreadChannel) []*discordgo.MessageEmbedField {
+	mode := "Every message"
+	if c.Mode == database.AutoThreadAttachments {
+		mode = "With attachments"
+	}
+	name := c.NameTemplate
+	if name == "" {
+		name = defaultAutoThreadName
+	}
+	archive, ok := autoThreadArchiveNames[c.ArchiveMinutes]
+	if !ok {
+		archive = fmt.Sprintf("%d minutes", c.ArchiveMinutes)
+	}
+
+	return []*discordgo.MessageEmbedField{
+		{Name: "Messages", Value: mode, Inline: true},
+		{Name: "Name", Value: "`" + name + "`", Inline: true},
+		{Name: "Archive After", Value: archive, Inline: true},
+	}
+}
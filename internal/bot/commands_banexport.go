@@ -281,7 +281,7 @@ func (ch *CommandHandler) scanBansHandler(s *discordgo.Session, i *discordgo.Int
 			{Name: "Deleted Accounts", Value: strconv.Itoa(deletedUsers), Inline: true},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Use /exportbans to export the full list",
+			Text: "Use /banlist exportbans to export the full list",
 		},
 	}
 
@@ -0,0 +1,293 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerBirthdayCommands() {
+	months := make([]*discordgo.ApplicationCommandOptionChoice, 12)
+	for m := 1; m <= 12; m++ {
+		months[m-1] = &discordgo.ApplicationCommandOptionChoice{Name: time.Month(m).String(), Value: m}
+	}
+
+	ch.Register(&Command{
+		Name:        "birthday",
+		Description: "Birthday announcements",
+		Category:    "Birthdays",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Set your birthday (uses your /settimezone timezone)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "month",
+						Description: "Birth month",
+						Required:    true,
+						Choices:     months,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "day",
+						Description: "Day of the month",
+						Required:    true,
+						MinValue:    floatPtr(1),
+						MaxValue:    31,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "year",
+						Description: "Birth year (optional, used for {age})",
+						Required:    false,
+						MinValue:    floatPtr(1900),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove your birthday",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "view",
+				Description: "View someone's birthday",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "User to look up (defaults to you)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "upcoming",
+				Description: "List upcoming birthdays",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Configure birthday announcements (admin)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to announce birthdays in",
+						Required:    true,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+							discordgo.ChannelTypeGuildNews,
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to give members for 24 hours on their birthday",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "Announcement message ({user}, {username}, {age})",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Disable birthday announcements (admin)",
+			},
+		},
+		Handler: ch.birthdayHandler,
+	})
+}
+
+func (ch *CommandHandler) birthdayHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch getSubcommandName(i) {
+	case "set":
+		ch.birthdaySetHandler(s, i)
+	case "remove":
+		if err := ch.bot.DB.DeleteBirthday(i.GuildID, i.Member.User.ID); err != nil {
+			respondEphemeral(s, i, "Failed to remove your birthday.")
+			return
+		}
+		respondEphemeral(s, i, "Your birthday has been removed.")
+	case "view":
+		ch.birthdayViewHandler(s, i)
+	case "upcoming":
+		ch.birthdayUpcomingHandler(s, i)
+	case "setup", "disable":
+		ch.birthdayConfigHandler(s, i)
+	}
+}
+
+func (ch *CommandHandler) birthdaySetHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	month := int(getIntOption(i, "month"))
+	day := int(getIntOption(i, "day"))
+	year := int(getIntOption(i, "year"))
+
+	// 2000 is a leap year, so February 29 is accepted
+	if date := time.Date(2000, time.Month(month), day, 0, 0, 0, 0, time.UTC); date.Day() != day {
+		respondEphemeral(s, i, fmt.Sprintf("%s doesn't have %d days.", time.Month(month), day))
+		return
+	}
+	if year > 0 {
+		if year > time.Now().Year() {
+			respondEphemeral(s, i, "Your birth year can't be in the future.")
+			return
+		}
+		if month == 2 && day == 29 && time.Date(year, time.February, 29, 0, 0, 0, 0, time.UTC).Day() != 29 {
+			respondEphemeral(s, i, fmt.Sprintf("%d wasn't a leap year.", year))
+			return
+		}
+	}
+
+	if err := ch.bot.DB.SetBirthday(i.GuildID, i.Member.User.ID, month, day, year); err != nil {
+		respondEphemeral(s, i, "Failed to save your birthday.")
+		return
+	}
+
+	msg := fmt.Sprintf("Your birthday has been set to **%s %d**.", time.Month(month), day)
+	if tz, _ := ch.bot.DB.GetUserTimezone(i.Member.User.ID); tz != "" {
+		msg += fmt.Sprintf("\nIt will be announced at midnight in **%s**.", tz)
+	} else {
+		msg += "\nIt will be announced at midnight UTC. Use `/settimezone` to use your own timezone."
+	}
+	respondEphemeral(s, i, msg)
+}
+
+func (ch *CommandHandler) birthdayViewHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := getUserOption(i, "user")
+	if user == nil {
+		user = i.Member.User
+	}
+
+	bd, err := ch.bot.DB.GetBirthday(i.GuildID, user.ID)
+	if err != nil || bd == nil {
+		if user.ID == i.Member.User.ID {
+			respondEphemeral(s, i, "You haven't set your birthday. Use `/birthday set` to set it.")
+		} else {
+			respondEphemeral(s, i, fmt.Sprintf("**%s** hasn't set their birthday.", user.Username))
+		}
+		return
+	}
+
+	next := nextBirthday(*bd, time.Now())
+	embed := &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("🎂 %s's Birthday", user.Username),
+		Color:     0xFF69B4,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: avatarURL(user)},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Birthday", Value: formatBirthday(*bd), Inline: true},
+			{Name: "Next", Value: fmt.Sprintf("<t:%s:D>", formatUnixTime(next)), Inline: true},
+		},
+	}
+	if bd.Timezone != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Timezone", Value: bd.Timezone, Inline: true})
+	}
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) birthdayUpcomingHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	birthdays, err := ch.bot.DB.GetGuildBirthdays(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get birthdays.")
+		return
+	}
+	if len(birthdays) == 0 {
+		respondEphemeral(s, i, "No birthdays have been set on this server.")
+		return
+	}
+
+	now := time.Now()
+	sort.SliceStable(birthdays, func(a, b int) bool {
+		return nextBirthday(birthdays[a], now).Before(nextBirthday(birthdays[b], now))
+	})
+	if len(birthdays) > 15 {
+		birthdays = birthdays[:15]
+	}
+
+	var lines []string
+	for _, bd := range birthdays {
+		next := nextBirthday(bd, now)
+		lines = append(lines, fmt.Sprintf("<@%s> — %s %d (<t:%s:R>)", bd.UserID, next.Month(), next.Day(), formatUnixTime(next)))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎂 Upcoming Birthdays",
+		Description: strings.Join(lines, "\n"),
+		Color:       0xFF69B4,
+	}
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) birthdayConfigHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure birthdays.")
+		return
+	}
+
+	cfg, err := ch.bot.DB.GetBirthdayConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get birthday config.")
+		return
+	}
+
+	if getSubcommandName(i) == "disable" {
+		cfg.Enabled = false
+		if err := ch.bot.DB.SetBirthdayConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save birthday config.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Birthdays Disabled", "Birthday announcements have been disabled"))
+		return
+	}
+
+	cfg.Enabled = true
+	cfg.ChannelID = getChannelOption(i, "channel").ID
+	cfg.RoleID = ""
+	if role := getRoleOption(i, "role"); role != nil {
+		cfg.RoleID = role.ID
+	}
+	cfg.Message = getStringOption(i, "message")
+	if err := ch.bot.DB.SetBirthdayConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to save birthday config.")
+		return
+	}
+
+	desc := fmt.Sprintf("Birthdays will be announced in <#%s>", cfg.ChannelID)
+	if cfg.RoleID != "" {
+		desc += fmt.Sprintf("\nMembers will get <@&%s> for 24 hours", cfg.RoleID)
+	}
+	message := cfg.Message
+	if message == "" {
+		message = defaultBirthdayMessage
+	}
+	desc += "\nMessage: " + message
+	respondEmbed(s, i, successEmbed("Birthdays Enabled", desc))
+}
@@ -0,0 +1,236 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerBlocklistCommands() {
+	// Global blocklist opt-in
+	ch.Register(&Command{
+		Name:        "blocklist",
+		Description: "Configure the bot-wide blocklist of known raid and scam accounts",
+		Category:    "Anti-Raid",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "View blocklist configuration",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "enable",
+				Description: "Opt this server into the global blocklist",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Opt this server out of the global blocklist",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Configure blocklist action and notifications",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "action",
+						Description: "Action to take on blocklisted users",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Log only", Value: "log"},
+							{Name: "Kick", Value: "kick"},
+							{Name: "Ban", Value: "ban"},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel for staff notifications (defaults to automod log)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to ping when a blocklisted user is detected",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "check",
+				Description: "Check whether a user is on the global blocklist",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "User to check",
+						Required:    true,
+					},
+				},
+			},
+		},
+		Handler: ch.blocklistHandler,
+	})
+}
+
+func (ch *CommandHandler) blocklistHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure the blocklist.")
+		return
+	}
+
+	subCmd := i.ApplicationCommandData().Options[0].Name
+
+	switch subCmd {
+	case "status":
+		ch.blocklistStatusHandler(s, i)
+	case "enable", "disable":
+		ch.blocklistToggleHandler(s, i, subCmd == "enable")
+	case "set":
+		ch.blocklistSetHandler(s, i)
+	case "check":
+		ch.blocklistCheckHandler(s, i)
+	}
+}
+
+func (ch *CommandHandler) blocklistStatusHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg, err := ch.bot.DB.GetBlocklistConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get blocklist configuration.")
+		return
+	}
+
+	status := "Disabled"
+	if cfg.Enabled {
+		status = "Enabled"
+	}
+
+	logChannel := "Automod log channel"
+	if cfg.LogChannelID != "" {
+		logChannel = fmt.Sprintf("<#%s>", cfg.LogChannelID)
+	}
+
+	alertRole := "Not set"
+	if cfg.AlertRoleID != "" {
+		alertRole = fmt.Sprintf("<@&%s>", cfg.AlertRoleID)
+	}
+
+	entries, _ := ch.bot.DB.GetBotBans("user")
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Global Blocklist Configuration",
+		Color: 0xFF69B4,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: status, Inline: true},
+			{Name: "Action", Value: cfg.Action, Inline: true},
+			{Name: "Listed Users", Value: fmt.Sprintf("%d", len(entries)), Inline: true},
+			{Name: "Notify Channel", Value: logChannel, Inline: true},
+			{Name: "Alert Role", Value: alertRole, Inline: true},
+		},
+	}
+
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) blocklistToggleHandler(s *discordgo.Session, i *discordgo.InteractionCreate, enabled bool) {
+	cfg, _ := ch.bot.DB.GetBlocklistConfig(i.GuildID)
+	cfg.Enabled = enabled
+
+	if err := ch.bot.DB.SetBlocklistConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to update blocklist setting.")
+		return
+	}
+
+	if enabled {
+		respondEmbed(s, i, successEmbed("Blocklist Enabled",
+			fmt.Sprintf("This server now uses the global blocklist. Listed users will be handled with action: **%s**", cfg.Action)))
+	} else {
+		respondEmbed(s, i, successEmbed("Blocklist Disabled", "This server no longer uses the global blocklist."))
+	}
+}
+
+func (ch *CommandHandler) blocklistSetHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg, _ := ch.bot.DB.GetBlocklistConfig(i.GuildID)
+	opts := i.ApplicationCommandData().Options[0].Options
+
+	changes := []string{}
+
+	for _, opt := range opts {
+		switch opt.Name {
+		case "action":
+			cfg.Action = opt.StringValue()
+			changes = append(changes, fmt.Sprintf("Action: %s", cfg.Action))
+		case "channel":
+			cfg.LogChannelID = opt.ChannelValue(nil).ID
+			changes = append(changes, fmt.Sprintf("Notify channel: <#%s>", cfg.LogChannelID))
+		case "role":
+			cfg.AlertRoleID = opt.RoleValue(nil, "").ID
+			changes = append(changes, fmt.Sprintf("Alert role: <@&%s>", cfg.AlertRoleID))
+		}
+	}
+
+	if len(changes) == 0 {
+		respondEphemeral(s, i, "No settings provided.")
+		return
+	}
+
+	if err := ch.bot.DB.SetBlocklistConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to update settings.")
+		return
+	}
+
+	embed := successEmbed("Blocklist Updated", "Updated settings:\n- "+strings.Join(changes, "\n- "))
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) blocklistCheckHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := getUserOption(i, "user")
+	if user == nil {
+		respondEphemeral(s, i, "Please specify a user.")
+		return
+	}
+
+	entry, err := ch.bot.DB.GetBlocklistEntry(user.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to check the blocklist.")
+		return
+	}
+
+	if entry == nil {
+		respondEmbedEphemeral(s, i, infoEmbed("Not Listed", fmt.Sprintf("%s is not on the global blocklist.", user.Mention())))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Blocklisted User",
+		Description: fmt.Sprintf("%s (`%s`) is on the global blocklist", user.Mention(), user.ID),
+		Color:       0xFF0000,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Category", Value: entry.Category, Inline: true},
+			{Name: "Listed", Value: fmt.Sprintf("<t:%s:R>", formatUnixTime(entry.CreatedAt)), Inline: true},
+			{Name: "Reason", Value: truncate(entry.Reason, 1024), Inline: false},
+		},
+	}
+
+	respondEmbedEphemeral(s, i, embed)
+}
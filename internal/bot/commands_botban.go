@@ -52,6 +52,18 @@ func (ch *CommandHandler) registerBotBanCommands() {
 				Description: "Reason for the ban",
 				Required:    false,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "category",
+				Description: "Blocklist category (users are shared with opted-in servers)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Raid bot", Value: "raid"},
+					{Name: "Scam account", Value: "scam"},
+					{Name: "Spammer", Value: "spam"},
+					{Name: "Other", Value: "other"},
+				},
+			},
 		},
 		Handler: ch.botBanHandler,
 	})
@@ -94,7 +106,7 @@ func (ch *CommandHandler) registerBotBanCommands() {
 }
 
 func (ch *CommandHandler) botBanHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if !ch.bot.Config.IsOwner(i.Member.User.ID) {
+	if !ch.bot.Config().IsOwner(i.Member.User.ID) {
 		respondEphemeral(s, i, "Only bot owners can use bot-level bans.")
 		return
 	}
@@ -102,12 +114,16 @@ func (ch *CommandHandler) botBanHandler(s *discordgo.Session, i *discordgo.Inter
 	banType := getStringOption(i, "type")
 	targetID := getStringOption(i, "id")
 	reason := getStringOption(i, "reason")
+	category := getStringOption(i, "category")
 
 	if reason == "" {
 		reason = "No reason provided"
 	}
+	if category == "" {
+		category = "other"
+	}
 
-	err := ch.bot.DB.AddBotBan(targetID, banType, reason, i.Member.User.ID)
+	err := ch.bot.DB.AddBotBanWithCategory(targetID, banType, reason, i.Member.User.ID, category)
 	if err != nil {
 		respondEphemeral(s, i, "Failed to add bot ban.")
 		return
@@ -120,15 +136,19 @@ func (ch *CommandHandler) botBanHandler(s *discordgo.Session, i *discordgo.Inter
 
 	embed := &discordgo.MessageEmbed{
 		Title:       fmt.Sprintf("%s Bot Ban Added", emoji),
-		Description: fmt.Sprintf("**Type:** %s\n**ID:** `%s`\n**Reason:** %s", banType, targetID, reason),
+		Description: fmt.Sprintf("**Type:** %s\n**ID:** `%s`\n**Category:** %s\n**Reason:** %s", banType, targetID, category, reason),
 		Color:       0xFF0000,
 	}
 
+	if banType == "user" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "User added to the global blocklist"}
+	}
+
 	respondEmbed(s, i, embed)
 }
 
 func (ch *CommandHandler) botUnbanHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if !ch.bot.Config.IsOwner(i.Member.User.ID) {
+	if !ch.bot.Config().IsOwner(i.Member.User.ID) {
 		respondEphemeral(s, i, "Only bot owners can manage bot-level bans.")
 		return
 	}
@@ -147,7 +167,7 @@ func (ch *CommandHandler) botUnbanHandler(s *discordgo.Session, i *discordgo.Int
 }
 
 func (ch *CommandHandler) botBanListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if !ch.bot.Config.IsOwner(i.Member.User.ID) {
+	if !ch.bot.Config().IsOwner(i.Member.User.ID) {
 		respondEphemeral(s, i, "Only bot owners can view bot-level bans.")
 		return
 	}
@@ -167,7 +187,7 @@ func (ch *CommandHandler) botBanListHandler(s *discordgo.Session, i *discordgo.I
 
 	var userBans, serverBans []string
 	for _, ban := range bans {
-		entry := fmt.Sprintf("`%s` - %s", ban.TargetID, truncate(ban.Reason, 50))
+		entry := fmt.Sprintf("`%s` [%s] - %s", ban.TargetID, ban.Category, truncate(ban.Reason, 50))
 		if ban.BanType == "user" {
 			userBans = append(userBans, entry)
 		} else {
@@ -57,7 +57,7 @@ func (ch *CommandHandler) registerDMCommands() {
 }
 
 func (ch *CommandHandler) setDMChannelHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if !ch.bot.Config.IsOwner(i.Member.User.ID) {
+	if !ch.bot.Config().IsOwner(i.Member.User.ID) {
 		respondEphemeral(s, i, "Only bot owners can configure DM forwarding.")
 		return
 	}
@@ -80,7 +80,7 @@ func (ch *CommandHandler) setDMChannelHandler(s *discordgo.Session, i *discordgo
 }
 
 func (ch *CommandHandler) disableDMHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if !ch.bot.Config.IsOwner(i.Member.User.ID) {
+	if !ch.bot.Config().IsOwner(i.Member.User.ID) {
 		respondEphemeral(s, i, "Only bot owners can configure DM forwarding.")
 		return
 	}
@@ -0,0 +1,436 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerEconomyCommands() {
+	// Balance
+	ch.Register(&Command{
+		Name:        "balance",
+		Description: "Check your or another member's balance",
+		Category:    "Economy",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "Member to check (defaults to yourself)",
+				Required:    false,
+			},
+		},
+		Handler: ch.balanceHandler,
+	})
+
+	// Daily reward
+	ch.Register(&Command{
+		Name:        "daily",
+		Description: "Claim your daily reward",
+		Category:    "Economy",
+		Handler:     ch.dailyHandler,
+	})
+
+	// Work
+	ch.Register(&Command{
+		Name:        "work",
+		Description: "Work to earn some currency",
+		Category:    "Economy",
+		Handler:     ch.workHandler,
+	})
+
+	// Pay
+	ch.Register(&Command{
+		Name:        "pay",
+		Description: "Send currency to another member",
+		Category:    "Economy",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "Member to pay",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "amount",
+				Description: "Amount to send",
+				Required:    true,
+				MinValue:    floatPtr(1),
+			},
+		},
+		Handler: ch.payHandler,
+	})
+
+	// Richest
+	ch.Register(&Command{
+		Name:        "richest",
+		Description: "View the server's richest members",
+		Category:    "Economy",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "page",
+				Description: "Page number",
+				Required:    false,
+				MinValue:    floatPtr(1),
+			},
+		},
+		Handler: ch.richestHandler,
+	})
+
+	// Economy settings (Admin)
+	amountOptions := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "Member",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "amount",
+			Description: "Amount",
+			Required:    true,
+			MinValue:    floatPtr(1),
+		},
+	}
+	ch.Register(&Command{
+		Name:        "economy",
+		Description: "Configure the server economy",
+		Category:    "Economy",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "settings",
+				Description: "View or change economy settings",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionBoolean, Name: "enabled", Description: "Enable the economy"},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "currency_name", Description: "Currency name (e.g. coins)", MaxLength: 32},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "currency_symbol", Description: "Currency symbol or emoji", MaxLength: 64},
+					{Type: discordgo.ApplicationCommandOptionInteger, Name: "daily", Description: "Daily reward", MinValue: floatPtr(0)},
+					{Type: discordgo.ApplicationCommandOptionInteger, Name: "work_min", Description: "Minimum /work payout", MinValue: floatPtr(0)},
+					{Type: discordgo.ApplicationCommandOptionInteger, Name: "work_max", Description: "Maximum /work payout", MinValue: floatPtr(0)},
+					{Type: discordgo.ApplicationCommandOptionInteger, Name: "work_cooldown", Description: "Minutes between /work", MinValue: floatPtr(1)},
+					{Type: discordgo.ApplicationCommandOptionInteger, Name: "chat_amount", Description: "Paid per message (0 to disable)", MinValue: floatPtr(0)},
+					{Type: discordgo.ApplicationCommandOptionInteger, Name: "chat_cooldown", Description: "Seconds between chat payouts", MinValue: floatPtr(0)},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "give",
+				Description: "Give currency to a member",
+				Options:     amountOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "take",
+				Description: "Take currency from a member",
+				Options:     amountOptions,
+			},
+		},
+		Handler: ch.economyHandler,
+	})
+}
+
+// economyConfig returns the guild's economy config, responding with an error if it is unavailable
+func (ch *CommandHandler) economyConfig(s *discordgo.Session, i *discordgo.InteractionCreate) *database.EconomyConfig {
+	cfg, err := ch.bot.DB.GetEconomyConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get economy settings.")
+		return nil
+	}
+	if !cfg.Enabled {
+		respondEphemeral(s, i, "The economy is disabled on this server.")
+		return nil
+	}
+	return cfg
+}
+
+func (ch *CommandHandler) balanceHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg := ch.economyConfig(s, i)
+	if cfg == nil {
+		return
+	}
+
+	user := getUserOption(i, "user")
+	if user == nil {
+		user = i.Member.User
+	}
+	account, err := ch.bot.DB.GetEconomyAccount(i.GuildID, user.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get balance.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("%s's Balance", user.Username),
+		Color:     0xF1C40F,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: avatarURL(user)},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Balance", Value: formatCurrency(cfg, account.Balance), Inline: true},
+			{Name: "Daily Streak", Value: fmt.Sprintf("%d day(s)", account.DailyStreak), Inline: true},
+		},
+	}
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) dailyHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg := ch.economyConfig(s, i)
+	if cfg == nil {
+		return
+	}
+
+	current, err := ch.bot.DB.GetEconomyAccount(i.GuildID, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to claim daily reward.")
+		return
+	}
+	// The streak continues if the last claim was within two days
+	streak := 1
+	if time.Since(time.UnixMilli(current.LastDaily)) < 48*time.Hour {
+		streak = current.DailyStreak + 1
+	}
+	amount := dailyReward(cfg, streak)
+
+	account, err := ch.bot.DB.ClaimReward(i.GuildID, i.Member.User.ID, "daily", amount, 24*time.Hour)
+	if errors.Is(err, database.ErrOnCooldown) {
+		next := time.UnixMilli(account.LastDaily).Add(24 * time.Hour)
+		respondEphemeral(s, i, fmt.Sprintf("You've already claimed your daily reward. Come back <t:%s:R>.", formatUnixTime(next)))
+		return
+	}
+	if err != nil {
+		respondEphemeral(s, i, "Failed to claim daily reward.")
+		return
+	}
+
+	desc := fmt.Sprintf("You claimed %s!\nBalance: %s", formatCurrency(cfg, amount), formatCurrency(cfg, account.Balance))
+	if account.DailyStreak > 1 {
+		desc += fmt.Sprintf("\n🔥 %d day streak", account.DailyStreak)
+	}
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "Daily Reward",
+		Description: desc,
+		Color:       0xF1C40F,
+	})
+}
+
+func (ch *CommandHandler) workHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg := ch.economyConfig(s, i)
+	if cfg == nil {
+		return
+	}
+
+	amount := cfg.WorkMin
+	if cfg.WorkMax > cfg.WorkMin {
+		amount += rand.Int63n(cfg.WorkMax - cfg.WorkMin + 1)
+	}
+	cooldown := time.Duration(cfg.WorkCooldownMins) * time.Minute
+
+	account, err := ch.bot.DB.ClaimReward(i.GuildID, i.Member.User.ID, "work", amount, cooldown)
+	if errors.Is(err, database.ErrOnCooldown) {
+		next := time.UnixMilli(account.LastWork).Add(cooldown)
+		respondEphemeral(s, i, fmt.Sprintf("You're tired. You can work again <t:%s:R>.", formatUnixTime(next)))
+		return
+	}
+	if err != nil {
+		respondEphemeral(s, i, "Failed to work.")
+		return
+	}
+
+	message := fmt.Sprintf(workMessages[rand.Intn(len(workMessages))], formatCurrency(cfg, amount))
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "Work",
+		Description: fmt.Sprintf("%s\nBalance: %s", message, formatCurrency(cfg, account.Balance)),
+		Color:       0xF1C40F,
+	})
+}
+
+func (ch *CommandHandler) payHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg := ch.economyConfig(s, i)
+	if cfg == nil {
+		return
+	}
+
+	user := getUserOption(i, "user")
+	amount := getIntOption(i, "amount")
+	if user == nil || amount <= 0 {
+		respondEphemeral(s, i, "Please provide a member and a positive amount.")
+		return
+	}
+	if user.ID == i.Member.User.ID {
+		respondEphemeral(s, i, "You can't pay yourself.")
+		return
+	}
+	if user.Bot {
+		respondEphemeral(s, i, "You can't pay bots.")
+		return
+	}
+
+	err := ch.bot.DB.TransferBalance(i.GuildID, i.Member.User.ID, user.ID, amount)
+	if errors.Is(err, database.ErrInsufficientFunds) {
+		respondEphemeral(s, i, "You don't have enough to send that much.")
+		return
+	}
+	if err != nil {
+		respondEphemeral(s, i, "Failed to send payment.")
+		return
+	}
+
+	respondEmbed(s, i, successEmbed("Payment Sent", fmt.Sprintf("You sent %s to <@%s>", formatCurrency(cfg, amount), user.ID)))
+}
+
+func (ch *CommandHandler) richestHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg := ch.economyConfig(s, i)
+	if cfg == nil {
+		return
+	}
+
+	page := max(int(getIntOption(i, "page")), 1)
+	perPage := 10
+	accounts, err := ch.bot.DB.GetEconomyLeaderboard(i.GuildID, perPage, (page-1)*perPage)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get leaderboard.")
+		return
+	}
+	if len(accounts) == 0 {
+		respondEphemeral(s, i, "Nobody has earned anything yet.")
+		return
+	}
+
+	var description strings.Builder
+	for idx, a := range accounts {
+		rank := (page-1)*perPage + idx + 1
+		medal := ""
+		switch rank {
+		case 1:
+			medal = " :first_place:"
+		case 2:
+			medal = " :second_place:"
+		case 3:
+			medal = " :third_place:"
+		}
+		description.WriteString(fmt.Sprintf("**#%d**%s <@%s> - %s\n", rank, medal, a.UserID, formatCurrency(cfg, a.Balance)))
+	}
+
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "Richest Members",
+		Description: description.String(),
+		Color:       0xF1C40F,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d", page)},
+	})
+}
+
+func (ch *CommandHandler) economyHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage the economy.")
+		return
+	}
+
+	cfg, err := ch.bot.DB.GetEconomyConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get economy settings.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "settings":
+		ch.economySettingsHandler(s, i, cfg)
+
+	case "give", "take":
+		user := getUserOption(i, "user")
+		amount := getIntOption(i, "amount")
+		kind := "admin_give"
+		if getSubcommandName(i) == "take" {
+			amount = -amount
+			kind = "admin_take"
+		}
+
+		balance, err := ch.bot.DB.AdjustBalance(i.GuildID, user.ID, amount, kind)
+		if errors.Is(err, database.ErrInsufficientFunds) {
+			respondEphemeral(s, i, fmt.Sprintf("<@%s> only has %s.", user.ID, formatCurrency(cfg, balance)))
+			return
+		}
+		if err != nil {
+			respondEphemeral(s, i, "Failed to update balance.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Balance Updated",
+			fmt.Sprintf("<@%s> now has %s", user.ID, formatCurrency(cfg, balance))))
+	}
+}
+
+func (ch *CommandHandler) economySettingsHandler(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *database.EconomyConfig) {
+	options := getOptions(i)
+	for _, opt := range options {
+		switch opt.Name {
+		case "enabled":
+			cfg.Enabled = opt.BoolValue()
+		case "currency_name":
+			cfg.CurrencyName = opt.StringValue()
+		case "currency_symbol":
+			cfg.CurrencySymbol = opt.StringValue()
+		case "daily":
+			cfg.DailyAmount = opt.IntValue()
+		case "work_min":
+			cfg.WorkMin = opt.IntValue()
+		case "work_max":
+			cfg.WorkMax = opt.IntValue()
+		case "work_cooldown":
+			cfg.WorkCooldownMins = int(opt.IntValue())
+		case "chat_amount":
+			cfg.ChatAmount = opt.IntValue()
+		case "chat_cooldown":
+			cfg.ChatCooldownSecs = int(opt.IntValue())
+		}
+	}
+	if cfg.WorkMax < cfg.WorkMin {
+		respondEphemeral(s, i, "The maximum work payout can't be lower than the minimum.")
+		return
+	}
+
+	if len(options) > 0 {
+		if err := ch.bot.DB.SetEconomyConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save economy settings.")
+			return
+		}
+	}
+
+	status := "Disabled"
+	if cfg.Enabled {
+		status = "Enabled"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "Economy Settings",
+		Color: 0xF1C40F,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: status, Inline: true},
+			{Name: "Currency", Value: fmt.Sprintf("%s %s", cfg.CurrencySymbol, cfg.CurrencyName), Inline: true},
+			{Name: "Daily", Value: formatCurrency(cfg, cfg.DailyAmount), Inline: true},
+			{Name: "Work", Value: fmt.Sprintf("%d-%d every %d min", cfg.WorkMin, cfg.WorkMax, cfg.WorkCooldownMins), Inline: true},
+			{Name: "Chat", Value: fmt.Sprintf("%d every %ds", cfg.ChatAmount, cfg.ChatCooldownSecs), Inline: true},
+		},
+	}
+	respondEmbed(s, i, embed)
+}
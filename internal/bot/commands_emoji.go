@@ -0,0 +1,426 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxEmojiSize is Discord's upload limit for a single emoji
+const maxEmojiSize = 256 * 1024
+
+// emojiNamePattern matches names Discord accepts for custom emojis
+var emojiNamePattern = regexp.MustCompile(`^\w{2,32}$`)
+
+// emojiNameInvalid matches runs of characters emoji names can't contain
+var emojiNameInvalid = regexp.MustCompile(`\W+`)
+
+// maxEmojiUploads is how many attachment options /emoji upload has
+const maxEmojiUploads = 5
+
+func (ch *CommandHandler) registerEmojiCommands() {
+	emojiOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "emoji",
+		Description:  "Emoji on this server",
+		Required:     true,
+		Autocomplete: true,
+	}
+
+	uploadOptions := []*discordgo.ApplicationCommandOption{}
+	for n := 1; n <= maxEmojiUploads; n++ {
+		uploadOptions = append(uploadOptions, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionAttachment,
+			Name:        fmt.Sprintf("file%d", n),
+			Description: "Image to add; the file name becomes the emoji name",
+			Required:    n == 1,
+		})
+	}
+
+	ch.Register(&Command{
+		Name:        "emoji",
+		Description: "Manage this server's emojis",
+		Category:    "Utility",
+		SlashOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "steal",
+				Description: "Add an emoji from another server or an image URL",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "Custom emoji to copy (paste it) or an image URL",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name for the emoji (default: the original name)",
+						Required:    false,
+						MaxLength:   32,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "upload",
+				Description: "Add emojis from uploaded images",
+				Options:     uploadOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "rename",
+				Description: "Rename an emoji",
+				Options: []*discordgo.ApplicationCommandOption{
+					emojiOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "New name",
+						Required:    true,
+						MaxLength:   32,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete an emoji",
+				Options:     []*discordgo.ApplicationCommandOption{emojiOption},
+			},
+		},
+		Handler:      ch.emojiHandler,
+		Autocomplete: ch.emojiAutocomplete,
+	})
+}
+
+func (ch *CommandHandler) emojiHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Emojis can only be managed in a server.")
+		return
+	}
+	if !hasPermission(s, i.GuildID, i.Member.User.ID, discordgo.PermissionManageEmojis) {
+		respondEphemeral(s, i, "You need Manage Expressions permission to manage emojis.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "steal":
+		ch.emojiStealHandler(s, i)
+	case "upload":
+		ch.emojiUploadHandler(s, i)
+	case "rename":
+		ch.emojiRenameHandler(s, i)
+	case "delete":
+		ch.emojiDeleteHandler(s, i)
+	}
+}
+
+func (ch *CommandHandler) emojiStealHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	input := strings.TrimSpace(getStringOption(i, "emoji"))
+	name := getStringOption(i, "name")
+
+	var url string
+	if m := customEmojiPattern.FindStringSubmatch(input); m != nil {
+		ext := "png"
+		if strings.HasPrefix(input, "<a:") {
+			ext = "gif"
+		}
+		url = fmt.Sprintf("https://cdn.discordapp.com/emojis/%s.%s", m[2], ext)
+		if name == "" {
+			name = m[1]
+		}
+	} else if strings.HasPrefix(input, "https://") || strings.HasPrefix(input, "http://") {
+		url = input
+		if name == "" {
+			name = emojiNameFromFile(path.Base(strings.SplitN(input, "?", 2)[0]))
+		}
+	} else {
+		respondEphemeral(s, i, "Please paste a custom emoji (not a default one) or an image URL.")
+		return
+	}
+	if !emojiNamePattern.MatchString(name) {
+		respondEphemeral(s, i, "Emoji names must be 2-32 characters of letters, numbers and underscores.")
+		return
+	}
+
+	respondDeferred(s, i)
+
+	emoji, err := addEmojiFromURL(s, i.GuildID, name, url)
+	if err != nil {
+		editResponse(s, i, "Failed to add emoji: "+err.Error())
+		return
+	}
+	editResponse(s, i, fmt.Sprintf("Added %s as `:%s:`", emoji.MessageFormat(), emoji.Name))
+}
+
+func (ch *CommandHandler) emojiUploadHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	var files []*discordgo.MessageAttachment
+	for _, opt := range getOptions(i) {
+		if id, ok := opt.Value.(string); ok && strings.HasPrefix(opt.Name, "file") && data.Resolved != nil {
+			if a := data.Resolved.Attachments[id]; a != nil {
+				files = append(files, a)
+			}
+		}
+	}
+	if len(files) == 0 {
+		respondEphemeral(s, i, "Please attach at least one image.")
+		return
+	}
+
+	respondDeferred(s, i)
+
+	var added, failed []string
+	for idx, a := range files {
+		name := emojiNameFromFile(a.Filename)
+		emoji, err := addEmojiFromURL(s, i.GuildID, name, a.URL)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("`%s`: %s", a.Filename, err.Error()))
+			if errors.Is(err, errEmojiSlotsFull) {
+				// The rest would fail the same way
+				for _, rest := range files[idx+1:] {
+					failed = append(failed, fmt.Sprintf("`%s`: skipped", rest.Filename))
+				}
+				break
+			}
+			continue
+		}
+		added = append(added, fmt.Sprintf("%s `:%s:`", emoji.MessageFormat(), emoji.Name))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Added %d of %d Emojis", len(added), len(files)),
+		Color: 0x57F287,
+	}
+	if len(added) == 0 {
+		embed.Color = 0xED4245
+	}
+	if len(added) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Added", Value: strings.Join(added, "\n")})
+	}
+	if len(failed) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Failed", Value: truncate(strings.Join(failed, "\n"), 1024)})
+	}
+	editResponseEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) emojiRenameHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	emoji := findGuildEmoji(s, i.GuildID, getStringOption(i, "emoji"))
+	if emoji == nil {
+		respondEphemeral(s, i, "That emoji isn't on this server.")
+		return
+	}
+	name := getStringOption(i, "name")
+	if !emojiNamePattern.MatchString(name) {
+		respondEphemeral(s, i, "Emoji names must be 2-32 characters of letters, numbers and underscores.")
+		return
+	}
+
+	oldName := emoji.Name
+	emoji, err := s.GuildEmojiEdit(i.GuildID, emoji.ID, &discordgo.EmojiParams{Name: name})
+	if err != nil {
+		respondEphemeral(s, i, "Failed to rename emoji: "+err.Error())
+		return
+	}
+	respondEmbed(s, i, successEmbed("Emoji Renamed", fmt.Sprintf("%s `:%s:` is now `:%s:`", emoji.MessageFormat(), oldName, emoji.Name)))
+}
+
+func (ch *CommandHandler) emojiDeleteHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	emoji := findGuildEmoji(s, i.GuildID, getStringOption(i, "emoji"))
+	if emoji == nil {
+		respondEphemeral(s, i, "That emoji isn't on this server.")
+		return
+	}
+	if err := s.GuildEmojiDelete(i.GuildID, emoji.ID); err != nil {
+		respondEphemeral(s, i, "Failed to delete emoji: "+err.Error())
+		return
+	}
+	respondEmbed(s, i, successEmbed("Emoji Deleted", fmt.Sprintf("`:%s:` has been deleted.", emoji.Name)))
+}
+
+func (ch *CommandHandler) emojiAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	typed := ""
+	for _, opt := range getOptions(i) {
+		if opt.Focused {
+			typed = strings.ToLower(strings.Trim(strings.TrimSpace(fmt.Sprint(opt.Value)), ":"))
+		}
+	}
+
+	emojis, err := s.GuildEmojis(i.GuildID)
+	if err != nil {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, e := range emojis {
+		if typed != "" && !strings.Contains(strings.ToLower(e.Name), typed) {
+			continue
+		}
+		label := ":" + e.Name + ":"
+		if e.Animated {
+			label += " (animated)"
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: label, Value: e.ID})
+		if len(choices) == 25 {
+			break
+		}
+	}
+	respondAutocomplete(s, i, choices)
+}
+
+// findGuildEmoji looks up a guild emoji by ID (from autocomplete), pasted emoji or name
+func findGuildEmoji(s *discordgo.Session, guildID, input string) *discordgo.Emoji {
+	input = strings.TrimSpace(input)
+	if m := customEmojiPattern.FindStringSubmatch(input); m != nil {
+		input = m[2]
+	}
+	input = strings.Trim(input, ":")
+
+	emojis, err := s.GuildEmojis(guildID)
+	if err != nil {
+		return nil
+	}
+	for _, e := range emojis {
+		if e.ID == input {
+			return e
+		}
+	}
+	for _, e := range emojis {
+		if strings.EqualFold(e.Name, input) {
+			return e
+		}
+	}
+	return nil
+}
+
+// emojiNameFromFile turns a file name into a valid emoji name
+func emojiNameFromFile(filename string) string {
+	name := strings.TrimSuffix(filename, path.Ext(filename))
+	name = emojiNameInvalid.ReplaceAllString(name, "_")
+	name = strings.Trim(name, "_")
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	for len(name) < 2 {
+		name += "_"
+	}
+	return name
+}
+
+// errEmojiSlotsFull is returned when a guild has no room for another emoji of that kind
+var errEmojiSlotsFull = errors.New("no emoji slots left")
+
+// emojiSlots is how many static and how many animated emojis a guild can have at its boost tier
+func emojiSlots(tier discordgo.PremiumTier) int {
+	switch tier {
+	case discordgo.PremiumTier1:
+		return 100
+	case discordgo.PremiumTier2:
+		return 150
+	case discordgo.PremiumTier3:
+		return 250
+	}
+	return 50
+}
+
+// addEmojiFromURL downloads an image and adds it to the guild as an emoji, with
+// errors worded for the member who asked
+func addEmojiFromURL(s *discordgo.Session, guildID, name, url string) (*discordgo.Emoji, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, errors.New("couldn't download the image")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("couldn't download the image (HTTP %d)", resp.StatusCode)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxEmojiSize+1))
+	if err != nil {
+		return nil, errors.New("couldn't download the image")
+	}
+	if len(image) > maxEmojiSize {
+		return nil, fmt.Errorf("the image is over Discord's %d KB emoji limit", maxEmojiSize/1024)
+	}
+
+	contentType := http.DetectContentType(image)
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		return nil, errors.New("that isn't a PNG, JPEG, GIF or WebP image")
+	}
+	animated := contentType == "image/gif"
+
+	// Check for room first so a full server gets a clear answer
+	if guild, err := s.State.Guild(guildID); err == nil {
+		if emojis, err := s.GuildEmojis(guildID); err == nil {
+			used := 0
+			for _, e := range emojis {
+				if e.Animated == animated {
+					used++
+				}
+			}
+			if limit := emojiSlots(guild.PremiumTier); used >= limit {
+				return nil, emojiSlotsFullError(animated, limit)
+			}
+		}
+	}
+
+	emoji, err := s.GuildEmojiCreate(guildID, &discordgo.EmojiParams{
+		Name:  name,
+		Image: fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(image)),
+	})
+	if err != nil {
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Message != nil {
+			switch restErr.Message.Code {
+			case discordgo.ErrCodeMaximumNumberOfEmojisReached:
+				return nil, emojiSlotsFullError(false, 0)
+			case discordgo.ErrCodeMaximumNumberOfAnimatedEmojisReached:
+				return nil, emojiSlotsFullError(true, 0)
+			}
+			return nil, errors.New(restErr.Message.Message)
+		}
+		return nil, err
+	}
+	return emoji, nil
+}
+
+// emojiSlotsFullError wraps errEmojiSlotsFull with which slots ran out
+func emojiSlotsFullError(animated bool, limit int) error {
+	kind := "static"
+	if animated {
+		kind = "animated"
+	}
+	if limit > 0 {
+		return fmt.Errorf("%w: the server has used all %d %s emoji slots. Delete some with `/emoji delete` or boost the server for more", errEmojiSlotsFull, limit, kind)
+	}
+	return fmt.Errorf("%w: the server has used all of its %s emoji slots. Delete some with `/emoji delete` or boost the server for more", errEmojiSlotsFull, kind)
+}
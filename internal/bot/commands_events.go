@@ -0,0 +1,412 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerGuildEventCommands() {
+	eventOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "event",
+		Description:  "The event",
+		Required:     true,
+		Autocomplete: true,
+	}
+
+	ch.Register(&Command{
+		Name:        "event",
+		Description: "Create and manage Discord scheduled events",
+		Category:    "Events",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Create a scheduled event",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Event name",
+						Required:    true,
+						MaxLength:   100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "start",
+						Description: "Start time: a delay like 2h, or YYYY-MM-DD HH:MM in your /settimezone timezone",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "Event description",
+						Required:    false,
+						MaxLength:   1000,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Voice or stage channel to host the event in",
+						Required:    false,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildVoice,
+							discordgo.ChannelTypeGuildStageVoice,
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "location",
+						Description: "External location (used when no channel is given)",
+						Required:    false,
+						MaxLength:   100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "duration",
+						Description: "How long the event lasts (e.g. 1h30m, default 1h for external events)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List upcoming events",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "start",
+				Description: "Start an event now",
+				Options:     []*discordgo.ApplicationCommandOption{eventOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "end",
+				Description: "End an active event",
+				Options:     []*discordgo.ApplicationCommandOption{eventOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "cancel",
+				Description: "Cancel a scheduled event",
+				Options:     []*discordgo.ApplicationCommandOption{eventOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Announce events and post reminders in a channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Announcement channel",
+						Required:    true,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+							discordgo.ChannelTypeGuildNews,
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "reminder",
+						Description: "Minutes before start to post a reminder (0 to disable, default 15)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    10080,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "ping_role",
+						Description: "Role to ping when an event is announced",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Stop announcing events",
+			},
+		},
+		Handler:      ch.guildEventHandler,
+		Autocomplete: ch.guildEventAutocomplete,
+	})
+}
+
+func (ch *CommandHandler) guildEventHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := getSubcommandName(i)
+	if subcommand == "list" {
+		ch.guildEventListHandler(s, i)
+		return
+	}
+
+	if !hasPermission(s, i.GuildID, i.Member.User.ID, discordgo.PermissionManageEvents) {
+		respondEphemeral(s, i, "You need the Manage Events permission to use this command.")
+		return
+	}
+
+	switch subcommand {
+	case "create":
+		ch.guildEventCreateHandler(s, i)
+	case "start", "end", "cancel":
+		ch.guildEventStatusHandler(s, i, subcommand)
+	case "setup", "disable":
+		ch.guildEventConfigHandler(s, i, subcommand)
+	}
+}
+
+// parseEventTime parses a relative delay or an absolute time in the given location
+func parseEventTime(input string, loc *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if d, err := parseDuration(input); err == nil && d > 0 {
+		return time.Now().Add(d), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q", input)
+}
+
+func (ch *CommandHandler) guildEventCreateHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	loc := time.UTC
+	if tz, _ := ch.bot.DB.GetUserTimezone(i.Member.User.ID); tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+
+	start, err := parseEventTime(getStringOption(i, "start"), loc)
+	if err != nil {
+		respondEphemeral(s, i, "Invalid start time. Use a delay like `2h` or a date like `2025-06-01 18:00`.")
+		return
+	}
+	if !start.After(time.Now()) {
+		respondEphemeral(s, i, "The start time must be in the future.")
+		return
+	}
+
+	params := &discordgo.GuildScheduledEventParams{
+		Name:               getStringOption(i, "name"),
+		Description:        getStringOption(i, "description"),
+		ScheduledStartTime: &start,
+		PrivacyLevel:       discordgo.GuildScheduledEventPrivacyLevelGuildOnly,
+	}
+
+	var duration time.Duration
+	if durationStr := getStringOption(i, "duration"); durationStr != "" {
+		duration, err = parseDuration(durationStr)
+		if err != nil || duration <= 0 {
+			respondEphemeral(s, i, "Invalid duration. Use a format like `1h30m`.")
+			return
+		}
+	}
+
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		params.ChannelID = channel.ID
+		params.EntityType = discordgo.GuildScheduledEventEntityTypeVoice
+		if c, err := s.State.Channel(channel.ID); err == nil && c.Type == discordgo.ChannelTypeGuildStageVoice {
+			params.EntityType = discordgo.GuildScheduledEventEntityTypeStageInstance
+		}
+	} else {
+		location := getStringOption(i, "location")
+		if location == "" {
+			respondEphemeral(s, i, "Please provide either a channel or a location.")
+			return
+		}
+		params.EntityType = discordgo.GuildScheduledEventEntityTypeExternal
+		params.EntityMetadata = &discordgo.GuildScheduledEventEntityMetadata{Location: location}
+		if duration == 0 {
+			duration = time.Hour
+		}
+	}
+	if duration > 0 {
+		end := start.Add(duration)
+		params.ScheduledEndTime = &end
+	}
+
+	ev, err := s.GuildScheduledEventCreate(i.GuildID, params)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Failed to create event: %v", err))
+		return
+	}
+
+	// Announcement and reminders are handled by the GUILD_SCHEDULED_EVENT_CREATE handler
+	respondEmbed(s, i, successEmbed("Event Created",
+		fmt.Sprintf("[%s](%s) starts <t:%s:F>", ev.Name, guildEventURL(ev), formatUnixTime(ev.ScheduledStartTime))))
+}
+
+// findGuildEvent resolves an event by ID or case-insensitive name
+func findGuildEvent(s *discordgo.Session, guildID, query string) *discordgo.GuildScheduledEvent {
+	events, err := s.GuildScheduledEvents(guildID, false)
+	if err != nil {
+		return nil
+	}
+	for _, ev := range events {
+		if ev.ID == query || strings.EqualFold(ev.Name, query) {
+			return ev
+		}
+	}
+	return nil
+}
+
+func (ch *CommandHandler) guildEventStatusHandler(s *discordgo.Session, i *discordgo.InteractionCreate, action string) {
+	ev := findGuildEvent(s, i.GuildID, getStringOption(i, "event"))
+	if ev == nil {
+		respondEphemeral(s, i, "Event not found.")
+		return
+	}
+
+	var status discordgo.GuildScheduledEventStatus
+	var verb string
+	switch action {
+	case "start":
+		if ev.Status != discordgo.GuildScheduledEventStatusScheduled {
+			respondEphemeral(s, i, "Only scheduled events can be started.")
+			return
+		}
+		status, verb = discordgo.GuildScheduledEventStatusActive, "started"
+	case "end":
+		if ev.Status != discordgo.GuildScheduledEventStatusActive {
+			respondEphemeral(s, i, "Only active events can be ended.")
+			return
+		}
+		status, verb = discordgo.GuildScheduledEventStatusCompleted, "ended"
+	case "cancel":
+		if ev.Status != discordgo.GuildScheduledEventStatusScheduled {
+			respondEphemeral(s, i, "Only scheduled events can be cancelled.")
+			return
+		}
+		status, verb = discordgo.GuildScheduledEventStatusCanceled, "cancelled"
+	}
+
+	if _, err := s.GuildScheduledEventEdit(i.GuildID, ev.ID, &discordgo.GuildScheduledEventParams{Status: status}); err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Failed to update event: %v", err))
+		return
+	}
+	respondEmbed(s, i, successEmbed("Event Updated", fmt.Sprintf("**%s** has been %s", ev.Name, verb)))
+}
+
+func (ch *CommandHandler) guildEventListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	events, err := s.GuildScheduledEvents(i.GuildID, true)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get events.")
+		return
+	}
+	if len(events) == 0 {
+		respondEphemeral(s, i, "There are no upcoming events.")
+		return
+	}
+
+	var lines []string
+	for _, ev := range events {
+		line := fmt.Sprintf("**[%s](%s)** — <t:%s:R> • %s • %d interested",
+			ev.Name, guildEventURL(ev), formatUnixTime(ev.ScheduledStartTime), guildEventLocation(ev), ev.UserCount)
+		if ev.Status == discordgo.GuildScheduledEventStatusActive {
+			line = "🔴 " + line
+		}
+		lines = append(lines, line)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📅 Events",
+		Description: truncate(strings.Join(lines, "\n"), 4096),
+		Color:       0x5865F2,
+	}
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) guildEventConfigHandler(s *discordgo.Session, i *discordgo.InteractionCreate, action string) {
+	cfg, err := ch.bot.DB.GetGuildEventConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get event config.")
+		return
+	}
+
+	if action == "disable" {
+		cfg.Enabled = false
+		if err := ch.bot.DB.SetGuildEventConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save event config.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Event Announcements Disabled", "Events will no longer be announced"))
+		return
+	}
+
+	cfg.Enabled = true
+	cfg.ChannelID = getChannelOption(i, "channel").ID
+	cfg.PingRoleID = ""
+	if role := getRoleOption(i, "ping_role"); role != nil {
+		cfg.PingRoleID = role.ID
+	}
+	cfg.ReminderMinutes = 15
+	for _, opt := range getOptions(i) {
+		if opt.Name == "reminder" {
+			cfg.ReminderMinutes = int(opt.IntValue())
+		}
+	}
+	if err := ch.bot.DB.SetGuildEventConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to save event config.")
+		return
+	}
+
+	// Schedule reminders for events that already exist
+	if events, err := s.GuildScheduledEvents(i.GuildID, false); err == nil {
+		for _, ev := range events {
+			ch.bot.scheduleGuildEventReminder(cfg, ev)
+		}
+	}
+
+	desc := fmt.Sprintf("New events will be announced in <#%s>", cfg.ChannelID)
+	if cfg.ReminderMinutes > 0 {
+		desc += fmt.Sprintf("\nReminders are posted %d minute(s) before start", cfg.ReminderMinutes)
+	}
+	if cfg.PingRoleID != "" {
+		desc += fmt.Sprintf("\nAnnouncements ping <@&%s>", cfg.PingRoleID)
+	}
+	respondEmbed(s, i, successEmbed("Event Announcements Enabled", desc))
+}
+
+func (ch *CommandHandler) guildEventAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	events, err := s.GuildScheduledEvents(i.GuildID, false)
+	if err != nil {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	query := strings.ToLower(getStringOption(i, "event"))
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, ev := range events {
+		if len(choices) >= 25 {
+			break
+		}
+		if query == "" || strings.Contains(strings.ToLower(ev.Name), query) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  truncate(ev.Name, 100),
+				Value: ev.ID,
+			})
+		}
+	}
+	respondAutocomplete(s, i, choices)
+}
@@ -196,7 +196,7 @@ func (ch *CommandHandler) listFiltersHandler(s *discordgo.Session, i *discordgo.
 		Description: description.String(),
 		Color:       0x5865F2,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Use /removefilter <id> to remove a filter",
+			Text: "Use /filters removefilter <id> to remove a filter",
 		},
 	}
 
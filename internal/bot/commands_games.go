@@ -0,0 +1,199 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerGameCommands() {
+	channelOption := func(required bool) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "channel",
+			Description:  "Game channel (defaults to this channel)",
+			Required:     required,
+			ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+		}
+	}
+
+	ch.Register(&Command{
+		Name:        "game",
+		Description: "Counting and word-chain channels",
+		Category:    "Games",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Turn a channel into a game channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "type",
+						Description: "Game to play",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Counting", Value: gameCounting},
+							{Name: "Word Chain", Value: gameWordChain},
+						},
+					},
+					channelOption(false),
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "strict",
+						Description: "Delete messages that aren't moves (default: false)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "allow_consecutive",
+						Description: "Let the same member play twice in a row (default: false)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop a game in a channel",
+				Options:     []*discordgo.ApplicationCommandOption{channelOption(false)},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Reset a game's progress (keeps the high score)",
+				Options:     []*discordgo.ApplicationCommandOption{channelOption(false)},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show game channels and high scores",
+			},
+		},
+		Handler: ch.gameHandler,
+	})
+}
+
+func (ch *CommandHandler) gameHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := getSubcommandName(i)
+	if subcommand == "status" {
+		ch.gameStatusHandler(s, i)
+		return
+	}
+
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage game channels.")
+		return
+	}
+
+	channelID := i.ChannelID
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		channelID = channel.ID
+	}
+
+	switch subcommand {
+	case "setup":
+		game := &database.GameChannel{
+			ChannelID:        channelID,
+			GuildID:          i.GuildID,
+			GameType:         getStringOption(i, "type"),
+			Strict:           getBoolOption(i, "strict"),
+			AllowConsecutive: getBoolOption(i, "allow_consecutive"),
+		}
+		if err := ch.bot.DB.SetGameChannel(game); err != nil {
+			respondEphemeral(s, i, "Failed to set up game channel.")
+			return
+		}
+
+		var rules string
+		if game.GameType == gameCounting {
+			rules = "Count up one number at a time, starting at **1**."
+		} else {
+			rules = "Each word must start with the last letter of the previous word. No repeats!"
+		}
+		if !game.AllowConsecutive {
+			rules += "\nMembers can't play twice in a row."
+		}
+		if game.Strict {
+			rules += "\nMessages that aren't moves are deleted."
+		}
+		respondEmbed(s, i, successEmbed("Game Channel Set Up", fmt.Sprintf("<#%s> is now a %s channel.\n%s",
+			channelID, gameName(game.GameType), rules)))
+
+	case "remove":
+		if err := ch.bot.DB.DeleteGameChannel(channelID); err != nil {
+			respondEphemeral(s, i, "Failed to remove game channel.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Game Channel Removed", fmt.Sprintf("<#%s> is no longer a game channel", channelID)))
+
+	case "reset":
+		game, err := ch.bot.DB.GetGameChannel(channelID)
+		if err != nil || game == nil {
+			respondEphemeral(s, i, "That channel isn't a game channel.")
+			return
+		}
+		if err := ch.bot.DB.ResetGame(channelID); err != nil {
+			respondEphemeral(s, i, "Failed to reset game.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Game Reset", fmt.Sprintf("The game in <#%s> has been reset", channelID)))
+	}
+}
+
+func (ch *CommandHandler) gameStatusHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	games, err := ch.bot.DB.GetGuildGameChannels(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get game channels.")
+		return
+	}
+	if len(games) == 0 {
+		respondEphemeral(s, i, "There are no game channels on this server.")
+		return
+	}
+
+	var lines []string
+	for _, g := range games {
+		line := fmt.Sprintf("<#%s> — **%s** • current: **%d** • high score: **%d**",
+			g.ChannelID, gameName(g.GameType), g.Count, g.HighScore)
+		if !g.HighScoreAt.IsZero() {
+			line += fmt.Sprintf(" (<t:%s:R>)", formatUnixTime(g.HighScoreAt))
+		}
+		if g.GameType == gameWordChain && g.LastWord != "" {
+			line += fmt.Sprintf("\n  Last word: `%s`", g.LastWord)
+		}
+		lines = append(lines, line)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎮 Game Channels",
+		Description: strings.Join(lines, "\n"),
+		Color:       0x5865F2,
+	}
+	respondEmbed(s, i, embed)
+}
+
+func gameName(gameType string) string {
+	if gameType == gameWordChain {
+		return "Word Chain"
+	}
+	return "Counting"
+}
@@ -0,0 +1,224 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+var gitHubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+func (ch *CommandHandler) registerGitHubCommands() {
+	ch.Register(&Command{
+		Name:        "githubrelay",
+		Description: "Relay GitHub repository events to a channel",
+		Category:    "GitHub",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Relay a repository to a channel, or change where it goes",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "repo",
+						Description: "Repository as owner/name",
+						Required:    true,
+						MaxLength:   140,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to post events in",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "events",
+						Description: "Comma-separated: push, release, issues, pull_request (default: all)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "new_secret",
+						Description: "Generate a new webhook secret (the old one stops working)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop relaying a repository",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "repo",
+						Description: "Repository as owner/name",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List relayed repositories",
+			},
+		},
+		Handler: ch.gitHubHandler,
+	})
+}
+
+func (ch *CommandHandler) gitHubHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage GitHub relays.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "add":
+		ch.gitHubAdd(s, i)
+	case "remove":
+		repo := strings.TrimSpace(getStringOption(i, "repo"))
+		removed, err := ch.bot.DB.RemoveGitHubRelay(i.GuildID, repo)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to remove GitHub relay.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, fmt.Sprintf("`%s` isn't being relayed.", repo))
+			return
+		}
+		respondEmbed(s, i, successEmbed("GitHub Relay Removed",
+			fmt.Sprintf("Events from `%s` will no longer be posted. You can delete the webhook on GitHub.", repo)))
+	case "list":
+		ch.gitHubList(s, i)
+	}
+}
+
+func (ch *CommandHandler) gitHubAdd(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	repo := strings.TrimSpace(getStringOption(i, "repo"))
+	repo = strings.TrimPrefix(strings.TrimSuffix(repo, ".git"), "https://github.com/")
+	if !gitHubRepoPattern.MatchString(repo) {
+		respondEphemeral(s, i, "Repository must be given as `owner/name`.")
+		return
+	}
+	channel := getChannelOption(i, "channel")
+
+	var events []string
+	for _, e := range strings.Split(getStringOption(i, "events"), ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || e == "all" {
+			continue
+		}
+		if e == "pr" || e == "pull_requests" {
+			e = database.GitHubEventPullRequest
+		}
+		if !slices.Contains(database.GitHubEvents, e) {
+			respondEphemeral(s, i, fmt.Sprintf("Unknown event `%s`. Choose from: %s.", e, strings.Join(database.GitHubEvents, ", ")))
+			return
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+
+	relay, err := ch.bot.DB.GetGitHubRelay(i.GuildID, repo)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get GitHub relay.")
+		return
+	}
+	isNew := relay == nil
+	if isNew {
+		relay = &database.GitHubRelay{GuildID: i.GuildID, Repo: repo, CreatedBy: i.Member.User.ID}
+	}
+	relay.ChannelID = channel.ID
+	relay.Events = strings.Join(events, ",")
+
+	if isNew || getBoolOption(i, "new_secret") {
+		secret := make([]byte, 20)
+		if _, err := rand.Read(secret); err != nil {
+			respondEphemeral(s, i, "Failed to generate webhook secret.")
+			return
+		}
+		relay.Secret = hex.EncodeToString(secret)
+	}
+
+	if err := ch.bot.DB.SetGitHubRelay(relay); err != nil {
+		respondEphemeral(s, i, "Failed to save GitHub relay.")
+		return
+	}
+
+	embed := successEmbed("GitHub Relay Saved",
+		fmt.Sprintf("Events from `%s` will be posted in <#%s>.", strings.ToLower(repo), channel.ID))
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Events", Value: gitHubEventNames(relay)},
+		{Name: "Setup", Value: fmt.Sprintf("On GitHub, open **Settings → Webhooks → Add webhook** for `%s` and enter:\n"+
+			"• Payload URL: `https://<your webhook address>/webhooks/github` (the `webserver.webhook_port` listener, not the dashboard)\n"+
+			"• Content type: `application/json`\n"+
+			"• Secret: ||`%s`||\n"+
+			"• Events: *Send me everything*, or pick the ones above", repo, relay.Secret)},
+	}
+
+	// The secret is shown only to the admin who asked for it
+	respondEmbedEphemeral(s, i, embed)
+}
+
+func (ch *CommandHandler) gitHubList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	relays, err := ch.bot.DB.GetGitHubRelays(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get GitHub relays.")
+		return
+	}
+
+	if len(relays) == 0 {
+		respondEphemeral(s, i, "No GitHub repositories are being relayed.")
+		return
+	}
+
+	var description strings.Builder
+	for _, r := range relays {
+		description.WriteString(fmt.Sprintf("**%s**\n", r.Repo))
+		description.WriteString(fmt.Sprintf("├ Channel: <#%s>\n", r.ChannelID))
+		description.WriteString(fmt.Sprintf("└ Events: %s\n\n", gitHubEventNames(r)))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("GitHub Relays (%d)", len(relays)),
+		Description: truncate(description.String(), 4096),
+		Color:       0x5865F2,
+	}
+
+	respondEmbed(s, i, embed)
+}
+
+// gitHubEventNames lists the events a relay posts
+func gitHubEventNames(r *database.GitHubRelay) string {
+	if r.Events == "" {
+		return "All (" + strings.Join(database.GitHubEvents, ", ") + ")"
+	}
+	return strings.ReplaceAll(r.Events, ",", ", ")
+}
@@ -17,12 +17,15 @@
 package bot
 
 import (
+	"cmp"
 	"fmt"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/blubskye/himiko/internal/updater"
 	"github.com/bwmarrin/discordgo"
 )
@@ -113,7 +116,43 @@ func (ch *CommandHandler) registerInfoCommands() {
 		Name:        "stats",
 		Description: "Show detailed bot statistics",
 		Category:    "Info",
-		Handler:     ch.statsHandler,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "bot",
+				Description: "Show servers, users, uptime and memory",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "commands",
+				Description: "Show which commands are used most, which fail and how slow they are",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "days",
+						Description: "How many days to look back (default 7)",
+						MinValue:    floatPtr(1),
+						MaxValue:    90,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "sort",
+						Description: "What to rank commands by",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Most used", Value: "uses"},
+							{Name: "Error rate", Value: "errors"},
+							{Name: "Slowest (p95)", Value: "latency"},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "all_servers",
+						Description: "Include every server (bot owner only)",
+					},
+				},
+			},
+		},
+		Handler: ch.statsHandler,
 	})
 
 	// Invite info
@@ -337,6 +376,17 @@ func (ch *CommandHandler) userInfoHandler(s *discordgo.Session, i *discordgo.Int
 		}
 	}
 
+	// Staff see how many notes the member has
+	if i.GuildID != "" && isModerator(s, i.GuildID, i.Member.User.ID) {
+		if count, _ := ch.bot.DB.CountUserNotes(i.GuildID, user.ID); count > 0 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:   "Staff Notes",
+				Value:  fmt.Sprintf("%d (`/notes view`)", count),
+				Inline: true,
+			})
+		}
+	}
+
 	// Add banner if exists
 	if bannerURL := bannerURL(fullUser); bannerURL != "" {
 		embed.Image = &discordgo.MessageEmbedImage{URL: bannerURL}
@@ -480,16 +530,19 @@ func (ch *CommandHandler) roleInfoHandler(s *discordgo.Session, i *discordgo.Int
 
 	createdAt, _ := discordgo.SnowflakeTimestamp(role.ID)
 
+	// Counting every member can take a moment on large servers
+	respondDeferred(s, i)
+
 	// Count members with this role
-	members, _ := s.GuildMembers(i.GuildID, "", 1000)
-	memberCount := 0
-	for _, m := range members {
-		for _, r := range m.Roles {
-			if r == role.ID {
-				memberCount++
-				break
+	memberCount := "Unknown"
+	if members, err := fetchGuildMembers(s, i.GuildID); err == nil {
+		count := 0
+		for _, m := range members {
+			if slices.Contains(m.Roles, role.ID) {
+				count++
 			}
 		}
+		memberCount = strconv.Itoa(count)
 	}
 
 	embed := &discordgo.MessageEmbed{
@@ -499,14 +552,14 @@ func (ch *CommandHandler) roleInfoHandler(s *discordgo.Session, i *discordgo.Int
 			{Name: "ID", Value: role.ID, Inline: true},
 			{Name: "Color", Value: fmt.Sprintf("#%06X", role.Color), Inline: true},
 			{Name: "Position", Value: strconv.Itoa(role.Position), Inline: true},
-			{Name: "Members", Value: strconv.Itoa(memberCount), Inline: true},
+			{Name: "Members", Value: memberCount, Inline: true},
 			{Name: "Mentionable", Value: fmt.Sprintf("%t", role.Mentionable), Inline: true},
 			{Name: "Hoisted", Value: fmt.Sprintf("%t", role.Hoist), Inline: true},
 			{Name: "Created", Value: fmt.Sprintf("<t:%d:F>", createdAt.Unix()), Inline: false},
 		},
 	}
 
-	respondEmbed(s, i, embed)
+	editResponseEmbed(s, i, embed)
 }
 
 func (ch *CommandHandler) emojiInfoHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -588,6 +641,11 @@ func (ch *CommandHandler) botInfoHandler(s *discordgo.Session, i *discordgo.Inte
 }
 
 func (ch *CommandHandler) statsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if getSubcommandName(i) == "commands" {
+		ch.commandStatsHandler(s, i)
+		return
+	}
+
 	// Gather statistics
 	guilds := len(s.State.Guilds)
 	var totalMembers int
@@ -632,6 +690,90 @@ func (ch *CommandHandler) statsHandler(s *discordgo.Session, i *discordgo.Intera
 	respondEmbed(s, i, embed)
 }
 
+// maxCommandStatsShown is how many commands /stats commands lists
+const maxCommandStatsShown = 15
+
+func (ch *CommandHandler) commandStatsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	days := int(getIntOption(i, "days"))
+	if days <= 0 {
+		days = 7
+	}
+
+	guildID := i.GuildID
+	scope := "this server"
+	if getBoolOption(i, "all_servers") {
+		if !ch.bot.Config().IsOwner(i.Member.User.ID) {
+			respondEphemeral(s, i, "Only the bot owner can view stats for every server.")
+			return
+		}
+		guildID = ""
+		scope = "all servers"
+	}
+
+	stats, err := ch.bot.DB.GetCommandUsageStats(guildID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		respondEphemeral(s, i, "Failed to load command stats.")
+		return
+	}
+	if len(stats) == 0 {
+		respondEphemeral(s, i, fmt.Sprintf("No commands have been used in %s in the last %d days.", scope, days))
+		return
+	}
+
+	var uses, failures int
+	for _, stat := range stats {
+		uses += stat.Uses
+		failures += stat.Failures
+	}
+
+	switch getStringOption(i, "sort") {
+	case "errors":
+		slices.SortStableFunc(stats, func(a, b database.CommandUsageStat) int {
+			return cmp.Compare(b.ErrorRate(), a.ErrorRate())
+		})
+	case "latency":
+		slices.SortStableFunc(stats, func(a, b database.CommandUsageStat) int {
+			return cmp.Compare(b.P95Ms, a.P95Ms)
+		})
+	}
+
+	var sb strings.Builder
+	for _, stat := range stats[:min(len(stats), maxCommandStatsShown)] {
+		fmt.Fprintf(&sb, "`/%s` — %d uses", strings.ReplaceAll(stat.Command, "_", " "), stat.Uses)
+		if stat.Failures > 0 {
+			fmt.Fprintf(&sb, " · %.1f%% failed", stat.ErrorRate()*100)
+		}
+		if stat.P95Ms > 0 {
+			fmt.Fprintf(&sb, " · p95 %s", formatLatency(stat.P95Ms))
+		}
+		sb.WriteString("\n")
+	}
+	if len(stats) > maxCommandStatsShown {
+		fmt.Fprintf(&sb, "*…and %d more*", len(stats)-maxCommandStatsShown)
+	}
+
+	errorRate := float64(failures) / float64(uses) * 100
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "Command Usage",
+		Description: truncate(sb.String(), 4000),
+		Color:       0xFF69B4,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Uses", Value: strconv.Itoa(uses), Inline: true},
+			{Name: "Commands", Value: strconv.Itoa(len(stats)), Inline: true},
+			{Name: "Failed", Value: fmt.Sprintf("%d (%.1f%%)", failures, errorRate), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Last %d days in %s", days, scope)},
+	})
+}
+
+// formatLatency formats a duration in milliseconds, switching to seconds above one second
+func formatLatency(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
+
 func (ch *CommandHandler) inviteInfoHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	inviteStr := getStringOption(i, "invite")
 
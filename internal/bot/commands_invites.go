@@ -0,0 +1,185 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerInviteCommands() {
+	// Invite tracking
+	ch.Register(&Command{
+		Name:        "invites",
+		Description: "View invite tracking statistics",
+		Category:    "Info",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "leaderboard",
+				Description: "Show the members who invited the most people",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "user",
+				Description: "Show how many people a member invited",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "User to check (defaults to yourself)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "joinedwith",
+				Description: "Show which invite a member joined with",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "User to check (defaults to yourself)",
+						Required:    false,
+					},
+				},
+			},
+		},
+		Handler: ch.invitesHandler,
+	})
+}
+
+func (ch *CommandHandler) invitesHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch getSubcommandName(i) {
+	case "leaderboard":
+		ch.invitesLeaderboardHandler(s, i)
+	case "user":
+		ch.invitesUserHandler(s, i)
+	case "joinedwith":
+		ch.joinedWithHandler(s, i)
+	}
+}
+
+func (ch *CommandHandler) invitesLeaderboardHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	leaderboard, err := ch.bot.DB.GetInviteLeaderboard(i.GuildID, 10)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get invite leaderboard.")
+		return
+	}
+
+	if len(leaderboard) == 0 {
+		respondEphemeral(s, i, "No tracked invites yet. The bot needs the Manage Server permission to track invites.")
+		return
+	}
+
+	var description strings.Builder
+	for idx, entry := range leaderboard {
+		rank := idx + 1
+		medal := ""
+		switch rank {
+		case 1:
+			medal = " :first_place:"
+		case 2:
+			medal = " :second_place:"
+		case 3:
+			medal = " :third_place:"
+		}
+		description.WriteString(fmt.Sprintf("**#%d**%s <@%s> - %d invites (%d total joins)\n",
+			rank, medal, entry.InviterID, entry.Unique, entry.Total))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Invite Leaderboard",
+		Description: description.String(),
+		Color:       0xFFD700,
+	}
+
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) invitesUserHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := getUserOption(i, "user")
+	if user == nil {
+		user = i.Member.User
+	}
+
+	stats, err := ch.bot.DB.GetInviterStats(i.GuildID, user.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get invite stats.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Invites for %s", user.Username),
+		Color: 0xFF69B4,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Members Invited", Value: fmt.Sprintf("%d", stats.Unique), Inline: true},
+			{Name: "Total Joins", Value: fmt.Sprintf("%d", stats.Total), Inline: true},
+		},
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: avatarURL(user),
+		},
+	}
+
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) joinedWithHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := getUserOption(i, "user")
+	if user == nil {
+		user = i.Member.User
+	}
+
+	join, err := ch.bot.DB.GetInviteJoin(i.GuildID, user.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to look up invite.")
+		return
+	}
+
+	if join == nil {
+		respondEphemeral(s, i, fmt.Sprintf("No invite recorded for %s.", user.Username))
+		return
+	}
+
+	inviter := "Vanity URL"
+	if join.InviterID != "" {
+		inviter = fmt.Sprintf("<@%s>", join.InviterID)
+	}
+
+	code := join.InviteCode
+	if code == "" {
+		code = "Unknown"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("How %s Joined", user.Username),
+		Color: 0xFF69B4,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Invited By", Value: inviter, Inline: true},
+			{Name: "Invite Code", Value: fmt.Sprintf("`%s`", code), Inline: true},
+			{Name: "Joined", Value: fmt.Sprintf("<t:%s:R>", formatUnixTime(join.JoinedAt)), Inline: true},
+		},
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: avatarURL(user),
+		},
+	}
+
+	respondEmbed(s, i, embed)
+}
@@ -0,0 +1,307 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerLibraryCommands() {
+	ch.Register(&Command{
+		Name:        "library",
+		Description: "Browse the local music library by artist and album",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "artists",
+				Description: "List the artists in the library",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "albums",
+				Description: "List the albums in the library",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "artist",
+						Description:  "Only show this artist's albums",
+						Required:     false,
+						Autocomplete: true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "album",
+				Description: "Show an album's tracks",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "Album name",
+						Required:     true,
+						Autocomplete: true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "play",
+						Description: "Queue the whole album (default: no)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "rescan",
+				Description: "Re-index the music folder now (Admin)",
+			},
+		},
+		Handler:      ch.libraryHandler,
+		Autocomplete: ch.libraryAutocomplete,
+	})
+}
+
+func (ch *CommandHandler) libraryHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil || settings.MusicFolder == nil || *settings.MusicFolder == "" {
+		respondEphemeral(s, i, "No music folder configured. Use `/musicsettings musicfolder` to set one.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "artists":
+		artists, err := ch.bot.DB.GetLocalArtists(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to read the music library.")
+			return
+		}
+		if len(artists) == 0 {
+			respondEphemeral(s, i, "No tagged artists in the library yet. New folders can take a few minutes to index.")
+			return
+		}
+
+		var sb strings.Builder
+		for idx, a := range artists {
+			if idx >= 30 {
+				sb.WriteString(fmt.Sprintf("\n*...and %d more artists*", len(artists)-30))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("🎤 **%s** - %d tracks\n", a.Name, a.Tracks))
+		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title:       "Library Artists",
+			Description: sb.String(),
+			Color:       0x5865F2,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Use /musiclibrary library albums to see an artist's albums"},
+		})
+
+	case "albums":
+		artist := strings.TrimSpace(getStringOption(i, "artist"))
+		albums, err := ch.bot.DB.GetLocalAlbums(i.GuildID, artist)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to read the music library.")
+			return
+		}
+		if len(albums) == 0 {
+			respondEphemeral(s, i, "No albums found.")
+			return
+		}
+
+		var sb strings.Builder
+		for idx, a := range albums {
+			if idx >= 30 {
+				sb.WriteString(fmt.Sprintf("\n*...and %d more albums*", len(albums)-30))
+				break
+			}
+			by := ""
+			if a.Artist != "" && artist == "" {
+				by = " by " + a.Artist
+			}
+			sb.WriteString(fmt.Sprintf("💿 **%s**%s - %d tracks, %s\n", a.Name, by, a.Tracks, formatMusicDuration(a.Duration)))
+		}
+		title := "Library Albums"
+		if artist != "" {
+			title = "Albums by " + artist
+		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title:       title,
+			Description: sb.String(),
+			Color:       0x5865F2,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Use /musiclibrary library album to see or play an album"},
+		})
+
+	case "album":
+		ch.libraryAlbum(s, i, *settings.MusicFolder)
+
+	case "rescan":
+		if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "You need administrator permission to rescan the library.")
+			return
+		}
+		respondDeferred(s, i)
+		updated, removed, err := ch.bot.scanMusicLibrary(i.GuildID, *settings.MusicFolder)
+		if err != nil {
+			editResponse(s, i, "Failed to scan the music folder: "+err.Error())
+			return
+		}
+		editResponse(s, i, fmt.Sprintf("✅ Library rescanned: %d files indexed, %d removed.", updated, removed))
+	}
+}
+
+// libraryAlbum lists an album's tracks, queuing them all if asked to
+func (ch *CommandHandler) libraryAlbum(s *discordgo.Session, i *discordgo.InteractionCreate, folder string) {
+	name := strings.TrimSpace(getStringOption(i, "name"))
+	tracks, err := ch.bot.DB.GetLocalAlbumTracks(i.GuildID, name)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to read the music library.")
+		return
+	}
+	if len(tracks) == 0 {
+		respondEphemeral(s, i, fmt.Sprintf("There's no album named **%s** in the library.", name))
+		return
+	}
+
+	if !getBoolOption(i, "play") {
+		var sb strings.Builder
+		total := 0
+		for idx, t := range tracks {
+			total += t.Duration
+			if idx < 25 {
+				sb.WriteString(fmt.Sprintf("%d. %s [%s]\n", idx+1, localTrackTitle(&t), formatMusicDuration(t.Duration)))
+			}
+		}
+		if len(tracks) > 25 {
+			sb.WriteString(fmt.Sprintf("\n*...and %d more tracks*", len(tracks)-25))
+		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title:       "💿 " + tracks[0].Album,
+			Description: sb.String(),
+			Color:       0x5865F2,
+			Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d tracks, %s", len(tracks), formatMusicDuration(total))},
+		})
+		return
+	}
+
+	channelID, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+
+	respondDeferred(s, i)
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	if !player.IsConnected() {
+		if err := player.Connect(s, channelID); err != nil {
+			editResponse(s, i, "Failed to join voice channel: "+err.Error())
+			return
+		}
+	}
+
+	queued := 0
+	limits := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID)
+	limited := ""
+	for _, t := range tracks {
+		track := &Track{
+			Title:       localTrackTitle(&t),
+			URL:         filepath.Join(folder, t.Path),
+			Duration:    t.Duration,
+			Requester:   i.Member.User.Username,
+			RequesterID: i.Member.User.ID,
+			IsLocal:     true,
+		}
+		reason, full := limits.check(player, track)
+		if reason != "" {
+			limited = reason
+			if full {
+				break
+			}
+			continue
+		}
+		if err := ch.queueTrack(i, player, channelID, track); err != nil {
+			editResponse(s, i, "Failed to start playback: "+err.Error())
+			return
+		}
+		queued++
+	}
+
+	if queued == 0 {
+		editResponse(s, i, limited)
+		return
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Album Queued",
+		Description: fmt.Sprintf("💿 **%s**", tracks[0].Album),
+		Color:       0xFF69B4,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Queued", Value: fmt.Sprintf("%d tracks", queued), Inline: true},
+			{Name: "Requested by", Value: i.Member.User.Username, Inline: true},
+		},
+	}
+	if limited != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Limited", Value: limited})
+	}
+	editResponseEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) libraryAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	for _, opt := range getOptions(i) {
+		if opt.Focused {
+			focused = opt
+		}
+	}
+	if focused == nil {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	var names []string
+	if focused.Name == "artist" {
+		artists, _ := ch.bot.DB.GetLocalArtists(i.GuildID)
+		for _, a := range artists {
+			names = append(names, a.Name)
+		}
+	} else {
+		albums, _ := ch.bot.DB.GetLocalAlbums(i.GuildID, "")
+		for _, a := range albums {
+			names = append(names, a.Name)
+		}
+	}
+
+	input := strings.ToLower(focused.StringValue())
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, name := range names {
+		if len(choices) >= 25 {
+			break
+		}
+		if strings.Contains(strings.ToLower(name), input) && len(name) <= 100 {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+		}
+	}
+	respondAutocomplete(s, i, choices)
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -33,8 +34,39 @@ func (ch *CommandHandler) registerLoggingCommands() {
 			{
 				Type:        discordgo.ApplicationCommandOptionChannel,
 				Name:        "channel",
-				Description: "Channel to send logs to",
-				Required:    true,
+				Description: "Channel to send logs to (not needed with clear)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "category",
+				Description: "Only route this log category to the channel (default: all logs)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Message Logs", Value: "message"},
+					{Name: "Member Logs", Value: "member"},
+					{Name: "Voice Logs", Value: "voice"},
+					{Name: "Mod Logs", Value: "mod"},
+					{Name: "Automod Logs", Value: "automod"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "clear",
+				Description: "Remove the category route so it falls back to the default log channel",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Webhook username for these logs (when webhook delivery is enabled)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "avatar",
+				Description: "Webhook avatar URL for these logs (when webhook delivery is enabled)",
+				Required:    false,
 			},
 		},
 		Handler: ch.setLogChannelHandler,
@@ -72,9 +104,17 @@ func (ch *CommandHandler) registerLoggingCommands() {
 					{Name: "Message Edit", Value: "message_edit"},
 					{Name: "Voice Join", Value: "voice_join"},
 					{Name: "Voice Leave", Value: "voice_leave"},
+					{Name: "Voice Move", Value: "voice_move"},
+					{Name: "Voice Mute/Deafen", Value: "voice_mute"},
+					{Name: "Voice Stream/Video", Value: "voice_stream"},
+					{Name: "Voice Session Duration", Value: "voice_session"},
 					{Name: "Nickname Change", Value: "nickname"},
 					{Name: "Avatar Change", Value: "avatar"},
 					{Name: "Presence Change", Value: "presence"},
+					{Name: "Role Change", Value: "role_change"},
+					{Name: "Moderation (bans/kicks)", Value: "moderation"},
+					{Name: "Archive Deleted Attachments", Value: "attachments"},
+					{Name: "Deliver via Webhooks", Value: "webhooks"},
 				},
 			},
 			{
@@ -134,12 +174,54 @@ func (ch *CommandHandler) setLogChannelHandler(s *discordgo.Session, i *discordg
 		return
 	}
 
+	category := getStringOption(i, "category")
+	clearRoute := getBoolOption(i, "clear")
+	if clearRoute && category == "" {
+		respondEphemeral(s, i, "Please specify the category whose route should be cleared.")
+		return
+	}
+
 	channel := getChannelOption(i, "channel")
-	if channel == nil {
+	if channel == nil && !clearRoute {
 		respondEphemeral(s, i, "Please specify a channel.")
 		return
 	}
 
+	name, avatar := getStringOption(i, "name"), getStringOption(i, "avatar")
+	if name != "" || avatar != "" {
+		identityCategory := category
+		if identityCategory == "" {
+			identityCategory = "default"
+		}
+		if avatar != "" && !strings.HasPrefix(avatar, "https://") {
+			respondEphemeral(s, i, "The avatar must be an https:// image URL.")
+			return
+		}
+		if len(name) > 80 {
+			respondEphemeral(s, i, "The webhook name can be at most 80 characters.")
+			return
+		}
+		err := ch.bot.DB.SetLogIdentity(&database.LogIdentity{
+			GuildID:   i.GuildID,
+			Category:  identityCategory,
+			Username:  name,
+			AvatarURL: avatar,
+		})
+		if err != nil {
+			respondEphemeral(s, i, "Failed to set log webhook identity.")
+			return
+		}
+	}
+
+	if category != "" {
+		channelID := ""
+		if channel != nil {
+			channelID = channel.ID
+		}
+		ch.setCategoryLogChannel(s, i, category, channelID)
+		return
+	}
+
 	err := ch.bot.DB.SetLogChannel(i.GuildID, channel.ID)
 	if err != nil {
 		respondEphemeral(s, i, "Failed to set log channel.")
@@ -151,6 +233,37 @@ func (ch *CommandHandler) setLogChannelHandler(s *discordgo.Session, i *discordg
 	respondEmbed(s, i, embed)
 }
 
+// logCategoryNames are display names for log routing categories
+var logCategoryNames = map[string]string{
+	logCategoryMessage: "Message Logs",
+	logCategoryMember:  "Member Logs",
+	logCategoryVoice:   "Voice Logs",
+	logCategoryMod:     "Mod Logs",
+	logCategoryAutomod: "Automod Logs",
+}
+
+func (ch *CommandHandler) setCategoryLogChannel(s *discordgo.Session, i *discordgo.InteractionCreate, category, channelID string) {
+	if getBoolOption(i, "clear") {
+		if err := ch.bot.DB.SetCategoryLogChannel(i.GuildID, category, ""); err != nil {
+			respondEphemeral(s, i, "Failed to clear log channel.")
+			return
+		}
+		embed := successEmbed("Log Route Cleared",
+			fmt.Sprintf("**%s** will use the default log channel", logCategoryNames[category]))
+		respondEmbed(s, i, embed)
+		return
+	}
+
+	if err := ch.bot.DB.SetCategoryLogChannel(i.GuildID, category, channelID); err != nil {
+		respondEphemeral(s, i, "Failed to set log channel.")
+		return
+	}
+
+	embed := successEmbed("Log Channel Set",
+		fmt.Sprintf("**%s** will be sent to <#%s>", logCategoryNames[category], channelID))
+	respondEmbed(s, i, embed)
+}
+
 func (ch *CommandHandler) toggleLoggingHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
 		respondEphemeral(s, i, "You need administrator permission to configure logging.")
@@ -199,12 +312,28 @@ func (ch *CommandHandler) logConfigHandler(s *discordgo.Session, i *discordgo.In
 		config.VoiceJoin = enabled
 	case "voice_leave":
 		config.VoiceLeave = enabled
+	case "voice_move":
+		config.VoiceMove = enabled
+	case "voice_mute":
+		config.VoiceMute = enabled
+	case "voice_stream":
+		config.VoiceStream = enabled
+	case "voice_session":
+		config.VoiceSession = enabled
 	case "nickname":
 		config.NicknameChange = enabled
 	case "avatar":
 		config.AvatarChange = enabled
 	case "presence":
 		config.PresenceChange = enabled
+	case "role_change":
+		config.RoleChange = enabled
+	case "moderation":
+		config.Moderation = enabled
+	case "attachments":
+		config.ArchiveAttachments = enabled
+	case "webhooks":
+		config.UseWebhooks = enabled
 	}
 
 	err = ch.bot.DB.SetLoggingConfig(config)
@@ -223,9 +352,17 @@ func (ch *CommandHandler) logConfigHandler(s *discordgo.Session, i *discordgo.In
 		"message_edit":   "Message Edit",
 		"voice_join":     "Voice Join",
 		"voice_leave":    "Voice Leave",
+		"voice_move":     "Voice Move",
+		"voice_mute":     "Voice Mute/Deafen",
+		"voice_stream":   "Voice Stream/Video",
+		"voice_session":  "Voice Session Duration",
 		"nickname":       "Nickname Change",
 		"avatar":         "Avatar Change",
 		"presence":       "Presence Change",
+		"role_change":    "Role Change",
+		"moderation":     "Moderation",
+		"attachments":    "Attachment Archival",
+		"webhooks":       "Webhook Delivery",
 	}
 
 	embed := successEmbed("Log Config Updated",
@@ -300,6 +437,17 @@ func (ch *CommandHandler) logStatusHandler(s *discordgo.Session, i *discordgo.In
 		logChannel = fmt.Sprintf("<#%s>", *config.LogChannelID)
 	}
 
+	var routes []string
+	for _, category := range []string{logCategoryMessage, logCategoryMember, logCategoryVoice, logCategoryMod, logCategoryAutomod} {
+		if channelID := logCategoryRoute(config, category); channelID != "" {
+			routes = append(routes, fmt.Sprintf("%s: <#%s>", logCategoryNames[category], channelID))
+		}
+	}
+	routeList := "None"
+	if len(routes) > 0 {
+		routeList = strings.Join(routes, "\n")
+	}
+
 	var disabledList string
 	if len(disabledChannels) > 0 {
 		channels := make([]string, len(disabledChannels))
@@ -322,9 +470,18 @@ func (ch *CommandHandler) logStatusHandler(s *discordgo.Session, i *discordgo.In
 			{Name: "Message Edit", Value: statusEmoji(config.MessageEdit), Inline: true},
 			{Name: "Voice Join", Value: statusEmoji(config.VoiceJoin), Inline: true},
 			{Name: "Voice Leave", Value: statusEmoji(config.VoiceLeave), Inline: true},
+			{Name: "Voice Move", Value: statusEmoji(config.VoiceMove), Inline: true},
+			{Name: "Voice Mute/Deafen", Value: statusEmoji(config.VoiceMute), Inline: true},
+			{Name: "Voice Stream/Video", Value: statusEmoji(config.VoiceStream), Inline: true},
+			{Name: "Voice Session", Value: statusEmoji(config.VoiceSession), Inline: true},
 			{Name: "Nickname Change", Value: statusEmoji(config.NicknameChange), Inline: true},
 			{Name: "Avatar Change", Value: statusEmoji(config.AvatarChange), Inline: true},
 			{Name: "Presence Change", Value: statusEmoji(config.PresenceChange), Inline: true},
+			{Name: "Role Change", Value: statusEmoji(config.RoleChange), Inline: true},
+			{Name: "Moderation", Value: statusEmoji(config.Moderation), Inline: true},
+			{Name: "Archive Attachments", Value: statusEmoji(config.ArchiveAttachments), Inline: true},
+			{Name: "Webhook Delivery", Value: statusEmoji(config.UseWebhooks), Inline: true},
+			{Name: "Routing", Value: routeList, Inline: false},
 			{Name: "Disabled Channels", Value: disabledList, Inline: false},
 		},
 	}
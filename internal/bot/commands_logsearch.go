@@ -0,0 +1,222 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Results shown per /logsearch page
+const logSearchPageSize = 10
+
+func (ch *CommandHandler) registerLogSearchCommands() {
+	rangeOptions := func(extra ...*discordgo.ApplicationCommandOption) []*discordgo.ApplicationCommandOption {
+		return append(extra,
+			&discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "since",
+				Description: "Only entries newer than this long ago (e.g. 2h, 7d)",
+				Required:    false,
+			},
+			&discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "until",
+				Description: "Only entries older than this long ago (e.g. 1h, 1d)",
+				Required:    false,
+			},
+			&discordgo.ApplicationCommandOption{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "page",
+				Description: "Page of results to show",
+				Required:    false,
+				MinValue:    floatPtr(1),
+			},
+		)
+	}
+
+	// Search stored logs
+	ch.Register(&Command{
+		Name:        "logsearch",
+		Description: "Search stored logs",
+		Category:    "Logging",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "deleted",
+				Description: "Search deleted messages",
+				Options: rangeOptions(&discordgo.ApplicationCommandOption{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Author of the deleted messages",
+					Required:    false,
+				}),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "modactions",
+				Description: "Search moderation actions",
+				Options: rangeOptions(
+					&discordgo.ApplicationCommandOption{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "moderator",
+						Description: "Moderator who took the action",
+						Required:    false,
+					},
+					&discordgo.ApplicationCommandOption{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "action",
+						Description: "Action type",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Ban", Value: "ban"},
+							{Name: "Unban", Value: "unban"},
+							{Name: "Kick", Value: "kick"},
+							{Name: "Timeout", Value: "timeout"},
+						},
+					},
+				),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "joins",
+				Description: "Search member joins",
+				Options: rangeOptions(&discordgo.ApplicationCommandOption{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Member who joined",
+					Required:    false,
+				}),
+			},
+		},
+		Handler: ch.logSearchHandler,
+	})
+}
+
+func (ch *CommandHandler) logSearchHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to search logs.")
+		return
+	}
+
+	q := database.LogSearchQuery{GuildID: i.GuildID, Limit: logSearchPageSize}
+	now := time.Now()
+	if since := getStringOption(i, "since"); since != "" {
+		d, err := parseDuration(since)
+		if err != nil || d <= 0 {
+			respondEphemeral(s, i, "Invalid `since` duration. Use formats like 30m, 2h, 7d.")
+			return
+		}
+		q.Since = now.Add(-d)
+	}
+	if until := getStringOption(i, "until"); until != "" {
+		d, err := parseDuration(until)
+		if err != nil || d <= 0 {
+			respondEphemeral(s, i, "Invalid `until` duration. Use formats like 30m, 2h, 7d.")
+			return
+		}
+		q.Until = now.Add(-d)
+	}
+
+	page := int(getIntOption(i, "page"))
+	if page < 1 {
+		page = 1
+	}
+	q.Offset = (page - 1) * logSearchPageSize
+
+	var (
+		title string
+		lines []string
+		total int
+		err   error
+	)
+
+	switch getSubcommandName(i) {
+	case "deleted":
+		title = "Deleted Messages"
+		if user := getUserOption(i, "user"); user != nil {
+			q.UserID = user.ID
+		}
+		var messages []database.DeletedMessage
+		messages, total, err = ch.bot.DB.SearchDeletedMessages(q)
+		for _, m := range messages {
+			content := m.Content
+			if len(m.Attachments) > 0 {
+				content += fmt.Sprintf(" [%d attachment(s)]", len(m.Attachments))
+			}
+			lines = append(lines, fmt.Sprintf("<t:%s:f> <@%s> in <#%s>\n%s",
+				formatUnixTime(m.DeletedAt), m.UserID, m.ChannelID, truncate(nonEmpty(content), 200)))
+		}
+
+	case "modactions":
+		title = "Moderation Actions"
+		if mod := getUserOption(i, "moderator"); mod != nil {
+			q.UserID = mod.ID
+		}
+		q.Action = getStringOption(i, "action")
+		var actions []database.ModAction
+		actions, total, err = ch.bot.DB.SearchModActions(q)
+		for _, a := range actions {
+			line := fmt.Sprintf("<t:%d:f> **%s** <@%s> by <@%s>", a.Timestamp/1000, a.Action, a.TargetID, a.ModeratorID)
+			if a.Reason != nil && *a.Reason != "" {
+				line += "\n" + truncate(*a.Reason, 200)
+			}
+			lines = append(lines, line)
+		}
+
+	case "joins":
+		title = "Member Joins"
+		if user := getUserOption(i, "user"); user != nil {
+			q.UserID = user.ID
+		}
+		var joins []database.MemberJoin
+		joins, total, err = ch.bot.DB.SearchMemberJoins(q)
+		for _, j := range joins {
+			lines = append(lines, fmt.Sprintf("<t:%d:f> <@%s> (account created <t:%d:R>)",
+				j.JoinedAt/1000, j.UserID, j.AccountCreatedAt/1000))
+		}
+	}
+
+	if err != nil {
+		respondEphemeral(s, i, "Failed to search logs.")
+		return
+	}
+
+	pages := (total + logSearchPageSize - 1) / logSearchPageSize
+	if len(lines) == 0 {
+		if total > 0 {
+			respondEphemeral(s, i, fmt.Sprintf("Page %d is out of range (%d page(s) of results).", page, pages))
+		} else {
+			respondEphemeral(s, i, "No matching log entries found.")
+		}
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Log Search: " + title,
+		Description: truncate(strings.Join(lines, "\n\n"), 4096),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d • %d result(s)", page, pages, total),
+		},
+	}
+	respondEmbedEphemeral(s, i, embed)
+}
@@ -18,11 +18,77 @@ package bot
 
 import (
 	"fmt"
+	"math/rand"
+	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
 )
 
+// mentionResponseSeparator splits a response into alternatives picked at random
+const mentionResponseSeparator = "||"
+
+// Mention response cooldowns, keyed by guildID:triggerID:channelID
+var mentionResponseCooldowns = newCooldownTracker("mention")
+
+// mentionPatterns caches compiled regex and wildcard triggers by mode and trigger text
+var mentionPatterns sync.Map
+
+// mentionPattern compiles a regex or wildcard trigger, case-insensitively.
+// Wildcards match the whole message, with * for any text and ? for one character.
+func mentionPattern(mode, trigger string) (*regexp.Regexp, error) {
+	key := mode + ":" + trigger
+	if cached, ok := mentionPatterns.Load(key); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	expr := trigger
+	if mode == database.MentionMatchWildcard {
+		expr = regexp.QuoteMeta(trigger)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		expr = "^" + expr + "$"
+	}
+	re, err := regexp.Compile("(?is)" + expr)
+	if err != nil {
+		return nil, err
+	}
+	mentionPatterns.Store(key, re)
+	return re, nil
+}
+
+// mentionResponseMatches reports whether the text after the bot's mention fires a trigger
+func mentionResponseMatches(resp *database.MentionResponse, text string) bool {
+	lower := strings.ToLower(text)
+	switch resp.MatchMode {
+	case database.MentionMatchExact:
+		return lower == resp.TriggerText
+	case database.MentionMatchStartsWith:
+		return strings.HasPrefix(lower, resp.TriggerText)
+	case database.MentionMatchWildcard, database.MentionMatchRegex:
+		re, err := mentionPattern(resp.MatchMode, resp.TriggerText)
+		return err == nil && re.MatchString(text)
+	default:
+		return strings.Contains(lower, resp.TriggerText)
+	}
+}
+
+// pickMentionResponse chooses one of a response's || separated alternatives
+func pickMentionResponse(response string) string {
+	var choices []string
+	for _, choice := range strings.Split(response, mentionResponseSeparator) {
+		if choice = strings.TrimSpace(choice); choice != "" {
+			choices = append(choices, choice)
+		}
+	}
+	if len(choices) == 0 {
+		return response
+	}
+	return choices[rand.Intn(len(choices))]
+}
+
 func (ch *CommandHandler) registerMentionCommands() {
 	// Mention response management
 	ch.Register(&Command{
@@ -44,7 +110,7 @@ func (ch *CommandHandler) registerMentionCommands() {
 					{
 						Type:        discordgo.ApplicationCommandOptionString,
 						Name:        "response",
-						Description: "Response message",
+						Description: "Response message, separate several with || to pick one at random",
 						Required:    true,
 					},
 					{
@@ -53,6 +119,27 @@ func (ch *CommandHandler) registerMentionCommands() {
 						Description: "Optional image URL to include",
 						Required:    false,
 					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "How the trigger is matched (default: contains)",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Contains the trigger", Value: database.MentionMatchContains},
+							{Name: "Is exactly the trigger", Value: database.MentionMatchExact},
+							{Name: "Starts with the trigger", Value: database.MentionMatchStartsWith},
+							{Name: "Wildcard (* and ?)", Value: database.MentionMatchWildcard},
+							{Name: "Regular expression", Value: database.MentionMatchRegex},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "cooldown",
+						Description: "Seconds before it can fire again in the same channel",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    86400,
+					},
 				},
 			},
 			{
@@ -100,23 +187,46 @@ func (ch *CommandHandler) mentionAddHandler(s *discordgo.Session, i *discordgo.I
 	opts := i.ApplicationCommandData().Options[0].Options
 
 	var trigger, response, imageURL string
+	mr := &database.MentionResponse{
+		GuildID:   i.GuildID,
+		CreatedBy: i.Member.User.ID,
+		MatchMode: database.MentionMatchContains,
+	}
 	for _, opt := range opts {
 		switch opt.Name {
 		case "trigger":
-			trigger = strings.ToLower(opt.StringValue())
+			trigger = opt.StringValue()
 		case "response":
 			response = opt.StringValue()
 		case "image":
 			imageURL = opt.StringValue()
+		case "mode":
+			mr.MatchMode = opt.StringValue()
+		case "cooldown":
+			mr.CooldownSeconds = int(opt.IntValue())
+		}
+	}
+
+	// Patterns keep their case since it can matter in a regex, they match case-insensitively anyway
+	switch mr.MatchMode {
+	case database.MentionMatchRegex, database.MentionMatchWildcard:
+		if _, err := mentionPattern(mr.MatchMode, trigger); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("That isn't a valid pattern: %s", err))
+			return
 		}
+	default:
+		trigger = strings.ToLower(trigger)
 	}
 
 	var imgPtr *string
 	if imageURL != "" {
 		imgPtr = &imageURL
 	}
+	mr.TriggerText = trigger
+	mr.Response = response
+	mr.ImageURL = imgPtr
 
-	err := ch.bot.DB.AddMentionResponse(i.GuildID, trigger, response, imgPtr, i.Member.User.ID)
+	err := ch.bot.DB.AddMentionResponse(mr)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint") {
 			respondEphemeral(s, i, "A mention response with that trigger already exists.")
@@ -126,7 +236,13 @@ func (ch *CommandHandler) mentionAddHandler(s *discordgo.Session, i *discordgo.I
 		return
 	}
 
-	description := fmt.Sprintf("**Trigger:** %s\n**Response:** %s", trigger, truncate(response, 100))
+	description := fmt.Sprintf("**Trigger:** %s\n**Match:** %s\n**Response:** %s", trigger, mr.MatchMode, truncate(response, 100))
+	if count := len(strings.Split(response, mentionResponseSeparator)); count > 1 {
+		description += fmt.Sprintf("\n**Alternatives:** %d, one picked at random", count)
+	}
+	if mr.CooldownSeconds > 0 {
+		description += fmt.Sprintf("\n**Cooldown:** %ds per channel", mr.CooldownSeconds)
+	}
 	if imageURL != "" {
 		description += "\n**Image:** Attached"
 	}
@@ -171,7 +287,11 @@ func (ch *CommandHandler) mentionListHandler(s *discordgo.Session, i *discordgo.
 		if resp.ImageURL != nil && *resp.ImageURL != "" {
 			hasImage = " [IMG]"
 		}
-		description.WriteString(fmt.Sprintf("**%s**%s\n└ %s\n\n", resp.TriggerText, hasImage, truncate(resp.Response, 50)))
+		mode := ""
+		if resp.MatchMode != database.MentionMatchContains {
+			mode = " `" + resp.MatchMode + "`"
+		}
+		description.WriteString(fmt.Sprintf("**%s**%s%s\n└ %s\n\n", resp.TriggerText, mode, hasImage, truncate(resp.Response, 50)))
 	}
 
 	embed := &discordgo.MessageEmbed{
@@ -18,9 +18,12 @@ package bot
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -80,7 +83,7 @@ func (ch *CommandHandler) registerMiscCommands() {
 					{
 						Type:        discordgo.ApplicationCommandOptionString,
 						Name:        "response",
-						Description: "Command response",
+						Description: "Command response ({user}, {args}, {random:a|b}, {choose} and more are filled in)",
 						Required:    true,
 					},
 				},
@@ -103,6 +106,71 @@ func (ch *CommandHandler) registerMiscCommands() {
 				Name:        "list",
 				Description: "List all custom commands",
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "settings",
+				Description: "Limit who can use a custom command, where, and how often",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Command name",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Add or remove a role allowed to use it (none means everyone)",
+						Required:    false,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Add or remove a channel it works in (none means everywhere)",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "cooldown",
+						Description: "Seconds each member has to wait between uses (0 for none)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    86400,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "delete_invocation",
+						Description: "Delete the message that ran the command",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "embed",
+				Description: "Reply with an embed, built in a form or imported from JSON",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Command name",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "json",
+						Description: "Embed JSON to import, e.g. from Discohook",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "clear",
+						Description: "Remove the embed and reply with plain text only",
+						Required:    false,
+					},
+				},
+			},
 		},
 		Handler: ch.customCommandHandler,
 	})
@@ -124,6 +192,12 @@ func (ch *CommandHandler) registerMiscCommands() {
 						Description: "Tag name",
 						Required:    true,
 					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "args",
+						Description: "Words filled into the tag's {args} placeholders",
+						Required:    false,
+					},
 				},
 			},
 			{
@@ -140,7 +214,7 @@ func (ch *CommandHandler) registerMiscCommands() {
 					{
 						Type:        discordgo.ApplicationCommandOptionString,
 						Name:        "content",
-						Description: "Tag content",
+						Description: "Tag content ({user}, {args}, {random:a|b}, {choose} and more are filled in)",
 						Required:    true,
 					},
 				},
@@ -163,9 +237,93 @@ func (ch *CommandHandler) registerMiscCommands() {
 				Name:        "list",
 				Description: "List all tags",
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "alias",
+				Description: "Give a tag another name",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Tag name",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "alias",
+						Description: "New name that points to the tag",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "search",
+				Description: "Search tag names and content",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "text",
+						Description: "Text to look for",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "info",
+				Description: "Show a tag's owner, uses and aliases",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Tag name",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "claim",
+				Description: "Take ownership of a tag whose owner left the server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Tag name",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "embed",
+				Description: "Reply with an embed, built in a form or imported from JSON",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Tag name",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "json",
+						Description: "Embed JSON to import, e.g. from Discohook",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "clear",
+						Description: "Remove the embed and reply with plain text only",
+						Required:    false,
+					},
+				},
+			},
 		},
 		Handler: ch.tagHandler,
 	})
+	ch.RegisterComponent("respembed", ch.handleResponseEmbedComponent)
 
 	// Keyword notifier
 	ch.Register(&Command{
@@ -184,6 +342,29 @@ func (ch *CommandHandler) registerMiscCommands() {
 						Description: "Keyword to track",
 						Required:    true,
 					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "match",
+						Description: "How the keyword is matched (default: anywhere in a message)",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Anywhere in a message", Value: database.KeywordMatchContains},
+							{Name: "Whole word only", Value: database.KeywordMatchWord},
+							{Name: "Regular expression", Value: database.KeywordMatchRegex},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "case_sensitive",
+						Description: "Only match the exact capitalization",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "all_servers",
+						Description: "Match in every server you share with the bot, not just this one",
+						Required:    false,
+					},
 				},
 			},
 			{
@@ -202,7 +383,58 @@ func (ch *CommandHandler) registerMiscCommands() {
 			{
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Name:        "list",
-				Description: "List tracked keywords",
+				Description: "List tracked keywords and alert settings",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "quiet",
+				Description: "Hold alerts during these hours in your timezone, leave both empty to turn off",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "start",
+						Description: "Hour quiet time begins (0-23)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    23,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "end",
+						Description: "Hour quiet time ends (0-23)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    23,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "ignore",
+				Description: "Stop or resume alerts from a channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to ignore, or to stop ignoring",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "digest",
+				Description: "Batch alerts into one DM instead of one per message",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "minutes",
+						Description: "Minutes between digests, 0 to get every alert right away",
+						Required:    true,
+						MinValue:    floatPtr(0),
+						MaxValue:    1440,
+					},
+				},
 			},
 		},
 		Handler: ch.keywordHandler,
@@ -211,16 +443,56 @@ func (ch *CommandHandler) registerMiscCommands() {
 	// Command history
 	ch.Register(&Command{
 		Name:        "history",
-		Description: "View command history",
+		Description: "View command history or control whether yours is recorded",
 		Category:    "Misc",
 		Options: []*discordgo.ApplicationCommandOption{
 			{
-				Type:        discordgo.ApplicationCommandOptionInteger,
-				Name:        "limit",
-				Description: "Number of commands to show",
-				Required:    false,
-				MinValue:    floatPtr(1),
-				MaxValue:    25,
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "me",
+				Description: "View the commands you've used here",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "limit",
+						Description: "Number of commands to show",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    25,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "server",
+				Description: "View recent commands used in this server (moderators)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "limit",
+						Description: "Number of commands to show",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    25,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "optout",
+				Description: "Stop recording the commands you use",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "delete_existing",
+						Description: "Also delete the history already recorded for you",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "optin",
+				Description: "Start recording the commands you use again",
 			},
 		},
 		Handler: ch.historyHandler,
@@ -259,7 +531,7 @@ func (ch *CommandHandler) helpHandler(s *discordgo.Session, i *discordgo.Interac
 	if command != "" {
 		if cmd, ok := ch.commands[command]; ok {
 			embed := &discordgo.MessageEmbed{
-				Title:       "/" + cmd.Name,
+				Title:       "/" + cmd.SlashName(),
 				Description: cmd.Description,
 				Color:       0x5865F2,
 				Fields: []*discordgo.MessageEmbedField{
@@ -299,7 +571,7 @@ func (ch *CommandHandler) helpHandler(s *discordgo.Session, i *discordgo.Interac
 
 		var cmdList []string
 		for _, cmd := range cmds {
-			cmdList = append(cmdList, fmt.Sprintf("`/%s` - %s", cmd.Name, cmd.Description))
+			cmdList = append(cmdList, fmt.Sprintf("`/%s` - %s", cmd.SlashName(), cmd.Description))
 		}
 
 		// Sort alphabetically
@@ -367,7 +639,12 @@ func (ch *CommandHandler) customCommandHandler(s *discordgo.Session, i *discordg
 			return
 		}
 
-		err := ch.bot.DB.CreateCustomCommand(i.GuildID, name, response, i.Member.User.ID)
+		if _, builtin := ch.commands[strings.ToLower(name)]; builtin {
+			respondEphemeral(s, i, "That name is already used by a built-in command.")
+			return
+		}
+
+		err := ch.bot.DB.CreateCustomCommand(i.GuildID, strings.ToLower(name), response, i.Member.User.ID)
 		if err != nil {
 			respondEphemeral(s, i, "Failed to create custom command.")
 			return
@@ -416,9 +693,96 @@ func (ch *CommandHandler) customCommandHandler(s *discordgo.Session, i *discordg
 		}
 
 		respondEmbed(s, i, embed)
+
+	case "settings":
+		ch.customCommandSettingsHandler(s, i)
+
+	case "embed":
+		ch.responseEmbedHandler(s, i, responseEmbedCustomCommand, getStringOption(i, "name"))
 	}
 }
 
+func (ch *CommandHandler) customCommandSettingsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cc, err := ch.bot.DB.GetCustomCommand(i.GuildID, getStringOption(i, "name"))
+	if err != nil || cc == nil {
+		respondEphemeral(s, i, "Custom command not found.")
+		return
+	}
+
+	// Options act as toggles, with none given the current settings are shown
+	changed := false
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "role":
+			cc.AllowedRoles = toggleID(cc.AllowedRoles, opt.RoleValue(nil, "").ID)
+			changed = true
+		case "channel":
+			cc.AllowedChannels = toggleID(cc.AllowedChannels, opt.ChannelValue(nil).ID)
+			changed = true
+		case "cooldown":
+			cc.CooldownSeconds = int(opt.IntValue())
+			changed = true
+		case "delete_invocation":
+			cc.DeleteInvocation = opt.BoolValue()
+			changed = true
+		}
+	}
+
+	if changed {
+		if cc.CreatedBy != i.Member.User.ID && !isModerator(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "Only the command's creator or a moderator can change its settings.")
+			return
+		}
+		if err := ch.bot.DB.SetCustomCommandRestrictions(cc); err != nil {
+			respondEphemeral(s, i, "Failed to update custom command settings.")
+			return
+		}
+	}
+
+	roles, channels := "Everyone", "Everywhere"
+	if len(cc.AllowedRoles) > 0 {
+		roles = truncate(formatRoleMentions(cc.AllowedRoles), 1024)
+	}
+	if len(cc.AllowedChannels) > 0 {
+		mentions := make([]string, len(cc.AllowedChannels))
+		for idx, id := range cc.AllowedChannels {
+			mentions[idx] = "<#" + id + ">"
+		}
+		channels = truncate(strings.Join(mentions, ", "), 1024)
+	}
+	cooldown := "None"
+	if cc.CooldownSeconds > 0 {
+		cooldown = (time.Duration(cc.CooldownSeconds) * time.Second).String()
+	}
+	deleteInvocation := "No"
+	if cc.DeleteInvocation {
+		deleteInvocation = "Yes"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Custom Command: %s", cc.Name),
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Allowed Roles", Value: roles, Inline: false},
+			{Name: "Allowed Channels", Value: channels, Inline: false},
+			{Name: "Cooldown", Value: cooldown, Inline: true},
+			{Name: "Delete Invocation", Value: deleteInvocation, Inline: true},
+			{Name: "Uses", Value: fmt.Sprintf("%d", cc.UseCount), Inline: true},
+		},
+	}
+	respondEmbed(s, i, embed)
+}
+
+// toggleID adds id to the list, or removes it if it's already there
+func toggleID(ids []string, id string) []string {
+	for idx, existing := range ids {
+		if existing == id {
+			return append(ids[:idx:idx], ids[idx+1:]...)
+		}
+	}
+	return append(ids, id)
+}
+
 func (ch *CommandHandler) tagHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	subcommand := getSubcommandName(i)
 
@@ -432,15 +796,34 @@ func (ch *CommandHandler) tagHandler(s *discordgo.Session, i *discordgo.Interact
 			return
 		}
 
-		ch.bot.DB.IncrementTagUse(i.GuildID, name)
-		respond(s, i, tag.Content)
+		ch.bot.DB.IncrementTagUse(i.GuildID, tag.Name)
+		tctx := &templateContext{
+			Session:   s,
+			GuildID:   i.GuildID,
+			ChannelID: i.ChannelID,
+			User:      i.Member.User,
+			Member:    i.Member,
+			Args:      strings.Fields(getStringOption(i, "args")),
+		}
+		content := renderTemplate(tag.Content, tctx)
+		embed := renderResponseEmbed(tag.Embed, tctx)
+		if embed == nil {
+			respond(s, i, content)
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Embeds:  []*discordgo.MessageEmbed{embed},
+			},
+		})
 
 	case "add":
 		name := getStringOption(i, "name")
 		content := getStringOption(i, "content")
 
-		existing, _ := ch.bot.DB.GetTag(i.GuildID, name)
-		if existing != nil {
+		if taken, _ := ch.bot.DB.TagNameTaken(i.GuildID, name); taken {
 			respondEphemeral(s, i, "A tag with that name already exists.")
 			return
 		}
@@ -463,6 +846,17 @@ func (ch *CommandHandler) tagHandler(s *discordgo.Session, i *discordgo.Interact
 			return
 		}
 
+		// Removing an alias leaves the tag itself alone
+		if existing.Name != name {
+			if _, err := ch.bot.DB.DeleteTagAlias(i.GuildID, name); err != nil {
+				respondEphemeral(s, i, "Failed to delete alias.")
+				return
+			}
+			respondEmbed(s, i, successEmbed("Alias Deleted",
+				fmt.Sprintf("`%s` no longer points to `%s`.", name, existing.Name)))
+			return
+		}
+
 		err := ch.bot.DB.DeleteTag(i.GuildID, name)
 		if err != nil {
 			respondEphemeral(s, i, "Failed to delete tag.")
@@ -491,24 +885,197 @@ func (ch *CommandHandler) tagHandler(s *discordgo.Session, i *discordgo.Interact
 		}
 
 		respondEmbed(s, i, embed)
+
+	case "alias":
+		ch.tagAliasHandler(s, i)
+
+	case "search":
+		ch.tagSearchHandler(s, i)
+
+	case "info":
+		ch.tagInfoHandler(s, i)
+
+	case "claim":
+		ch.tagClaimHandler(s, i)
+
+	case "embed":
+		ch.responseEmbedHandler(s, i, responseEmbedTag, getStringOption(i, "name"))
 	}
 }
 
+func (ch *CommandHandler) tagAliasHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := getStringOption(i, "name")
+	alias := strings.TrimSpace(getStringOption(i, "alias"))
+
+	tag, err := ch.bot.DB.GetTag(i.GuildID, name)
+	if err != nil || tag == nil {
+		respondEphemeral(s, i, "Tag not found.")
+		return
+	}
+	if tag.CreatedBy != i.Member.User.ID && !isModerator(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "Only the tag's owner or a moderator can add aliases.")
+		return
+	}
+	if alias == "" {
+		respondEphemeral(s, i, "Please give the alias a name.")
+		return
+	}
+	if taken, _ := ch.bot.DB.TagNameTaken(i.GuildID, alias); taken {
+		respondEphemeral(s, i, "A tag or alias with that name already exists.")
+		return
+	}
+
+	if err := ch.bot.DB.CreateTagAlias(i.GuildID, alias, tag.ID, i.Member.User.ID); err != nil {
+		respondEphemeral(s, i, "Failed to create alias.")
+		return
+	}
+
+	respondEmbed(s, i, successEmbed("Alias Created", fmt.Sprintf("`%s` now points to `%s`.", alias, tag.Name)))
+}
+
+func (ch *CommandHandler) tagSearchHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	query := strings.ToLower(strings.TrimSpace(getStringOption(i, "text")))
+	if query == "" {
+		respondEphemeral(s, i, "Please enter something to search for.")
+		return
+	}
+
+	// Content is stored encrypted, so matching happens after decryption
+	tags, err := ch.bot.DB.ListTags(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to search tags.")
+		return
+	}
+	aliases, _ := ch.bot.DB.GetTagAliases(i.GuildID)
+
+	var nameMatches, contentMatches []string
+	for _, tag := range tags {
+		nameHit := strings.Contains(strings.ToLower(tag.Name), query)
+		for _, alias := range aliases[tag.ID] {
+			if strings.Contains(strings.ToLower(alias), query) {
+				nameHit = true
+			}
+		}
+		line := fmt.Sprintf("`%s` — %s", tag.Name, truncate(strings.ReplaceAll(tag.Content, "\n", " "), 80))
+		if nameHit {
+			nameMatches = append(nameMatches, line)
+		} else if strings.Contains(strings.ToLower(tag.Content), query) {
+			contentMatches = append(contentMatches, line)
+		}
+	}
+
+	results := append(nameMatches, contentMatches...)
+	if len(results) == 0 {
+		respondEphemeral(s, i, "No tags matched your search.")
+		return
+	}
+
+	total := len(results)
+	if len(results) > 15 {
+		results = results[:15]
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Tag Search: %s", truncate(query, 100)),
+		Description: strings.Join(results, "\n"),
+		Color:       0x5865F2,
+	}
+	if total > len(results) {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Showing %d of %d matches", len(results), total)}
+	}
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) tagInfoHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	tag, err := ch.bot.DB.GetTag(i.GuildID, getStringOption(i, "name"))
+	if err != nil || tag == nil {
+		respondEphemeral(s, i, "Tag not found.")
+		return
+	}
+
+	aliasList := "None"
+	if aliases, err := ch.bot.DB.GetTagAliases(i.GuildID); err == nil && len(aliases[tag.ID]) > 0 {
+		names := make([]string, len(aliases[tag.ID]))
+		for idx, alias := range aliases[tag.ID] {
+			names[idx] = "`" + alias + "`"
+		}
+		aliasList = truncate(strings.Join(names, ", "), 1024)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Tag: %s", tag.Name),
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Owner", Value: fmt.Sprintf("<@%s>", tag.CreatedBy), Inline: true},
+			{Name: "Uses", Value: fmt.Sprintf("%d", tag.UseCount), Inline: true},
+			{Name: "Created", Value: fmt.Sprintf("<t:%d:R>", tag.CreatedAt.Unix()), Inline: true},
+			{Name: "Aliases", Value: aliasList, Inline: false},
+		},
+	}
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) tagClaimHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	tag, err := ch.bot.DB.GetTag(i.GuildID, getStringOption(i, "name"))
+	if err != nil || tag == nil {
+		respondEphemeral(s, i, "Tag not found.")
+		return
+	}
+	if tag.CreatedBy == i.Member.User.ID {
+		respondEphemeral(s, i, "You already own this tag.")
+		return
+	}
+
+	if _, err := s.GuildMember(i.GuildID, tag.CreatedBy); err == nil {
+		respondEphemeral(s, i, "This tag's owner is still in the server.")
+		return
+	} else if restErr, ok := err.(*discordgo.RESTError); !ok || restErr.Response == nil || restErr.Response.StatusCode != 404 {
+		respondEphemeral(s, i, "Couldn't check whether the owner is still here. Please try again later.")
+		return
+	}
+
+	if err := ch.bot.DB.SetTagOwner(tag.ID, i.Member.User.ID); err != nil {
+		respondEphemeral(s, i, "Failed to claim tag.")
+		return
+	}
+
+	respondEmbed(s, i, successEmbed("Tag Claimed", fmt.Sprintf("You now own `%s`.", tag.Name)))
+}
+
 func (ch *CommandHandler) keywordHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	subcommand := getSubcommandName(i)
 
 	switch subcommand {
 	case "add":
 		keyword := getStringOption(i, "keyword")
+		kn := &database.KeywordNotification{
+			UserID:        i.Member.User.ID,
+			Keyword:       keyword,
+			MatchMode:     database.KeywordMatchContains,
+			CaseSensitive: getBoolOption(i, "case_sensitive"),
+		}
+		if match := getStringOption(i, "match"); match != "" {
+			kn.MatchMode = match
+		}
+		if !getBoolOption(i, "all_servers") {
+			kn.GuildID = &i.GuildID
+		}
+		if _, err := keywordPattern(kn); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("That isn't a valid regular expression: %s", err))
+			return
+		}
 
-		err := ch.bot.DB.AddKeywordNotification(i.Member.User.ID, i.GuildID, keyword)
+		err := ch.bot.DB.AddKeywordNotification(kn)
 		if err != nil {
 			respondEphemeral(s, i, "Failed to add keyword. It may already be tracked.")
 			return
 		}
 
+		where := "in this server"
+		if kn.GuildID == nil {
+			where = "in any server"
+		}
 		embed := successEmbed("Keyword Added",
-			fmt.Sprintf("You will be notified when `%s` is mentioned.", keyword))
+			fmt.Sprintf("You will be notified when `%s` is mentioned %s.", keyword, where))
 		respondEmbedEphemeral(s, i, embed)
 
 	case "remove":
@@ -533,28 +1100,184 @@ func (ch *CommandHandler) keywordHandler(s *discordgo.Session, i *discordgo.Inte
 
 		var list []string
 		for _, kw := range keywords {
-			list = append(list, fmt.Sprintf("`%s`", kw.Keyword))
+			var flags []string
+			if kw.MatchMode != database.KeywordMatchContains {
+				flags = append(flags, kw.MatchMode)
+			}
+			if kw.CaseSensitive {
+				flags = append(flags, "case sensitive")
+			}
+			switch {
+			case kw.GuildID == nil || *kw.GuildID == "":
+				flags = append(flags, "all servers")
+			case *kw.GuildID != i.GuildID:
+				name := *kw.GuildID
+				if guild, err := s.State.Guild(name); err == nil {
+					name = guild.Name
+				}
+				flags = append(flags, name)
+			}
+			line := fmt.Sprintf("`%s`", kw.Keyword)
+			if len(flags) > 0 {
+				line += " — " + strings.Join(flags, ", ")
+			}
+			list = append(list, line)
 		}
 
 		embed := &discordgo.MessageEmbed{
 			Title:       fmt.Sprintf("Your Keywords (%d)", len(keywords)),
-			Description: strings.Join(list, ", "),
+			Description: truncate(strings.Join(list, "\n"), 4096),
 			Color:       0x5865F2,
 		}
+		if settings, err := ch.bot.DB.GetKeywordSettings(i.Member.User.ID); err == nil {
+			embed.Fields = keywordSettingsFields(settings)
+		}
 
 		respondEmbedEphemeral(s, i, embed)
+
+	case "quiet", "ignore", "digest":
+		ch.keywordSettingsHandler(s, i, subcommand)
+	}
+}
+
+func (ch *CommandHandler) keywordSettingsHandler(s *discordgo.Session, i *discordgo.InteractionCreate, subcommand string) {
+	settings, err := ch.bot.DB.GetKeywordSettings(i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get your keyword settings.")
+		return
+	}
+
+	var message string
+	switch subcommand {
+	case "quiet":
+		var start, end *int64
+		for _, opt := range getOptions(i) {
+			value := opt.IntValue()
+			switch opt.Name {
+			case "start":
+				start = &value
+			case "end":
+				end = &value
+			}
+		}
+		switch {
+		case start == nil && end == nil:
+			settings.QuietStart, settings.QuietEnd = -1, -1
+			message = "Quiet hours turned off."
+		case start == nil || end == nil || *start == *end:
+			respondEphemeral(s, i, "Give both a start and an end hour, and make them different.")
+			return
+		default:
+			settings.QuietStart, settings.QuietEnd = int(*start), int(*end)
+			message = fmt.Sprintf("Alerts between %02d:00 and %02d:00 will wait until quiet hours end.", *start, *end)
+			if tz, _ := ch.bot.DB.GetUserTimezone(i.Member.User.ID); tz == "" {
+				message += " You haven't set a timezone, so these hours are in UTC."
+			}
+		}
+
+	case "ignore":
+		channel := getChannelOption(i, "channel")
+		settings.IgnoredChannels = toggleID(settings.IgnoredChannels, channel.ID)
+		message = fmt.Sprintf("You'll get alerts from <#%s> again.", channel.ID)
+		if slices.Contains(settings.IgnoredChannels, channel.ID) {
+			message = fmt.Sprintf("You won't get alerts from <#%s>.", channel.ID)
+		}
+
+	case "digest":
+		settings.DigestMinutes = int(getIntOption(i, "minutes"))
+		message = "You'll get each alert right away."
+		if settings.DigestMinutes > 0 {
+			message = fmt.Sprintf("Alerts will be collected and sent together every %d minutes.", settings.DigestMinutes)
+		}
+	}
+
+	if err := ch.bot.DB.SetKeywordSettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save your keyword settings.")
+		return
+	}
+	respondEmbedEphemeral(s, i, successEmbed("Keyword Settings Updated", message))
+}
+
+// keywordSettingsFields summarises how a member's keyword alerts are delivered
+func keywordSettingsFields(settings *database.KeywordSettings) []*discordgo.MessageEmbedField {
+	quiet := "Off"
+	if settings.QuietStart >= 0 && settings.QuietEnd >= 0 && settings.QuietStart != settings.QuietEnd {
+		quiet = fmt.Sprintf("%02d:00 – %02d:00", settings.QuietStart, settings.QuietEnd)
+	}
+	digest := "Off"
+	if settings.DigestMinutes > 0 {
+		digest = fmt.Sprintf("Every %d min", settings.DigestMinutes)
+	}
+	ignored := "None"
+	if len(settings.IgnoredChannels) > 0 {
+		mentions := make([]string, len(settings.IgnoredChannels))
+		for idx, id := range settings.IgnoredChannels {
+			mentions[idx] = "<#" + id + ">"
+		}
+		ignored = truncate(strings.Join(mentions, ", "), 1024)
+	}
+
+	return []*discordgo.MessageEmbedField{
+		{Name: "Quiet Hours", Value: quiet, Inline: true},
+		{Name: "Digest", Value: digest, Inline: true},
+		{Name: "Ignored Channels", Value: ignored, Inline: false},
 	}
 }
 
 func (ch *CommandHandler) historyHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := i.Member.User.ID
+
+	switch getSubcommandName(i) {
+	case "optout":
+		if err := ch.bot.DB.SetCommandHistoryOptOut(userID, true); err != nil {
+			respondEphemeral(s, i, "Failed to update your history setting.")
+			return
+		}
+		msg := "Your commands will no longer be recorded."
+		if getBoolOption(i, "delete_existing") {
+			deleted, err := ch.bot.DB.DeleteUserCommandHistory(userID)
+			if err != nil {
+				respondEphemeral(s, i, msg+" Deleting your existing history failed, please try again.")
+				return
+			}
+			msg += fmt.Sprintf(" Deleted %d recorded command(s).", deleted)
+		}
+		respondEmbedEphemeral(s, i, successEmbed("History Disabled", msg))
+		return
+
+	case "optin":
+		if err := ch.bot.DB.SetCommandHistoryOptOut(userID, false); err != nil {
+			respondEphemeral(s, i, "Failed to update your history setting.")
+			return
+		}
+		respondEmbedEphemeral(s, i, successEmbed("History Enabled", "Your commands will be recorded again."))
+		return
+	}
+
 	limit := int(getIntOption(i, "limit"))
 	if limit == 0 {
 		limit = 10
 	}
 
-	history, err := ch.bot.DB.GetCommandHistory(i.GuildID, limit)
+	var history []database.CommandHistory
+	var err error
+	title := "Command History"
+	if getSubcommandName(i) == "server" {
+		if !isModerator(s, i.GuildID, userID) {
+			respondEphemeral(s, i, "You need moderator permissions to view the server's command history.")
+			return
+		}
+		history, err = ch.bot.DB.GetCommandHistory(i.GuildID, limit)
+	} else {
+		title = "Your Command History"
+		history, err = ch.bot.DB.GetUserCommandHistory(i.GuildID, userID, limit)
+	}
 	if err != nil || len(history) == 0 {
-		respondEphemeral(s, i, "No command history found.")
+		msg := "No command history found."
+		if ch.bot.DB.IsCommandHistoryOptedOut(userID) {
+			msg += " You've opted out, use `/history optin` to start recording again."
+		}
+		respondEphemeral(s, i, msg)
 		return
 	}
 
@@ -569,9 +1292,12 @@ func (ch *CommandHandler) historyHandler(s *discordgo.Session, i *discordgo.Inte
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "Command History",
+		Title:       title,
 		Description: strings.Join(list, "\n"),
 		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("History is kept for %d days", ch.bot.Config().Features.CommandHistoryDays),
+		},
 	}
 
 	respondEmbedEphemeral(s, i, embed)
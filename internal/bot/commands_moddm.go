@@ -0,0 +1,214 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerModDMCommands() {
+	ch.Register(&Command{
+		Name:        "moddm",
+		Description: "DM members when they're warned, timed out, kicked or banned",
+		Category:    "Administration",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "enable",
+				Description: "Start DMing members about moderation actions",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Stop DMing members about moderation actions",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "appeal",
+				Description: "Set the appeal link included in the DM",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "link",
+						Description: "Appeal form or server invite; leave empty to remove",
+						MaxLength:   512,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show the moderation DM settings",
+			},
+		},
+		Handler: ch.modDMHandler,
+	})
+}
+
+func (ch *CommandHandler) modDMHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure moderation DMs.")
+		return
+	}
+
+	cfg, err := ch.bot.DB.GetModDMConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get moderation DM settings.")
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch getSubcommandName(i) {
+	case "enable":
+		cfg.Enabled = true
+		embed = successEmbed("Moderation DMs Enabled", "Members will be DMed when they're warned, timed out, kicked or banned.")
+	case "disable":
+		cfg.Enabled = false
+		embed = successEmbed("Moderation DMs Disabled", "Members will no longer be DMed about moderation actions.")
+	case "appeal":
+		link := getStringOption(i, "link")
+		if link != "" {
+			if u, err := url.Parse(link); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				respondEphemeral(s, i, "The appeal link must be a full http(s) URL.")
+				return
+			}
+		}
+		cfg.AppealURL = link
+		if link == "" {
+			embed = successEmbed("Appeal Link Removed", "Moderation DMs no longer include an appeal link.")
+		} else {
+			embed = successEmbed("Appeal Link Set", "Moderation DMs will link to "+link)
+		}
+	case "status":
+		status := "Disabled"
+		if cfg.Enabled {
+			status = "Enabled"
+		}
+		appeal := "None"
+		if cfg.AppealURL != "" {
+			appeal = cfg.AppealURL
+		}
+		respondEmbedEphemeral(s, i, &discordgo.MessageEmbed{
+			Title: "Moderation DMs",
+			Color: 0xFF69B4,
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Status", Value: status, Inline: true},
+				{Name: "Appeal Link", Value: appeal, Inline: true},
+			},
+		})
+		return
+	}
+
+	if err := ch.bot.DB.SetModDMConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to save moderation DM settings.")
+		return
+	}
+	respondEmbed(s, i, embed)
+}
+
+// modDMActions describes each action in the DM sent to its target
+var modDMActions = map[string]struct {
+	phrase string // "You were <phrase> <guild>"
+	color  int
+}{
+	"warn":    {"warned in", 0xFEE75C},
+	"timeout": {"timed out in", 0xE67E22},
+	"kick":    {"kicked from", 0xE67E22},
+	"softban": {"softbanned from", 0xED4245},
+	"ban":     {"banned from", 0xED4245},
+}
+
+// sendModDM tells the target of a moderation action what happened, when the
+// server has moderation DMs on. It returns the status to record on the case,
+// or "" if no DM was attempted. Kicks and bans must send it first, since the
+// bot can't DM users it no longer shares a server with.
+func (b *Bot) sendModDM(s *discordgo.Session, guildID string, user *discordgo.User, action, reason string, duration time.Duration) string {
+	cfg, err := b.DB.GetModDMConfig(guildID)
+	if err != nil || !cfg.Enabled || user.Bot {
+		return ""
+	}
+
+	guildName := guildID
+	if guild, err := s.State.Guild(guildID); err == nil {
+		guildName = guild.Name
+	}
+	if reason == "" {
+		reason = "No reason provided"
+	}
+
+	info := modDMActions[action]
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("You were %s %s", info.phrase, guildName),
+		Color: info.color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Reason", Value: truncate(reason, 1024)},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if duration > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Duration", Value: formatDuration(duration), Inline: true,
+		})
+	}
+	if cfg.AppealURL != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Appeal", Value: cfg.AppealURL, Inline: true,
+		})
+	}
+
+	channel, err := s.UserChannelCreate(user.ID)
+	if err != nil {
+		return database.ModDMFailed
+	}
+	if _, err := s.ChannelMessageSendEmbed(channel.ID, embed); err != nil {
+		return database.ModDMFailed
+	}
+	return database.ModDMSent
+}
+
+// recordModCase stores a moderation command's action along with whether its
+// target was DMed about it. Returns the case ID, 0 if it couldn't be stored.
+func (b *Bot) recordModCase(guildID, moderatorID, targetID, action, reason, dmStatus string) int64 {
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+	id, err := b.DB.CreateModAction(guildID, moderatorID, targetID, action, reasonPtr, time.Now().UnixMilli())
+	if err != nil {
+		return 0
+	}
+	if dmStatus != "" {
+		b.DB.SetModActionDMStatus(id, dmStatus)
+	}
+	return id
+}
+
+// modDMNote is appended to a moderation command's reply to say whether the DM arrived
+func modDMNote(dmStatus string) string {
+	switch dmStatus {
+	case database.ModDMSent:
+		return "\n**DM:** Delivered"
+	case database.ModDMFailed:
+		return "\n**DM:** Couldn't be delivered"
+	}
+	return ""
+}
@@ -22,6 +22,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -31,7 +32,21 @@ func (ch *CommandHandler) registerModStatsCommands() {
 		Name:        "modstats",
 		Description: "View moderation statistics for this server",
 		Category:    "Moderation",
-		Handler:     ch.modStatsHandler,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "range",
+				Description: "Period to count actions over (default: all time)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Last 7 days", Value: 7},
+					{Name: "Last 30 days", Value: 30},
+					{Name: "Last 90 days", Value: 90},
+					{Name: "All time", Value: 0},
+				},
+			},
+		},
+		Handler: ch.modStatsHandler,
 	})
 
 	// Import mod history (from audit log/ban list)
@@ -91,14 +106,21 @@ func (ch *CommandHandler) modStatsHandler(s *discordgo.Session, i *discordgo.Int
 	banCount := len(bans)
 
 	// Get mod stats from database
-	stats, err := ch.bot.DB.GetModStats(i.GuildID)
+	days := int(getIntOption(i, "range"))
+	var since int64
+	period := "All time"
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days).UnixMilli()
+		period = fmt.Sprintf("Last %d days", days)
+	}
+	stats, err := ch.bot.DB.GetModStats(i.GuildID, since, 10)
 	if err != nil {
 		followUp(s, i, "Failed to get mod stats.")
 		return
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:     fmt.Sprintf("Moderator Statistics for %s", guild.Name),
+		Title:     fmt.Sprintf("Moderator Statistics for %s (%s)", guild.Name, period),
 		Color:     0xFF69B4,
 		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: guild.IconURL("128")},
 		Timestamp: time.Now().Format(time.RFC3339),
@@ -114,11 +136,12 @@ func (ch *CommandHandler) modStatsHandler(s *discordgo.Session, i *discordgo.Int
 
 	// Action breakdown
 	if stats.TotalActions > 0 {
-		actionText := fmt.Sprintf("**Bans:** %d\n**Unbans:** %d\n**Kicks:** %d\n**Timeouts:** %d",
+		actionText := fmt.Sprintf("**Bans:** %d\n**Unbans:** %d\n**Kicks:** %d\n**Timeouts:** %d\n**Warnings:** %d",
 			stats.ActionCounts["ban"],
 			stats.ActionCounts["unban"],
 			stats.ActionCounts["kick"],
-			stats.ActionCounts["timeout"])
+			stats.ActionCounts["timeout"],
+			stats.ActionCounts["warn"])
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
 			Name:   "Action Breakdown",
 			Value:  actionText,
@@ -127,7 +150,7 @@ func (ch *CommandHandler) modStatsHandler(s *discordgo.Session, i *discordgo.Int
 	}
 
 	// Top moderators
-	if len(stats.TopMods) > 0 {
+	if stats.TotalActions > 0 {
 		var topModsText strings.Builder
 		for idx, mod := range stats.TopMods {
 			if idx >= 5 {
@@ -159,9 +182,17 @@ func (ch *CommandHandler) modStatsHandler(s *discordgo.Session, i *discordgo.Int
 			if mod.Actions["unban"] > 0 {
 				breakdown = append(breakdown, fmt.Sprintf("%d unbans", mod.Actions["unban"]))
 			}
+			if mod.Actions["warn"] > 0 {
+				breakdown = append(breakdown, fmt.Sprintf("%d warnings", mod.Actions["warn"]))
+			}
+
+			trend := ""
+			if days > 0 {
+				trend = " " + modStatsTrend(mod.Count, mod.PreviousCount, days)
+			}
 
-			topModsText.WriteString(fmt.Sprintf("**%d. %s** - %d actions\n   %s\n\n",
-				idx+1, modName, mod.Count, strings.Join(breakdown, ", ")))
+			topModsText.WriteString(fmt.Sprintf("**%d. %s** - %d actions%s\n   %s\n\n",
+				idx+1, modName, mod.Count, trend, strings.Join(breakdown, ", ")))
 		}
 
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
@@ -172,18 +203,29 @@ func (ch *CommandHandler) modStatsHandler(s *discordgo.Session, i *discordgo.Int
 	} else {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
 			Name:   "No Tracked Actions",
-			Value:  "Run `/scanbans` to import moderation history from audit logs.",
+			Value:  "Run `/banlist scanbans` to import moderation history from audit logs.",
 			Inline: false,
 		})
 	}
 
 	embed.Footer = &discordgo.MessageEmbedFooter{
-		Text: "Use /scanbans to import audit log history",
+		Text: "Use /banlist scanbans to import audit log history",
 	}
 
 	followUpEmbed(s, i, embed)
 }
 
+// modStatsTrend compares a moderator's actions with the same length of time before the range
+func modStatsTrend(count, previous, days int) string {
+	switch {
+	case count > previous:
+		return fmt.Sprintf("(▲ %d vs previous %d days)", count-previous, days)
+	case count < previous:
+		return fmt.Sprintf("(▼ %d vs previous %d days)", previous-count, days)
+	}
+	return fmt.Sprintf("(same as previous %d days)", days)
+}
+
 func (ch *CommandHandler) importModHistoryHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
 		respondEphemeral(s, i, "You need administrator permission to scan bans.")
@@ -402,7 +444,7 @@ func (ch *CommandHandler) scanAuditLogs(s *discordgo.Session, i *discordgo.Inter
 			{Name: "Skipped (duplicates)", Value: strconv.Itoa(skipped), Inline: true},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Note: Audit logs only go back ~45 days. Use /scanbans bans for full ban list.",
+			Text: "Note: Audit logs only go back ~45 days. Use /banlist scanbans bans for full ban list.",
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
@@ -445,8 +487,19 @@ func (ch *CommandHandler) modHistoryHandler(s *discordgo.Session, i *discordgo.I
 			reason = *action.Reason
 		}
 
-		description.WriteString(fmt.Sprintf("**%s** by <@%s>\n└ %s\n└ <t:%d:R>\n\n",
-			strings.Title(action.Action), action.ModeratorID, truncate(reason, 50), action.Timestamp/1000))
+		dm := ""
+		switch action.DMStatus {
+		case database.ModDMSent:
+			dm = "\n└ DM delivered"
+		case database.ModDMFailed:
+			dm = "\n└ DM not delivered"
+		}
+		if action.UndoneBy != "" {
+			dm += fmt.Sprintf("\n└ Undone by <@%s>", action.UndoneBy)
+		}
+
+		description.WriteString(fmt.Sprintf("**%s** by <@%s>\n└ %s\n└ <t:%d:R>%s\n\n",
+			strings.Title(action.Action), action.ModeratorID, truncate(reason, 50), action.Timestamp/1000, dm))
 	}
 
 	embed := &discordgo.MessageEmbed{
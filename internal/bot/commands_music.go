@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
@@ -91,6 +93,7 @@ func (ch *CommandHandler) registerMusicCommands() {
 		Category:    "Music",
 		Handler:     ch.nowPlayingHandler,
 	})
+	ch.RegisterComponent("np", ch.handleNowPlayingComponent)
 
 	// Remove command
 	ch.Register(&Command{
@@ -134,6 +137,59 @@ func (ch *CommandHandler) registerMusicCommands() {
 		Handler: ch.moveTopHandler,
 	})
 
+	ch.Register(&Command{
+		Name:        "skipto",
+		Description: "Skip ahead to a track in the queue",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "position",
+				Description: "Position in queue (1-based)",
+				Required:    true,
+				MinValue:    floatPtr(1),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "keep",
+				Description: "Move the skipped tracks to the end of the queue instead of dropping them",
+				Required:    false,
+			},
+		},
+		Handler: ch.skipToHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "playnext",
+		Description: "Queue a track to play right after the current one",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "query",
+				Description: "URL or search query",
+				Required:    true,
+			},
+		},
+		Handler: ch.playNextHandler,
+	})
+
+	// Shuffle command
+	ch.Register(&Command{
+		Name:        "shuffle",
+		Description: "Shuffle the queue",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "smart",
+				Description: "Avoid playing the same person's tracks back to back",
+				Required:    false,
+			},
+		},
+		Handler: ch.shuffleHandler,
+	})
+
 	// Volume command
 	ch.Register(&Command{
 		Name:        "volume",
@@ -152,6 +208,91 @@ func (ch *CommandHandler) registerMusicCommands() {
 		Handler: ch.volumeHandler,
 	})
 
+	ch.Register(&Command{
+		Name:        "musicconfig",
+		Description: "Configure music command channels and queue limits",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Allow or disallow music commands in a text channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Text channel to toggle",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "clear",
+				Description: "Allow music commands in every channel again",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "limits",
+				Description: "Limit what members can queue (0 = no limit, DJs are exempt)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "queue",
+						Description: "Max tracks waiting in the queue",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    1000,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "duration",
+						Description: "Longest track in minutes",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    600,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "per_user",
+						Description: "Max queued tracks per member",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    100,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show the music command channels",
+			},
+		},
+		Handler: ch.musicConfigHandler,
+	})
+
+	// Loop command
+	ch.Register(&Command{
+		Name:        "loop",
+		Description: "Loop the current track or the whole queue",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "mode",
+				Description: "What to loop",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Off", Value: "off"},
+					{Name: "Track", Value: "track"},
+					{Name: "Queue", Value: "queue"},
+				},
+			},
+		},
+		Handler: ch.loopHandler,
+	})
+
 	// Join command
 	ch.Register(&Command{
 		Name:        "join",
@@ -295,6 +436,252 @@ func (ch *CommandHandler) registerMusicCommands() {
 		},
 		Handler: ch.musicHistoryHandler,
 	})
+
+	// Seek commands
+	ch.Register(&Command{
+		Name:        "seek",
+		Description: "Jump to a position in the current track",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "timestamp",
+				Description: "Position to jump to (e.g. 1:30, 90, 2m15s)",
+				Required:    true,
+			},
+		},
+		Handler: ch.seekHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "forward",
+		Description: "Skip ahead in the current track",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "seconds",
+				Description: "Seconds to skip ahead (default 10)",
+				Required:    false,
+				MinValue:    floatPtr(1),
+			},
+		},
+		Handler: ch.seekHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "rewind",
+		Description: "Go back in the current track",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "seconds",
+				Description: "Seconds to go back (default 10)",
+				Required:    false,
+				MinValue:    floatPtr(1),
+			},
+		},
+		Handler: ch.seekHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "replay",
+		Description: "Restart the current track from the beginning",
+		Category:    "Music",
+		Handler:     ch.seekHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "lyrics",
+		Description: "Show lyrics for the current track or a song",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "query",
+				Description: "Song to search for (default: the current track)",
+				Required:    false,
+			},
+		},
+		Handler: ch.lyricsHandler,
+	})
+	ch.RegisterComponent("lyrics", ch.handleLyricsComponent)
+	ch.RegisterComponent("search", ch.handleSearchComponent)
+
+	ch.Register(&Command{
+		Name:        "247",
+		Description: "Keep the bot in voice when the queue ends",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "enabled",
+				Description: "Enable or disable 24/7 mode",
+				Required:    true,
+			},
+		},
+		Handler: ch.stayConnectedHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "autoplay",
+		Description: "Keep playing related tracks when the queue runs out",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "enabled",
+				Description: "Enable or disable autoplay",
+				Required:    true,
+			},
+		},
+		Handler: ch.autoplayHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "normalize",
+		Description: "Even out the loudness of tracks",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "enabled",
+				Description: "Enable or disable volume normalization",
+				Required:    true,
+			},
+		},
+		Handler: ch.normalizeHandler,
+	})
+
+	sponsorChoices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(sponsorBlockCategories))
+	for _, c := range sponsorBlockCategories {
+		sponsorChoices = append(sponsorChoices, &discordgo.ApplicationCommandOptionChoice{Name: c.Label, Value: c.Name})
+	}
+	ch.Register(&Command{
+		Name:        "sponsorblock",
+		Description: "Skip sponsor reads, intros and other non-music segments in YouTube tracks",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "toggle",
+				Description: "Turn SponsorBlock skipping on or off",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Enable or disable SponsorBlock",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "category",
+				Description: "Choose whether a kind of segment is skipped",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "category",
+						Description: "Segment category",
+						Required:    true,
+						Choices:     sponsorChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "skip",
+						Description: "Whether to skip it",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show the SponsorBlock settings",
+			},
+		},
+		Handler: ch.sponsorBlockHandler,
+	})
+
+	presetChoices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(audioFilterPresets))
+	for _, p := range audioFilterPresets {
+		presetChoices = append(presetChoices, &discordgo.ApplicationCommandOptionChoice{Name: p.Label, Value: p.Name})
+	}
+	eqGain := func(name, description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        name,
+			Description: description,
+			Required:    false,
+			MinValue:    floatPtr(-10),
+			MaxValue:    10,
+		}
+	}
+	ch.Register(&Command{
+		Name:        "filter",
+		Description: "Apply audio filters to the music",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "preset",
+				Description: "Turn a filter preset on or off",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Filter preset",
+						Required:    true,
+						Choices:     presetChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Turn it on or off (default: toggle)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "speed",
+				Description: "Change playback speed without changing pitch",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionNumber,
+						Name:        "multiplier",
+						Description: "Speed multiplier (0.5 - 2.0, 1 for normal)",
+						Required:    true,
+						MinValue:    floatPtr(0.5),
+						MaxValue:    2,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "eq",
+				Description: "Adjust the equalizer",
+				Options: []*discordgo.ApplicationCommandOption{
+					eqGain("bass", "Bass gain in dB (-10 to 10)"),
+					eqGain("mid", "Mid gain in dB (-10 to 10)"),
+					eqGain("treble", "Treble gain in dB (-10 to 10)"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Remove all audio filters",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show the active audio filters",
+			},
+		},
+		Handler: ch.filterHandler,
+	})
 }
 
 
@@ -319,6 +706,12 @@ func (ch *CommandHandler) playHandler(s *discordgo.Session, i *discordgo.Interac
 
 	respondDeferred(s, i)
 
+	// Searches let the member pick the right result instead of taking the first match
+	if !strings.Contains(query, "://") && !isLocalFile(query) {
+		ch.playSearch(s, i, query)
+		return
+	}
+
 	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
 
 	// Connect if not already connected
@@ -329,30 +722,48 @@ func (ch *CommandHandler) playHandler(s *discordgo.Session, i *discordgo.Interac
 		}
 	}
 
+	// Spotify links are resolved to their closest YouTube matches
+	if kind, id, ok := ParseSpotifyURL(query); ok {
+		ch.playSpotify(s, i, player, channelID, kind, id)
+		return
+	}
+
+	if IsCollectionURL(query) {
+		ch.playCollection(s, i, player, channelID, query)
+		return
+	}
+
 	// Extract video info
-	info, err := ExtractInfo(query, ch.bot.Config.APIs.YouTubeAPIKey, ch.bot.Config.APIs.SoundCloudAuthToken)
+	info, err := ExtractInfo(query, ch.bot.Config().APIs.YouTubeAPIKey, ch.bot.Config().APIs.SoundCloudAuthToken)
 	if err != nil {
 		editResponse(s, i, "Failed to get track info: "+err.Error())
 		return
 	}
 
 	track := &Track{
-		Title:     info.Title,
-		URL:       info.URL,
-		Duration:  info.Duration,
-		Thumbnail: info.Thumbnail,
-		Requester: i.Member.User.Username,
-		IsLocal:   false,
+		Title:       info.Title,
+		URL:         info.URL,
+		Duration:    info.Duration,
+		Thumbnail:   info.Thumbnail,
+		Requester:   i.Member.User.Username,
+		RequesterID: i.Member.User.ID,
+		IsLocal:     false,
+		IsLive:      info.IsLive,
+		Source:      info.Source,
+		PageURL:     info.WebpageURL,
+	}
+
+	if reason, _ := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID).check(player, track); reason != "" {
+		editResponse(s, i, reason)
+		return
 	}
 
-	player.AddTrack(track)
-
 	// Save to database queue
 	var thumbnail *string
 	if info.Thumbnail != "" {
 		thumbnail = &info.Thumbnail
 	}
-	ch.bot.DB.AddToMusicQueue(&database.MusicQueueItem{
+	item := &database.MusicQueueItem{
 		GuildID:   i.GuildID,
 		ChannelID: channelID,
 		UserID:    i.Member.User.ID,
@@ -361,7 +772,12 @@ func (ch *CommandHandler) playHandler(s *discordgo.Session, i *discordgo.Interac
 		Duration:  info.Duration,
 		Thumbnail: thumbnail,
 		IsLocal:   false,
-	})
+	}
+	if err := ch.bot.DB.AddToMusicQueue(item); err == nil {
+		track.QueueID = item.ID
+	}
+
+	player.AddTrack(track)
 
 	// Start playing if not already
 	if !player.IsPlaying() {
@@ -378,10 +794,13 @@ func (ch *CommandHandler) playHandler(s *discordgo.Session, i *discordgo.Interac
 			Description: info.Title,
 			Color:       0xFF69B4,
 			Fields: []*discordgo.MessageEmbedField{
-				{Name: "Duration", Value: formatMusicDuration(info.Duration), Inline: true},
+				{Name: "Duration", Value: formatTrackLength(info.Duration, info.IsLive), Inline: true},
 				{Name: "Requested by", Value: i.Member.User.Username, Inline: true},
 			},
 		}
+		if info.Source != "" {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: info.Source}
+		}
 		if info.Thumbnail != "" {
 			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: info.Thumbnail}
 		}
@@ -394,9 +813,12 @@ func (ch *CommandHandler) playHandler(s *discordgo.Session, i *discordgo.Interac
 			Color:       0x5865F2,
 			Fields: []*discordgo.MessageEmbedField{
 				{Name: "Position", Value: fmt.Sprintf("#%d", len(queue)), Inline: true},
-				{Name: "Duration", Value: formatMusicDuration(info.Duration), Inline: true},
+				{Name: "Duration", Value: formatTrackLength(info.Duration, info.IsLive), Inline: true},
 			},
 		}
+		if info.Source != "" {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: info.Source}
+		}
 		if info.Thumbnail != "" {
 			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: info.Thumbnail}
 		}
@@ -404,6 +826,84 @@ func (ch *CommandHandler) playHandler(s *discordgo.Session, i *discordgo.Interac
 	}
 }
 
+// playCollection queues every track of a SoundCloud set or Bandcamp album as yt-dlp resolves them
+func (ch *CommandHandler) playCollection(s *discordgo.Session, i *discordgo.InteractionCreate, player *MusicPlayer, channelID, url string) {
+	var first *Track
+	queued := 0
+	lastUpdate := time.Now()
+	var queueErr error
+	limits := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID)
+	limited, queueFull := "", false
+
+	err := ExtractCollectionInfo(url, ch.bot.Config().APIs.YouTubeAPIKey, ch.bot.Config().APIs.SoundCloudAuthToken, func(info *VideoInfo) {
+		if queueErr != nil || queueFull {
+			return
+		}
+		track := &Track{
+			Title:       info.Title,
+			URL:         info.URL,
+			Duration:    info.Duration,
+			Thumbnail:   info.Thumbnail,
+			Requester:   i.Member.User.Username,
+			RequesterID: i.Member.User.ID,
+			Source:      info.Source,
+			PageURL:     info.WebpageURL,
+		}
+		if reason, full := limits.check(player, track); reason != "" {
+			limited, queueFull = reason, full
+			return
+		}
+		if queueErr = ch.queueTrack(i, player, channelID, track); queueErr != nil {
+			return
+		}
+		if first == nil {
+			first = track
+		}
+		queued++
+
+		if time.Since(lastUpdate) > 3*time.Second {
+			editResponse(s, i, fmt.Sprintf("⏳ Loading tracks... %d queued", queued))
+			lastUpdate = time.Now()
+		}
+	})
+	if queueErr != nil {
+		editResponse(s, i, "Failed to start playback: "+queueErr.Error())
+		return
+	}
+	if queued == 0 {
+		if limited != "" {
+			editResponse(s, i, limited)
+		} else if err != nil {
+			editResponse(s, i, "Failed to get track info: "+err.Error())
+		} else {
+			editResponse(s, i, "No playable tracks found at that link.")
+		}
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s Tracks Queued", first.Source),
+		Description: fmt.Sprintf("Starting with **%s**", first.Title),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Queued", Value: fmt.Sprintf("%d tracks", queued), Inline: true},
+			{Name: "Requested by", Value: i.Member.User.Username, Inline: true},
+		},
+	}
+	if limited != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Limited", Value: limited})
+	}
+	if first.Thumbnail != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: first.Thumbnail}
+	}
+
+	content := ""
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		Embeds:  &[]*discordgo.MessageEmbed{embed},
+	})
+}
+
 func (ch *CommandHandler) skipHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.GuildID == "" {
 		respondEphemeral(s, i, "This command can only be used in a server.")
@@ -511,7 +1011,7 @@ func (ch *CommandHandler) queueHandler(s *discordgo.Session, i *discordgo.Intera
 	var description strings.Builder
 
 	if nowPlaying != nil {
-		description.WriteString(fmt.Sprintf("**Now Playing:**\n🎵 %s [%s]\n\n", nowPlaying.Title, formatMusicDuration(nowPlaying.Duration)))
+		description.WriteString(fmt.Sprintf("**Now Playing:**\n🎵 %s [%s]\n\n", nowPlaying.Title, formatTrackLength(nowPlaying.Duration, nowPlaying.IsLive)))
 	}
 
 	if len(queue) > 0 {
@@ -521,7 +1021,7 @@ func (ch *CommandHandler) queueHandler(s *discordgo.Session, i *discordgo.Intera
 				description.WriteString(fmt.Sprintf("\n*...and %d more tracks*", len(queue)-10))
 				break
 			}
-			description.WriteString(fmt.Sprintf("%d. %s [%s]\n", idx+1, track.Title, formatMusicDuration(track.Duration)))
+			description.WriteString(fmt.Sprintf("%d. %s [%s]\n", idx+1, track.Title, formatTrackLength(track.Duration, track.IsLive)))
 		}
 	}
 
@@ -531,6 +1031,9 @@ func (ch *CommandHandler) queueHandler(s *discordgo.Session, i *discordgo.Intera
 		Color:       0x5865F2,
 		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d tracks in queue", len(queue))},
 	}
+	if nowPlaying != nil && nowPlaying.Thumbnail != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: nowPlaying.Thumbnail}
+	}
 
 	respondEmbed(s, i, embed)
 }
@@ -541,33 +1044,34 @@ func (ch *CommandHandler) nowPlayingHandler(s *discordgo.Session, i *discordgo.I
 		return
 	}
 
-	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
-	nowPlaying := player.NowPlaying()
-
-	if nowPlaying == nil {
+	player := ch.bot.MusicManager.FindPlayer(i.GuildID)
+	if player == nil || player.NowPlaying() == nil {
 		respondEphemeral(s, i, "Nothing is currently playing.")
 		return
 	}
 
-	embed := &discordgo.MessageEmbed{
-		Title:       "Now Playing",
-		Description: nowPlaying.Title,
-		Color:       0xFF69B4,
-		Fields: []*discordgo.MessageEmbedField{
-			{Name: "Duration", Value: formatMusicDuration(nowPlaying.Duration), Inline: true},
-			{Name: "Requested by", Value: nowPlaying.Requester, Inline: true},
+	embed, components := renderNowPlaying(player)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
 		},
+	})
+	if err != nil {
+		return
 	}
-
-	if nowPlaying.Thumbnail != "" {
-		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: nowPlaying.Thumbnail}
+	msg, err := s.InteractionResponse(i.Interaction)
+	if err != nil {
+		return
 	}
 
-	if player.IsPaused() {
-		embed.Footer = &discordgo.MessageEmbedFooter{Text: "⏸️ Paused"}
+	// Only the newest controller stays live; the old one is removed so its buttons can't be used
+	ctrl := &nowPlayingController{ChannelID: msg.ChannelID, MessageID: msg.ID, stop: make(chan struct{})}
+	if old := nowPlayingControllers.replace(i.GuildID, ctrl); old != nil {
+		s.ChannelMessageDelete(old.ChannelID, old.MessageID)
 	}
-
-	respondEmbed(s, i, embed)
+	go ch.bot.runNowPlaying(i.GuildID, ctrl)
 }
 
 func (ch *CommandHandler) removeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -626,6 +1130,42 @@ func (ch *CommandHandler) clearHandler(s *discordgo.Session, i *discordgo.Intera
 	respond(s, i, "🧹 Cleared the queue.")
 }
 
+func (ch *CommandHandler) shuffleHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	queue := player.GetQueue()
+	if len(queue) < 2 {
+		respondEphemeral(s, i, "There aren't enough tracks in the queue to shuffle.")
+		return
+	}
+
+	// Only DJ+ can reorder others' tracks
+	settings, _ := ch.bot.DB.GetMusicSettings(i.GuildID)
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+	if permLevel < MusicPermDJ {
+		for _, track := range queue {
+			if track.RequesterID != i.Member.User.ID {
+				respondEphemeral(s, i, "You need DJ role to shuffle other users' tracks.")
+				return
+			}
+		}
+	}
+
+	smart := getBoolOption(i, "smart")
+	shuffled := player.Shuffle(smart)
+	ch.saveQueueOrder(i.GuildID, shuffled)
+
+	if smart {
+		respond(s, i, fmt.Sprintf("🔀 Smart shuffled **%d** tracks.", len(shuffled)))
+	} else {
+		respond(s, i, fmt.Sprintf("🔀 Shuffled **%d** tracks.", len(shuffled)))
+	}
+}
+
 func (ch *CommandHandler) moveTopHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.GuildID == "" {
 		respondEphemeral(s, i, "This command can only be used in a server.")
@@ -660,6 +1200,156 @@ func (ch *CommandHandler) moveTopHandler(s *discordgo.Session, i *discordgo.Inte
 	respond(s, i, fmt.Sprintf("⬆️ Moved **%s** to the top of the queue.", track.Title))
 }
 
+func (ch *CommandHandler) skipToHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	if _, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID); err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+
+	position := int(getIntOption(i, "position"))
+	keep := getBoolOption(i, "keep")
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	queue := player.GetQueue()
+	if position < 1 || position > len(queue) {
+		respondEphemeral(s, i, fmt.Sprintf("Invalid position. Queue has %d tracks.", len(queue)))
+		return
+	}
+
+	// Only DJ+ can skip past others' tracks
+	settings, _ := ch.bot.DB.GetMusicSettings(i.GuildID)
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+	if permLevel < MusicPermDJ {
+		passed := queue[:position-1]
+		if nowPlaying := player.NowPlaying(); nowPlaying != nil {
+			passed = append([]*Track{nowPlaying}, passed...)
+		}
+		for _, track := range passed {
+			if track.RequesterID != i.Member.User.ID {
+				respondEphemeral(s, i, "You need DJ role to skip other users' tracks.")
+				return
+			}
+		}
+	}
+
+	if err := player.SkipTo(position-1, keep); err != nil {
+		respondEphemeral(s, i, "Failed to skip: "+err.Error())
+		return
+	}
+	ch.saveQueueOrder(i.GuildID, player.GetQueue())
+
+	message := fmt.Sprintf("⏭️ Skipped to **%s**.", queue[position-1].Title)
+	switch {
+	case position == 1:
+	case keep:
+		message += fmt.Sprintf(" Moved %d skipped tracks to the end of the queue.", position-1)
+	default:
+		message += fmt.Sprintf(" Dropped %d tracks.", position-1)
+	}
+	respond(s, i, message)
+}
+
+func (ch *CommandHandler) playNextHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	query := getStringOption(i, "query")
+	channelID, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+
+	respondDeferred(s, i)
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	if !player.IsConnected() {
+		if err := player.Connect(s, channelID); err != nil {
+			editResponse(s, i, "Failed to join voice channel: "+err.Error())
+			return
+		}
+	}
+
+	// There's no room for a picker here, so searches take the top match
+	if !strings.Contains(query, "://") && !isLocalFile(query) {
+		query = "ytsearch1:" + query
+	}
+	info, err := ExtractInfo(query, ch.bot.Config().APIs.YouTubeAPIKey, ch.bot.Config().APIs.SoundCloudAuthToken)
+	if err != nil {
+		editResponse(s, i, "Failed to get track info: "+err.Error())
+		return
+	}
+
+	track := &Track{
+		Title:       info.Title,
+		URL:         info.URL,
+		Duration:    info.Duration,
+		Thumbnail:   info.Thumbnail,
+		Requester:   i.Member.User.Username,
+		RequesterID: i.Member.User.ID,
+		IsLocal:     isLocalFile(info.URL),
+		IsLive:      info.IsLive,
+		Source:      info.Source,
+		PageURL:     info.WebpageURL,
+	}
+	if reason, _ := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID).check(player, track); reason != "" {
+		editResponse(s, i, reason)
+		return
+	}
+
+	var thumbnail *string
+	if track.Thumbnail != "" {
+		thumbnail = &track.Thumbnail
+	}
+	item := &database.MusicQueueItem{
+		GuildID:   i.GuildID,
+		ChannelID: channelID,
+		UserID:    i.Member.User.ID,
+		Title:     track.Title,
+		URL:       track.URL,
+		Duration:  track.Duration,
+		Thumbnail: thumbnail,
+		IsLocal:   track.IsLocal,
+	}
+	if err := ch.bot.DB.AddToMusicQueue(item); err == nil {
+		track.QueueID = item.ID
+	}
+	player.AddTrackNext(track)
+	ch.saveQueueOrder(i.GuildID, player.GetQueue())
+
+	if player.IsPlaying() {
+		embed := queuedTrackEmbed(player, track, false)
+		embed.Title = "Playing Next"
+		embed.Color = 0x5865F2
+		editResponseEmbed(s, i, embed)
+		return
+	}
+	if err := player.Play(); err != nil {
+		editResponse(s, i, "Failed to start playback: "+err.Error())
+		return
+	}
+	ch.bot.DB.AddToMusicHistory(i.GuildID, i.Member.User.ID, track.Title, track.URL)
+	editResponseEmbed(s, i, queuedTrackEmbed(player, track, false))
+}
+
+// saveQueueOrder writes the player's queue order back to the persisted queue
+func (ch *CommandHandler) saveQueueOrder(guildID string, queue []*Track) {
+	ids := make([]int64, 0, len(queue))
+	for _, track := range queue {
+		if track.QueueID != 0 {
+			ids = append(ids, track.QueueID)
+		}
+	}
+	ch.bot.DB.ReorderMusicQueue(guildID, ids)
+}
+
 func (ch *CommandHandler) volumeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.GuildID == "" {
 		respondEphemeral(s, i, "This command can only be used in a server.")
@@ -687,6 +1377,34 @@ func (ch *CommandHandler) volumeHandler(s *discordgo.Session, i *discordgo.Inter
 	respond(s, i, fmt.Sprintf("🔊 Volume set to %d%%", level))
 }
 
+func (ch *CommandHandler) loopHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, _ := ch.bot.DB.GetMusicSettings(i.GuildID)
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+
+	if permLevel < MusicPermDJ {
+		respondEphemeral(s, i, "You need DJ role to change the loop mode.")
+		return
+	}
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	switch getStringOption(i, "mode") {
+	case "track":
+		player.SetLoopMode(LoopTrack)
+		respond(s, i, "🔂 Looping the current track.")
+	case "queue":
+		player.SetLoopMode(LoopQueue)
+		respond(s, i, "🔁 Looping the queue.")
+	default:
+		player.SetLoopMode(LoopOff)
+		respond(s, i, "➡️ Loop disabled.")
+	}
+}
+
 func (ch *CommandHandler) joinHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.GuildID == "" {
 		respondEphemeral(s, i, "This command can only be used in a server.")
@@ -775,23 +1493,148 @@ func (ch *CommandHandler) musicRoleHandler(s *discordgo.Session, i *discordgo.In
 		if settings.DJRoleID != nil && *settings.DJRoleID != "" {
 			djRole = fmt.Sprintf("<@&%s>", *settings.DJRoleID)
 		}
-		if settings.ModRoleID != nil && *settings.ModRoleID != "" {
-			modRole = fmt.Sprintf("<@&%s>", *settings.ModRoleID)
+		if settings.ModRoleID != nil && *settings.ModRoleID != "" {
+			modRole = fmt.Sprintf("<@&%s>", *settings.ModRoleID)
+		}
+
+		embed := &discordgo.MessageEmbed{
+			Title: "Music Role Settings",
+			Color: 0x5865F2,
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "DJ Role", Value: djRole, Inline: true},
+				{Name: "Mod Role", Value: modRole, Inline: true},
+				{Name: "Volume", Value: fmt.Sprintf("%d%%", settings.Volume), Inline: true},
+				{Name: "24/7 Mode", Value: boolToEnabled(settings.StayConnected), Inline: true},
+				{Name: "Autoplay", Value: boolToEnabled(settings.Autoplay), Inline: true},
+				{Name: "Normalization", Value: boolToEnabled(settings.Normalize), Inline: true},
+			},
+		}
+		respondEmbed(s, i, embed)
+	}
+}
+
+func (ch *CommandHandler) musicConfigHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure music.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get music settings.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "channel":
+		channel := getChannelOption(i, "channel")
+		if channel == nil {
+			respondEphemeral(s, i, "Please choose a channel.")
+			return
+		}
+		removed := false
+		for idx, id := range settings.TextChannels {
+			if id == channel.ID {
+				settings.TextChannels = append(settings.TextChannels[:idx], settings.TextChannels[idx+1:]...)
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			settings.TextChannels = append(settings.TextChannels, channel.ID)
+		}
+		if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+			respondEphemeral(s, i, "Failed to save music settings.")
+			return
+		}
+		switch {
+		case !removed:
+			respond(s, i, fmt.Sprintf("✅ Music commands are now allowed in <#%s>.", channel.ID))
+		case len(settings.TextChannels) == 0:
+			respond(s, i, fmt.Sprintf("✅ Removed <#%s>. Music commands can be used anywhere again.", channel.ID))
+		default:
+			respond(s, i, fmt.Sprintf("✅ Music commands are no longer allowed in <#%s>.", channel.ID))
+		}
+
+	case "clear":
+		settings.TextChannels = nil
+		if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+			respondEphemeral(s, i, "Failed to save music settings.")
+			return
+		}
+		respond(s, i, "✅ Music commands can be used in any channel.")
+
+	case "limits":
+		for _, opt := range getOptions(i) {
+			switch opt.Name {
+			case "queue":
+				settings.MaxQueueLength = int(opt.IntValue())
+			case "duration":
+				settings.MaxTrackDuration = int(opt.IntValue()) * 60
+			case "per_user":
+				settings.MaxUserTracks = int(opt.IntValue())
+			}
+		}
+		if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+			respondEphemeral(s, i, "Failed to save music settings.")
+			return
 		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title:  "Music Limits",
+			Color:  0x57F287,
+			Fields: musicLimitFields(settings),
+			Footer: &discordgo.MessageEmbedFooter{Text: "DJs and music mods aren't limited"},
+		})
 
+	case "show":
+		channels := "Any channel"
+		if len(settings.TextChannels) > 0 {
+			channels = "<#" + strings.Join(settings.TextChannels, ">, <#") + ">"
+		}
 		embed := &discordgo.MessageEmbed{
-			Title: "Music Role Settings",
+			Title: "Music Settings",
 			Color: 0x5865F2,
-			Fields: []*discordgo.MessageEmbedField{
-				{Name: "DJ Role", Value: djRole, Inline: true},
-				{Name: "Mod Role", Value: modRole, Inline: true},
-				{Name: "Volume", Value: fmt.Sprintf("%d%%", settings.Volume), Inline: true},
-			},
+			Fields: append([]*discordgo.MessageEmbedField{
+				{Name: "Command Channels", Value: channels, Inline: false},
+			}, musicLimitFields(settings)...),
 		}
 		respondEmbed(s, i, embed)
 	}
 }
 
+// musicLimitFields lists a guild's queue and playlist limits as embed fields
+func musicLimitFields(settings *database.MusicSettings) []*discordgo.MessageEmbedField {
+	limit := func(n int, format func(int) string) string {
+		if n == 0 {
+			return "No limit"
+		}
+		return format(n)
+	}
+	count := func(n int) string { return strconv.Itoa(n) }
+
+	return []*discordgo.MessageEmbedField{
+		{Name: "Queue Length", Value: limit(settings.MaxQueueLength, count), Inline: true},
+		{Name: "Track Duration", Value: limit(settings.MaxTrackDuration, formatMusicDuration), Inline: true},
+		{Name: "Tracks per Member", Value: limit(settings.MaxUserTracks, count), Inline: true},
+		{Name: "Playlists per Member", Value: count(settings.PlaylistLimit), Inline: true},
+		{Name: "Tracks per Playlist", Value: count(settings.PlaylistTrackLimit), Inline: true},
+	}
+}
+
+// musicTextChannels returns the channels a guild limits music commands to, if any
+func (b *Bot) musicTextChannels(guildID string) []string {
+	settings, err := b.DB.GetMusicSettings(guildID)
+	if err != nil {
+		return nil
+	}
+	return settings.TextChannels
+}
+
 func (ch *CommandHandler) foldersHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if i.GuildID == "" {
 		respondEphemeral(s, i, "This command can only be used in a server.")
@@ -800,7 +1643,7 @@ func (ch *CommandHandler) foldersHandler(s *discordgo.Session, i *discordgo.Inte
 
 	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
 	if err != nil || settings.MusicFolder == nil || *settings.MusicFolder == "" {
-		respondEphemeral(s, i, "No music folder configured. Use `/musicfolder` to set one.")
+		respondEphemeral(s, i, "No music folder configured. Use `/musicsettings musicfolder` to set one.")
 		return
 	}
 
@@ -859,12 +1702,10 @@ func (ch *CommandHandler) filesHandler(s *discordgo.Session, i *discordgo.Intera
 	}
 
 	var files []string
-	audioExts := map[string]bool{".mp3": true, ".wav": true, ".ogg": true, ".flac": true, ".m4a": true, ".opus": true}
-
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			ext := strings.ToLower(filepath.Ext(entry.Name()))
-			if audioExts[ext] {
+			if localAudioExts[ext] {
 				files = append(files, entry.Name())
 			}
 		}
@@ -959,27 +1800,41 @@ func (ch *CommandHandler) localHandler(s *discordgo.Session, i *discordgo.Intera
 
 	fileName := filepath.Base(fullPath)
 	title := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	duration := 0
+	if indexed, _ := ch.bot.DB.GetLocalTrack(i.GuildID, filepath.Clean(filePath)); indexed != nil {
+		title = localTrackTitle(indexed)
+		duration = indexed.Duration
+	}
 
 	track := &Track{
-		Title:     title,
-		URL:       fullPath,
-		Duration:  0,
-		Thumbnail: "",
-		Requester: i.Member.User.Username,
-		IsLocal:   true,
+		Title:       title,
+		URL:         fullPath,
+		Duration:    duration,
+		Thumbnail:   "",
+		Requester:   i.Member.User.Username,
+		RequesterID: i.Member.User.ID,
+		IsLocal:     true,
 	}
 
-	player.AddTrack(track)
-	ch.bot.DB.AddToMusicQueue(&database.MusicQueueItem{
+	if reason, _ := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID).check(player, track); reason != "" {
+		editResponse(s, i, reason)
+		return
+	}
+
+	item := &database.MusicQueueItem{
 		GuildID:   i.GuildID,
 		ChannelID: channelID,
 		UserID:    i.Member.User.ID,
 		Title:     title,
 		URL:       fullPath,
-		Duration:  0,
+		Duration:  duration,
 		Thumbnail: nil,
 		IsLocal:   true,
-	})
+	}
+	if err := ch.bot.DB.AddToMusicQueue(item); err == nil {
+		track.QueueID = item.ID
+	}
+	player.AddTrack(track)
 
 	if !player.IsPlaying() {
 		if err := player.Play(); err != nil {
@@ -1026,33 +1881,19 @@ func (ch *CommandHandler) localAutocomplete(s *discordgo.Session, i *discordgo.I
 		}
 	}
 
-	var choices []*discordgo.ApplicationCommandOptionChoice
-	audioExts := map[string]bool{".mp3": true, ".wav": true, ".ogg": true, ".flac": true, ".m4a": true, ".opus": true}
-
-	// Walk through folders
-	filepath.Walk(*settings.MusicFolder, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			return nil
-		}
-
-		ext := strings.ToLower(filepath.Ext(path))
-		if !audioExts[ext] {
-			return nil
-		}
+	tracks, _, err := ch.bot.DB.SearchLocalTracks(i.GuildID, input, 25)
+	if err != nil {
+		respondAutocomplete(s, i, nil)
+		return
+	}
 
-		relPath, _ := filepath.Rel(*settings.MusicFolder, path)
-		if strings.Contains(strings.ToLower(relPath), strings.ToLower(input)) && len(choices) < 25 {
-			displayName := relPath
-			if len(displayName) > 100 {
-				displayName = "..." + displayName[len(displayName)-97:]
-			}
-			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
-				Name:  displayName,
-				Value: relPath,
-			})
-		}
-		return nil
-	})
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, t := range tracks {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(localTrackTitle(&t), 100),
+			Value: t.Path,
+		})
+	}
 
 	respondAutocomplete(s, i, choices)
 }
@@ -1071,45 +1912,29 @@ func (ch *CommandHandler) searchLocalHandler(s *discordgo.Session, i *discordgo.
 		return
 	}
 
-	var results []string
-	audioExts := map[string]bool{".mp3": true, ".wav": true, ".ogg": true, ".flac": true, ".m4a": true, ".opus": true}
-
-	filepath.Walk(*settings.MusicFolder, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			return nil
-		}
-
-		ext := strings.ToLower(filepath.Ext(path))
-		if !audioExts[ext] {
-			return nil
-		}
-
-		relPath, _ := filepath.Rel(*settings.MusicFolder, path)
-		if strings.Contains(strings.ToLower(relPath), query) {
-			results = append(results, relPath)
-		}
-		return nil
-	})
-
-	if len(results) == 0 {
+	results, total, err := ch.bot.DB.SearchLocalTracks(i.GuildID, query, 15)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to search the music library.")
+		return
+	}
+	if total == 0 {
 		respondEphemeral(s, i, "No results found for: "+query)
 		return
 	}
 
 	var description strings.Builder
-	for idx, result := range results {
-		if idx >= 15 {
-			description.WriteString(fmt.Sprintf("\n*...and %d more results*", len(results)-15))
-			break
-		}
-		description.WriteString(fmt.Sprintf("🎵 `%s`\n", result))
+	for _, t := range results {
+		description.WriteString(fmt.Sprintf("🎵 **%s** [%s]\n`%s`\n", localTrackTitle(&t), formatMusicDuration(t.Duration), t.Path))
+	}
+	if total > len(results) {
+		description.WriteString(fmt.Sprintf("\n*...and %d more results*", total-len(results)))
 	}
 
 	embed := &discordgo.MessageEmbed{
 		Title:       "Search Results",
 		Description: description.String(),
 		Color:       0x5865F2,
-		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d results found • Use /local <path> to play", len(results))},
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d results found • Use /musiclibrary local <path> to play", total)},
 	}
 
 	respondEmbed(s, i, embed)
@@ -1144,7 +1969,9 @@ func (ch *CommandHandler) musicFolderHandler(s *discordgo.Session, i *discordgo.
 	settings.MusicFolder = &path
 	ch.bot.DB.SetMusicSettings(settings)
 
-	respond(s, i, fmt.Sprintf("✅ Music folder set to: `%s`", path))
+	go ch.bot.scanMusicLibrary(i.GuildID, path)
+
+	respond(s, i, fmt.Sprintf("✅ Music folder set to: `%s`. Indexing the library in the background.", path))
 }
 
 func (ch *CommandHandler) musicHistoryHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -1179,6 +2006,371 @@ func (ch *CommandHandler) musicHistoryHandler(s *discordgo.Session, i *discordgo
 	respondEmbed(s, i, embed)
 }
 
+func (ch *CommandHandler) stayConnectedHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get music settings.")
+		return
+	}
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+	if permLevel < MusicPermMod {
+		respondEphemeral(s, i, "You need the music mod role to change 24/7 mode.")
+		return
+	}
+
+	settings.StayConnected = getBoolOption(i, "enabled")
+	if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save music settings.")
+		return
+	}
+
+	if settings.StayConnected {
+		respond(s, i, "🌙 24/7 mode enabled. I'll stay in voice when the queue ends.")
+		return
+	}
+
+	// Start the idle countdown now if nothing is playing
+	if player := ch.bot.MusicManager.FindPlayer(i.GuildID); player != nil && !player.IsPlaying() {
+		go ch.bot.onMusicQueueEnd(i.GuildID)
+	}
+	respond(s, i, fmt.Sprintf("☀️ 24/7 mode disabled. I'll leave voice after %d minutes without music.", int(musicIdleTimeout.Minutes())))
+}
+
+func (ch *CommandHandler) autoplayHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get music settings.")
+		return
+	}
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+	if permLevel < MusicPermDJ {
+		respondEphemeral(s, i, "You need DJ role to change autoplay.")
+		return
+	}
+
+	settings.Autoplay = getBoolOption(i, "enabled")
+	if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save music settings.")
+		return
+	}
+
+	if settings.Autoplay {
+		respond(s, i, "📻 Autoplay enabled. I'll keep playing related tracks when the queue runs out.")
+	} else {
+		respond(s, i, "📻 Autoplay disabled.")
+	}
+}
+
+func (ch *CommandHandler) normalizeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get music settings.")
+		return
+	}
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+	if permLevel < MusicPermDJ {
+		respondEphemeral(s, i, "You need DJ role to change volume normalization.")
+		return
+	}
+
+	settings.Normalize = getBoolOption(i, "enabled")
+	if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save music settings.")
+		return
+	}
+
+	// Restart the current track where it is so the change is heard right away
+	if player := ch.bot.MusicManager.FindPlayer(i.GuildID); player != nil {
+		player.SetNormalize(settings.Normalize)
+		if player.IsPlaying() {
+			player.Seek(int(player.Position().Seconds()))
+		}
+	}
+
+	if settings.Normalize {
+		respond(s, i, "🎚️ Volume normalization enabled. Tracks will play at a similar loudness.")
+	} else {
+		respond(s, i, "🎚️ Volume normalization disabled.")
+	}
+}
+
+func (ch *CommandHandler) sponsorBlockHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get music settings.")
+		return
+	}
+
+	sub := getSubcommandName(i)
+	if sub != "show" {
+		permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+		if permLevel < MusicPermDJ {
+			respondEphemeral(s, i, "You need DJ role to change SponsorBlock settings.")
+			return
+		}
+	}
+
+	switch sub {
+	case "toggle":
+		settings.SponsorBlock = getBoolOption(i, "enabled")
+
+	case "category":
+		// Start from the defaults the first time a server picks its own
+		categories := slices.Clone(sponsorBlockSelection(settings))
+		category := getStringOption(i, "category")
+		categories = slices.DeleteFunc(categories, func(c string) bool { return c == category })
+		if getBoolOption(i, "skip") {
+			categories = append(categories, category)
+		}
+		if len(categories) == 0 {
+			respondEphemeral(s, i, "At least one category has to be skipped. Use `/musicsettings sponsorblock toggle` to turn SponsorBlock off.")
+			return
+		}
+		settings.SponsorBlockCategories = categories
+	}
+
+	if sub != "show" {
+		if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+			respondEphemeral(s, i, "Failed to save music settings.")
+			return
+		}
+		if player := ch.bot.MusicManager.FindPlayer(i.GuildID); player != nil {
+			player.SetSponsorBlock(sponsorBlockFor(settings))
+		}
+	}
+
+	skipped := sponsorBlockSelection(settings)
+	var lines []string
+	for _, c := range sponsorBlockCategories {
+		mark := "➖"
+		if slices.Contains(skipped, c.Name) {
+			mark = "⏭️"
+		}
+		lines = append(lines, mark+" "+c.Label)
+	}
+	status := "Disabled"
+	if settings.SponsorBlock {
+		status = "Enabled"
+	}
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "SponsorBlock",
+		Description: strings.Join(lines, "\n"),
+		Color:       0x00D400,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: status, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Segments from sponsor.ajay.app • YouTube tracks only"},
+	})
+}
+
+func (ch *CommandHandler) filterHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get music settings.")
+		return
+	}
+	filters := ParseAudioFilters(settings.AudioFilters)
+
+	subcommand := getSubcommandName(i)
+	if subcommand == "show" {
+		respond(s, i, "🎛️ Active filters: "+filters.Describe())
+		return
+	}
+
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+	if permLevel < MusicPermDJ {
+		respondEphemeral(s, i, "You need DJ role to change audio filters.")
+		return
+	}
+
+	switch subcommand {
+	case "preset":
+		name := getStringOption(i, "name")
+		on := !filters.Preset(name)
+		for _, opt := range getOptions(i) {
+			if opt.Name == "enabled" {
+				on = opt.BoolValue()
+			}
+		}
+		if !filters.SetPreset(name, on) {
+			respondEphemeral(s, i, "Unknown filter preset.")
+			return
+		}
+	case "speed":
+		filters.Speed = getNumberOption(i, "multiplier")
+	case "eq":
+		filters.EQ = [3]int{int(getIntOption(i, "bass")), int(getIntOption(i, "mid")), int(getIntOption(i, "treble"))}
+	case "reset":
+		filters = AudioFilters{}
+	}
+
+	settings.AudioFilters = filters.String()
+	if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save music settings.")
+		return
+	}
+
+	// Restart the current track where it is so the change is heard right away
+	if player := ch.bot.MusicManager.FindPlayer(i.GuildID); player != nil {
+		player.SetFilters(filters)
+		if player.IsPlaying() {
+			player.Seek(int(player.Position().Seconds()))
+		}
+	}
+
+	if subcommand == "reset" {
+		respond(s, i, "🎛️ Audio filters cleared.")
+		return
+	}
+	respond(s, i, "🎛️ Active filters: "+filters.Describe())
+}
+
+// seekHandler handles /seek, /forward, /rewind and /replay
+func (ch *CommandHandler) seekHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	if _, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID); err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	nowPlaying := player.NowPlaying()
+	if nowPlaying == nil {
+		respondEphemeral(s, i, "Nothing is currently playing.")
+		return
+	}
+	if nowPlaying.IsLive {
+		respondEphemeral(s, i, "You can't seek in a live stream.")
+		return
+	}
+
+	// Only DJ+ can seek in others' tracks
+	settings, _ := ch.bot.DB.GetMusicSettings(i.GuildID)
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+	if nowPlaying.RequesterID != i.Member.User.ID && permLevel < MusicPermDJ {
+		respondEphemeral(s, i, "You need DJ role to seek in other users' tracks.")
+		return
+	}
+
+	current := int(player.Position().Seconds())
+	step := int(getIntOption(i, "seconds"))
+	if step == 0 {
+		step = 10
+	}
+
+	var target int
+	var message string
+	switch i.ApplicationCommandData().Name {
+	case "seek":
+		t, err := parseTimestamp(getStringOption(i, "timestamp"))
+		if err != nil {
+			respondEphemeral(s, i, "Invalid timestamp. Use a format like `1:30`, `90` or `2m15s`.")
+			return
+		}
+		target = t
+		message = "⏩ Jumped to **%s**."
+	case "forward":
+		target = current + step
+		message = "⏩ Skipped ahead to **%s**."
+	case "rewind":
+		target = max(current-step, 0)
+		message = "⏪ Rewound to **%s**."
+	case "replay":
+		target = 0
+		message = "🔁 Replaying from **%s**."
+	}
+
+	if err := player.Seek(target); err != nil {
+		respondEphemeral(s, i, "Failed to seek: "+err.Error())
+		return
+	}
+
+	position := formatMusicTimestamp(target)
+	if nowPlaying.Duration > 0 {
+		position += " / " + formatMusicTimestamp(nowPlaying.Duration)
+	}
+	respond(s, i, fmt.Sprintf(message, position))
+}
+
+// parseTimestamp parses h:mm:ss, m:ss, plain seconds or unit durations like 2m15s into seconds
+func parseTimestamp(input string) (int, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, fmt.Errorf("empty timestamp")
+	}
+
+	if strings.Contains(input, ":") {
+		parts := strings.Split(input, ":")
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("invalid timestamp")
+		}
+		total := 0
+		for _, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid timestamp")
+			}
+			total = total*60 + n
+		}
+		return total, nil
+	}
+
+	if n, err := strconv.Atoi(input); err == nil && n >= 0 {
+		return n, nil
+	}
+
+	d, err := parseDuration(input)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timestamp")
+	}
+	return int(d.Seconds()), nil
+}
+
+// formatMusicTimestamp formats a position in seconds, unlike formatMusicDuration 0 is 0:00
+func formatMusicTimestamp(seconds int) string {
+	if seconds == 0 {
+		return "0:00"
+	}
+	return formatMusicDuration(seconds)
+}
+
+// formatTrackLength formats a track's duration, showing live streams as such
+func formatTrackLength(seconds int, live bool) string {
+	if live {
+		return "🔴 LIVE"
+	}
+	return formatMusicDuration(seconds)
+}
+
 func formatMusicDuration(seconds int) string {
 	if seconds == 0 {
 		return "Unknown"
@@ -0,0 +1,310 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	notesPerPage  = 5
+	maxNoteLength = 1000
+)
+
+func (ch *CommandHandler) registerNoteCommands() {
+	noteIDOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "id",
+		Description: "Note ID, shown in /notes view",
+		Required:    true,
+		MinValue:    floatPtr(1),
+	}
+
+	ch.Register(&Command{
+		Name:        "notes",
+		Description: "Keep private staff notes about members",
+		Category:    "Moderation",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Add a note about a member",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Member the note is about",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "note",
+						Description: "Note text",
+						Required:    true,
+						MaxLength:   maxNoteLength,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "view",
+				Description: "View the notes about a member",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Member to view notes for",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "page",
+						Description: "Page to start on",
+						Required:    false,
+						MinValue:    floatPtr(1),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "edit",
+				Description: "Change the text of a note",
+				Options: []*discordgo.ApplicationCommandOption{
+					noteIDOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "note",
+						Description: "New note text",
+						Required:    true,
+						MaxLength:   maxNoteLength,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a note",
+				Options:     []*discordgo.ApplicationCommandOption{noteIDOption},
+			},
+		},
+		Handler: ch.notesHandler,
+	})
+
+	ch.RegisterComponent("notes", ch.handleNotesComponent)
+}
+
+func (ch *CommandHandler) notesHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need moderator permissions to use notes.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "add":
+		ch.addNote(s, i)
+	case "view":
+		user := getUserOption(i, "user")
+		if user == nil {
+			respondEphemeral(s, i, "Please specify a user.")
+			return
+		}
+		embed, components, err := ch.bot.renderNotes(i.GuildID, user.ID, int(getIntOption(i, "page")))
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get notes.")
+			return
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: components,
+				Flags:      discordgo.MessageFlagsEphemeral,
+			},
+		})
+	case "edit":
+		ch.editNote(s, i)
+	case "delete":
+		ch.deleteNote(s, i)
+	}
+}
+
+func (ch *CommandHandler) addNote(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := getUserOption(i, "user")
+	text := strings.TrimSpace(getStringOption(i, "note"))
+	if user == nil || text == "" {
+		respondEphemeral(s, i, "Please specify a user and a note.")
+		return
+	}
+
+	id, err := ch.bot.DB.AddUserNote(i.GuildID, user.ID, text, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to save the note.")
+		return
+	}
+	count, _ := ch.bot.DB.CountUserNotes(i.GuildID, user.ID)
+
+	respondEmbedEphemeral(s, i, successEmbed("Note Added",
+		fmt.Sprintf("Saved note #%d about <@%s>. They now have %d note(s).", id, user.ID, count)))
+}
+
+// ownedNote fetches a note the invoker may change: their own, or any note if they're an admin
+func (ch *CommandHandler) ownedNote(s *discordgo.Session, i *discordgo.InteractionCreate) *database.UserNote {
+	id := getIntOption(i, "id")
+	note, err := ch.bot.DB.GetUserNote(i.GuildID, id)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get the note.")
+		return nil
+	}
+	if note == nil {
+		respondEphemeral(s, i, fmt.Sprintf("There's no note #%d in this server.", id))
+		return nil
+	}
+	if note.CreatedBy != i.Member.User.ID && !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "Only the note's author or an administrator can change it.")
+		return nil
+	}
+	return note
+}
+
+func (ch *CommandHandler) editNote(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	text := strings.TrimSpace(getStringOption(i, "note"))
+	if text == "" {
+		respondEphemeral(s, i, "The note can't be empty.")
+		return
+	}
+	note := ch.ownedNote(s, i)
+	if note == nil {
+		return
+	}
+
+	if err := ch.bot.DB.UpdateUserNote(i.GuildID, note.ID, text, i.Member.User.ID); err != nil {
+		respondEphemeral(s, i, "Failed to update the note.")
+		return
+	}
+	respondEmbedEphemeral(s, i, successEmbed("Note Updated",
+		fmt.Sprintf("Note #%d about <@%s> now reads:\n%s", note.ID, note.UserID, text)))
+}
+
+func (ch *CommandHandler) deleteNote(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	note := ch.ownedNote(s, i)
+	if note == nil {
+		return
+	}
+
+	if err := ch.bot.DB.DeleteUserNote(i.GuildID, note.ID); err != nil {
+		respondEphemeral(s, i, "Failed to delete the note.")
+		return
+	}
+	respondEmbedEphemeral(s, i, successEmbed("Note Deleted",
+		fmt.Sprintf("Deleted note #%d about <@%s>.", note.ID, note.UserID)))
+}
+
+// renderNotes builds one page of a member's notes with navigation buttons
+func (b *Bot) renderNotes(guildID, userID string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	total, err := b.DB.CountUserNotes(guildID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	totalPages := max((total+notesPerPage-1)/notesPerPage, 1)
+	page = min(max(page, 1), totalPages)
+
+	notes, err := b.DB.GetUserNotesPage(guildID, userID, notesPerPage, (page-1)*notesPerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Staff Notes",
+		Description: fmt.Sprintf("Notes about <@%s>", userID),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d • %d note(s)", page, totalPages, total),
+		},
+	}
+	if len(notes) == 0 {
+		embed.Description += "\n\nNo notes yet. Add one with `/notes add`."
+	}
+	for _, n := range notes {
+		meta := fmt.Sprintf("by <@%s> <t:%d:R>", n.CreatedBy, n.CreatedAt.Unix())
+		if n.UpdatedAt != nil {
+			meta += fmt.Sprintf(" • edited by <@%s> <t:%d:R>", n.UpdatedBy, n.UpdatedAt.Unix())
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("Note #%d", n.ID),
+			Value: truncate(n.Note, 1000-len(meta)) + "\n" + meta,
+		})
+	}
+
+	if totalPages == 1 {
+		return embed, nil, nil
+	}
+
+	// Every button needs a unique custom ID, so the action is part of it even though only the page matters
+	navButton := func(action, emoji string, target int, disabled bool) discordgo.Button {
+		return discordgo.Button{
+			Emoji:    &discordgo.ComponentEmoji{Name: emoji},
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("notes:%s:%s:%d", action, userID, target),
+			Disabled: disabled,
+		}
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			navButton("first", "⏮️", 1, page == 1),
+			navButton("prev", "◀️", page-1, page == 1),
+			navButton("next", "▶️", page+1, page == totalPages),
+			navButton("last", "⏭️", totalPages, page == totalPages),
+		}},
+	}, nil
+}
+
+// handleNotesComponent handles "notes:<first|prev|next|last>:<userID>:<page>"
+func (ch *CommandHandler) handleNotesComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 4 {
+		return
+	}
+	if !isModerator(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need moderator permissions to use notes.")
+		return
+	}
+	page, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return
+	}
+
+	embed, components, err := ch.bot.renderNotes(i.GuildID, parts[2], page)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get notes.")
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
@@ -0,0 +1,562 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/config"
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Rows shown for a read-only console query
+	sqlConsoleMaxRows = 25
+
+	// How long a write statement waits for its Run button
+	sqlConfirmTimeout = 2 * time.Minute
+)
+
+// pendingSQL is a write statement waiting for the owner to confirm it
+type pendingSQL struct {
+	query   string
+	userID  string
+	expires time.Time
+}
+
+// Pending console writes, keyed by a random token carried in the button IDs
+var pendingSQLStatements sync.Map
+
+func (ch *CommandHandler) registerOwnerCommands() {
+	ch.Register(&Command{
+		Name:          "owner",
+		Description:   "Bot owner tools",
+		Category:      "Owner",
+		HomeGuildOnly: true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "leave",
+				Description: "Make the bot leave a server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "guild_id",
+						Description: "Server ID",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "blacklist",
+				Description: "Leave a server and refuse to join it again",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "guild_id",
+						Description: "Server ID",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "reason",
+						Description: "Why the server is blacklisted",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "broadcast",
+				Description: "Post an announcement to every server's mod log or system channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "Announcement text",
+						Required:    true,
+						MaxLength:   4000,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "title",
+						Description: "Announcement title",
+						Required:    false,
+						MaxLength:   256,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reload",
+				Description: "Reload config.json without restarting",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "presence",
+				Description: "Manage the rotating bot status",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "add",
+						Description: "Add a status to the rotation",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "type",
+								Description: "Activity type",
+								Required:    true,
+								Choices: []*discordgo.ApplicationCommandOptionChoice{
+									{Name: "Playing", Value: "playing"},
+									{Name: "Watching", Value: "watching"},
+									{Name: "Listening to", Value: "listening"},
+									{Name: "Competing in", Value: "competing"},
+								},
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "text",
+								Description: "Status text; {guilds}, {users}, {version} and {prefix} are filled in",
+								Required:    true,
+								MaxLength:   128,
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "remove",
+						Description: "Remove a status from the rotation",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionInteger,
+								Name:        "number",
+								Description: "Status number from /owner presence list",
+								Required:    true,
+								MinValue:    floatPtr(1),
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "list",
+						Description: "Show the status rotation",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "interval",
+						Description: "Set how often the status changes",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionInteger,
+								Name:        "seconds",
+								Description: "Seconds between changes",
+								Required:    true,
+								MinValue:    floatPtr(15),
+								MaxValue:    86400,
+							},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "sql",
+				Description: "Run SQL against the database; statements that write need confirming",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "query",
+						Description: "SQL statement",
+						Required:    true,
+					},
+				},
+			},
+		},
+		Handler: ch.ownerHandler,
+	})
+
+	ch.RegisterComponent("ownersql", ch.handleOwnerSQLComponent)
+}
+
+func (ch *CommandHandler) ownerHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil || !ch.bot.Config().IsOwner(i.Member.User.ID) {
+		respondEphemeral(s, i, "This command is only available to bot owners.")
+		return
+	}
+
+	if group, sub := getSubcommandGroup(i); group == "presence" {
+		ch.ownerPresenceHandler(s, i, sub)
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "leave":
+		ch.ownerLeaveHandler(s, i)
+	case "blacklist":
+		ch.ownerBlacklistHandler(s, i)
+	case "broadcast":
+		ch.ownerBroadcastHandler(s, i)
+	case "reload":
+		ch.ownerReloadHandler(s, i)
+	case "sql":
+		ch.ownerSQLHandler(s, i)
+	}
+}
+
+// guildLabel names a guild for replies, falling back to its ID
+func guildLabel(s *discordgo.Session, guildID string) string {
+	if guild, err := s.State.Guild(guildID); err == nil && guild.Name != "" {
+		return fmt.Sprintf("**%s** (`%s`)", guild.Name, guildID)
+	}
+	return fmt.Sprintf("`%s`", guildID)
+}
+
+func (ch *CommandHandler) ownerLeaveHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildID := strings.TrimSpace(getStringOption(i, "guild_id"))
+	if guildID == ch.bot.Config().HomeGuildID {
+		respondEphemeral(s, i, "I won't leave the home server, that's where these commands live.")
+		return
+	}
+
+	label := guildLabel(s, guildID)
+	if err := s.GuildLeave(guildID); err != nil {
+		respondEphemeral(s, i, "Failed to leave that server: "+err.Error())
+		return
+	}
+	respondEmbedEphemeral(s, i, successEmbed("Left Server", "Left "+label+"."))
+}
+
+func (ch *CommandHandler) ownerBlacklistHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildID := strings.TrimSpace(getStringOption(i, "guild_id"))
+	if guildID == ch.bot.Config().HomeGuildID {
+		respondEphemeral(s, i, "The home server can't be blacklisted.")
+		return
+	}
+	reason := getStringOption(i, "reason")
+	if reason == "" {
+		reason = "No reason provided"
+	}
+
+	if err := ch.bot.DB.AddBotBan(guildID, "server", reason, i.Member.User.ID); err != nil {
+		respondEphemeral(s, i, "Failed to blacklist that server.")
+		return
+	}
+
+	label := guildLabel(s, guildID)
+	desc := fmt.Sprintf("Blacklisted %s.\n**Reason:** %s", label, reason)
+	if _, err := s.State.Guild(guildID); err == nil {
+		if err := s.GuildLeave(guildID); err != nil {
+			desc += "\n\nFailed to leave it: " + err.Error()
+		} else {
+			desc += "\n\nLeft the server."
+		}
+	}
+	respondEmbedEphemeral(s, i, successEmbed("Server Blacklisted", desc+"\nUse `/botbans botunban` to lift it."))
+}
+
+func (ch *CommandHandler) ownerBroadcastHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	title := getStringOption(i, "title")
+	if title == "" {
+		title = "📢 Announcement"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: getStringOption(i, "message"),
+		Color:       0xFF69B4,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "From the " + s.State.User.Username + " team"},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	respondDeferredEphemeral(s, i)
+
+	var sent, skipped, failed int
+	for _, guild := range s.State.Guilds {
+		channelID := ch.bot.broadcastChannel(guild)
+		if channelID == "" {
+			skipped++
+			continue
+		}
+		if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	editResponseEmbed(s, i, successEmbed("Broadcast Sent",
+		fmt.Sprintf("Sent to **%d** servers.\nSkipped **%d** without a mod log or system channel, **%d** failed.", sent, skipped, failed)))
+}
+
+// broadcastChannel picks where an announcement goes in a guild: the mod log, which
+// staff read, or the system channel if there's no mod log
+func (b *Bot) broadcastChannel(guild *discordgo.Guild) string {
+	if settings, err := b.DB.GetGuildSettings(guild.ID); err == nil && settings.ModLogChannel != nil && *settings.ModLogChannel != "" {
+		return *settings.ModLogChannel
+	}
+	return guild.SystemChannelID
+}
+
+func (ch *CommandHandler) ownerReloadHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg, err := config.Load("config.json")
+	if err != nil {
+		respondEphemeral(s, i, "Failed to reload config: "+err.Error())
+		return
+	}
+
+	// Settings read once at startup keep their old value until a restart
+	old := ch.bot.Config()
+	var restart []string
+	if cfg.Token != old.Token {
+		restart = append(restart, "token")
+	}
+	if cfg.DatabasePath != old.DatabasePath {
+		restart = append(restart, "database_path")
+	}
+	if cfg.WebServer != old.WebServer {
+		restart = append(restart, "webserver")
+	}
+	if cfg.Music != old.Music {
+		restart = append(restart, "music")
+	}
+	if cfg.Redis != old.Redis {
+		restart = append(restart, "redis")
+	}
+	if cfg.Encryption != old.Encryption {
+		restart = append(restart, "encryption")
+	}
+
+	// Swap in the new config whole; handlers already running keep reading the old one
+	ch.bot.config.Store(cfg)
+	log.Printf("Config reloaded by %s", i.Member.User.Username)
+
+	desc := "`config.json` has been reloaded."
+	if len(restart) > 0 {
+		desc += "\n\nThese sections changed but only take effect after a restart: `" + strings.Join(restart, "`, `") + "`"
+	}
+	respondEmbedEphemeral(s, i, successEmbed("Config Reloaded", desc))
+}
+
+func (ch *CommandHandler) ownerPresenceHandler(s *discordgo.Session, i *discordgo.InteractionCreate, sub string) {
+	// The rotation reads the live config, so changes go to a copy that's swapped in
+	cfg := ch.bot.Config().Clone()
+
+	var embed *discordgo.MessageEmbed
+	switch sub {
+	case "add":
+		// Start from the default so adding a status doesn't drop it unexpectedly
+		if len(cfg.Presence.Statuses) == 0 {
+			cfg.Presence.Statuses = []config.PresenceStatus{defaultPresence}
+		}
+		status := config.PresenceStatus{Type: getStringOption(i, "type"), Text: getStringOption(i, "text")}
+		cfg.Presence.Statuses = append(cfg.Presence.Statuses, status)
+		embed = successEmbed("Status Added", fmt.Sprintf("Added **%s** %s as #%d.\nPreview: %s",
+			status.Type, status.Text, len(cfg.Presence.Statuses), ch.bot.fillPresence(status.Text)))
+	case "remove":
+		n := int(getIntOption(i, "number"))
+		if n < 1 || n > len(cfg.Presence.Statuses) {
+			respondEphemeral(s, i, "There's no status with that number. Check `/owner presence list`.")
+			return
+		}
+		removed := cfg.Presence.Statuses[n-1]
+		cfg.Presence.Statuses = append(cfg.Presence.Statuses[:n-1], cfg.Presence.Statuses[n:]...)
+		embed = successEmbed("Status Removed", fmt.Sprintf("Removed **%s** %s.", removed.Type, removed.Text))
+		if len(cfg.Presence.Statuses) == 0 {
+			embed.Description += "\nThe rotation is empty, so the default status is shown."
+		}
+	case "interval":
+		cfg.Presence.Interval = int(getIntOption(i, "seconds"))
+		embed = successEmbed("Status Interval Set", fmt.Sprintf("The status now changes every **%d** seconds.", cfg.Presence.Interval))
+	default:
+		var sb strings.Builder
+		for n, status := range ch.bot.presenceStatuses() {
+			fmt.Fprintf(&sb, "**%d.** %s %s\n", n+1, status.Type, status.Text)
+		}
+		respondEmbedEphemeral(s, i, &discordgo.MessageEmbed{
+			Title:       "Status Rotation",
+			Description: sb.String(),
+			Color:       0xFF69B4,
+			Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Changes every %d seconds", cfg.Presence.Interval)},
+		})
+		return
+	}
+
+	if err := ch.bot.saveConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to save config: "+err.Error())
+		return
+	}
+	respondEmbedEphemeral(s, i, embed)
+}
+
+func (ch *CommandHandler) ownerSQLHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	query := strings.TrimSpace(getStringOption(i, "query"))
+
+	result, err := ch.bot.DB.QueryReadOnly(query, sqlConsoleMaxRows)
+	if err == nil {
+		respondEmbedEphemeral(s, i, sqlResultEmbed(query, result))
+		return
+	}
+	if !errors.Is(err, database.ErrSQLWrites) {
+		respondEmbedEphemeral(s, i, errorEmbed("SQL Error", codeBlock(query)+"\n"+truncate(err.Error(), 1000)))
+		return
+	}
+
+	// Writes wait for an explicit confirmation; drop any nobody clicked
+	now := time.Now()
+	pendingSQLStatements.Range(func(key, value interface{}) bool {
+		if now.After(value.(*pendingSQL).expires) {
+			pendingSQLStatements.Delete(key)
+		}
+		return true
+	})
+
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+	pendingSQLStatements.Store(token, &pendingSQL{
+		query:   query,
+		userID:  i.Member.User.ID,
+		expires: time.Now().Add(sqlConfirmTimeout),
+	})
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "⚠️ Confirm Database Write",
+				Description: codeBlock(query) + "\nThis statement modifies the database and can't be undone. Take a backup first if you're unsure.",
+				Color:       0xFEE75C,
+				Footer:      &discordgo.MessageEmbedFooter{Text: "Expires in 2 minutes"},
+			}},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Run", Style: discordgo.DangerButton, CustomID: "ownersql:run:" + token},
+					discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "ownersql:cancel:" + token},
+				}},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleOwnerSQLComponent handles the confirm buttons, custom ID "ownersql:<run|cancel>:<token>"
+func (ch *CommandHandler) handleOwnerSQLComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 3 {
+		return
+	}
+	action, token := parts[1], parts[2]
+
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	value, ok := pendingSQLStatements.LoadAndDelete(token)
+	var pending *pendingSQL
+	if ok {
+		pending = value.(*pendingSQL)
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch {
+	case pending == nil || time.Now().After(pending.expires):
+		embed = errorEmbed("Confirmation Expired", "Run the statement again to get a new confirmation.")
+	case user == nil || user.ID != pending.userID || !ch.bot.Config().IsOwner(user.ID):
+		// Put it back for the owner who asked
+		pendingSQLStatements.Store(token, pending)
+		respondEphemeral(s, i, "Only the owner who ran this statement can confirm it.")
+		return
+	case action == "cancel":
+		embed = infoEmbed("Cancelled", codeBlock(pending.query)+"\nNothing was changed.")
+	default:
+		result, err := ch.bot.DB.ExecConsole(pending.query)
+		if err != nil {
+			embed = errorEmbed("SQL Error", codeBlock(pending.query)+"\n"+truncate(err.Error(), 1000))
+		} else {
+			log.Printf("[Owner] %s ran SQL: %s", user.Username, pending.query)
+			embed = successEmbed("Statement Executed", fmt.Sprintf("%s\n**%d** rows affected.", codeBlock(pending.query), result.RowsAffected))
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// sqlResultEmbed renders query results as a plain text table
+func sqlResultEmbed(query string, result *database.SQLResult) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "SQL Result",
+		Description: codeBlock(query),
+		Color:       0x5865F2,
+	}
+	if len(result.Columns) == 0 {
+		embed.Description += "\nStatement returned no columns."
+		return embed
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Join(result.Columns, " | "))
+	for _, row := range result.Rows {
+		line := "\n" + strings.Join(row, " | ")
+		// Leave room for the code fences and the query above
+		if sb.Len()+len(line) > 3000 {
+			result.Truncated = true
+			break
+		}
+		sb.WriteString(line)
+	}
+	embed.Description += "\n```\n" + sb.String() + "\n```"
+
+	footer := fmt.Sprintf("%d rows", len(result.Rows))
+	if result.Truncated {
+		footer = fmt.Sprintf("Showing the first rows only (limit %d)", sqlConsoleMaxRows)
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+	return embed
+}
+
+func codeBlock(query string) string {
+	return "```sql\n" + truncate(strings.ReplaceAll(query, "```", "'''"), 800) + "\n```"
+}
+
+// onGuildCreate leaves servers that have been blacklisted, both when invited
+// and when they come back online at startup
+func (b *Bot) onGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	ban, err := b.DB.GetBotBan(g.ID)
+	if err != nil || ban == nil || ban.BanType != "server" {
+		return
+	}
+
+	log.Printf("Leaving blacklisted server %s (%s): %s", g.Name, g.ID, ban.Reason)
+	if err := s.GuildLeave(g.ID); err != nil {
+		log.Printf("Failed to leave blacklisted server %s: %v", g.ID, err)
+	}
+}
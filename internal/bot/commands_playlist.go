@@ -0,0 +1,656 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerPlaylistCommands() {
+	nameOption := func(description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "name",
+			Description:  description,
+			Required:     true,
+			MaxLength:    50,
+			Autocomplete: true,
+		}
+	}
+
+	ch.Register(&Command{
+		Name:        "playlist",
+		Description: "Save and play your own playlists",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Create a new playlist",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Playlist name",
+						Required:    true,
+						MaxLength:   50,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "public",
+						Description: "Let everyone in the server play it (default: no)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Add a track to one of your playlists",
+				Options: []*discordgo.ApplicationCommandOption{
+					nameOption("Playlist to add to"),
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "query",
+						Description: "URL, search query or local file (folder/filename)",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "play",
+				Description: "Queue an entire playlist",
+				Options: []*discordgo.ApplicationCommandOption{
+					nameOption("Playlist to play"),
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "owner",
+						Description: "Play another member's public playlist",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "share",
+				Description: "Make one of your playlists public or private",
+				Options: []*discordgo.ApplicationCommandOption{
+					nameOption("Playlist to share"),
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "public",
+						Description: "Whether everyone in the server can play it",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List saved playlists",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Show another member's public playlists",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete one of your playlists",
+				Options: []*discordgo.ApplicationCommandOption{
+					nameOption("Playlist to delete"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "limits",
+				Description: "Set playlist limits for this server (Admin)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "playlists",
+						Description: "Max playlists per member",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "tracks",
+						Description: "Max tracks per playlist",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    500,
+					},
+				},
+			},
+		},
+		Handler:      ch.playlistHandler,
+		Autocomplete: ch.playlistAutocomplete,
+	})
+}
+
+func (ch *CommandHandler) playlistHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get music settings.")
+		return
+	}
+
+	userID := i.Member.User.ID
+	name := strings.TrimSpace(getStringOption(i, "name"))
+
+	switch getSubcommandName(i) {
+	case "create":
+		if name == "" {
+			respondEphemeral(s, i, "Please provide a playlist name.")
+			return
+		}
+		existing, err := ch.bot.DB.GetUserPlaylists(i.GuildID, userID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get your playlists.")
+			return
+		}
+		if len(existing) >= settings.PlaylistLimit {
+			respondEphemeral(s, i, fmt.Sprintf("You can only have %d playlists in this server.", settings.PlaylistLimit))
+			return
+		}
+		for _, p := range existing {
+			if strings.EqualFold(p.Name, name) {
+				respondEphemeral(s, i, fmt.Sprintf("You already have a playlist named **%s**.", p.Name))
+				return
+			}
+		}
+
+		public := getBoolOption(i, "public")
+		if _, err := ch.bot.DB.CreatePlaylist(i.GuildID, userID, name, public); err != nil {
+			respondEphemeral(s, i, "Failed to create playlist.")
+			return
+		}
+		visibility := "private"
+		if public {
+			visibility = "public"
+		}
+		respondEmbed(s, i, successEmbed("Playlist Created",
+			fmt.Sprintf("Created %s playlist **%s**. Add tracks with `/musiclibrary playlist add`.", visibility, name)))
+
+	case "add":
+		playlist := ch.ownPlaylist(s, i, name)
+		if playlist == nil {
+			return
+		}
+		if playlist.TrackCount >= settings.PlaylistTrackLimit {
+			respondEphemeral(s, i, fmt.Sprintf("Playlists can hold at most %d tracks in this server.", settings.PlaylistTrackLimit))
+			return
+		}
+
+		query := getStringOption(i, "query")
+		respondDeferred(s, i)
+
+		track := &database.PlaylistTrack{PlaylistID: playlist.ID}
+		if settings.MusicFolder != nil && *settings.MusicFolder != "" {
+			fullPath := filepath.Join(*settings.MusicFolder, query)
+			if info, err := os.Stat(fullPath); err == nil && !info.IsDir() {
+				track.URL = fullPath
+				track.Title = strings.TrimSuffix(filepath.Base(fullPath), filepath.Ext(fullPath))
+				track.IsLocal = true
+			}
+		}
+		if !track.IsLocal {
+			info, err := ExtractInfo(query, ch.bot.Config().APIs.YouTubeAPIKey, ch.bot.Config().APIs.SoundCloudAuthToken)
+			if err != nil {
+				editResponse(s, i, "Failed to get track info: "+err.Error())
+				return
+			}
+			// Store the page URL; stream URLs expire and are resolved again at play time
+			track.URL = info.WebpageURL
+			if track.URL == "" {
+				track.URL = query
+			}
+			track.Title = info.Title
+			track.Duration = info.Duration
+		}
+
+		if err := ch.bot.DB.AddPlaylistTrack(track); err != nil {
+			editResponse(s, i, "Failed to add track to playlist.")
+			return
+		}
+		editResponseEmbed(s, i, &discordgo.MessageEmbed{
+			Title:       "Added to Playlist",
+			Description: track.Title,
+			Color:       0x5865F2,
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Playlist", Value: playlist.Name, Inline: true},
+				{Name: "Position", Value: fmt.Sprintf("#%d", playlist.TrackCount+1), Inline: true},
+				{Name: "Duration", Value: formatMusicDuration(track.Duration), Inline: true},
+			},
+		})
+
+	case "play":
+		ownerID := userID
+		if owner := getUserOption(i, "owner"); owner != nil {
+			ownerID = owner.ID
+		}
+		playlist, err := ch.bot.DB.GetPlaylist(i.GuildID, ownerID, name)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get playlist.")
+			return
+		}
+		if playlist == nil || (ownerID != userID && !playlist.Public) {
+			respondEphemeral(s, i, "Playlist not found.")
+			return
+		}
+		ch.playPlaylist(s, i, playlist)
+
+	case "share":
+		playlist := ch.ownPlaylist(s, i, name)
+		if playlist == nil {
+			return
+		}
+		public := getBoolOption(i, "public")
+		if err := ch.bot.DB.SetPlaylistPublic(playlist.ID, public); err != nil {
+			respondEphemeral(s, i, "Failed to update playlist.")
+			return
+		}
+		if public {
+			respondEmbed(s, i, successEmbed("Playlist Shared",
+				fmt.Sprintf("Anyone can now play **%s** with `/musiclibrary playlist play owner:%s`.", playlist.Name, i.Member.User.Username)))
+		} else {
+			respondEmbed(s, i, successEmbed("Playlist Private", fmt.Sprintf("**%s** is now private.", playlist.Name)))
+		}
+
+	case "list":
+		target := i.Member.User
+		if user := getUserOption(i, "user"); user != nil {
+			target = user
+		}
+
+		playlists, err := ch.bot.DB.GetUserPlaylists(i.GuildID, target.ID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get playlists.")
+			return
+		}
+
+		var own strings.Builder
+		for _, p := range playlists {
+			if target.ID != userID && !p.Public {
+				continue
+			}
+			marker := ""
+			if p.Public && target.ID == userID {
+				marker = " 🌐"
+			}
+			own.WriteString(fmt.Sprintf("**%s**%s - %d tracks\n", p.Name, marker, p.TrackCount))
+		}
+
+		embed := &discordgo.MessageEmbed{
+			Title:  fmt.Sprintf("%s's Playlists", target.Username),
+			Color:  0x5865F2,
+			Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Limit: %d playlists, %d tracks each", settings.PlaylistLimit, settings.PlaylistTrackLimit)},
+		}
+		if own.Len() == 0 {
+			embed.Description = "No playlists yet."
+		} else {
+			embed.Description = truncate(own.String(), 4096)
+		}
+
+		if target.ID == userID {
+			shared, err := ch.bot.DB.GetPublicPlaylists(i.GuildID)
+			if err == nil {
+				var sb strings.Builder
+				for _, p := range shared {
+					if p.OwnerID == userID {
+						continue
+					}
+					sb.WriteString(fmt.Sprintf("**%s** by <@%s> - %d tracks\n", p.Name, p.OwnerID, p.TrackCount))
+				}
+				if sb.Len() > 0 {
+					embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+						Name:  "Shared in this Server",
+						Value: truncate(sb.String(), 1024),
+					})
+				}
+			}
+		}
+		respondEmbed(s, i, embed)
+
+	case "delete":
+		playlist := ch.ownPlaylist(s, i, name)
+		if playlist == nil {
+			return
+		}
+		if err := ch.bot.DB.DeletePlaylist(playlist.ID); err != nil {
+			respondEphemeral(s, i, "Failed to delete playlist.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Playlist Deleted", fmt.Sprintf("Deleted **%s** (%d tracks).", playlist.Name, playlist.TrackCount)))
+
+	case "limits":
+		if !isAdmin(s, i.GuildID, userID) {
+			respondEphemeral(s, i, "You need administrator permission to change playlist limits.")
+			return
+		}
+		for _, opt := range getOptions(i) {
+			switch opt.Name {
+			case "playlists":
+				settings.PlaylistLimit = int(opt.IntValue())
+			case "tracks":
+				settings.PlaylistTrackLimit = int(opt.IntValue())
+			}
+		}
+		if err := ch.bot.DB.SetMusicSettings(settings); err != nil {
+			respondEphemeral(s, i, "Failed to save playlist limits.")
+			return
+		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title: "Playlist Limits",
+			Color: 0x57F287,
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Playlists per Member", Value: fmt.Sprintf("%d", settings.PlaylistLimit), Inline: true},
+				{Name: "Tracks per Playlist", Value: fmt.Sprintf("%d", settings.PlaylistTrackLimit), Inline: true},
+			},
+		})
+	}
+}
+
+// ownPlaylist looks up one of the invoking member's playlists, responding if it doesn't exist
+func (ch *CommandHandler) ownPlaylist(s *discordgo.Session, i *discordgo.InteractionCreate, name string) *database.Playlist {
+	playlist, err := ch.bot.DB.GetPlaylist(i.GuildID, i.Member.User.ID, name)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get playlist.")
+		return nil
+	}
+	if playlist == nil {
+		respondEphemeral(s, i, fmt.Sprintf("You don't have a playlist named **%s**.", name))
+		return nil
+	}
+	return playlist
+}
+
+// playPlaylist resolves each saved track and appends it to the guild queue,
+// starting playback as soon as the first track is ready
+func (ch *CommandHandler) playPlaylist(s *discordgo.Session, i *discordgo.InteractionCreate, playlist *database.Playlist) {
+	tracks, err := ch.bot.DB.GetPlaylistTracks(playlist.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get playlist tracks.")
+		return
+	}
+	if len(tracks) == 0 {
+		respondEphemeral(s, i, fmt.Sprintf("**%s** has no tracks yet.", playlist.Name))
+		return
+	}
+
+	channelID, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+
+	respondDeferred(s, i)
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	if !player.IsConnected() {
+		if err := player.Connect(s, channelID); err != nil {
+			editResponse(s, i, "Failed to join voice channel: "+err.Error())
+			return
+		}
+	}
+
+	queued, skipped := 0, 0
+	lastUpdate := time.Now()
+	limits := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID)
+	limited := ""
+	for _, t := range tracks {
+		track := &Track{
+			Title:       t.Title,
+			URL:         t.URL,
+			Duration:    t.Duration,
+			Requester:   i.Member.User.Username,
+			RequesterID: i.Member.User.ID,
+			IsLocal:     t.IsLocal,
+		}
+		if t.IsLocal {
+			if _, err := os.Stat(t.URL); err != nil {
+				skipped++
+				continue
+			}
+		} else {
+			info, err := ExtractInfo(t.URL, ch.bot.Config().APIs.YouTubeAPIKey, ch.bot.Config().APIs.SoundCloudAuthToken)
+			if err != nil {
+				skipped++
+				continue
+			}
+			track.Title = info.Title
+			track.URL = info.URL
+			track.Duration = info.Duration
+			track.IsLive = info.IsLive
+			track.Thumbnail = info.Thumbnail
+			track.Source = info.Source
+			track.PageURL = t.URL
+		}
+
+		reason, full := limits.check(player, track)
+		if reason != "" {
+			limited = reason
+			if full {
+				break
+			}
+			continue
+		}
+		if err := ch.queueTrack(i, player, channelID, track); err != nil {
+			editResponse(s, i, "Failed to start playback: "+err.Error())
+			return
+		}
+		queued++
+
+		if time.Since(lastUpdate) > 3*time.Second {
+			editResponse(s, i, fmt.Sprintf("⏳ Queuing **%s**... %d/%d", playlist.Name, queued+skipped, len(tracks)))
+			lastUpdate = time.Now()
+		}
+	}
+
+	if queued == 0 {
+		if limited != "" {
+			editResponse(s, i, limited)
+		} else {
+			editResponse(s, i, fmt.Sprintf("None of the tracks in **%s** could be loaded.", playlist.Name))
+		}
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Playlist Queued",
+		Description: fmt.Sprintf("**%s** by <@%s>", playlist.Name, playlist.OwnerID),
+		Color:       0xFF69B4,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Queued", Value: fmt.Sprintf("%d tracks", queued), Inline: true},
+			{Name: "Requested by", Value: i.Member.User.Username, Inline: true},
+		},
+	}
+	if skipped > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Skipped", Value: fmt.Sprintf("%d unavailable", skipped), Inline: true,
+		})
+	}
+	if limited != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Limited", Value: limited})
+	}
+	content := ""
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		Embeds:  &[]*discordgo.MessageEmbed{embed},
+	})
+}
+
+// queueTrack appends a resolved track to the player and persisted queue,
+// starting playback if nothing is playing
+func (ch *CommandHandler) queueTrack(i *discordgo.InteractionCreate, player *MusicPlayer, channelID string, track *Track) error {
+	var thumbnail *string
+	if track.Thumbnail != "" {
+		thumbnail = &track.Thumbnail
+	}
+	item := &database.MusicQueueItem{
+		GuildID:   i.GuildID,
+		ChannelID: channelID,
+		UserID:    i.Member.User.ID,
+		Title:     track.Title,
+		URL:       track.URL,
+		Duration:  track.Duration,
+		Thumbnail: thumbnail,
+		IsLocal:   track.IsLocal,
+	}
+	if err := ch.bot.DB.AddToMusicQueue(item); err == nil {
+		track.QueueID = item.ID
+	}
+	player.AddTrack(track)
+
+	if player.IsPlaying() {
+		return nil
+	}
+	if err := player.Play(); err != nil {
+		return err
+	}
+	ch.bot.DB.AddToMusicHistory(i.GuildID, i.Member.User.ID, track.Title, track.URL)
+	return nil
+}
+
+// queueLimits are a guild's music limits as they apply to one member, 0 meaning no limit
+type queueLimits struct {
+	userID      string
+	maxQueue    int
+	maxDuration int
+	maxPerUser  int
+}
+
+// queueLimitsFor looks up the limits for a member; DJs and up aren't limited
+func (ch *CommandHandler) queueLimitsFor(s *discordgo.Session, guildID, userID string) *queueLimits {
+	limits := &queueLimits{userID: userID}
+	settings, err := ch.bot.DB.GetMusicSettings(guildID)
+	if err != nil || GetMusicPermLevel(s, guildID, userID, settings.DJRoleID, settings.ModRoleID) >= MusicPermDJ {
+		return limits
+	}
+	limits.maxQueue = settings.MaxQueueLength
+	limits.maxDuration = settings.MaxTrackDuration
+	limits.maxPerUser = settings.MaxUserTracks
+	return limits
+}
+
+// check returns why a track can't be queued, or "" if it can. full is set
+// when the queue has no room left, so no later track would fit either.
+func (l *queueLimits) check(player *MusicPlayer, track *Track) (reason string, full bool) {
+	queue := player.GetQueue()
+	if l.maxQueue > 0 && len(queue) >= l.maxQueue {
+		return fmt.Sprintf("The queue is full (%d tracks).", l.maxQueue), true
+	}
+	if l.maxPerUser > 0 {
+		mine := 0
+		for _, t := range queue {
+			if t.RequesterID == l.userID {
+				mine++
+			}
+		}
+		if mine >= l.maxPerUser {
+			return fmt.Sprintf("You can only have %d tracks in the queue at once.", l.maxPerUser), true
+		}
+	}
+	// Live streams have no length to hold against the limit
+	if l.maxDuration > 0 && !track.IsLive && track.Duration > l.maxDuration {
+		return fmt.Sprintf("Tracks longer than %s can't be queued here.", formatMusicDuration(l.maxDuration)), false
+	}
+	return "", false
+}
+
+// queuedTrackEmbed announces a track that started playing, or its queue position if queued is set
+func queuedTrackEmbed(player *MusicPlayer, track *Track, queued bool) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "Now Playing",
+		Description: track.Title,
+		Color:       0xFF69B4,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Duration", Value: formatTrackLength(track.Duration, track.IsLive), Inline: true},
+			{Name: "Requested by", Value: track.Requester, Inline: true},
+		},
+	}
+	if queued {
+		embed.Title = "Added to Queue"
+		embed.Color = 0x5865F2
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Position", Value: fmt.Sprintf("#%d", len(player.GetQueue())), Inline: true},
+			embed.Fields[0],
+		}
+	}
+	if track.Source != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: track.Source}
+	}
+	if track.Thumbnail != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: track.Thumbnail}
+	}
+	return embed
+}
+
+func (ch *CommandHandler) playlistAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	ownerID := i.Member.User.ID
+	input := ""
+	for _, opt := range getOptions(i) {
+		switch {
+		case opt.Focused:
+			input = opt.StringValue()
+		case opt.Name == "owner":
+			if id, ok := opt.Value.(string); ok {
+				ownerID = id
+			}
+		}
+	}
+
+	playlists, err := ch.bot.DB.GetUserPlaylists(i.GuildID, ownerID)
+	if err != nil {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, p := range playlists {
+		if ownerID != i.Member.User.ID && !p.Public {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(p.Name), strings.ToLower(input)) || len(choices) >= 25 {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%d tracks)", p.Name, p.TrackCount),
+			Value: p.Name,
+		})
+	}
+	respondAutocomplete(s, i, choices)
+}
@@ -0,0 +1,294 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerQuoteCommands() {
+	// Right-click a message > Apps > Quote Message
+	ch.Register(&Command{
+		Name:        "Quote Message",
+		Description: "Save a message to the quote board",
+		Category:    "Quotes",
+		Type:        discordgo.MessageApplicationCommand,
+		Handler:     ch.quoteMessageHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "quote",
+		Description: "Save and recall memorable messages",
+		Category:    "Quotes",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Save a message to the quote board",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "Link to the message",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "random",
+				Description: "Show a random quote",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Only quotes by this member",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "search",
+				Description: "Search the quote board",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "text",
+						Description: "Text to look for",
+						Required:    true,
+						MaxLength:   100,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a quote you added (moderators can remove any)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "number",
+						Description: "Quote number",
+						Required:    true,
+						MinValue:    floatPtr(1),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "daily",
+				Description: "Post a random quote every day",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Turn the daily quote on or off",
+						Required:    true,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to post in (required when turning it on)",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hour",
+						Description: "Hour of the day to post, in UTC (default: 12)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    23,
+					},
+				},
+			},
+		},
+		Handler: ch.quoteHandler,
+	})
+}
+
+func (ch *CommandHandler) quoteMessageHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Quotes can only be saved in a server.")
+		return
+	}
+	data := i.ApplicationCommandData()
+	msg := data.Resolved.Messages[data.TargetID]
+	if msg == nil {
+		respondEphemeral(s, i, "That message couldn't be found.")
+		return
+	}
+	ch.saveQuote(s, i, msg)
+}
+
+func (ch *CommandHandler) quoteHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch getSubcommandName(i) {
+	case "add":
+		match := messageLinkPattern.FindStringSubmatch(getStringOption(i, "message"))
+		if match == nil || match[1] != i.GuildID {
+			respondEphemeral(s, i, "That isn't a link to a message in this server.")
+			return
+		}
+		msg, err := s.ChannelMessage(match[2], match[3])
+		if err != nil {
+			respondEphemeral(s, i, "That message couldn't be found.")
+			return
+		}
+		ch.saveQuote(s, i, msg)
+
+	case "random":
+		userID := ""
+		if user := getUserOption(i, "user"); user != nil {
+			userID = user.ID
+		}
+		q, err := ch.bot.DB.GetRandomQuote(i.GuildID, userID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get a quote.")
+			return
+		}
+		if q == nil {
+			respondEphemeral(s, i, "There are no quotes yet. Save one with `/quote add` or **Apps > Quote Message**.")
+			return
+		}
+		respondEmbed(s, i, quoteEmbed(s, q))
+
+	case "search":
+		ch.quoteSearchHandler(s, i)
+
+	case "remove":
+		ch.quoteRemoveHandler(s, i)
+
+	case "daily":
+		ch.quoteDailyHandler(s, i)
+	}
+}
+
+// saveQuote adds a message to the quote board and shows the new quote
+func (ch *CommandHandler) saveQuote(s *discordgo.Session, i *discordgo.InteractionCreate, msg *discordgo.Message) {
+	if msg.Author != nil && msg.Author.Bot {
+		respondEphemeral(s, i, "Messages from bots can't be quoted.")
+		return
+	}
+	q := quoteFromMessage(i.GuildID, i.Member.User.ID, msg)
+	if q == nil {
+		respondEphemeral(s, i, "That message has nothing to quote.")
+		return
+	}
+
+	err := ch.bot.DB.AddQuote(q)
+	if errors.Is(err, database.ErrQuoteExists) {
+		respondEphemeral(s, i, "That message is already on the quote board.")
+		return
+	}
+	if err != nil {
+		respondEphemeral(s, i, "Failed to save the quote.")
+		return
+	}
+
+	embed := quoteEmbed(s, q)
+	embed.Title = "Quote Saved"
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) quoteSearchHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	text := strings.TrimSpace(getStringOption(i, "text"))
+	quotes, err := ch.bot.DB.SearchQuotes(i.GuildID, text, 10)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to search quotes.")
+		return
+	}
+	if len(quotes) == 0 {
+		respondEphemeral(s, i, "No quotes found.")
+		return
+	}
+
+	var lines []string
+	for _, q := range quotes {
+		line := fmt.Sprintf("**#%d** <@%s>: %s", q.Number, q.UserID, truncate(strings.ReplaceAll(q.Content, "\n", " "), 100))
+		if q.MessageID != "" {
+			line += fmt.Sprintf(" ([jump](https://discord.com/channels/%s/%s/%s))", q.GuildID, q.ChannelID, q.MessageID)
+		}
+		lines = append(lines, line)
+	}
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Quotes matching \"%s\"", text),
+		Description: truncate(strings.Join(lines, "\n"), 4096),
+		Color:       0xFF69B4,
+	})
+}
+
+func (ch *CommandHandler) quoteRemoveHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	number := int(getIntOption(i, "number"))
+	q, err := ch.bot.DB.GetQuote(i.GuildID, number)
+	if err != nil || q == nil {
+		respondEphemeral(s, i, "Quote not found.")
+		return
+	}
+	if q.AddedBy != i.Member.User.ID && !isModerator(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You can only remove quotes you added.")
+		return
+	}
+	if _, err := ch.bot.DB.DeleteQuote(i.GuildID, number); err != nil {
+		respondEphemeral(s, i, "Failed to remove the quote.")
+		return
+	}
+	respondEmbed(s, i, successEmbed("Quote Removed", fmt.Sprintf("Removed quote **#%d**.", number)))
+}
+
+func (ch *CommandHandler) quoteDailyHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure the daily quote.")
+		return
+	}
+	cfg, err := ch.bot.DB.GetQuoteConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get quote config.")
+		return
+	}
+
+	cfg.DailyEnabled = getBoolOption(i, "enabled")
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		cfg.ChannelID = channel.ID
+	}
+	for _, opt := range getOptions(i) {
+		if opt.Name == "hour" {
+			cfg.PostHour = int(opt.IntValue())
+		}
+	}
+	if cfg.DailyEnabled && cfg.ChannelID == "" {
+		respondEphemeral(s, i, "Pick a channel for the daily quote.")
+		return
+	}
+	if err := ch.bot.DB.SetQuoteConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to save quote config.")
+		return
+	}
+
+	if !cfg.DailyEnabled {
+		respondEmbed(s, i, successEmbed("Daily Quote Disabled", "No more daily quotes will be posted."))
+		return
+	}
+	count, _ := ch.bot.DB.CountQuotes(i.GuildID)
+	respondEmbed(s, i, successEmbed("Daily Quote Enabled",
+		fmt.Sprintf("A random quote from the %d on the board will be posted in <#%s> every day at %02d:00 UTC.", count, cfg.ChannelID, cfg.PostHour)))
+}
@@ -0,0 +1,295 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultRadioStations are available in every server unless it saves a preset with the same name
+var defaultRadioStations = []database.RadioStation{
+	{Name: "lofi", URL: "https://www.youtube.com/watch?v=jfKfPfyJRdk"},
+	{Name: "groovesalad", URL: "https://ice1.somafm.com/groovesalad-128-mp3"},
+	{Name: "defcon", URL: "https://ice1.somafm.com/defcon-128-mp3"},
+}
+
+func (ch *CommandHandler) registerRadioCommands() {
+	stationOption := func(description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "station",
+			Description:  description,
+			Required:     true,
+			MaxLength:    50,
+			Autocomplete: true,
+		}
+	}
+
+	ch.Register(&Command{
+		Name:        "radio",
+		Description: "Play internet radio stations",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "play",
+				Description: "Tune in to a radio station",
+				Options: []*discordgo.ApplicationCommandOption{
+					stationOption("Station preset to play"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Save a radio station preset for this server (Music Mod)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "station",
+						Description: "Preset name",
+						Required:    true,
+						MaxLength:   50,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "url",
+						Description: "Stream URL or YouTube live link",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Delete a radio station preset (Music Mod)",
+				Options: []*discordgo.ApplicationCommandOption{
+					stationOption("Preset to delete"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the radio stations available here",
+			},
+		},
+		Handler:      ch.radioHandler,
+		Autocomplete: ch.radioAutocomplete,
+	})
+}
+
+// radioStations returns the guild's presets merged over the defaults, sorted by name
+func (ch *CommandHandler) radioStations(guildID string) ([]database.RadioStation, error) {
+	saved, err := ch.bot.DB.GetRadioStations(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	stations := saved
+	for _, def := range defaultRadioStations {
+		overridden := false
+		for _, r := range saved {
+			if strings.EqualFold(r.Name, def.Name) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			stations = append(stations, def)
+		}
+	}
+	sort.Slice(stations, func(a, b int) bool {
+		return strings.ToLower(stations[a].Name) < strings.ToLower(stations[b].Name)
+	})
+	return stations, nil
+}
+
+func (ch *CommandHandler) radioHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	name := strings.TrimSpace(getStringOption(i, "station"))
+
+	switch getSubcommandName(i) {
+	case "play":
+		ch.radioPlay(s, i, name)
+
+	case "add", "remove":
+		settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get music settings.")
+			return
+		}
+		permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+		if permLevel < MusicPermMod {
+			respondEphemeral(s, i, "You need the music mod role to manage radio stations.")
+			return
+		}
+
+		if getSubcommandName(i) == "remove" {
+			removed, err := ch.bot.DB.RemoveRadioStation(i.GuildID, name)
+			if err != nil {
+				respondEphemeral(s, i, "Failed to remove radio station.")
+				return
+			}
+			if !removed {
+				respondEphemeral(s, i, fmt.Sprintf("This server has no saved station named **%s**.", name))
+				return
+			}
+			respondEmbed(s, i, successEmbed("Radio Station Removed", fmt.Sprintf("Removed **%s**.", name)))
+			return
+		}
+
+		streamURL := strings.TrimSpace(getStringOption(i, "url"))
+		if u, err := url.Parse(streamURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			respondEphemeral(s, i, "Please provide an http or https stream URL.")
+			return
+		}
+		if err := ch.bot.DB.SetRadioStation(i.GuildID, name, streamURL, i.Member.User.ID); err != nil {
+			respondEphemeral(s, i, "Failed to save radio station.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Radio Station Saved",
+			fmt.Sprintf("Saved **%s**. Tune in with `/musiclibrary radio play station:%s`.", name, name)))
+
+	case "list":
+		stations, err := ch.radioStations(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get radio stations.")
+			return
+		}
+
+		var sb strings.Builder
+		for _, r := range stations {
+			marker := ""
+			if r.GuildID == "" {
+				marker = " *(default)*"
+			}
+			sb.WriteString(fmt.Sprintf("📻 **%s**%s - <%s>\n", r.Name, marker, r.URL))
+		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title:       "Radio Stations",
+			Description: truncate(sb.String(), 4096),
+			Color:       0x5865F2,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Add your own with /musiclibrary radio add"},
+		})
+	}
+}
+
+// radioPlay queues a station as a live track
+func (ch *CommandHandler) radioPlay(s *discordgo.Session, i *discordgo.InteractionCreate, name string) {
+	stations, err := ch.radioStations(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get radio stations.")
+		return
+	}
+	var station *database.RadioStation
+	for idx := range stations {
+		if strings.EqualFold(stations[idx].Name, name) {
+			station = &stations[idx]
+			break
+		}
+	}
+	if station == nil {
+		respondEphemeral(s, i, fmt.Sprintf("There's no radio station named **%s**. See `/musiclibrary radio list`.", name))
+		return
+	}
+
+	channelID, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+
+	respondDeferred(s, i)
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	if !player.IsConnected() {
+		if err := player.Connect(s, channelID); err != nil {
+			editResponse(s, i, "Failed to join voice channel: "+err.Error())
+			return
+		}
+	}
+
+	info, err := ExtractInfo(station.URL, ch.bot.Config().APIs.YouTubeAPIKey, ch.bot.Config().APIs.SoundCloudAuthToken)
+	if err != nil {
+		editResponse(s, i, "Failed to tune in: "+err.Error())
+		return
+	}
+
+	// Plain stream URLs have no real title, so name them after the preset
+	title, source := info.Title, info.Source
+	if info.Extractor == "Generic" {
+		title, source = station.Name, "Radio"
+	}
+	track := &Track{
+		Title:       "📻 " + title,
+		URL:         info.URL,
+		Thumbnail:   info.Thumbnail,
+		Requester:   i.Member.User.Username,
+		RequesterID: i.Member.User.ID,
+		IsLive:      true,
+		Source:      source,
+		PageURL:     info.WebpageURL,
+	}
+
+	if reason, _ := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID).check(player, track); reason != "" {
+		editResponse(s, i, reason)
+		return
+	}
+
+	wasPlaying := player.IsPlaying()
+	if err := ch.queueTrack(i, player, channelID, track); err != nil {
+		editResponse(s, i, "Failed to start playback: "+err.Error())
+		return
+	}
+
+	editResponseEmbed(s, i, queuedTrackEmbed(player, track, wasPlaying))
+}
+
+func (ch *CommandHandler) radioAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	input := ""
+	for _, opt := range getOptions(i) {
+		if opt.Focused {
+			input = strings.ToLower(opt.StringValue())
+		}
+	}
+
+	stations, err := ch.radioStations(i.GuildID)
+	if err != nil {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	// Defaults can't be removed, only overridden
+	removing := getSubcommandName(i) == "remove"
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, r := range stations {
+		if (removing && r.GuildID == "") || !strings.Contains(strings.ToLower(r.Name), input) || len(choices) >= 25 {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: r.Name, Value: r.Name})
+	}
+	respondAutocomplete(s, i, choices)
+}
@@ -34,12 +34,12 @@ func (ch *CommandHandler) registerRandomCommands() {
 		Handler:     ch.adviceHandler,
 	})
 
-	// Random quote
+	// Random quote; /quote is the server's own quote board
 	ch.Register(&Command{
-		Name:        "quote",
+		Name:        "inspire",
 		Description: "Get a random inspirational quote",
 		Category:    "Random",
-		Handler:     ch.quoteHandler,
+		Handler:     ch.inspireHandler,
 	})
 
 	// Random fact
@@ -182,7 +182,7 @@ func (ch *CommandHandler) adviceHandler(s *discordgo.Session, i *discordgo.Inter
 	followUpEmbed(s, i, embed)
 }
 
-func (ch *CommandHandler) quoteHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (ch *CommandHandler) inspireHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	respondDeferred(s, i)
 
 	resp, err := http.Get("https://api.quotable.io/random")
@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
@@ -75,12 +76,46 @@ func (ch *CommandHandler) registerRanksCommands() {
 		Handler:     ch.listRanksHandler,
 	})
 
-	// Sync ranks from role names
+	// Repair members' rank roles
 	ch.Register(&Command{
 		Name:        "syncranks",
-		Description: "Auto-detect ranks from role names (e.g., 'Member (Lvl 5+)')",
+		Description: "Fix every member's rank roles to match their level and the rank mode",
 		Category:    "Ranks",
-		Handler:     ch.syncRanksHandler,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "from_role_names",
+				Description: "Instead, auto-detect ranks from role names (e.g., 'Member (Lvl 5+)')",
+				Required:    false,
+			},
+		},
+		Handler: ch.syncRanksHandler,
+	})
+
+	// Rank reward mode
+	ch.Register(&Command{
+		Name:        "rankconfig",
+		Description: "Configure how rank roles are given and removed",
+		Category:    "Ranks",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "mode",
+				Description: "Which earned rank roles members keep",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Stack - keep every earned rank", Value: "stack"},
+					{Name: "Highest - keep only the highest rank", Value: "highest"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "remove_on_loss",
+				Description: "Remove rank roles when a member drops below their level",
+				Required:    false,
+			},
+		},
+		Handler: ch.rankConfigHandler,
 	})
 
 	// Apply ranks to a user
@@ -156,7 +191,7 @@ func (ch *CommandHandler) listRanksHandler(s *discordgo.Session, i *discordgo.In
 	}
 
 	if len(ranks) == 0 {
-		respondEphemeral(s, i, "No rank rewards configured. Use `/addrank` to add some!")
+		respondEphemeral(s, i, "No rank rewards configured. Use `/ranks addrank` to add some!")
 		return
 	}
 
@@ -177,12 +212,131 @@ func (ch *CommandHandler) listRanksHandler(s *discordgo.Session, i *discordgo.In
 	respondEmbed(s, i, embed)
 }
 
+func (ch *CommandHandler) rankConfigHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure ranks.")
+		return
+	}
+
+	cfg, err := ch.bot.DB.GetLevelRankConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get rank settings.")
+		return
+	}
+
+	options := getOptions(i)
+	for _, opt := range options {
+		switch opt.Name {
+		case "mode":
+			cfg.Stack = opt.StringValue() == "stack"
+		case "remove_on_loss":
+			cfg.RemoveOnLoss = opt.BoolValue()
+		}
+	}
+	if len(options) > 0 {
+		if err := ch.bot.DB.SetLevelRankConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save rank settings.")
+			return
+		}
+	}
+
+	mode := "Stack - members keep every rank they've earned"
+	if !cfg.Stack {
+		mode = "Highest - members keep only their highest rank"
+	}
+	onLoss := "Kept"
+	if cfg.RemoveOnLoss {
+		onLoss = "Removed"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Rank Settings",
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Mode", Value: mode},
+			{Name: "Ranks Above a Member's Level", Value: onLoss},
+		},
+	}
+	if len(options) > 0 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Run /ranks syncranks to apply the new settings to existing members"}
+	}
+	respondEmbed(s, i, embed)
+}
+
 func (ch *CommandHandler) syncRanksHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
 		respondEphemeral(s, i, "You need administrator permission to sync ranks.")
 		return
 	}
 
+	if getBoolOption(i, "from_role_names") {
+		ch.detectRanksFromRoleNames(s, i)
+		return
+	}
+
+	respondDeferred(s, i)
+
+	ranks, err := ch.bot.DB.GetLevelRanks(i.GuildID)
+	if err != nil || len(ranks) == 0 {
+		editResponse(s, i, "No rank rewards configured.")
+		return
+	}
+	ch.syncAllRanks(s, i, ranks)
+}
+
+// syncAllRanks repairs every member's rank roles, reporting progress in a channel message
+// since large servers can take longer than the interaction token lasts
+func (ch *CommandHandler) syncAllRanks(s *discordgo.Session, i *discordgo.InteractionCreate, ranks []database.LevelRank) {
+	cfg, err := ch.bot.DB.GetLevelRankConfig(i.GuildID)
+	if err != nil {
+		editResponse(s, i, "Failed to get rank settings.")
+		return
+	}
+
+	members, err := fetchGuildMembers(s, i.GuildID)
+	if err != nil {
+		editResponse(s, i, "Failed to get server members.")
+		return
+	}
+
+	progress, err := postProgress(s, i.ChannelID, fmt.Sprintf("Syncing rank roles... 0/%d members checked", len(members)), nil)
+	if err != nil {
+		editResponse(s, i, "Failed to post the progress message.")
+		return
+	}
+	editResponse(s, i, fmt.Sprintf("Syncing rank roles for %d members. Progress is shown below.", len(members)))
+
+	added, removed, updated := 0, 0, 0
+	lastProgress := time.Now()
+	for idx, member := range members {
+		if member.User.Bot {
+			continue
+		}
+		xpData, err := ch.bot.DB.GetUserXP(i.GuildID, member.User.ID)
+		if err != nil {
+			continue
+		}
+		a, r := ch.bot.syncMemberRanks(i.GuildID, member, xpData.Level, ranks, cfg)
+		added += a
+		removed += r
+		if a+r > 0 {
+			updated++
+		}
+
+		// Role edits are rate limited, so large servers take a while
+		if time.Since(lastProgress) >= memberProgressInterval {
+			progress.update(fmt.Sprintf("Syncing rank roles... %d/%d members checked (%d updated)", idx+1, len(members), updated), nil)
+			lastProgress = time.Now()
+		}
+	}
+
+	progress.finish(successEmbed("Ranks Synced",
+		fmt.Sprintf("Checked **%d** members and updated **%d**: added **%d** and removed **%d** rank roles",
+			len(members), updated, added, removed)))
+}
+
+// detectRanksFromRoleNames adds level ranks for roles named like "Member (Lvl 5+)"
+func (ch *CommandHandler) detectRanksFromRoleNames(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	respondDeferred(s, i)
 
 	// Get all roles
@@ -237,53 +391,21 @@ func (ch *CommandHandler) applyRanksHandler(s *discordgo.Session, i *discordgo.I
 
 	if user != nil {
 		// Apply to single user
-		applied, err := ch.applyRanksToUser(s, i.GuildID, user.ID, ranks)
+		xpData, err := ch.bot.DB.GetUserXP(i.GuildID, user.ID)
 		if err != nil {
 			followUp(s, i, fmt.Sprintf("Failed to apply ranks: %v", err))
 			return
 		}
-		embed := successEmbed("Ranks Applied",
-			fmt.Sprintf("Applied **%d** rank roles to %s", applied, user.Mention()))
-		followUpEmbed(s, i, embed)
-	} else {
-		// Apply to all users with XP
-		members, err := s.GuildMembers(i.GuildID, "", 1000)
+		added, removed, err := ch.bot.applyLevelRanks(i.GuildID, user.ID, xpData.Level)
 		if err != nil {
-			followUp(s, i, "Failed to get server members.")
+			followUp(s, i, fmt.Sprintf("Failed to apply ranks: %v", err))
 			return
 		}
-
-		totalApplied := 0
-		usersUpdated := 0
-		for _, member := range members {
-			applied, _ := ch.applyRanksToUser(s, i.GuildID, member.User.ID, ranks)
-			if applied > 0 {
-				totalApplied += applied
-				usersUpdated++
-			}
-		}
-
 		embed := successEmbed("Ranks Applied",
-			fmt.Sprintf("Applied **%d** rank roles to **%d** users", totalApplied, usersUpdated))
+			fmt.Sprintf("Added **%d** and removed **%d** rank roles for %s", added, removed, user.Mention()))
 		followUpEmbed(s, i, embed)
+	} else {
+		// Apply to all members
+		ch.syncAllRanks(s, i, ranks)
 	}
 }
-
-func (ch *CommandHandler) applyRanksToUser(s *discordgo.Session, guildID, userID string, ranks []database.LevelRank) (int, error) {
-	xpData, err := ch.bot.DB.GetUserXP(guildID, userID)
-	if err != nil {
-		return 0, err
-	}
-
-	applied := 0
-	for _, rank := range ranks {
-		if xpData.Level >= rank.Level {
-			err := s.GuildMemberRoleAdd(guildID, userID, rank.RoleID)
-			if err == nil {
-				applied++
-			}
-		}
-	}
-
-	return applied, nil
-}
@@ -0,0 +1,295 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerReactionRoleCommands() {
+	messageOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "message_id",
+		Description: "ID of the message",
+		Required:    true,
+	}
+
+	// Reaction role menus
+	ch.Register(&Command{
+		Name:        "reactionrole",
+		Description: "Manage reaction role menus",
+		Category:    "Roles",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Bind an emoji on a message to a role",
+				Options: []*discordgo.ApplicationCommandOption{
+					messageOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "Emoji to react with",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to grant",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel the message is in (defaults to this channel)",
+						Required:    false,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+							discordgo.ChannelTypeGuildNews,
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove an emoji binding from a message",
+				Options: []*discordgo.ApplicationCommandOption{
+					messageOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "Emoji to unbind",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "mode",
+				Description: "Set how roles on a message are handed out",
+				Options: []*discordgo.ApplicationCommandOption{
+					messageOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "Role mode",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Normal - react to get, unreact to lose", Value: reactionRoleNormal},
+							{Name: "Unique - only one role at a time", Value: reactionRoleUnique},
+							{Name: "Limit - at most N roles", Value: reactionRoleLimit},
+							{Name: "Verify - react to get, keep on unreact", Value: reactionRoleVerify},
+							{Name: "Drop - react to remove the role", Value: reactionRoleDrop},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "max",
+						Description: "Maximum roles for limit mode",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    20,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "clear",
+				Description: "Remove all reaction roles from a message",
+				Options:     []*discordgo.ApplicationCommandOption{messageOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List reaction role menus in this server",
+			},
+		},
+		Handler: ch.reactionRoleHandler,
+	})
+}
+
+func (ch *CommandHandler) reactionRoleHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage reaction roles.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "add":
+		ch.reactionRoleAddHandler(s, i)
+	case "remove":
+		ch.reactionRoleRemoveHandler(s, i)
+	case "mode":
+		ch.reactionRoleModeHandler(s, i)
+	case "clear":
+		ch.reactionRoleClearHandler(s, i)
+	case "list":
+		ch.reactionRoleListHandler(s, i)
+	}
+}
+
+func (ch *CommandHandler) reactionRoleAddHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	messageID := strings.TrimSpace(getStringOption(i, "message_id"))
+	emoji := parseEmojiInput(getStringOption(i, "emoji"))
+	role := getRoleOption(i, "role")
+	channelID := i.ChannelID
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		channelID = channel.ID
+	}
+
+	if role == nil || role.ID == i.GuildID || role.Managed {
+		respondEphemeral(s, i, "That role can't be assigned.")
+		return
+	}
+
+	if _, err := s.ChannelMessage(channelID, messageID); err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Couldn't find that message in <#%s>.", channelID))
+		return
+	}
+
+	// Reacting first validates the emoji and gives members something to click
+	if err := s.MessageReactionAdd(channelID, messageID, emoji); err != nil {
+		respondEphemeral(s, i, "Couldn't react with that emoji. Use a standard emoji or one from this server.")
+		return
+	}
+
+	if err := ch.bot.DB.AddReactionRole(i.GuildID, channelID, messageID, emoji, role.ID); err != nil {
+		respondEphemeral(s, i, "Failed to save reaction role.")
+		return
+	}
+
+	embed := successEmbed("Reaction Role Added",
+		fmt.Sprintf("Reacting with %s on [this message](https://discord.com/channels/%s/%s/%s) grants <@&%s>",
+			formatEmoji(emoji), i.GuildID, channelID, messageID, role.ID))
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) reactionRoleRemoveHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	messageID := strings.TrimSpace(getStringOption(i, "message_id"))
+	emoji := parseEmojiInput(getStringOption(i, "emoji"))
+
+	rm, err := ch.bot.DB.GetReactionRoleMessage(messageID)
+	if err != nil || rm == nil || rm.GuildID != i.GuildID {
+		respondEphemeral(s, i, "That message has no reaction roles.")
+		return
+	}
+
+	removed, err := ch.bot.DB.RemoveReactionRole(messageID, emoji)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to remove reaction role.")
+		return
+	}
+	if !removed {
+		respondEphemeral(s, i, "That emoji isn't bound on this message.")
+		return
+	}
+
+	s.MessageReactionRemove(rm.ChannelID, messageID, emoji, "@me")
+
+	respondEmbed(s, i, successEmbed("Reaction Role Removed",
+		fmt.Sprintf("%s no longer grants a role on that message", formatEmoji(emoji))))
+}
+
+func (ch *CommandHandler) reactionRoleModeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	messageID := strings.TrimSpace(getStringOption(i, "message_id"))
+	mode := getStringOption(i, "mode")
+	maxRoles := int(getIntOption(i, "max"))
+
+	rm, err := ch.bot.DB.GetReactionRoleMessage(messageID)
+	if err != nil || rm == nil || rm.GuildID != i.GuildID {
+		respondEphemeral(s, i, "That message has no reaction roles.")
+		return
+	}
+
+	if mode == reactionRoleLimit && maxRoles < 1 {
+		respondEphemeral(s, i, "Limit mode needs a `max` value.")
+		return
+	}
+	if mode != reactionRoleLimit {
+		maxRoles = 0
+	}
+
+	if err := ch.bot.DB.SetReactionRoleMode(messageID, mode, maxRoles); err != nil {
+		respondEphemeral(s, i, "Failed to update mode.")
+		return
+	}
+
+	rm.Mode, rm.MaxRoles = mode, maxRoles
+	respondEmbed(s, i, successEmbed("Reaction Role Mode Updated",
+		fmt.Sprintf("Mode set to **%s**", describeReactionRoleMode(*rm))))
+}
+
+func (ch *CommandHandler) reactionRoleClearHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	messageID := strings.TrimSpace(getStringOption(i, "message_id"))
+
+	rm, err := ch.bot.DB.GetReactionRoleMessage(messageID)
+	if err != nil || rm == nil || rm.GuildID != i.GuildID {
+		respondEphemeral(s, i, "That message has no reaction roles.")
+		return
+	}
+
+	bindings, _ := ch.bot.DB.GetReactionRoles(messageID)
+	if err := ch.bot.DB.DeleteReactionRoleMessage(messageID); err != nil {
+		respondEphemeral(s, i, "Failed to clear reaction roles.")
+		return
+	}
+	for _, binding := range bindings {
+		s.MessageReactionRemove(rm.ChannelID, messageID, binding.Emoji, "@me")
+	}
+
+	respondEmbed(s, i, successEmbed("Reaction Roles Cleared",
+		fmt.Sprintf("Removed %d reaction role(s) from that message", len(bindings))))
+}
+
+func (ch *CommandHandler) reactionRoleListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	messages, err := ch.bot.DB.GetReactionRoleMessages(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get reaction roles.")
+		return
+	}
+	if len(messages) == 0 {
+		respondEphemeral(s, i, "No reaction role menus are set up.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Reaction Role Menus",
+		Color: 0x5865F2,
+	}
+	for _, rm := range messages {
+		bindings, _ := ch.bot.DB.GetReactionRoles(rm.MessageID)
+		var lines []string
+		for _, binding := range bindings {
+			lines = append(lines, fmt.Sprintf("%s → <@&%s>", formatEmoji(binding.Emoji), binding.RoleID))
+		}
+		lines = append(lines, fmt.Sprintf("[Jump to message](https://discord.com/channels/%s/%s/%s)", rm.GuildID, rm.ChannelID, rm.MessageID))
+
+		if len(embed.Fields) == 25 {
+			break
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s • %s", rm.MessageID, describeReactionRoleMode(rm)),
+			Value: truncate(strings.Join(lines, "\n"), 1024),
+		})
+	}
+
+	respondEmbed(s, i, embed)
+}
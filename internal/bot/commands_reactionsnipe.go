@@ -0,0 +1,142 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// reactionSnipeRetention is how long removed reactions can be sniped
+const reactionSnipeRetention = 10 * time.Minute
+
+func (ch *CommandHandler) registerReactionSnipeCommands() {
+	ch.Register(&Command{
+		Name:        "reactionsnipe",
+		Description: "See recently removed reactions",
+		Category:    "Utility",
+		SlashOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show reactions removed in this channel in the last few minutes",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "amount",
+						Description: "Number of reactions to retrieve (1-15)",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    15,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "toggle",
+				Description: "Enable or disable reaction sniping on this server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Enable or disable reaction sniping",
+						Required:    true,
+					},
+				},
+			},
+		},
+		Handler: ch.reactionSnipeHandler,
+	})
+}
+
+// recordRemovedReaction keeps a removed reaction for /reactionsnipe if the guild has it enabled
+func (b *Bot) recordRemovedReaction(r *discordgo.MessageReactionRemove) {
+	if !b.DB.IsReactionSnipeEnabled(r.GuildID) {
+		return
+	}
+	if member, err := b.Session.State.Member(r.GuildID, r.UserID); err == nil && member.User != nil && member.User.Bot {
+		return
+	}
+	b.DB.LogRemovedReaction(&database.RemovedReaction{
+		GuildID:   r.GuildID,
+		ChannelID: r.ChannelID,
+		MessageID: r.MessageID,
+		UserID:    r.UserID,
+		Emoji:     r.Emoji.MessageFormat(),
+		RemovedAt: time.Now(),
+	})
+}
+
+func (ch *CommandHandler) reactionSnipeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if getSubcommandName(i) == "toggle" {
+		if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "You need administrator permission to configure reaction sniping.")
+			return
+		}
+		enabled := getBoolOption(i, "enabled")
+		if err := ch.bot.DB.SetReactionSnipeEnabled(i.GuildID, enabled); err != nil {
+			respondEphemeral(s, i, "Failed to update reaction snipe setting.")
+			return
+		}
+		status := "disabled"
+		if enabled {
+			status = "enabled"
+		}
+		respondEmbed(s, i, successEmbed("Reaction Snipe Updated",
+			fmt.Sprintf("Reaction sniping has been **%s**", status)))
+		return
+	}
+
+	if !ch.bot.DB.IsReactionSnipeEnabled(i.GuildID) {
+		respondEphemeral(s, i, "Reaction sniping is not enabled on this server.")
+		return
+	}
+
+	amount := getIntOption(i, "amount")
+	if amount == 0 {
+		amount = 1
+	}
+	reactions, err := ch.bot.DB.GetRemovedReactions(i.ChannelID, time.Now().Add(-reactionSnipeRetention), int(amount))
+	if err != nil || len(reactions) == 0 {
+		respondEphemeral(s, i, fmt.Sprintf("No reactions were removed in this channel in the last %d minutes.", int(reactionSnipeRetention.Minutes())))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Sniped Reactions",
+		Color: 0x5865F2,
+	}
+	for _, r := range reactions {
+		user, _ := s.User(r.UserID)
+		username := r.UserID
+		if user != nil {
+			username = user.Username
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("%s - <t:%d:R>", username, r.RemovedAt.Unix()),
+			Value: fmt.Sprintf("Removed %s from [this message](https://discord.com/channels/%s/%s/%s)",
+				r.Emoji, r.GuildID, r.ChannelID, r.MessageID),
+		})
+	}
+
+	respondEmbedEphemeral(s, i, embed)
+}
@@ -0,0 +1,216 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxReasonPresets caps how many reason presets a server can define
+const maxReasonPresets = 50
+
+func (ch *CommandHandler) registerReasonPresetCommands() {
+	ch.Register(&Command{
+		Name:        "reasonpreset",
+		Description: "Manage canned reasons for warn, timeout, kick and ban",
+		Category:    "Administration",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Add or update a reason preset",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Short name, like r1 or spam",
+						Required:    true,
+						MaxLength:   32,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "reason",
+						Description: "The full reason recorded in the mod log",
+						Required:    true,
+						MaxLength:   512,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List this server's reason presets",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a reason preset",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "Preset to remove",
+						Required:     true,
+						Autocomplete: true,
+					},
+				},
+			},
+		},
+		Handler:      ch.reasonPresetHandler,
+		Autocomplete: ch.reasonPresetAutocomplete,
+	})
+}
+
+func (ch *CommandHandler) reasonPresetHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sub := getSubcommandName(i)
+	if sub == "list" {
+		if !isModerator(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "You need moderator permissions to view reason presets.")
+			return
+		}
+		ch.reasonPresetListHandler(s, i)
+		return
+	}
+
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage reason presets.")
+		return
+	}
+
+	name := strings.TrimSpace(getStringOption(i, "name"))
+	switch sub {
+	case "add":
+		reason := strings.TrimSpace(getStringOption(i, "reason"))
+		if name == "" || reason == "" {
+			respondEphemeral(s, i, "Both a name and a reason are needed.")
+			return
+		}
+		existing, err := ch.bot.DB.GetReasonPreset(i.GuildID, name)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to save the reason preset.")
+			return
+		}
+		if existing == nil {
+			if count, _ := ch.bot.DB.CountReasonPresets(i.GuildID); count >= maxReasonPresets {
+				respondEphemeral(s, i, fmt.Sprintf("This server already has the maximum of %d reason presets.", maxReasonPresets))
+				return
+			}
+		}
+		if err := ch.bot.DB.AddReasonPreset(i.GuildID, name, reason, i.Member.User.ID); err != nil {
+			respondEphemeral(s, i, "Failed to save the reason preset.")
+			return
+		}
+		title := "Reason Preset Added"
+		if existing != nil {
+			title = "Reason Preset Updated"
+		}
+		respondEmbed(s, i, successEmbed(title, fmt.Sprintf("**%s**: %s\n\nPick it from the reason option of `/warn`, `/timeout`, `/kick` or `/ban`, or type its name.", name, reason)))
+	case "remove":
+		removed, err := ch.bot.DB.DeleteReasonPreset(i.GuildID, name)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to remove the reason preset.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "There's no reason preset called **"+name+"**.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Reason Preset Removed", fmt.Sprintf("Removed **%s**.", name)))
+	}
+}
+
+func (ch *CommandHandler) reasonPresetListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	presets, err := ch.bot.DB.GetReasonPresets(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get reason presets.")
+		return
+	}
+	if len(presets) == 0 {
+		respondEphemeral(s, i, "This server has no reason presets. Add one with `/reasonpreset add`.")
+		return
+	}
+
+	var sb strings.Builder
+	for _, p := range presets {
+		fmt.Fprintf(&sb, "**%s**: %s\n", p.Name, truncate(p.Reason, 120))
+	}
+	respondEmbedEphemeral(s, i, &discordgo.MessageEmbed{
+		Title:       "Reason Presets",
+		Description: truncate(sb.String(), 4000),
+		Color:       0xFF69B4,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d/%d presets", len(presets), maxReasonPresets)},
+	})
+}
+
+// reasonPresetAutocomplete suggests presets for /reasonpreset remove and for
+// the reason option of moderation commands. Moderation commands also offer
+// whatever was typed, so a custom reason can still be given.
+func (ch *CommandHandler) reasonPresetAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	typed := ""
+	for _, opt := range getOptions(i) {
+		if opt.Focused {
+			typed = strings.TrimSpace(opt.StringValue())
+		}
+	}
+	input := strings.ToLower(typed)
+
+	presets, err := ch.bot.DB.GetReasonPresets(i.GuildID)
+	if err != nil {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	// Offer the typed text as-is unless it's already a preset's name
+	removing := i.ApplicationCommandData().Name == "reasonpreset"
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if !removing && typed != "" && len(typed) <= 100 && !slices.ContainsFunc(presets, func(p database.ReasonPreset) bool {
+		return strings.EqualFold(p.Name, typed)
+	}) {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: typed, Value: typed})
+	}
+	for _, p := range presets {
+		if len(choices) >= 25 {
+			break
+		}
+		if !strings.Contains(strings.ToLower(p.Name), input) && !strings.Contains(strings.ToLower(p.Reason), input) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(p.Name+": "+p.Reason, 100),
+			Value: p.Name,
+		})
+	}
+	respondAutocomplete(s, i, choices)
+}
+
+// resolveReason expands the name of a reason preset into its full reason.
+// Anything else is returned unchanged.
+func (ch *CommandHandler) resolveReason(guildID, reason string) string {
+	if reason == "" || len(reason) > 32 {
+		return reason
+	}
+	preset, err := ch.bot.DB.GetReasonPreset(guildID, strings.TrimSpace(reason))
+	if err != nil || preset == nil {
+		return reason
+	}
+	return preset.Reason
+}
@@ -0,0 +1,331 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// messageLinkPattern matches a Discord message link
+var messageLinkPattern = regexp.MustCompile(`discord(?:app)?\.com/channels/(\d+)/(\d+)/(\d+)`)
+
+func (ch *CommandHandler) registerReportCommands() {
+	// Right-click a message > Apps > Report Message
+	ch.Register(&Command{
+		Name:        "Report Message",
+		Description: "Report a message to the server staff",
+		Category:    "Moderation",
+		Type:        discordgo.MessageApplicationCommand,
+		Handler:     ch.reportMessageHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "report",
+		Description: "Report a member to the server staff",
+		Category:    "Moderation",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "Member you're reporting",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "reason",
+				Description: "What happened",
+				Required:    true,
+				MaxLength:   500,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "message",
+				Description: "Link to the message you're reporting",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "anonymous",
+				Description: "Hide your name from staff, if the server allows it",
+				Required:    false,
+			},
+		},
+		Handler: ch.reportHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "reports",
+		Description: "Configure and review member reports",
+		Category:    "Moderation",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Set the staff channel reports are posted to and enable reports",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Staff channel to post reports in",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "allow_anonymous",
+						Description: "Let reporters hide their name from staff (default: true)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Disable reports",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List reports",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "status",
+						Description: "Only show reports with this status (default: open)",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Open", Value: database.ReportOpen},
+							{Name: "Resolved", Value: database.ReportResolved},
+							{Name: "Dismissed", Value: database.ReportDismissed},
+						},
+					},
+				},
+			},
+		},
+		Handler: ch.reportsHandler,
+	})
+
+	ch.RegisterComponent("report", ch.handleReportComponent)
+}
+
+// reportable checks that a member may be reported by the caller, returning why not
+func reportable(reporterID string, target *discordgo.User) string {
+	switch {
+	case target == nil:
+		return "That member couldn't be found."
+	case target.ID == reporterID:
+		return "You can't report yourself."
+	case target.Bot:
+		return "Bots can't be reported."
+	}
+	return ""
+}
+
+func (ch *CommandHandler) reportMessageHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Messages can only be reported in a server.")
+		return
+	}
+	cfg, err := ch.bot.DB.GetReportConfig(i.GuildID)
+	if err != nil || !cfg.Enabled || cfg.ChannelID == "" {
+		respondEphemeral(s, i, "Reports are not enabled on this server.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	msg := data.Resolved.Messages[data.TargetID]
+	if msg == nil {
+		respondEphemeral(s, i, "That message couldn't be found.")
+		return
+	}
+	if reason := reportable(i.Member.User.ID, msg.Author); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	rows := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.TextInput{
+				CustomID:  "reason",
+				Label:     "What's wrong with this message?",
+				Style:     discordgo.TextInputParagraph,
+				Required:  true,
+				MaxLength: 500,
+			},
+		}},
+	}
+	if cfg.AllowAnonymous {
+		rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.TextInput{
+				CustomID:    "anonymous",
+				Label:       "Hide your name from staff? (yes/no)",
+				Style:       discordgo.TextInputShort,
+				Placeholder: "no",
+				Required:    false,
+				MaxLength:   3,
+			},
+		}})
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   fmt.Sprintf("report:submit:%s:%s", msg.ChannelID, msg.ID),
+			Title:      "Report Message",
+			Components: rows,
+		},
+	})
+}
+
+func (ch *CommandHandler) reportHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg, err := ch.bot.DB.GetReportConfig(i.GuildID)
+	if err != nil || !cfg.Enabled || cfg.ChannelID == "" {
+		respondEphemeral(s, i, "Reports are not enabled on this server.")
+		return
+	}
+
+	target := getUserOption(i, "user")
+	if reason := reportable(i.Member.User.ID, target); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+	if getBoolOption(i, "anonymous") && !cfg.AllowAnonymous {
+		respondEphemeral(s, i, "This server doesn't allow anonymous reports.")
+		return
+	}
+
+	report := &database.Report{
+		GuildID:    i.GuildID,
+		TargetID:   target.ID,
+		ReporterID: i.Member.User.ID,
+		Reason:     strings.TrimSpace(getStringOption(i, "reason")),
+		Anonymous:  getBoolOption(i, "anonymous"),
+	}
+
+	var reported *discordgo.Message
+	if link := strings.TrimSpace(getStringOption(i, "message")); link != "" {
+		match := messageLinkPattern.FindStringSubmatch(link)
+		if match == nil || match[1] != i.GuildID {
+			respondEphemeral(s, i, "That isn't a link to a message in this server.")
+			return
+		}
+		msg, err := s.ChannelMessage(match[2], match[3])
+		if err != nil {
+			respondEphemeral(s, i, "That message couldn't be found.")
+			return
+		}
+		if msg.Author == nil || msg.Author.ID != target.ID {
+			respondEphemeral(s, i, fmt.Sprintf("That message wasn't sent by <@%s>.", target.ID))
+			return
+		}
+		report.ChannelID, report.MessageID = msg.ChannelID, msg.ID
+		reported = msg
+	}
+
+	respondDeferredEphemeral(s, i)
+	ch.fileReport(s, i, cfg, report, reported)
+}
+
+func (ch *CommandHandler) reportsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := getSubcommandName(i)
+	if subcommand == "list" {
+		if !isModerator(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "You need moderator permissions to view reports.")
+			return
+		}
+		ch.reportsListHandler(s, i)
+		return
+	}
+
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure reports.")
+		return
+	}
+
+	cfg, err := ch.bot.DB.GetReportConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get report config.")
+		return
+	}
+
+	switch subcommand {
+	case "setup":
+		cfg.ChannelID = getChannelOption(i, "channel").ID
+		cfg.Enabled = true
+		cfg.AllowAnonymous = true
+		for _, opt := range getOptions(i) {
+			if opt.Name == "allow_anonymous" {
+				cfg.AllowAnonymous = opt.BoolValue()
+			}
+		}
+		if err := ch.bot.DB.SetReportConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save report config.")
+			return
+		}
+		anonymity := "Reporters can choose to hide their name."
+		if !cfg.AllowAnonymous {
+			anonymity = "Reporters are always named."
+		}
+		respondEmbed(s, i, successEmbed("Reports Enabled",
+			fmt.Sprintf("Reports made with `/report` or **Apps > Report Message** will be posted in <#%s>. %s", cfg.ChannelID, anonymity)))
+
+	case "disable":
+		cfg.Enabled = false
+		if err := ch.bot.DB.SetReportConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save report config.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Reports Disabled", "Members can no longer file reports"))
+	}
+}
+
+func (ch *CommandHandler) reportsListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	status := getStringOption(i, "status")
+	if status == "" {
+		status = database.ReportOpen
+	}
+	reports, err := ch.bot.DB.GetReports(i.GuildID, status, 15)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get reports.")
+		return
+	}
+	if len(reports) == 0 {
+		respondEphemeral(s, i, fmt.Sprintf("There are no %s reports.", status))
+		return
+	}
+
+	var lines []string
+	for _, r := range reports {
+		line := fmt.Sprintf("**#%d** <@%s> ×%d <t:%d:R> — %s", r.ID, r.TargetID, r.ReportCount, r.CreatedAt.Unix(),
+			truncate(strings.ReplaceAll(r.Reason, "\n", " "), 80))
+		if r.StaffMessageID != "" {
+			line += fmt.Sprintf(" ([view](https://discord.com/channels/%s/%s/%s))", r.GuildID, r.StaffChannelID, r.StaffMessageID)
+		}
+		lines = append(lines, line)
+	}
+
+	respondEmbedEphemeral(s, i, &discordgo.MessageEmbed{
+		Title:       reportStatuses[status].Label + " Reports",
+		Description: truncate(strings.Join(lines, "\n"), 4096),
+		Color:       reportStatuses[status].Color,
+	})
+}
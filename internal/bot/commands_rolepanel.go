@@ -0,0 +1,438 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerRolePanelCommands() {
+	panelOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "panel",
+		Description: "Panel ID (see /rolepanel list)",
+		Required:    true,
+	}
+
+	// Button and select menu role panels
+	ch.Register(&Command{
+		Name:        "rolepanel",
+		Description: "Build role panels with buttons or select menus",
+		Category:    "Roles",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Create a new role panel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "title",
+						Description: "Panel title",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "Text shown above the roles",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "style",
+						Description: "How roles are picked",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Buttons", Value: "buttons"},
+							{Name: "Select Menu", Value: "select"},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "addrole",
+				Description: "Add a role to a panel",
+				Options: []*discordgo.ApplicationCommandOption{
+					panelOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to offer",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "label",
+						Description: "Button/option label (defaults to the role name)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "Emoji shown next to the label",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "Option description (select menus only)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "removerole",
+				Description: "Remove a role from a panel",
+				Options: []*discordgo.ApplicationCommandOption{
+					panelOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to remove",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "settings",
+				Description: "Change group limits, required role and temporary roles",
+				Options: []*discordgo.ApplicationCommandOption{
+					panelOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "max",
+						Description: "Roles a member may hold from this panel (0 = no limit, 1 = pick one)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+						MaxValue:    maxRolePanelRoles,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "required_role",
+						Description: "Role members need to use the panel",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "clear_required",
+						Description: "Remove the required role",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "duration",
+						Description: "Remove granted roles after this long (e.g. 1h, 7d; 0 = permanent)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "post",
+				Description: "Post a panel, or refresh it if already posted",
+				Options: []*discordgo.ApplicationCommandOption{
+					panelOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to post in (defaults to where it is, or this channel)",
+						Required:    false,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+							discordgo.ChannelTypeGuildNews,
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a panel and its message",
+				Options:     []*discordgo.ApplicationCommandOption{panelOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List role panels in this server",
+			},
+		},
+		Handler: ch.rolePanelHandler,
+	})
+
+	ch.RegisterComponent("rolepanel", ch.handleRolePanelComponent)
+}
+
+func (ch *CommandHandler) rolePanelHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage role panels.")
+		return
+	}
+
+	subcommand := getSubcommandName(i)
+	switch subcommand {
+	case "create":
+		ch.rolePanelCreateHandler(s, i)
+		return
+	case "list":
+		ch.rolePanelListHandler(s, i)
+		return
+	}
+
+	panel, err := ch.bot.DB.GetRolePanel(getIntOption(i, "panel"))
+	if err != nil || panel == nil || panel.GuildID != i.GuildID {
+		respondEphemeral(s, i, "Role panel not found. Use `/rolepanel list` to see panel IDs.")
+		return
+	}
+
+	switch subcommand {
+	case "addrole":
+		ch.rolePanelAddRoleHandler(s, i, panel)
+	case "removerole":
+		ch.rolePanelRemoveRoleHandler(s, i, panel)
+	case "settings":
+		ch.rolePanelSettingsHandler(s, i, panel)
+	case "post":
+		ch.rolePanelPostHandler(s, i, panel)
+	case "delete":
+		ch.rolePanelDeleteHandler(s, i, panel)
+	}
+}
+
+func (ch *CommandHandler) rolePanelCreateHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	style := getStringOption(i, "style")
+	if style == "" {
+		style = "buttons"
+	}
+
+	panel := &database.RolePanel{
+		GuildID:     i.GuildID,
+		Title:       truncate(getStringOption(i, "title"), 256),
+		Description: getStringOption(i, "description"),
+		Style:       style,
+		CreatedBy:   i.Member.User.ID,
+	}
+	id, err := ch.bot.DB.CreateRolePanel(panel)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to create role panel.")
+		return
+	}
+
+	respondEmbed(s, i, successEmbed("Role Panel Created",
+		fmt.Sprintf("Panel **#%d** created. Add roles with `/rolepanel addrole panel:%d`, then `/rolepanel post panel:%d`.", id, id, id)))
+}
+
+func (ch *CommandHandler) rolePanelAddRoleHandler(s *discordgo.Session, i *discordgo.InteractionCreate, panel *database.RolePanel) {
+	role := getRoleOption(i, "role")
+	if role == nil || role.ID == i.GuildID || role.Managed {
+		respondEphemeral(s, i, "That role can't be assigned.")
+		return
+	}
+
+	exists := false
+	for _, r := range panel.Roles {
+		if r.RoleID == role.ID {
+			exists = true
+		}
+	}
+	if !exists && len(panel.Roles) >= maxRolePanelRoles {
+		respondEphemeral(s, i, fmt.Sprintf("A panel can have at most %d roles.", maxRolePanelRoles))
+		return
+	}
+
+	err := ch.bot.DB.AddRolePanelRole(&database.RolePanelRole{
+		PanelID:     panel.ID,
+		RoleID:      role.ID,
+		Label:       getStringOption(i, "label"),
+		Emoji:       parseEmojiInput(getStringOption(i, "emoji")),
+		Description: getStringOption(i, "description"),
+	})
+	if err != nil {
+		respondEphemeral(s, i, "Failed to add role.")
+		return
+	}
+
+	ch.refreshRolePanel(s, panel)
+	respondEmbed(s, i, successEmbed("Role Added",
+		fmt.Sprintf("<@&%s> added to panel **#%d**", role.ID, panel.ID)))
+}
+
+func (ch *CommandHandler) rolePanelRemoveRoleHandler(s *discordgo.Session, i *discordgo.InteractionCreate, panel *database.RolePanel) {
+	role := getRoleOption(i, "role")
+	if role == nil {
+		respondEphemeral(s, i, "Please specify a role.")
+		return
+	}
+
+	removed, err := ch.bot.DB.RemoveRolePanelRole(panel.ID, role.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to remove role.")
+		return
+	}
+	if !removed {
+		respondEphemeral(s, i, "That role isn't on this panel.")
+		return
+	}
+
+	ch.refreshRolePanel(s, panel)
+	respondEmbed(s, i, successEmbed("Role Removed",
+		fmt.Sprintf("<@&%s> removed from panel **#%d**", role.ID, panel.ID)))
+}
+
+func (ch *CommandHandler) rolePanelSettingsHandler(s *discordgo.Session, i *discordgo.InteractionCreate, panel *database.RolePanel) {
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "max":
+			panel.MaxRoles = int(opt.IntValue())
+		case "required_role":
+			panel.RequiredRoleID = opt.RoleValue(nil, "").ID
+		case "clear_required":
+			if opt.BoolValue() {
+				panel.RequiredRoleID = ""
+			}
+		case "duration":
+			value := strings.TrimSpace(opt.StringValue())
+			if value == "0" {
+				panel.TempDuration = 0
+				continue
+			}
+			d, err := parseDuration(value)
+			if err != nil || d < time.Minute {
+				respondEphemeral(s, i, "Invalid duration. Use formats like 30m, 12h, 7d (minimum 1m), or 0 for permanent.")
+				return
+			}
+			panel.TempDuration = int(d.Seconds())
+		}
+	}
+
+	if err := ch.bot.DB.UpdateRolePanel(panel); err != nil {
+		respondEphemeral(s, i, "Failed to update panel.")
+		return
+	}
+
+	ch.refreshRolePanel(s, panel)
+	respondEmbed(s, i, ch.rolePanelEmbed(panel))
+}
+
+func (ch *CommandHandler) rolePanelPostHandler(s *discordgo.Session, i *discordgo.InteractionCreate, panel *database.RolePanel) {
+	if len(panel.Roles) == 0 {
+		respondEphemeral(s, i, "Add at least one role before posting the panel.")
+		return
+	}
+
+	channelID := panel.ChannelID
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		channelID = channel.ID
+	} else if channelID == "" {
+		channelID = i.ChannelID
+	}
+
+	posted, err := ch.bot.publishRolePanel(s, panel.ID, channelID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to post the panel. Check that I can send messages in that channel.")
+		return
+	}
+
+	respondEmbedEphemeral(s, i, successEmbed("Role Panel Posted",
+		fmt.Sprintf("[Jump to panel](https://discord.com/channels/%s/%s/%s)", posted.GuildID, posted.ChannelID, posted.MessageID)))
+}
+
+func (ch *CommandHandler) rolePanelDeleteHandler(s *discordgo.Session, i *discordgo.InteractionCreate, panel *database.RolePanel) {
+	if err := ch.bot.DB.DeleteRolePanel(i.GuildID, panel.ID); err != nil {
+		respondEphemeral(s, i, "Failed to delete panel.")
+		return
+	}
+	if panel.MessageID != "" {
+		s.ChannelMessageDelete(panel.ChannelID, panel.MessageID)
+	}
+
+	respondEmbed(s, i, successEmbed("Role Panel Deleted", fmt.Sprintf("Panel **#%d** has been deleted", panel.ID)))
+}
+
+func (ch *CommandHandler) rolePanelListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	panels, err := ch.bot.DB.GetRolePanels(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get role panels.")
+		return
+	}
+	if len(panels) == 0 {
+		respondEphemeral(s, i, "No role panels yet. Create one with `/rolepanel create`.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Role Panels",
+		Color: 0x5865F2,
+	}
+	for _, panel := range panels {
+		if len(embed.Fields) == 25 {
+			break
+		}
+		location := "Not posted"
+		if panel.MessageID != "" {
+			location = fmt.Sprintf("[Posted in <#%s>](https://discord.com/channels/%s/%s/%s)", panel.ChannelID, panel.GuildID, panel.ChannelID, panel.MessageID)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  truncate(fmt.Sprintf("#%d • %s (%s)", panel.ID, panel.Title, panel.Style), 256),
+			Value: fmt.Sprintf("%d role(s) • %s", len(panel.Roles), location),
+		})
+	}
+
+	respondEmbed(s, i, embed)
+}
+
+// rolePanelEmbed summarises a panel's settings
+func (ch *CommandHandler) rolePanelEmbed(panel *database.RolePanel) *discordgo.MessageEmbed {
+	limit := "No limit"
+	if panel.MaxRoles == 1 {
+		limit = "Pick one"
+	} else if panel.MaxRoles > 1 {
+		limit = fmt.Sprintf("Up to %d", panel.MaxRoles)
+	}
+	required := "None"
+	if panel.RequiredRoleID != "" {
+		required = fmt.Sprintf("<@&%s>", panel.RequiredRoleID)
+	}
+	duration := "Permanent"
+	if panel.TempDuration > 0 {
+		duration = formatDuration(time.Duration(panel.TempDuration) * time.Second)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Role Panel #%d Settings", panel.ID),
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Limit", Value: limit, Inline: true},
+			{Name: "Required Role", Value: required, Inline: true},
+			{Name: "Role Duration", Value: duration, Inline: true},
+		},
+	}
+}
+
+// refreshRolePanel updates an already posted panel after a change
+func (ch *CommandHandler) refreshRolePanel(s *discordgo.Session, panel *database.RolePanel) {
+	if panel.MessageID != "" {
+		ch.bot.publishRolePanel(s, panel.ID, "")
+	}
+}
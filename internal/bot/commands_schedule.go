@@ -0,0 +1,415 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerScheduleCommands() {
+	idOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionInteger,
+		Name:         "id",
+		Description:  "Scheduled message",
+		Required:     true,
+		Autocomplete: true,
+	}
+
+	ch.Register(&Command{
+		Name:        "schedule",
+		Description: "Schedule a message",
+		Category:    "Utility",
+		SlashOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Schedule a message",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "time",
+						Description: "When to send (e.g., 1h30m, 2d)",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "Message to send",
+						Required:    false,
+						MaxLength:   2000,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to send in (default: this one)",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "embed",
+						Description: "Embed JSON, such as one exported from Discohook",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Name:        "attachment",
+						Description: "File to send with the message",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List scheduled messages",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "all",
+						Description: "Show everyone's scheduled messages (moderators only)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "edit",
+				Description: "Change a message before it's sent",
+				Options: []*discordgo.ApplicationCommandOption{
+					idOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "time",
+						Description: "New delay from now (e.g., 1h30m, 2d)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "New message text",
+						Required:    false,
+						MaxLength:   2000,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "New channel to send in",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "embed",
+						Description: "New embed JSON",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "remove",
+						Description: "Remove part of the message",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Text", Value: "message"},
+							{Name: "Embed", Value: "embed"},
+							{Name: "Attachments", Value: "attachments"},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "cancel",
+				Description: "Cancel a scheduled message",
+				Options:     []*discordgo.ApplicationCommandOption{idOption},
+			},
+		},
+		Handler:      ch.scheduleHandler,
+		Autocomplete: ch.scheduleAutocomplete,
+	})
+
+	ch.RegisterComponent("schedule", ch.handleScheduleCancel)
+}
+
+func (ch *CommandHandler) scheduleHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "Messages can only be scheduled in a server.")
+		return
+	}
+	switch getSubcommandName(i) {
+	case "create":
+		ch.scheduleCreateHandler(s, i)
+	case "list":
+		ch.scheduleListHandler(s, i)
+	case "edit":
+		ch.scheduleEditHandler(s, i)
+	case "cancel":
+		ch.scheduleCancelHandler(s, i)
+	}
+}
+
+// canScheduleIn reports whether a member can send messages in a channel themselves
+func canScheduleIn(s *discordgo.Session, userID, channelID string) bool {
+	perms, err := s.UserChannelPermissions(userID, channelID)
+	return err == nil && perms&discordgo.PermissionSendMessages != 0
+}
+
+// scheduleAttachment returns the attachment given to /schedule create, if any
+func scheduleAttachment(i *discordgo.InteractionCreate) *discordgo.MessageAttachment {
+	data := i.ApplicationCommandData()
+	for _, opt := range getOptions(i) {
+		if opt.Name == "attachment" {
+			if id, ok := opt.Value.(string); ok && data.Resolved != nil {
+				return data.Resolved.Attachments[id]
+			}
+		}
+	}
+	return nil
+}
+
+func (ch *CommandHandler) scheduleCreateHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	scheduledFor, ok := scheduleTime(getStringOption(i, "time"))
+	if !ok {
+		respondEphemeral(s, i, "Invalid time format. Use format like: 1h30m, 2d, 30m")
+		return
+	}
+
+	channelID := i.ChannelID
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		channelID = channel.ID
+	}
+	if !canScheduleIn(s, i.Member.User.ID, channelID) {
+		respondEphemeral(s, i, fmt.Sprintf("You can't send messages in <#%s>.", channelID))
+		return
+	}
+
+	guildID := i.GuildID
+	sm := &database.ScheduledMessage{
+		GuildID:      &guildID,
+		ChannelID:    channelID,
+		UserID:       i.Member.User.ID,
+		Message:      strings.TrimSpace(getStringOption(i, "message")),
+		ScheduledFor: scheduledFor,
+	}
+	if raw := getStringOption(i, "embed"); raw != "" {
+		embed, err := encodeScheduledEmbed(raw)
+		if err != nil {
+			respondEphemeral(s, i, "Invalid embed: "+err.Error()+".")
+			return
+		}
+		sm.Embed = embed
+	}
+	attachment := scheduleAttachment(i)
+	if sm.Message == "" && sm.Embed == "" && attachment == nil {
+		respondEphemeral(s, i, "Give a message, an embed or an attachment to send.")
+		return
+	}
+	if count, _ := ch.bot.DB.CountUpcomingScheduledMessages(i.GuildID, i.Member.User.ID); count >= maxScheduledMessages {
+		respondEphemeral(s, i, fmt.Sprintf("You already have the maximum of %d scheduled messages.", maxScheduledMessages))
+		return
+	}
+
+	// Downloading the attachment can take longer than Discord waits for a response
+	respondDeferredEphemeral(s, i)
+
+	if attachment != nil {
+		saved, err := ch.bot.saveScheduledAttachment(i.GuildID, attachment)
+		if err != nil {
+			editResponse(s, i, "Failed to save the attachment: "+err.Error()+".")
+			return
+		}
+		sm.Attachments = append(sm.Attachments, saved)
+	}
+
+	if err := ch.bot.DB.ScheduleMessage(sm); err != nil {
+		removeScheduledAttachments(sm)
+		editResponse(s, i, "Failed to schedule message.")
+		return
+	}
+
+	editResponseEmbed(s, i, successEmbed("Message Scheduled",
+		fmt.Sprintf("Message **#%d** will be sent in <#%s> <t:%d:R>.\nSee or cancel it with `/schedule list`.", sm.ID, channelID, scheduledFor.Unix())))
+}
+
+func (ch *CommandHandler) scheduleListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	everyone := getBoolOption(i, "all")
+	if everyone && !isModerator(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need moderator permissions to see everyone's scheduled messages.")
+		return
+	}
+	userID := i.Member.User.ID
+	if everyone {
+		userID = ""
+	}
+
+	messages, err := ch.bot.DB.GetUpcomingScheduledMessages(i.GuildID, userID, 25)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get scheduled messages.")
+		return
+	}
+	embed, components := renderScheduleList(messages, everyone)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// scheduledMessageFor looks up the message picked in the id option and checks the caller may change it
+func (ch *CommandHandler) scheduledMessageFor(s *discordgo.Session, i *discordgo.InteractionCreate) *database.ScheduledMessage {
+	sm, err := ch.bot.DB.GetScheduledMessage(getIntOption(i, "id"))
+	if err != nil || sm == nil || sm.GuildID == nil || *sm.GuildID != i.GuildID {
+		respondEphemeral(s, i, "That message has already been sent or doesn't exist.")
+		return nil
+	}
+	if !canManageScheduledMessage(s, i.GuildID, i.Member.User.ID, sm) {
+		respondEphemeral(s, i, "You can only change your own scheduled messages.")
+		return nil
+	}
+	return sm
+}
+
+func (ch *CommandHandler) scheduleEditHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sm := ch.scheduledMessageFor(s, i)
+	if sm == nil {
+		return
+	}
+	if !sm.ScheduledFor.After(time.Now()) {
+		respondEphemeral(s, i, "That message is being sent and can't be changed anymore.")
+		return
+	}
+
+	var removed []database.DeletedAttachment
+	switch getStringOption(i, "remove") {
+	case "message":
+		sm.Message = ""
+	case "embed":
+		sm.Embed = ""
+	case "attachments":
+		removed, sm.Attachments = sm.Attachments, nil
+	}
+
+	if input := getStringOption(i, "time"); input != "" {
+		scheduledFor, ok := scheduleTime(input)
+		if !ok {
+			respondEphemeral(s, i, "Invalid time format. Use format like: 1h30m, 2d, 30m")
+			return
+		}
+		sm.ScheduledFor = scheduledFor
+	}
+	if message := strings.TrimSpace(getStringOption(i, "message")); message != "" {
+		sm.Message = message
+	}
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		if !canScheduleIn(s, i.Member.User.ID, channel.ID) {
+			respondEphemeral(s, i, fmt.Sprintf("You can't send messages in <#%s>.", channel.ID))
+			return
+		}
+		sm.ChannelID = channel.ID
+	}
+	if raw := getStringOption(i, "embed"); raw != "" {
+		embed, err := encodeScheduledEmbed(raw)
+		if err != nil {
+			respondEphemeral(s, i, "Invalid embed: "+err.Error()+".")
+			return
+		}
+		sm.Embed = embed
+	}
+	if sm.Message == "" && sm.Embed == "" && len(sm.Attachments) == 0 {
+		respondEphemeral(s, i, "The message would be empty. Cancel it with `/schedule cancel` instead.")
+		return
+	}
+
+	updated, err := ch.bot.DB.UpdateScheduledMessage(sm)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to update the scheduled message.")
+		return
+	}
+	if !updated {
+		respondEphemeral(s, i, "That message has already been sent.")
+		return
+	}
+	removeScheduledAttachments(&database.ScheduledMessage{Attachments: removed})
+
+	respondEmbedEphemeral(s, i, successEmbed("Scheduled Message Updated", describeScheduledMessage(sm)))
+}
+
+func (ch *CommandHandler) scheduleCancelHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sm := ch.scheduledMessageFor(s, i)
+	if sm == nil {
+		return
+	}
+	cancelled, err := ch.bot.DB.CancelScheduledMessage(sm.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to cancel the scheduled message.")
+		return
+	}
+	if !cancelled {
+		respondEphemeral(s, i, "That message has already been sent.")
+		return
+	}
+	removeScheduledAttachments(sm)
+	respondEmbedEphemeral(s, i, successEmbed("Scheduled Message Cancelled", fmt.Sprintf("Message **#%d** won't be sent.", sm.ID)))
+}
+
+// scheduleAutocomplete suggests the caller's unsent messages for the id option;
+// moderators are offered everyone's
+func (ch *CommandHandler) scheduleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	typed := ""
+	for _, opt := range getOptions(i) {
+		if opt.Focused {
+			typed = strings.TrimSpace(fmt.Sprint(opt.Value))
+		}
+	}
+
+	userID := i.Member.User.ID
+	if isModerator(s, i.GuildID, userID) {
+		userID = ""
+	}
+	messages, err := ch.bot.DB.GetUpcomingScheduledMessages(i.GuildID, userID, 25)
+	if err != nil {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, sm := range messages {
+		id := strconv.FormatInt(sm.ID, 10)
+		if typed != "" && !strings.HasPrefix(id, typed) {
+			continue
+		}
+		summary := sm.Message
+		if summary == "" {
+			summary = "(embed or attachment)"
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(fmt.Sprintf("#%d · %s · %s", sm.ID, sm.ScheduledFor.UTC().Format("Jan 2 15:04 UTC"), strings.ReplaceAll(summary, "\n", " ")), 100),
+			Value: sm.ID,
+		})
+	}
+	respondAutocomplete(s, i, choices)
+}
@@ -0,0 +1,209 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerSeasonCommands() {
+	ch.Register(&Command{
+		Name:        "season",
+		Description: "XP seasons with periodic winners",
+		Category:    "XP",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "standings",
+				Description: "Show the current season's standings",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "history",
+				Description: "Show the results of past seasons",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Enable and configure seasons (Admin)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "period",
+						Description: "Season length",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Weekly (starts Monday)", Value: "weekly"},
+							{Name: "Monthly (starts on the 1st)", Value: "monthly"},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel for winner announcements",
+						Required:    false,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "winner_role",
+						Description: "Role given to each season's winner",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "top",
+						Description: "How many places to announce and archive (default 10)",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    25,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Disable seasons (Admin)",
+			},
+		},
+		Handler: ch.seasonHandler,
+	})
+}
+
+func (ch *CommandHandler) seasonHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg, err := ch.bot.DB.GetXPSeasonConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get season settings.")
+		return
+	}
+
+	switch subcommand := getSubcommandName(i); subcommand {
+	case "standings":
+		if !cfg.Enabled || cfg.SeasonStart == 0 {
+			respondEphemeral(s, i, "Seasons are not enabled on this server.")
+			return
+		}
+		start := time.UnixMilli(cfg.SeasonStart).UTC()
+		end := seasonEndFor(start, cfg.Period)
+		standings, err := ch.bot.DB.GetXPGainsBetween(i.GuildID, start, end, max(cfg.TopCount, 1))
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get season standings.")
+			return
+		}
+
+		var desc strings.Builder
+		if len(standings) == 0 {
+			desc.WriteString("Nobody has earned XP this season yet.")
+		}
+		for idx, ux := range standings {
+			desc.WriteString(fmt.Sprintf("%s <@%s> - %d XP\n", seasonPlace(idx+1), ux.UserID, ux.XP))
+		}
+		desc.WriteString(fmt.Sprintf("\nSeason ends <t:%s:R>", formatUnixTime(end)))
+		if cfg.WinnerRoleID != "" {
+			desc.WriteString(fmt.Sprintf(", the winner gets <@&%s>", cfg.WinnerRoleID))
+		}
+
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("📅 %s Season Standings", strings.Title(cfg.Period)),
+			Description: desc.String(),
+			Color:       0xFFD700,
+		})
+
+	case "history":
+		entries, err := ch.bot.DB.GetXPSeasonArchive(i.GuildID, 5, 3)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get season history.")
+			return
+		}
+		if len(entries) == 0 {
+			respondEphemeral(s, i, "No seasons have finished yet.")
+			return
+		}
+
+		embed := &discordgo.MessageEmbed{
+			Title: "🏆 Past Seasons",
+			Color: 0xFFD700,
+		}
+		for _, e := range entries {
+			name := fmt.Sprintf("%s – %s", time.UnixMilli(e.SeasonStart).UTC().Format("Jan 2"),
+				time.UnixMilli(e.SeasonEnd).UTC().AddDate(0, 0, -1).Format("Jan 2, 2006"))
+			line := fmt.Sprintf("%s <@%s> - %d XP", seasonPlace(e.Position), e.UserID, e.XP)
+			if n := len(embed.Fields); n > 0 && embed.Fields[n-1].Name == name {
+				embed.Fields[n-1].Value += "\n" + line
+				continue
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: line})
+		}
+		respondEmbed(s, i, embed)
+
+	case "setup", "disable":
+		if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "You need administrator permission to configure seasons.")
+			return
+		}
+
+		if subcommand == "disable" {
+			cfg.Enabled = false
+			cfg.SeasonStart = 0
+			if err := ch.bot.DB.SetXPSeasonConfig(cfg); err != nil {
+				respondEphemeral(s, i, "Failed to disable seasons.")
+				return
+			}
+			respondEmbed(s, i, successEmbed("Seasons Disabled", "The current season was dropped without archiving. Lifetime XP is unaffected."))
+			return
+		}
+
+		period := getStringOption(i, "period")
+		if !cfg.Enabled || cfg.Period != period {
+			cfg.SeasonStart = seasonStartFor(time.Now(), period).UnixMilli()
+		}
+		cfg.Enabled = true
+		cfg.Period = period
+		for _, opt := range getOptions(i) {
+			switch opt.Name {
+			case "channel":
+				cfg.ChannelID = opt.ChannelValue(nil).ID
+			case "winner_role":
+				cfg.WinnerRoleID = opt.RoleValue(nil, "").ID
+			case "top":
+				cfg.TopCount = int(opt.IntValue())
+			}
+		}
+		if err := ch.bot.DB.SetXPSeasonConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save season settings.")
+			return
+		}
+
+		end := seasonEndFor(time.UnixMilli(cfg.SeasonStart).UTC(), cfg.Period)
+		desc := fmt.Sprintf("**%s** seasons are enabled. The current season ends <t:%s:R>.\nTop **%d** will be archived",
+			strings.Title(cfg.Period), formatUnixTime(end), cfg.TopCount)
+		if cfg.ChannelID != "" {
+			desc += fmt.Sprintf(" and announced in <#%s>", cfg.ChannelID)
+		}
+		desc += "."
+		if cfg.WinnerRoleID != "" {
+			desc += fmt.Sprintf("\nWinners receive <@&%s> until the next season ends.", cfg.WinnerRoleID)
+		}
+		respondEmbed(s, i, successEmbed("Seasons Configured", desc))
+	}
+}
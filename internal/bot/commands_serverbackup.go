@@ -0,0 +1,306 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxServerBackups is how many backups a server can keep; old ones aren't
+// pruned automatically, since the one from before an attack is what matters
+const maxServerBackups = 10
+
+func (ch *CommandHandler) registerServerBackupCommands() {
+	idOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionInteger,
+		Name:         "id",
+		Description:  "Backup",
+		Required:     true,
+		Autocomplete: true,
+	}
+
+	ch.Register(&Command{
+		Name:        "serverbackup",
+		Description: "Back up and restore the server's channels, roles and settings",
+		Category:    "Administration",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Take a backup of the server's structure",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name for the backup",
+						Required:    false,
+						MaxLength:   100,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the server's backups",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "restore",
+				Description: "Rebuild the server's channels, roles and settings from a backup",
+				Options: []*discordgo.ApplicationCommandOption{
+					idOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "delete_extra",
+						Description: "Also delete channels that aren't in the backup",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a backup",
+				Options:     []*discordgo.ApplicationCommandOption{idOption},
+			},
+		},
+		Handler:      ch.serverBackupHandler,
+		Autocomplete: ch.serverBackupAutocomplete,
+	})
+
+	ch.RegisterComponent("serverbackup", ch.handleServerBackupComponent)
+}
+
+func (ch *CommandHandler) serverBackupHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage server backups.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "create":
+		ch.serverBackupCreate(s, i)
+	case "list":
+		ch.serverBackupList(s, i)
+	case "restore":
+		ch.serverBackupRestore(s, i)
+	case "delete":
+		id := getIntOption(i, "id")
+		deleted, err := ch.bot.DB.DeleteServerBackup(i.GuildID, id)
+		if err != nil || !deleted {
+			respondEphemeral(s, i, fmt.Sprintf("Backup **#%d** doesn't exist.", id))
+			return
+		}
+		respondEmbed(s, i, successEmbed("Backup Deleted", fmt.Sprintf("Backup **#%d** has been deleted.", id)))
+	}
+}
+
+func (ch *CommandHandler) serverBackupCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if ch.bot.DB.CountServerBackups(i.GuildID) >= maxServerBackups {
+		respondEphemeral(s, i, fmt.Sprintf("This server already has %d backups. Delete one with `/serverbackup delete` first.", maxServerBackups))
+		return
+	}
+
+	respondDeferred(s, i)
+
+	snap, err := snapshotGuild(s, i.GuildID)
+	if err != nil {
+		editResponse(s, i, "Failed to read the server's structure: "+err.Error())
+		return
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		editResponse(s, i, "Failed to save the backup.")
+		return
+	}
+
+	name := getStringOption(i, "name")
+	if name == "" {
+		name = time.Now().UTC().Format("2006-01-02 15:04 UTC")
+	}
+	backup := &database.ServerBackup{
+		GuildID:      i.GuildID,
+		Name:         name,
+		CreatedBy:    i.Member.User.ID,
+		RoleCount:    len(snap.Roles),
+		ChannelCount: len(snap.Channels),
+		Data:         string(data),
+	}
+	if err := ch.bot.DB.CreateServerBackup(backup); err != nil {
+		editResponse(s, i, "Failed to save the backup.")
+		return
+	}
+
+	editResponseEmbed(s, i, successEmbed("Server Backed Up",
+		fmt.Sprintf("Saved backup **#%d** (%s) with **%d** roles and **%d** channels, including permission overwrites and server settings.\nRestore it with `/serverbackup restore id:%d`.",
+			backup.ID, name, backup.RoleCount, backup.ChannelCount, backup.ID)))
+}
+
+func (ch *CommandHandler) serverBackupList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	backups, err := ch.bot.DB.GetServerBackups(i.GuildID)
+	if err != nil || len(backups) == 0 {
+		respondEphemeral(s, i, "This server has no backups. Take one with `/serverbackup create`.")
+		return
+	}
+
+	var sb strings.Builder
+	for _, b := range backups {
+		sb.WriteString(fmt.Sprintf("**#%d** %s\n%d roles · %d channels · by <@%s> <t:%d:R>\n\n",
+			b.ID, b.Name, b.RoleCount, b.ChannelCount, b.CreatedBy, b.CreatedAt.Unix()))
+	}
+	respondEmbedEphemeral(s, i, &discordgo.MessageEmbed{
+		Title:       "Server Backups",
+		Description: sb.String(),
+		Color:       0x5865F2,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d of %d backups", len(backups), maxServerBackups)},
+	})
+}
+
+func (ch *CommandHandler) serverBackupRestore(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	id := getIntOption(i, "id")
+	backup, err := ch.bot.DB.GetServerBackup(i.GuildID, id)
+	if err != nil || backup == nil {
+		respondEphemeral(s, i, fmt.Sprintf("Backup **#%d** doesn't exist.", id))
+		return
+	}
+
+	deleteExtra := getBoolOption(i, "delete_extra")
+	description := fmt.Sprintf("This will restore **%d** roles and **%d** channels from backup **#%d** (%s), taken <t:%d:R>. Roles and channels that were changed are edited back and missing ones are recreated.",
+		backup.RoleCount, backup.ChannelCount, backup.ID, backup.Name, backup.CreatedAt.Unix())
+	if deleteExtra {
+		description += "\n\n**Channels that aren't in the backup will be deleted, along with their messages.**"
+	}
+	flag := "0"
+	if deleteExtra {
+		flag = "1"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "⚠️ Confirm Server Restore",
+				Description: description,
+				Color:       0xFEE75C,
+			}},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Restore", Style: discordgo.DangerButton, CustomID: fmt.Sprintf("serverbackup:restore:%d:%s", backup.ID, flag)},
+					discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "serverbackup:cancel"},
+				}},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleServerBackupComponent handles the restore confirmation, custom ID "serverbackup:<restore:<id>:<0|1>|cancel>"
+func (ch *CommandHandler) handleServerBackupComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+
+	updateMessage := func(embed *discordgo.MessageEmbed) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+	}
+
+	if len(parts) < 2 || parts[1] == "cancel" {
+		updateMessage(infoEmbed("Cancelled", "Nothing was changed."))
+		return
+	}
+	if len(parts) != 4 || parts[1] != "restore" {
+		return
+	}
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to restore server backups.")
+		return
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return
+	}
+	deleteExtra := parts[3] == "1"
+
+	backup, err := ch.bot.DB.GetServerBackup(i.GuildID, id)
+	if err != nil || backup == nil {
+		updateMessage(errorEmbed("Backup Not Found", fmt.Sprintf("Backup **#%d** no longer exists.", id)))
+		return
+	}
+	var snap serverSnapshot
+	if err := json.Unmarshal([]byte(backup.Data), &snap); err != nil {
+		updateMessage(errorEmbed("Backup Unreadable", fmt.Sprintf("Backup **#%d** couldn't be read.", id)))
+		return
+	}
+	if _, running := activeRestores.LoadOrStore(i.GuildID, true); running {
+		updateMessage(errorEmbed("Restore Running", "A restore is already running on this server. Wait for it to finish."))
+		return
+	}
+	defer activeRestores.Delete(i.GuildID)
+
+	updateMessage(infoEmbed("Restoring...", fmt.Sprintf("Restoring backup **#%d**. This can take a few minutes on large servers.", id)))
+	log.Printf("[ServerBackup] %s restoring backup #%d in guild %s (delete extra: %v)", i.Member.User.Username, id, i.GuildID, deleteExtra)
+
+	result, err := restoreGuild(s, i.GuildID, &snap, deleteExtra)
+	if err != nil {
+		editResponseEmbed(s, i, errorEmbed("Restore Failed", err.Error()))
+		return
+	}
+	editResponseEmbed(s, i, successEmbed("Server Restored", describeRestore(result)))
+}
+
+func (ch *CommandHandler) serverBackupAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	typed := ""
+	for _, opt := range getOptions(i) {
+		if opt.Focused {
+			typed = strings.TrimSpace(fmt.Sprint(opt.Value))
+		}
+	}
+
+	backups, err := ch.bot.DB.GetServerBackups(i.GuildID)
+	if err != nil || !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondAutocomplete(s, i, nil)
+		return
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, b := range backups {
+		id := strconv.FormatInt(b.ID, 10)
+		if typed != "" && !strings.HasPrefix(id, typed) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(fmt.Sprintf("#%d · %s · %s", b.ID, b.Name, b.CreatedAt.UTC().Format("Jan 2 2006")), 100),
+			Value: b.ID,
+		})
+	}
+	respondAutocomplete(s, i, choices)
+}
@@ -18,7 +18,10 @@ package bot
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/blubskye/himiko/internal/rankcard"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -70,13 +73,155 @@ func (ch *CommandHandler) registerSettingsCommands() {
 			{
 				Type:        discordgo.ApplicationCommandOptionString,
 				Name:        "message",
-				Description: "Welcome message ({user}, {username}, {server} placeholders)",
+				Description: "Welcome message ({user}, {username}, {server}, {inviter} placeholders)",
 				Required:    true,
 			},
 		},
 		Handler: ch.setWelcomeHandler,
 	})
 
+	// Welcome image card
+	ch.Register(&Command{
+		Name:        "welcomecard",
+		Description: "Attach a generated image card to welcome messages",
+		Category:    "Settings",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "enabled",
+				Description: "Turn welcome cards on or off",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "background",
+				Description: "Background color as a hex code (e.g., #23272A)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "accent",
+				Description: "Accent color for the title and avatar ring (e.g., #5865F2)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "background_url",
+				Description: "PNG, JPEG or GIF drawn behind the card, or \"none\" to remove it",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "title",
+				Description: "Heading above the username (default: WELCOME)",
+				Required:    false,
+				MaxLength:   40,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "reset",
+				Description: "Reset the card to the default look",
+				Required:    false,
+			},
+		},
+		Handler: ch.welcomeCardHandler,
+	})
+
+	// Welcome, leave and join DM embeds
+	greetingChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Welcome message", Value: database.GreetingWelcome},
+		{Name: "Leave message", Value: database.GreetingLeave},
+		{Name: "Leave message for kicks", Value: database.GreetingLeaveKick},
+		{Name: "Leave message for bans", Value: database.GreetingLeaveBan},
+		{Name: "Join DM", Value: database.GreetingJoinDM},
+	}
+	ch.Register(&Command{
+		Name:        "welcome",
+		Description: "Customize welcome, leave and join DM messages",
+		Category:    "Settings",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "test",
+				Description: "Preview a message as if you had just joined or left",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "type",
+						Description: "Message to preview (default: welcome message)",
+						Required:    false,
+						Choices:     greetingChoices,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "embed",
+				Description: "Send an embed, built in a form or imported from JSON",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "type",
+						Description: "Message the embed belongs to",
+						Required:    true,
+						Choices:     greetingChoices,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "json",
+						Description: "Embed JSON to import, e.g. from Discohook",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "clear",
+						Description: "Remove the embed and send plain text only",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "leave",
+				Description: "Post a message when a member leaves",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel for leave messages",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "Leave message ({user}, {username}, {membercount}, {duration} placeholders)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "kicked_message",
+						Description: "Sent instead when the member was kicked ({moderator}, {reason}), \"none\" to remove",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "banned_message",
+						Description: "Sent instead when the member was banned ({moderator}, {reason}), \"none\" to remove",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "disable",
+						Description: "Turn leave messages off",
+						Required:    false,
+					},
+				},
+			},
+		},
+		Handler: ch.welcomeHandler,
+	})
+
 	// Disable welcome
 	ch.Register(&Command{
 		Name:        "disablewelcome",
@@ -198,7 +343,7 @@ func (ch *CommandHandler) setWelcomeHandler(s *discordgo.Session, i *discordgo.I
 
 	embed := successEmbed("Welcome Message Configured",
 		fmt.Sprintf("Welcome messages will be sent to <#%s>\n\n**Preview:**\n%s",
-			channel.ID, replacePlaceholders(message, i.Member.User, i.GuildID)))
+			channel.ID, replaceInvitePlaceholders(replacePlaceholders(message, i.Member.User, i.GuildID), s, i.Member.User.ID, "example")))
 	respondEmbed(s, i, embed)
 }
 
@@ -324,6 +469,9 @@ func (ch *CommandHandler) disableJoinDMHandler(s *discordgo.Session, i *discordg
 	settings.JoinDMMessage = nil
 
 	err := ch.bot.DB.SetGuildSettings(settings)
+	if err == nil {
+		err = ch.bot.DB.DeleteGreetingMessage(i.GuildID, database.GreetingJoinDM)
+	}
 	if err != nil {
 		respondEphemeral(s, i, "Failed to update settings.")
 		return
@@ -333,3 +481,252 @@ func (ch *CommandHandler) disableJoinDMHandler(s *discordgo.Session, i *discordg
 		"New members will no longer receive a DM when joining this server.")
 	respondEmbed(s, i, embed)
 }
+
+func (ch *CommandHandler) welcomeCardHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to change settings.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetWelcomeCardSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get welcome card settings.")
+		return
+	}
+
+	if getBoolOption(i, "reset") {
+		settings.Background, settings.Accent = nil, nil
+		settings.BackgroundURL, settings.Title = "", ""
+	}
+
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "enabled":
+			settings.Enabled = opt.BoolValue()
+		case "background", "accent":
+			c, err := rankcard.ParseHexColor(opt.StringValue())
+			if err != nil {
+				respondEphemeral(s, i, fmt.Sprintf("Invalid %s color. Use a hex code like `#5865F2`.", opt.Name))
+				return
+			}
+			v := rankcard.ToInt(c)
+			if opt.Name == "background" {
+				settings.Background = &v
+			} else {
+				settings.Accent = &v
+			}
+		case "background_url":
+			url := strings.TrimSpace(opt.StringValue())
+			if strings.EqualFold(url, "none") {
+				url = ""
+			} else if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+				respondEphemeral(s, i, "The background must be an http(s) link to an image.")
+				return
+			}
+			settings.BackgroundURL = url
+		case "title":
+			settings.Title = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	// Downloading the background and avatar can take a moment
+	respondDeferred(s, i)
+
+	if settings.BackgroundURL != "" {
+		if _, err := welcomeBackground(settings.BackgroundURL); err != nil {
+			editResponse(s, i, "Couldn't load that background image. Make sure the link points straight at a PNG, JPEG or GIF.")
+			return
+		}
+	}
+
+	if err := ch.bot.DB.SetWelcomeCardSettings(settings); err != nil {
+		editResponse(s, i, "Failed to save welcome card settings.")
+		return
+	}
+
+	status := "Disabled"
+	if settings.Enabled {
+		status = "Enabled"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: "Welcome Card Updated",
+		Color: rankcard.ToInt(rankcard.DefaultAccent),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: status, Inline: true},
+		},
+	}
+	if settings.Accent != nil {
+		embed.Color = *settings.Accent
+	}
+	if settings.Enabled {
+		if welcome, _ := ch.bot.DB.GetGuildSettings(i.GuildID); welcome == nil || welcome.WelcomeChannel == nil {
+			embed.Description = "Set a welcome channel with `/config setwelcome` so the card has somewhere to go."
+		}
+	}
+
+	edit := &discordgo.WebhookEdit{Embeds: &[]*discordgo.MessageEmbed{embed}}
+	memberNumber := 0
+	if guild, err := s.State.Guild(i.GuildID); err == nil {
+		memberNumber = guild.MemberCount
+	}
+	if card, err := ch.bot.renderWelcomeCard(settings, i.Member.User, memberNumber); err == nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://welcome.png"}
+		edit.Files = []*discordgo.File{{Name: "welcome.png", ContentType: "image/png", Reader: card}}
+	}
+	s.InteractionResponseEdit(i.Interaction, edit)
+}
+
+func (ch *CommandHandler) welcomeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to change settings.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "test":
+		ch.welcomeTestHandler(s, i)
+
+	case "embed":
+		ch.responseEmbedHandler(s, i, responseEmbedGreeting, getStringOption(i, "type"))
+
+	case "leave":
+		greeting, err := ch.bot.DB.GetGreetingMessage(i.GuildID, database.GreetingLeave)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get leave message settings.")
+			return
+		}
+
+		if getBoolOption(i, "disable") {
+			for _, kind := range []string{database.GreetingLeave, database.GreetingLeaveKick, database.GreetingLeaveBan} {
+				if err := ch.bot.DB.DeleteGreetingMessage(i.GuildID, kind); err != nil {
+					respondEphemeral(s, i, "Failed to update settings.")
+					return
+				}
+			}
+			respondEmbed(s, i, successEmbed("Leave Messages Disabled", "Leave messages have been disabled for this server."))
+			return
+		}
+
+		if channel := getChannelOption(i, "channel"); channel != nil {
+			greeting.ChannelID = channel.ID
+		}
+		if message := getStringOption(i, "message"); message != "" {
+			greeting.Content = message
+		}
+		if greeting.ChannelID == "" {
+			respondEphemeral(s, i, "Pick a channel for leave messages.")
+			return
+		}
+		if greeting.Content == "" && greeting.Embed == "" {
+			greeting.Content = "**{username}** has left the server."
+		}
+		if err := ch.bot.DB.SetGreetingMessage(greeting); err != nil {
+			respondEphemeral(s, i, "Failed to update settings.")
+			return
+		}
+
+		embed := successEmbed("Leave Messages Configured", fmt.Sprintf("Leave messages will be sent to <#%s>", greeting.ChannelID))
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Message", Value: greetingSummary(greeting)},
+		}
+
+		// Kick and ban messages replace the leave message when set
+		variants := []struct{ kind, option, label string }{
+			{database.GreetingLeaveKick, "kicked_message", "Kicked"},
+			{database.GreetingLeaveBan, "banned_message", "Banned"},
+		}
+		for _, v := range variants {
+			variant, err := ch.bot.DB.GetGreetingMessage(i.GuildID, v.kind)
+			if err != nil {
+				respondEphemeral(s, i, "Failed to get leave message settings.")
+				return
+			}
+			if message := getStringOption(i, v.option); message != "" {
+				if strings.EqualFold(message, "none") {
+					message = ""
+				}
+				variant.Content = message
+				if err := ch.bot.DB.SetGreetingMessage(variant); err != nil {
+					respondEphemeral(s, i, "Failed to update settings.")
+					return
+				}
+			}
+			if variant.Content != "" || variant.Embed != "" {
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: v.label, Value: greetingSummary(variant)})
+			}
+		}
+		respondEmbed(s, i, embed)
+	}
+}
+
+// welcomeTestHandler shows a greeting exactly as it would be sent, with the invoker
+// standing in for the member who joined or left
+func (ch *CommandHandler) welcomeTestHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	kind := getStringOption(i, "type")
+	if kind == "" {
+		kind = database.GreetingWelcome
+	}
+
+	settings, err := ch.bot.DB.GetGuildSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get settings.")
+		return
+	}
+
+	// The welcome card can take a moment to draw
+	respondDeferredEphemeral(s, i)
+
+	user := i.Member.User
+	var header string
+	var msg *discordgo.MessageSend
+	switch kind {
+	case database.GreetingWelcome:
+		msg = ch.bot.welcomeMessage(s, i.GuildID, user, settings, "", "")
+		header = "**Welcome message preview**"
+		if settings.WelcomeChannel == nil {
+			header += " (off, set a channel with `/config setwelcome`)"
+		} else {
+			header += fmt.Sprintf(" (sent to <#%s>)", *settings.WelcomeChannel)
+		}
+	case database.GreetingLeave, database.GreetingLeaveKick, database.GreetingLeaveBan:
+		// Kicks and bans are shown as if the invoker had done them
+		var entry *discordgo.AuditLogEntry
+		if kind != database.GreetingLeave {
+			entry = &discordgo.AuditLogEntry{UserID: user.ID, Reason: "Testing the leave message"}
+		}
+		var channelID string
+		channelID, msg = ch.bot.leaveMessage(s, i.GuildID, user, kind, entry)
+		header = fmt.Sprintf("**Leave message preview** (sent to <#%s>)", channelID)
+	case database.GreetingJoinDM:
+		if embed := ch.bot.joinDMEmbed(s, i.GuildID, user, settings, "", ""); embed != nil {
+			msg = &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+		}
+		header = "**Join DM preview**"
+	}
+
+	if msg == nil {
+		editResponse(s, i, "That message isn't set up yet, so nothing would be sent.")
+		return
+	}
+
+	content := header
+	if msg.Content != "" {
+		content = truncate(content+"\n"+msg.Content, 2000)
+	}
+	edit := &discordgo.WebhookEdit{Content: &content, Files: msg.Files}
+	if len(msg.Embeds) > 0 {
+		edit.Embeds = &msg.Embeds
+	}
+	s.InteractionResponseEdit(i.Interaction, edit)
+}
+
+// greetingSummary shows a greeting's text for a settings embed
+func greetingSummary(greeting *database.GreetingMessage) string {
+	switch {
+	case greeting.Content == "":
+		return "Embed only"
+	case greeting.Embed != "":
+		return truncate(greeting.Content, 1000) + "\n*(with an embed)*"
+	}
+	return truncate(greeting.Content, 1024)
+}
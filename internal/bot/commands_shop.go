@@ -0,0 +1,269 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerShopCommands() {
+	// Role shop
+	ch.Register(&Command{
+		Name:        "shop",
+		Description: "Browse and manage the role shop",
+		Category:    "Economy",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "view",
+				Description: "See what's for sale",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "List a role in the shop, or update its listing (admin)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to sell",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "price",
+						Description: "Price",
+						Required:    true,
+						MinValue:    floatPtr(0),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "stock",
+						Description: "How many can be sold (default: unlimited)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "duration",
+						Description: "How long the role lasts after buying (e.g. 7d, default: permanent)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "Shown in the shop",
+						Required:    false,
+						MaxLength:   200,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a role from the shop (admin)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to stop selling",
+						Required:    true,
+					},
+				},
+			},
+		},
+		Handler: ch.shopHandler,
+	})
+
+	// Buy
+	ch.Register(&Command{
+		Name:        "buy",
+		Description: "Buy a role from the shop",
+		Category:    "Economy",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionRole,
+				Name:        "role",
+				Description: "Role to buy",
+				Required:    true,
+			},
+		},
+		Handler: ch.buyHandler,
+	})
+}
+
+func (ch *CommandHandler) shopHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := getSubcommandName(i)
+	if subcommand == "view" {
+		ch.shopViewHandler(s, i)
+		return
+	}
+
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage the shop.")
+		return
+	}
+
+	role := getRoleOption(i, "role")
+	switch subcommand {
+	case "add":
+		item := &database.ShopItem{
+			GuildID:     i.GuildID,
+			RoleID:      role.ID,
+			Price:       getIntOption(i, "price"),
+			Stock:       -1,
+			Description: getStringOption(i, "description"),
+		}
+		for _, opt := range getOptions(i) {
+			if opt.Name == "stock" {
+				item.Stock = int(opt.IntValue())
+			}
+		}
+		if durationStr := getStringOption(i, "duration"); durationStr != "" {
+			duration, err := parseDuration(durationStr)
+			if err != nil || duration < time.Minute {
+				respondEphemeral(s, i, "Invalid duration. Use a format like `1h`, `7d` (minimum 1 minute).")
+				return
+			}
+			item.DurationSecs = int(duration.Seconds())
+		}
+
+		if err := ch.bot.DB.SetShopItem(item); err != nil {
+			respondEphemeral(s, i, "Failed to save shop item.")
+			return
+		}
+		cfg, _ := ch.bot.DB.GetEconomyConfig(i.GuildID)
+		respondEmbed(s, i, successEmbed("Shop Updated", fmt.Sprintf("<@&%s> is for sale: %s", role.ID, shopItemSummary(cfg, item))))
+
+	case "remove":
+		removed, err := ch.bot.DB.DeleteShopItem(i.GuildID, role.ID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to remove shop item.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "That role isn't in the shop.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Shop Updated", fmt.Sprintf("<@&%s> has been removed from the shop", role.ID)))
+	}
+}
+
+// shopItemSummary describes an item's price, stock and duration
+func shopItemSummary(cfg *database.EconomyConfig, item *database.ShopItem) string {
+	parts := []string{formatCurrency(cfg, item.Price)}
+	if item.DurationSecs > 0 {
+		parts = append(parts, "lasts "+formatDuration(time.Duration(item.DurationSecs)*time.Second))
+	}
+	switch {
+	case item.Stock == 0:
+		parts = append(parts, "sold out")
+	case item.Stock > 0:
+		parts = append(parts, fmt.Sprintf("%d left", item.Stock))
+	}
+	return strings.Join(parts, " • ")
+}
+
+func (ch *CommandHandler) shopViewHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg := ch.economyConfig(s, i)
+	if cfg == nil {
+		return
+	}
+
+	items, err := ch.bot.DB.GetShopItems(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get the shop.")
+		return
+	}
+	if len(items) == 0 {
+		respondEphemeral(s, i, "The shop is empty.")
+		return
+	}
+
+	var lines []string
+	for _, item := range items {
+		line := fmt.Sprintf("<@&%s> — %s", item.RoleID, shopItemSummary(cfg, &item))
+		if item.Description != "" {
+			line += "\n" + item.Description
+		}
+		lines = append(lines, line)
+	}
+
+	account, _ := ch.bot.DB.GetEconomyAccount(i.GuildID, i.Member.User.ID)
+	embed := &discordgo.MessageEmbed{
+		Title:       "🛒 Role Shop",
+		Description: truncate(strings.Join(lines, "\n\n"), 4096),
+		Color:       0xF1C40F,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Use /buy to purchase a role"},
+	}
+	if account != nil {
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Your Balance", Value: formatCurrency(cfg, account.Balance)}}
+	}
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) buyHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg := ch.economyConfig(s, i)
+	if cfg == nil {
+		return
+	}
+
+	role := getRoleOption(i, "role")
+	item, err := ch.bot.DB.GetShopItemByRole(i.GuildID, role.ID)
+	if err != nil || item == nil {
+		respondEphemeral(s, i, "That role isn't for sale.")
+		return
+	}
+	if hasRole(i.Member.Roles, role.ID) {
+		respondEphemeral(s, i, "You already have that role.")
+		return
+	}
+
+	purchase, err := ch.bot.DB.BuyShopItem(item, i.Member.User.ID)
+	switch {
+	case errors.Is(err, database.ErrInsufficientFunds):
+		respondEphemeral(s, i, fmt.Sprintf("You can't afford that. It costs %s.", formatCurrency(cfg, item.Price)))
+		return
+	case errors.Is(err, database.ErrOutOfStock):
+		respondEphemeral(s, i, "That role is sold out.")
+		return
+	case err != nil:
+		respondEphemeral(s, i, "Failed to complete the purchase.")
+		return
+	}
+
+	if err := s.GuildMemberRoleAdd(i.GuildID, i.Member.User.ID, role.ID); err != nil {
+		ch.bot.DB.RefundShopPurchase(purchase)
+		respondEphemeral(s, i, "I couldn't give you that role, so you haven't been charged. My role may be too low.")
+		return
+	}
+
+	desc := fmt.Sprintf("You bought <@&%s> for %s", role.ID, formatCurrency(cfg, item.Price))
+	if purchase.ExpiresAt > 0 {
+		target := i.Member.User.ID + ":" + role.ID
+		ch.bot.DB.DeleteScheduledEventByTarget(i.GuildID, eventTempRole, target)
+		ch.bot.DB.AddScheduledEvent(i.GuildID, eventTempRole, target, purchase.ExpiresAt)
+		desc += fmt.Sprintf("\nIt expires <t:%d:R>", purchase.ExpiresAt/1000)
+	}
+	respondEmbed(s, i, successEmbed("Purchase Complete", desc))
+}
@@ -148,7 +148,7 @@ func (ch *CommandHandler) spamFilterStatusHandler(s *discordgo.Session, i *disco
 			{Name: "Max Emojis", Value: strconv.Itoa(config.MaxEmojis), Inline: true},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Use /spamfilter set to modify these settings",
+			Text: "Use /automod spamfilter set to modify these settings",
 		},
 	}
 
@@ -0,0 +1,275 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerStatsChannelCommands() {
+	templateOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "template",
+		Description: "Channel name with {count}, {members}, {bots}, {goal}, {remaining}, {percent}",
+		Required:    false,
+		MaxLength:   100,
+	}
+	goalOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "goal",
+		Description: "Member goal, for goal channels",
+		Required:    false,
+		MinValue:    floatPtr(1),
+	}
+
+	ch.Register(&Command{
+		Name:        "statschannels",
+		Description: "Voice channels that show live member counts",
+		Category:    "Settings",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Create a stats channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "type",
+						Description: "What the channel counts",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Members", Value: database.StatsChannelMembers},
+							{Name: "Humans", Value: database.StatsChannelHumans},
+							{Name: "Bots", Value: database.StatsChannelBots},
+							{Name: "Member goal", Value: database.StatsChannelGoal},
+						},
+					},
+					templateOption,
+					goalOption,
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "category",
+						Description:  "Category to put the channel in",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildCategory},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "edit",
+				Description: "Change a stats channel's name template or goal",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Stats channel to change",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+					},
+					templateOption,
+					goalOption,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop updating a stats channel and delete it",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Stats channel to remove",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "keep_channel",
+						Description: "Keep the channel itself, just stop updating it",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List stats channels",
+			},
+		},
+		Handler: ch.statsChannelsHandler,
+	})
+}
+
+func (ch *CommandHandler) statsChannelsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage stats channels.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "create":
+		ch.statsChannelCreate(s, i)
+	case "edit":
+		ch.statsChannelEdit(s, i)
+	case "remove":
+		ch.statsChannelRemove(s, i)
+	case "list":
+		ch.statsChannelList(s, i)
+	}
+}
+
+func (ch *CommandHandler) statsChannelCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	c := &database.StatsChannel{
+		GuildID:   i.GuildID,
+		Kind:      getStringOption(i, "type"),
+		Template:  strings.TrimSpace(getStringOption(i, "template")),
+		Goal:      int(getIntOption(i, "goal")),
+		CreatedBy: i.Member.User.ID,
+	}
+	if c.Kind == database.StatsChannelGoal && c.Goal == 0 {
+		respondEphemeral(s, i, "Goal channels need a `goal`.")
+		return
+	}
+
+	total, bots, ok := guildMemberCounts(s, i.GuildID)
+	if !ok {
+		respondEphemeral(s, i, "Couldn't count this server's members, please try again in a moment.")
+		return
+	}
+
+	data := discordgo.GuildChannelCreateData{
+		Name: statsChannelName(c, total, bots),
+		Type: discordgo.ChannelTypeGuildVoice,
+		// Nobody needs to join a stats channel, it's only there to be read
+		PermissionOverwrites: []*discordgo.PermissionOverwrite{
+			{ID: i.GuildID, Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionVoiceConnect},
+		},
+	}
+	if category := getChannelOption(i, "category"); category != nil {
+		data.ParentID = category.ID
+	}
+
+	channel, err := s.GuildChannelCreateComplex(i.GuildID, data)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to create the channel. Make sure I have the Manage Channels permission.")
+		return
+	}
+	c.ChannelID = channel.ID
+	if err := ch.bot.DB.SetStatsChannel(c); err != nil {
+		s.ChannelDelete(channel.ID)
+		respondEphemeral(s, i, "Failed to save the stats channel.")
+		return
+	}
+
+	embed := successEmbed("Stats Channel Created",
+		fmt.Sprintf("<#%s> will be kept up to date every %d minutes.", channel.ID, int(statsChannelInterval.Minutes())))
+	respondEmbed(s, i, embed)
+}
+
+func (ch *CommandHandler) statsChannelEdit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channel := getChannelOption(i, "channel")
+	c, err := ch.bot.DB.GetStatsChannel(i.GuildID, channel.ID)
+	if err != nil || c == nil {
+		respondEphemeral(s, i, fmt.Sprintf("<#%s> isn't a stats channel.", channel.ID))
+		return
+	}
+
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "template":
+			c.Template = strings.TrimSpace(opt.StringValue())
+		case "goal":
+			c.Goal = int(opt.IntValue())
+		}
+	}
+	if err := ch.bot.DB.SetStatsChannel(c); err != nil {
+		respondEphemeral(s, i, "Failed to update the stats channel.")
+		return
+	}
+
+	description := fmt.Sprintf("<#%s> has been updated.", c.ChannelID)
+	if err := ch.bot.renameStatsChannel(s, c); err != nil {
+		description = fmt.Sprintf("<#%s> has been updated. Discord limits how often channels can be renamed, so the new name will show within %d minutes.",
+			c.ChannelID, int(statsChannelInterval.Minutes()))
+	}
+	respondEmbed(s, i, successEmbed("Stats Channel Updated", description))
+}
+
+func (ch *CommandHandler) statsChannelRemove(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channel := getChannelOption(i, "channel")
+	c, err := ch.bot.DB.GetStatsChannel(i.GuildID, channel.ID)
+	if err != nil || c == nil {
+		respondEphemeral(s, i, fmt.Sprintf("<#%s> isn't a stats channel.", channel.ID))
+		return
+	}
+
+	if err := ch.bot.DB.DeleteStatsChannel(c.ChannelID); err != nil {
+		respondEphemeral(s, i, "Failed to remove the stats channel.")
+		return
+	}
+
+	if getBoolOption(i, "keep_channel") {
+		respondEmbed(s, i, successEmbed("Stats Channel Removed", fmt.Sprintf("<#%s> will no longer be updated.", c.ChannelID)))
+		return
+	}
+	if _, err := s.ChannelDelete(c.ChannelID); err != nil {
+		respondEmbed(s, i, successEmbed("Stats Channel Removed",
+			fmt.Sprintf("<#%s> will no longer be updated, but I couldn't delete it.", c.ChannelID)))
+		return
+	}
+	respondEmbed(s, i, successEmbed("Stats Channel Removed", "The stats channel has been deleted."))
+}
+
+func (ch *CommandHandler) statsChannelList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	channels, err := ch.bot.DB.GetStatsChannels(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get stats channels.")
+		return
+	}
+
+	if len(channels) == 0 {
+		respondEphemeral(s, i, "No stats channels configured.")
+		return
+	}
+
+	var description strings.Builder
+	for _, c := range channels {
+		template := c.Template
+		if template == "" {
+			template = statsChannelDefaults[c.Kind]
+		}
+		description.WriteString(fmt.Sprintf("<#%s>\n", c.ChannelID))
+		description.WriteString(fmt.Sprintf("├ Counts: %s\n", c.Kind))
+		if c.Kind == database.StatsChannelGoal {
+			description.WriteString(fmt.Sprintf("├ Goal: %d\n", c.Goal))
+		}
+		description.WriteString(fmt.Sprintf("└ Name: `%s`\n\n", template))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Stats Channels (%d)", len(channels)),
+		Description: truncate(description.String(), 4096),
+		Color:       0x5865F2,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Updated every %d minutes", int(statsChannelInterval.Minutes()))},
+	}
+	respondEmbed(s, i, embed)
+}
@@ -0,0 +1,175 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerStickyRolesCommands() {
+	// Sticky roles
+	ch.Register(&Command{
+		Name:        "stickyroles",
+		Description: "Restore roles and nicknames when members rejoin",
+		Category:    "Roles",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show sticky roles settings",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "enable",
+				Description: "Enable sticky roles",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Disable sticky roles",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "exclude",
+				Description: "Never restore a role",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to exclude",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "include",
+				Description: "Remove a role from the exclusion list",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to include again",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "nickname",
+				Description: "Choose whether nicknames are restored",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Restore nicknames on rejoin",
+						Required:    true,
+					},
+				},
+			},
+		},
+		Handler: ch.stickyRolesHandler,
+	})
+}
+
+func (ch *CommandHandler) stickyRolesHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure sticky roles.")
+		return
+	}
+
+	cfg, err := ch.bot.DB.GetStickyRolesConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get sticky roles config.")
+		return
+	}
+
+	var message string
+	switch getSubcommandName(i) {
+	case "status":
+		ch.stickyRolesStatus(s, i)
+		return
+	case "enable":
+		cfg.Enabled = true
+		count := ch.bot.snapshotGuildMembers(s, i.GuildID)
+		message = fmt.Sprintf("Sticky roles **enabled**. Saved roles for %d current members.", count)
+	case "disable":
+		cfg.Enabled = false
+		message = "Sticky roles **disabled**."
+	case "exclude":
+		role := getRoleOption(i, "role")
+		if !hasRole(cfg.ExcludedRoles, role.ID) {
+			cfg.ExcludedRoles = append(cfg.ExcludedRoles, role.ID)
+		}
+		message = fmt.Sprintf("<@&%s> will not be restored.", role.ID)
+	case "include":
+		role := getRoleOption(i, "role")
+		var kept []string
+		for _, roleID := range cfg.ExcludedRoles {
+			if roleID != role.ID {
+				kept = append(kept, roleID)
+			}
+		}
+		cfg.ExcludedRoles = kept
+		message = fmt.Sprintf("<@&%s> will be restored again.", role.ID)
+	case "nickname":
+		cfg.RestoreNickname = getBoolOption(i, "enabled")
+		message = "Nickname restore **disabled**."
+		if cfg.RestoreNickname {
+			message = "Nickname restore **enabled**."
+		}
+	}
+
+	if err := ch.bot.DB.SetStickyRolesConfig(cfg); err != nil {
+		respondEphemeral(s, i, "Failed to update sticky roles config.")
+		return
+	}
+	respondEmbed(s, i, successEmbed("Sticky Roles Updated", message))
+}
+
+func (ch *CommandHandler) stickyRolesStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg, err := ch.bot.DB.GetStickyRolesConfig(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get sticky roles config.")
+		return
+	}
+
+	statusEmoji := func(enabled bool) string {
+		if enabled {
+			return ":white_check_mark:"
+		}
+		return ":x:"
+	}
+
+	excluded := "None"
+	if len(cfg.ExcludedRoles) > 0 {
+		excluded = truncate(formatRoleMentions(cfg.ExcludedRoles), 1024)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Sticky Roles",
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Enabled", Value: statusEmoji(cfg.Enabled), Inline: true},
+			{Name: "Restore Nickname", Value: statusEmoji(cfg.RestoreNickname), Inline: true},
+			{Name: "Excluded Roles", Value: excluded, Inline: false},
+		},
+	}
+	respondEmbed(s, i, embed)
+}
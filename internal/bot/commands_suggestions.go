@@ -0,0 +1,275 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerSuggestionCommands() {
+	// Submit a suggestion
+	ch.Register(&Command{
+		Name:        "suggest",
+		Description: "Submit a suggestion for the server",
+		Category:    "Suggestions",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "suggestion",
+				Description: "Your suggestion",
+				Required:    true,
+				MaxLength:   2000,
+			},
+		},
+		Handler: ch.suggestHandler,
+	})
+
+	numberOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "number",
+		Description: "Suggestion number",
+		Required:    true,
+		MinValue:    floatPtr(1),
+	}
+	reasonOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "reason",
+		Description: "Response shown on the suggestion and sent to the author",
+		Required:    false,
+	}
+	statusSubcommand := func(name, description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        name,
+			Description: description,
+			Options:     []*discordgo.ApplicationCommandOption{numberOption, reasonOption},
+		}
+	}
+
+	// Manage suggestions
+	ch.Register(&Command{
+		Name:        "suggestion",
+		Description: "Configure and respond to suggestions",
+		Category:    "Suggestions",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Set the suggestions channel and enable suggestions",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to post suggestions in",
+						Required:    true,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+							discordgo.ChannelTypeGuildNews,
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "dm_author",
+						Description: "DM authors when their suggestion's status changes (default: true)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Disable suggestions",
+			},
+			statusSubcommand("approve", "Approve a suggestion"),
+			statusSubcommand("deny", "Deny a suggestion"),
+			statusSubcommand("implement", "Mark a suggestion as implemented"),
+			statusSubcommand("consider", "Mark a suggestion as under consideration"),
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List suggestions",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "status",
+						Description: "Only show suggestions with this status",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Pending", Value: "pending"},
+							{Name: "Under Consideration", Value: "considered"},
+							{Name: "Approved", Value: "approved"},
+							{Name: "Denied", Value: "denied"},
+							{Name: "Implemented", Value: "implemented"},
+						},
+					},
+				},
+			},
+		},
+		Handler: ch.suggestionHandler,
+	})
+
+	ch.RegisterComponent("suggest", ch.handleSuggestionVote)
+}
+
+func (ch *CommandHandler) suggestHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cfg, err := ch.bot.DB.GetSuggestionConfig(i.GuildID)
+	if err != nil || !cfg.Enabled || cfg.ChannelID == "" {
+		respondEphemeral(s, i, "Suggestions are not enabled on this server.")
+		return
+	}
+
+	content := strings.TrimSpace(getStringOption(i, "suggestion"))
+	if content == "" {
+		respondEphemeral(s, i, "Please write a suggestion.")
+		return
+	}
+
+	sg, err := ch.bot.DB.CreateSuggestion(i.GuildID, i.Member.User.ID, content)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to save your suggestion.")
+		return
+	}
+
+	embed, components := renderSuggestion(s, sg)
+	msg, err := s.ChannelMessageSendComplex(cfg.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		respondEphemeral(s, i, "Failed to post your suggestion. Please let the staff know.")
+		return
+	}
+	ch.bot.DB.SetSuggestionMessage(sg.ID, cfg.ChannelID, msg.ID)
+
+	respondEphemeral(s, i, fmt.Sprintf("Your suggestion **#%d** has been posted in <#%s>.", sg.Number, cfg.ChannelID))
+}
+
+// Subcommands that change a suggestion's status
+var suggestionActions = map[string]string{
+	"approve":   "approved",
+	"deny":      "denied",
+	"implement": "implemented",
+	"consider":  "considered",
+}
+
+func (ch *CommandHandler) suggestionHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage suggestions.")
+		return
+	}
+
+	subcommand := getSubcommandName(i)
+	if status, ok := suggestionActions[subcommand]; ok {
+		ch.suggestionStatusHandler(s, i, status)
+		return
+	}
+
+	switch subcommand {
+	case "setup":
+		cfg, err := ch.bot.DB.GetSuggestionConfig(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get suggestion config.")
+			return
+		}
+		cfg.ChannelID = getChannelOption(i, "channel").ID
+		cfg.Enabled = true
+		cfg.DMAuthor = true
+		for _, opt := range getOptions(i) {
+			if opt.Name == "dm_author" {
+				cfg.DMAuthor = opt.BoolValue()
+			}
+		}
+		if err := ch.bot.DB.SetSuggestionConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save suggestion config.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Suggestions Enabled",
+			fmt.Sprintf("Suggestions made with `/suggest` will be posted in <#%s>", cfg.ChannelID)))
+
+	case "disable":
+		cfg, err := ch.bot.DB.GetSuggestionConfig(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get suggestion config.")
+			return
+		}
+		cfg.Enabled = false
+		if err := ch.bot.DB.SetSuggestionConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save suggestion config.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("Suggestions Disabled", "`/suggest` has been disabled"))
+
+	case "list":
+		ch.suggestionListHandler(s, i)
+	}
+}
+
+func (ch *CommandHandler) suggestionStatusHandler(s *discordgo.Session, i *discordgo.InteractionCreate, status string) {
+	sg, err := ch.bot.DB.GetSuggestionByNumber(i.GuildID, int(getIntOption(i, "number")))
+	if err != nil || sg == nil {
+		respondEphemeral(s, i, "Suggestion not found.")
+		return
+	}
+
+	reason := getStringOption(i, "reason")
+	if err := ch.bot.DB.SetSuggestionStatus(sg.ID, status, i.Member.User.ID, reason); err != nil {
+		respondEphemeral(s, i, "Failed to update suggestion.")
+		return
+	}
+	sg.Status, sg.StaffID, sg.StaffReason = status, i.Member.User.ID, reason
+
+	ch.bot.updateSuggestionMessage(s, sg)
+	if cfg, err := ch.bot.DB.GetSuggestionConfig(i.GuildID); err == nil && cfg.DMAuthor {
+		ch.bot.notifySuggestionAuthor(s, sg)
+	}
+
+	respondEmbed(s, i, successEmbed("Suggestion Updated",
+		fmt.Sprintf("Suggestion **#%d** marked **%s**", sg.Number, suggestionStatuses[status].Label)))
+}
+
+func (ch *CommandHandler) suggestionListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	status := getStringOption(i, "status")
+	suggestions, err := ch.bot.DB.GetSuggestions(i.GuildID, status, 15)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get suggestions.")
+		return
+	}
+	if len(suggestions) == 0 {
+		respondEphemeral(s, i, "No suggestions found.")
+		return
+	}
+
+	var lines []string
+	for _, sg := range suggestions {
+		line := fmt.Sprintf("**#%d** [%s] 👍 %d 👎 %d — %s", sg.Number, suggestionStatuses[sg.Status].Label,
+			sg.Upvotes, sg.Downvotes, truncate(strings.ReplaceAll(sg.Content, "\n", " "), 80))
+		if sg.MessageID != "" {
+			line += fmt.Sprintf(" ([view](https://discord.com/channels/%s/%s/%s))", sg.GuildID, sg.ChannelID, sg.MessageID)
+		}
+		lines = append(lines, line)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Suggestions",
+		Description: truncate(strings.Join(lines, "\n"), 4096),
+		Color:       0x5865F2,
+	}
+	respondEmbed(s, i, embed)
+}
@@ -0,0 +1,258 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Limits on what /summarize reads
+const (
+	summarizeDefaultCount = 100
+	summarizeMaxCount     = 500
+	summarizeMaxSince     = 7 * 24 * time.Hour
+	summarizeMaxChars     = 24000 // Roughly 6000 tokens of transcript
+)
+
+const summarizeSystemPrompt = "You summarize Discord conversations. Reply with short bullet points covering the main topics, " +
+	"any decisions made and open questions, naming who said what where it matters. Keep it under 1500 characters."
+
+func (ch *CommandHandler) registerSummarizeCommands() {
+	ch.Register(&Command{
+		Name:        "summarize",
+		Description: "Summarize recent messages in this channel with AI",
+		Category:    "AI",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: fmt.Sprintf("Number of recent messages to read (default: %d)", summarizeDefaultCount),
+				Required:    false,
+				MinValue:    floatPtr(5),
+				MaxValue:    summarizeMaxCount,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "since",
+				Description: "Read messages from this long ago, e.g. 2h or 1d (up to 7d)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "post",
+				Description: "Post the summary in the channel instead of only showing it to you",
+				Required:    false,
+			},
+		},
+		Handler: ch.summarizeHandler,
+	})
+}
+
+func (ch *CommandHandler) summarizeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	settings, err := ch.bot.DB.GetAISettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI settings.")
+		return
+	}
+
+	if !canSummarize(s, i, settings) {
+		respondEphemeral(s, i, "You don't have permission to summarize channels.")
+		return
+	}
+
+	count := summarizeDefaultCount
+	if n := getIntOption(i, "count"); n > 0 {
+		count = int(n)
+	}
+	var since time.Time
+	if input := getStringOption(i, "since"); input != "" {
+		d, err := parseDuration(input)
+		if err != nil || d <= 0 {
+			respondEphemeral(s, i, "Invalid time. Use a format like `30m`, `2h` or `1d`.")
+			return
+		}
+		since = time.Now().Add(-min(d, summarizeMaxSince))
+		if getIntOption(i, "count") == 0 {
+			count = summarizeMaxCount
+		}
+	}
+
+	provider, model, err := ch.bot.aiProvider(settings)
+	if errors.Is(err, errAINotConfigured) {
+		respondEphemeral(s, i, "AI is not configured. Ask an admin to set a provider with `/ai provider`, or set an API key in the config.")
+		return
+	}
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get AI settings.")
+		return
+	}
+
+	if refusal := ch.bot.aiBudgetRefusal(settings, i.Member.User.ID); refusal != "" {
+		respondEphemeral(s, i, refusal)
+		return
+	}
+
+	// Budgets are checked before reading, the transcript's size is checked once it's known
+	usage, err := ch.bot.DB.GetAIUsage(i.GuildID, aiUsageDay(), database.AIFeatureSummarize)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to check AI usage.")
+		return
+	}
+	if settings.SummaryDailyLimit > 0 && usage.Requests >= settings.SummaryDailyLimit {
+		respondEphemeral(s, i, fmt.Sprintf("This server has used all %d summaries for today. The limit resets at midnight UTC.", settings.SummaryDailyLimit))
+		return
+	}
+	if settings.SummaryTokenBudget > 0 && usage.Tokens >= settings.SummaryTokenBudget {
+		respondEphemeral(s, i, "This server has used its AI token budget for today. The budget resets at midnight UTC.")
+		return
+	}
+
+	post := getBoolOption(i, "post")
+	if post {
+		respondDeferred(s, i)
+	} else {
+		respondDeferredEphemeral(s, i)
+	}
+
+	messages, err := fetchSummaryMessages(s, i.ChannelID, count, since)
+	if err != nil {
+		editResponse(s, i, "Failed to read messages: "+err.Error())
+		return
+	}
+	transcript := summaryTranscript(messages)
+	if transcript == "" {
+		editResponse(s, i, "There are no messages to summarize.")
+		return
+	}
+
+	promptTokens := estimateTokens(summarizeSystemPrompt + transcript)
+	if settings.SummaryTokenBudget > 0 && usage.Tokens+promptTokens > settings.SummaryTokenBudget {
+		editResponse(s, i, fmt.Sprintf("Summarizing this many messages would go over today's AI token budget (%d of %d used). Try fewer messages.",
+			usage.Tokens, settings.SummaryTokenBudget))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), aiTimeout)
+	defer cancel()
+
+	summary, err := provider.Complete(ctx, AIRequest{
+		Model:       model,
+		System:      summarizeSystemPrompt,
+		Prompt:      transcript,
+		MaxTokens:   800,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		editResponse(s, i, "AI Error: "+err.Error())
+		return
+	}
+
+	ch.bot.recordAIUsage(i.GuildID, i.Member.User.ID, database.AIFeatureSummarize, promptTokens+estimateTokens(summary))
+
+	from := messages[0].Timestamp
+	embed := &discordgo.MessageEmbed{
+		Title:       "Channel Summary",
+		Description: truncate(summary, 4096),
+		Color:       0x10A37F,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Messages", Value: fmt.Sprintf("%d", len(messages)), Inline: true},
+			{Name: "Since", Value: fmt.Sprintf("<t:%d:R>", from.Unix()), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Model: %s • %s • Requested by %s", model, provider.Name(), i.Member.User.Username),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	editResponseEmbed(s, i, embed)
+}
+
+// canSummarize reports whether the caller may use /summarize: moderators always
+// can, everyone else needs the guild's summary role
+func canSummarize(s *discordgo.Session, i *discordgo.InteractionCreate, settings *database.AISettings) bool {
+	if isModerator(s, i.GuildID, i.Member.User.ID) {
+		return true
+	}
+	if settings.SummaryRoleID == "" {
+		return false
+	}
+	return settings.SummaryRoleID == i.GuildID || slices.Contains(i.Member.Roles, settings.SummaryRoleID)
+}
+
+// fetchSummaryMessages returns up to count of a channel's latest messages sent
+// after since, oldest first, leaving out bots
+func fetchSummaryMessages(s *discordgo.Session, channelID string, count int, since time.Time) ([]*discordgo.Message, error) {
+	var messages []*discordgo.Message
+	before := ""
+	for len(messages) < count {
+		batch, err := s.ChannelMessages(channelID, 100, before, "", "")
+		if err != nil {
+			return nil, err
+		}
+		done := len(batch) < 100
+		for _, m := range batch {
+			if !since.IsZero() && m.Timestamp.Before(since) {
+				done = true
+				break
+			}
+			if m.Author == nil || m.Author.Bot || len(messages) >= count {
+				continue
+			}
+			messages = append(messages, m)
+		}
+		if done || len(batch) == 0 {
+			break
+		}
+		before = batch[len(batch)-1].ID
+	}
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+// summaryTranscript flattens messages into "[time] name: text" lines, dropping
+// the oldest lines when the transcript is too long to send
+func summaryTranscript(messages []*discordgo.Message) string {
+	var lines []string
+	for _, m := range messages {
+		text := strings.Join(transcriptContent(m), " ")
+		if text == "" {
+			continue
+		}
+		name := m.Author.Username
+		if m.Member != nil && m.Member.Nick != "" {
+			name = m.Member.Nick
+		} else if m.Author.GlobalName != "" {
+			name = m.Author.GlobalName
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s: %s", m.Timestamp.UTC().Format("Jan 2 15:04"), name, truncate(text, 500)))
+	}
+
+	total := 0
+	start := len(lines)
+	for start > 0 && total+len(lines[start-1])+1 <= summarizeMaxChars {
+		start--
+		total += len(lines[start]) + 1
+	}
+	return strings.Join(lines[start:], "\n")
+}
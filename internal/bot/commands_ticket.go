@@ -17,23 +17,109 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
 )
 
+// ticketMemberPerms is what the opener, added members and support staff get in a ticket channel
+const ticketMemberPerms = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages |
+	discordgo.PermissionReadMessageHistory | discordgo.PermissionAttachFiles | discordgo.PermissionEmbedLinks
+
+// ticketChannelDeleteDelay gives everyone a moment to read the close notice before the channel goes
+const ticketChannelDeleteDelay = 10 * time.Second
+
+// ticketOpening holds guildID:userID keys while a ticket is being created, so a double click can't open two
+var ticketOpening sync.Map
+
+var (
+	errTicketAlreadyOpen = errors.New("member already has an open ticket")
+	errTicketOpening     = errors.New("member is already opening a ticket")
+)
+
+// ticketRoute says where a new ticket is opened and which role is pulled into it
+type ticketRoute struct {
+	Mode          string
+	ChannelID     string
+	CategoryID    string
+	SupportRoleID string
+	PanelID       int64
+}
+
+func ticketRouteFromConfig(config *database.TicketConfig) ticketRoute {
+	return ticketRoute{
+		Mode:          config.Mode,
+		ChannelID:     config.ChannelID,
+		CategoryID:    config.CategoryID,
+		SupportRoleID: config.SupportRoleID,
+	}
+}
+
 func (ch *CommandHandler) registerTicketCommands() {
-	// Set ticket channel
+	// Configure the ticket system
 	ch.Register(&Command{
 		Name:        "setticket",
-		Description: "Set the channel where tickets will be forwarded",
+		Description: "Configure where tickets are opened",
 		Category:    "Ticket",
 		Options: []*discordgo.ApplicationCommandOption{
 			{
-				Type:        discordgo.ApplicationCommandOptionChannel,
-				Name:        "channel",
-				Description: "Channel to receive tickets",
-				Required:    true,
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "channel",
+				Description:  "Channel tickets are opened in as threads (or logged to in channel mode)",
+				Required:     true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "mode",
+				Description: "Open tickets as private threads or as channels",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Private thread", Value: database.TicketModeThread},
+					{Name: "Channel under a category", Value: database.TicketModeChannel},
+				},
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "category",
+				Description:  "Category ticket channels are created under (channel mode)",
+				Required:     false,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildCategory},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionRole,
+				Name:        "support_role",
+				Description: "Role added to every ticket",
+				Required:    false,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "transcript_channel",
+				Description:  "Channel transcripts of closed tickets are posted to",
+				Required:     false,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "inactive_hours",
+				Description: "Close tickets after this many hours without messages (0 = never)",
+				Required:    false,
+				MinValue:    floatPtr(0),
+				MaxValue:    720,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "warning_hours",
+				Description: "Hours between the inactivity warning and the close (default 24)",
+				Required:    false,
+				MinValue:    floatPtr(1),
+				MaxValue:    168,
 			},
 		},
 		Handler: ch.setTicketHandler,
@@ -55,21 +141,93 @@ func (ch *CommandHandler) registerTicketCommands() {
 		Handler:     ch.ticketStatusHandler,
 	})
 
-	// Submit a ticket
+	// Open and manage tickets
+	userOption := func(description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: description,
+			Required:    true,
+		}
+	}
+
 	ch.Register(&Command{
 		Name:        "ticket",
-		Description: "Submit a ticket/issue to the server staff",
+		Description: "Open and manage support tickets",
 		Category:    "Ticket",
 		Options: []*discordgo.ApplicationCommandOption{
 			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "issue",
-				Description: "Describe your issue or problem",
-				Required:    true,
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "open",
+				Description: "Open a private ticket with the server staff",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "subject",
+						Description: "What do you need help with?",
+						Required:    true,
+						MaxLength:   200,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "close",
+				Description: "Close this ticket",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "reason",
+						Description: "Why the ticket is being closed",
+						Required:    false,
+						MaxLength:   200,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Add a member to this ticket",
+				Options: []*discordgo.ApplicationCommandOption{
+					userOption("Member to add"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a member from this ticket",
+				Options: []*discordgo.ApplicationCommandOption{
+					userOption("Member to remove"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "claim",
+				Description: "Claim this ticket as the staff member handling it",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "unclaim",
+				Description: "Release your claim on this ticket",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "stats",
+				Description: "Show how many tickets each staff member has handled",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Only show this staff member",
+						Required:    false,
+					},
+				},
 			},
 		},
-		Handler: ch.ticketSubmitHandler,
+		Handler: ch.ticketHandler,
 	})
+
+	ch.RegisterComponent("ticket", ch.handleTicketComponent)
 }
 
 func (ch *CommandHandler) setTicketHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -84,14 +242,58 @@ func (ch *CommandHandler) setTicketHandler(s *discordgo.Session, i *discordgo.In
 		return
 	}
 
-	err := ch.bot.DB.SetTicketConfig(i.GuildID, channel.ID, true)
-	if err != nil {
+	config := &database.TicketConfig{
+		GuildID:   i.GuildID,
+		ChannelID: channel.ID,
+		Enabled:   true,
+		Mode:      getStringOption(i, "mode"),
+	}
+	// Auto-close settings carry over unless they're given again
+	if existing, _ := ch.bot.DB.GetTicketConfig(i.GuildID); existing != nil {
+		config.InactiveHours = existing.InactiveHours
+		config.InactiveGraceHours = existing.InactiveGraceHours
+	}
+	for _, opt := range getOptions(i) {
+		switch opt.Name {
+		case "inactive_hours":
+			config.InactiveHours = int(opt.IntValue())
+		case "warning_hours":
+			config.InactiveGraceHours = int(opt.IntValue())
+		}
+	}
+	if config.Mode == "" {
+		config.Mode = database.TicketModeThread
+	}
+	if category := getChannelOption(i, "category"); category != nil {
+		config.CategoryID = category.ID
+	}
+	if role := getRoleOption(i, "support_role"); role != nil {
+		config.SupportRoleID = role.ID
+	}
+	if transcripts := getChannelOption(i, "transcript_channel"); transcripts != nil {
+		config.TranscriptChannelID = transcripts.ID
+	}
+
+	if err := ch.bot.DB.SetTicketConfig(config); err != nil {
 		respondEphemeral(s, i, "Failed to set ticket channel.")
 		return
 	}
 
+	where := fmt.Sprintf("as private threads in <#%s>", channel.ID)
+	if config.Mode == database.TicketModeChannel {
+		where = "as new channels"
+		if config.CategoryID != "" {
+			where += fmt.Sprintf(" under <#%s>", config.CategoryID)
+		}
+		where += fmt.Sprintf(", with closed tickets logged to <#%s>", channel.ID)
+	}
+	if config.InactiveHours > 0 {
+		where += fmt.Sprintf(" Tickets with no messages for %dh are warned, then closed %dh later.",
+			config.InactiveHours, config.InactiveGraceHours)
+	}
+
 	embed := successEmbed("Ticket System Enabled",
-		fmt.Sprintf("Tickets will be forwarded to <#%s>\n\nUsers can now use `/ticket` to submit issues.", channel.ID))
+		fmt.Sprintf("Tickets will be opened %s.\n\nUsers can now use `/ticket open` to contact staff.", where))
 	respondEmbed(s, i, embed)
 }
 
@@ -124,60 +326,516 @@ func (ch *CommandHandler) ticketStatusHandler(s *discordgo.Session, i *discordgo
 		status = "Enabled"
 	}
 
+	mode := "Private threads"
+	if config.Mode == database.TicketModeChannel {
+		mode = "Channels"
+	}
+
+	category := "None"
+	if config.CategoryID != "" {
+		category = fmt.Sprintf("<#%s>", config.CategoryID)
+	}
+	supportRole := "None"
+	if config.SupportRoleID != "" {
+		supportRole = fmt.Sprintf("<@&%s>", config.SupportRoleID)
+	}
+	transcripts := "DM to opener only"
+	if config.TranscriptChannelID != "" {
+		transcripts = fmt.Sprintf("<#%s>", config.TranscriptChannelID)
+	}
+	autoClose := "Off"
+	if config.InactiveHours > 0 {
+		autoClose = fmt.Sprintf("After %dh inactive (+%dh warning)", config.InactiveHours, config.InactiveGraceHours)
+	}
+
+	open, _ := ch.bot.DB.CountOpenTickets(i.GuildID)
+
 	embed := &discordgo.MessageEmbed{
 		Title: "Ticket System Status",
 		Color: 0xFF69B4,
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "Status", Value: status, Inline: true},
+			{Name: "Mode", Value: mode, Inline: true},
 			{Name: "Channel", Value: fmt.Sprintf("<#%s>", config.ChannelID), Inline: true},
+			{Name: "Category", Value: category, Inline: true},
+			{Name: "Support Role", Value: supportRole, Inline: true},
+			{Name: "Transcripts", Value: transcripts, Inline: true},
+			{Name: "Auto-Close", Value: autoClose, Inline: true},
+			{Name: "Open Tickets", Value: fmt.Sprintf("%d", open), Inline: true},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Users can use /ticket to submit issues",
+			Text: "Users can use /ticket open to contact staff",
 		},
 	}
 
 	respondEmbed(s, i, embed)
 }
 
-func (ch *CommandHandler) ticketSubmitHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func (ch *CommandHandler) ticketHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "open":
+		ch.ticketOpenHandler(s, i)
+		return
+	case "stats":
+		ch.ticketStatsHandler(s, i)
+		return
+	}
+
+	ticket, err := ch.bot.DB.GetTicketByChannel(i.ChannelID)
+	if err != nil || ticket == nil || ticket.Status != database.TicketStatusOpen {
+		respondEphemeral(s, i, "This command can only be used inside an open ticket.")
+		return
+	}
+	config, _ := ch.bot.DB.GetTicketConfig(i.GuildID)
+	staff := ch.isTicketStaff(s, i.GuildID, i.Member, ticket, config)
+	opener := ticket.OpenerID == i.Member.User.ID
+
+	switch getSubcommandName(i) {
+	case "close":
+		if !staff && !opener {
+			respondEphemeral(s, i, "Only the ticket opener or staff can close this ticket.")
+			return
+		}
+		ch.closeTicket(s, i, ticket, config, getStringOption(i, "reason"))
+
+	case "add", "remove":
+		if !staff && !opener {
+			respondEphemeral(s, i, "Only the ticket opener or staff can change who is in this ticket.")
+			return
+		}
+		user := getUserOption(i, "user")
+		if user == nil {
+			respondEphemeral(s, i, "Please specify a member.")
+			return
+		}
+		if getSubcommandName(i) == "add" {
+			ch.ticketAddMember(s, i, ticket, user)
+		} else {
+			ch.ticketRemoveMember(s, i, ticket, user)
+		}
+
+	case "claim":
+		ch.claimTicket(s, i, ticket, staff)
+
+	case "unclaim":
+		ch.unclaimTicket(s, i, ticket)
+	}
+}
+
+// claimTicket makes the member the one staff owner of a ticket
+func (ch *CommandHandler) claimTicket(s *discordgo.Session, i *discordgo.InteractionCreate, ticket *database.Ticket, staff bool) {
+	if !staff {
+		respondEphemeral(s, i, "Only staff can claim tickets.")
+		return
+	}
+	if ticket.ClaimedBy == i.Member.User.ID {
+		respondEphemeral(s, i, "You've already claimed this ticket.")
+		return
+	}
+
+	claimed, err := ch.bot.DB.ClaimTicket(ticket.ID, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to claim ticket.")
+		return
+	}
+	if !claimed {
+		if current, _ := ch.bot.DB.GetTicketByChannel(ticket.ChannelID); current != nil && current.ClaimedBy != "" {
+			respondEphemeral(s, i, fmt.Sprintf("This ticket is already claimed by <@%s>.", current.ClaimedBy))
+		} else {
+			respondEphemeral(s, i, "This ticket is already claimed.")
+		}
+		return
+	}
+
+	respondEmbed(s, i, successEmbed("Ticket Claimed",
+		fmt.Sprintf("%s is now handling this ticket.", i.Member.User.Mention())))
+}
+
+// unclaimTicket releases a claim; moderators can release someone else's
+func (ch *CommandHandler) unclaimTicket(s *discordgo.Session, i *discordgo.InteractionCreate, ticket *database.Ticket) {
+	if ticket.ClaimedBy == "" {
+		respondEphemeral(s, i, "This ticket isn't claimed.")
+		return
+	}
+	if ticket.ClaimedBy != i.Member.User.ID && !isModerator(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, fmt.Sprintf("Only <@%s> or a moderator can release this claim.", ticket.ClaimedBy))
+		return
+	}
+
+	if err := ch.bot.DB.UnclaimTicket(ticket.ID); err != nil {
+		respondEphemeral(s, i, "Failed to unclaim ticket.")
+		return
+	}
+
+	respondEmbed(s, i, infoEmbed("Ticket Unclaimed",
+		fmt.Sprintf("%s released this ticket. Any staff member can claim it now.", i.Member.User.Mention())))
+}
+
+func (ch *CommandHandler) ticketStatsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i.GuildID, i.Member.User.ID) {
+		config, _ := ch.bot.DB.GetTicketConfig(i.GuildID)
+		if config == nil || config.SupportRoleID == "" || !hasRole(i.Member.Roles, config.SupportRoleID) {
+			respondEphemeral(s, i, "Only staff can view ticket statistics.")
+			return
+		}
+	}
+
+	stats, err := ch.bot.DB.GetTicketStaffStats(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get ticket statistics.")
+		return
+	}
+
+	if user := getUserOption(i, "user"); user != nil {
+		filtered := stats[:0]
+		for _, st := range stats {
+			if st.UserID == user.ID {
+				filtered = append(filtered, st)
+			}
+		}
+		stats = filtered
+	}
+	if len(stats) == 0 {
+		respondEphemeral(s, i, "No tickets have been claimed yet.")
+		return
+	}
+
+	var lines []string
+	for idx, st := range stats {
+		if idx == 20 {
+			break
+		}
+		line := fmt.Sprintf("**%d.** <@%s> — %d claimed · %d resolved", idx+1, st.UserID, st.Claimed, st.Resolved)
+		if open := st.Claimed - st.Resolved; open > 0 {
+			line += fmt.Sprintf(" · %d open", open)
+		}
+		if st.AvgResolveSeconds > 0 {
+			line += " · avg " + formatDuration(time.Duration(st.AvgResolveSeconds)*time.Second)
+		}
+		lines = append(lines, line)
+	}
+
+	open, _ := ch.bot.DB.CountOpenTickets(i.GuildID)
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "Ticket Staff Statistics",
+		Description: strings.Join(lines, "\n"),
+		Color:       0xFF69B4,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d ticket(s) currently open", open)},
+	})
+}
+
+func (ch *CommandHandler) ticketOpenHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	config, err := ch.bot.DB.GetTicketConfig(i.GuildID)
 	if err != nil || config == nil || !config.Enabled {
 		respondEphemeral(s, i, "The ticket system is not enabled on this server.")
 		return
 	}
 
-	issue := getStringOption(i, "issue")
-	if issue == "" {
+	subject := strings.TrimSpace(getStringOption(i, "subject"))
+	if subject == "" {
 		respondEphemeral(s, i, "Please describe your issue.")
 		return
 	}
 
-	// Create embed for the ticket channel
-	ticketEmbed := &discordgo.MessageEmbed{
-		Title: "New Ticket",
-		Color: 0xFF69B4,
+	if existing := ch.openTicketFor(s, i.GuildID, i.Member.User.ID); existing != nil {
+		respondEphemeral(s, i, fmt.Sprintf("You already have an open ticket: <#%s>", existing.ChannelID))
+		return
+	}
+
+	respondDeferredEphemeral(s, i)
+
+	ticket, err := ch.openTicket(s, i.GuildID, i.Member.User, subject, "", ticketRouteFromConfig(config))
+	if err != nil {
+		editResponse(s, i, ticketOpenError(i.GuildID, ticket, err))
+		return
+	}
+
+	editResponseEmbed(s, i, successEmbed("Ticket Opened",
+		fmt.Sprintf("Your ticket has been opened in <#%s>. Staff will be with you shortly.", ticket.ChannelID)))
+}
+
+// ticketOpenError explains why openTicket failed
+func ticketOpenError(guildID string, existing *database.Ticket, err error) string {
+	switch {
+	case errors.Is(err, errTicketAlreadyOpen):
+		return fmt.Sprintf("You already have an open ticket: <#%s>", existing.ChannelID)
+	case errors.Is(err, errTicketOpening):
+		return "Your ticket is already being opened."
+	}
+	log.Printf("Failed to open ticket in guild %s: %v", guildID, err)
+	return "Failed to open a ticket. Please ask staff to check my permissions."
+}
+
+// openTicketFor returns the member's open ticket, closing out any whose thread or channel was deleted by hand
+func (ch *CommandHandler) openTicketFor(s *discordgo.Session, guildID, userID string) *database.Ticket {
+	ticket, err := ch.bot.DB.GetOpenTicketByUser(guildID, userID)
+	if err != nil || ticket == nil {
+		return nil
+	}
+	if _, err := s.Channel(ticket.ChannelID); err != nil {
+		if restErr, ok := err.(*discordgo.RESTError); ok && restErr.Response != nil && restErr.Response.StatusCode == 404 {
+			ch.bot.DB.CloseTicket(ticket.ID, "")
+			return nil
+		}
+	}
+	return ticket
+}
+
+// openTicket creates the ticket row and its private thread or channel, then posts the opening message.
+// A member with an open ticket gets it back along with errTicketAlreadyOpen.
+func (ch *CommandHandler) openTicket(s *discordgo.Session, guildID string, user *discordgo.User, subject, details string, route ticketRoute) (*database.Ticket, error) {
+	key := guildID + ":" + user.ID
+	if _, busy := ticketOpening.LoadOrStore(key, struct{}{}); busy {
+		return nil, errTicketOpening
+	}
+	defer ticketOpening.Delete(key)
+
+	if existing := ch.openTicketFor(s, guildID, user.ID); existing != nil {
+		return existing, errTicketAlreadyOpen
+	}
+
+	ticket, err := ch.bot.DB.CreateTicket(&database.Ticket{
+		GuildID:       guildID,
+		OpenerID:      user.ID,
+		Subject:       subject,
+		SupportRoleID: route.SupportRoleID,
+		PanelID:       route.PanelID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("ticket-%04d", ticket.Number)
+	var channelID string
+
+	if route.Mode == database.TicketModeChannel {
+		overwrites := []*discordgo.PermissionOverwrite{
+			{ID: guildID, Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionViewChannel},
+			{ID: user.ID, Type: discordgo.PermissionOverwriteTypeMember, Allow: ticketMemberPerms},
+			{ID: s.State.User.ID, Type: discordgo.PermissionOverwriteTypeMember, Allow: ticketMemberPerms | discordgo.PermissionManageChannels},
+		}
+		if route.SupportRoleID != "" {
+			overwrites = append(overwrites, &discordgo.PermissionOverwrite{
+				ID: route.SupportRoleID, Type: discordgo.PermissionOverwriteTypeRole, Allow: ticketMemberPerms,
+			})
+		}
+		channel, err := s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
+			Name:                 name,
+			Type:                 discordgo.ChannelTypeGuildText,
+			Topic:                truncate(fmt.Sprintf("Ticket #%d opened by %s: %s", ticket.Number, user.Username, subject), 1024),
+			ParentID:             route.CategoryID,
+			PermissionOverwrites: overwrites,
+		})
+		if err != nil {
+			ch.bot.DB.DeleteTicket(ticket.ID)
+			return nil, err
+		}
+		channelID = channel.ID
+	} else {
+		thread, err := s.ThreadStartComplex(route.ChannelID, &discordgo.ThreadStart{
+			Name:                name,
+			AutoArchiveDuration: 10080,
+			Type:                discordgo.ChannelTypeGuildPrivateThread,
+			Invitable:           false,
+		})
+		if err != nil {
+			ch.bot.DB.DeleteTicket(ticket.ID)
+			return nil, err
+		}
+		channelID = thread.ID
+		if err := s.ThreadMemberAdd(thread.ID, user.ID); err != nil {
+			log.Printf("Failed to add %s to ticket thread %s: %v", user.ID, thread.ID, err)
+		}
+	}
+
+	if err := ch.bot.DB.SetTicketChannel(ticket.ID, channelID); err != nil {
+		return nil, err
+	}
+	ticket.ChannelID = channelID
+
+	// Mentioning the support role pulls its members into a private thread
+	content := user.Mention()
+	mentions := &discordgo.MessageAllowedMentions{Users: []string{user.ID}}
+	if route.SupportRoleID != "" {
+		content += fmt.Sprintf(" <@&%s>", route.SupportRoleID)
+		mentions.Roles = []string{route.SupportRoleID}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Ticket #%d", ticket.Number),
+		Description: "Staff will be with you shortly. Staff can claim the ticket below; use `/ticket close` or the close button once your issue is resolved.",
+		Color:       0xFF69B4,
 		Fields: []*discordgo.MessageEmbedField{
-			{Name: "From", Value: fmt.Sprintf("%s (%s)", i.Member.User.Username, i.Member.User.Mention()), Inline: true},
-			{Name: "User ID", Value: i.Member.User.ID, Inline: true},
-			{Name: "Issue", Value: issue, Inline: false},
+			{Name: "Opened By", Value: user.Mention(), Inline: true},
+			{Name: "Subject", Value: subject, Inline: false},
 		},
-		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: avatarURL(i.Member.User),
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: avatarURL(user)},
+		Timestamp: ticket.CreatedAt.Format(time.RFC3339),
+	}
+	if details != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Details", Value: truncate(details, 1024)})
+	}
+
+	_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         content,
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: mentions,
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Claim", Style: discordgo.SuccessButton, CustomID: "ticket:claim", Emoji: &discordgo.ComponentEmoji{Name: "🙋"}},
+				discordgo.Button{Label: "Unclaim", Style: discordgo.SecondaryButton, CustomID: "ticket:unclaim"},
+				discordgo.Button{Label: "Close Ticket", Style: discordgo.DangerButton, CustomID: "ticket:close", Emoji: &discordgo.ComponentEmoji{Name: "🔒"}},
+			}},
 		},
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Submitted from #%s", i.ChannelID),
+	})
+	if err != nil {
+		log.Printf("Failed to post ticket message in %s: %v", channelID, err)
+	}
+
+	return ticket, nil
+}
+
+// isTicketStaff reports whether a member can claim and manage a ticket: moderators, the server's
+// support role, and the role the ticket was routed to
+func (ch *CommandHandler) isTicketStaff(s *discordgo.Session, guildID string, member *discordgo.Member, ticket *database.Ticket, config *database.TicketConfig) bool {
+	if ticket.SupportRoleID != "" && hasRole(member.Roles, ticket.SupportRoleID) {
+		return true
+	}
+	if config != nil && config.SupportRoleID != "" && hasRole(member.Roles, config.SupportRoleID) {
+		return true
+	}
+	return isModerator(s, guildID, member.User.ID)
+}
+
+// closeTicket marks the ticket closed, then archives its thread or deletes its channel
+func (ch *CommandHandler) closeTicket(s *discordgo.Session, i *discordgo.InteractionCreate, ticket *database.Ticket, config *database.TicketConfig, reason string) {
+	if err := ch.bot.DB.CloseTicket(ticket.ID, i.Member.User.ID); err != nil {
+		respondEphemeral(s, i, "Failed to close ticket.")
+		return
+	}
+
+	embed := ticketClosedEmbed(ticket, i.Member.User.ID, reason)
+	respondEmbed(s, i, embed)
+
+	go ch.finishTicketClose(s, ticket, config, embed)
+}
+
+// ticketClosedEmbed is posted in the ticket and alongside its transcript
+func ticketClosedEmbed(ticket *database.Ticket, closedBy, reason string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Ticket #%d Closed", ticket.Number),
+		Color: 0xED4245,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Opened By", Value: fmt.Sprintf("<@%s>", ticket.OpenerID), Inline: true},
+			{Name: "Closed By", Value: fmt.Sprintf("<@%s>", closedBy), Inline: true},
+			{Name: "Subject", Value: ticket.Subject, Inline: false},
 		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if ticket.ClaimedBy != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Claimed By", Value: fmt.Sprintf("<@%s>", ticket.ClaimedBy), Inline: true})
+	}
+	if reason != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Reason", Value: reason, Inline: false})
+	}
+	return embed
+}
+
+// finishTicketClose saves the transcript, then archives the ticket thread or deletes its channel
+func (ch *CommandHandler) finishTicketClose(s *discordgo.Session, ticket *database.Ticket, config *database.TicketConfig, embed *discordgo.MessageEmbed) {
+	channel, err := s.State.Channel(ticket.ChannelID)
+	if err != nil {
+		channel, err = s.Channel(ticket.ChannelID)
+		if err != nil {
+			return
+		}
 	}
 
-	// Send to ticket channel
-	_, err = s.ChannelMessageSendEmbed(config.ChannelID, ticketEmbed)
+	ch.saveTicketTranscript(s, ticket, config, channel, embed)
+
+	if channel.IsThread() {
+		archived, locked := true, true
+		if _, err := s.ChannelEditComplex(channel.ID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked}); err != nil {
+			log.Printf("Failed to archive ticket thread %s: %v", channel.ID, err)
+		}
+		return
+	}
+
+	time.AfterFunc(ticketChannelDeleteDelay, func() {
+		if _, err := s.ChannelDelete(channel.ID); err != nil {
+			log.Printf("Failed to delete ticket channel %s: %v", channel.ID, err)
+		}
+	})
+}
+
+func (ch *CommandHandler) ticketAddMember(s *discordgo.Session, i *discordgo.InteractionCreate, ticket *database.Ticket, user *discordgo.User) {
+	var err error
+	if channel, cerr := s.State.Channel(ticket.ChannelID); cerr == nil && channel.IsThread() {
+		err = s.ThreadMemberAdd(ticket.ChannelID, user.ID)
+	} else {
+		err = s.ChannelPermissionSet(ticket.ChannelID, user.ID, discordgo.PermissionOverwriteTypeMember, ticketMemberPerms, 0)
+	}
 	if err != nil {
-		respondEphemeral(s, i, "Failed to submit ticket. Please try again later.")
+		respondEphemeral(s, i, "Failed to add that member to the ticket.")
+		return
+	}
+	respondEmbed(s, i, successEmbed("Member Added", fmt.Sprintf("%s has been added to this ticket.", user.Mention())))
+}
+
+func (ch *CommandHandler) ticketRemoveMember(s *discordgo.Session, i *discordgo.InteractionCreate, ticket *database.Ticket, user *discordgo.User) {
+	if user.ID == ticket.OpenerID {
+		respondEphemeral(s, i, "The ticket opener can't be removed. Close the ticket instead.")
 		return
 	}
 
-	// Respond to user (ephemeral so only they see it)
-	embed := successEmbed("Ticket Submitted",
-		"Your ticket has been submitted to the server staff. They will review it shortly.")
-	respondEmbedEphemeral(s, i, embed)
+	var err error
+	if channel, cerr := s.State.Channel(ticket.ChannelID); cerr == nil && channel.IsThread() {
+		err = s.ThreadMemberRemove(ticket.ChannelID, user.ID)
+	} else {
+		err = s.ChannelPermissionDelete(ticket.ChannelID, user.ID)
+	}
+	if err != nil {
+		respondEphemeral(s, i, "Failed to remove that member from the ticket.")
+		return
+	}
+	respondEmbed(s, i, successEmbed("Member Removed", fmt.Sprintf("%s has been removed from this ticket.", user.Mention())))
+}
+
+// handleTicketComponent handles the close button posted in every ticket
+func (ch *CommandHandler) handleTicketComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+
+	ticket, err := ch.bot.DB.GetTicketByChannel(i.ChannelID)
+	if err != nil || ticket == nil || ticket.Status != database.TicketStatusOpen {
+		respondEphemeral(s, i, "This ticket is already closed.")
+		return
+	}
+	config, _ := ch.bot.DB.GetTicketConfig(i.GuildID)
+	staff := ch.isTicketStaff(s, i.GuildID, i.Member, ticket, config)
+
+	switch i.MessageComponentData().CustomID {
+	case "ticket:claim":
+		ch.claimTicket(s, i, ticket, staff)
+	case "ticket:unclaim":
+		ch.unclaimTicket(s, i, ticket)
+	case "ticket:keepopen":
+		if ticket.OpenerID != i.Member.User.ID && !staff {
+			respondEphemeral(s, i, "Only the ticket opener or staff can keep this ticket open.")
+			return
+		}
+		ch.cancelTicketAutoClose(s, i, ticket)
+	case "ticket:close":
+		if ticket.OpenerID != i.Member.User.ID && !staff {
+			respondEphemeral(s, i, "Only the ticket opener or staff can close this ticket.")
+			return
+		}
+		ch.closeTicket(s, i, ticket, config, "")
+	}
 }
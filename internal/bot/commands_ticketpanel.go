@@ -0,0 +1,356 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerTicketPanelCommands() {
+	ch.Register(&Command{
+		Name:        "ticketpanel",
+		Description: "Post panels with an Open Ticket button",
+		Category:    "Ticket",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "create",
+				Description: "Post a new ticket panel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "title",
+						Description: "Panel title",
+						Required:    true,
+						MaxLength:   256,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to post in (defaults to this channel)",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "Text shown above the button",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "button_label",
+						Description: "Button text (defaults to Open Ticket)",
+						Required:    false,
+						MaxLength:   80,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "Open tickets as private threads in the panel channel or as channels",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Private thread", Value: database.TicketModeThread},
+							{Name: "Channel under a category", Value: database.TicketModeChannel},
+						},
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "category",
+						Description:  "Category ticket channels are created under (channel mode)",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildCategory},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "support_role",
+						Description: "Role pulled into tickets opened from this panel",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Delete a ticket panel and its message",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "panel",
+						Description: "Panel ID (see /tickets ticketpanel list)",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List ticket panels in this server",
+			},
+		},
+		Handler: ch.ticketPanelHandler,
+	})
+
+	ch.RegisterComponent("ticketpanel", ch.handleTicketPanelComponent)
+}
+
+func (ch *CommandHandler) ticketPanelHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage ticket panels.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "create":
+		ch.ticketPanelCreateHandler(s, i)
+	case "delete":
+		ch.ticketPanelDeleteHandler(s, i)
+	case "list":
+		ch.ticketPanelListHandler(s, i)
+	}
+}
+
+func (ch *CommandHandler) ticketPanelCreateHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	panel := &database.TicketPanel{
+		GuildID:     i.GuildID,
+		ChannelID:   i.ChannelID,
+		Title:       getStringOption(i, "title"),
+		Description: getStringOption(i, "description"),
+		ButtonLabel: getStringOption(i, "button_label"),
+		Mode:        getStringOption(i, "mode"),
+		CreatedBy:   i.Member.User.ID,
+	}
+	if channel := getChannelOption(i, "channel"); channel != nil {
+		panel.ChannelID = channel.ID
+	}
+	if category := getChannelOption(i, "category"); category != nil {
+		panel.CategoryID = category.ID
+	}
+	if role := getRoleOption(i, "support_role"); role != nil {
+		panel.SupportRoleID = role.ID
+	}
+	if panel.ButtonLabel == "" {
+		panel.ButtonLabel = "Open Ticket"
+	}
+	if panel.Mode == "" {
+		panel.Mode = database.TicketModeThread
+	}
+
+	id, err := ch.bot.DB.CreateTicketPanel(panel)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to create ticket panel.")
+		return
+	}
+	panel.ID = id
+
+	msg, err := s.ChannelMessageSendComplex(panel.ChannelID, ticketPanelMessage(panel))
+	if err != nil {
+		ch.bot.DB.DeleteTicketPanel(i.GuildID, id)
+		respondEphemeral(s, i, "Failed to post the panel. Check that I can send messages in that channel.")
+		return
+	}
+	ch.bot.DB.SetTicketPanelMessage(id, msg.ID)
+
+	respondEmbedEphemeral(s, i, successEmbed("Ticket Panel Posted",
+		fmt.Sprintf("Panel **#%d** posted. [Jump to panel](https://discord.com/channels/%s/%s/%s)", id, i.GuildID, panel.ChannelID, msg.ID)))
+}
+
+func (ch *CommandHandler) ticketPanelDeleteHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	panel, err := ch.bot.DB.GetTicketPanel(getIntOption(i, "panel"))
+	if err != nil || panel == nil || panel.GuildID != i.GuildID {
+		respondEphemeral(s, i, "Ticket panel not found. Use `/tickets ticketpanel list` to see panel IDs.")
+		return
+	}
+
+	if err := ch.bot.DB.DeleteTicketPanel(i.GuildID, panel.ID); err != nil {
+		respondEphemeral(s, i, "Failed to delete panel.")
+		return
+	}
+	if panel.MessageID != "" {
+		s.ChannelMessageDelete(panel.ChannelID, panel.MessageID)
+	}
+
+	respondEmbed(s, i, successEmbed("Ticket Panel Deleted", fmt.Sprintf("Panel **#%d** has been deleted", panel.ID)))
+}
+
+func (ch *CommandHandler) ticketPanelListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	panels, err := ch.bot.DB.GetTicketPanels(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get ticket panels.")
+		return
+	}
+	if len(panels) == 0 {
+		respondEphemeral(s, i, "No ticket panels yet. Create one with `/tickets ticketpanel create`.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Ticket Panels",
+		Color: 0xFF69B4,
+	}
+	for _, panel := range panels {
+		if len(embed.Fields) == 25 {
+			break
+		}
+		routing := fmt.Sprintf("Threads in <#%s>", panel.ChannelID)
+		if panel.Mode == database.TicketModeChannel {
+			routing = "Channels"
+			if panel.CategoryID != "" {
+				routing += fmt.Sprintf(" under <#%s>", panel.CategoryID)
+			}
+		}
+		if panel.SupportRoleID != "" {
+			routing += fmt.Sprintf(" • <@&%s>", panel.SupportRoleID)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: truncate(fmt.Sprintf("#%d • %s", panel.ID, panel.Title), 256),
+			Value: fmt.Sprintf("%s\n[Posted in <#%s>](https://discord.com/channels/%s/%s/%s)",
+				routing, panel.ChannelID, panel.GuildID, panel.ChannelID, panel.MessageID),
+		})
+	}
+
+	respondEmbed(s, i, embed)
+}
+
+// ticketPanelMessage builds the embed and button a panel is posted as
+func ticketPanelMessage(panel *database.TicketPanel) *discordgo.MessageSend {
+	description := panel.Description
+	if description == "" {
+		description = "Need help from staff? Click the button below to open a private ticket."
+	}
+
+	return &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       panel.Title,
+			Description: description,
+			Color:       0xFF69B4,
+		}},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    panel.ButtonLabel,
+					Style:    discordgo.PrimaryButton,
+					CustomID: fmt.Sprintf("ticketpanel:open:%d", panel.ID),
+					Emoji:    &discordgo.ComponentEmoji{Name: "🎫"},
+				},
+			}},
+		},
+	}
+}
+
+// handleTicketPanelComponent shows the ticket modal from a panel button and opens the ticket on submit
+func (ch *CommandHandler) handleTicketPanelComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+
+	var customID string
+	if i.Type == discordgo.InteractionModalSubmit {
+		customID = i.ModalSubmitData().CustomID
+	} else {
+		customID = i.MessageComponentData().CustomID
+	}
+
+	parts := strings.Split(customID, ":")
+	if len(parts) != 3 {
+		return
+	}
+	panelID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return
+	}
+
+	panel, err := ch.bot.DB.GetTicketPanel(panelID)
+	if err != nil || panel == nil || panel.GuildID != i.GuildID {
+		respondEphemeral(s, i, "This ticket panel no longer exists.")
+		return
+	}
+	config, _ := ch.bot.DB.GetTicketConfig(i.GuildID)
+	if config != nil && !config.Enabled {
+		respondEphemeral(s, i, "The ticket system is not enabled on this server.")
+		return
+	}
+	if existing := ch.openTicketFor(s, i.GuildID, i.Member.User.ID); existing != nil {
+		respondEphemeral(s, i, fmt.Sprintf("You already have an open ticket: <#%s>", existing.ChannelID))
+		return
+	}
+
+	switch parts[1] {
+	case "open":
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: fmt.Sprintf("ticketpanel:submit:%d", panel.ID),
+				Title:    truncate(panel.Title, 45),
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "subject",
+							Label:     "Subject",
+							Style:     discordgo.TextInputShort,
+							Required:  true,
+							MaxLength: 200,
+						},
+					}},
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "description",
+							Label:     "Description",
+							Style:     discordgo.TextInputParagraph,
+							Required:  false,
+							MaxLength: 1000,
+						},
+					}},
+				},
+			},
+		})
+
+	case "submit":
+		subject := strings.TrimSpace(getModalValue(i, "subject"))
+		if subject == "" {
+			respondEphemeral(s, i, "Please describe your issue.")
+			return
+		}
+
+		respondDeferredEphemeral(s, i)
+
+		route := ticketRoute{
+			Mode:          panel.Mode,
+			ChannelID:     panel.ChannelID,
+			CategoryID:    panel.CategoryID,
+			SupportRoleID: panel.SupportRoleID,
+			PanelID:       panel.ID,
+		}
+		// Panels without their own support role ping the server-wide one
+		if route.SupportRoleID == "" && config != nil {
+			route.SupportRoleID = config.SupportRoleID
+		}
+		ticket, err := ch.openTicket(s, i.GuildID, i.Member.User, subject, strings.TrimSpace(getModalValue(i, "description")), route)
+		if err != nil {
+			editResponse(s, i, ticketOpenError(i.GuildID, ticket, err))
+			return
+		}
+
+		editResponseEmbed(s, i, successEmbed("Ticket Opened",
+			fmt.Sprintf("Your ticket has been opened in <#%s>. Staff will be with you shortly.", ticket.ChannelID)))
+	}
+}
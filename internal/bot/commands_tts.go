@@ -0,0 +1,276 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerTTSCommands() {
+	ch.Register(&Command{
+		Name:        "tts",
+		Description: "Read text out loud in your voice channel",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "text",
+				Description: "What to say",
+				Required:    true,
+				MaxLength:   ttsMaxLength,
+			},
+		},
+		Handler: ch.ttsHandler,
+	})
+
+	ch.Register(&Command{
+		Name:        "ttsconfig",
+		Description: "Configure text-to-speech",
+		Category:    "Music",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "enable",
+				Description: "Allow /music tts in this server",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disable",
+				Description: "Turn off /music tts in this server",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "voice",
+				Description: "Choose the speech engine and voice",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "engine",
+						Description: "Speech engine",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "espeak-ng (local)", Value: database.TTSEngineEspeak},
+							{Name: "OpenAI", Value: database.TTSEngineOpenAI},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "voice",
+						Description: "espeak-ng voice like en-us or de, or an OpenAI voice like alloy or nova",
+						Required:    false,
+						MaxLength:   32,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show text-to-speech settings",
+			},
+		},
+		Handler: ch.ttsConfigHandler,
+	})
+}
+
+func (ch *CommandHandler) ttsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "This command can only be used in a server.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetTTSSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get text-to-speech settings.")
+		return
+	}
+	if !settings.Enabled {
+		respondEphemeral(s, i, "Text-to-speech is disabled in this server. An admin can turn it on with `/musicsettings ttsconfig enable`.")
+		return
+	}
+
+	text := strings.TrimSpace(getStringOption(i, "text"))
+	if text == "" {
+		respondEphemeral(s, i, "Give me something to say.")
+		return
+	}
+
+	channelID, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	if player.IsConnected() && player.ChannelID() != "" && player.ChannelID() != channelID {
+		respondEphemeral(s, i, "You need to be in my voice channel to use text-to-speech.")
+		return
+	}
+
+	// The OpenAI engine spends the same budget as the other AI commands
+	var aiSettings *database.AISettings
+	if settings.Engine == database.TTSEngineOpenAI {
+		aiSettings, err = ch.bot.DB.GetAISettings(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get AI settings.")
+			return
+		}
+		if refusal := ch.bot.aiBudgetRefusal(aiSettings, i.Member.User.ID); refusal != "" {
+			respondEphemeral(s, i, refusal)
+			return
+		}
+	}
+
+	track := &Track{
+		Title:       "🗣️ " + truncate(text, 80),
+		Requester:   i.Member.User.Username,
+		RequesterID: i.Member.User.ID,
+		IsLocal:     true,
+		IsSpeech:    true,
+		Source:      "Text-to-speech",
+	}
+	if reason, _ := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID).check(player, track); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	respondDeferred(s, i)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	path, err := ch.bot.synthesizeSpeech(ctx, i.GuildID, settings, text)
+	if errors.Is(err, errAINotConfigured) {
+		editResponse(s, i, "The OpenAI voice needs an API key: set one with `/ai provider` or in the config.")
+		return
+	}
+	if err != nil {
+		editResponse(s, i, "Failed to synthesize speech: "+err.Error())
+		return
+	}
+	track.URL = path
+	if aiSettings != nil {
+		ch.bot.recordAIUsage(i.GuildID, i.Member.User.ID, database.AIFeatureTTS, estimateTokens(text))
+	}
+
+	if !player.IsConnected() {
+		if err := player.Connect(s, channelID); err != nil {
+			editResponse(s, i, "Failed to join voice channel: "+err.Error())
+			return
+		}
+	}
+
+	// Clips aren't saved to the persistent queue, their files don't outlive a restart
+	player.AddTrack(track)
+
+	if !player.IsPlaying() {
+		if err := player.Play(); err != nil {
+			editResponse(s, i, "Failed to start playback: "+err.Error())
+			return
+		}
+		editResponseEmbed(s, i, &discordgo.MessageEmbed{
+			Title:       "Speaking",
+			Description: truncate(text, 4096),
+			Color:       0xFF69B4,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Requested by " + i.Member.User.Username},
+		})
+		return
+	}
+
+	editResponseEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "Speech Queued",
+		Description: truncate(text, 4096),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Position", Value: fmt.Sprintf("#%d", len(player.GetQueue())), Inline: true},
+		},
+	})
+}
+
+func (ch *CommandHandler) ttsConfigHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure text-to-speech.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetTTSSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get text-to-speech settings.")
+		return
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch getSubcommandName(i) {
+	case "enable":
+		settings.Enabled = true
+		embed = successEmbed("Text-to-Speech Enabled", "Members can now use `/music tts` in voice channels.")
+	case "disable":
+		settings.Enabled = false
+		embed = successEmbed("Text-to-Speech Disabled", "`/music tts` is turned off.")
+	case "voice":
+		engine := getStringOption(i, "engine")
+		voice := strings.ToLower(strings.TrimSpace(getStringOption(i, "voice")))
+		if voice != "" && !validTTSVoice(engine, voice) {
+			if engine == database.TTSEngineOpenAI {
+				respondEphemeral(s, i, "Unknown OpenAI voice. Choose from: "+strings.Join(openAIVoices, ", ")+".")
+			} else {
+				respondEphemeral(s, i, "That isn't an espeak-ng voice name. Try `en-us`, `en-gb`, `de` or `ja`.")
+			}
+			return
+		}
+		if engine == database.TTSEngineOpenAI && ch.bot.openAIKey(i.GuildID) == "" {
+			respondEphemeral(s, i, "The OpenAI voice needs an API key: set one with `/ai provider` or in the config.")
+			return
+		}
+		settings.Engine = engine
+		settings.Voice = voice
+		embed = successEmbed("Text-to-Speech Voice Set", fmt.Sprintf("`/music tts` now speaks with **%s** (%s).", ttsVoice(settings), ttsEngineName(settings.Engine)))
+	case "status":
+		status := "Disabled"
+		if settings.Enabled {
+			status = "Enabled"
+		}
+		respondEmbedEphemeral(s, i, &discordgo.MessageEmbed{
+			Title: "Text-to-Speech",
+			Color: 0xFF69B4,
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Status", Value: status, Inline: true},
+				{Name: "Engine", Value: ttsEngineName(settings.Engine), Inline: true},
+				{Name: "Voice", Value: ttsVoice(settings), Inline: true},
+			},
+		})
+		return
+	}
+
+	if err := ch.bot.DB.SetTTSSettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save text-to-speech settings.")
+		return
+	}
+	respondEmbed(s, i, embed)
+}
+
+func ttsEngineName(engine string) string {
+	if engine == database.TTSEngineOpenAI {
+		return "OpenAI"
+	}
+	return "espeak-ng"
+}
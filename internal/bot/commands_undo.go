@@ -0,0 +1,196 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// undoWindow is how long after an action /undo can still reverse it
+const undoWindow = time.Hour
+
+// undoActions maps an undoable action to the case recorded when it's reversed
+var undoActions = map[string]string{
+	"ban":     "unban",
+	"warn":    "unwarn",
+	"timeout": "untimeout",
+}
+
+func (ch *CommandHandler) registerUndoCommands() {
+	ch.Register(&Command{
+		Name:        "undo",
+		Description: "Reverse your last ban, warning or timeout",
+		Category:    "Administration",
+		Handler:     ch.undoHandler,
+	})
+
+	ch.RegisterComponent("undo", ch.handleUndoComponent)
+}
+
+func (ch *CommandHandler) undoHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isModerator(s, i.GuildID, i.Member.User.ID) && !hasPermission(s, i.GuildID, i.Member.User.ID, discordgo.PermissionModerateMembers) {
+		respondEphemeral(s, i, "You don't have permission to use moderation commands.")
+		return
+	}
+
+	since := time.Now().Add(-undoWindow).UnixMilli()
+	action, err := ch.bot.DB.GetLastUndoableModAction(i.GuildID, i.Member.User.ID, since)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to look up your recent actions.")
+		return
+	}
+	if action == nil {
+		respondEphemeral(s, i, fmt.Sprintf("You haven't banned, warned or timed out anyone in the last %d minutes.", int(undoWindow.Minutes())))
+		return
+	}
+
+	reason := "No reason"
+	if action.Reason != nil && *action.Reason != "" {
+		reason = *action.Reason
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title: "Undo " + strings.Title(action.Action) + "?",
+				Description: fmt.Sprintf("%s\n**Member:** <@%s>\n**Reason:** %s\n**When:** <t:%d:R>",
+					undoDescription(action.Action), action.TargetID, truncate(reason, 500), action.Timestamp/1000),
+				Color:  0xFEE75C,
+				Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Case #%d", action.ID)},
+			}},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Undo", Style: discordgo.DangerButton, CustomID: fmt.Sprintf("undo:confirm:%d", action.ID)},
+					discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "undo:cancel"},
+				}},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// undoDescription says what undoing an action will do
+func undoDescription(action string) string {
+	switch action {
+	case "ban":
+		return "This will unban the member."
+	case "warn":
+		return "This will remove the warning."
+	default:
+		return "This will lift the timeout."
+	}
+}
+
+// handleUndoComponent handles the confirmation buttons, custom ID "undo:<confirm:<case>|cancel>"
+func (ch *CommandHandler) handleUndoComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+
+	var embed *discordgo.MessageEmbed
+	switch {
+	case len(parts) == 3 && parts[1] == "confirm":
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return
+		}
+		embed = ch.undoModAction(s, i, id)
+	default:
+		embed = infoEmbed("Cancelled", "Nothing was changed.")
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// undoModAction reverses a case for the moderator who clicked Undo and records the reversal
+func (ch *CommandHandler) undoModAction(s *discordgo.Session, i *discordgo.InteractionCreate, id int64) *discordgo.MessageEmbed {
+	moderatorID := i.Member.User.ID
+	action, err := ch.bot.DB.GetModAction(i.GuildID, id)
+	switch {
+	case err != nil || action == nil:
+		return errorEmbed("Undo Failed", "That case no longer exists.")
+	case action.ModeratorID != moderatorID:
+		return errorEmbed("Undo Failed", "You can only undo your own actions.")
+	case action.UndoneBy != "":
+		return errorEmbed("Undo Failed", "That action has already been undone.")
+	case time.Since(time.UnixMilli(action.Timestamp)) > undoWindow:
+		return errorEmbed("Undo Failed", fmt.Sprintf("Actions can only be undone within %d minutes.", int(undoWindow.Minutes())))
+	}
+
+	var result string
+	switch action.Action {
+	case "ban":
+		if !hasPermission(s, i.GuildID, moderatorID, discordgo.PermissionBanMembers) {
+			return errorEmbed("Undo Failed", "You no longer have permission to unban members.")
+		}
+		if err := s.GuildBanDelete(i.GuildID, action.TargetID); err != nil {
+			return errorEmbed("Undo Failed", "Failed to unban: "+err.Error())
+		}
+		result = fmt.Sprintf("<@%s> has been unbanned.", action.TargetID)
+	case "warn":
+		if action.WarningID == 0 {
+			return errorEmbed("Undo Failed", "That case isn't linked to a warning.")
+		}
+		warning, err := ch.bot.DB.GetWarning(i.GuildID, action.WarningID)
+		if err != nil {
+			return errorEmbed("Undo Failed", "Failed to look up the warning.")
+		}
+		if warning == nil {
+			return errorEmbed("Undo Failed", "That warning has already been cleared.")
+		}
+		if err := ch.bot.DB.RemoveWarning(warning, moderatorID); err != nil {
+			return errorEmbed("Undo Failed", "Failed to remove the warning.")
+		}
+		result = fmt.Sprintf("Warning #%d for <@%s> has been removed.", warning.ID, action.TargetID)
+	case "timeout":
+		if !hasPermission(s, i.GuildID, moderatorID, discordgo.PermissionModerateMembers) {
+			return errorEmbed("Undo Failed", "You no longer have permission to remove timeouts.")
+		}
+		member, err := s.GuildMember(i.GuildID, action.TargetID)
+		if err != nil {
+			return errorEmbed("Undo Failed", "That member is no longer in the server.")
+		}
+		if member.CommunicationDisabledUntil == nil || member.CommunicationDisabledUntil.Before(time.Now()) {
+			return errorEmbed("Undo Failed", "That timeout has already ended.")
+		}
+		if err := s.GuildMemberTimeout(i.GuildID, action.TargetID, nil); err != nil {
+			return errorEmbed("Undo Failed", "Failed to remove timeout: "+err.Error())
+		}
+		result = fmt.Sprintf("The timeout on <@%s> has been lifted.", action.TargetID)
+	default:
+		return errorEmbed("Undo Failed", "That action can't be undone.")
+	}
+
+	if marked, err := ch.bot.DB.MarkModActionUndone(action.ID, moderatorID); err == nil && marked {
+		ch.bot.recordModCase(i.GuildID, moderatorID, action.TargetID, undoActions[action.Action],
+			fmt.Sprintf("Undo of case #%d", action.ID), "")
+	}
+	return successEmbed("Action Undone", result)
+}
@@ -18,7 +18,9 @@ package bot
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/blubskye/himiko/internal/updater"
@@ -37,16 +39,18 @@ func (ch *CommandHandler) registerUpdateCommands() {
 	})
 }
 
+const updateUsage = "update <check|apply|rollback [db]|channel [stable|beta]|version>"
+
 // updatePrefixHandler handles prefix-based update commands
 func (ch *CommandHandler) updatePrefixHandler(ctx *PrefixContext) {
 	// Owner only
-	if !ch.bot.Config.IsOwner(ctx.Author.ID) {
+	if !ch.bot.Config().IsOwner(ctx.Author.ID) {
 		ctx.Reply("This command is only available to bot owners.")
 		return
 	}
 
 	if len(ctx.Args) == 0 {
-		ctx.Reply("Usage: `" + ctx.Prefix + "update <check|apply|version>`")
+		ctx.Reply("Usage: `" + ctx.Prefix + updateUsage + "`")
 		return
 	}
 
@@ -57,64 +61,37 @@ func (ch *CommandHandler) updatePrefixHandler(ctx *PrefixContext) {
 		ch.updateCheckPrefix(ctx)
 	case "apply":
 		ch.updateApplyPrefix(ctx)
+	case "rollback":
+		ch.updateRollbackPrefix(ctx)
+	case "channel":
+		ch.updateChannelPrefix(ctx)
 	case "version":
 		ch.updateVersionPrefix(ctx)
 	default:
-		ctx.Reply("Unknown subcommand. Usage: `" + ctx.Prefix + "update <check|apply|version>`")
+		ctx.Reply("Unknown subcommand. Usage: `" + ctx.Prefix + updateUsage + "`")
 	}
 }
 
 func (ch *CommandHandler) updateCheckPrefix(ctx *PrefixContext) {
-	info, err := updater.CheckForUpdateByPattern()
+	info, err := updater.CheckForUpdate(ch.bot.updateChannel())
 	if err != nil {
 		ctx.Reply("Failed to check for updates: " + err.Error())
 		return
 	}
 
-	if !info.Available {
-		embed := &discordgo.MessageEmbed{
-			Title:       "No Updates Available",
-			Description: fmt.Sprintf("You are running the latest version (**v%s**).", info.CurrentVersion),
-			Color:       0x57F287,
-		}
-		ctx.ReplyEmbed(embed)
-		return
-	}
-
-	embed := &discordgo.MessageEmbed{
-		Title:       "Update Available!",
-		Description: fmt.Sprintf("A new version is available: **v%s** (current: v%s)", info.NewVersion, info.CurrentVersion),
-		Color:       0x5865F2,
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:  "Download Size",
-				Value: formatBytes(info.Size),
-			},
-		},
-	}
-
-	if info.ReleaseNotes != "" {
-		notes := info.ReleaseNotes
-		if len(notes) > 1000 {
-			notes = notes[:1000] + "..."
-		}
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:  "Release Notes",
-			Value: notes,
-		})
-	}
-
-	embed.Footer = &discordgo.MessageEmbedFooter{
-		Text: "Use " + ctx.Prefix + "update apply to download and install",
-	}
-
-	ctx.ReplyEmbed(embed)
+	ctx.ReplyEmbed(updateInfoEmbed(info, ctx.Prefix))
 }
 
 func (ch *CommandHandler) updateApplyPrefix(ctx *PrefixContext) {
+	if !ch.bot.updateMu.TryLock() {
+		ctx.Reply("An update is already in progress.")
+		return
+	}
+	defer ch.bot.updateMu.Unlock()
+
 	ctx.Reply("Checking for updates...")
 
-	info, err := updater.CheckForUpdateByPattern()
+	info, err := updater.CheckForUpdate(ch.bot.updateChannel())
 	if err != nil {
 		ctx.Reply("Failed to check for updates: " + err.Error())
 		return
@@ -127,69 +104,105 @@ func (ch *CommandHandler) updateApplyPrefix(ctx *PrefixContext) {
 
 	ctx.Reply(fmt.Sprintf("Downloading update v%s (%s)...", info.NewVersion, formatBytes(info.Size)))
 
-	zipPath, err := updater.DownloadUpdate(info, nil)
+	zipPath, err := ch.bot.downloadVerifiedUpdate(info, nil)
 	if err != nil {
 		ctx.Reply("Failed to download update: " + err.Error())
 		return
 	}
 
-	ctx.Reply("Download complete. Applying update...")
+	ctx.Reply("Download verified. Applying update...")
 
-	if err := updater.ApplyUpdate(zipPath); err != nil {
+	if err := ch.bot.installUpdate(info, zipPath); err != nil {
 		ctx.Reply("Failed to apply update: " + err.Error())
 		return
 	}
 
-	embed := &discordgo.MessageEmbed{
-		Title:       "Update Applied Successfully!",
-		Description: fmt.Sprintf("Updated from v%s to v%s\n\n**Relaunching bot with new version...**", info.CurrentVersion, info.NewVersion),
-		Color:       0x57F287,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Bot is relaunching automatically",
-		},
+	ctx.ReplyEmbed(updateAppliedEmbed(info))
+
+	// Give Discord a moment to receive the response
+	time.Sleep(2 * time.Second)
+
+	ch.bot.restartForUpdate()
+}
+
+func (ch *CommandHandler) updateRollbackPrefix(ctx *PrefixContext) {
+	if !ch.bot.updateMu.TryLock() {
+		ctx.Reply("An update is already in progress.")
+		return
+	}
+	defer ch.bot.updateMu.Unlock()
+
+	state, err := updater.LoadState()
+	if err != nil {
+		ctx.Reply("Failed to read update state: " + err.Error())
+		return
+	}
+	if state == nil || !updater.CanRollback() {
+		ctx.Reply("There's no previous version to roll back to.")
+		return
 	}
 
-	ctx.ReplyEmbed(embed)
+	restoreDB := strings.EqualFold(ctx.GetArg(1), "db")
+	if restoreDB && state.DBBackup == "" {
+		ctx.Reply("No database backup was taken for the last update. Run `" + ctx.Prefix + "update rollback` to restore only the binary.")
+		return
+	}
+
+	desc := fmt.Sprintf("Rolling back from v%s to **v%s**.", updater.GetCurrentVersion(), state.PreviousVersion)
+	if restoreDB {
+		desc += fmt.Sprintf("\nThe database will be restored to its state from <t:%d:f>; changes since then are lost.", state.AppliedAt.Unix())
+	} else {
+		desc += fmt.Sprintf("\nThe database is kept as is. Use `%supdate rollback db` to also restore the pre-update backup.", ctx.Prefix)
+	}
+	ctx.ReplyEmbed(&discordgo.MessageEmbed{
+		Title:       "Rolling Back Update",
+		Description: desc + "\n\n**Relaunching bot with the previous version...**",
+		Color:       0xFEE75C,
+	})
 
 	// Give Discord a moment to receive the response
 	time.Sleep(2 * time.Second)
 
-	// Relaunch the bot with the new executable
-	if err := updater.RelaunchAfterUpdate(); err != nil {
-		fmt.Printf("[Update] Failed to relaunch: %v\n", err)
-		ctx.Reply(fmt.Sprintf("Failed to auto-relaunch: %v\nPlease restart the bot manually.", err))
-	}
+	ch.bot.rollbackUpdate(state, restoreDB)
 }
 
-func (ch *CommandHandler) updateVersionPrefix(ctx *PrefixContext) {
-	embed := &discordgo.MessageEmbed{
-		Title: "Himiko Version Info",
-		Color: 0xFF69B4,
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:   "Current Version",
-				Value:  "v" + updater.GetCurrentVersion(),
-				Inline: true,
-			},
-			{
-				Name:   "Auto-Update Check",
-				Value:  boolToEnabled(ch.bot.Config.Features.AutoUpdate),
-				Inline: true,
-			},
-			{
-				Name:   "Auto-Apply Updates",
-				Value:  boolToEnabled(ch.bot.Config.Features.AutoUpdateApply),
-				Inline: true,
-			},
-		},
+func (ch *CommandHandler) updateChannelPrefix(ctx *PrefixContext) {
+	channel := strings.ToLower(ctx.GetArg(1))
+	if channel == "" {
+		ctx.Reply(fmt.Sprintf("Updates are coming from the **%s** channel. Use `%supdate channel <stable|beta>` to switch.", ch.bot.updateChannel(), ctx.Prefix))
+		return
+	}
+	if !updater.ValidChannel(channel) {
+		ctx.Reply("Unknown channel. Choose `stable` or `beta`.")
+		return
+	}
+
+	cfg := ch.bot.Config().Clone()
+	cfg.Features.UpdateChannel = channel
+	if err := ch.bot.saveConfig(cfg); err != nil {
+		ctx.Reply("Failed to save config: " + err.Error())
+		return
+	}
+
+	// Let the next check announce whatever the new channel offers
+	ch.bot.updateMu.Lock()
+	ch.bot.updateNotified = ""
+	ch.bot.updateMu.Unlock()
+
+	desc := "Only full releases will be installed."
+	if channel == updater.ChannelBeta {
+		desc = "Pre-releases will be offered as well as full releases. Beta builds may be unstable."
 	}
+	ctx.ReplyEmbed(successEmbed("Update Channel Set", fmt.Sprintf("Now following the **%s** channel.\n%s", channel, desc)))
+}
 
-	ctx.ReplyEmbed(embed)
+func (ch *CommandHandler) updateVersionPrefix(ctx *PrefixContext) {
+	ctx.ReplyEmbed(ch.updateVersionEmbed())
 }
 
 func (ch *CommandHandler) updateHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	// Owner only
-	if i.Member == nil || !ch.bot.Config.IsOwner(i.Member.User.ID) {
+	if i.Member == nil || !ch.bot.Config().IsOwner(i.Member.User.ID) {
 		respondEphemeral(s, i, "This command is only available to bot owners.")
 		return
 	}
@@ -211,57 +224,26 @@ func (ch *CommandHandler) updateHandler(s *discordgo.Session, i *discordgo.Inter
 func (ch *CommandHandler) updateCheckHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	respondDeferred(s, i)
 
-	info, err := updater.CheckForUpdateByPattern()
+	info, err := updater.CheckForUpdate(ch.bot.updateChannel())
 	if err != nil {
 		editResponse(s, i, "Failed to check for updates: "+err.Error())
 		return
 	}
 
-	if !info.Available {
-		embed := &discordgo.MessageEmbed{
-			Title:       "No Updates Available",
-			Description: fmt.Sprintf("You are running the latest version (**v%s**).", info.CurrentVersion),
-			Color:       0x57F287,
-		}
-		editResponseEmbed(s, i, embed)
-		return
-	}
-
-	embed := &discordgo.MessageEmbed{
-		Title:       "Update Available!",
-		Description: fmt.Sprintf("A new version is available: **v%s** (current: v%s)", info.NewVersion, info.CurrentVersion),
-		Color:       0x5865F2,
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:  "Download Size",
-				Value: formatBytes(info.Size),
-			},
-		},
-	}
-
-	if info.ReleaseNotes != "" {
-		notes := info.ReleaseNotes
-		if len(notes) > 1000 {
-			notes = notes[:1000] + "..."
-		}
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:  "Release Notes",
-			Value: notes,
-		})
-	}
-
-	embed.Footer = &discordgo.MessageEmbedFooter{
-		Text: "Use /update apply to download and install",
-	}
-
-	editResponseEmbed(s, i, embed)
+	editResponseEmbed(s, i, updateInfoEmbed(info, "/"))
 }
 
 func (ch *CommandHandler) updateApplyHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !ch.bot.updateMu.TryLock() {
+		respondEphemeral(s, i, "An update is already in progress.")
+		return
+	}
+	defer ch.bot.updateMu.Unlock()
+
 	respondDeferred(s, i)
 
 	// Check for update
-	info, err := updater.CheckForUpdateByPattern()
+	info, err := updater.CheckForUpdate(ch.bot.updateChannel())
 	if err != nil {
 		editResponse(s, i, "Failed to check for updates: "+err.Error())
 		return
@@ -277,7 +259,7 @@ func (ch *CommandHandler) updateApplyHandler(s *discordgo.Session, i *discordgo.
 
 	// Download update
 	var lastUpdate time.Time
-	zipPath, err := updater.DownloadUpdate(info, func(downloaded, total int64) {
+	zipPath, err := ch.bot.downloadVerifiedUpdate(info, func(downloaded, total int64) {
 		// Update progress every 2 seconds
 		if time.Since(lastUpdate) > 2*time.Second {
 			lastUpdate = time.Now()
@@ -293,38 +275,38 @@ func (ch *CommandHandler) updateApplyHandler(s *discordgo.Session, i *discordgo.
 		return
 	}
 
-	editResponse(s, i, "Download complete. Applying update...")
+	editResponse(s, i, "Download verified. Applying update...")
 
 	// Apply update
-	if err := updater.ApplyUpdate(zipPath); err != nil {
+	if err := ch.bot.installUpdate(info, zipPath); err != nil {
 		editResponse(s, i, "Failed to apply update: "+err.Error())
 		return
 	}
 
-	embed := &discordgo.MessageEmbed{
-		Title:       "Update Applied Successfully!",
-		Description: fmt.Sprintf("Updated from v%s to v%s\n\n**Relaunching bot with new version...**", info.CurrentVersion, info.NewVersion),
-		Color:       0x57F287,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Bot is relaunching automatically",
-		},
-	}
-
-	editResponseEmbed(s, i, embed)
+	editResponseEmbed(s, i, updateAppliedEmbed(info))
 
 	// Give Discord a moment to receive the response
 	time.Sleep(2 * time.Second)
 
-	// Relaunch the bot with the new executable
-	if err := updater.RelaunchAfterUpdate(); err != nil {
-		// If relaunch fails, log it and notify the user
-		fmt.Printf("[Update] Failed to relaunch: %v\n", err)
-		followUp(s, i, fmt.Sprintf("Failed to auto-relaunch: %v\nPlease restart the bot manually.", err))
-	}
+	ch.bot.restartForUpdate()
 }
 
 func (ch *CommandHandler) updateVersionHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	embed := &discordgo.MessageEmbed{
+	respondEmbed(s, i, ch.updateVersionEmbed())
+}
+
+func (ch *CommandHandler) updateVersionEmbed() *discordgo.MessageEmbed {
+	verification := "Checksums"
+	if ch.bot.Config().Features.UpdatePublicKey != "" {
+		verification = "Checksums + signature"
+	}
+
+	rollback := "Not available"
+	if state, _ := updater.LoadState(); state != nil && updater.CanRollback() {
+		rollback = "To v" + state.PreviousVersion
+	}
+
+	return &discordgo.MessageEmbed{
 		Title: "Himiko Version Info",
 		Color: 0xFF69B4,
 		Fields: []*discordgo.MessageEmbedField{
@@ -333,20 +315,103 @@ func (ch *CommandHandler) updateVersionHandler(s *discordgo.Session, i *discordg
 				Value:  "v" + updater.GetCurrentVersion(),
 				Inline: true,
 			},
+			{
+				Name:   "Release Channel",
+				Value:  ch.bot.updateChannel(),
+				Inline: true,
+			},
+			{
+				Name:   "Verification",
+				Value:  verification,
+				Inline: true,
+			},
+			{
+				Name:   "Rollback",
+				Value:  rollback,
+				Inline: true,
+			},
 			{
 				Name:   "Auto-Update Check",
-				Value:  boolToEnabled(ch.bot.Config.Features.AutoUpdate),
+				Value:  boolToEnabled(ch.bot.Config().Features.AutoUpdate),
 				Inline: true,
 			},
 			{
 				Name:   "Auto-Apply Updates",
-				Value:  boolToEnabled(ch.bot.Config.Features.AutoUpdateApply),
+				Value:  boolToEnabled(ch.bot.Config().Features.AutoUpdateApply),
+				Inline: true,
+			},
+		},
+	}
+}
+
+// updateInfoEmbed describes the result of an update check
+func updateInfoEmbed(info *updater.UpdateInfo, prefix string) *discordgo.MessageEmbed {
+	if !info.Available {
+		return &discordgo.MessageEmbed{
+			Title:       "No Updates Available",
+			Description: fmt.Sprintf("You are running the latest version on the %s channel (**v%s**).", info.Channel, info.CurrentVersion),
+			Color:       0x57F287,
+		}
+	}
+
+	title := "Update Available!"
+	if info.Prerelease {
+		title = "Beta Update Available!"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		URL:         info.ReleaseURL,
+		Description: fmt.Sprintf("A new version is available: **v%s** (current: v%s)", info.NewVersion, info.CurrentVersion),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Download Size",
+				Value:  formatBytes(info.Size),
+				Inline: true,
+			},
+			{
+				Name:   "Channel",
+				Value:  info.Channel,
 				Inline: true,
 			},
 		},
 	}
 
-	respondEmbed(s, i, embed)
+	if info.ChecksumURL == "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Warning",
+			Value: "This release has no checksums file and can't be installed automatically.",
+		})
+	}
+
+	if info.ReleaseNotes != "" {
+		notes := info.ReleaseNotes
+		if len(notes) > 1000 {
+			notes = notes[:1000] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Release Notes",
+			Value: notes,
+		})
+	}
+
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: "Use " + prefix + "update apply to download and install",
+	}
+
+	return embed
+}
+
+func updateAppliedEmbed(info *updater.UpdateInfo) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "Update Applied Successfully!",
+		Description: fmt.Sprintf("Updated from v%s to v%s\n\n**Relaunching bot with new version...**", info.CurrentVersion, info.NewVersion),
+		Color:       0x57F287,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Bot is relaunching automatically",
+		},
+	}
 }
 
 func formatBytes(bytes int64) string {
@@ -369,6 +434,96 @@ func boolToEnabled(b bool) string {
 	return "Disabled"
 }
 
+// updateChannel returns the configured release channel
+func (b *Bot) updateChannel() string {
+	if updater.ValidChannel(b.Config().Features.UpdateChannel) {
+		return b.Config().Features.UpdateChannel
+	}
+	return updater.ChannelStable
+}
+
+// downloadVerifiedUpdate downloads an update and checks it against the release checksums
+func (b *Bot) downloadVerifiedUpdate(info *updater.UpdateInfo, progressFn func(downloaded, total int64)) (string, error) {
+	zipPath, err := updater.DownloadUpdate(info, progressFn)
+	if err != nil {
+		return "", err
+	}
+
+	if err := updater.VerifyUpdate(info, zipPath, b.Config().Features.UpdatePublicKey); err != nil {
+		os.Remove(zipPath)
+		return "", fmt.Errorf("verification failed: %w", err)
+	}
+	return zipPath, nil
+}
+
+// installUpdate snapshots the database, swaps in the new binary and records
+// the update so the next start can announce it and an owner can roll it back
+func (b *Bot) installUpdate(info *updater.UpdateInfo, zipPath string) error {
+	dbBackup := b.DB.GetPath() + ".pre-update.bak"
+	if err := b.DB.Backup(dbBackup); err != nil {
+		os.Remove(zipPath)
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	if err := updater.ApplyUpdate(zipPath); err != nil {
+		return err
+	}
+
+	state := &updater.State{
+		PreviousVersion: info.CurrentVersion,
+		NewVersion:      info.NewVersion,
+		Channel:         info.Channel,
+		ReleaseNotes:    info.ReleaseNotes,
+		ReleaseURL:      info.ReleaseURL,
+		DBBackup:        dbBackup,
+		AppliedAt:       time.Now(),
+	}
+	if err := updater.SaveState(state); err != nil {
+		log.Printf("[Update] Failed to record update state: %v", err)
+	}
+	return nil
+}
+
+// rollbackUpdate shuts down, restores the previous binary (and optionally the
+// pre-update database) and relaunches
+func (b *Bot) rollbackUpdate(state *updater.State, restoreDB bool) {
+	log.Printf("[Update] Rolling back to v%s...", state.PreviousVersion)
+	b.shutdownForRestart()
+
+	if restoreDB {
+		if err := updater.RestoreDatabase(state.DBBackup, b.DB.GetPath()); err != nil {
+			log.Printf("[Update] Failed to restore database: %v", err)
+		}
+	}
+	if err := updater.Rollback(); err != nil {
+		log.Printf("[Update] Failed to roll back: %v", err)
+	} else if err := updater.ClearState(); err != nil {
+		log.Printf("[Update] Failed to clear update state: %v", err)
+	}
+
+	b.relaunch()
+}
+
+// restartForUpdate shuts the bot down cleanly and starts the freshly installed binary
+func (b *Bot) restartForUpdate() {
+	b.shutdownForRestart()
+	b.relaunch()
+}
+
+func (b *Bot) shutdownForRestart() {
+	log.Println("[Update] Shutting down for restart...")
+	b.Stop()
+	b.DB.Close()
+}
+
+func (b *Bot) relaunch() {
+	log.Println("[Update] Relaunching...")
+	if err := updater.RelaunchAfterUpdate(); err != nil {
+		// Everything is already stopped, exit non-zero so a service manager restarts us
+		log.Printf("[Update] Failed to relaunch: %v", err)
+		os.Exit(1)
+	}
+}
 
 // CheckAndNotifyUpdate checks for updates and notifies the owner via DM
 func (b *Bot) CheckAndNotifyUpdate() {
@@ -377,12 +532,12 @@ func (b *Bot) CheckAndNotifyUpdate() {
 
 // StartPeriodicUpdateCheck starts a background goroutine that periodically checks for updates
 func (b *Bot) StartPeriodicUpdateCheck() {
-	if b.Config.Features.UpdateCheckHours <= 0 {
+	if b.Config().Features.UpdateCheckHours <= 0 {
 		return
 	}
 
 	go func() {
-		ticker := time.NewTicker(time.Duration(b.Config.Features.UpdateCheckHours) * time.Hour)
+		ticker := time.NewTicker(time.Duration(b.Config().Features.UpdateCheckHours) * time.Hour)
 		defer ticker.Stop()
 
 		for {
@@ -398,11 +553,17 @@ func (b *Bot) StartPeriodicUpdateCheck() {
 
 // checkForUpdates performs the actual update check
 func (b *Bot) checkForUpdates(isStartup bool) {
-	if !b.Config.Features.AutoUpdate {
+	if !b.Config().Features.AutoUpdate {
+		return
+	}
+
+	// Skip this round if an owner is installing an update by hand
+	if !b.updateMu.TryLock() {
 		return
 	}
+	defer b.updateMu.Unlock()
 
-	info, err := updater.CheckForUpdateByPattern()
+	info, err := updater.CheckForUpdate(b.updateChannel())
 	if err != nil {
 		fmt.Printf("[Update] Failed to check for updates: %v\n", err)
 		return
@@ -415,27 +576,25 @@ func (b *Bot) checkForUpdates(isStartup bool) {
 		return
 	}
 
-	fmt.Printf("[Update] Update available: v%s -> v%s\n", info.CurrentVersion, info.NewVersion)
+	fmt.Printf("[Update] Update available on %s channel: v%s -> v%s\n", info.Channel, info.CurrentVersion, info.NewVersion)
 
 	// If auto-apply is enabled, download and apply
-	if b.Config.Features.AutoUpdateApply {
+	if b.Config().Features.AutoUpdateApply {
 		fmt.Println("[Update] Auto-applying update...")
-		zipPath, err := updater.DownloadUpdate(info, nil)
+		zipPath, err := b.downloadVerifiedUpdate(info, nil)
 		if err != nil {
 			fmt.Printf("[Update] Failed to download update: %v\n", err)
 			return
 		}
 
-		if err := updater.ApplyUpdate(zipPath); err != nil {
+		if err := b.installUpdate(info, zipPath); err != nil {
 			fmt.Printf("[Update] Failed to apply update: %v\n", err)
 			return
 		}
 
 		fmt.Println("[Update] Update applied! Relaunching with new version...")
 
-		// Notify via channel if configured
-		b.sendUpdateNotification(info, true)
-
+		// The changelog is posted by the new version once it's up, this just says it's happening
 		// Notify owners via DM
 		b.notifyOwnersDM(&discordgo.MessageEmbed{
 			Title:       "Himiko Auto-Updated!",
@@ -446,34 +605,35 @@ func (b *Bot) checkForUpdates(isStartup bool) {
 		// Give Discord a moment to send notifications
 		time.Sleep(2 * time.Second)
 
-		// Relaunch the bot with the new executable
-		fmt.Println("[Update] Relaunching...")
-		if err := updater.RelaunchAfterUpdate(); err != nil {
-			fmt.Printf("[Update] Failed to relaunch: %v\n", err)
-			fmt.Println("[Update] Exiting for manual restart...")
-			os.Exit(0)
-		}
-	} else {
-		// Notify via channel if configured
-		b.sendUpdateNotification(info, false)
+		b.restartForUpdate()
+		return
+	}
 
-		// Notify owners via DM
-		b.notifyOwnersDM(&discordgo.MessageEmbed{
-			Title:       "Himiko Update Available!",
-			Description: fmt.Sprintf("A new version is available: **v%s** (current: v%s)\n\nUse `/update apply` to download and install.", info.NewVersion, info.CurrentVersion),
-			Color:       0x5865F2,
-		})
+	// Periodic checks only announce each version once
+	if b.updateNotified == info.NewVersion {
+		return
 	}
+	b.updateNotified = info.NewVersion
+
+	// Notify via channel if configured
+	b.sendUpdateNotification(info)
+
+	// Notify owners via DM
+	b.notifyOwnersDM(&discordgo.MessageEmbed{
+		Title:       "Himiko Update Available!",
+		Description: fmt.Sprintf("A new version is available: **v%s** (current: v%s)\n\nUse `/update apply` to download and install.", info.NewVersion, info.CurrentVersion),
+		Color:       0x5865F2,
+	})
 }
 
 // notifyOwnersDM sends a DM to all configured bot owners
 func (b *Bot) notifyOwnersDM(embed *discordgo.MessageEmbed) {
 	// Collect all owner IDs
 	ownerIDs := make(map[string]bool)
-	if b.Config.OwnerID != "" {
-		ownerIDs[b.Config.OwnerID] = true
+	if b.Config().OwnerID != "" {
+		ownerIDs[b.Config().OwnerID] = true
 	}
-	for _, id := range b.Config.OwnerIDs {
+	for _, id := range b.Config().OwnerIDs {
 		ownerIDs[id] = true
 	}
 
@@ -487,33 +647,22 @@ func (b *Bot) notifyOwnersDM(embed *discordgo.MessageEmbed) {
 }
 
 // sendUpdateNotification sends an update notification to the configured channel
-func (b *Bot) sendUpdateNotification(info *updater.UpdateInfo, applied bool) {
-	if b.Config.Features.UpdateNotifyChannel == "" {
+func (b *Bot) sendUpdateNotification(info *updater.UpdateInfo) {
+	if b.Config().Features.UpdateNotifyChannel == "" {
 		return
 	}
 
-	var embed *discordgo.MessageEmbed
-	if applied {
-		embed = &discordgo.MessageEmbed{
-			Title:       "Himiko Update Applied!",
-			Description: fmt.Sprintf("Himiko has been updated from **v%s** to **v%s**.\n\nThe bot will use the new version after restart.", info.CurrentVersion, info.NewVersion),
-			Color:       0x57F287,
-			Thumbnail: &discordgo.MessageEmbedThumbnail{
-				URL: "https://raw.githubusercontent.com/blubskye/himiko/main/himiko.png",
-			},
-		}
-	} else {
-		embed = &discordgo.MessageEmbed{
-			Title:       "Himiko Update Available!",
-			Description: fmt.Sprintf("A new version of Himiko is available!\n\n**Current:** v%s\n**New:** v%s", info.CurrentVersion, info.NewVersion),
-			Color:       0x5865F2,
-			Thumbnail: &discordgo.MessageEmbedThumbnail{
-				URL: "https://raw.githubusercontent.com/blubskye/himiko/main/himiko.png",
-			},
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: "Bot owner can use /update apply to install",
-			},
-		}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Himiko Update Available!",
+		URL:         info.ReleaseURL,
+		Description: fmt.Sprintf("A new version of Himiko is available!\n\n**Current:** v%s\n**New:** v%s", info.CurrentVersion, info.NewVersion),
+		Color:       0x5865F2,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: "https://raw.githubusercontent.com/blubskye/himiko/main/himiko.png",
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Bot owner can use /update apply to install",
+		},
 	}
 
 	if info.ReleaseNotes != "" {
@@ -527,5 +676,50 @@ func (b *Bot) sendUpdateNotification(info *updater.UpdateInfo, applied bool) {
 		})
 	}
 
-	b.Session.ChannelMessageSendEmbed(b.Config.Features.UpdateNotifyChannel, embed)
+	b.Session.ChannelMessageSendEmbed(b.Config().Features.UpdateNotifyChannel, embed)
+}
+
+// announceCompletedUpdate posts the changelog once the new version is running,
+// to the update channel if one is configured and to the owners otherwise
+func (b *Bot) announceCompletedUpdate() {
+	state, err := updater.LoadState()
+	if err != nil {
+		log.Printf("[Update] Failed to read update state: %v", err)
+		return
+	}
+	if state == nil || state.Announced || state.NewVersion != updater.GetCurrentVersion() {
+		return
+	}
+
+	notes := state.ReleaseNotes
+	if notes == "" {
+		notes = "No release notes were published for this version."
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Himiko Updated to v%s", state.NewVersion),
+		URL:         state.ReleaseURL,
+		Description: truncate(notes, 4000),
+		Color:       0x57F287,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: "https://raw.githubusercontent.com/blubskye/himiko/main/himiko.png",
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Updated from v%s • Owners can /update rollback if something broke", state.PreviousVersion),
+		},
+	}
+
+	if b.Config().Features.UpdateNotifyChannel != "" {
+		if _, err := b.Session.ChannelMessageSendEmbed(b.Config().Features.UpdateNotifyChannel, embed); err != nil {
+			log.Printf("[Update] Failed to post changelog: %v", err)
+			return
+		}
+	} else {
+		b.notifyOwnersDM(embed)
+	}
+
+	state.Announced = true
+	if err := updater.SaveState(state); err != nil {
+		log.Printf("[Update] Failed to record update state: %v", err)
+	}
 }
@@ -19,9 +19,11 @@ package bot
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -52,6 +54,25 @@ func (ch *CommandHandler) registerUtilityCommands() {
 		Handler: ch.snipeHandler,
 	})
 
+	// Edit snipe
+	ch.Register(&Command{
+		Name:        "editsnipe",
+		Description: "Retrieve recently edited messages",
+		Category:    "Utility",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "amount",
+				Description: "Number of edits to retrieve (1-10)",
+				Required:    false,
+				MinValue:    floatPtr(1),
+				MaxValue:    10,
+			},
+		},
+		Handler:       ch.editSnipeHandler,
+		PrefixHandler: ch.editSnipePrefixHandler,
+	})
+
 	// AFK
 	ch.Register(&Command{
 		Name:        "afk",
@@ -64,6 +85,12 @@ func (ch *CommandHandler) registerUtilityCommands() {
 				Description: "Your AFK message",
 				Required:    false,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "server_only",
+				Description: "Only be AFK in this server",
+				Required:    false,
+			},
 		},
 		Handler: ch.afkHandler,
 	})
@@ -90,28 +117,6 @@ func (ch *CommandHandler) registerUtilityCommands() {
 		Handler: ch.remindHandler,
 	})
 
-	// Schedule
-	ch.Register(&Command{
-		Name:        "schedule",
-		Description: "Schedule a message",
-		Category:    "Utility",
-		Options: []*discordgo.ApplicationCommandOption{
-			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "time",
-				Description: "When to send (e.g., 1h30m, 2d)",
-				Required:    true,
-			},
-			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "message",
-				Description: "Message to send",
-				Required:    true,
-			},
-		},
-		Handler: ch.scheduleHandler,
-	})
-
 	// Poll
 	ch.Register(&Command{
 		Name:        "poll",
@@ -130,9 +135,22 @@ func (ch *CommandHandler) registerUtilityCommands() {
 				Description: "Options separated by | (max 10)",
 				Required:    false,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "duration",
+				Description: "Close the poll automatically after this long (e.g. 1h, 2d)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "anonymous",
+				Description: "Hide who voted for each option",
+				Required:    false,
+			},
 		},
 		Handler: ch.pollHandler,
 	})
+	ch.RegisterComponent("poll", ch.handlePollComponent)
 
 	// Embed builder
 	ch.Register(&Command{
@@ -236,28 +254,6 @@ func (ch *CommandHandler) registerUtilityCommands() {
 		Handler: ch.sayHandler,
 	})
 
-	// Steal emoji
-	ch.Register(&Command{
-		Name:        "stealemoji",
-		Description: "Add an emoji to this server",
-		Category:    "Utility",
-		Options: []*discordgo.ApplicationCommandOption{
-			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "emoji",
-				Description: "Emoji to steal (paste the emoji)",
-				Required:    true,
-			},
-			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "name",
-				Description: "Name for the emoji",
-				Required:    false,
-			},
-		},
-		Handler: ch.stealEmojiHandler,
-	})
-
 	// Math
 	ch.Register(&Command{
 		Name:        "math",
@@ -322,6 +318,8 @@ func (ch *CommandHandler) snipeHandler(s *discordgo.Session, i *discordgo.Intera
 		Color: 0x5865F2,
 	}
 
+	// Archived copies are re-uploaded since Discord removes a deleted message's attachments
+	var archived []database.DeletedAttachment
 	for _, msg := range messages {
 		user, _ := s.User(msg.UserID)
 		username := msg.UserID
@@ -331,13 +329,123 @@ func (ch *CommandHandler) snipeHandler(s *discordgo.Session, i *discordgo.Intera
 
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
 			Name:  fmt.Sprintf("%s - <t:%s:R>", username, formatUnixTime(msg.DeletedAt)),
-			Value: truncate(msg.Content, 1024),
+			Value: snipeFieldValue(msg),
 		})
+
+		archived = append(archived, msg.Attachments...)
+	}
+
+	// Attachments left out by the size cap are still linked in the field text
+	files, handles, _ := archivedFiles(archived, maxReuploadBytes)
+	defer func() {
+		for _, f := range handles {
+			f.Close()
+		}
+	}()
+	if name := firstImageFile(files); name != "" {
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + name}
 	}
 
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Files:  files,
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil && len(files) > 0 {
+		// The upload was refused, so fall back to the text alone
+		embed.Image = nil
+		respondEmbedEphemeral(s, i, embed)
+	}
+}
+
+// snipeFieldValue shows a deleted message's text with its attachments, stickers and embeds
+func snipeFieldValue(msg database.DeletedMessage) string {
+	var lines []string
+	if msg.Content != "" {
+		lines = append(lines, msg.Content)
+	}
+	for _, att := range msg.Attachments {
+		line := fmt.Sprintf("📎 [%s](%s) (%s)", att.Filename, att.URL, formatBytes(int64(att.Size)))
+		if att.ArchivePath != "" {
+			line += " - archived"
+		}
+		lines = append(lines, line)
+	}
+	if len(msg.Stickers) > 0 {
+		lines = append(lines, "🏷️ Sticker: "+strings.Join(msg.Stickers, ", "))
+	}
+	for _, summary := range msg.Embeds {
+		lines = append(lines, "🔗 Embed: "+summary)
+	}
+	if len(lines) == 0 {
+		return "*No text content*"
+	}
+	return truncate(strings.Join(lines, "\n"), 1024)
+}
+
+func (ch *CommandHandler) editSnipeHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	amount := getIntOption(i, "amount")
+	if amount == 0 {
+		amount = 1
+	}
+
+	embed := ch.editSnipeEmbed(s, i.ChannelID, int(amount))
+	if embed == nil {
+		respondEphemeral(s, i, "No edited messages found in this channel.")
+		return
+	}
 	respondEmbedEphemeral(s, i, embed)
 }
 
+func (ch *CommandHandler) editSnipePrefixHandler(ctx *PrefixContext) {
+	amount := 1
+	if arg := ctx.GetArg(0); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > 10 {
+			ctx.Reply("Usage: `" + ctx.Prefix + "editsnipe [amount 1-10]`")
+			return
+		}
+		amount = n
+	}
+
+	embed := ch.editSnipeEmbed(ctx.Session, ctx.ChannelID, amount)
+	if embed == nil {
+		ctx.Reply("No edited messages found in this channel.")
+		return
+	}
+	ctx.ReplyEmbed(embed)
+}
+
+// editSnipeEmbed shows a channel's most recent edits as word diffs, nil if there are none
+func (ch *CommandHandler) editSnipeEmbed(s *discordgo.Session, channelID string, amount int) *discordgo.MessageEmbed {
+	edits, err := ch.bot.DB.GetEditedMessages(channelID, amount)
+	if err != nil || len(edits) == 0 {
+		return nil
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Sniped Edits",
+		Color: 0x5865F2,
+	}
+
+	for _, edit := range edits {
+		user, _ := s.User(edit.UserID)
+		username := edit.UserID
+		if user != nil {
+			username = user.Username
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s - <t:%s:R>", username, formatUnixTime(edit.EditedAt)),
+			Value: truncate(wordDiff(edit.Before, edit.After), 1024),
+		})
+	}
+	return embed
+}
+
 func (ch *CommandHandler) afkHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	message := getStringOption(i, "message")
 
@@ -345,13 +453,38 @@ func (ch *CommandHandler) afkHandler(s *discordgo.Session, i *discordgo.Interact
 		message = "AFK"
 	}
 
-	err := ch.bot.DB.SetAFK(i.Member.User.ID, message)
+	afk := &database.AFKStatus{UserID: i.Member.User.ID, Message: &message}
+	if getBoolOption(i, "server_only") {
+		afk.GuildID = i.GuildID
+	}
+
+	// Going AFK again keeps the nickname from before the first time
+	original := i.Member.Nick
+	if previous, _ := ch.bot.DB.GetAFK(i.Member.User.ID); previous != nil && previous.NickGuildID != "" {
+		if previous.NickGuildID == i.GuildID {
+			original = previous.OriginalNick
+		} else {
+			restoreAFKNickname(s, previous)
+		}
+	}
+	if applyAFKNickname(s, i.GuildID, i.Member, original) {
+		afk.NickGuildID = i.GuildID
+		afk.OriginalNick = original
+	}
+
+	err := ch.bot.DB.SetAFK(afk)
 	if err != nil {
 		respondEphemeral(s, i, "Failed to set AFK status.")
 		return
 	}
+	// Mentions from an earlier AFK that was never cleared don't belong in this summary
+	ch.bot.DB.ClearAFKMentions(i.Member.User.ID)
 
-	respond(s, i, fmt.Sprintf("You are now AFK: %s", message))
+	where := ""
+	if afk.GuildID != "" {
+		where = " in this server"
+	}
+	respond(s, i, fmt.Sprintf("You are now AFK%s: %s", where, message))
 }
 
 func (ch *CommandHandler) remindHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -377,82 +510,67 @@ func (ch *CommandHandler) remindHandler(s *discordgo.Session, i *discordgo.Inter
 	respondEmbed(s, i, embed)
 }
 
-func (ch *CommandHandler) scheduleHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	timeStr := getStringOption(i, "time")
-	message := getStringOption(i, "message")
-
-	duration, err := parseDuration(timeStr)
-	if err != nil || duration <= 0 {
-		respondEphemeral(s, i, "Invalid time format. Use format like: 1h30m, 2d, 30m")
-		return
-	}
-
-	scheduledFor := time.Now().Add(duration)
-
-	err = ch.bot.DB.ScheduleMessage(i.GuildID, i.ChannelID, i.Member.User.ID, message, scheduledFor)
-	if err != nil {
-		respondEphemeral(s, i, "Failed to schedule message.")
-		return
-	}
-
-	embed := successEmbed("Message Scheduled",
-		fmt.Sprintf("Message will be sent <t:%d:R>", scheduledFor.Unix()))
-	respondEmbedEphemeral(s, i, embed)
-}
-
 func (ch *CommandHandler) pollHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	question := getStringOption(i, "question")
 	optionsStr := getStringOption(i, "options")
 
-	var options []string
+	options := []string{"Yes", "No"}
 	if optionsStr != "" {
-		options = strings.Split(optionsStr, "|")
-		for idx := range options {
-			options[idx] = strings.TrimSpace(options[idx])
+		options = nil
+		for _, opt := range strings.Split(optionsStr, "|") {
+			if opt = strings.TrimSpace(opt); opt != "" {
+				options = append(options, opt)
+			}
 		}
 	}
+	if len(options) < 2 || len(options) > maxPollOptions {
+		respondEphemeral(s, i, fmt.Sprintf("A poll needs between 2 and %d options.", maxPollOptions))
+		return
+	}
 
-	embed := &discordgo.MessageEmbed{
-		Title: question,
-		Color: 0x5865F2,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Poll by %s", i.Member.User.Username),
-		},
+	poll := &database.Poll{
+		GuildID:   i.GuildID,
+		ChannelID: i.ChannelID,
+		UserID:    i.Member.User.ID,
+		Question:  truncate(question, 256),
+		Options:   options,
+		Anonymous: getBoolOption(i, "anonymous"),
+	}
+	if durationStr := getStringOption(i, "duration"); durationStr != "" {
+		duration, err := parseDuration(durationStr)
+		if err != nil || duration < time.Minute {
+			respondEphemeral(s, i, "Invalid duration. Use a format like `30m`, `1h` or `2d` (minimum 1 minute).")
+			return
+		}
+		poll.EndsAt = time.Now().Add(duration).UnixMilli()
 	}
 
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	id, err := ch.bot.DB.CreatePoll(poll)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to create poll.")
+		return
+	}
+	poll.ID = id
+
+	embed, components := renderPoll(poll, nil)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{embed},
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
 		},
 	})
+	if err != nil {
+		return
+	}
 
-	// Get the message to add reactions
 	msg, err := s.InteractionResponse(i.Interaction)
 	if err != nil {
 		return
 	}
-
-	if len(options) == 0 {
-		// Yes/No poll
-		s.MessageReactionAdd(i.ChannelID, msg.ID, "✅")
-		s.MessageReactionAdd(i.ChannelID, msg.ID, "❌")
-	} else {
-		// Multiple choice
-		emojis := []string{"1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣", "7️⃣", "8️⃣", "9️⃣", "🔟"}
-		var desc string
-		for idx, opt := range options {
-			if idx >= 10 {
-				break
-			}
-			desc += fmt.Sprintf("%s %s\n", emojis[idx], opt)
-			s.MessageReactionAdd(i.ChannelID, msg.ID, emojis[idx])
-		}
-
-		embed.Description = desc
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Embeds: &[]*discordgo.MessageEmbed{embed},
-		})
+	ch.bot.DB.SetPollMessage(id, msg.ID)
+	if poll.EndsAt > 0 {
+		ch.bot.DB.AddScheduledEvent(i.GuildID, eventPollClose, strconv.FormatInt(id, 10), poll.EndsAt)
 	}
 }
 
@@ -628,77 +746,6 @@ func (ch *CommandHandler) sayHandler(s *discordgo.Session, i *discordgo.Interact
 	respondEphemeral(s, i, "Message sent!")
 }
 
-func (ch *CommandHandler) stealEmojiHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if !hasPermission(s, i.GuildID, i.Member.User.ID, discordgo.PermissionManageEmojis) {
-		respondEphemeral(s, i, "You don't have permission to manage emojis.")
-		return
-	}
-
-	emojiStr := getStringOption(i, "emoji")
-	name := getStringOption(i, "name")
-
-	// Parse emoji ID from string like <:name:id> or <a:name:id>
-	var emojiID string
-	var animated bool
-
-	if strings.HasPrefix(emojiStr, "<a:") {
-		animated = true
-		parts := strings.Split(strings.Trim(emojiStr, "<>"), ":")
-		if len(parts) >= 3 {
-			emojiID = parts[2]
-			if name == "" {
-				name = parts[1]
-			}
-		}
-	} else if strings.HasPrefix(emojiStr, "<:") {
-		parts := strings.Split(strings.Trim(emojiStr, "<>"), ":")
-		if len(parts) >= 3 {
-			emojiID = parts[2]
-			if name == "" {
-				name = parts[1]
-			}
-		}
-	} else {
-		respondEphemeral(s, i, "Please provide a custom emoji (not a default one).")
-		return
-	}
-
-	if emojiID == "" {
-		respondEphemeral(s, i, "Could not parse emoji.")
-		return
-	}
-
-	// Construct URL
-	ext := "png"
-	if animated {
-		ext = "gif"
-	}
-	url := fmt.Sprintf("https://cdn.discordapp.com/emojis/%s.%s", emojiID, ext)
-
-	respondDeferred(s, i)
-
-	// Download the image
-	resp, err := httpClient.Get(url)
-	if err != nil {
-		followUp(s, i, "Failed to download emoji.")
-		return
-	}
-	defer resp.Body.Close()
-
-	// Create the emoji
-	emoji, err := s.GuildEmojiCreate(i.GuildID, &discordgo.EmojiParams{
-		Name:  name,
-		Image: fmt.Sprintf("data:image/%s;base64,", ext),
-	})
-
-	if err != nil {
-		followUp(s, i, "Failed to create emoji: "+err.Error())
-		return
-	}
-
-	followUp(s, i, fmt.Sprintf("Successfully added emoji: %s", emoji.MessageFormat()))
-}
-
 func (ch *CommandHandler) mathHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	expression := getStringOption(i, "expression")
 
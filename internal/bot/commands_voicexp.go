@@ -18,6 +18,7 @@ package bot
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/blubskye/himiko/internal/database"
 	"github.com/bwmarrin/discordgo"
@@ -83,6 +84,49 @@ func (ch *CommandHandler) registerVoiceXPCommands() {
 					},
 				},
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "rules",
+				Description: "Configure voice XP anti-abuse rules",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "ignore_alone",
+						Description: "Don't give XP to members alone in a channel",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "ignore_deafened",
+						Description: "Don't give XP to deafened members",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "daily_cap",
+						Description: "Max voice XP per member per day (0 for no cap)",
+						Required:    false,
+						MinValue:    floatPtr(0),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "exclude",
+				Description: "Toggle whether a voice channel earns XP",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Voice channel",
+						Required:    true,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildVoice,
+							discordgo.ChannelTypeGuildStageVoice,
+						},
+					},
+				},
+			},
 			{
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Name:        "status",
@@ -170,6 +214,46 @@ func (ch *CommandHandler) voiceXPHandler(s *discordgo.Session, i *discordgo.Inte
 			fmt.Sprintf("Users in AFK channel %s.", status))
 		respondEmbed(s, i, embed)
 
+	case "rules":
+		for _, opt := range subCmd.Options {
+			switch opt.Name {
+			case "ignore_alone":
+				config.IgnoreAlone = opt.BoolValue()
+			case "ignore_deafened":
+				config.IgnoreDeafened = opt.BoolValue()
+			case "daily_cap":
+				config.DailyCap = int(opt.IntValue())
+			}
+		}
+		if err := ch.bot.DB.SetVoiceXPConfig(config); err != nil {
+			respondEphemeral(s, i, "Failed to update voice XP rules.")
+			return
+		}
+		ch.voiceXPStatus(s, i, config)
+
+	case "exclude":
+		channelID := subCmd.Options[0].ChannelValue(nil).ID
+		removed := false
+		for idx, id := range config.ExcludedChannels {
+			if id == channelID {
+				config.ExcludedChannels = append(config.ExcludedChannels[:idx], config.ExcludedChannels[idx+1:]...)
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			config.ExcludedChannels = append(config.ExcludedChannels, channelID)
+		}
+		if err := ch.bot.DB.SetVoiceXPConfig(config); err != nil {
+			respondEphemeral(s, i, "Failed to update excluded channels.")
+			return
+		}
+		if removed {
+			respondEmbed(s, i, successEmbed("Channel Included", fmt.Sprintf("<#%s> earns voice XP again.", channelID)))
+		} else {
+			respondEmbed(s, i, successEmbed("Channel Excluded", fmt.Sprintf("<#%s> will no longer earn voice XP.", channelID)))
+		}
+
 	case "status":
 		ch.voiceXPStatus(s, i, config)
 	}
@@ -183,6 +267,15 @@ func (ch *CommandHandler) voiceXPStatus(s *discordgo.Session, i *discordgo.Inter
 		return ":x:"
 	}
 
+	dailyCap := "None"
+	if config.DailyCap > 0 {
+		dailyCap = fmt.Sprintf("%d XP", config.DailyCap)
+	}
+	excluded := "None"
+	if len(config.ExcludedChannels) > 0 {
+		excluded = "<#" + strings.Join(config.ExcludedChannels, ">, <#") + ">"
+	}
+
 	embed := &discordgo.MessageEmbed{
 		Title: "Voice XP Configuration",
 		Color: 0x5865F2,
@@ -191,6 +284,10 @@ func (ch *CommandHandler) voiceXPStatus(s *discordgo.Session, i *discordgo.Inter
 			{Name: "XP Rate", Value: fmt.Sprintf("%d XP", config.XPRate), Inline: true},
 			{Name: "Interval", Value: fmt.Sprintf("%d minutes", config.IntervalMins), Inline: true},
 			{Name: "Ignore AFK", Value: statusEmoji(config.IgnoreAFK), Inline: true},
+			{Name: "Ignore Alone", Value: statusEmoji(config.IgnoreAlone), Inline: true},
+			{Name: "Ignore Deafened", Value: statusEmoji(config.IgnoreDeafened), Inline: true},
+			{Name: "Daily Cap", Value: dailyCap, Inline: true},
+			{Name: "Excluded Channels", Value: excluded},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: fmt.Sprintf("Users earn %d XP every %d minutes in voice", config.XPRate, config.IntervalMins),
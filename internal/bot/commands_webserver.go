@@ -50,7 +50,7 @@ func (ch *CommandHandler) registerWebServerCommands() {
 // webserverPrefixHandler handles prefix-based webserver commands
 func (ch *CommandHandler) webserverPrefixHandler(ctx *PrefixContext) {
 	// Owner only
-	if !ch.bot.Config.IsOwner(ctx.Author.ID) {
+	if !ch.bot.Config().IsOwner(ctx.Author.ID) {
 		ctx.Reply("This command is only available to bot owners.")
 		return
 	}
@@ -88,10 +88,10 @@ func (ch *CommandHandler) webserverOnHandler(s *discordgo.Session, i *discordgo.
 	}
 
 	// Update config to persist the setting
-	ch.bot.Config.WebServer.Enabled = true
-	ch.bot.Config.Save("config.json")
+	ch.bot.Config().WebServer.Enabled = true
+	ch.bot.Config().Save("config.json")
 
-	addr := fmt.Sprintf("http://%s:%d", ch.bot.Config.WebServer.Host, ch.bot.Config.WebServer.Port)
+	addr := fmt.Sprintf("http://%s:%d", ch.bot.Config().WebServer.Host, ch.bot.Config().WebServer.Port)
 
 	embed := &discordgo.MessageEmbed{
 		Title:       "Web Server Started",
@@ -100,12 +100,12 @@ func (ch *CommandHandler) webserverOnHandler(s *discordgo.Session, i *discordgo.
 		Fields: []*discordgo.MessageEmbedField{
 			{
 				Name:   "Host",
-				Value:  ch.bot.Config.WebServer.Host,
+				Value:  ch.bot.Config().WebServer.Host,
 				Inline: true,
 			},
 			{
 				Name:   "Port",
-				Value:  strconv.Itoa(ch.bot.Config.WebServer.Port),
+				Value:  strconv.Itoa(ch.bot.Config().WebServer.Port),
 				Inline: true,
 			},
 		},
@@ -129,8 +129,8 @@ func (ch *CommandHandler) webserverOffHandler(s *discordgo.Session, i *discordgo
 	}
 
 	// Update config to persist the setting
-	ch.bot.Config.WebServer.Enabled = false
-	ch.bot.Config.Save("config.json")
+	ch.bot.Config().WebServer.Enabled = false
+	ch.bot.Config().Save("config.json")
 
 	embed := &discordgo.MessageEmbed{
 		Title:       "Web Server Stopped",
@@ -156,7 +156,7 @@ func (ch *CommandHandler) webserverStatusHandler(s *discordgo.Session, i *discor
 		color = 0xED4245
 	}
 
-	addr := fmt.Sprintf("http://%s:%d", ch.bot.Config.WebServer.Host, ch.bot.Config.WebServer.Port)
+	addr := fmt.Sprintf("http://%s:%d", ch.bot.Config().WebServer.Host, ch.bot.Config().WebServer.Port)
 
 	embed := &discordgo.MessageEmbed{
 		Title: "Web Server Status",
@@ -169,7 +169,7 @@ func (ch *CommandHandler) webserverStatusHandler(s *discordgo.Session, i *discor
 			},
 			{
 				Name:   "Auto-Start",
-				Value:  boolToEnabled(ch.bot.Config.WebServer.Enabled),
+				Value:  boolToEnabled(ch.bot.Config().WebServer.Enabled),
 				Inline: true,
 			},
 			{
@@ -179,7 +179,7 @@ func (ch *CommandHandler) webserverStatusHandler(s *discordgo.Session, i *discor
 			},
 			{
 				Name:   "Allow Remote",
-				Value:  boolToEnabled(ch.bot.Config.WebServer.AllowRemote),
+				Value:  boolToEnabled(ch.bot.Config().WebServer.AllowRemote),
 				Inline: true,
 			},
 		},
@@ -205,18 +205,18 @@ func (ch *CommandHandler) webserverConfigHandler(s *discordgo.Session, i *discor
 		case "port":
 			port := int(opt.IntValue())
 			if port >= 1 && port <= 65535 {
-				ch.bot.Config.WebServer.Port = port
+				ch.bot.Config().WebServer.Port = port
 				changed = true
 			}
 		case "allow_remote":
-			ch.bot.Config.WebServer.AllowRemote = opt.BoolValue()
+			ch.bot.Config().WebServer.AllowRemote = opt.BoolValue()
 			changed = true
 		}
 	}
 
 	if changed {
 		// Save config
-		if err := ch.bot.Config.Save("config.json"); err != nil {
+		if err := ch.bot.Config().Save("config.json"); err != nil {
 			respondEphemeral(s, i, fmt.Sprintf("Failed to save config: %v", err))
 			return
 		}
@@ -231,12 +231,12 @@ func (ch *CommandHandler) webserverConfigHandler(s *discordgo.Session, i *discor
 			Fields: []*discordgo.MessageEmbedField{
 				{
 					Name:   "Port",
-					Value:  strconv.Itoa(ch.bot.Config.WebServer.Port),
+					Value:  strconv.Itoa(ch.bot.Config().WebServer.Port),
 					Inline: true,
 				},
 				{
 					Name:   "Allow Remote",
-					Value:  boolToEnabled(ch.bot.Config.WebServer.AllowRemote),
+					Value:  boolToEnabled(ch.bot.Config().WebServer.AllowRemote),
 					Inline: true,
 				},
 			},
@@ -275,10 +275,10 @@ func (ch *CommandHandler) webserverOnPrefix(ctx *PrefixContext) {
 	}
 
 	// Update config to persist the setting
-	ch.bot.Config.WebServer.Enabled = true
-	ch.bot.Config.Save("config.json")
+	ch.bot.Config().WebServer.Enabled = true
+	ch.bot.Config().Save("config.json")
 
-	addr := fmt.Sprintf("http://%s:%d", ch.bot.Config.WebServer.Host, ch.bot.Config.WebServer.Port)
+	addr := fmt.Sprintf("http://%s:%d", ch.bot.Config().WebServer.Host, ch.bot.Config().WebServer.Port)
 
 	embed := &discordgo.MessageEmbed{
 		Title:       "Web Server Started",
@@ -287,12 +287,12 @@ func (ch *CommandHandler) webserverOnPrefix(ctx *PrefixContext) {
 		Fields: []*discordgo.MessageEmbedField{
 			{
 				Name:   "Host",
-				Value:  ch.bot.Config.WebServer.Host,
+				Value:  ch.bot.Config().WebServer.Host,
 				Inline: true,
 			},
 			{
 				Name:   "Port",
-				Value:  strconv.Itoa(ch.bot.Config.WebServer.Port),
+				Value:  strconv.Itoa(ch.bot.Config().WebServer.Port),
 				Inline: true,
 			},
 		},
@@ -316,8 +316,8 @@ func (ch *CommandHandler) webserverOffPrefix(ctx *PrefixContext) {
 	}
 
 	// Update config to persist the setting
-	ch.bot.Config.WebServer.Enabled = false
-	ch.bot.Config.Save("config.json")
+	ch.bot.Config().WebServer.Enabled = false
+	ch.bot.Config().Save("config.json")
 
 	embed := &discordgo.MessageEmbed{
 		Title:       "Web Server Stopped",
@@ -343,7 +343,7 @@ func (ch *CommandHandler) webserverStatusPrefix(ctx *PrefixContext) {
 		color = 0xED4245
 	}
 
-	addr := fmt.Sprintf("http://%s:%d", ch.bot.Config.WebServer.Host, ch.bot.Config.WebServer.Port)
+	addr := fmt.Sprintf("http://%s:%d", ch.bot.Config().WebServer.Host, ch.bot.Config().WebServer.Port)
 
 	embed := &discordgo.MessageEmbed{
 		Title: "Web Server Status",
@@ -356,7 +356,7 @@ func (ch *CommandHandler) webserverStatusPrefix(ctx *PrefixContext) {
 			},
 			{
 				Name:   "Auto-Start",
-				Value:  boolToEnabled(ch.bot.Config.WebServer.Enabled),
+				Value:  boolToEnabled(ch.bot.Config().WebServer.Enabled),
 				Inline: true,
 			},
 			{
@@ -366,7 +366,7 @@ func (ch *CommandHandler) webserverStatusPrefix(ctx *PrefixContext) {
 			},
 			{
 				Name:   "Allow Remote",
-				Value:  boolToEnabled(ch.bot.Config.WebServer.AllowRemote),
+				Value:  boolToEnabled(ch.bot.Config().WebServer.AllowRemote),
 				Inline: true,
 			},
 		},
@@ -396,18 +396,18 @@ func (ch *CommandHandler) webserverConfigPrefix(ctx *PrefixContext) {
 			var port int
 			fmt.Sscanf(value, "%d", &port)
 			if port >= 1 && port <= 65535 {
-				ch.bot.Config.WebServer.Port = port
+				ch.bot.Config().WebServer.Port = port
 				changed = true
 			}
 		case "allow_remote":
-			ch.bot.Config.WebServer.AllowRemote = value == "true" || value == "1" || value == "yes"
+			ch.bot.Config().WebServer.AllowRemote = value == "true" || value == "1" || value == "yes"
 			changed = true
 		}
 	}
 
 	if changed {
 		// Save config
-		if err := ch.bot.Config.Save("config.json"); err != nil {
+		if err := ch.bot.Config().Save("config.json"); err != nil {
 			ctx.Reply(fmt.Sprintf("Failed to save config: %v", err))
 			return
 		}
@@ -421,12 +421,12 @@ func (ch *CommandHandler) webserverConfigPrefix(ctx *PrefixContext) {
 			Fields: []*discordgo.MessageEmbedField{
 				{
 					Name:   "Port",
-					Value:  strconv.Itoa(ch.bot.Config.WebServer.Port),
+					Value:  strconv.Itoa(ch.bot.Config().WebServer.Port),
 					Inline: true,
 				},
 				{
 					Name:   "Allow Remote",
-					Value:  boolToEnabled(ch.bot.Config.WebServer.AllowRemote),
+					Value:  boolToEnabled(ch.bot.Config().WebServer.AllowRemote),
 					Inline: true,
 				},
 			},
@@ -447,7 +447,7 @@ func (ch *CommandHandler) webserverConfigPrefix(ctx *PrefixContext) {
 // botstatsHandler shows real-time bot statistics
 func (ch *CommandHandler) botstatsHandler(ctx *PrefixContext) {
 	// Owner only
-	if !ch.bot.Config.IsOwner(ctx.Author.ID) {
+	if !ch.bot.Config().IsOwner(ctx.Author.ID) {
 		ctx.Reply("This command is only available to bot owners.")
 		return
 	}
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/blubskye/himiko/internal/database"
+	"github.com/blubskye/himiko/internal/rankcard"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -54,9 +55,21 @@ func (ch *CommandHandler) registerXPCommands() {
 				Description: "Page number",
 				Required:    false,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "period",
+				Description: "Rank by total XP or XP gained recently",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "All Time", Value: "all"},
+					{Name: "Weekly", Value: "weekly"},
+					{Name: "Monthly", Value: "monthly"},
+				},
+			},
 		},
 		Handler: ch.leaderboardHandler,
 	})
+	ch.RegisterComponent("xplb", ch.handleLeaderboardComponent)
 
 	// Rank (same as XP but with different styling)
 	ch.Register(&Command{
@@ -74,6 +87,34 @@ func (ch *CommandHandler) registerXPCommands() {
 		Handler: ch.rankHandler,
 	})
 
+	// Rank card customization
+	ch.Register(&Command{
+		Name:        "rankcard",
+		Description: "Customize the colors of your rank card",
+		Category:    "XP",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "background",
+				Description: "Background color as a hex code (e.g., #23272A)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "accent",
+				Description: "Accent color for the progress bar and avatar ring (e.g., #5865F2)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "reset",
+				Description: "Reset to the default colors",
+				Required:    false,
+			},
+		},
+		Handler: ch.rankCardHandler,
+	})
+
 	// Set Level (Admin)
 	ch.Register(&Command{
 		Name:        "setlevel",
@@ -206,58 +247,22 @@ func (ch *CommandHandler) xpHandler(s *discordgo.Session, i *discordgo.Interacti
 }
 
 func (ch *CommandHandler) leaderboardHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	period := getLeaderboardPeriod(getStringOption(i, "period"))
 	page := max(int(getIntOption(i, "page")), 1)
 
-	perPage := 10
-	offset := (page - 1) * perPage
-
-	leaderboard, err := ch.bot.DB.GetGuildLeaderboard(i.GuildID, 100) // Get top 100
+	embed, components, err := ch.bot.renderLeaderboard(i.GuildID, period, page, "")
 	if err != nil {
 		respondEphemeral(s, i, "Failed to get leaderboard.")
 		return
 	}
 
-	if len(leaderboard) == 0 {
-		respondEphemeral(s, i, "No XP data yet! Start chatting to earn XP.")
-		return
-	}
-
-	totalPages := (len(leaderboard) + perPage - 1) / perPage
-	page = min(page, totalPages)
-
-	start := offset
-	end := offset + perPage
-	if start >= len(leaderboard) {
-		start = max(len(leaderboard)-perPage, 0)
-	}
-	end = min(end, len(leaderboard))
-
-	var description strings.Builder
-	for idx, entry := range leaderboard[start:end] {
-		rank := start + idx + 1
-		medal := ""
-		switch rank {
-		case 1:
-			medal = " :first_place:"
-		case 2:
-			medal = " :second_place:"
-		case 3:
-			medal = " :third_place:"
-		}
-		description.WriteString(fmt.Sprintf("**#%d**%s <@%s> - Level %d (%d XP)\n",
-			rank, medal, entry.UserID, entry.Level, entry.XP))
-	}
-
-	embed := &discordgo.MessageEmbed{
-		Title:       "XP Leaderboard",
-		Description: description.String(),
-		Color:       0xFFD700,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Page %d/%d", page, totalPages),
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
 		},
-	}
-
-	respondEmbed(s, i, embed)
+	})
 }
 
 func (ch *CommandHandler) rankHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -274,20 +279,96 @@ func (ch *CommandHandler) rankHandler(s *discordgo.Session, i *discordgo.Interac
 
 	rank, _ := ch.bot.DB.GetUserRank(i.GuildID, user.ID)
 
-	embed := &discordgo.MessageEmbed{
-		Title: fmt.Sprintf("%s's Rank", user.Username),
-		Color: 0x5865F2,
-		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: user.AvatarURL("128"),
-		},
-		Fields: []*discordgo.MessageEmbedField{
-			{Name: "Rank", Value: fmt.Sprintf("#%d", rank), Inline: true},
-			{Name: "Level", Value: strconv.Itoa(xpData.Level), Inline: true},
-			{Name: "Total XP", Value: strconv.FormatInt(xpData.XP, 10), Inline: true},
+	// Downloading the avatar can take a moment
+	respondDeferred(s, i)
+
+	card, err := ch.bot.renderRankCard(user, xpData, rank)
+	if err != nil {
+		// Fall back to the plain embed
+		editResponseEmbed(s, i, &discordgo.MessageEmbed{
+			Title: fmt.Sprintf("%s's Rank", user.Username),
+			Color: 0x5865F2,
+			Thumbnail: &discordgo.MessageEmbedThumbnail{
+				URL: user.AvatarURL("128"),
+			},
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Rank", Value: fmt.Sprintf("#%d", rank), Inline: true},
+				{Name: "Level", Value: strconv.Itoa(xpData.Level), Inline: true},
+				{Name: "Total XP", Value: strconv.FormatInt(xpData.XP, 10), Inline: true},
+			},
+		})
+		return
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Files: []*discordgo.File{
+			{Name: "rank.png", ContentType: "image/png", Reader: card},
 		},
+	})
+}
+
+func (ch *CommandHandler) rankCardHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := i.Member.User.ID
+
+	if getBoolOption(i, "reset") {
+		if err := ch.bot.DB.DeleteRankCardSettings(userID); err != nil {
+			respondEphemeral(s, i, "Failed to reset rank card.")
+			return
+		}
+		respondEmbedEphemeral(s, i, successEmbed("Rank Card Reset", "Your rank card is back to the default colors"))
+		return
 	}
 
-	respondEmbed(s, i, embed)
+	settings, err := ch.bot.DB.GetRankCardSettings(userID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get rank card settings.")
+		return
+	}
+
+	for _, opt := range []struct {
+		name  string
+		value **int
+	}{
+		{"background", &settings.Background},
+		{"accent", &settings.Accent},
+	} {
+		hex := getStringOption(i, opt.name)
+		if hex == "" {
+			continue
+		}
+		c, err := rankcard.ParseHexColor(hex)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Invalid %s color. Use a hex code like `#5865F2`.", opt.name))
+			return
+		}
+		v := rankcard.ToInt(c)
+		*opt.value = &v
+	}
+
+	if err := ch.bot.DB.SetRankCardSettings(settings); err != nil {
+		respondEphemeral(s, i, "Failed to save rank card settings.")
+		return
+	}
+
+	colorName := func(c *int, def int) string {
+		if c == nil {
+			return fmt.Sprintf("#%06X (default)", def)
+		}
+		return fmt.Sprintf("#%06X", *c)
+	}
+	accent := rankcard.ToInt(rankcard.DefaultAccent)
+	if settings.Accent != nil {
+		accent = *settings.Accent
+	}
+	respondEmbedEphemeral(s, i, &discordgo.MessageEmbed{
+		Title:       "Rank Card Updated",
+		Description: "Use `/rank` to see your new card.",
+		Color:       accent,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Background", Value: colorName(settings.Background, rankcard.ToInt(rankcard.DefaultBackground)), Inline: true},
+			{Name: "Accent", Value: colorName(settings.Accent, rankcard.ToInt(rankcard.DefaultAccent)), Inline: true},
+		},
+	})
 }
 
 func (ch *CommandHandler) setLevelHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -312,6 +393,8 @@ func (ch *CommandHandler) setLevelHandler(s *discordgo.Session, i *discordgo.Int
 		respondEphemeral(s, i, "Failed to set level.")
 		return
 	}
+	ch.bot.raiseAchievementStat(i.GuildID, user.ID, statLevel, int64(level), i.ChannelID)
+	ch.bot.applyLevelRanks(i.GuildID, user.ID, level)
 
 	embed := successEmbed("Level Set",
 		fmt.Sprintf("Set %s's level to **%d** (%d XP)", user.Mention(), level, xp))
@@ -338,6 +421,8 @@ func (ch *CommandHandler) setXPHandler(s *discordgo.Session, i *discordgo.Intera
 		respondEphemeral(s, i, "Failed to set XP.")
 		return
 	}
+	ch.bot.raiseAchievementStat(i.GuildID, user.ID, statLevel, int64(level), i.ChannelID)
+	ch.bot.applyLevelRanks(i.GuildID, user.ID, level)
 
 	embed := successEmbed("XP Set",
 		fmt.Sprintf("Set %s's XP to **%d** (Level %d)", user.Mention(), xp, level))
@@ -363,6 +448,8 @@ func (ch *CommandHandler) addXPHandler(s *discordgo.Session, i *discordgo.Intera
 		respondEphemeral(s, i, "Failed to add XP.")
 		return
 	}
+	ch.bot.raiseAchievementStat(i.GuildID, user.ID, statLevel, int64(xpData.Level), i.ChannelID)
+	ch.bot.applyLevelRanks(i.GuildID, user.ID, xpData.Level)
 
 	embed := successEmbed("XP Added",
 		fmt.Sprintf("Added **%d XP** to %s\nNew total: %d XP (Level %d)", amount, user.Mention(), xpData.XP, xpData.Level))
@@ -386,7 +473,7 @@ func (ch *CommandHandler) massAddXPHandler(s *discordgo.Session, i *discordgo.In
 	respondDeferred(s, i)
 
 	// Get all members with this role
-	members, err := s.GuildMembers(i.GuildID, "", 1000)
+	members, err := fetchGuildMembers(s, i.GuildID)
 	if err != nil {
 		followUp(s, i, "Failed to get server members.")
 		return
@@ -396,8 +483,10 @@ func (ch *CommandHandler) massAddXPHandler(s *discordgo.Session, i *discordgo.In
 	for _, member := range members {
 		for _, roleID := range member.Roles {
 			if roleID == role.ID {
-				_, err := ch.bot.DB.AddUserXP(i.GuildID, member.User.ID, amount)
+				xpData, err := ch.bot.DB.AddUserXP(i.GuildID, member.User.ID, amount)
 				if err == nil {
+					ch.bot.raiseAchievementStat(i.GuildID, member.User.ID, statLevel, int64(xpData.Level), "")
+					ch.bot.applyLevelRanks(i.GuildID, member.User.ID, xpData.Level)
 					count++
 				}
 				break
@@ -0,0 +1,336 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerXPBoostCommands() {
+	multiplierOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionNumber,
+		Name:        "multiplier",
+		Description: "XP multiplier (e.g., 1.5 for +50%, 0 for no XP)",
+		Required:    true,
+		MinValue:    floatPtr(0),
+		MaxValue:    10,
+	}
+
+	// Role and channel multipliers (Admin)
+	ch.Register(&Command{
+		Name:        "xpmultiplier",
+		Description: "Manage role and channel XP multipliers",
+		Category:    "XP",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "role",
+				Description: "Set the XP multiplier for a role (members get their highest role multiplier)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role",
+						Required:    true,
+					},
+					multiplierOption,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Set the XP multiplier for a channel or category",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel or category",
+						Required:    true,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+							discordgo.ChannelTypeGuildVoice,
+							discordgo.ChannelTypeGuildStageVoice,
+							discordgo.ChannelTypeGuildCategory,
+						},
+					},
+					multiplierOption,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a role or channel multiplier",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to remove the multiplier from",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to remove the multiplier from",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List XP multipliers and boosts",
+			},
+		},
+		Handler: ch.xpMultiplierHandler,
+	})
+
+	// Server-wide boost events (Admin)
+	ch.Register(&Command{
+		Name:        "xpboost",
+		Description: "Run server-wide XP boost events",
+		Category:    "XP",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "start",
+				Description: "Schedule an XP boost",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionNumber,
+						Name:        "multiplier",
+						Description: "XP multiplier (e.g., 2 for double XP)",
+						Required:    true,
+						MinValue:    floatPtr(1),
+						MaxValue:    10,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "duration",
+						Description: "How long the boost lasts (e.g., 2d, 12h)",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name of the event (e.g., Double XP Weekend)",
+						Required:    false,
+						MaxLength:   100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "starts_in",
+						Description: "Delay before the boost starts (e.g., 1h). Starts now by default",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "stop",
+				Description: "Cancel an XP boost",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "id",
+						Description: "Boost ID (from /xpadmin xpmultiplier list)",
+						Required:    true,
+					},
+				},
+			},
+		},
+		Handler: ch.xpBoostHandler,
+	})
+}
+
+func (ch *CommandHandler) xpMultiplierHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	subcommand := getSubcommandName(i)
+	if subcommand == "list" {
+		ch.xpMultiplierListHandler(s, i)
+		return
+	}
+
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage XP multipliers.")
+		return
+	}
+
+	switch subcommand {
+	case "role":
+		role := getRoleOption(i, "role")
+		multiplier := getNumberOption(i, "multiplier")
+		if err := ch.bot.DB.SetXPMultiplier(i.GuildID, "role", role.ID, multiplier); err != nil {
+			respondEphemeral(s, i, "Failed to set multiplier.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("XP Multiplier Set",
+			fmt.Sprintf("Members with %s now earn **%gx** XP", role.Mention(), multiplier)))
+
+	case "channel":
+		channel := getChannelOption(i, "channel")
+		multiplier := getNumberOption(i, "multiplier")
+		if err := ch.bot.DB.SetXPMultiplier(i.GuildID, "channel", channel.ID, multiplier); err != nil {
+			respondEphemeral(s, i, "Failed to set multiplier.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("XP Multiplier Set",
+			fmt.Sprintf("Activity in <#%s> now earns **%gx** XP", channel.ID, multiplier)))
+
+	case "remove":
+		targetType, targetID := "", ""
+		if role := getRoleOption(i, "role"); role != nil {
+			targetType, targetID = "role", role.ID
+		} else if channel := getChannelOption(i, "channel"); channel != nil {
+			targetType, targetID = "channel", channel.ID
+		} else {
+			respondEphemeral(s, i, "Please specify a role or channel.")
+			return
+		}
+		removed, err := ch.bot.DB.RemoveXPMultiplier(i.GuildID, targetType, targetID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to remove multiplier.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "That "+targetType+" has no XP multiplier.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("XP Multiplier Removed", "The multiplier has been removed"))
+	}
+}
+
+func (ch *CommandHandler) xpMultiplierListHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	multipliers, err := ch.bot.DB.GetXPMultipliers(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get XP multipliers.")
+		return
+	}
+	boosts, err := ch.bot.DB.GetXPBoosts(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get XP boosts.")
+		return
+	}
+
+	var roles, channels, events []string
+	for _, m := range multipliers {
+		switch m.TargetType {
+		case "role":
+			roles = append(roles, fmt.Sprintf("<@&%s> — **%gx**", m.TargetID, m.Multiplier))
+		case "channel":
+			channels = append(channels, fmt.Sprintf("<#%s> — **%gx**", m.TargetID, m.Multiplier))
+		}
+	}
+	now := time.Now().UnixMilli()
+	for _, b := range boosts {
+		name := b.Name
+		if name == "" {
+			name = "XP Boost"
+		}
+		when := fmt.Sprintf("ends <t:%d:R>", b.EndsAt/1000)
+		if b.StartsAt > now {
+			when = fmt.Sprintf("starts <t:%d:R>, ends <t:%d:f>", b.StartsAt/1000, b.EndsAt/1000)
+		}
+		events = append(events, fmt.Sprintf("`#%d` **%s** — **%gx**, %s", b.ID, name, b.Multiplier, when))
+	}
+
+	orNone := func(lines []string) string {
+		if len(lines) == 0 {
+			return "None"
+		}
+		return truncate(strings.Join(lines, "\n"), 1024)
+	}
+	respondEmbed(s, i, &discordgo.MessageEmbed{
+		Title:       "XP Multipliers",
+		Description: "Members get their highest role multiplier, combined with the channel multiplier and the strongest running boost.",
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Roles", Value: orNone(roles)},
+			{Name: "Channels", Value: orNone(channels)},
+			{Name: "Boosts", Value: orNone(events)},
+		},
+	})
+}
+
+func (ch *CommandHandler) xpBoostHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to manage XP boosts.")
+		return
+	}
+
+	switch getSubcommandName(i) {
+	case "start":
+		duration, err := parseDuration(getStringOption(i, "duration"))
+		if err != nil || duration <= 0 {
+			respondEphemeral(s, i, "Invalid duration. Use a format like `2d`, `12h` or `30m`.")
+			return
+		}
+		var delay time.Duration
+		if startsIn := getStringOption(i, "starts_in"); startsIn != "" {
+			if delay, err = parseDuration(startsIn); err != nil {
+				respondEphemeral(s, i, "Invalid start delay. Use a format like `1h` or `30m`.")
+				return
+			}
+		}
+
+		start := time.Now().Add(delay)
+		boost := &database.XPBoost{
+			GuildID:    i.GuildID,
+			Name:       getStringOption(i, "name"),
+			Multiplier: getNumberOption(i, "multiplier"),
+			StartsAt:   start.UnixMilli(),
+			EndsAt:     start.Add(duration).UnixMilli(),
+			CreatedBy:  i.Member.User.ID,
+		}
+		id, err := ch.bot.DB.CreateXPBoost(boost)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to create XP boost.")
+			return
+		}
+
+		name := boost.Name
+		if name == "" {
+			name = "XP Boost"
+		}
+		desc := fmt.Sprintf("**%gx XP** is active until <t:%s:f>", boost.Multiplier, formatUnixTime(start.Add(duration)))
+		if delay > 0 {
+			desc = fmt.Sprintf("**%gx XP** from <t:%s:f> until <t:%s:f>", boost.Multiplier,
+				formatUnixTime(start), formatUnixTime(start.Add(duration)))
+		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title:       "🚀 " + name,
+			Description: desc,
+			Color:       0x57F287,
+			Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Boost #%d", id)},
+		})
+
+	case "stop":
+		removed, err := ch.bot.DB.DeleteXPBoost(i.GuildID, getIntOption(i, "id"))
+		if err != nil {
+			respondEphemeral(s, i, "Failed to stop XP boost.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "XP boost not found.")
+			return
+		}
+		respondEmbed(s, i, successEmbed("XP Boost Stopped", "The boost has been cancelled"))
+	}
+}
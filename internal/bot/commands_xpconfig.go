@@ -0,0 +1,295 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+func (ch *CommandHandler) registerXPConfigCommands() {
+	targetOptions := func(action string) []*discordgo.ApplicationCommandOption {
+		return []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionChannel,
+				Name:        "channel",
+				Description: "Channel or category to " + action,
+				Required:    false,
+				ChannelTypes: []discordgo.ChannelType{
+					discordgo.ChannelTypeGuildText,
+					discordgo.ChannelTypeGuildNews,
+					discordgo.ChannelTypeGuildForum,
+					discordgo.ChannelTypeGuildCategory,
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionRole,
+				Name:        "role",
+				Description: "Role to " + action,
+				Required:    false,
+			},
+		}
+	}
+
+	// XP configuration (Admin)
+	ch.Register(&Command{
+		Name:        "xpconfig",
+		Description: "Configure how members earn XP",
+		Category:    "XP",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "ignore",
+				Description: "Stop a channel or role from earning XP",
+				Options:     targetOptions("exclude from XP"),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "unignore",
+				Description: "Let a channel or role earn XP again",
+				Options:     targetOptions("allow XP for again"),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "levelup",
+				Description: "Configure level-up announcements",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Announce level ups",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "destination",
+						Description: "Where to announce",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Channel where they leveled up", Value: "current"},
+							{Name: "Direct message", Value: "dm"},
+							{Name: "A fixed channel", Value: "channel"},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel for the fixed channel destination",
+						Required:    false,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "Message template: {user}, {username}, {level}, {server}. Use 'default' to reset",
+						Required:    false,
+						MaxLength:   1000,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "embed",
+						Description: "Send the message as an embed",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "every",
+						Description: "Only announce every N levels (levels with rank rewards are always announced)",
+						Required:    false,
+						MinValue:    floatPtr(1),
+						MaxValue:    100,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "View XP settings",
+			},
+		},
+		Handler: ch.xpConfigHandler,
+	})
+}
+
+func (ch *CommandHandler) xpConfigHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need administrator permission to configure XP.")
+		return
+	}
+
+	switch subcommand := getSubcommandName(i); subcommand {
+	case "ignore", "unignore":
+		var targets [][2]string
+		if channel := getChannelOption(i, "channel"); channel != nil {
+			targets = append(targets, [2]string{"channel", channel.ID})
+		}
+		if role := getRoleOption(i, "role"); role != nil {
+			targets = append(targets, [2]string{"role", role.ID})
+		}
+		if len(targets) == 0 {
+			respondEphemeral(s, i, "Please specify a channel or role.")
+			return
+		}
+
+		var changed []string
+		for _, t := range targets {
+			mention := fmt.Sprintf("<#%s>", t[1])
+			if t[0] == "role" {
+				mention = fmt.Sprintf("<@&%s>", t[1])
+			}
+			if subcommand == "ignore" {
+				if err := ch.bot.DB.AddXPExclusion(i.GuildID, t[0], t[1]); err != nil {
+					respondEphemeral(s, i, "Failed to update XP exclusions.")
+					return
+				}
+				changed = append(changed, mention)
+				continue
+			}
+			removed, err := ch.bot.DB.RemoveXPExclusion(i.GuildID, t[0], t[1])
+			if err != nil {
+				respondEphemeral(s, i, "Failed to update XP exclusions.")
+				return
+			}
+			if removed {
+				changed = append(changed, mention)
+			}
+		}
+
+		if subcommand == "ignore" {
+			respondEmbed(s, i, successEmbed("XP Disabled", strings.Join(changed, ", ")+" will no longer earn XP"))
+		} else if len(changed) == 0 {
+			respondEphemeral(s, i, "That channel or role wasn't excluded from XP.")
+		} else {
+			respondEmbed(s, i, successEmbed("XP Enabled", strings.Join(changed, ", ")+" can earn XP again"))
+		}
+
+	case "levelup":
+		cfg, err := ch.bot.DB.GetLevelUpConfig(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get level-up settings.")
+			return
+		}
+		for _, opt := range getOptions(i) {
+			switch opt.Name {
+			case "enabled":
+				cfg.Enabled = opt.BoolValue()
+			case "destination":
+				cfg.Destination = opt.StringValue()
+			case "channel":
+				cfg.ChannelID = opt.ChannelValue(nil).ID
+			case "message":
+				cfg.Message = opt.StringValue()
+				if strings.EqualFold(cfg.Message, "default") {
+					cfg.Message = ""
+				}
+			case "embed":
+				cfg.UseEmbed = opt.BoolValue()
+			case "every":
+				cfg.EveryLevels = int(opt.IntValue())
+			}
+		}
+		if cfg.Destination == "channel" && cfg.ChannelID == "" {
+			respondEphemeral(s, i, "Please specify a channel for the fixed channel destination.")
+			return
+		}
+		if err := ch.bot.DB.SetLevelUpConfig(cfg); err != nil {
+			respondEphemeral(s, i, "Failed to save level-up settings.")
+			return
+		}
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title:  "Level-Up Announcements Updated",
+			Color:  0x57F287,
+			Fields: levelUpFields(cfg),
+		})
+
+	case "show":
+		exclusions, err := ch.bot.DB.GetXPExclusions(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get XP settings.")
+			return
+		}
+		levelUp, err := ch.bot.DB.GetLevelUpConfig(i.GuildID)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get XP settings.")
+			return
+		}
+
+		var channels, roles []string
+		for _, e := range exclusions {
+			if e.TargetType == "role" {
+				roles = append(roles, fmt.Sprintf("<@&%s>", e.TargetID))
+			} else {
+				channels = append(channels, fmt.Sprintf("<#%s>", e.TargetID))
+			}
+		}
+		orNone := func(items []string) string {
+			if len(items) == 0 {
+				return "None"
+			}
+			return truncate(strings.Join(items, ", "), 1024)
+		}
+
+		respondEmbed(s, i, &discordgo.MessageEmbed{
+			Title: "XP Settings",
+			Color: 0x5865F2,
+			Fields: append([]*discordgo.MessageEmbedField{
+				{Name: "No-XP Channels", Value: orNone(channels)},
+				{Name: "No-XP Roles", Value: orNone(roles)},
+			}, levelUpFields(levelUp)...),
+			Footer: &discordgo.MessageEmbedFooter{Text: "Excluding a category covers every channel in it"},
+		})
+	}
+}
+
+// levelUpFields describes the level-up announcement settings
+func levelUpFields(cfg *database.LevelUpConfig) []*discordgo.MessageEmbedField {
+	statusEmoji := func(enabled bool) string {
+		if enabled {
+			return ":white_check_mark:"
+		}
+		return ":x:"
+	}
+
+	destination := "Channel where they leveled up"
+	switch cfg.Destination {
+	case "dm":
+		destination = "Direct message"
+	case "channel":
+		destination = fmt.Sprintf("<#%s>", cfg.ChannelID)
+	}
+	every := "Every level"
+	if cfg.EveryLevels > 1 {
+		every = fmt.Sprintf("Every %d levels", cfg.EveryLevels)
+	}
+	message := cfg.Message
+	if message == "" {
+		message = defaultLevelUpMessage
+	}
+
+	return []*discordgo.MessageEmbedField{
+		{Name: "Level-Up Announcements", Value: statusEmoji(cfg.Enabled), Inline: true},
+		{Name: "Destination", Value: destination, Inline: true},
+		{Name: "Frequency", Value: every, Inline: true},
+		{Name: "Embed", Value: statusEmoji(cfg.UseEmbed), Inline: true},
+		{Name: "Message", Value: truncate("`"+message+"`", 1024)},
+	}
+}
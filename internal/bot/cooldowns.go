@@ -0,0 +1,75 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/cache"
+)
+
+// sharedCooldowns counts cooldowns in Redis so they hold across bot processes, nil when Redis is off
+var sharedCooldowns *cache.Cache
+
+// cooldownTracker rate limits arbitrary keys, such as a member using a custom command
+type cooldownTracker struct {
+	name    string // Namespace for the shared Redis keys
+	mu      sync.Mutex
+	expires map[string]time.Time // key -> when its cooldown ends
+}
+
+func newCooldownTracker(name string) *cooldownTracker {
+	return &cooldownTracker{name: name, expires: make(map[string]time.Time)}
+}
+
+// take starts key's cooldown and returns zero, or if it's still cooling down, how long is left
+func (t *cooldownTracker) take(key string, cooldown time.Duration) time.Duration {
+	if cooldown <= 0 {
+		return 0
+	}
+
+	if sharedCooldowns != nil {
+		left, err := sharedCooldowns.Take("cooldown:"+t.name+":"+key, cooldown)
+		if err == nil {
+			return left
+		}
+		// Fall back to this process's own count while Redis is unreachable
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if left := t.expires[key].Sub(now); left > 0 {
+		return left
+	}
+
+	// Forget finished cooldowns now and then so the map doesn't grow forever
+	if len(t.expires) > 1000 {
+		for k, end := range t.expires {
+			if !end.After(now) {
+				delete(t.expires, k)
+			}
+		}
+	}
+	t.expires[key] = now.Add(cooldown)
+	return 0
+}
+
+// Custom command cooldowns, keyed by guildID:command:userID
+var customCommandCooldowns = newCooldownTracker("customcmd")
@@ -0,0 +1,64 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Daily streak bonus per consecutive day, capped at maxStreakBonusDays
+const (
+	streakBonusPercent = 10
+	maxStreakBonusDays = 7
+)
+
+var workMessages = []string{
+	"You worked a shift at the café and earned %s.",
+	"You fixed a neighbour's computer and earned %s.",
+	"You walked some very good dogs and earned %s.",
+	"You streamed for a few hours and earned %s.",
+	"You helped moderate a busy server and earned %s.",
+	"You delivered pizzas around town and earned %s.",
+	"You sold some handmade stickers and earned %s.",
+}
+
+// formatCurrency formats an amount with the guild's currency symbol and name
+func formatCurrency(cfg *database.EconomyConfig, amount int64) string {
+	return fmt.Sprintf("%s **%s** %s", cfg.CurrencySymbol, formatNumberInt(int(amount)), cfg.CurrencyName)
+}
+
+// dailyReward returns the daily payout for a member on the given streak
+func dailyReward(cfg *database.EconomyConfig, streak int) int64 {
+	bonusDays := min(max(streak-1, 0), maxStreakBonusDays)
+	return cfg.DailyAmount + cfg.DailyAmount*int64(bonusDays*streakBonusPercent)/100
+}
+
+// awardChatCurrency pays members for chatting, limited by the guild's chat cooldown
+func (b *Bot) awardChatCurrency(m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	cfg, err := b.DB.GetEconomyConfig(m.GuildID)
+	if err != nil || !cfg.Enabled || cfg.ChatAmount <= 0 {
+		return
+	}
+	b.DB.ClaimReward(m.GuildID, m.Author.ID, "chat", cfg.ChatAmount, time.Duration(cfg.ChatCooldownSecs)*time.Second)
+}
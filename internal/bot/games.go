@@ -0,0 +1,181 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	gameCounting  = "counting"
+	gameWordChain = "wordchain"
+)
+
+// gameMu serializes moves so fast typers can't race each other
+var gameMu sync.Mutex
+
+// handleGameMessage processes messages in counting and word-chain channels.
+// It returns true if the message belonged to a game channel.
+func (b *Bot) handleGameMessage(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if m.GuildID == "" {
+		return false
+	}
+	if g, err := b.DB.GetGameChannel(m.ChannelID); err != nil || g == nil {
+		return false
+	}
+
+	gameMu.Lock()
+	defer gameMu.Unlock()
+
+	// Re-read under the lock so every move sees the previous one
+	g, err := b.DB.GetGameChannel(m.ChannelID)
+	if err != nil || g == nil {
+		return false
+	}
+
+	switch g.GameType {
+	case gameCounting:
+		b.playCounting(s, m, g)
+	case gameWordChain:
+		b.playWordChain(s, m, g)
+	}
+	return true
+}
+
+func (b *Bot) playCounting(s *discordgo.Session, m *discordgo.MessageCreate, g *database.GameChannel) {
+	fields := strings.Fields(m.Content)
+	if len(fields) == 0 {
+		b.ignoreGameMessage(s, m, g)
+		return
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		b.ignoreGameMessage(s, m, g)
+		return
+	}
+
+	if !g.AllowConsecutive && m.Author.ID == g.LastUserID {
+		b.failGame(s, m, g, "You can't count twice in a row.")
+		return
+	}
+	if n != g.Count+1 {
+		b.failGame(s, m, g, fmt.Sprintf("The next number was **%d**.", g.Count+1))
+		return
+	}
+
+	if err := b.DB.AdvanceGame(g.ChannelID, m.Author.ID, "", n); err != nil {
+		return
+	}
+	b.reactGameSuccess(s, m, g, n)
+}
+
+func (b *Bot) playWordChain(s *discordgo.Session, m *discordgo.MessageCreate, g *database.GameChannel) {
+	word := strings.ToLower(strings.TrimSpace(m.Content))
+	if !isGameWord(word) {
+		b.ignoreGameMessage(s, m, g)
+		return
+	}
+
+	if !g.AllowConsecutive && m.Author.ID == g.LastUserID {
+		b.failGame(s, m, g, "You can't play twice in a row.")
+		return
+	}
+	if g.LastWord != "" {
+		last, _ := utf8.DecodeLastRuneInString(g.LastWord)
+		first, _ := utf8.DecodeRuneInString(word)
+		if first != last {
+			b.failGame(s, m, g, fmt.Sprintf("The word had to start with **%c**.", last))
+			return
+		}
+	}
+	if used, err := b.DB.IsGameWordUsed(g.ChannelID, word); err != nil {
+		return
+	} else if used {
+		b.failGame(s, m, g, fmt.Sprintf("**%s** has already been used in this chain.", word))
+		return
+	}
+
+	if err := b.DB.AdvanceGame(g.ChannelID, m.Author.ID, word, g.Count+1); err != nil {
+		return
+	}
+	b.reactGameSuccess(s, m, g, g.Count+1)
+}
+
+// isGameWord reports whether s is a single word made only of letters
+func isGameWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoreGameMessage handles chatter in a game channel, deleting it in strict mode
+func (b *Bot) ignoreGameMessage(s *discordgo.Session, m *discordgo.MessageCreate, g *database.GameChannel) {
+	if g.Strict {
+		s.ChannelMessageDelete(m.ChannelID, m.ID)
+	}
+}
+
+func (b *Bot) reactGameSuccess(s *discordgo.Session, m *discordgo.MessageCreate, g *database.GameChannel, score int) {
+	if score > g.HighScore && g.HighScore > 0 {
+		s.MessageReactionAdd(m.ChannelID, m.ID, "🏆")
+		return
+	}
+	s.MessageReactionAdd(m.ChannelID, m.ID, "✅")
+}
+
+// failGame resets the game after a wrong move
+func (b *Bot) failGame(s *discordgo.Session, m *discordgo.MessageCreate, g *database.GameChannel, reason string) {
+	s.MessageReactionAdd(m.ChannelID, m.ID, "❌")
+
+	if g.Count == 0 {
+		// Nothing to ruin yet
+		if g.GameType == gameCounting {
+			s.ChannelMessageSend(m.ChannelID, "The count starts at **1**.")
+		}
+		return
+	}
+
+	if err := b.DB.ResetGame(g.ChannelID); err != nil {
+		return
+	}
+
+	highScore := g.HighScore
+	if g.Count > highScore {
+		highScore = g.Count
+	}
+	var next string
+	if g.GameType == gameCounting {
+		next = fmt.Sprintf("<@%s> ruined it at **%d**! %s The next number is **1**.", m.Author.ID, g.Count, reason)
+	} else {
+		next = fmt.Sprintf("<@%s> broke the chain at **%d** word(s)! %s Start a new chain with any word.", m.Author.ID, g.Count, reason)
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("%s\nHigh score: **%d**", next, highScore))
+}
@@ -0,0 +1,203 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// greetingFiller fills in the placeholders of welcome, leave and join DM messages
+func greetingFiller(s *discordgo.Session, guildID string, user *discordgo.User, inviterID, inviteCode string) func(string) string {
+	return func(text string) string {
+		text = replacePlaceholders(text, user, guildID)
+		if strings.Contains(text, "{membercount}") {
+			count := "unknown"
+			if guild, err := s.State.Guild(guildID); err == nil {
+				count = strconv.Itoa(guild.MemberCount)
+			}
+			text = strings.ReplaceAll(text, "{membercount}", count)
+		}
+		return replaceInvitePlaceholders(text, s, inviterID, inviteCode)
+	}
+}
+
+// leaveFiller adds the leave-only placeholders: how long the member stayed and, for
+// kicks and bans, who removed them and why
+func (b *Bot) leaveFiller(s *discordgo.Session, guildID string, user *discordgo.User, entry *discordgo.AuditLogEntry) func(string) string {
+	fill := greetingFiller(s, guildID, user, "", "")
+	return func(text string) string {
+		text = fill(text)
+		if strings.Contains(text, "{duration}") {
+			// Joins are only on record when the bot saw them happen
+			duration := "an unknown time"
+			if join, err := b.DB.GetInviteJoin(guildID, user.ID); err == nil && join != nil {
+				duration = formatDuration(time.Since(join.JoinedAt))
+			}
+			text = strings.ReplaceAll(text, "{duration}", duration)
+		}
+
+		moderator, reason := "Unknown", "No reason given"
+		if entry != nil {
+			if entry.UserID != "" {
+				moderator = "<@" + entry.UserID + ">"
+			}
+			if entry.Reason != "" {
+				reason = entry.Reason
+			}
+		}
+		text = strings.ReplaceAll(text, "{moderator}", moderator)
+		return strings.ReplaceAll(text, "{reason}", reason)
+	}
+}
+
+// welcomeMessage builds the welcome message for a member, with their welcome card
+// attached when the guild has cards turned on. Returns nil if there's nothing to send.
+func (b *Bot) welcomeMessage(s *discordgo.Session, guildID string, user *discordgo.User, settings *database.GuildSettings, inviterID, inviteCode string) *discordgo.MessageSend {
+	fill := greetingFiller(s, guildID, user, inviterID, inviteCode)
+
+	msg := &discordgo.MessageSend{}
+	if settings.WelcomeMessage != nil {
+		msg.Content = fill(*settings.WelcomeMessage)
+	}
+	var embed *discordgo.MessageEmbed
+	if greeting, err := b.DB.GetGreetingMessage(guildID, database.GreetingWelcome); err == nil {
+		if embed = fillEmbed(greeting.Embed, fill); embed != nil {
+			msg.Embeds = []*discordgo.MessageEmbed{embed}
+		}
+	}
+
+	if cardSettings, err := b.DB.GetWelcomeCardSettings(guildID); err == nil && cardSettings.Enabled {
+		memberNumber := 0
+		if guild, err := s.State.Guild(guildID); err == nil {
+			memberNumber = guild.MemberCount
+		}
+		if card, err := b.renderWelcomeCard(cardSettings, user, memberNumber); err == nil {
+			msg.Files = []*discordgo.File{{Name: "welcome.png", ContentType: "image/png", Reader: card}}
+			// The card goes inside the embed unless the embed already has a picture
+			if embed != nil && (embed.Image == nil || embed.Image.URL == "") {
+				embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://welcome.png"}
+			}
+		}
+	}
+
+	if msg.Content == "" && len(msg.Embeds) == 0 && len(msg.Files) == 0 {
+		return nil
+	}
+	return msg
+}
+
+// leaveMessage builds the leave message for a member, nil if leave messages are off.
+// kind picks the kick or ban variant, which falls back to the plain leave message when
+// it isn't set up; entry is the audit log entry for the kick or ban, if known.
+func (b *Bot) leaveMessage(s *discordgo.Session, guildID string, user *discordgo.User, kind string, entry *discordgo.AuditLogEntry) (channelID string, msg *discordgo.MessageSend) {
+	greeting, err := b.DB.GetGreetingMessage(guildID, database.GreetingLeave)
+	if err != nil || greeting.ChannelID == "" {
+		return "", nil
+	}
+	if kind != database.GreetingLeave {
+		if variant, err := b.DB.GetGreetingMessage(guildID, kind); err == nil && (variant.Content != "" || variant.Embed != "") {
+			greeting.Content, greeting.Embed = variant.Content, variant.Embed
+		}
+	}
+	fill := b.leaveFiller(s, guildID, user, entry)
+
+	msg = &discordgo.MessageSend{Content: fill(greeting.Content)}
+	if embed := fillEmbed(greeting.Embed, fill); embed != nil {
+		msg.Embeds = []*discordgo.MessageEmbed{embed}
+	}
+	if msg.Content == "" && len(msg.Embeds) == 0 {
+		return "", nil
+	}
+	return greeting.ChannelID, msg
+}
+
+// joinDMEmbed builds the DM sent to a new member. A saved embed replaces the plain
+// title and message. Returns nil if join DMs are off.
+func (b *Bot) joinDMEmbed(s *discordgo.Session, guildID string, user *discordgo.User, settings *database.GuildSettings, inviterID, inviteCode string) *discordgo.MessageEmbed {
+	fill := greetingFiller(s, guildID, user, inviterID, inviteCode)
+
+	if greeting, err := b.DB.GetGreetingMessage(guildID, database.GreetingJoinDM); err == nil {
+		if embed := fillEmbed(greeting.Embed, fill); embed != nil {
+			return embed
+		}
+	}
+
+	if settings.JoinDMTitle == nil && settings.JoinDMMessage == nil {
+		return nil
+	}
+	embed := &discordgo.MessageEmbed{
+		Color: 0xFF69B4,
+	}
+	if settings.JoinDMTitle != nil {
+		embed.Title = fill(*settings.JoinDMTitle)
+	}
+	if settings.JoinDMMessage != nil {
+		embed.Description = fill(*settings.JoinDMMessage)
+	}
+	return embed
+}
+
+// sendWelcome posts the welcome message for a member who just joined
+func (b *Bot) sendWelcome(s *discordgo.Session, guildID string, user *discordgo.User, settings *database.GuildSettings, inviterID, inviteCode string) {
+	if settings.WelcomeChannel == nil {
+		return
+	}
+	if msg := b.welcomeMessage(s, guildID, user, settings, inviterID, inviteCode); msg != nil {
+		s.ChannelMessageSendComplex(*settings.WelcomeChannel, msg)
+	}
+}
+
+// sendLeave posts the leave message for a member who left or was removed. kick is the
+// audit log entry if they were kicked; otherwise bans are looked up only when the guild
+// has a ban message, to save an audit log request on every leave.
+func (b *Bot) sendLeave(s *discordgo.Session, guildID string, user *discordgo.User, kick *discordgo.AuditLogEntry) {
+	leave, err := b.DB.GetGreetingMessage(guildID, database.GreetingLeave)
+	if err != nil || leave.ChannelID == "" {
+		return
+	}
+
+	kind, entry := database.GreetingLeave, kick
+	if kick != nil {
+		kind = database.GreetingLeaveKick
+	} else if ban, err := b.DB.GetGreetingMessage(guildID, database.GreetingLeaveBan); err == nil && (ban.Content != "" || ban.Embed != "") {
+		if entry = findAuditEntry(s, guildID, user.ID, discordgo.AuditLogActionMemberBanAdd, auditLogMaxAge); entry != nil {
+			kind = database.GreetingLeaveBan
+		}
+	}
+
+	if channelID, msg := b.leaveMessage(s, guildID, user, kind, entry); msg != nil {
+		s.ChannelMessageSendComplex(channelID, msg)
+	}
+}
+
+// sendJoinDM sends the join DM to a member who just joined
+func (b *Bot) sendJoinDM(s *discordgo.Session, guildID string, user *discordgo.User, settings *database.GuildSettings, inviterID, inviteCode string) {
+	embed := b.joinDMEmbed(s, guildID, user, settings, inviterID, inviteCode)
+	if embed == nil {
+		return
+	}
+	channel, err := s.UserChannelCreate(user.ID)
+	if err != nil {
+		return
+	}
+	s.ChannelMessageSendEmbed(channel.ID, embed)
+}
@@ -0,0 +1,210 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Scheduled event type that posts a reminder before a Discord event starts
+const eventGuildEventReminder = "eventreminder"
+
+// Maximum interested members mentioned in a reminder
+const maxReminderMentions = 50
+
+// guildEventURL links to a Discord event
+func guildEventURL(ev *discordgo.GuildScheduledEvent) string {
+	return fmt.Sprintf("https://discord.com/events/%s/%s", ev.GuildID, ev.ID)
+}
+
+// guildEventLocation describes where an event takes place
+func guildEventLocation(ev *discordgo.GuildScheduledEvent) string {
+	if ev.EntityType == discordgo.GuildScheduledEventEntityTypeExternal {
+		return ev.EntityMetadata.Location
+	}
+	return "<#" + ev.ChannelID + ">"
+}
+
+// guildEventEmbed builds the announcement embed for an event
+func guildEventEmbed(ev *discordgo.GuildScheduledEvent) *discordgo.MessageEmbed {
+	start := formatUnixTime(ev.ScheduledStartTime)
+	embed := &discordgo.MessageEmbed{
+		Title:       "📅 " + ev.Name,
+		URL:         guildEventURL(ev),
+		Description: truncate(ev.Description, 2048),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Starts", Value: fmt.Sprintf("<t:%s:F> (<t:%s:R>)", start, start)},
+		},
+	}
+	if ev.ScheduledEndTime != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Ends", Value: fmt.Sprintf("<t:%s:F>", formatUnixTime(*ev.ScheduledEndTime)),
+		})
+	}
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{Name: "Location", Value: guildEventLocation(ev), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Interested", Value: fmt.Sprintf("%d", ev.UserCount), Inline: true},
+	)
+	if ev.Creator != nil {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Hosted by " + ev.Creator.Username}
+	}
+
+	switch ev.Status {
+	case discordgo.GuildScheduledEventStatusActive:
+		embed.Title = "🔴 Live: " + ev.Name
+		embed.Color = 0x57F287
+	case discordgo.GuildScheduledEventStatusCompleted:
+		embed.Title = "[Ended] " + ev.Name
+		embed.Color = 0x99AAB5
+	case discordgo.GuildScheduledEventStatusCanceled:
+		embed.Title = "[Cancelled] " + ev.Name
+		embed.Color = 0xED4245
+	}
+	return embed
+}
+
+// scheduleGuildEventReminder (re)schedules the reminder for an event
+func (b *Bot) scheduleGuildEventReminder(cfg *database.GuildEventConfig, ev *discordgo.GuildScheduledEvent) {
+	b.DB.DeleteScheduledEventByTarget(ev.GuildID, eventGuildEventReminder, ev.ID)
+	if cfg.ReminderMinutes <= 0 || ev.Status != discordgo.GuildScheduledEventStatusScheduled {
+		return
+	}
+	remindAt := ev.ScheduledStartTime.Add(-time.Duration(cfg.ReminderMinutes) * time.Minute)
+	if remindAt.Before(time.Now()) {
+		return
+	}
+	b.DB.AddScheduledEvent(ev.GuildID, eventGuildEventReminder, ev.ID, remindAt.UnixMilli())
+}
+
+// refreshGuildEventAnnouncement updates an event's announcement with its current state
+func (b *Bot) refreshGuildEventAnnouncement(s *discordgo.Session, ev *discordgo.GuildScheduledEvent) {
+	announcement, err := b.DB.GetGuildEventAnnouncement(ev.ID)
+	if err != nil || announcement == nil {
+		return
+	}
+	// Gateway payloads don't include the interested count
+	if full, err := s.GuildScheduledEvent(ev.GuildID, ev.ID, true); err == nil {
+		ev = full
+	}
+	s.ChannelMessageEditEmbed(announcement.ChannelID, announcement.MessageID, guildEventEmbed(ev))
+}
+
+func (b *Bot) onGuildScheduledEventCreate(s *discordgo.Session, e *discordgo.GuildScheduledEventCreate) {
+	cfg, err := b.DB.GetGuildEventConfig(e.GuildID)
+	if err != nil || !cfg.Enabled {
+		return
+	}
+	b.scheduleGuildEventReminder(cfg, e.GuildScheduledEvent)
+
+	if cfg.ChannelID == "" {
+		return
+	}
+	msg := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{guildEventEmbed(e.GuildScheduledEvent)},
+	}
+	if cfg.PingRoleID != "" {
+		msg.Content = "<@&" + cfg.PingRoleID + ">"
+		msg.AllowedMentions = &discordgo.MessageAllowedMentions{Roles: []string{cfg.PingRoleID}}
+	}
+	sent, err := s.ChannelMessageSendComplex(cfg.ChannelID, msg)
+	if err != nil {
+		return
+	}
+	b.DB.SetGuildEventAnnouncement(&database.GuildEventAnnouncement{
+		EventID:   e.ID,
+		GuildID:   e.GuildID,
+		ChannelID: cfg.ChannelID,
+		MessageID: sent.ID,
+	})
+}
+
+func (b *Bot) onGuildScheduledEventUpdate(s *discordgo.Session, e *discordgo.GuildScheduledEventUpdate) {
+	cfg, err := b.DB.GetGuildEventConfig(e.GuildID)
+	if err != nil {
+		return
+	}
+	b.scheduleGuildEventReminder(cfg, e.GuildScheduledEvent)
+	b.refreshGuildEventAnnouncement(s, e.GuildScheduledEvent)
+
+	if e.Status == discordgo.GuildScheduledEventStatusCompleted || e.Status == discordgo.GuildScheduledEventStatusCanceled {
+		b.DB.DeleteGuildEventData(e.ID)
+	}
+}
+
+func (b *Bot) onGuildScheduledEventDelete(s *discordgo.Session, e *discordgo.GuildScheduledEventDelete) {
+	b.DB.DeleteScheduledEventByTarget(e.GuildID, eventGuildEventReminder, e.ID)
+
+	// Deleting a scheduled event cancels it
+	if announcement, err := b.DB.GetGuildEventAnnouncement(e.ID); err == nil && announcement != nil {
+		ev := *e.GuildScheduledEvent
+		ev.Status = discordgo.GuildScheduledEventStatusCanceled
+		if users, err := b.DB.GetGuildEventRSVPs(e.ID); err == nil {
+			ev.UserCount = len(users)
+		}
+		s.ChannelMessageEditEmbed(announcement.ChannelID, announcement.MessageID, guildEventEmbed(&ev))
+	}
+	b.DB.DeleteGuildEventData(e.ID)
+}
+
+func (b *Bot) onGuildScheduledEventUserAdd(s *discordgo.Session, e *discordgo.GuildScheduledEventUserAdd) {
+	b.DB.AddGuildEventRSVP(e.GuildID, e.GuildScheduledEventID, e.UserID)
+	b.refreshGuildEventAnnouncement(s, &discordgo.GuildScheduledEvent{ID: e.GuildScheduledEventID, GuildID: e.GuildID})
+}
+
+func (b *Bot) onGuildScheduledEventUserRemove(s *discordgo.Session, e *discordgo.GuildScheduledEventUserRemove) {
+	b.DB.RemoveGuildEventRSVP(e.GuildScheduledEventID, e.UserID)
+	b.refreshGuildEventAnnouncement(s, &discordgo.GuildScheduledEvent{ID: e.GuildScheduledEventID, GuildID: e.GuildID})
+}
+
+// sendGuildEventReminder posts a reminder for an upcoming event, pinging interested members
+func (b *Bot) sendGuildEventReminder(guildID, eventID string) {
+	cfg, err := b.DB.GetGuildEventConfig(guildID)
+	if err != nil || !cfg.Enabled || cfg.ChannelID == "" {
+		return
+	}
+	ev, err := b.Session.GuildScheduledEvent(guildID, eventID, true)
+	if err != nil || ev.Status != discordgo.GuildScheduledEventStatusScheduled {
+		return
+	}
+
+	users, _ := b.DB.GetGuildEventRSVPs(eventID)
+	if len(users) > maxReminderMentions {
+		users = users[:maxReminderMentions]
+	}
+	mentions := make([]string, len(users))
+	for idx, userID := range users {
+		mentions[idx] = "<@" + userID + ">"
+	}
+
+	content := fmt.Sprintf("⏰ **%s** starts <t:%s:R>!", ev.Name, formatUnixTime(ev.ScheduledStartTime))
+	if len(mentions) > 0 {
+		content += "\n" + strings.Join(mentions, " ")
+	}
+	b.Session.ChannelMessageSendComplex(cfg.ChannelID, &discordgo.MessageSend{
+		Content: content,
+		Embeds:  []*discordgo.MessageEmbed{guildEventEmbed(ev)},
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Users: users,
+		},
+	})
+}
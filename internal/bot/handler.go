@@ -17,15 +17,73 @@
 package bot
 
 import (
+	"fmt"
 	"log"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+const (
+	// Discord allows at most 100 global chat input commands per application
+	maxGlobalCommands = 100
+
+	// Most subcommands and subcommand groups one slash command can have
+	maxSubcommands = 25
+
+	// Discord caps the combined length of a command's names, descriptions and choices
+	maxCommandChars = 8000
+)
+
+// slashGroup nests related commands under one slash command
+type slashGroup struct {
+	Description string
+	Commands    []string
+}
+
+// slashGroups keeps the slash command list under Discord's limit by nesting families of
+// commands, e.g. /logging setlogchannel. Prefix commands keep their own names. Commands in
+// prefix-only categories are registered as slash commands once they're in a group.
+var slashGroups = map[string]slashGroup{
+	"logging":       {"Configure message, member and voice logging", []string{"setlogchannel", "togglelogging", "logconfig", "disablechannellog", "enablechannellog", "logstatus", "logsearch"}},
+	"filters":       {"Manage word filters", []string{"addfilter", "removefilter", "listfilters", "testfilter"}},
+	"ranks":         {"Manage level rank rewards", []string{"addrank", "removerank", "listranks", "syncranks", "rankconfig", "applyranks"}},
+	"dm":            {"Configure DM forwarding", []string{"setdmchannel", "disabledm", "dmstatus"}},
+	"botbans":       {"Ban users or servers from using the bot", []string{"botban", "botunban", "botbanlist"}},
+	"banlist":       {"Export, import and scan the server ban list", []string{"exportbans", "importbans", "scanbans"}},
+	"tickets":       {"Configure the ticket system", []string{"setticket", "disableticket", "ticketstatus", "ticketpanel"}},
+	"raid":          {"Anti-raid protection, silencing and lockdowns", []string{"antiraid", "silence", "unsilence", "getraid", "banraid", "lockdown", "blocklist"}},
+	"automod":       {"Configure anti-spam, the spam filter and AI moderation", []string{"antispam", "spamfilter", "aimod"}},
+	"config":        {"Server settings, welcomes and stats channels", []string{"setprefix", "setmodlog", "setwelcome", "welcomecard", "welcome", "disablewelcome", "settings", "setjoindm", "disablejoindm", "statschannels"}},
+	"automation":    {"Configure auto-clean and auto-threads", []string{"autoclean", "setcleanmessage", "setcleanimage", "autothread"}},
+	"xpadmin":       {"Manage XP, boosts, seasons and voice XP", []string{"setlevel", "setxp", "addxp", "massaddxp", "xpmultiplier", "xpboost", "xpconfig", "season", "voicexp"}},
+	"channel":       {"Lock, unlock, sync and clone channels", []string{"lock", "unlock", "chanlockdown", "chanunlock", "syncperms", "clonechannel"}},
+	"music":         {"Play music and control playback", []string{"seek", "forward", "rewind", "replay", "lyrics", "tts", "shuffle", "skipto", "playnext", "play", "skip", "stop", "pause", "resume", "queue", "nowplaying", "remove", "clear", "movetop", "volume", "loop", "join", "leave"}},
+	"musiclibrary":  {"Saved playlists, radio stations and the local music library", []string{"playlist", "radio", "library", "local", "files", "folders", "search", "musichistory"}},
+	"musicsettings": {"Configure music playback, permissions and text-to-speech", []string{"ttsconfig", "247", "autoplay", "filter", "musicconfig", "normalize", "sponsorblock", "musicrole", "musicfolder"}},
+}
+
+// slashGroupOf maps a command name to the slash group it's nested under
+var slashGroupOf = func() map[string]string {
+	groups := make(map[string]string)
+	for group, g := range slashGroups {
+		for _, name := range g.Commands {
+			groups[name] = group
+		}
+	}
+	return groups
+}()
+
+// ComponentHandler handles a button, select menu or modal submit interaction
+type ComponentHandler func(s *discordgo.Session, i *discordgo.InteractionCreate)
+
 type CommandHandler struct {
-	bot      *Bot
-	commands map[string]*Command
+	bot        *Bot
+	commands   map[string]*Command
+	components map[string]ComponentHandler // Keyed by custom ID prefix
 }
 
 type Command struct {
@@ -38,6 +96,37 @@ type Command struct {
 	PrefixHandler func(ctx *PrefixContext) // Handler for prefix-based commands
 	SlashOnly     bool                     // If true, only register as slash command (default behavior for essential commands)
 	PrefixOnly    bool                     // If true, only available via prefix (not registered as slash command)
+	HomeGuildOnly bool                     // If true, only registered in the configured home guild
+
+	// Type is zero for slash commands, or discordgo.MessageApplicationCommand /
+	// discordgo.UserApplicationCommand for a context menu entry. Context menu
+	// entries take no options and their Description is not sent to Discord.
+	Type discordgo.ApplicationCommandType
+}
+
+// isSlash reports whether the command is registered with Discord, directly or under a slash group
+func (cmd *Command) isSlash() bool {
+	if cmd.PrefixOnly {
+		return false
+	}
+	return cmd.SlashOnly || !prefixOnlyCategories[cmd.Category] || slashGroupOf[cmd.Name] != ""
+}
+
+// isPrefix reports whether the command can be used with the prefix. Commands in prefix-only
+// categories that were moved under a slash group stay prefix commands only if they have a handler.
+func (cmd *Command) isPrefix() bool {
+	if cmd.PrefixOnly {
+		return true
+	}
+	return prefixOnlyCategories[cmd.Category] && (!cmd.isSlash() || cmd.PrefixHandler != nil)
+}
+
+// SlashName is how the command is typed as a slash command, including its group if it has one
+func (cmd *Command) SlashName() string {
+	if group := slashGroupOf[cmd.Name]; group != "" {
+		return group + " " + cmd.Name
+	}
+	return cmd.Name
 }
 
 // PrefixContext holds context for prefix-based command execution
@@ -94,43 +183,85 @@ var prefixOnlyCategories = map[string]bool{
 
 func NewCommandHandler(b *Bot) *CommandHandler {
 	ch := &CommandHandler{
-		bot:      b,
-		commands: make(map[string]*Command),
+		bot:        b,
+		commands:   make(map[string]*Command),
+		components: make(map[string]ComponentHandler),
 	}
 
 	// Register all commands
 	ch.registerAdminCommands()
+	ch.registerModDMCommands()
+	ch.registerReasonPresetCommands()
+	ch.registerAutoSlowmodeCommands()
+	ch.registerReportCommands()
+	ch.registerQuoteCommands()
+	ch.registerArchiveCommands()
+	ch.registerServerBackupCommands()
+	ch.registerUndoCommands()
+	ch.registerNoteCommands()
 	ch.registerFunCommands()
 	ch.registerTextCommands()
 	ch.registerImageCommands()
 	ch.registerUtilityCommands()
+	ch.registerScheduleCommands()
+	ch.registerReactionSnipeCommands()
+	ch.registerEmojiCommands()
 	ch.registerMiscCommands()
 	ch.registerInfoCommands()
+	ch.registerActivityCommands()
 	ch.registerLookupCommands()
 	ch.registerRandomCommands()
 	ch.registerToolsCommands()
 	ch.registerSettingsCommands()
+	ch.registerStatsChannelCommands()
 	ch.registerAICommands()
+	ch.registerSummarizeCommands()
+	ch.registerAIUsageCommands()
 
 	// New Yuno-ported commands
 	ch.registerXPCommands()
 	ch.registerFiltersCommands()
 	ch.registerLoggingCommands()
 	ch.registerAutoCleanCommands()
+	ch.registerAutoThreadCommands()
 	ch.registerVoiceXPCommands()
 	ch.registerRanksCommands()
 	ch.registerDMCommands()
 	ch.registerBotBanCommands()
+	ch.registerOwnerCommands()
 	ch.registerBanExportCommands()
 	ch.registerModStatsCommands()
 	ch.registerSpamCommands()
 	ch.registerMentionCommands()
 	ch.registerTicketCommands()
+	ch.registerTicketPanelCommands()
 	ch.registerAntiRaidCommands()
+	ch.registerBlocklistCommands()
 	ch.registerAntiSpamCommands()
+	ch.registerAIModerationCommands()
 	ch.registerMusicCommands()
+	ch.registerTTSCommands()
 	ch.registerUpdateCommands()
 	ch.registerWebServerCommands()
+	ch.registerGitHubCommands()
+	ch.registerInviteCommands()
+	ch.registerLogSearchCommands()
+	ch.registerReactionRoleCommands()
+	ch.registerRolePanelCommands()
+	ch.registerStickyRolesCommands()
+	ch.registerSuggestionCommands()
+	ch.registerBirthdayCommands()
+	ch.registerGuildEventCommands()
+	ch.registerGameCommands()
+	ch.registerEconomyCommands()
+	ch.registerShopCommands()
+	ch.registerAchievementCommands()
+	ch.registerXPBoostCommands()
+	ch.registerXPConfigCommands()
+	ch.registerSeasonCommands()
+	ch.registerPlaylistCommands()
+	ch.registerRadioCommands()
+	ch.registerLibraryCommands()
 
 	return ch
 }
@@ -139,23 +270,83 @@ func (ch *CommandHandler) Register(cmd *Command) {
 	ch.commands[cmd.Name] = cmd
 }
 
-func (ch *CommandHandler) RegisterCommands() error {
-	var appCommands []*discordgo.ApplicationCommand
-	var prefixOnlyCount int
+// RegisterComponent routes message components and modals whose custom ID is "prefix" or starts with "prefix:"
+func (ch *CommandHandler) RegisterComponent(prefix string, handler ComponentHandler) {
+	ch.components[prefix] = handler
+}
 
-	for _, cmd := range ch.commands {
-		// Skip prefix-only commands
-		if cmd.PrefixOnly {
-			prefixOnlyCount++
+// HandleComponent dispatches a button, select menu or modal submit to its registered handler
+func (ch *CommandHandler) HandleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var customID string
+	if i.Type == discordgo.InteractionModalSubmit {
+		customID = i.ModalSubmitData().CustomID
+	} else {
+		customID = i.MessageComponentData().CustomID
+	}
+
+	prefix, _, _ := strings.Cut(customID, ":")
+	if handler, ok := ch.components[prefix]; ok {
+		handler(s, i)
+	}
+}
+
+// buildSlashCommands builds the global and home guild command lists. It fails rather than
+// leave commands out when there are more chat commands than Discord accepts.
+func (ch *CommandHandler) buildSlashCommands() (appCommands, homeCommands []*discordgo.ApplicationCommand, prefixOnlyCount int, err error) {
+	names := make([]string, 0, len(ch.commands))
+	for name := range ch.commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	grouped := make(map[string][]*discordgo.ApplicationCommandOption)
+	chatCommands := 0
+	for _, name := range names {
+		cmd := ch.commands[name]
+
+		// Owner tools stay out of the global list
+		if cmd.HomeGuildOnly {
+			homeCommands = append(homeCommands, &discordgo.ApplicationCommand{
+				Name:        cmd.Name,
+				Description: cmd.Description,
+				Options:     cmd.Options,
+			})
 			continue
 		}
 
-		// Skip commands in prefix-only categories (unless explicitly marked as slash-only)
-		if !cmd.SlashOnly && prefixOnlyCategories[cmd.Category] {
+		// Skip prefix-only commands, including prefix-only categories unless slash-only or grouped
+		if !cmd.isSlash() {
 			prefixOnlyCount++
 			continue
 		}
 
+		// Context menu entries have their own limits and no description
+		if cmd.Type != 0 && cmd.Type != discordgo.ChatApplicationCommand {
+			appCommands = append(appCommands, &discordgo.ApplicationCommand{Name: cmd.Name, Type: cmd.Type})
+			continue
+		}
+
+		// Grouped commands become a subcommand, or a subcommand group if they have subcommands of their own
+		if group := slashGroupOf[cmd.Name]; group != "" {
+			optionType := discordgo.ApplicationCommandOptionSubCommand
+			for _, opt := range cmd.Options {
+				switch opt.Type {
+				case discordgo.ApplicationCommandOptionSubCommand:
+					optionType = discordgo.ApplicationCommandOptionSubCommandGroup
+				case discordgo.ApplicationCommandOptionSubCommandGroup:
+					return nil, nil, 0, fmt.Errorf("command %s has subcommand groups and can't be nested under /%s", cmd.Name, group)
+				}
+			}
+			grouped[group] = append(grouped[group], &discordgo.ApplicationCommandOption{
+				Type:        optionType,
+				Name:        cmd.Name,
+				Description: cmd.Description,
+				Options:     cmd.Options,
+			})
+			continue
+		}
+
+		chatCommands++
 		appCommands = append(appCommands, &discordgo.ApplicationCommand{
 			Name:        cmd.Name,
 			Description: cmd.Description,
@@ -163,28 +354,124 @@ func (ch *CommandHandler) RegisterCommands() error {
 		})
 	}
 
-	// Register commands globally
-	_, err := ch.bot.Session.ApplicationCommandBulkOverwrite(ch.bot.Session.State.User.ID, "", appCommands)
+	groups := make([]string, 0, len(grouped))
+	for group := range grouped {
+		groups = append(groups, group)
+	}
+	slices.Sort(groups)
+	for _, group := range groups {
+		if _, exists := ch.commands[group]; exists {
+			return nil, nil, 0, fmt.Errorf("slash group /%s has the same name as a command", group)
+		}
+		if len(grouped[group]) > maxSubcommands {
+			return nil, nil, 0, fmt.Errorf("slash group /%s has %d commands but Discord allows %d, split it",
+				group, len(grouped[group]), maxSubcommands)
+		}
+		description := slashGroups[group].Description
+		if size := len(group) + len(description) + optionChars(grouped[group]); size > maxCommandChars {
+			return nil, nil, 0, fmt.Errorf("slash group /%s is %d characters but Discord allows %d, split it",
+				group, size, maxCommandChars)
+		}
+		chatCommands++
+		appCommands = append(appCommands, &discordgo.ApplicationCommand{
+			Name:        group,
+			Description: description,
+			Options:     grouped[group],
+		})
+	}
+
+	if chatCommands > maxGlobalCommands {
+		return nil, nil, 0, fmt.Errorf("%d slash commands defined but Discord allows %d, nest some under a slash group",
+			chatCommands, maxGlobalCommands)
+	}
+	return appCommands, homeCommands, prefixOnlyCount, nil
+}
+
+// optionChars counts the option names, descriptions and choices that Discord limits per command
+func optionChars(options []*discordgo.ApplicationCommandOption) int {
+	n := 0
+	for _, opt := range options {
+		n += len(opt.Name) + len(opt.Description) + optionChars(opt.Options)
+		for _, choice := range opt.Choices {
+			n += len(choice.Name) + len(fmt.Sprint(choice.Value))
+		}
+	}
+	return n
+}
+
+func (ch *CommandHandler) RegisterCommands() error {
+	appCommands, homeCommands, prefixOnlyCount, err := ch.buildSlashCommands()
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Registered %d slash commands (%d prefix-only)", len(appCommands), prefixOnlyCount)
+	// Register globally, or only in the dev guild where changes show up instantly
+	guildID := ch.bot.Config().DevGuildID
+	scope := "globally"
+	if guildID != "" {
+		scope = "in dev guild " + guildID
+	}
+
+	// A sync replaces everything in its scope, so a home guild that's also the dev guild gets one list
+	homeGuildID := ch.bot.Config().HomeGuildID
+	if homeGuildID != "" && homeGuildID == guildID {
+		appCommands = append(appCommands, homeCommands...)
+	} else if homeGuildID != "" {
+		result, err := ch.syncCommands(ch.bot.Session.State.User.ID, homeGuildID, homeCommands)
+		if err != nil {
+			log.Printf("Warning: Failed to register owner commands in home guild %s: %v", homeGuildID, err)
+		} else {
+			log.Printf("Synced %d owner commands in home guild %s: %d created, %d updated, %d deleted",
+				len(homeCommands), homeGuildID, result.created, result.updated, result.deleted)
+		}
+	} else if len(homeCommands) > 0 {
+		log.Printf("Owner commands are not registered, set home_guild_id to use them")
+	}
+
+	result, err := ch.syncCommands(ch.bot.Session.State.User.ID, guildID, appCommands)
+	if err != nil {
+		return err
+	}
+
+	mode := "incremental"
+	if result.bulk {
+		mode = "bulk"
+	}
+	log.Printf("Synced %d slash commands %s (%s): %d created, %d updated, %d deleted, %d unchanged (%d prefix-only)",
+		len(appCommands), scope, mode, result.created, result.updated, result.deleted, result.unchanged, prefixOnlyCount)
 	return nil
 }
 
 func (ch *CommandHandler) UnregisterCommands() {
-	commands, err := ch.bot.Session.ApplicationCommands(ch.bot.Session.State.User.ID, "")
+	guildID := ch.bot.Config().DevGuildID
+	commands, err := ch.bot.Session.ApplicationCommands(ch.bot.Session.State.User.ID, guildID)
 	if err != nil {
 		return
 	}
 
 	for _, cmd := range commands {
-		ch.bot.Session.ApplicationCommandDelete(ch.bot.Session.State.User.ID, "", cmd.ID)
+		ch.bot.Session.ApplicationCommandDelete(ch.bot.Session.State.User.ID, guildID, cmd.ID)
 	}
 }
 
+// unwrapSlashGroup rewrites an interaction for a command nested under a slash group so it
+// looks like the command was used directly, e.g. /logging setlogchannel becomes /setlogchannel
+func (ch *CommandHandler) unwrapSlashGroup(i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if _, ok := slashGroups[data.Name]; !ok || len(data.Options) == 0 {
+		return
+	}
+	inner := data.Options[0]
+	if slashGroupOf[inner.Name] != data.Name {
+		return
+	}
+	data.Name = inner.Name
+	data.Options = inner.Options
+	i.Data = data
+}
+
 func (ch *CommandHandler) HandleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	ch.unwrapSlashGroup(i)
 	cmdName := i.ApplicationCommandData().Name
 
 	// Handle subcommands
@@ -220,6 +507,13 @@ func (ch *CommandHandler) HandleSlashCommand(s *discordgo.Session, i *discordgo.
 				respondEphemeral(s, i, "This command is disabled on this server.")
 				return
 			}
+			// Music commands can be limited to designated text channels
+			if cmd.Category == "Music" && cmd.Name != "musicconfig" {
+				if allowed := ch.bot.musicTextChannels(i.GuildID); len(allowed) > 0 && !slices.Contains(allowed, i.ChannelID) {
+					respondEphemeral(s, i, "Music commands can only be used in <#"+strings.Join(allowed, ">, <#")+">.")
+					return
+				}
+			}
 		}
 
 		// Log command usage
@@ -233,20 +527,38 @@ func (ch *CommandHandler) HandleSlashCommand(s *discordgo.Session, i *discordgo.
 			args += opt.Name + " "
 		}
 
-		ch.bot.DB.LogCommand(guildID, i.ChannelID, i.Member.User.ID, cmdName, strings.TrimSpace(args))
-
 		// Increment command counter for stats
 		if ch.bot.WebServer != nil {
 			ch.bot.WebServer.IncrementCommand()
 		}
+		ch.bot.trackAchievementStat(i.GuildID, i.Member.User.ID, statCommands, 1, i.ChannelID)
 
-		cmd.Handler(s, i)
+		start := time.Now()
+		failed := runCommand(cmdName, func() { cmd.Handler(s, i) })
+		if failed {
+			respondEphemeral(s, i, "Something went wrong running this command.")
+		}
+		ch.bot.DB.LogCommand(guildID, i.ChannelID, i.Member.User.ID, cmdName, strings.TrimSpace(args), time.Since(start), failed)
 	} else {
 		respond(s, i, "Unknown command")
 	}
 }
 
+// runCommand calls a command handler, recovering from a panic so one broken
+// command can't take the bot down. It reports whether the handler panicked.
+func runCommand(name string, fn func()) (failed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[PANIC] command %s: %v\n%s", name, r, debug.Stack())
+			failed = true
+		}
+	}()
+	fn()
+	return false
+}
+
 func (ch *CommandHandler) HandleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	ch.unwrapSlashGroup(i)
 	cmdName := i.ApplicationCommandData().Name
 
 	cmd, exists := ch.commands[cmdName]
@@ -262,7 +574,8 @@ func (ch *CommandHandler) GetCommands() map[string]*Command {
 func (ch *CommandHandler) GetCommandsByCategory(category string) []*Command {
 	var cmds []*Command
 	for _, cmd := range ch.commands {
-		if cmd.Category == category {
+		// Context menu entries can't be typed, so they stay out of command lists
+		if cmd.Category == category && cmd.Type == 0 {
 			cmds = append(cmds, cmd)
 		}
 	}
@@ -0,0 +1,196 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// inviteSnapshot is the last known state of an invite
+type inviteSnapshot struct {
+	Uses      int
+	MaxUses   int
+	InviterID string
+	DeletedAt time.Time // Set when Discord removes the invite (e.g. last use of a limited invite)
+}
+
+// InviteTracker caches invite use counts per guild so joins can be attributed
+type InviteTracker struct {
+	mu     sync.Mutex
+	guilds map[string]map[string]*inviteSnapshot // guildID -> code -> snapshot
+}
+
+// NewInviteTracker creates a new invite tracker
+func NewInviteTracker() *InviteTracker {
+	return &InviteTracker{
+		guilds: make(map[string]map[string]*inviteSnapshot),
+	}
+}
+
+// Global invite tracker
+var inviteTracker = NewInviteTracker()
+
+// How long a deleted invite remains a candidate for the join that consumed it
+const deletedInviteWindow = 30 * time.Second
+
+// snapshotInvites records the current use counts of a guild's invites
+func (b *Bot) snapshotInvites(s *discordgo.Session, guildID string) {
+	invites, err := s.GuildInvites(guildID)
+	if err != nil {
+		return // Missing Manage Server permission
+	}
+
+	snapshot := make(map[string]*inviteSnapshot, len(invites))
+	for _, inv := range invites {
+		snapshot[inv.Code] = newInviteSnapshot(inv)
+	}
+
+	inviteTracker.mu.Lock()
+	inviteTracker.guilds[guildID] = snapshot
+	inviteTracker.mu.Unlock()
+}
+
+func newInviteSnapshot(inv *discordgo.Invite) *inviteSnapshot {
+	snap := &inviteSnapshot{Uses: inv.Uses, MaxUses: inv.MaxUses}
+	if inv.Inviter != nil {
+		snap.InviterID = inv.Inviter.ID
+	}
+	return snap
+}
+
+// resolveInvite compares current invite uses against the snapshot to find which
+// invite a new member used. Returns empty strings if it cannot be determined.
+func (b *Bot) resolveInvite(s *discordgo.Session, guildID string) (code, inviterID string) {
+	invites, err := s.GuildInvites(guildID)
+	if err != nil {
+		return "", ""
+	}
+
+	inviteTracker.mu.Lock()
+	defer inviteTracker.mu.Unlock()
+
+	previous, tracked := inviteTracker.guilds[guildID]
+	current := make(map[string]*inviteSnapshot, len(invites))
+
+	// Without a baseline every used invite would look new
+	if !tracked {
+		for _, inv := range invites {
+			current[inv.Code] = newInviteSnapshot(inv)
+		}
+		inviteTracker.guilds[guildID] = current
+		return "", ""
+	}
+
+	for _, inv := range invites {
+		current[inv.Code] = newInviteSnapshot(inv)
+		if code != "" {
+			continue
+		}
+		if old, ok := previous[inv.Code]; ok {
+			if inv.Uses > old.Uses {
+				code, inviterID = inv.Code, current[inv.Code].InviterID
+			}
+		} else if inv.Uses > 0 {
+			// Created since the last snapshot and already used
+			code, inviterID = inv.Code, current[inv.Code].InviterID
+		}
+	}
+
+	// A limited invite that reached its max uses is deleted instead of incremented
+	if code == "" {
+		for oldCode, old := range previous {
+			if _, ok := current[oldCode]; ok {
+				continue
+			}
+			if !old.DeletedAt.IsZero() && time.Since(old.DeletedAt) < deletedInviteWindow &&
+				old.MaxUses > 0 && old.Uses+1 >= old.MaxUses {
+				code, inviterID = oldCode, old.InviterID
+				break
+			}
+		}
+	}
+
+	inviteTracker.guilds[guildID] = current
+
+	// Fall back to the vanity URL
+	if code == "" {
+		if guild, err := s.State.Guild(guildID); err == nil && guild.VanityURLCode != "" {
+			code = guild.VanityURLCode
+		}
+	}
+
+	return code, inviterID
+}
+
+func (b *Bot) onInviteCreate(s *discordgo.Session, e *discordgo.InviteCreate) {
+	inviteTracker.mu.Lock()
+	defer inviteTracker.mu.Unlock()
+
+	if inviteTracker.guilds[e.GuildID] == nil {
+		inviteTracker.guilds[e.GuildID] = make(map[string]*inviteSnapshot)
+	}
+	inviteTracker.guilds[e.GuildID][e.Code] = newInviteSnapshot(e.Invite)
+}
+
+func (b *Bot) onInviteDelete(s *discordgo.Session, e *discordgo.InviteDelete) {
+	inviteTracker.mu.Lock()
+	defer inviteTracker.mu.Unlock()
+
+	// Keep the entry briefly so a join that consumed the invite can still be attributed
+	if snap, ok := inviteTracker.guilds[e.GuildID][e.Code]; ok {
+		snap.DeletedAt = time.Now()
+	}
+}
+
+// trackMemberInvite attributes a new member to an invite and stores it
+func (b *Bot) trackMemberInvite(s *discordgo.Session, m *discordgo.GuildMemberAdd) (code, inviterID string) {
+	code, inviterID = b.resolveInvite(s, m.GuildID)
+	if code != "" || inviterID != "" {
+		b.DB.RecordInviteJoin(m.GuildID, m.User.ID, inviterID, code)
+	}
+	return code, inviterID
+}
+
+// replaceInvitePlaceholders fills in {inviter}, {invitername} and {invitecode}
+func replaceInvitePlaceholders(text string, s *discordgo.Session, inviterID, code string) string {
+	if !strings.Contains(text, "{invite") {
+		return text
+	}
+
+	inviter, inviterName := "Unknown", "Unknown"
+	if inviterID != "" {
+		inviter = fmt.Sprintf("<@%s>", inviterID)
+		if user, err := s.User(inviterID); err == nil {
+			inviterName = user.Username
+		}
+	} else if code != "" {
+		inviter, inviterName = "Vanity URL", "Vanity URL"
+	}
+	if code == "" {
+		code = "unknown"
+	}
+
+	text = strings.ReplaceAll(text, "{inviter}", inviter)
+	text = strings.ReplaceAll(text, "{invitername}", inviterName)
+	text = strings.ReplaceAll(text, "{invitecode}", code)
+	return text
+}
@@ -0,0 +1,230 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// keywordPatterns caches compiled keyword matchers by mode, case sensitivity and keyword
+var keywordPatterns sync.Map
+
+// keywordPattern compiles a tracked keyword into a matcher. Whole word keywords
+// may start or end with punctuation, so they're bounded by non-word characters
+// rather than \b.
+func keywordPattern(kn *database.KeywordNotification) (*regexp.Regexp, error) {
+	key := fmt.Sprintf("%s:%t:%s", kn.MatchMode, kn.CaseSensitive, kn.Keyword)
+	if cached, ok := keywordPatterns.Load(key); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	var expr string
+	switch kn.MatchMode {
+	case database.KeywordMatchRegex:
+		expr = kn.Keyword
+	case database.KeywordMatchWord:
+		expr = `(?:^|\W)` + regexp.QuoteMeta(kn.Keyword) + `(?:\W|$)`
+	default:
+		expr = regexp.QuoteMeta(kn.Keyword)
+	}
+	if !kn.CaseSensitive {
+		expr = "(?i)" + expr
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	keywordPatterns.Store(key, re)
+	return re, nil
+}
+
+// inKeywordQuietHours reports whether it's currently within a member's quiet hours,
+// read in their /timezone if they've set one and UTC otherwise
+func (b *Bot) inKeywordQuietHours(ks *database.KeywordSettings, now time.Time) bool {
+	if ks.QuietStart < 0 || ks.QuietEnd < 0 || ks.QuietStart == ks.QuietEnd {
+		return false
+	}
+
+	loc := time.UTC
+	if tz, _ := b.DB.GetUserTimezone(ks.UserID); tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+
+	hour := now.In(loc).Hour()
+	if ks.QuietStart < ks.QuietEnd {
+		return hour >= ks.QuietStart && hour < ks.QuietEnd
+	}
+	// Quiet hours that run past midnight
+	return hour >= ks.QuietStart || hour < ks.QuietEnd
+}
+
+func (b *Bot) checkKeywordNotifications(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" || m.Content == "" {
+		return
+	}
+
+	notifications, err := b.DB.GetGuildKeywordNotifications(m.GuildID)
+	if err != nil {
+		return
+	}
+
+	// One alert per member per message, even if several of their keywords match
+	notified := make(map[string]bool)
+	for idx := range notifications {
+		n := &notifications[idx]
+
+		// Don't notify user of their own messages
+		if n.UserID == m.Author.ID || notified[n.UserID] {
+			continue
+		}
+
+		re, err := keywordPattern(n)
+		if err != nil || !re.MatchString(m.Content) {
+			continue
+		}
+		notified[n.UserID] = true
+		b.deliverKeywordAlert(s, m, n)
+	}
+}
+
+// deliverKeywordAlert DMs a member about a keyword match, or holds it for their
+// digest when they use one or are in their quiet hours
+func (b *Bot) deliverKeywordAlert(s *discordgo.Session, m *discordgo.MessageCreate, n *database.KeywordNotification) {
+	settings, err := b.DB.GetKeywordSettings(n.UserID)
+	if err != nil {
+		return
+	}
+
+	// Threads are ignored along with their parent channel
+	if slices.Contains(settings.IgnoredChannels, m.ChannelID) {
+		return
+	}
+	if channel, err := s.State.Channel(m.ChannelID); err == nil && channel.IsThread() && slices.Contains(settings.IgnoredChannels, channel.ParentID) {
+		return
+	}
+
+	if settings.DigestMinutes > 0 || b.inKeywordQuietHours(settings, time.Now()) {
+		b.DB.QueueKeywordDigest(&database.KeywordDigestEntry{
+			UserID:    n.UserID,
+			GuildID:   m.GuildID,
+			ChannelID: m.ChannelID,
+			MessageID: m.ID,
+			AuthorID:  m.Author.ID,
+			Keyword:   n.Keyword,
+			Content:   truncate(m.Content, 1000),
+		})
+		return
+	}
+
+	channel, err := s.UserChannelCreate(n.UserID)
+	if err != nil {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Keyword Alert: " + n.Keyword,
+		Description: truncate(m.Content, 4096),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Author", Value: m.Author.Username, Inline: true},
+			{Name: "Channel", Value: "<#" + m.ChannelID + ">", Inline: true},
+			{Name: "Message", Value: fmt.Sprintf("[Jump to message](https://discord.com/channels/%s/%s/%s)", m.GuildID, m.ChannelID, m.ID), Inline: true},
+		},
+		Timestamp: m.Timestamp.Format(time.RFC3339),
+	}
+
+	s.ChannelMessageSendEmbed(channel.ID, embed)
+}
+
+// flushKeywordDigests sends held back keyword alerts to members whose digest is due
+// and who aren't in their quiet hours
+func (b *Bot) flushKeywordDigests() {
+	entries, err := b.DB.GetKeywordDigests()
+	if err != nil || len(entries) == 0 {
+		return
+	}
+
+	byUser := make(map[string][]database.KeywordDigestEntry)
+	var order []string
+	for _, e := range entries {
+		if _, ok := byUser[e.UserID]; !ok {
+			order = append(order, e.UserID)
+		}
+		byUser[e.UserID] = append(byUser[e.UserID], e)
+	}
+
+	now := time.Now()
+	for _, userID := range order {
+		pending := byUser[userID]
+		settings, err := b.DB.GetKeywordSettings(userID)
+		if err != nil || b.inKeywordQuietHours(settings, now) {
+			continue
+		}
+		if settings.DigestMinutes > 0 && now.Sub(pending[0].CreatedAt) < time.Duration(settings.DigestMinutes)*time.Minute {
+			continue
+		}
+
+		channel, err := b.Session.UserChannelCreate(userID)
+		if err == nil {
+			_, err = b.Session.ChannelMessageSendEmbed(channel.ID, keywordDigestEmbed(pending))
+		}
+		// Members who closed their DMs would otherwise pile up alerts forever
+		if err != nil {
+			if restErr, ok := err.(*discordgo.RESTError); !ok || restErr.Response == nil || restErr.Response.StatusCode != 403 {
+				continue
+			}
+		}
+		b.DB.ClearKeywordDigest(userID, pending[len(pending)-1].ID)
+	}
+}
+
+// keywordDigestEmbed lists held back alerts, as many as fit in one embed
+func keywordDigestEmbed(entries []database.KeywordDigestEntry) *discordgo.MessageEmbed {
+	var description strings.Builder
+	shown := 0
+	for _, e := range entries {
+		line := fmt.Sprintf("**%s** in <#%s> by <@%s> <t:%d:R> · [Jump](https://discord.com/channels/%s/%s/%s)\n> %s\n\n",
+			e.Keyword, e.ChannelID, e.AuthorID, e.CreatedAt.Unix(), e.GuildID, e.ChannelID, e.MessageID,
+			truncate(strings.ReplaceAll(e.Content, "\n", " "), 150))
+		if description.Len()+len(line) > 3900 {
+			break
+		}
+		description.WriteString(line)
+		shown++
+	}
+	if shown < len(entries) {
+		description.WriteString(fmt.Sprintf("…and %d more", len(entries)-shown))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Keyword Digest (%d)", len(entries)),
+		Description: description.String(),
+		Color:       0x5865F2,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+}
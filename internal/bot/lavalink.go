@@ -0,0 +1,614 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+)
+
+// lavalinkVoiceTimeout is how long Join waits for Discord to hand over a voice server
+const lavalinkVoiceTimeout = 10 * time.Second
+
+// lavalinkBackend plays audio on a Lavalink v4 node. The bot only relays the
+// voice session to the node; searching, decoding and encoding all happen there.
+type lavalinkBackend struct {
+	session  *discordgo.Session
+	baseURL  string
+	wsURL    string
+	password string
+
+	mu        sync.Mutex
+	sessionID string // Lavalink session, empty while the websocket is down
+	guilds    map[string]*lavalinkGuild
+	started   sync.Once
+}
+
+// lavalinkGuild is the voice state the node needs for one guild
+type lavalinkGuild struct {
+	voiceSessionID string
+	token          string
+	endpoint       string
+	ready          bool // The node has a working voice connection
+	stream         *lavalinkStream
+}
+
+// NewLavalinkBackend creates a backend that plays through the Lavalink node at
+// host (host:port), listening on the session for the voice events it relays
+func NewLavalinkBackend(s *discordgo.Session, host, password string, secure bool) AudioBackend {
+	httpScheme, wsScheme := "http", "ws"
+	if secure {
+		httpScheme, wsScheme = "https", "wss"
+	}
+
+	b := &lavalinkBackend{
+		session:  s,
+		baseURL:  httpScheme + "://" + host + "/v4",
+		wsURL:    wsScheme + "://" + host + "/v4/websocket",
+		password: password,
+		guilds:   make(map[string]*lavalinkGuild),
+	}
+	s.AddHandler(b.onVoiceStateUpdate)
+	s.AddHandler(b.onVoiceServerUpdate)
+	return b
+}
+
+// Lavalink REST and websocket payloads
+
+type lavalinkTrack struct {
+	Encoded string `json:"encoded"`
+	Info    struct {
+		Title    string `json:"title"`
+		Length   int64  `json:"length"`
+		IsStream bool   `json:"isStream"`
+	} `json:"info"`
+}
+
+type lavalinkVoiceState struct {
+	Token     string `json:"token"`
+	Endpoint  string `json:"endpoint"`
+	SessionID string `json:"sessionId"`
+}
+
+type lavalinkEQBand struct {
+	Band int     `json:"band"`
+	Gain float64 `json:"gain"`
+}
+
+type lavalinkTimescale struct {
+	Speed float64 `json:"speed"`
+	Pitch float64 `json:"pitch"`
+	Rate  float64 `json:"rate"`
+}
+
+type lavalinkRotation struct {
+	RotationHz float64 `json:"rotationHz"`
+}
+
+type lavalinkFilters struct {
+	Equalizer []lavalinkEQBand   `json:"equalizer,omitempty"`
+	Timescale *lavalinkTimescale `json:"timescale,omitempty"`
+	Rotation  *lavalinkRotation  `json:"rotation,omitempty"`
+}
+
+// lavalinkTrackUpdate sets the playing track; a nil Encoded stops playback
+type lavalinkTrackUpdate struct {
+	Encoded *string `json:"encoded"`
+}
+
+type lavalinkPlayerUpdate struct {
+	Track    *lavalinkTrackUpdate `json:"track,omitempty"`
+	Position *int64               `json:"position,omitempty"`
+	Volume   *int                 `json:"volume,omitempty"`
+	Paused   *bool                `json:"paused,omitempty"`
+	Filters  *lavalinkFilters     `json:"filters,omitempty"`
+	Voice    *lavalinkVoiceState  `json:"voice,omitempty"`
+}
+
+type lavalinkMessage struct {
+	Op        string `json:"op"`
+	SessionID string `json:"sessionId"`
+	GuildID   string `json:"guildId"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	Code      int    `json:"code"`
+	State     struct {
+		Position int64 `json:"position"`
+	} `json:"state"`
+	Exception struct {
+		Message string `json:"message"`
+	} `json:"exception"`
+}
+
+// lavalinkFiltersFor maps /filter settings onto Lavalink's built-in filters
+func lavalinkFiltersFor(f AudioFilters) *lavalinkFilters {
+	filters := &lavalinkFilters{}
+
+	// 15 bands from 25Hz to 16kHz: roughly 0-3 bass, 4-9 mids, 10-14 treble.
+	// Gains are multipliers where 0.25 is about +10dB.
+	var gains [15]float64
+	for band := range gains {
+		switch {
+		case band <= 3:
+			gains[band] = float64(f.EQ[0]) / 40
+			if f.BassBoost {
+				gains[band] += 0.2
+			}
+		case band <= 9:
+			gains[band] = float64(f.EQ[1]) / 40
+		default:
+			gains[band] = float64(f.EQ[2]) / 40
+		}
+		if gains[band] != 0 {
+			filters.Equalizer = append(filters.Equalizer, lavalinkEQBand{Band: band, Gain: max(gains[band], -0.25)})
+		}
+	}
+
+	rate := 1.0
+	switch {
+	case f.Nightcore:
+		rate = 1.25
+	case f.Vaporwave:
+		rate = 0.8
+	}
+	speed := 1.0
+	if f.Speed != 0 {
+		speed = f.Speed
+	}
+	if rate != 1 || speed != 1 {
+		filters.Timescale = &lavalinkTimescale{Speed: speed, Pitch: 1, Rate: rate}
+	}
+
+	if f.EightD {
+		filters.Rotation = &lavalinkRotation{RotationHz: 0.2}
+	}
+	return filters
+}
+
+func (b *lavalinkBackend) request(method, path string, body, v interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, b.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", b.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("lavalink API error: %s", resp.Status)
+	}
+	if v != nil {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	return nil
+}
+
+// updatePlayer patches the guild's player on the node
+func (b *lavalinkBackend) updatePlayer(guildID string, update lavalinkPlayerUpdate) error {
+	b.mu.Lock()
+	sessionID := b.sessionID
+	b.mu.Unlock()
+
+	if sessionID == "" {
+		return errors.New("lavalink node is not connected")
+	}
+	return b.request("PATCH", "/sessions/"+sessionID+"/players/"+guildID, update, nil)
+}
+
+// loadTrack resolves a URL, file path or search query to a track the node can play
+func (b *lavalinkBackend) loadTrack(identifier string) (*lavalinkTrack, error) {
+	var result struct {
+		LoadType string          `json:"loadType"`
+		Data     json.RawMessage `json:"data"`
+	}
+	if err := b.request("GET", "/loadtracks?identifier="+url.QueryEscape(identifier), nil, &result); err != nil {
+		return nil, err
+	}
+
+	switch result.LoadType {
+	case "track":
+		var track lavalinkTrack
+		if err := json.Unmarshal(result.Data, &track); err != nil {
+			return nil, err
+		}
+		return &track, nil
+	case "search":
+		var tracks []lavalinkTrack
+		if err := json.Unmarshal(result.Data, &tracks); err != nil {
+			return nil, err
+		}
+		if len(tracks) > 0 {
+			return &tracks[0], nil
+		}
+	case "playlist":
+		var playlist struct {
+			Tracks []lavalinkTrack `json:"tracks"`
+		}
+		if err := json.Unmarshal(result.Data, &playlist); err != nil {
+			return nil, err
+		}
+		if len(playlist.Tracks) > 0 {
+			return &playlist.Tracks[0], nil
+		}
+	case "error":
+		var failure struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(result.Data, &failure)
+		return nil, fmt.Errorf("lavalink couldn't load track: %s", failure.Message)
+	}
+	return nil, errors.New("lavalink found no playable track")
+}
+
+// run keeps the websocket to the node open, reconnecting when it drops
+func (b *lavalinkBackend) run() {
+	backoff := time.Second
+	for {
+		header := http.Header{}
+		header.Set("Authorization", b.password)
+		header.Set("User-Id", b.session.State.User.ID)
+		header.Set("Client-Name", "Himiko")
+
+		conn, _, err := websocket.DefaultDialer.Dial(b.wsURL, header)
+		if err != nil {
+			log.Printf("[Lavalink] Failed to connect to node: %v", err)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, time.Minute)
+			continue
+		}
+		backoff = time.Second
+		log.Printf("[Lavalink] Connected to node")
+
+		for {
+			var msg lavalinkMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				log.Printf("[Lavalink] Lost connection to node: %v", err)
+				break
+			}
+			b.handleMessage(&msg)
+		}
+		conn.Close()
+
+		// The node's players went with the session; streams end as if voice
+		// dropped so players resume once it's back
+		b.mu.Lock()
+		b.sessionID = ""
+		var streams []*lavalinkStream
+		for _, g := range b.guilds {
+			g.ready = false
+			if g.stream != nil {
+				streams = append(streams, g.stream)
+			}
+		}
+		b.mu.Unlock()
+		for _, st := range streams {
+			st.finish(errVoiceLost)
+		}
+		time.Sleep(backoff)
+	}
+}
+
+func (b *lavalinkBackend) handleMessage(msg *lavalinkMessage) {
+	switch msg.Op {
+	case "ready":
+		b.mu.Lock()
+		b.sessionID = msg.SessionID
+		guildIDs := make([]string, 0, len(b.guilds))
+		for guildID := range b.guilds {
+			guildIDs = append(guildIDs, guildID)
+		}
+		b.mu.Unlock()
+
+		// Hand the node any voice sessions we already hold
+		for _, guildID := range guildIDs {
+			b.sendVoice(guildID)
+		}
+
+	case "playerUpdate":
+		b.mu.Lock()
+		if g := b.guilds[msg.GuildID]; g != nil && g.stream != nil {
+			g.stream.setPosition(msg.State.Position)
+		}
+		b.mu.Unlock()
+
+	case "event":
+		b.mu.Lock()
+		g := b.guilds[msg.GuildID]
+		var stream *lavalinkStream
+		if g != nil {
+			stream = g.stream
+			if msg.Type == "WebSocketClosedEvent" {
+				g.ready = false
+			}
+		}
+		b.mu.Unlock()
+		if stream == nil {
+			return
+		}
+
+		switch msg.Type {
+		case "TrackEndEvent":
+			// Stopped and replaced tracks were ended by us and already finished
+			if msg.Reason != "stopped" && msg.Reason != "replaced" {
+				stream.finish(nil)
+			}
+		case "TrackExceptionEvent":
+			stream.finish(fmt.Errorf("lavalink playback error: %s", msg.Exception.Message))
+		case "TrackStuckEvent":
+			stream.finish(errors.New("lavalink playback got stuck"))
+		case "WebSocketClosedEvent":
+			log.Printf("[Lavalink] Voice connection closed in guild %s (code %d)", msg.GuildID, msg.Code)
+			stream.finish(errVoiceLost)
+		}
+	}
+}
+
+func (b *lavalinkBackend) onVoiceStateUpdate(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	if s.State.User == nil || v.UserID != s.State.User.ID {
+		return
+	}
+
+	b.mu.Lock()
+	g := b.guilds[v.GuildID]
+	if g == nil {
+		b.mu.Unlock()
+		return
+	}
+	if v.ChannelID == "" {
+		g.ready = false
+		b.mu.Unlock()
+		return
+	}
+	g.voiceSessionID = v.SessionID
+	b.mu.Unlock()
+
+	b.sendVoice(v.GuildID)
+}
+
+func (b *lavalinkBackend) onVoiceServerUpdate(s *discordgo.Session, v *discordgo.VoiceServerUpdate) {
+	b.mu.Lock()
+	g := b.guilds[v.GuildID]
+	if g == nil {
+		b.mu.Unlock()
+		return
+	}
+	g.token = v.Token
+	g.endpoint = v.Endpoint
+	b.mu.Unlock()
+
+	b.sendVoice(v.GuildID)
+}
+
+// sendVoice passes the guild's voice session to the node once all of it has arrived
+func (b *lavalinkBackend) sendVoice(guildID string) {
+	b.mu.Lock()
+	g := b.guilds[guildID]
+	if g == nil || g.voiceSessionID == "" || g.token == "" || g.endpoint == "" || b.sessionID == "" {
+		b.mu.Unlock()
+		return
+	}
+	voice := lavalinkVoiceState{Token: g.token, Endpoint: g.endpoint, SessionID: g.voiceSessionID}
+	b.mu.Unlock()
+
+	if err := b.updatePlayer(guildID, lavalinkPlayerUpdate{Voice: &voice}); err != nil {
+		log.Printf("[Lavalink] Failed to send voice state for guild %s: %v", guildID, err)
+		return
+	}
+
+	b.mu.Lock()
+	if g := b.guilds[guildID]; g != nil {
+		g.ready = true
+	}
+	b.mu.Unlock()
+}
+
+func (b *lavalinkBackend) Join(s *discordgo.Session, guildID, channelID string) error {
+	if s.State.User == nil {
+		return errors.New("bot is not ready yet")
+	}
+	b.started.Do(func() { go b.run() })
+
+	b.mu.Lock()
+	g := b.guilds[guildID]
+	if g == nil {
+		g = &lavalinkGuild{}
+		b.guilds[guildID] = g
+	}
+	g.ready = false
+	b.mu.Unlock()
+
+	if err := s.ChannelVoiceJoinManual(guildID, channelID, false, true); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(lavalinkVoiceTimeout)
+	for time.Now().Before(deadline) {
+		if b.Ready(guildID) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return errors.New("timed out waiting for the lavalink node to connect to voice")
+}
+
+func (b *lavalinkBackend) Leave(s *discordgo.Session, guildID string) error {
+	b.mu.Lock()
+	g := b.guilds[guildID]
+	delete(b.guilds, guildID)
+	sessionID := b.sessionID
+	b.mu.Unlock()
+
+	if g != nil && g.stream != nil {
+		g.stream.finish(nil)
+	}
+	if sessionID != "" {
+		b.request("DELETE", "/sessions/"+sessionID+"/players/"+guildID, nil, nil)
+	}
+	return s.ChannelVoiceJoinManual(guildID, "", false, false)
+}
+
+func (b *lavalinkBackend) Ready(guildID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g := b.guilds[guildID]
+	return g != nil && g.ready && b.sessionID != ""
+}
+
+// Play starts a track on the node. Lavalink has no loudness normalization
+// filter, so opts.Normalize is ignored.
+func (b *lavalinkBackend) Play(guildID string, track *Track, opts StreamOptions) (AudioStream, error) {
+	// Page URLs resolve on the node; stream URLs from yt-dlp are tied to our IP
+	identifier := track.URL
+	if track.PageURL != "" {
+		identifier = track.PageURL
+	}
+	loaded, err := b.loadTrack(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &lavalinkStream{
+		backend:   b,
+		guildID:   guildID,
+		done:      make(chan error, 1),
+		position:  int64(opts.StartAt) * 1000,
+		updatedAt: time.Now(),
+	}
+
+	b.mu.Lock()
+	g := b.guilds[guildID]
+	if g == nil {
+		b.mu.Unlock()
+		return nil, errors.New("not connected to voice channel")
+	}
+	g.stream = stream
+	b.mu.Unlock()
+
+	// Lavalink volume is a percentage where 100 is unchanged; ours defaults to 50
+	position := int64(opts.StartAt) * 1000
+	volume := opts.Volume * 2
+	paused := false
+	err = b.updatePlayer(guildID, lavalinkPlayerUpdate{
+		Track:    &lavalinkTrackUpdate{Encoded: &loaded.Encoded},
+		Position: &position,
+		Volume:   &volume,
+		Paused:   &paused,
+		Filters:  lavalinkFiltersFor(opts.Filters),
+	})
+	if err != nil {
+		stream.finish(nil)
+		return nil, err
+	}
+	return stream, nil
+}
+
+// lavalinkStream is a track playing on the node. Its position is estimated
+// between the node's periodic player updates.
+type lavalinkStream struct {
+	backend   *lavalinkBackend
+	guildID   string
+	done      chan error
+	finished  sync.Once
+	mu        sync.Mutex
+	position  int64 // Milliseconds, as of updatedAt
+	updatedAt time.Time
+	paused    bool
+}
+
+// finish ends the stream once, detaching it from its guild
+func (st *lavalinkStream) finish(err error) {
+	st.finished.Do(func() {
+		st.backend.mu.Lock()
+		if g := st.backend.guilds[st.guildID]; g != nil && g.stream == st {
+			g.stream = nil
+		}
+		st.backend.mu.Unlock()
+		st.done <- err
+	})
+}
+
+func (st *lavalinkStream) setPosition(ms int64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.position = ms
+	st.updatedAt = time.Now()
+}
+
+func (st *lavalinkStream) Done() <-chan error {
+	return st.done
+}
+
+func (st *lavalinkStream) Stop() {
+	st.backend.mu.Lock()
+	g := st.backend.guilds[st.guildID]
+	current := g != nil && g.stream == st
+	st.backend.mu.Unlock()
+
+	if current {
+		st.backend.updatePlayer(st.guildID, lavalinkPlayerUpdate{Track: &lavalinkTrackUpdate{}})
+	}
+	st.finish(nil)
+}
+
+func (st *lavalinkStream) SetPaused(paused bool) {
+	st.mu.Lock()
+	if !st.paused {
+		st.position += time.Since(st.updatedAt).Milliseconds()
+	}
+	st.updatedAt = time.Now()
+	st.paused = paused
+	st.mu.Unlock()
+
+	st.backend.updatePlayer(st.guildID, lavalinkPlayerUpdate{Paused: &paused})
+}
+
+func (st *lavalinkStream) Position() time.Duration {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	pos := time.Duration(st.position) * time.Millisecond
+	if !st.paused {
+		pos += time.Since(st.updatedAt)
+	}
+	return pos
+}
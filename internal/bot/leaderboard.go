@@ -0,0 +1,197 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const leaderboardPerPage = 10
+
+// leaderboardPeriod is a leaderboard view; Days of 0 ranks by total XP
+type leaderboardPeriod struct {
+	Name  string
+	Label string
+	Days  int
+}
+
+var leaderboardPeriods = []leaderboardPeriod{
+	{Name: "all", Label: "All Time", Days: 0},
+	{Name: "weekly", Label: "Weekly", Days: 7},
+	{Name: "monthly", Label: "Monthly", Days: 30},
+}
+
+func getLeaderboardPeriod(name string) leaderboardPeriod {
+	for _, p := range leaderboardPeriods {
+		if p.Name == name {
+			return p
+		}
+	}
+	return leaderboardPeriods[0]
+}
+
+// renderLeaderboard builds a leaderboard page with navigation buttons. highlightID is marked if it's on the page.
+func (b *Bot) renderLeaderboard(guildID string, period leaderboardPeriod, page int, highlightID string) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	total, err := b.DB.CountXPLeaderboard(guildID, period.Days)
+	if err != nil {
+		return nil, nil, err
+	}
+	totalPages := max((total+leaderboardPerPage-1)/leaderboardPerPage, 1)
+	page = min(max(page, 1), totalPages)
+
+	entries, err := b.DB.GetXPLeaderboardPage(guildID, period.Days, leaderboardPerPage, (page-1)*leaderboardPerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var description strings.Builder
+	if len(entries) == 0 {
+		if period.Days > 0 {
+			description.WriteString(fmt.Sprintf("Nobody has earned XP in the last %d days.", period.Days))
+		} else {
+			description.WriteString("No XP data yet! Start chatting to earn XP.")
+		}
+	}
+	for idx, entry := range entries {
+		rank := (page-1)*leaderboardPerPage + idx + 1
+		medal := ""
+		switch rank {
+		case 1:
+			medal = " :first_place:"
+		case 2:
+			medal = " :second_place:"
+		case 3:
+			medal = " :third_place:"
+		}
+		line := fmt.Sprintf("**#%d**%s <@%s> - Level %d (%d XP)", rank, medal, entry.UserID, entry.Level, entry.XP)
+		if period.Days > 0 {
+			line = fmt.Sprintf("**#%d**%s <@%s> - +%d XP (Level %d)", rank, medal, entry.UserID, entry.XP, entry.Level)
+		}
+		if entry.UserID == highlightID {
+			line = "➡️ " + line
+		}
+		description.WriteString(line + "\n")
+	}
+
+	title := "XP Leaderboard"
+	if period.Days > 0 {
+		title = fmt.Sprintf("XP Leaderboard — %s", period.Label)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description.String(),
+		Color:       0xFFD700,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d • %d members", page, totalPages, total),
+		},
+	}
+
+	// Every button needs a unique custom ID, so the action is part of it even though only the page matters
+	navButton := func(action, emoji string, target int, disabled bool) discordgo.Button {
+		return discordgo.Button{
+			Emoji:    &discordgo.ComponentEmoji{Name: emoji},
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("xplb:%s:%s:%d", action, period.Name, target),
+			Disabled: disabled,
+		}
+	}
+	nav := []discordgo.MessageComponent{
+		navButton("first", "⏮️", 1, page == 1),
+		navButton("prev", "◀️", page-1, page == 1),
+		navButton("next", "▶️", page+1, page == totalPages),
+		navButton("last", "⏭️", totalPages, page == totalPages),
+		discordgo.Button{
+			Label:    "My Rank",
+			Style:    discordgo.PrimaryButton,
+			CustomID: "xplb:me:" + period.Name,
+		},
+	}
+
+	var views []discordgo.MessageComponent
+	for _, p := range leaderboardPeriods {
+		style := discordgo.SecondaryButton
+		if p.Name == period.Name {
+			style = discordgo.SuccessButton
+		}
+		views = append(views, discordgo.Button{
+			Label:    p.Label,
+			Style:    style,
+			CustomID: "xplb:view:" + p.Name,
+			Disabled: p.Name == period.Name,
+		})
+	}
+
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: nav},
+		discordgo.ActionsRow{Components: views},
+	}, nil
+}
+
+// handleLeaderboardComponent handles "xplb:<first|prev|next|last>:<period>:<page>", "xplb:me:<period>" and "xplb:view:<period>"
+func (ch *CommandHandler) handleLeaderboardComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) < 3 {
+		return
+	}
+	period := getLeaderboardPeriod(parts[2])
+
+	page := 1
+	highlightID := ""
+	switch parts[1] {
+	case "me":
+		position, err := ch.bot.DB.GetXPLeaderboardPosition(i.GuildID, i.Member.User.ID, period.Days)
+		if err != nil {
+			respondEphemeral(s, i, "Failed to get your rank.")
+			return
+		}
+		if position == 0 {
+			respondEphemeral(s, i, "You're not on this leaderboard yet.")
+			return
+		}
+		page = (position-1)/leaderboardPerPage + 1
+		highlightID = i.Member.User.ID
+	case "view":
+	default:
+		if len(parts) != 4 {
+			return
+		}
+		var err error
+		if page, err = strconv.Atoi(parts[3]); err != nil {
+			return
+		}
+	}
+
+	embed, components, err := ch.bot.renderLeaderboard(i.GuildID, period, page, highlightID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get leaderboard.")
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
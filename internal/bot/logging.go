@@ -0,0 +1,554 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// How far back an audit log entry may be and still be attributed to an event
+const (
+	auditLogMaxAge        = 30 * time.Second
+	auditLogMessageMaxAge = 5 * time.Minute // Message delete entries are merged and keep their original ID
+)
+
+// Log categories, each of which can be routed to its own channel
+const (
+	logCategoryMessage = "message"
+	logCategoryMember  = "member"
+	logCategoryVoice   = "voice"
+	logCategoryMod     = "mod"
+	logCategoryAutomod = "automod"
+)
+
+// logCategoryRoute returns the channel a log category is explicitly routed to, or "" if it has no route
+func logCategoryRoute(cfg *database.LoggingConfig, category string) string {
+	var route *string
+	switch category {
+	case logCategoryMessage:
+		route = cfg.MessageLogChannelID
+	case logCategoryMember:
+		route = cfg.MemberLogChannelID
+	case logCategoryVoice:
+		route = cfg.VoiceLogChannelID
+	case logCategoryMod:
+		route = cfg.ModLogChannelID
+	case logCategoryAutomod:
+		route = cfg.AutomodLogChannelID
+	}
+
+	if route == nil {
+		return ""
+	}
+	return *route
+}
+
+// logChannelFor resolves the channel for a log category, falling back to the default log channel.
+// Automod logs fall back to the mod log channel first.
+func logChannelFor(cfg *database.LoggingConfig, category string) string {
+	if route := logCategoryRoute(cfg, category); route != "" {
+		return route
+	}
+	if category == logCategoryAutomod {
+		if route := logCategoryRoute(cfg, logCategoryMod); route != "" {
+			return route
+		}
+	}
+	if cfg.LogChannelID != nil {
+		return *cfg.LogChannelID
+	}
+	return ""
+}
+
+// getLogConfig returns the logging config if logging is active for the guild and source channel
+func (b *Bot) getLogConfig(guildID, sourceChannelID string) *database.LoggingConfig {
+	if guildID == "" {
+		return nil
+	}
+
+	cfg, err := b.DB.GetLoggingConfig(guildID)
+	if err != nil || !cfg.Enabled {
+		return nil
+	}
+
+	if sourceChannelID != "" {
+		if disabled, _ := b.DB.IsLogChannelDisabled(guildID, sourceChannelID); disabled {
+			return nil
+		}
+	}
+
+	return cfg
+}
+
+// sendLog sends a log embed to the channel configured for its category
+func (b *Bot) sendLog(s *discordgo.Session, cfg *database.LoggingConfig, category string, embed *discordgo.MessageEmbed) {
+	b.sendLogWithFiles(s, cfg, category, embed, nil)
+}
+
+// sendLogWithFiles sends a log embed with file uploads to the channel configured for its category
+func (b *Bot) sendLogWithFiles(s *discordgo.Session, cfg *database.LoggingConfig, category string, embed *discordgo.MessageEmbed, files []*discordgo.File) {
+	b.sendLogMessage(s, cfg, category, "", embed, files)
+}
+
+// sendLogMessage sends a log with message content (such as a role ping), an embed and
+// file uploads to the channel configured for its category
+func (b *Bot) sendLogMessage(s *discordgo.Session, cfg *database.LoggingConfig, category, content string, embed *discordgo.MessageEmbed, files []*discordgo.File) {
+	channelID := logChannelFor(cfg, category)
+	if channelID == "" {
+		return
+	}
+
+	if embed.Timestamp == "" {
+		embed.Timestamp = time.Now().Format(time.RFC3339)
+	}
+	if cfg.UseWebhooks && b.sendLogWebhook(s, cfg.GuildID, channelID, category, content, embed, files) {
+		return
+	}
+	s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: content,
+		Embeds:  []*discordgo.MessageEmbed{embed},
+		Files:   files,
+	})
+}
+
+// sendAutomodLog sends an automated moderation embed to the automod log route,
+// or to the guild's mod log channel when logging is not configured
+func (b *Bot) sendAutomodLog(s *discordgo.Session, guildID string, embed *discordgo.MessageEmbed) {
+	b.sendAutomodAlert(s, guildID, "", embed)
+}
+
+// sendAutomodAlert is sendAutomodLog with message content alongside the embed,
+// used for alerts that ping a staff role
+func (b *Bot) sendAutomodAlert(s *discordgo.Session, guildID, content string, embed *discordgo.MessageEmbed) {
+	if cfg := b.getLogConfig(guildID, ""); cfg != nil {
+		if channelID := logChannelFor(cfg, logCategoryAutomod); channelID != "" {
+			b.sendLogMessage(s, cfg, logCategoryAutomod, content, embed, nil)
+			return
+		}
+	}
+
+	settings, err := b.DB.GetGuildSettings(guildID)
+	if err != nil || settings.ModLogChannel == nil {
+		return
+	}
+	s.ChannelMessageSendComplex(*settings.ModLogChannel, &discordgo.MessageSend{
+		Content: content,
+		Embed:   embed,
+	})
+}
+
+// findAuditEntry looks up the most recent audit log entry for an action against a target
+func findAuditEntry(s *discordgo.Session, guildID, targetID string, action discordgo.AuditLogAction, maxAge time.Duration) *discordgo.AuditLogEntry {
+	auditLog, err := s.GuildAuditLog(guildID, "", "", int(action), 10)
+	if err != nil {
+		return nil
+	}
+
+	for _, entry := range auditLog.AuditLogEntries {
+		if entry.TargetID != targetID {
+			continue
+		}
+		created := time.UnixMilli(snowflakeToTimestamp(entry.ID))
+		if time.Since(created) > maxAge {
+			continue
+		}
+		return entry
+	}
+	return nil
+}
+
+// auditActorField builds the "Responsible" embed field for an audit log entry
+func auditActorField(entry *discordgo.AuditLogEntry) *discordgo.MessageEmbedField {
+	value := "Unknown"
+	if entry != nil && entry.UserID != "" {
+		value = fmt.Sprintf("<@%s> (`%s`)", entry.UserID, entry.UserID)
+	}
+	return &discordgo.MessageEmbedField{Name: "Responsible", Value: value, Inline: true}
+}
+
+// recordAuditModAction stores a mod action attributed through the audit log.
+// Actions taken by the bot itself are recorded by the code that performs them.
+func (b *Bot) recordAuditModAction(s *discordgo.Session, guildID, targetID, action string, entry *discordgo.AuditLogEntry) {
+	if entry == nil || entry.UserID == "" || entry.UserID == s.State.User.ID {
+		return
+	}
+
+	timestamp := snowflakeToTimestamp(entry.ID)
+	if exists, _ := b.DB.ModActionExists(guildID, targetID, action, timestamp); exists {
+		return
+	}
+
+	var reason *string
+	if entry.Reason != "" {
+		reason = &entry.Reason
+	}
+	b.DB.AddModAction(guildID, entry.UserID, targetID, action, reason, timestamp)
+}
+
+// logMessageDelete logs a deleted message, attributing it to a moderator when possible
+// and re-uploading any archived attachments
+func (b *Bot) logMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete, attachments []database.DeletedAttachment) {
+	if m.BeforeDelete == nil || m.BeforeDelete.Author == nil || m.BeforeDelete.Author.Bot {
+		return
+	}
+
+	cfg := b.getLogConfig(m.GuildID, m.ChannelID)
+	if cfg == nil || !cfg.MessageDelete {
+		return
+	}
+
+	author := m.BeforeDelete.Author
+	content := m.BeforeDelete.Content
+	if content == "" {
+		content = "*No text content*"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Message Deleted",
+		Description: truncate(content, 4000),
+		Color:       0xFF0000,
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    author.Username,
+			IconURL: avatarURL(author),
+		},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Author", Value: author.Mention(), Inline: true},
+			{Name: "Channel", Value: fmt.Sprintf("<#%s>", m.ChannelID), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("User ID: %s | Message ID: %s", author.ID, m.ID),
+		},
+	}
+
+	// Discord only creates an entry when someone deletes another user's message
+	entry := findAuditEntry(s, m.GuildID, author.ID, discordgo.AuditLogActionMessageDelete, auditLogMessageMaxAge)
+	if entry != nil && entry.Options != nil && entry.Options.ChannelID == m.ChannelID {
+		embed.Fields = append(embed.Fields, auditActorField(entry))
+	} else {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Responsible", Value: "Author or bot", Inline: true,
+		})
+	}
+
+	files, handles, skipped := archivedFiles(attachments, maxReuploadBytes)
+	defer func() {
+		for _, f := range handles {
+			f.Close()
+		}
+	}()
+
+	if len(attachments) > 0 {
+		var names []string
+		for _, att := range attachments {
+			name := fmt.Sprintf("%s (%s)", att.Filename, formatBytes(int64(att.Size)))
+			switch {
+			case slices.Contains(skipped, att):
+				// Too big to re-upload alongside the rest, so link the original instead
+				name = fmt.Sprintf("[%s](%s) (%s) - archived, not re-uploaded", att.Filename, att.URL, formatBytes(int64(att.Size)))
+			case att.ArchivePath != "":
+				name += " - archived"
+			}
+			names = append(names, name)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Attachments", Value: truncate(strings.Join(names, "\n"), 1024),
+		})
+	}
+
+	if name := firstImageFile(files); name != "" {
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + name}
+	}
+
+	b.sendLogWithFiles(s, cfg, logCategoryMessage, embed, files)
+}
+
+// logMessageEdit logs an edited message with a word-level diff of the change
+func (b *Bot) logMessageEdit(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	cfg := b.getLogConfig(m.GuildID, m.ChannelID)
+	if cfg == nil || !cfg.MessageEdit {
+		return
+	}
+
+	author := m.Author
+	before := m.BeforeUpdate.Content
+	after := m.Content
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Message Edited",
+		Description: truncate(wordDiff(before, after), 4000),
+		Color:       0xFFA500,
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    author.Username,
+			IconURL: avatarURL(author),
+		},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Before", Value: truncate(nonEmpty(before), 1024), Inline: false},
+			{Name: "After", Value: truncate(nonEmpty(after), 1024), Inline: false},
+			{Name: "Author", Value: author.Mention(), Inline: true},
+			{Name: "Channel", Value: fmt.Sprintf("<#%s>", m.ChannelID), Inline: true},
+			{Name: "Jump", Value: fmt.Sprintf("[Go to message](https://discord.com/channels/%s/%s/%s)", m.GuildID, m.ChannelID, m.ID), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("User ID: %s | Message ID: %s", author.ID, m.ID),
+		},
+	}
+
+	if history, err := b.DB.GetMessageEditHistory(m.ID); err == nil && len(history) > 1 {
+		embed.Footer.Text += fmt.Sprintf(" | Revision %d", len(history))
+	}
+
+	b.sendLog(s, cfg, logCategoryMessage, embed)
+}
+
+// onGuildBanAdd logs bans with the moderator who issued them
+func (b *Bot) onGuildBanAdd(s *discordgo.Session, e *discordgo.GuildBanAdd) {
+	entry := findAuditEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberBanAdd, auditLogMaxAge)
+	b.recordAuditModAction(s, e.GuildID, e.User.ID, "ban", entry)
+
+	cfg := b.getLogConfig(e.GuildID, "")
+	if cfg == nil || !cfg.Moderation {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Member Banned",
+		Description: fmt.Sprintf("%s (`%s`) was banned", e.User.Mention(), e.User.ID),
+		Color:       0xFF0000,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: avatarURL(e.User)},
+		Fields:      []*discordgo.MessageEmbedField{auditActorField(entry)},
+	}
+	if entry != nil && entry.Reason != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Reason", Value: truncate(entry.Reason, 1024)})
+	}
+
+	b.sendLog(s, cfg, logCategoryMod, embed)
+}
+
+// onGuildBanRemove logs unbans with the moderator who lifted them
+func (b *Bot) onGuildBanRemove(s *discordgo.Session, e *discordgo.GuildBanRemove) {
+	entry := findAuditEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberBanRemove, auditLogMaxAge)
+	b.recordAuditModAction(s, e.GuildID, e.User.ID, "unban", entry)
+
+	cfg := b.getLogConfig(e.GuildID, "")
+	if cfg == nil || !cfg.Moderation {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Member Unbanned",
+		Description: fmt.Sprintf("%s (`%s`) was unbanned", e.User.Mention(), e.User.ID),
+		Color:       0x00FF00,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: avatarURL(e.User)},
+		Fields:      []*discordgo.MessageEmbedField{auditActorField(entry)},
+	}
+
+	b.sendLog(s, cfg, logCategoryMod, embed)
+}
+
+// onGuildMemberRemove posts the leave message, logs kicks (plain leaves have no audit log entry)
+// and keeps sticky roles for rejoins
+func (b *Bot) onGuildMemberRemove(s *discordgo.Session, e *discordgo.GuildMemberRemove) {
+	if e.User == nil {
+		return
+	}
+
+	b.markMemberLeft(e.GuildID, e.User.ID)
+	serverActivity.addLeave(e.GuildID, time.Now())
+
+	entry := findAuditEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberKick, auditLogMaxAge)
+	b.sendLeave(s, e.GuildID, e.User, entry)
+	if entry == nil {
+		return
+	}
+	b.recordAuditModAction(s, e.GuildID, e.User.ID, "kick", entry)
+
+	cfg := b.getLogConfig(e.GuildID, "")
+	if cfg == nil || !cfg.Moderation {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Member Kicked",
+		Description: fmt.Sprintf("%s (`%s`) was kicked", e.User.Mention(), e.User.ID),
+		Color:       0xFFA500,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: avatarURL(e.User)},
+		Fields:      []*discordgo.MessageEmbedField{auditActorField(entry)},
+	}
+	if entry.Reason != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Reason", Value: truncate(entry.Reason, 1024)})
+	}
+
+	b.sendLog(s, cfg, logCategoryMod, embed)
+}
+
+// onGuildMemberUpdate logs role and nickname changes with the member who made them and refreshes sticky role snapshots
+func (b *Bot) onGuildMemberUpdate(s *discordgo.Session, e *discordgo.GuildMemberUpdate) {
+	b.snapshotMember(e.Member)
+
+	if e.BeforeUpdate == nil || e.User == nil {
+		return
+	}
+
+	cfg := b.getLogConfig(e.GuildID, "")
+	if cfg == nil {
+		return
+	}
+
+	if cfg.RoleChange {
+		added, removed := diffRoles(e.BeforeUpdate.Roles, e.Roles)
+		if len(added) > 0 || len(removed) > 0 {
+			entry := findAuditEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberRoleUpdate, auditLogMaxAge)
+
+			embed := &discordgo.MessageEmbed{
+				Title:       "Member Roles Updated",
+				Description: fmt.Sprintf("%s (`%s`)", e.User.Mention(), e.User.ID),
+				Color:       0x5865F2,
+				Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: avatarURL(e.User)},
+			}
+			if len(added) > 0 {
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+					Name: "Added", Value: truncate(formatRoleMentions(added), 1024), Inline: true,
+				})
+			}
+			if len(removed) > 0 {
+				embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+					Name: "Removed", Value: truncate(formatRoleMentions(removed), 1024), Inline: true,
+				})
+			}
+			embed.Fields = append(embed.Fields, auditActorField(entry))
+
+			b.sendLog(s, cfg, logCategoryMember, embed)
+		}
+	}
+
+	if cfg.NicknameChange && e.BeforeUpdate.Nick != e.Nick {
+		entry := findAuditEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberUpdate, auditLogMaxAge)
+
+		before, after := e.BeforeUpdate.Nick, e.Nick
+		if before == "" {
+			before = "*None*"
+		}
+		if after == "" {
+			after = "*None*"
+		}
+
+		embed := &discordgo.MessageEmbed{
+			Title:       "Nickname Changed",
+			Description: fmt.Sprintf("%s (`%s`)", e.User.Mention(), e.User.ID),
+			Color:       0x5865F2,
+			Fields: []*discordgo.MessageEmbedField{
+				{Name: "Before", Value: before, Inline: true},
+				{Name: "After", Value: after, Inline: true},
+				auditActorField(entry),
+			},
+		}
+
+		b.sendLog(s, cfg, logCategoryMember, embed)
+	}
+}
+
+// diffRoles returns the role IDs added and removed between two role lists
+func diffRoles(before, after []string) (added, removed []string) {
+	beforeSet := make(map[string]bool, len(before))
+	for _, id := range before {
+		beforeSet[id] = true
+	}
+	afterSet := make(map[string]bool, len(after))
+	for _, id := range after {
+		afterSet[id] = true
+		if !beforeSet[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range before {
+		if !afterSet[id] {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+// formatRoleMentions formats role IDs as mentions
+func formatRoleMentions(roleIDs []string) string {
+	mentions := make([]string, len(roleIDs))
+	for i, id := range roleIDs {
+		mentions[i] = fmt.Sprintf("<@&%s>", id)
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// nonEmpty substitutes a placeholder for empty message content
+func nonEmpty(content string) string {
+	if content == "" {
+		return "*No text content*"
+	}
+	return content
+}
+
+// wordDiff renders the difference between two texts, striking removed words and bolding added ones
+func wordDiff(before, after string) string {
+	a := strings.Fields(before)
+	bw := strings.Fields(after)
+
+	// Longest common subsequence table over words
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bw)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(bw) - 1; j >= 0; j-- {
+			if a[i] == bw[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(bw) {
+		switch {
+		case a[i] == bw[j]:
+			out = append(out, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "~~"+a[i]+"~~")
+			i++
+		default:
+			out = append(out, "**"+bw[j]+"**")
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "~~"+a[i]+"~~")
+	}
+	for ; j < len(bw); j++ {
+		out = append(out, "**"+bw[j]+"**")
+	}
+
+	return strings.Join(out, " ")
+}
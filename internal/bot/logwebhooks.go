@@ -0,0 +1,108 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Name given to webhooks the bot creates in log channels
+const logWebhookName = "Himiko Logs"
+
+// sendLogWebhook delivers a log through the channel's bot-managed webhook.
+// Returns false if the webhook could not be used, so the caller can fall back to a bot message.
+func (b *Bot) sendLogWebhook(s *discordgo.Session, guildID, channelID, category, content string, embed *discordgo.MessageEmbed, files []*discordgo.File) bool {
+	// Buffer uploads so a retry after a deleted webhook can resend them
+	contents := make([][]byte, len(files))
+	for idx, f := range files {
+		data, err := io.ReadAll(f.Reader)
+		if err != nil {
+			return false
+		}
+		contents[idx] = data
+		f.Reader = bytes.NewReader(data) // Still readable if we fall back to a bot message
+	}
+
+	params := &discordgo.WebhookParams{Content: content, Embeds: []*discordgo.MessageEmbed{embed}}
+	if identity, err := b.DB.GetLogIdentity(guildID, category); err == nil {
+		params.Username = identity.Username
+		params.AvatarURL = identity.AvatarURL
+	}
+	if params.AvatarURL == "" && s.State.User != nil {
+		params.AvatarURL = s.State.User.AvatarURL("")
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		wh := b.logWebhook(s, guildID, channelID)
+		if wh == nil {
+			return false
+		}
+
+		params.Files = make([]*discordgo.File, len(files))
+		for idx, f := range files {
+			params.Files[idx] = &discordgo.File{Name: f.Name, ContentType: f.ContentType, Reader: bytes.NewReader(contents[idx])}
+		}
+
+		_, err := s.WebhookExecute(wh.WebhookID, wh.WebhookToken, false, params)
+		if err == nil {
+			return true
+		}
+
+		// The webhook was deleted from the channel; forget it and create a new one
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Response != nil &&
+			(restErr.Response.StatusCode == http.StatusNotFound ||
+				(restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownWebhook)) {
+			b.DB.DeleteLogWebhook(channelID)
+			continue
+		}
+
+		log.Printf("Failed to send log via webhook in %s: %v", channelID, err)
+		return false
+	}
+	return false
+}
+
+// logWebhook returns the cached webhook for a log channel, creating one if needed
+func (b *Bot) logWebhook(s *discordgo.Session, guildID, channelID string) *database.LogWebhook {
+	if wh, err := b.DB.GetLogWebhook(channelID); err == nil && wh != nil {
+		return wh
+	}
+
+	created, err := s.WebhookCreate(channelID, logWebhookName, "")
+	if err != nil {
+		return nil // Missing Manage Webhooks permission
+	}
+
+	wh := &database.LogWebhook{
+		ChannelID:    channelID,
+		GuildID:      guildID,
+		WebhookID:    created.ID,
+		WebhookToken: created.Token,
+	}
+	if err := b.DB.SetLogWebhook(wh); err != nil {
+		log.Printf("Failed to save log webhook for %s: %v", channelID, err)
+	}
+	return wh
+}
@@ -0,0 +1,216 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// lyricsPageSize keeps each page of lyrics comfortably readable in an embed
+const lyricsPageSize = 1800
+
+// lyricsCacheTTL is how long fetched lyrics are kept before being looked up again
+const lyricsCacheTTL = 30 * 24 * time.Hour
+
+var (
+	// Bracketed extras in video titles, e.g. (Official Video) or [Lyrics]
+	lyricsBracketRegex = regexp.MustCompile(`\s*[\(\[][^\)\]]*[\)\]]`)
+	lyricsNoiseRegex   = regexp.MustCompile(`(?i)\b(official (music )?video|official audio|lyric video|lyrics?|hd|4k|mv|visualizer)\b`)
+	lyricsFeatRegex    = regexp.MustCompile(`(?i)\s+(ft\.?|feat\.?|featuring)\s+.*$`)
+)
+
+// cleanLyricsQuery strips the noise video titles usually carry so the lyrics search matches the song
+func cleanLyricsQuery(title string) string {
+	q := lyricsBracketRegex.ReplaceAllString(title, "")
+	q = lyricsNoiseRegex.ReplaceAllString(q, "")
+	q = lyricsFeatRegex.ReplaceAllString(q, "")
+	q = strings.Trim(strings.Join(strings.Fields(q), " "), " -|")
+	if q == "" {
+		return strings.TrimSpace(title)
+	}
+	return q
+}
+
+// fetchLyrics returns lyrics for a query, using the cache when possible.
+// A nil result with no error means no lyrics were found.
+func (b *Bot) fetchLyrics(query string) (*database.CachedLyrics, error) {
+	key := strings.ToLower(query)
+	if cached, err := b.DB.GetCachedLyrics(key); err == nil && cached != nil &&
+		time.Since(cached.CachedAt) < lyricsCacheTTL {
+		return cached, nil
+	}
+
+	resp, err := httpClient.Get("https://lrclib.net/api/search?q=" + url.QueryEscape(query))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lyrics provider returned %s", resp.Status)
+	}
+
+	var results []struct {
+		TrackName    string `json:"trackName"`
+		ArtistName   string `json:"artistName"`
+		PlainLyrics  string `json:"plainLyrics"`
+		Instrumental bool   `json:"instrumental"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		lyrics := strings.TrimSpace(r.PlainLyrics)
+		if r.Instrumental {
+			lyrics = "🎼 *Instrumental*"
+		}
+		if lyrics == "" {
+			continue
+		}
+		entry := &database.CachedLyrics{Query: key, Title: r.TrackName, Artist: r.ArtistName, Lyrics: lyrics}
+		if err := b.DB.CacheLyrics(entry); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+	return nil, nil
+}
+
+// paginateLyrics splits lyrics into pages on line boundaries
+func paginateLyrics(lyrics string) []string {
+	var pages []string
+	var current strings.Builder
+	for _, line := range strings.Split(lyrics, "\n") {
+		if current.Len()+len(line)+1 > lyricsPageSize && current.Len() > 0 {
+			pages = append(pages, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+		current.WriteString(truncate(line, lyricsPageSize) + "\n")
+	}
+	if current.Len() > 0 {
+		pages = append(pages, strings.TrimSpace(current.String()))
+	}
+	return pages
+}
+
+// renderLyrics builds a lyrics page with navigation buttons when there's more than one
+func renderLyrics(entry *database.CachedLyrics, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	pages := paginateLyrics(entry.Lyrics)
+	page = min(max(page, 1), len(pages))
+
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate(entry.Title, 256),
+		Description: pages[page-1],
+		Color:       0xFF69B4,
+		Author:      &discordgo.MessageEmbedAuthor{Name: truncate(entry.Artist, 256)},
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d/%d • Lyrics from LRCLIB", page, len(pages))},
+	}
+	if len(pages) == 1 {
+		return embed, nil
+	}
+
+	navButton := func(emoji string, target int, disabled bool) discordgo.Button {
+		return discordgo.Button{
+			Emoji:    &discordgo.ComponentEmoji{Name: emoji},
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("lyrics:%d:%d", entry.ID, target),
+			Disabled: disabled,
+		}
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			navButton("◀️", page-1, page == 1),
+			navButton("▶️", page+1, page == len(pages)),
+		}},
+	}
+}
+
+func (ch *CommandHandler) lyricsHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	query := getStringOption(i, "query")
+	if query == "" {
+		if i.GuildID == "" {
+			respondEphemeral(s, i, "Please provide a song to search for.")
+			return
+		}
+		nowPlaying := ch.bot.MusicManager.GetPlayer(i.GuildID).NowPlaying()
+		if nowPlaying == nil {
+			respondEphemeral(s, i, "Nothing is currently playing. Provide a song to search for.")
+			return
+		}
+		query = cleanLyricsQuery(nowPlaying.Title)
+	}
+
+	respondDeferred(s, i)
+
+	entry, err := ch.bot.fetchLyrics(query)
+	if err != nil {
+		editResponse(s, i, "Failed to fetch lyrics: "+err.Error())
+		return
+	}
+	if entry == nil {
+		editResponse(s, i, fmt.Sprintf("No lyrics found for **%s**.", query))
+		return
+	}
+
+	embed, components := renderLyrics(entry, 1)
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+}
+
+// handleLyricsComponent handles "lyrics:<cacheID>:<page>"
+func (ch *CommandHandler) handleLyricsComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) < 3 {
+		return
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return
+	}
+	page, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return
+	}
+
+	entry, err := ch.bot.DB.GetCachedLyricsByID(id)
+	if err != nil || entry == nil {
+		respondEphemeral(s, i, "These lyrics have expired. Run `/music lyrics` again.")
+		return
+	}
+
+	embed, components := renderLyrics(entry, page)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
@@ -0,0 +1,99 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How long to wait for the next member chunk before falling back to the REST API
+	memberChunkTimeout = 15 * time.Second
+
+	// Delay between role edits in mass operations, to stay clear of Discord's rate limits
+	massRolePace = 200 * time.Millisecond
+
+	// How often long-running member operations edit their progress message
+	memberProgressInterval = 3 * time.Second
+)
+
+// Member chunk requests waiting on the gateway, keyed by the nonce they were sent with
+var memberChunkWaiters sync.Map
+
+// onGuildMembersChunk passes chunks to the request that asked for them
+func (b *Bot) onGuildMembersChunk(s *discordgo.Session, c *discordgo.GuildMembersChunk) {
+	value, ok := memberChunkWaiters.Load(c.Nonce)
+	if !ok {
+		return
+	}
+	select {
+	case value.(chan *discordgo.GuildMembersChunk) <- c:
+	case <-time.After(memberChunkTimeout):
+	}
+}
+
+// fetchGuildMembers returns every member of a guild. It asks the gateway for
+// them in chunks, which is fast even on large servers, and falls back to
+// paging through the REST API 1000 at a time if the gateway doesn't answer.
+func fetchGuildMembers(s *discordgo.Session, guildID string) ([]*discordgo.Member, error) {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	nonce := hex.EncodeToString(buf)
+
+	chunks := make(chan *discordgo.GuildMembersChunk, 8)
+	memberChunkWaiters.Store(nonce, chunks)
+	defer memberChunkWaiters.Delete(nonce)
+
+	if err := s.RequestGuildMembers(guildID, "", 0, nonce, false); err == nil {
+		var members []*discordgo.Member
+		for received := 0; ; {
+			select {
+			case c := <-chunks:
+				members = append(members, c.Members...)
+				if received++; received >= c.ChunkCount {
+					return members, nil
+				}
+			case <-time.After(memberChunkTimeout):
+				return fetchGuildMembersREST(s, guildID)
+			}
+		}
+	}
+	return fetchGuildMembersREST(s, guildID)
+}
+
+// fetchGuildMembersREST pages through a guild's members with the REST API
+func fetchGuildMembersREST(s *discordgo.Session, guildID string) ([]*discordgo.Member, error) {
+	var members []*discordgo.Member
+	after := ""
+	for {
+		batch, err := s.GuildMembers(guildID, after, 1000)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server members: %w", err)
+		}
+		members = append(members, batch...)
+		if len(batch) < 1000 {
+			return members, nil
+		}
+		after = batch[len(batch)-1].User.ID
+	}
+}
@@ -21,83 +21,157 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/jonas747/dca"
 )
 
 // Track represents a music track
 type Track struct {
-	Title     string
-	URL       string
-	Duration  int
-	Thumbnail string
-	Requester string
-	IsLocal   bool
+	Title       string
+	URL         string
+	Duration    int
+	Thumbnail   string
+	Requester   string
+	RequesterID string
+	IsLocal     bool
+	IsLive      bool   // Endless stream such as web radio or a YouTube live, has no duration and can't seek
+	Source      string // Display name of the site the track came from
+	PageURL     string // Page the track was found on, used to look up related tracks
+	QueueID     int64  // ID of the persisted music_queue row, 0 if not saved
+	IsSpeech    bool   // Text-to-speech clip from /tts, a local file that's cleaned up later
+}
+
+// voiceReconnectTimeout is how long a player waits for a dropped voice connection to come back
+const voiceReconnectTimeout = 2 * time.Minute
+
+// maxTrackResumes stops a track that keeps losing its connection from retrying forever
+const maxTrackResumes = 3
+
+// LoopMode controls what a player does with a track once it finishes
+type LoopMode int
+
+const (
+	LoopOff   LoopMode = iota
+	LoopTrack          // Replay the current track
+	LoopQueue          // Send finished tracks to the back of the queue
+)
+
+func (m LoopMode) String() string {
+	switch m {
+	case LoopTrack:
+		return "Track"
+	case LoopQueue:
+		return "Queue"
+	default:
+		return "Off"
+	}
 }
 
 // MusicPlayer handles audio playback for a guild
 type MusicPlayer struct {
-	guildID             string
-	voiceConn           *discordgo.VoiceConnection
-	encoding            *dca.EncodeSession
-	streaming           *dca.StreamingSession
-	queue               []*Track
-	nowPlaying          *Track
-	volume              int
-	mu                  sync.RWMutex
-	stopChan            chan bool
-	isPlaying           bool
-	isPaused            bool
-	youtubeAPIKey       string
-	soundcloudAuthToken string
+	guildID      string
+	session      *discordgo.Session
+	channelID    string // Voice channel the player belongs in, empty once it leaves on purpose
+	backend      AudioBackend
+	stream       AudioStream
+	connected    bool
+	queue        []*Track
+	nowPlaying   *Track
+	volume       int
+	mu           sync.RWMutex
+	stopChan     chan bool
+	isPlaying    bool
+	isPaused     bool
+	seekPending  bool // Set when the stream is being restarted at seekTarget
+	seekTarget   int
+	filters      AudioFilters
+	normalize    bool
+	sponsorBlock []string // SponsorBlock categories to skip, nil when off
+	loopMode     LoopMode
+	skipped      bool // Set by Skip so a looped track isn't replayed
+	onTrackStart func(guildID string, track *Track)
+	onQueueEnd   func(guildID string)
+	onAutoplay   func(guildID string, last *Track) *Track
+
+	// SponsorBlock segments of segmentsTrack, kept so a seek doesn't fetch them again
+	segmentsTrack *Track
+	segments      []sponsorSegment
 }
 
 // MusicManager manages music players across guilds
 type MusicManager struct {
-	players             map[string]*MusicPlayer
-	mu                  sync.RWMutex
-	youtubeAPIKey       string
-	soundcloudAuthToken string
+	players map[string]*MusicPlayer
+	mu      sync.RWMutex
+	backend AudioBackend
+
+	// OnTrackStart is called when a player starts a track
+	OnTrackStart func(guildID string, track *Track)
+
+	// OnQueueEnd is called when a player runs out of tracks or is stopped
+	OnQueueEnd func(guildID string)
+
+	// OnAutoplay is asked for a track to continue with when the queue runs out;
+	// returning nil lets playback end
+	OnAutoplay func(guildID string, last *Track) *Track
+
+	// OnPlayerCreated is called when a guild gets a new player, to load its saved settings
+	OnPlayerCreated func(player *MusicPlayer)
 }
 
 // NewMusicManager creates a new music manager
-func NewMusicManager(youtubeAPIKey, soundcloudAuthToken string) *MusicManager {
+func NewMusicManager(backend AudioBackend) *MusicManager {
 	return &MusicManager{
-		players:             make(map[string]*MusicPlayer),
-		youtubeAPIKey:       youtubeAPIKey,
-		soundcloudAuthToken: soundcloudAuthToken,
+		players: make(map[string]*MusicPlayer),
+		backend: backend,
 	}
 }
 
 // GetPlayer gets or creates a player for a guild
 func (m *MusicManager) GetPlayer(guildID string) *MusicPlayer {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	if player, exists := m.players[guildID]; exists {
+		m.mu.Unlock()
 		return player
 	}
 
 	player := &MusicPlayer{
-		guildID:             guildID,
-		queue:               make([]*Track, 0),
-		volume:              50,
-		stopChan:            make(chan bool, 1),
-		isPlaying:           false,
-		isPaused:            false,
-		youtubeAPIKey:       m.youtubeAPIKey,
-		soundcloudAuthToken: m.soundcloudAuthToken,
+		guildID:      guildID,
+		backend:      m.backend,
+		queue:        make([]*Track, 0),
+		volume:       50,
+		stopChan:     make(chan bool, 1),
+		isPlaying:    false,
+		isPaused:     false,
+		onTrackStart: m.OnTrackStart,
+		onQueueEnd:   m.OnQueueEnd,
+		onAutoplay:   m.OnAutoplay,
 	}
 	m.players[guildID] = player
+	m.mu.Unlock()
+
+	if m.OnPlayerCreated != nil {
+		m.OnPlayerCreated(player)
+	}
 	return player
 }
 
+// FindPlayer returns a guild's player without creating one, or nil if there is none
+func (m *MusicManager) FindPlayer(guildID string) *MusicPlayer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.players[guildID]
+}
+
 // RemovePlayer removes a player for a guild
 func (m *MusicManager) RemovePlayer(guildID string) {
 	m.mu.Lock()
@@ -112,10 +186,70 @@ func (m *MusicManager) RemovePlayer(guildID string) {
 
 // VideoInfo holds info extracted from yt-dlp
 type VideoInfo struct {
-	Title     string `json:"title"`
-	URL       string `json:"url"`
-	Duration  int    `json:"duration"`
-	Thumbnail string `json:"thumbnail"`
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	RawDuration float64 `json:"duration"` // SoundCloud and Bandcamp report fractional seconds
+	Duration    int     `json:"-"`
+	Thumbnail   string  `json:"thumbnail"`
+	WebpageURL  string  `json:"webpage_url"`
+	Uploader    string  `json:"uploader"`
+	Channel     string  `json:"channel"`
+	Extractor   string  `json:"extractor_key"`
+	IsLive      bool    `json:"is_live"`
+	Source      string  `json:"-"`
+}
+
+// collectionURLRegex matches SoundCloud sets and Bandcamp albums, which hold several tracks
+var collectionURLRegex = regexp.MustCompile(`soundcloud\.com/[^/]+/sets/|bandcamp\.com/album/`)
+
+// maxCollectionTracks caps how many tracks of a set or album are queued
+const maxCollectionTracks = 200
+
+// IsCollectionURL reports whether a URL points at a multi-track SoundCloud set or Bandcamp album
+func IsCollectionURL(url string) bool {
+	return collectionURLRegex.MatchString(url)
+}
+
+func ytdlpAuthArgs(youtubeAPIKey, soundcloudAuthToken string) []string {
+	var args []string
+	if youtubeAPIKey != "" {
+		args = append(args, "--username", "oauth2", "--password", "")
+	}
+	if soundcloudAuthToken != "" {
+		args = append(args, "--add-header", "Authorization:OAuth "+soundcloudAuthToken)
+	}
+	return args
+}
+
+// normalize fills in the display fields that differ between extractors
+func (info *VideoInfo) normalize() {
+	info.Duration = int(info.RawDuration + 0.5)
+
+	// Icecast and Shoutcast radio URLs come through the generic extractor as a
+	// plain audio file that never ends, so yt-dlp can't tell they're live
+	if info.Extractor == "Generic" && info.Duration == 0 {
+		info.IsLive = true
+	}
+
+	switch {
+	case strings.HasPrefix(info.Extractor, "Soundcloud"):
+		info.Source = "SoundCloud"
+		// SoundCloud titles usually omit the artist
+		if info.Uploader != "" && !strings.Contains(info.Title, " - ") {
+			info.Title = info.Uploader + " - " + info.Title
+		}
+	case strings.HasPrefix(info.Extractor, "Bandcamp"):
+		info.Source = "Bandcamp"
+	case strings.HasPrefix(info.Extractor, "Youtube"):
+		info.Source = "YouTube"
+	default:
+		info.Source = info.Extractor
+	}
+
+	// SoundCloud and Bandcamp hand out HLS or short-lived stream URLs, so play from the page instead
+	if (info.Source == "SoundCloud" || info.Source == "Bandcamp") && info.WebpageURL != "" {
+		info.URL = info.WebpageURL
+	}
 }
 
 // ExtractInfo extracts video info using yt-dlp
@@ -130,16 +264,7 @@ func ExtractInfo(url, youtubeAPIKey, soundcloudAuthToken string) (*VideoInfo, er
 		"--no-playlist",
 		"--format", "bestaudio",
 	}
-
-	// Add API keys if available
-	if youtubeAPIKey != "" {
-		args = append(args, "--username", "oauth2", "--password", "")
-	}
-
-	if soundcloudAuthToken != "" {
-		args = append(args, "--add-header", "Authorization:OAuth "+soundcloudAuthToken)
-	}
-
+	args = append(args, ytdlpAuthArgs(youtubeAPIKey, soundcloudAuthToken)...)
 	args = append(args, url)
 
 	cmd := exec.Command("yt-dlp", args...)
@@ -152,10 +277,82 @@ func ExtractInfo(url, youtubeAPIKey, soundcloudAuthToken string) (*VideoInfo, er
 	if err := json.Unmarshal(output, &info); err != nil {
 		return nil, fmt.Errorf("failed to parse video info: %w", err)
 	}
+	info.normalize()
 
 	return &info, nil
 }
 
+// ExtractFlatEntries lists up to limit entries of a playlist or search without
+// resolving each one, which is much faster than a full extraction
+func ExtractFlatEntries(url string, limit int) ([]VideoInfo, error) {
+	cmd := exec.Command("yt-dlp",
+		"--dump-json",
+		"--flat-playlist",
+		"--playlist-end", fmt.Sprint(limit),
+		url)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract entries: %w", err)
+	}
+
+	var entries []VideoInfo
+	decoder := json.NewDecoder(strings.NewReader(string(output)))
+	for {
+		var info VideoInfo
+		if err := decoder.Decode(&info); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse entry: %w", err)
+		}
+		info.Duration = int(info.RawDuration + 0.5)
+		entries = append(entries, info)
+	}
+	return entries, nil
+}
+
+// ExtractCollectionInfo extracts every track of a set or album, calling onTrack
+// as each one is resolved so playback can start before the whole list is read
+func ExtractCollectionInfo(url, youtubeAPIKey, soundcloudAuthToken string, onTrack func(*VideoInfo)) error {
+	args := []string{
+		"--dump-json",
+		"--yes-playlist",
+		"--ignore-errors",
+		"--playlist-end", fmt.Sprint(maxCollectionTracks),
+		"--format", "bestaudio",
+	}
+	args = append(args, ytdlpAuthArgs(youtubeAPIKey, soundcloudAuthToken)...)
+	args = append(args, url)
+
+	cmd := exec.Command("yt-dlp", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start yt-dlp: %w", err)
+	}
+
+	decoder := json.NewDecoder(stdout)
+	for {
+		var info VideoInfo
+		if err := decoder.Decode(&info); err != nil {
+			if err != io.EOF {
+				cmd.Process.Kill()
+				cmd.Wait()
+				return fmt.Errorf("failed to parse track info: %w", err)
+			}
+			break
+		}
+		info.normalize()
+		onTrack(&info)
+	}
+
+	// --ignore-errors makes yt-dlp exit non-zero when any single track failed,
+	// so callers should only treat this as fatal if no tracks came through
+	return cmd.Wait()
+}
+
 func isLocalFile(path string) bool {
 	if filepath.IsAbs(path) {
 		if _, err := os.Stat(path); err == nil {
@@ -186,29 +383,96 @@ func (p *MusicPlayer) Connect(s *discordgo.Session, channelID string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.voiceConn != nil {
+	if p.connected {
 		return nil
 	}
 
-	vc, err := s.ChannelVoiceJoin(p.guildID, channelID, false, true)
-	if err != nil {
+	if err := p.backend.Join(s, p.guildID, channelID); err != nil {
 		return fmt.Errorf("failed to join voice channel: %w", err)
 	}
 
-	p.voiceConn = vc
+	p.connected = true
+	p.session = s
+	p.channelID = channelID
+	return nil
+}
+
+// Rejoin reconnects to the player's voice channel after Discord dropped the connection
+func (p *MusicPlayer) Rejoin() error {
+	p.mu.RLock()
+	s, channelID := p.session, p.channelID
+	p.mu.RUnlock()
+
+	if s == nil || channelID == "" {
+		return errors.New("not connected to voice channel")
+	}
+
+	if err := p.backend.Join(s, p.guildID, channelID); err != nil {
+		return fmt.Errorf("failed to rejoin voice channel: %w", err)
+	}
 	return nil
 }
 
+// ChannelID returns the voice channel the player belongs in, or "" if it left on purpose
+func (p *MusicPlayer) ChannelID() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.channelID
+}
+
+// SetChannelID records that the bot was moved to another voice channel
+func (p *MusicPlayer) SetChannelID(channelID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.channelID != "" {
+		p.channelID = channelID
+	}
+}
+
+func (p *MusicPlayer) voiceReady() bool {
+	return p.backend.Ready(p.guildID)
+}
+
+// waitForVoice blocks until the voice connection is usable again, giving up
+// after voiceReconnectTimeout or when playback is stopped
+func (p *MusicPlayer) waitForVoice() bool {
+	deadline := time.After(voiceReconnectTimeout)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopChan:
+			// Hand the stop back to playLoop
+			select {
+			case p.stopChan <- true:
+			default:
+			}
+			return false
+		case <-deadline:
+			return false
+		case <-ticker.C:
+			if p.voiceReady() {
+				return true
+			}
+		}
+	}
+}
+
 // Disconnect leaves the voice channel
 func (p *MusicPlayer) Disconnect() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.voiceConn != nil {
-		if err := p.voiceConn.Disconnect(); err != nil {
+	// Clear the channel first so the resulting voice state update isn't mistaken for a drop
+	p.channelID = ""
+	if p.connected {
+		if err := p.backend.Leave(p.session, p.guildID); err != nil {
 			return err
 		}
-		p.voiceConn = nil
+		p.connected = false
 	}
 
 	p.stopInternal()
@@ -262,6 +526,99 @@ func (p *MusicPlayer) MoveToTop(position int) error {
 	return nil
 }
 
+// SkipTo ends the current track and plays the one at position next. The tracks
+// in between are dropped, or moved to the end of the queue if keep is set.
+func (p *MusicPlayer) SkipTo(position int, keep bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.isPlaying {
+		return errors.New("nothing is playing")
+	}
+	if position < 0 || position >= len(p.queue) {
+		return errors.New("invalid position")
+	}
+
+	queue := make([]*Track, 0, len(p.queue))
+	queue = append(queue, p.queue[position:]...)
+	if keep {
+		queue = append(queue, p.queue[:position]...)
+	}
+	p.queue = queue
+
+	p.skipped = true
+	if p.stream != nil {
+		p.stream.Stop()
+	}
+	return nil
+}
+
+// AddTrackNext queues a track to play right after the current one
+func (p *MusicPlayer) AddTrackNext(track *Track) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queue = append([]*Track{track}, p.queue...)
+}
+
+// Shuffle randomizes the queue. Smart shuffle spreads each requester's tracks
+// out so the same person is never queued back to back when it can be avoided.
+func (p *MusicPlayer) Shuffle(smart bool) []*Track {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rand.Shuffle(len(p.queue), func(a, b int) {
+		p.queue[a], p.queue[b] = p.queue[b], p.queue[a]
+	})
+
+	if smart {
+		last := ""
+		if p.nowPlaying != nil {
+			last = p.nowPlaying.RequesterID
+		}
+		p.queue = spreadByRequester(p.queue, last)
+	}
+
+	queueCopy := make([]*Track, len(p.queue))
+	copy(queueCopy, p.queue)
+	return queueCopy
+}
+
+// spreadByRequester reorders already shuffled tracks so consecutive tracks have
+// different requesters, always drawing next from whoever has the most left
+func spreadByRequester(tracks []*Track, last string) []*Track {
+	byRequester := make(map[string][]*Track)
+	var requesters []string
+	for _, t := range tracks {
+		if _, ok := byRequester[t.RequesterID]; !ok {
+			requesters = append(requesters, t.RequesterID)
+		}
+		byRequester[t.RequesterID] = append(byRequester[t.RequesterID], t)
+	}
+
+	result := make([]*Track, 0, len(tracks))
+	for len(result) < len(tracks) {
+		pick := ""
+		for _, r := range requesters {
+			if len(byRequester[r]) == 0 || (r == last && len(requesters) > 1) {
+				continue
+			}
+			if pick == "" || len(byRequester[r]) > len(byRequester[pick]) {
+				pick = r
+			}
+		}
+		// Only the previous requester has tracks left
+		if pick == "" {
+			pick = last
+		}
+
+		result = append(result, byRequester[pick][0])
+		byRequester[pick] = byRequester[pick][1:]
+		last = pick
+	}
+	return result
+}
+
 // ClearQueue clears all tracks from the queue
 func (p *MusicPlayer) ClearQueue() {
 	p.mu.Lock()
@@ -283,7 +640,7 @@ func (p *MusicPlayer) Play() error {
 		return errors.New("queue is empty")
 	}
 
-	if p.voiceConn == nil {
+	if !p.connected {
 		p.mu.Unlock()
 		return errors.New("not connected to voice channel")
 	}
@@ -298,10 +655,32 @@ func (p *MusicPlayer) Play() error {
 func (p *MusicPlayer) playLoop() {
 	for {
 		p.mu.Lock()
+		if len(p.queue) == 0 && p.nowPlaying != nil && p.onAutoplay != nil {
+			last := p.nowPlaying
+			p.nowPlaying = nil
+			p.mu.Unlock()
+
+			next := p.onAutoplay(p.guildID, last)
+			select {
+			case <-p.stopChan:
+				p.mu.Lock()
+				p.isPlaying = false
+				p.mu.Unlock()
+				p.queueEnded()
+				return
+			default:
+			}
+			if next != nil {
+				p.AddTrack(next)
+			}
+			p.mu.Lock()
+		}
+
 		if len(p.queue) == 0 {
 			p.isPlaying = false
 			p.nowPlaying = nil
 			p.mu.Unlock()
+			p.queueEnded()
 			return
 		}
 
@@ -310,124 +689,105 @@ func (p *MusicPlayer) playLoop() {
 		p.nowPlaying = track
 		p.mu.Unlock()
 
-		if err := p.playTrack(track); err != nil {
-			fmt.Printf("Error playing track: %v\n", err)
+		if p.onTrackStart != nil {
+			p.onTrackStart(p.guildID, track)
 		}
 
-		select {
-		case <-p.stopChan:
+		// Seeking tears down the stream and restarts the same track at an offset
+		offset := 0
+		resumes := 0
+		for {
+			err := p.playTrack(track, offset)
+
 			p.mu.Lock()
-			p.isPlaying = false
-			p.nowPlaying = nil
+			seeking := p.seekPending
+			offset = p.seekTarget
+			p.seekPending = false
 			p.mu.Unlock()
-			return
-		default:
-		}
-	}
-}
 
-func (p *MusicPlayer) playTrack(track *Track) error {
-	options := dca.StdEncodeOptions
-	options.RawOutput = true
-	options.Bitrate = 128
-	options.Application = "audio"
-	options.Volume = p.volume
+			if seeking {
+				continue
+			}
 
-	// Handle local files
-	if track.IsLocal {
-		encodeSession, err := dca.EncodeFile(track.URL, options)
-		if err != nil {
-			return fmt.Errorf("failed to encode local file: %w", err)
+			// The voice connection dropped mid-track; pick up where it left off once it's back
+			if err != nil && resumes < maxTrackResumes && (errors.Is(err, errVoiceLost) || !p.voiceReady()) {
+				resumeAt := int(p.Position().Seconds())
+				if p.waitForVoice() {
+					resumes++
+					offset = resumeAt
+					continue
+				}
+			}
+			if err != nil {
+				fmt.Printf("Error playing track: %v\n", err)
+			}
+			break
 		}
-		defer encodeSession.Cleanup()
-
-		p.mu.Lock()
-		p.encoding = encodeSession
-		done := make(chan error)
-		streamSession := dca.NewStream(encodeSession, p.voiceConn, done)
-		p.streaming = streamSession
-		p.mu.Unlock()
 
 		select {
-		case err := <-done:
-			if err != nil && err != io.EOF {
-				return fmt.Errorf("streaming error: %w", err)
-			}
 		case <-p.stopChan:
 			p.mu.Lock()
-			if p.streaming != nil {
-				p.streaming.SetPaused(true)
-			}
-			if p.encoding != nil {
-				p.encoding.Cleanup()
-			}
+			p.isPlaying = false
+			p.nowPlaying = nil
 			p.mu.Unlock()
-			return nil
+			p.queueEnded()
+			return
+		default:
 		}
 
-		return nil
-	}
-
-	// Handle online URLs with yt-dlp
-	args := []string{
-		"--format", "bestaudio",
-		"--output", "-",
-		"--no-playlist",
+		p.mu.Lock()
+		switch {
+		case p.loopMode == LoopTrack && !p.skipped:
+			p.queue = append([]*Track{track}, p.queue...)
+		case p.loopMode == LoopQueue:
+			p.queue = append(p.queue, track)
+		}
+		p.skipped = false
+		p.mu.Unlock()
 	}
+}
 
-	if p.youtubeAPIKey != "" {
-		args = append(args, "--username", "oauth2", "--password", "")
+func (p *MusicPlayer) queueEnded() {
+	if p.onQueueEnd != nil {
+		go p.onQueueEnd(p.guildID)
 	}
+}
 
-	if p.soundcloudAuthToken != "" {
-		args = append(args, "--add-header", "Authorization:OAuth "+p.soundcloudAuthToken)
+func (p *MusicPlayer) playTrack(track *Track, startAt int) error {
+	if track.IsLive {
+		// A live stream can only be joined at its current position
+		startAt = 0
 	}
 
-	args = append(args, track.URL)
-
-	cmd := exec.Command("yt-dlp", args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start yt-dlp: %w", err)
-	}
+	p.mu.RLock()
+	opts := StreamOptions{StartAt: startAt, Volume: p.volume, Filters: p.filters, Normalize: p.normalize}
+	sponsorBlock := p.sponsorBlock
+	p.mu.RUnlock()
 
-	encodeSession, err := dca.EncodeMem(stdout, options)
+	stream, err := p.backend.Play(p.guildID, track, opts)
 	if err != nil {
-		cmd.Process.Kill()
-		return fmt.Errorf("failed to encode audio: %w", err)
+		return err
 	}
-	defer encodeSession.Cleanup()
 
 	p.mu.Lock()
-	p.encoding = encodeSession
-	done := make(chan error)
-	streamSession := dca.NewStream(encodeSession, p.voiceConn, done)
-	p.streaming = streamSession
+	p.stream = stream
+	p.isPaused = false
 	p.mu.Unlock()
 
+	if len(sponsorBlock) > 0 && !track.IsLocal && !track.IsLive {
+		watchDone := make(chan struct{})
+		defer close(watchDone)
+		go p.skipSponsorSegments(track, sponsorBlock, watchDone)
+	}
+
 	select {
-	case err := <-done:
-		if err != nil && err != io.EOF {
-			return fmt.Errorf("streaming error: %w", err)
-		}
+	case err := <-stream.Done():
+		return err
 	case <-p.stopChan:
-		p.mu.Lock()
-		if p.streaming != nil {
-			p.streaming.SetPaused(true)
-		}
-		if p.encoding != nil {
-			p.encoding.Cleanup()
-		}
-		p.mu.Unlock()
+		stream.Stop()
+		<-stream.Done()
 		return nil
 	}
-
-	cmd.Wait()
-	return nil
 }
 
 // Skip skips the current track
@@ -439,12 +799,9 @@ func (p *MusicPlayer) Skip() error {
 		return errors.New("nothing is playing")
 	}
 
-	if p.streaming != nil {
-		p.streaming.SetPaused(true)
-	}
-
-	if p.encoding != nil {
-		p.encoding.Cleanup()
+	p.skipped = true
+	if p.stream != nil {
+		p.stream.Stop()
 	}
 
 	return nil
@@ -465,16 +822,75 @@ func (p *MusicPlayer) stopInternal() {
 		}
 	}
 
-	if p.streaming != nil {
-		p.streaming.SetPaused(true)
-	}
-
-	if p.encoding != nil {
-		p.encoding.Cleanup()
+	if p.stream != nil {
+		p.stream.Stop()
 	}
 
 	p.isPlaying = false
 	p.nowPlaying = nil
+	p.seekPending = false
+	p.skipped = false
+}
+
+// Seek restarts the current track at the given offset in seconds
+func (p *MusicPlayer) Seek(seconds int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.isPlaying || p.nowPlaying == nil {
+		return errors.New("nothing is playing")
+	}
+	if seconds < 0 || p.nowPlaying.IsLive {
+		// Live streams rejoin at the live edge, which still reapplies filters
+		seconds = 0
+	}
+	if p.nowPlaying.Duration > 0 && seconds >= p.nowPlaying.Duration {
+		return errors.New("that's past the end of the track")
+	}
+
+	p.seekPending = true
+	p.seekTarget = seconds
+
+	// Ending the stream lets playLoop restart it at the new position
+	if p.stream != nil {
+		p.stream.Stop()
+	}
+	p.isPaused = false
+
+	return nil
+}
+
+// Position returns how far into the current track playback is
+func (p *MusicPlayer) Position() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.nowPlaying == nil || p.stream == nil {
+		return 0
+	}
+	return p.stream.Position()
+}
+
+// Filters returns the player's audio filters
+func (p *MusicPlayer) Filters() AudioFilters {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.filters
+}
+
+// SetFilters changes the audio filters used from the next stream on; apply
+// them to the current track with Seek
+func (p *MusicPlayer) SetFilters(filters AudioFilters) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.filters = filters
+}
+
+// SetNormalize turns loudness normalization on or off from the next stream on
+func (p *MusicPlayer) SetNormalize(normalize bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.normalize = normalize
 }
 
 // Pause pauses playback
@@ -486,8 +902,8 @@ func (p *MusicPlayer) Pause() error {
 		return errors.New("nothing is playing")
 	}
 
-	if p.streaming != nil {
-		p.streaming.SetPaused(true)
+	if p.stream != nil {
+		p.stream.SetPaused(true)
 		p.isPaused = true
 	}
 
@@ -503,8 +919,8 @@ func (p *MusicPlayer) Resume() error {
 		return errors.New("player is not paused")
 	}
 
-	if p.streaming != nil {
-		p.streaming.SetPaused(false)
+	if p.stream != nil {
+		p.stream.SetPaused(false)
 		p.isPaused = false
 	}
 
@@ -524,6 +940,30 @@ func (p *MusicPlayer) SetVolume(volume int) error {
 	return nil
 }
 
+// Volume returns the playback volume (0-100)
+func (p *MusicPlayer) Volume() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.volume
+}
+
+// LoopMode returns the player's loop mode
+func (p *MusicPlayer) LoopMode() LoopMode {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.loopMode
+}
+
+// SetLoopMode changes what happens to tracks once they finish
+func (p *MusicPlayer) SetLoopMode(mode LoopMode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.loopMode = mode
+}
+
 // NowPlaying returns the currently playing track
 func (p *MusicPlayer) NowPlaying() *Track {
 	p.mu.RLock()
@@ -553,7 +993,7 @@ func (p *MusicPlayer) IsConnected() bool {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	return p.voiceConn != nil
+	return p.connected
 }
 
 // MusicPermLevel represents permission levels for music commands
@@ -0,0 +1,287 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// nowPlayingRefresh is how often the live now-playing message is re-rendered
+const nowPlayingRefresh = 10 * time.Second
+
+// nowPlayingBarLength is the number of segments in the progress bar
+const nowPlayingBarLength = 15
+
+// nowPlayingController is a guild's live /nowplaying message
+type nowPlayingController struct {
+	ChannelID string
+	MessageID string
+	stop      chan struct{}
+}
+
+// NowPlayingTracker keeps a single live now-playing message per guild
+type NowPlayingTracker struct {
+	mu     sync.Mutex
+	guilds map[string]*nowPlayingController
+}
+
+// NewNowPlayingTracker creates a new now-playing tracker
+func NewNowPlayingTracker() *NowPlayingTracker {
+	return &NowPlayingTracker{
+		guilds: make(map[string]*nowPlayingController),
+	}
+}
+
+// Global now-playing tracker
+var nowPlayingControllers = NewNowPlayingTracker()
+
+// replace makes ctrl the guild's controller, returning the one it took over from
+func (t *NowPlayingTracker) replace(guildID string, ctrl *nowPlayingController) *nowPlayingController {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	old := t.guilds[guildID]
+	t.guilds[guildID] = ctrl
+	if old != nil {
+		close(old.stop)
+	}
+	return old
+}
+
+// remove drops ctrl if it is still the guild's controller
+func (t *NowPlayingTracker) remove(guildID string, ctrl *nowPlayingController) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.guilds[guildID] == ctrl {
+		delete(t.guilds, guildID)
+		close(ctrl.stop)
+	}
+}
+
+// progressBar draws how far through a track playback is
+func progressBar(position, duration int) string {
+	if duration <= 0 {
+		return ""
+	}
+	filled := min(position*nowPlayingBarLength/duration, nowPlayingBarLength-1)
+	return strings.Repeat("▬", filled) + "🔘" + strings.Repeat("▬", nowPlayingBarLength-filled-1)
+}
+
+// renderNowPlaying builds the now-playing embed and its control buttons
+func renderNowPlaying(player *MusicPlayer) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	var nowPlaying *Track
+	if player != nil {
+		nowPlaying = player.NowPlaying()
+	}
+	if nowPlaying == nil {
+		return &discordgo.MessageEmbed{
+			Title:       "Now Playing",
+			Description: "Nothing is playing right now.",
+			Color:       0xFF69B4,
+		}, []discordgo.MessageComponent{}
+	}
+
+	position := int(player.Position().Seconds())
+	var progress string
+	if nowPlaying.IsLive {
+		// There's no end to measure against, so show how long it's been on instead
+		progress = "🔴 LIVE · listening for " + formatMusicTimestamp(position)
+	} else {
+		progress = formatMusicTimestamp(position) + " / " + formatMusicDuration(nowPlaying.Duration)
+		if bar := progressBar(position, nowPlaying.Duration); bar != "" {
+			progress = bar + "\n" + progress
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Now Playing",
+		Description: fmt.Sprintf("**%s**\n\n%s", nowPlaying.Title, progress),
+		Color:       0xFF69B4,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Requested by", Value: nowPlaying.Requester, Inline: true},
+			{Name: "Volume", Value: fmt.Sprintf("%d%%", player.Volume()), Inline: true},
+			{Name: "Loop", Value: player.LoopMode().String(), Inline: true},
+		},
+	}
+
+	if nowPlaying.Thumbnail != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: nowPlaying.Thumbnail}
+	}
+
+	if nowPlaying.Source != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Source", Value: nowPlaying.Source, Inline: true})
+	}
+
+	if filters := player.Filters(); filters != (AudioFilters{}) {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Filters", Value: filters.Describe(), Inline: true})
+	}
+
+	if queue := player.GetQueue(); len(queue) > 0 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Up next: %s (%d in queue)", queue[0].Title, len(queue))}
+	}
+
+	pauseButton := discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "⏸️"}, Style: discordgo.SecondaryButton, CustomID: "np:pause"}
+	if player.IsPaused() {
+		pauseButton = discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "▶️"}, Style: discordgo.SuccessButton, CustomID: "np:pause"}
+		embed.Title = "Now Playing (Paused)"
+	}
+	loopButton := discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "🔁"}, Style: discordgo.SecondaryButton, CustomID: "np:loop"}
+	switch player.LoopMode() {
+	case LoopTrack:
+		loopButton.Emoji.Name = "🔂"
+		loopButton.Style = discordgo.PrimaryButton
+	case LoopQueue:
+		loopButton.Style = discordgo.PrimaryButton
+	}
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			pauseButton,
+			discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "⏭️"}, Style: discordgo.SecondaryButton, CustomID: "np:skip"},
+			discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "⏹️"}, Style: discordgo.DangerButton, CustomID: "np:stop"},
+			loopButton,
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "🔉"}, Style: discordgo.SecondaryButton, CustomID: "np:voldown", Disabled: player.Volume() <= 0},
+			discordgo.Button{Emoji: &discordgo.ComponentEmoji{Name: "🔊"}, Style: discordgo.SecondaryButton, CustomID: "np:volup", Disabled: player.Volume() >= 100},
+		}},
+	}
+	return embed, components
+}
+
+// runNowPlaying keeps a controller message current until playback ends or a
+// newer /nowplaying takes over
+func (b *Bot) runNowPlaying(guildID string, ctrl *nowPlayingController) {
+	ticker := time.NewTicker(nowPlayingRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctrl.stop:
+			return
+		case <-ticker.C:
+		}
+
+		player := b.MusicManager.FindPlayer(guildID)
+		embed, components := renderNowPlaying(player)
+		_, err := b.Session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         ctrl.MessageID,
+			Channel:    ctrl.ChannelID,
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &components,
+		})
+		// Stop once the message is gone or there's nothing left to show
+		if err != nil || player == nil || player.NowPlaying() == nil {
+			nowPlayingControllers.remove(guildID, ctrl)
+			return
+		}
+	}
+}
+
+// handleNowPlayingComponent handles the now-playing control buttons
+func (ch *CommandHandler) handleNowPlayingComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" || i.Member == nil {
+		return
+	}
+
+	player := ch.bot.MusicManager.FindPlayer(i.GuildID)
+	if player == nil || player.NowPlaying() == nil {
+		respondEphemeral(s, i, "Nothing is currently playing.")
+		return
+	}
+	if _, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID); err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+
+	settings, err := ch.bot.DB.GetMusicSettings(i.GuildID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get music settings.")
+		return
+	}
+	permLevel := GetMusicPermLevel(s, i.GuildID, i.Member.User.ID, settings.DJRoleID, settings.ModRoleID)
+
+	_, action, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	switch action {
+	case "pause":
+		if player.IsPaused() {
+			err = player.Resume()
+		} else {
+			err = player.Pause()
+		}
+	case "skip":
+		// Only DJ+ can skip others' tracks
+		if player.NowPlaying().Requester != i.Member.User.Username && permLevel < MusicPermDJ {
+			respondEphemeral(s, i, "You need DJ role to skip other users' tracks.")
+			return
+		}
+		err = player.Skip()
+	case "stop":
+		if permLevel < MusicPermDJ {
+			respondEphemeral(s, i, "You need DJ role to stop playback.")
+			return
+		}
+		player.Stop()
+		player.ClearQueue()
+		ch.bot.DB.ClearMusicQueue(i.GuildID)
+	case "loop":
+		if permLevel < MusicPermDJ {
+			respondEphemeral(s, i, "You need DJ role to change the loop mode.")
+			return
+		}
+		player.SetLoopMode((player.LoopMode() + 1) % 3)
+	case "voldown", "volup":
+		if permLevel < MusicPermDJ {
+			respondEphemeral(s, i, "You need DJ role to change the volume.")
+			return
+		}
+		level := player.Volume() + 10
+		if action == "voldown" {
+			level = player.Volume() - 10
+		}
+		level = max(0, min(level, 100))
+		if err = player.SetVolume(level); err == nil {
+			ch.bot.DB.UpdateMusicVolume(i.GuildID, level)
+		}
+	default:
+		return
+	}
+	if err != nil {
+		respondEphemeral(s, i, "Failed: "+err.Error())
+		return
+	}
+
+	// Skipping takes a moment to start the next track
+	if action == "skip" {
+		time.Sleep(time.Second)
+	}
+
+	embed, components := renderNowPlaying(player)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
@@ -0,0 +1,175 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+)
+
+// libraryRescanInterval is how often music folders are re-indexed in the background
+const libraryRescanInterval = 6 * time.Hour
+
+// localAudioExts are the file types the music folder commands pick up
+var localAudioExts = map[string]bool{".mp3": true, ".wav": true, ".ogg": true, ".flac": true, ".m4a": true, ".opus": true}
+
+// libraryScanMu keeps scans from running over each other, probing the same files twice
+var libraryScanMu sync.Mutex
+
+// ffprobeOutput is the part of ffprobe's JSON output the index uses
+type ffprobeOutput struct {
+	Format struct {
+		Duration string            `json:"duration"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// probeLocalTrack reads a file's tags and length with ffprobe, falling back to
+// the file and folder names for anything untagged
+func probeLocalTrack(fullPath, relPath string) *database.LocalTrack {
+	track := &database.LocalTrack{
+		Path:  relPath,
+		Title: strings.TrimSuffix(filepath.Base(relPath), filepath.Ext(relPath)),
+	}
+	if dir := filepath.Dir(relPath); dir != "." {
+		// Libraries are usually laid out a folder per album
+		track.Album = filepath.Base(dir)
+	}
+
+	output, err := exec.Command("ffprobe", "-v", "quiet", "-print_format", "json", "-show_format", fullPath).Output()
+	if err != nil {
+		return track
+	}
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return track
+	}
+
+	if seconds, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		track.Duration = int(seconds + 0.5)
+	}
+	// Tag names are upper case in FLAC and Ogg files and lower case in ID3
+	tags := make(map[string]string, len(probe.Format.Tags))
+	for k, v := range probe.Format.Tags {
+		tags[strings.ToLower(k)] = strings.TrimSpace(v)
+	}
+	if tags["title"] != "" {
+		track.Title = tags["title"]
+	}
+	track.Artist = tags["artist"]
+	if track.Artist == "" {
+		track.Artist = tags["album_artist"]
+	}
+	if tags["album"] != "" {
+		track.Album = tags["album"]
+	}
+	// Track numbers are often written as "3/12"
+	number, _, _ := strings.Cut(tags["track"], "/")
+	track.TrackNumber, _ = strconv.Atoi(number)
+	return track
+}
+
+// scanMusicLibrary brings a guild's library index in line with its music folder,
+// only probing files that are new or changed since the last scan
+func (b *Bot) scanMusicLibrary(guildID, folder string) (updated, removed int, err error) {
+	libraryScanMu.Lock()
+	defer libraryScanMu.Unlock()
+
+	// A missing folder (say an unmounted drive) shouldn't wipe the index
+	if _, err := os.Stat(folder); err != nil {
+		return 0, 0, fmt.Errorf("music folder unavailable: %w", err)
+	}
+
+	known, err := b.DB.GetLocalTrackModTimes(guildID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	seen := make(map[string]bool)
+	filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !localAudioExts[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		relPath, err := filepath.Rel(folder, path)
+		if err != nil {
+			return nil
+		}
+		seen[relPath] = true
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		modTime := info.ModTime().Unix()
+		if indexed, ok := known[relPath]; ok && indexed == modTime {
+			return nil
+		}
+
+		track := probeLocalTrack(path, relPath)
+		track.GuildID = guildID
+		track.ModTime = modTime
+		if b.DB.SaveLocalTrack(track) == nil {
+			updated++
+		}
+		return nil
+	})
+
+	for relPath := range known {
+		if !seen[relPath] && b.DB.DeleteLocalTrack(guildID, relPath) == nil {
+			removed++
+		}
+	}
+	return updated, removed, nil
+}
+
+// scanMusicLibraries re-indexes every guild's music folder
+func (b *Bot) scanMusicLibraries() {
+	folders, err := b.DB.GetMusicFolders()
+	if err != nil {
+		log.Printf("[Music] Failed to list music folders: %v", err)
+		return
+	}
+	for guildID, folder := range folders {
+		updated, removed, err := b.scanMusicLibrary(guildID, folder)
+		if err != nil {
+			log.Printf("[Music] Library scan failed in guild %s: %v", guildID, err)
+			continue
+		}
+		if updated > 0 || removed > 0 {
+			log.Printf("[Music] Library scan in guild %s: %d updated, %d removed", guildID, updated, removed)
+		}
+	}
+}
+
+// localTrackTitle names an indexed file the way it's shown in the queue
+func localTrackTitle(t *database.LocalTrack) string {
+	if t.Artist != "" {
+		return t.Artist + " - " + t.Title
+	}
+	return t.Title
+}
@@ -0,0 +1,151 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// searchResultCount is how many matches /play offers for a search query
+const searchResultCount = 5
+
+// playSearch offers the top search results for a query in a select menu
+func (ch *CommandHandler) playSearch(s *discordgo.Session, i *discordgo.InteractionCreate, query string) {
+	entries, err := ExtractFlatEntries(fmt.Sprintf("ytsearch%d:%s", searchResultCount, query), searchResultCount)
+	if err != nil {
+		editResponse(s, i, "Search failed: "+err.Error())
+		return
+	}
+
+	var list strings.Builder
+	var options []discordgo.SelectMenuOption
+	for _, entry := range entries {
+		if entry.URL == "" {
+			continue
+		}
+		channel := entry.Channel
+		if channel == "" {
+			channel = entry.Uploader
+		}
+		length := formatTrackLength(entry.Duration, entry.IsLive)
+
+		list.WriteString(fmt.Sprintf("**%d.** %s\n%s · %s\n", len(options)+1, entry.Title, channel, length))
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncate(fmt.Sprintf("%d. %s", len(options)+1, entry.Title), 100),
+			Description: truncate(channel+" · "+length, 100),
+			Value:       entry.URL,
+		})
+	}
+	if len(options) == 0 {
+		editResponse(s, i, "No results found for that search.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Search Results",
+		Description: list.String(),
+		Color:       0x5865F2,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Pick a track below to queue it"},
+	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{embed},
+		Components: &[]discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    "search:" + i.Member.User.ID,
+					Placeholder: "Choose a track",
+					Options:     options,
+				},
+			}},
+		},
+	})
+}
+
+// handleSearchComponent queues the search result the member picked
+func (ch *CommandHandler) handleSearchComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" || i.Member == nil {
+		return
+	}
+	_, userID, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	if userID != i.Member.User.ID {
+		respondEphemeral(s, i, "Only the member who searched can pick a result.")
+		return
+	}
+	values := i.MessageComponentData().Values
+	if len(values) == 0 {
+		return
+	}
+
+	channelID, err := GetUserVoiceChannel(s, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "You need to be in a voice channel to use this command.")
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+
+	// Replace the results with the outcome so the menu can't be used twice
+	finish := func(embed *discordgo.MessageEmbed) {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &[]discordgo.MessageComponent{},
+		})
+	}
+
+	player := ch.bot.MusicManager.GetPlayer(i.GuildID)
+	if !player.IsConnected() {
+		if err := player.Connect(s, channelID); err != nil {
+			finish(errorEmbed("Playback Failed", "Failed to join voice channel: "+err.Error()))
+			return
+		}
+	}
+
+	info, err := ExtractInfo(values[0], ch.bot.Config().APIs.YouTubeAPIKey, ch.bot.Config().APIs.SoundCloudAuthToken)
+	if err != nil {
+		finish(errorEmbed("Playback Failed", "Failed to get track info: "+err.Error()))
+		return
+	}
+
+	track := &Track{
+		Title:       info.Title,
+		URL:         info.URL,
+		Duration:    info.Duration,
+		Thumbnail:   info.Thumbnail,
+		Requester:   i.Member.User.Username,
+		RequesterID: i.Member.User.ID,
+		IsLive:      info.IsLive,
+		Source:      info.Source,
+		PageURL:     info.WebpageURL,
+	}
+
+	if reason, _ := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID).check(player, track); reason != "" {
+		finish(errorEmbed("Queue Limit", reason))
+		return
+	}
+
+	wasPlaying := player.IsPlaying()
+	if err := ch.queueTrack(i, player, channelID, track); err != nil {
+		finish(errorEmbed("Playback Failed", "Failed to start playback: "+err.Error()))
+		return
+	}
+	finish(queuedTrackEmbed(player, track, wasPlaying))
+}
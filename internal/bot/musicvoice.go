@@ -0,0 +1,84 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// musicIdleTimeout is how long the bot lingers in voice after the queue ends when 24/7 mode is off
+const musicIdleTimeout = 5 * time.Minute
+
+// onMusicQueueEnd schedules leaving voice once a player has been idle for musicIdleTimeout
+func (b *Bot) onMusicQueueEnd(guildID string) {
+	settings, err := b.DB.GetMusicSettings(guildID)
+	if err != nil || settings.StayConnected {
+		return
+	}
+
+	time.AfterFunc(musicIdleTimeout, func() {
+		player := b.MusicManager.FindPlayer(guildID)
+		if player == nil || player.IsPlaying() {
+			return
+		}
+		// 24/7 may have been switched on while we waited
+		if settings, err := b.DB.GetMusicSettings(guildID); err == nil && settings.StayConnected {
+			return
+		}
+		b.MusicManager.RemovePlayer(guildID)
+		b.DB.ClearMusicQueue(guildID)
+	})
+}
+
+// onBotVoiceStateUpdate follows the bot's own voice state so a player that was
+// moved keeps the right channel, and one that was dropped rejoins and resumes
+func (b *Bot) onBotVoiceStateUpdate(v *discordgo.VoiceStateUpdate) {
+	player := b.MusicManager.FindPlayer(v.GuildID)
+	if player == nil {
+		return
+	}
+
+	if v.ChannelID != "" {
+		player.SetChannelID(v.ChannelID)
+		return
+	}
+
+	// An empty channel means we left on purpose
+	if player.ChannelID() == "" {
+		return
+	}
+
+	settings, _ := b.DB.GetMusicSettings(v.GuildID)
+	if !player.IsPlaying() && (settings == nil || !settings.StayConnected) {
+		b.MusicManager.RemovePlayer(v.GuildID)
+		return
+	}
+
+	go func() {
+		// Give Discord a moment to finish tearing down the old session
+		time.Sleep(2 * time.Second)
+		if err := player.Rejoin(); err != nil {
+			log.Printf("[Music] Failed to rejoin voice in guild %s: %v", v.GuildID, err)
+			b.MusicManager.RemovePlayer(v.GuildID)
+			return
+		}
+		log.Printf("[Music] Rejoined voice in guild %s", v.GuildID)
+	}()
+}
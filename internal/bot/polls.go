@@ -0,0 +1,250 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Scheduled event type that closes a poll when its duration runs out
+const eventPollClose = "pollclose"
+
+const maxPollOptions = 10
+
+var pollEmojis = []string{"1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣", "7️⃣", "8️⃣", "9️⃣", "🔟"}
+
+// pollBar renders a 10-segment result bar
+func pollBar(count, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = (count*10 + total/2) / total
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", 10-filled)
+}
+
+// renderPoll builds the poll embed with live result bars and its buttons
+func renderPoll(poll *database.Poll, votes map[int][]string) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	total := 0
+	best := 0
+	for idx := range poll.Options {
+		total += len(votes[idx])
+		if len(votes[idx]) > best {
+			best = len(votes[idx])
+		}
+	}
+
+	var desc strings.Builder
+	fmt.Fprintf(&desc, "Poll by <@%s>\n\n", poll.UserID)
+	for idx, opt := range poll.Options {
+		count := len(votes[idx])
+		percent := 0
+		if total > 0 {
+			percent = count * 100 / total
+		}
+		name := opt
+		if poll.Closed && best > 0 && count == best {
+			name = "**" + opt + "** 🏆"
+		}
+		fmt.Fprintf(&desc, "%s %s\n`%s` %d%% (%d)\n", pollEmojis[idx], name, pollBar(count, total), percent, count)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       poll.Question,
+		Description: desc.String(),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Poll #%d • %d vote(s)", poll.ID, total),
+		},
+	}
+	if poll.Anonymous {
+		embed.Footer.Text += " • Anonymous"
+	}
+	if poll.Closed {
+		embed.Title = "[Closed] " + poll.Question
+		embed.Color = 0x99AAB5
+	} else if poll.EndsAt > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Ends",
+			Value: fmt.Sprintf("<t:%d:R>", poll.EndsAt/1000),
+		})
+	}
+
+	var rows []discordgo.MessageComponent
+	var buttons []discordgo.MessageComponent
+	for idx, opt := range poll.Options {
+		buttons = append(buttons, discordgo.Button{
+			Label:    truncate(opt, 80),
+			Emoji:    &discordgo.ComponentEmoji{Name: pollEmojis[idx]},
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("poll:vote:%d:%d", poll.ID, idx),
+			Disabled: poll.Closed,
+		})
+		if len(buttons) == 5 {
+			rows = append(rows, discordgo.ActionsRow{Components: buttons})
+			buttons = nil
+		}
+	}
+	if len(buttons) > 0 {
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+	rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "Results",
+			Emoji:    &discordgo.ComponentEmoji{Name: "📊"},
+			Style:    discordgo.PrimaryButton,
+			CustomID: fmt.Sprintf("poll:results:%d", poll.ID),
+		},
+		discordgo.Button{
+			Label:    "End Poll",
+			Style:    discordgo.DangerButton,
+			CustomID: fmt.Sprintf("poll:end:%d", poll.ID),
+			Disabled: poll.Closed,
+		},
+	}})
+
+	return embed, rows
+}
+
+// handlePollComponent handles "poll:vote:<id>:<option>", "poll:results:<id>" and "poll:end:<id>"
+func (ch *CommandHandler) handlePollComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) < 3 {
+		return
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return
+	}
+
+	poll, err := ch.bot.DB.GetPoll(id)
+	if err != nil || poll == nil {
+		respondEphemeral(s, i, "This poll no longer exists.")
+		return
+	}
+
+	switch parts[1] {
+	case "vote":
+		if len(parts) != 4 {
+			return
+		}
+		option, err := strconv.Atoi(parts[3])
+		if err != nil || option < 0 || option >= len(poll.Options) {
+			return
+		}
+		if poll.Closed {
+			respondEphemeral(s, i, "This poll is closed.")
+			return
+		}
+		if err := ch.bot.DB.TogglePollVote(poll.ID, i.Member.User.ID, option); err != nil {
+			respondEphemeral(s, i, "Failed to record your vote.")
+			return
+		}
+		votes, err := ch.bot.DB.GetPollVotes(poll.ID)
+		if err != nil {
+			return
+		}
+		embed, components := renderPoll(poll, votes)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: components,
+			},
+		})
+
+	case "results":
+		ch.pollResultsHandler(s, i, poll)
+
+	case "end":
+		if i.Member.User.ID != poll.UserID && !isAdmin(s, i.GuildID, i.Member.User.ID) {
+			respondEphemeral(s, i, "Only the poll creator or an administrator can end this poll.")
+			return
+		}
+		if !poll.Closed {
+			ch.bot.DB.DeleteScheduledEventByTarget(poll.GuildID, eventPollClose, strconv.FormatInt(poll.ID, 10))
+			ch.bot.closePoll(poll.ID)
+		}
+		respondEphemeral(s, i, "The poll has been closed.")
+	}
+}
+
+// pollResultsHandler shows the full results, including voters for public polls
+func (ch *CommandHandler) pollResultsHandler(s *discordgo.Session, i *discordgo.InteractionCreate, poll *database.Poll) {
+	votes, err := ch.bot.DB.GetPollVotes(poll.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to get poll results.")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "📊 " + poll.Question,
+		Color: 0x5865F2,
+	}
+	for idx, opt := range poll.Options {
+		voters := votes[idx]
+		value := fmt.Sprintf("%d vote(s)", len(voters))
+		mentions := make([]string, len(voters))
+		voted := false
+		for n, userID := range voters {
+			mentions[n] = "<@" + userID + ">"
+			if userID == i.Member.User.ID {
+				voted = true
+			}
+		}
+		if !poll.Anonymous && len(voters) > 0 {
+			value += "\n" + strings.Join(mentions, " ")
+		}
+		if voted {
+			value += "\n*You voted for this*"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  truncate(pollEmojis[idx]+" "+opt, 256),
+			Value: truncate(value, 1024),
+		})
+	}
+	respondEmbedEphemeral(s, i, embed)
+}
+
+// closePoll closes a poll and updates its message with the final results
+func (b *Bot) closePoll(id int64) {
+	if err := b.DB.ClosePoll(id); err != nil {
+		return
+	}
+	poll, err := b.DB.GetPoll(id)
+	if err != nil || poll == nil || poll.MessageID == "" {
+		return
+	}
+	votes, err := b.DB.GetPollVotes(id)
+	if err != nil {
+		return
+	}
+	embed, components := renderPoll(poll, votes)
+	b.Session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         poll.MessageID,
+		Channel:    poll.ChannelID,
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+}
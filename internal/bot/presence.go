@@ -0,0 +1,103 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/config"
+	"github.com/blubskye/himiko/internal/updater"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Shown when no statuses are configured
+var defaultPresence = config.PresenceStatus{Type: "playing", Text: "/help | Prefix: {prefix}"}
+
+// presenceTypes maps config names to Discord activity types
+var presenceTypes = map[string]discordgo.ActivityType{
+	"playing":   discordgo.ActivityTypeGame,
+	"watching":  discordgo.ActivityTypeWatching,
+	"listening": discordgo.ActivityTypeListening,
+	"competing": discordgo.ActivityTypeCompeting,
+}
+
+// presenceStatuses returns the configured rotation, or the default status
+func (b *Bot) presenceStatuses() []config.PresenceStatus {
+	if len(b.Config().Presence.Statuses) == 0 {
+		return []config.PresenceStatus{defaultPresence}
+	}
+	return b.Config().Presence.Statuses
+}
+
+// rotatePresence moves to the next status on the configured interval. The
+// interval and list are read from the config each time, so edits and reloads
+// apply without a restart.
+func (b *Bot) rotatePresence() {
+	for {
+		interval := time.Duration(b.Config().Presence.Interval) * time.Second
+		if interval < 15*time.Second {
+			// Discord only allows a handful of presence updates per minute
+			interval = 15 * time.Second
+		}
+
+		select {
+		case <-b.stopChan:
+			return
+		case <-time.After(interval):
+			b.applyPresence()
+		}
+	}
+}
+
+// applyPresence shows the next status in the rotation
+func (b *Bot) applyPresence() {
+	statuses := b.presenceStatuses()
+
+	b.presenceMu.Lock()
+	if b.presenceIndex >= len(statuses) {
+		b.presenceIndex = 0
+	}
+	status := statuses[b.presenceIndex]
+	b.presenceIndex++
+	b.presenceMu.Unlock()
+
+	b.Session.UpdateStatusComplex(discordgo.UpdateStatusData{
+		Status: string(discordgo.StatusOnline),
+		Activities: []*discordgo.Activity{{
+			Name: truncate(b.fillPresence(status.Text), 128),
+			Type: presenceTypes[status.Type],
+		}},
+	})
+}
+
+// fillPresence replaces the status placeholders
+func (b *Bot) fillPresence(text string) string {
+	guilds := b.Session.State.Guilds
+	users := 0
+	for _, g := range guilds {
+		users += g.MemberCount
+	}
+
+	return strings.NewReplacer(
+		"{guilds}", strconv.Itoa(len(guilds)),
+		"{users}", strconv.Itoa(users),
+		"{version}", "v"+updater.GetCurrentVersion(),
+		"{prefix}", b.Config().Prefix,
+	).Replace(text)
+}
@@ -0,0 +1,198 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Largest /purge amount; anything over 100 runs as an extended purge
+	maxPurgeMessages = 5000
+
+	// How many messages an extended purge looks through when filters skip most of them
+	maxPurgeScan = 20000
+
+	// Messages older than this can't be bulk deleted, with a little slack for clock drift
+	bulkDeleteMaxAge = 14*24*time.Hour - time.Minute
+
+	// How often an extended purge edits its progress message
+	purgeProgressInterval = 3 * time.Second
+)
+
+// purgeJob is an extended purge in progress
+type purgeJob struct {
+	userID    string
+	cancelled atomic.Bool
+}
+
+// Running extended purges, keyed by a random token carried in the cancel button ID
+var purgeJobs sync.Map
+
+// extendedPurge deletes up to amount matching messages, paging back through the
+// channel's history. Recent messages are bulk deleted 100 at a time and older
+// ones are deleted one by one. Progress goes to a message in the channel rather
+// than the deferred response, since a long purge can outlive the interaction token.
+func (ch *CommandHandler) extendedPurge(s *discordgo.Session, i *discordgo.InteractionCreate, amount int, filterUser *discordgo.User, contains string) {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+	job := &purgeJob{userID: i.Member.User.ID}
+	purgeJobs.Store(token, job)
+	defer purgeJobs.Delete(token)
+
+	cancelButton := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Cancel", Style: discordgo.DangerButton, CustomID: "purge:cancel:" + token},
+		}},
+	}
+	progressMsg, err := postProgress(s, i.ChannelID, fmt.Sprintf("Purging up to %d messages...", amount), cancelButton)
+	if err != nil {
+		editResponse(s, i, "Failed to post the purge progress message: "+err.Error())
+		return
+	}
+	editResponse(s, i, fmt.Sprintf("Purging up to %d messages. Progress is shown in the channel.", amount))
+	progress := func(text string) {
+		progressMsg.update(text, cancelButton)
+	}
+
+	scanned, deleted, failed := 0, 0, 0
+	lastProgress := time.Now()
+	before := ""
+
+scan:
+	for deleted+failed < amount && scanned < maxPurgeScan && !job.cancelled.Load() {
+		messages, err := s.ChannelMessages(i.ChannelID, 100, before, "", "")
+		if err != nil {
+			failed++
+			break
+		}
+		if len(messages) == 0 {
+			break
+		}
+		scanned += len(messages)
+		before = messages[len(messages)-1].ID
+
+		var recent, old []string
+		for _, msg := range messages {
+			if msg.ID == i.ID || msg.ID == progressMsg.messageID {
+				continue
+			}
+			if filterUser != nil && msg.Author.ID != filterUser.ID {
+				continue
+			}
+			if contains != "" && !containsWord(msg.Content, contains) {
+				continue
+			}
+			if deleted+failed+len(recent)+len(old) >= amount {
+				break
+			}
+
+			msgTime, _ := discordgo.SnowflakeTimestamp(msg.ID)
+			if time.Since(msgTime) < bulkDeleteMaxAge {
+				recent = append(recent, msg.ID)
+			} else {
+				old = append(old, msg.ID)
+			}
+		}
+
+		switch len(recent) {
+		case 0:
+		case 1:
+			err = s.ChannelMessageDelete(i.ChannelID, recent[0])
+		default:
+			err = s.ChannelMessagesBulkDelete(i.ChannelID, recent)
+		}
+		if err != nil {
+			failed += len(recent)
+		} else {
+			deleted += len(recent)
+		}
+
+		for _, id := range old {
+			if job.cancelled.Load() {
+				break scan
+			}
+			if err := s.ChannelMessageDelete(i.ChannelID, id); err != nil {
+				failed++
+			} else {
+				deleted++
+			}
+
+			if time.Since(lastProgress) >= purgeProgressInterval {
+				progress(fmt.Sprintf("Purging... %d/%d deleted (deleting messages older than 14 days one at a time)", deleted, amount))
+				lastProgress = time.Now()
+			}
+		}
+
+		if time.Since(lastProgress) >= purgeProgressInterval {
+			progress(fmt.Sprintf("Purging... %d/%d deleted, %d messages checked", deleted, amount, scanned))
+			lastProgress = time.Now()
+		}
+		if len(messages) < 100 {
+			break
+		}
+	}
+
+	var result strings.Builder
+	if job.cancelled.Load() {
+		result.WriteString(fmt.Sprintf("Purge cancelled after deleting %d messages.", deleted))
+	} else {
+		result.WriteString(fmt.Sprintf("Successfully deleted %d messages.", deleted))
+	}
+	if failed > 0 {
+		result.WriteString(fmt.Sprintf(" %d couldn't be deleted.", failed))
+	}
+	if scanned >= maxPurgeScan && deleted+failed < amount {
+		result.WriteString(fmt.Sprintf(" Stopped after checking %d messages.", maxPurgeScan))
+	}
+
+	progressMsg.update(result.String(), nil)
+}
+
+// handlePurgeComponent handles the cancel button, custom ID "purge:cancel:<token>"
+func (ch *CommandHandler) handlePurgeComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 3 || parts[1] != "cancel" || i.Member == nil {
+		return
+	}
+
+	value, ok := purgeJobs.Load(parts[2])
+	if !ok {
+		respondEphemeral(s, i, "That purge has already finished.")
+		return
+	}
+	job := value.(*purgeJob)
+	if job.userID != i.Member.User.ID {
+		respondEphemeral(s, i, "Only the moderator who started this purge can cancel it.")
+		return
+	}
+	job.cancelled.Store(true)
+
+	// The purge loop posts the final count once it stops
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+}
@@ -0,0 +1,84 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// quoteEmbed shows a quote with its author and a link back to the original message
+func quoteEmbed(s *discordgo.Session, q *database.Quote) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Description: truncate(q.Content, 4000),
+		Color:       0xFF69B4,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Quote #%d", q.Number)},
+		Timestamp:   q.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if user, err := s.User(q.UserID); err == nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: user.Username, IconURL: avatarURL(user)}
+	}
+	if q.MessageID != "" {
+		embed.Description += fmt.Sprintf("\n\n[Jump to message](https://discord.com/channels/%s/%s/%s)", q.GuildID, q.ChannelID, q.MessageID)
+	}
+	return embed
+}
+
+// quoteFromMessage builds a quote of a message; it's nil for a message with nothing to quote
+func quoteFromMessage(guildID, addedBy string, m *discordgo.Message) *database.Quote {
+	content := m.Content
+	if content == "" && len(m.Attachments) > 0 {
+		content = m.Attachments[0].URL
+	}
+	if content == "" || m.Author == nil {
+		return nil
+	}
+	return &database.Quote{
+		GuildID:   guildID,
+		UserID:    m.Author.ID,
+		Content:   content,
+		ChannelID: m.ChannelID,
+		MessageID: m.ID,
+		AddedBy:   addedBy,
+	}
+}
+
+// postDailyQuotes posts a random quote in each guild whose daily quote is due
+func (b *Bot) postDailyQuotes() {
+	now := time.Now().UTC()
+	day := now.Format(activityDayFormat)
+	configs, err := b.DB.GetDueDailyQuotes(day, now.Hour())
+	if err != nil {
+		return
+	}
+
+	for _, cfg := range configs {
+		// Mark first so a failing channel doesn't retry every tick
+		b.DB.MarkDailyQuotePosted(cfg.GuildID, day)
+
+		q, err := b.DB.GetRandomQuote(cfg.GuildID, "")
+		if err != nil || q == nil {
+			continue
+		}
+		embed := quoteEmbed(b.Session, q)
+		embed.Title = "Quote of the Day"
+		b.Session.ChannelMessageSendEmbed(cfg.ChannelID, embed)
+	}
+}
@@ -0,0 +1,83 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/blubskye/himiko/internal/rankcard"
+	"github.com/bwmarrin/discordgo"
+)
+
+// renderRankCard draws a user's rank card using their saved colors
+func (b *Bot) renderRankCard(user *discordgo.User, xpData *database.UserXP, rank int) (*bytes.Buffer, error) {
+	card := &rankcard.Card{
+		Username:   user.Username,
+		Rank:       rank,
+		Level:      xpData.Level,
+		TotalXP:    xpData.XP,
+		Background: rankcard.DefaultBackground,
+		Accent:     rankcard.DefaultAccent,
+	}
+
+	levelStart := database.XPForLevel(xpData.Level)
+	card.LevelXP = xpData.XP - levelStart
+	card.LevelNeed = database.XPForLevel(xpData.Level+1) - levelStart
+
+	if settings, err := b.DB.GetRankCardSettings(user.ID); err == nil {
+		if settings.Background != nil {
+			card.Background = rankcard.FromInt(*settings.Background)
+		}
+		if settings.Accent != nil {
+			card.Accent = rankcard.FromInt(*settings.Accent)
+		}
+	}
+
+	// A missing avatar just leaves the placeholder circle
+	if avatar, err := fetchImage(user.AvatarURL("256")); err == nil {
+		card.Avatar = avatar
+	}
+
+	var buf bytes.Buffer
+	if err := rankcard.Render(&buf, card); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// fetchImage downloads and decodes a PNG, JPEG or GIF image
+func fetchImage(url string) (image.Image, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	img, _, err := image.Decode(resp.Body)
+	return img, err
+}
@@ -0,0 +1,236 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Reaction role modes
+const (
+	reactionRoleNormal = "normal" // React to get, unreact to lose
+	reactionRoleUnique = "unique" // Only one role from the message at a time
+	reactionRoleLimit  = "limit"  // At most MaxRoles roles from the message
+	reactionRoleVerify = "verify" // React to get; unreacting keeps the role
+	reactionRoleDrop   = "drop"   // React to lose the role
+)
+
+var customEmojiPattern = regexp.MustCompile(`^<a?:(\w+):(\d+)>$`)
+
+// parseEmojiInput converts user input into the API form used by reaction events (unicode or name:id)
+func parseEmojiInput(input string) string {
+	input = strings.TrimSpace(input)
+	if match := customEmojiPattern.FindStringSubmatch(input); match != nil {
+		return match[1] + ":" + match[2]
+	}
+	return input
+}
+
+// formatEmoji renders a stored emoji for display
+func formatEmoji(emoji string) string {
+	if strings.Contains(emoji, ":") {
+		return "<:" + emoji + ">"
+	}
+	return emoji
+}
+
+// reactionRoleFor returns the message config and the role bound to an emoji, or nil if there is none
+func (b *Bot) reactionRoleFor(messageID, emoji string) (*database.ReactionRoleMessage, *database.ReactionRole, []database.ReactionRole) {
+	rm, err := b.DB.GetReactionRoleMessage(messageID)
+	if err != nil || rm == nil {
+		return nil, nil, nil
+	}
+	bindings, err := b.DB.GetReactionRoles(messageID)
+	if err != nil {
+		return nil, nil, nil
+	}
+	for idx := range bindings {
+		if bindings[idx].Emoji == emoji {
+			return rm, &bindings[idx], bindings
+		}
+	}
+	return nil, nil, nil
+}
+
+func (b *Bot) onMessageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.GuildID == "" || r.Member == nil || r.Member.User == nil || r.Member.User.Bot {
+		return
+	}
+
+	rm, binding, bindings := b.reactionRoleFor(r.MessageID, r.Emoji.APIName())
+	if binding == nil {
+		return
+	}
+
+	switch rm.Mode {
+	case reactionRoleDrop:
+		b.setReactionRole(s, r.GuildID, r.UserID, binding.RoleID, false)
+		return
+
+	case reactionRoleUnique:
+		// Drop every other role (and reaction) from this message
+		for _, other := range bindings {
+			if other.Emoji == binding.Emoji {
+				continue
+			}
+			if hasRole(r.Member.Roles, other.RoleID) {
+				b.setReactionRole(s, r.GuildID, r.UserID, other.RoleID, false)
+			}
+			s.MessageReactionRemove(r.ChannelID, r.MessageID, other.Emoji, r.UserID)
+		}
+
+	case reactionRoleLimit:
+		if rm.MaxRoles > 0 && !hasRole(r.Member.Roles, binding.RoleID) {
+			held := 0
+			for _, other := range bindings {
+				if hasRole(r.Member.Roles, other.RoleID) {
+					held++
+				}
+			}
+			if held >= rm.MaxRoles {
+				s.MessageReactionRemove(r.ChannelID, r.MessageID, binding.Emoji, r.UserID)
+				return
+			}
+		}
+	}
+
+	b.setReactionRole(s, r.GuildID, r.UserID, binding.RoleID, true)
+}
+
+func (b *Bot) onMessageReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	if r.GuildID == "" {
+		return
+	}
+	if s.State.User != nil && r.UserID == s.State.User.ID {
+		return
+	}
+	b.recordRemovedReaction(r)
+
+	rm, binding, _ := b.reactionRoleFor(r.MessageID, r.Emoji.APIName())
+	if binding == nil {
+		return
+	}
+
+	switch rm.Mode {
+	case reactionRoleVerify, reactionRoleDrop:
+		return
+	}
+
+	// Skip members who never held the role (e.g. reaction removed by limit mode)
+	if member, err := s.State.Member(r.GuildID, r.UserID); err == nil && !hasRole(member.Roles, binding.RoleID) {
+		return
+	}
+	b.setReactionRole(s, r.GuildID, r.UserID, binding.RoleID, false)
+}
+
+func (b *Bot) setReactionRole(s *discordgo.Session, guildID, userID, roleID string, add bool) {
+	var err error
+	if add {
+		err = s.GuildMemberRoleAdd(guildID, userID, roleID)
+	} else {
+		err = s.GuildMemberRoleRemove(guildID, userID, roleID)
+	}
+	if err != nil {
+		log.Printf("Reaction role: failed to update role %s for %s in %s: %v", roleID, userID, guildID, err)
+	}
+}
+
+// syncReactionRoles restores bot reactions and grants roles for reactions added while offline.
+// Only normal and verify modes are synced; unique and limit depend on reaction order.
+func (b *Bot) syncReactionRoles(s *discordgo.Session, guildID string) {
+	messages, err := b.DB.GetReactionRoleMessages(guildID)
+	if err != nil {
+		return
+	}
+
+	for _, rm := range messages {
+		if _, err := s.ChannelMessage(rm.ChannelID, rm.MessageID); err != nil {
+			var restErr *discordgo.RESTError
+			if errors.As(err, &restErr) && restErr.Message != nil &&
+				(restErr.Message.Code == discordgo.ErrCodeUnknownMessage || restErr.Message.Code == discordgo.ErrCodeUnknownChannel) {
+				b.DB.DeleteReactionRoleMessage(rm.MessageID)
+			}
+			continue
+		}
+
+		bindings, err := b.DB.GetReactionRoles(rm.MessageID)
+		if err != nil {
+			continue
+		}
+
+		for _, binding := range bindings {
+			s.MessageReactionAdd(rm.ChannelID, rm.MessageID, binding.Emoji)
+
+			if rm.Mode != reactionRoleNormal && rm.Mode != reactionRoleVerify {
+				continue
+			}
+
+			afterID := ""
+			for {
+				users, err := s.MessageReactions(rm.ChannelID, rm.MessageID, binding.Emoji, 100, "", afterID)
+				if err != nil || len(users) == 0 {
+					break
+				}
+				for _, user := range users {
+					if user.Bot {
+						continue
+					}
+					if member, err := s.State.Member(guildID, user.ID); err == nil && hasRole(member.Roles, binding.RoleID) {
+						continue
+					}
+					b.setReactionRole(s, guildID, user.ID, binding.RoleID, true)
+				}
+				if len(users) < 100 {
+					break
+				}
+				afterID = users[len(users)-1].ID
+			}
+		}
+	}
+}
+
+func hasRole(roles []string, roleID string) bool {
+	for _, id := range roles {
+		if id == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// describeReactionRoleMode returns a short description of a mode for display
+func describeReactionRoleMode(rm database.ReactionRoleMessage) string {
+	switch rm.Mode {
+	case reactionRoleUnique:
+		return "Unique (pick one)"
+	case reactionRoleLimit:
+		return fmt.Sprintf("Limit (max %d)", rm.MaxRoles)
+	case reactionRoleVerify:
+		return "Verify (add only)"
+	case reactionRoleDrop:
+		return "Drop (react to remove)"
+	default:
+		return "Normal"
+	}
+}
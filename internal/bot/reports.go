@@ -0,0 +1,275 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Report statuses and how they are shown
+var reportStatuses = map[string]struct {
+	Label string
+	Color int
+}{
+	database.ReportOpen:      {"Open", 0xFEE75C},
+	database.ReportResolved:  {"Resolved", 0x57F287},
+	database.ReportDismissed: {"Dismissed", 0x95A5A6},
+}
+
+// reportJumpLink links to the reported message, or returns "" for a user report
+func reportJumpLink(r *database.Report) string {
+	if r.MessageID == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", r.GuildID, r.ChannelID, r.MessageID)
+}
+
+// renderReport builds the staff embed and buttons for a report. excerpt is the
+// reported message's content as it was when the report was filed.
+func renderReport(r *database.Report, excerpt string) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	status := reportStatuses[r.Status]
+
+	reporter := fmt.Sprintf("<@%s>", r.ReporterID)
+	if r.Anonymous {
+		reporter = "Anonymous"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Report #%d", r.ID),
+		Description: excerpt,
+		Color:       status.Color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Member", Value: fmt.Sprintf("<@%s> (`%s`)", r.TargetID, r.TargetID), Inline: true},
+			{Name: "Reported By", Value: reporter, Inline: true},
+			{Name: "Reports", Value: strconv.Itoa(r.ReportCount), Inline: true},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Status: " + status.Label},
+		Timestamp: r.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if link := reportJumpLink(r); link != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Message", Value: fmt.Sprintf("[Jump to message](%s) in <#%s>", link, r.ChannelID),
+		})
+	}
+	if r.Reason != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Reason", Value: truncate(r.Reason, 1024)})
+	}
+	if r.HandledBy != "" {
+		value := fmt.Sprintf("%s by <@%s>", status.Label, r.HandledBy)
+		if r.HandledAt != nil {
+			value += fmt.Sprintf(" <t:%d:R>", r.HandledAt.Unix())
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Handled", Value: value})
+	}
+
+	closed := r.Status != database.ReportOpen
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "Resolve",
+			Style:    discordgo.SuccessButton,
+			CustomID: fmt.Sprintf("report:resolve:%d", r.ID),
+			Disabled: closed,
+		},
+		discordgo.Button{
+			Label:    "Dismiss",
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("report:dismiss:%d", r.ID),
+			Disabled: closed,
+		},
+	}
+	if link := reportJumpLink(r); link != "" {
+		buttons = append(buttons, discordgo.Button{Label: "Jump to Message", Style: discordgo.LinkButton, URL: link})
+	}
+	return embed, []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// reportExcerpt quotes a reported message for the staff embed
+func reportExcerpt(m *discordgo.Message) string {
+	if m == nil {
+		return ""
+	}
+	content := strings.TrimSpace(m.Content)
+	if content == "" && len(m.Attachments) > 0 {
+		content = fmt.Sprintf("*%d attachment(s)*", len(m.Attachments))
+	}
+	if content == "" {
+		return ""
+	}
+	return "> " + strings.ReplaceAll(truncate(content, 1500), "\n", "\n> ")
+}
+
+// updateReportMessage re-renders a report already posted for staff, keeping its excerpt
+func (b *Bot) updateReportMessage(s *discordgo.Session, r *database.Report) {
+	if r.StaffMessageID == "" {
+		return
+	}
+	msg, err := s.ChannelMessage(r.StaffChannelID, r.StaffMessageID)
+	if err != nil {
+		return
+	}
+	excerpt := ""
+	if len(msg.Embeds) > 0 {
+		excerpt = msg.Embeds[0].Description
+	}
+	embed, components := renderReport(r, excerpt)
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         r.StaffMessageID,
+		Channel:    r.StaffChannelID,
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+}
+
+// fileReport records a report and posts it, or bumps the staff message of an
+// open report about the same thing. The interaction must already be deferred.
+func (ch *CommandHandler) fileReport(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *database.ReportConfig, r *database.Report, reported *discordgo.Message) {
+	report, created, err := ch.bot.DB.FileReport(r)
+	if errors.Is(err, database.ErrAlreadyReported) {
+		editResponse(s, i, "You've already reported this. Staff will look into it.")
+		return
+	}
+	if err != nil {
+		editResponse(s, i, "Failed to file your report.")
+		return
+	}
+
+	if !created {
+		ch.bot.updateReportMessage(s, report)
+		editResponse(s, i, "Thanks, staff have been told. Someone else already reported this, so your report was added to theirs.")
+		return
+	}
+
+	embed, components := renderReport(report, reportExcerpt(reported))
+	msg, err := s.ChannelMessageSendComplex(cfg.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		editResponse(s, i, "Your report was saved, but it couldn't be posted to the staff channel. Please let the staff know.")
+		return
+	}
+	ch.bot.DB.SetReportMessage(report.ID, cfg.ChannelID, msg.ID)
+	editResponse(s, i, "Thanks, your report has been sent to the staff.")
+}
+
+// handleReportComponent handles the report modal ("report:submit:<channel>:<message>")
+// and the staff buttons ("report:resolve:<id>" / "report:dismiss:<id>")
+func (ch *CommandHandler) handleReportComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	var customID string
+	if i.Type == discordgo.InteractionModalSubmit {
+		customID = i.ModalSubmitData().CustomID
+	} else {
+		customID = i.MessageComponentData().CustomID
+	}
+	parts := strings.Split(customID, ":")
+
+	switch {
+	case len(parts) == 4 && parts[1] == "submit":
+		ch.reportModalSubmit(s, i, parts[2], parts[3])
+	case len(parts) == 3 && (parts[1] == "resolve" || parts[1] == "dismiss"):
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return
+		}
+		status := database.ReportResolved
+		if parts[1] == "dismiss" {
+			status = database.ReportDismissed
+		}
+		ch.closeReport(s, i, id, status)
+	}
+}
+
+func (ch *CommandHandler) reportModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, channelID, messageID string) {
+	cfg, err := ch.bot.DB.GetReportConfig(i.GuildID)
+	if err != nil || !cfg.Enabled || cfg.ChannelID == "" {
+		respondEphemeral(s, i, "Reports are not enabled on this server.")
+		return
+	}
+
+	respondDeferredEphemeral(s, i)
+
+	msg, err := s.ChannelMessage(channelID, messageID)
+	if err != nil {
+		editResponse(s, i, "That message no longer exists.")
+		return
+	}
+
+	anonymous := false
+	if cfg.AllowAnonymous {
+		switch strings.ToLower(strings.TrimSpace(getModalValue(i, "anonymous"))) {
+		case "yes", "y", "true":
+			anonymous = true
+		}
+	}
+
+	ch.fileReport(s, i, cfg, &database.Report{
+		GuildID:    i.GuildID,
+		TargetID:   msg.Author.ID,
+		ChannelID:  channelID,
+		MessageID:  messageID,
+		ReporterID: i.Member.User.ID,
+		Reason:     strings.TrimSpace(getModalValue(i, "reason")),
+		Anonymous:  anonymous,
+	}, msg)
+}
+
+func (ch *CommandHandler) closeReport(s *discordgo.Session, i *discordgo.InteractionCreate, id int64, status string) {
+	if !isModerator(s, i.GuildID, i.Member.User.ID) {
+		respondEphemeral(s, i, "You need moderator permissions to handle reports.")
+		return
+	}
+
+	report, err := ch.bot.DB.GetReport(id)
+	if err != nil || report == nil || report.GuildID != i.GuildID {
+		respondEphemeral(s, i, "This report no longer exists.")
+		return
+	}
+	closed, err := ch.bot.DB.CloseReport(id, status, i.Member.User.ID)
+	if err != nil {
+		respondEphemeral(s, i, "Failed to update the report.")
+		return
+	}
+	if !closed {
+		respondEphemeral(s, i, fmt.Sprintf("This report was already %s.", report.Status))
+		return
+	}
+
+	report, err = ch.bot.DB.GetReport(id)
+	if err != nil || report == nil {
+		return
+	}
+	excerpt := ""
+	if i.Message != nil && len(i.Message.Embeds) > 0 {
+		excerpt = i.Message.Embeds[0].Description
+	}
+	embed, components := renderReport(report, excerpt)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
@@ -0,0 +1,387 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Targets of the respembed modal, the second part of its custom ID
+const (
+	responseEmbedTag           = "tag"
+	responseEmbedCustomCommand = "cc"
+	responseEmbedGreeting      = "greeting"
+)
+
+// parseResponseEmbed decodes embed JSON for a tag or custom command. It takes either
+// a bare embed object or a whole message as exported by embed builders such as
+// Discohook, in which case the first embed is used.
+func parseResponseEmbed(raw string) (*discordgo.MessageEmbed, error) {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(strings.TrimSuffix(raw, "```"), "```json")
+	raw = strings.TrimSpace(strings.TrimPrefix(raw, "```"))
+
+	var message struct {
+		Embeds []*discordgo.MessageEmbed `json:"embeds"`
+	}
+	if err := json.Unmarshal([]byte(raw), &message); err != nil {
+		return nil, errors.New("that isn't valid JSON")
+	}
+
+	var embed *discordgo.MessageEmbed
+	if len(message.Embeds) > 0 {
+		embed = message.Embeds[0]
+	} else if err := json.Unmarshal([]byte(raw), &embed); err != nil {
+		return nil, errors.New("that isn't valid embed JSON")
+	}
+
+	if err := validateResponseEmbed(embed); err != nil {
+		return nil, err
+	}
+	return embed, nil
+}
+
+// validateResponseEmbed checks an embed against Discord's limits so a bad one is
+// rejected when it's saved rather than every time it's used
+func validateResponseEmbed(embed *discordgo.MessageEmbed) error {
+	if embed == nil {
+		return errors.New("the embed is empty")
+	}
+
+	total := len(embed.Title) + len(embed.Description)
+	if embed.Footer != nil {
+		total += len(embed.Footer.Text)
+	}
+	if embed.Author != nil {
+		total += len(embed.Author.Name)
+	}
+	hasImage := embed.Image != nil && embed.Image.URL != ""
+	hasThumbnail := embed.Thumbnail != nil && embed.Thumbnail.URL != ""
+	if total == 0 && len(embed.Fields) == 0 && !hasImage && !hasThumbnail {
+		return errors.New("the embed needs a title, description, field or image")
+	}
+
+	switch {
+	case len(embed.Title) > 256:
+		return errors.New("the title can be at most 256 characters")
+	case len(embed.Description) > 4096:
+		return errors.New("the description can be at most 4096 characters")
+	case len(embed.Fields) > 25:
+		return errors.New("an embed can have at most 25 fields")
+	case embed.Color < 0 || embed.Color > 0xFFFFFF:
+		return errors.New("the color must be between #000000 and #FFFFFF")
+	}
+	for _, field := range embed.Fields {
+		if field == nil || field.Name == "" || field.Value == "" {
+			return errors.New("every field needs a name and a value")
+		}
+		if len(field.Name) > 256 || len(field.Value) > 1024 {
+			return errors.New("field names can be at most 256 characters and values 1024")
+		}
+		total += len(field.Name) + len(field.Value)
+	}
+	if total > 6000 {
+		return errors.New("the embed can hold at most 6000 characters of text")
+	}
+
+	// Anything the response doesn't control is dropped
+	embed.Type = discordgo.EmbedTypeRich
+	embed.Timestamp = ""
+	embed.Video = nil
+	embed.Provider = nil
+	return nil
+}
+
+// renderResponseEmbed decodes a stored embed and fills in its placeholders the same
+// way as the plain text response. Returns nil when there's no usable embed.
+func renderResponseEmbed(raw string, tctx *templateContext) *discordgo.MessageEmbed {
+	return fillEmbed(raw, func(text string) string { return renderTemplate(text, tctx) })
+}
+
+// fillEmbed decodes a stored embed and runs fill over all of its text
+func fillEmbed(raw string, fill func(string) string) *discordgo.MessageEmbed {
+	if raw == "" {
+		return nil
+	}
+
+	var embed discordgo.MessageEmbed
+	if err := json.Unmarshal([]byte(raw), &embed); err != nil {
+		return nil
+	}
+
+	embed.Title = fill(embed.Title)
+	embed.Description = fill(embed.Description)
+	if embed.Footer != nil {
+		embed.Footer.Text = fill(embed.Footer.Text)
+	}
+	if embed.Author != nil {
+		embed.Author.Name = fill(embed.Author.Name)
+	}
+	for _, field := range embed.Fields {
+		field.Name = fill(field.Name)
+		field.Value = fill(field.Value)
+	}
+	return &embed
+}
+
+// parseEmbedColor reads a #RRGGBB color, empty means no color
+func parseEmbedColor(text string) (int, error) {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "#")
+	if text == "" {
+		return 0, nil
+	}
+	color, err := strconv.ParseUint(text, 16, 32)
+	if err != nil || color > 0xFFFFFF {
+		return 0, fmt.Errorf("`%s` isn't a hex color like #5865F2", text)
+	}
+	return int(color), nil
+}
+
+// formatEmbedFields writes fields as the "Name | Value | inline" lines the modal reads
+func formatEmbedFields(fields []*discordgo.MessageEmbedField) string {
+	lines := make([]string, 0, len(fields))
+	for _, field := range fields {
+		line := field.Name + " | " + strings.ReplaceAll(field.Value, "\n", " ")
+		if field.Inline {
+			line += " | inline"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseEmbedFields reads one "Name | Value | inline" field per line
+func parseEmbedFields(text string) ([]*discordgo.MessageEmbedField, error) {
+	var fields []*discordgo.MessageEmbedField
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("field `%s` should look like `Name | Value`", truncate(line, 50))
+		}
+		field := &discordgo.MessageEmbedField{
+			Name:  strings.TrimSpace(parts[0]),
+			Value: strings.TrimSpace(parts[1]),
+		}
+		if len(parts) == 3 {
+			field.Inline = strings.EqualFold(strings.TrimSpace(parts[2]), "inline")
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// responseEmbedModal builds the embed editor, prefilled with the current embed if there is one
+func responseEmbedModal(target, name, current string) *discordgo.InteractionResponse {
+	embed := &discordgo.MessageEmbed{}
+	if current != "" {
+		json.Unmarshal([]byte(current), embed)
+	}
+
+	color, image := "", ""
+	if embed.Color != 0 {
+		color = fmt.Sprintf("#%06X", embed.Color)
+	}
+	if embed.Image != nil {
+		image = embed.Image.URL
+	}
+
+	input := func(id, label string, style discordgo.TextInputStyle, value, placeholder string, max int) discordgo.MessageComponent {
+		return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.TextInput{
+				CustomID:    id,
+				Label:       label,
+				Style:       style,
+				Value:       truncate(value, max),
+				Placeholder: placeholder,
+				Required:    false,
+				MaxLength:   max,
+			},
+		}}
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("respembed:%s:%s", target, name),
+			Title:    truncate("Embed: "+name, 45),
+			Components: []discordgo.MessageComponent{
+				input("title", "Title", discordgo.TextInputShort, embed.Title, "Welcome, {nickname}!", 256),
+				input("description", "Description", discordgo.TextInputParagraph, embed.Description, "", 4000),
+				input("color", "Color", discordgo.TextInputShort, color, "#5865F2", 7),
+				input("image", "Image URL", discordgo.TextInputShort, image, "https://...", 1000),
+				input("fields", "Fields, one per line", discordgo.TextInputParagraph, formatEmbedFields(embed.Fields), "Name | Value | inline", 4000),
+			},
+		},
+	}
+}
+
+// embedFromModal builds an embed out of a submitted respembed modal
+func embedFromModal(i *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+	color, err := parseEmbedColor(getModalValue(i, "color"))
+	if err != nil {
+		return nil, err
+	}
+	fields, err := parseEmbedFields(getModalValue(i, "fields"))
+	if err != nil {
+		return nil, err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       strings.TrimSpace(getModalValue(i, "title")),
+		Description: strings.TrimSpace(getModalValue(i, "description")),
+		Color:       color,
+		Fields:      fields,
+	}
+	if image := strings.TrimSpace(getModalValue(i, "image")); image != "" {
+		if !strings.HasPrefix(image, "https://") && !strings.HasPrefix(image, "http://") {
+			return nil, errors.New("the image must be an http(s) link")
+		}
+		embed.Image = &discordgo.MessageEmbedImage{URL: image}
+	}
+
+	if err := validateResponseEmbed(embed); err != nil {
+		return nil, err
+	}
+	return embed, nil
+}
+
+// responseEmbedTarget looks up the tag, custom command or greeting a respembed action is for and
+// returns its current embed and a function that saves a new one. If the member can't
+// edit it, problem holds the message to show them instead.
+func (ch *CommandHandler) responseEmbedTarget(s *discordgo.Session, i *discordgo.InteractionCreate, target, name string) (resolved, current string, save func(string) error, problem string) {
+	switch target {
+	case responseEmbedTag:
+		tag, err := ch.bot.DB.GetTag(i.GuildID, name)
+		if err != nil || tag == nil {
+			return "", "", nil, "Tag not found."
+		}
+		if tag.CreatedBy != i.Member.User.ID && !isModerator(s, i.GuildID, i.Member.User.ID) {
+			return "", "", nil, "Only the tag's owner or a moderator can change its embed."
+		}
+		return tag.Name, tag.Embed, func(raw string) error { return ch.bot.DB.SetTagEmbed(tag.ID, raw) }, ""
+
+	case responseEmbedCustomCommand:
+		cc, err := ch.bot.DB.GetCustomCommand(i.GuildID, name)
+		if err != nil || cc == nil {
+			return "", "", nil, "Custom command not found."
+		}
+		return cc.Name, cc.Embed, func(raw string) error { return ch.bot.DB.SetCustomCommandEmbed(cc.ID, raw) }, ""
+
+	case responseEmbedGreeting:
+		if !slices.Contains(database.GreetingKinds, name) {
+			return "", "", nil, "Unknown greeting type."
+		}
+		if !isAdmin(s, i.GuildID, i.Member.User.ID) {
+			return "", "", nil, "You need administrator permission to change settings."
+		}
+		greeting, err := ch.bot.DB.GetGreetingMessage(i.GuildID, name)
+		if err != nil {
+			return "", "", nil, "Failed to get the greeting."
+		}
+		return name, greeting.Embed, func(raw string) error {
+			greeting.Embed = raw
+			return ch.bot.DB.SetGreetingMessage(greeting)
+		}, ""
+	}
+	return "", "", nil, "Unknown embed target."
+}
+
+// responseEmbedHandler runs the embed subcommand of /tag, /customcommand and /welcome: json
+// imports an embed, clear removes it, and with neither the embed editor opens
+func (ch *CommandHandler) responseEmbedHandler(s *discordgo.Session, i *discordgo.InteractionCreate, target, name string) {
+	name, current, save, problem := ch.responseEmbedTarget(s, i, target, name)
+	if problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
+
+	if getBoolOption(i, "clear") {
+		if err := save(""); err != nil {
+			respondEphemeral(s, i, "Failed to remove the embed.")
+			return
+		}
+		respondEmbedEphemeral(s, i, successEmbed("Embed Removed", fmt.Sprintf("`%s` is back to plain text.", name)))
+		return
+	}
+
+	if raw := getStringOption(i, "json"); raw != "" {
+		embed, err := parseResponseEmbed(raw)
+		if err != nil {
+			respondEphemeral(s, i, "Couldn't import the embed: "+err.Error()+".")
+			return
+		}
+		ch.saveResponseEmbed(s, i, name, embed, save)
+		return
+	}
+
+	if len("respembed:"+target+":"+name) > 100 {
+		respondEphemeral(s, i, "That name is too long for the embed editor, please use the `json` option instead.")
+		return
+	}
+	s.InteractionRespond(i.Interaction, responseEmbedModal(target, name, current))
+}
+
+// saveResponseEmbed stores an embed and shows a preview of it
+func (ch *CommandHandler) saveResponseEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, name string, embed *discordgo.MessageEmbed, save func(string) error) {
+	raw, err := json.Marshal(embed)
+	if err != nil || save(string(raw)) != nil {
+		respondEphemeral(s, i, "Failed to save the embed.")
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("✅ Saved the embed for `%s`. Placeholders are filled in when it's used:", name),
+			Embeds:  []*discordgo.MessageEmbed{embed},
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleResponseEmbedComponent saves a submitted embed editor, custom ID "respembed:<target>:<name>"
+func (ch *CommandHandler) handleResponseEmbedComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.SplitN(i.ModalSubmitData().CustomID, ":", 3)
+	if len(parts) != 3 {
+		return
+	}
+
+	name, _, save, problem := ch.responseEmbedTarget(s, i, parts[1], parts[2])
+	if problem != "" {
+		respondEphemeral(s, i, problem)
+		return
+	}
+
+	embed, err := embedFromModal(i)
+	if err != nil {
+		respondEphemeral(s, i, "Couldn't save the embed: "+err.Error()+".")
+		return
+	}
+	ch.saveResponseEmbed(s, i, name, embed, save)
+}
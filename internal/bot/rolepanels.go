@@ -0,0 +1,315 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord allows 25 buttons (5 rows of 5) or 25 select options per message
+const maxRolePanelRoles = 25
+
+// Scheduled event type for removing a temporary panel role; target is "userID:roleID"
+const eventTempRole = "temprole"
+
+// componentEmoji converts a stored emoji into a component emoji
+func componentEmoji(emoji string) *discordgo.ComponentEmoji {
+	if emoji == "" {
+		return nil
+	}
+	if name, id, ok := strings.Cut(emoji, ":"); ok {
+		return &discordgo.ComponentEmoji{Name: name, ID: id}
+	}
+	return &discordgo.ComponentEmoji{Name: emoji}
+}
+
+// rolePanelLabel returns the label shown for a panel role
+func rolePanelLabel(s *discordgo.Session, guildID string, r database.RolePanelRole) string {
+	if r.Label != "" {
+		return r.Label
+	}
+	if role, err := s.State.Role(guildID, r.RoleID); err == nil {
+		return role.Name
+	}
+	return r.RoleID
+}
+
+// renderRolePanel builds the embed and components for a panel
+func renderRolePanel(s *discordgo.Session, panel *database.RolePanel) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	embed := &discordgo.MessageEmbed{
+		Title:       panel.Title,
+		Description: panel.Description,
+		Color:       0x5865F2,
+	}
+
+	var notes []string
+	if panel.MaxRoles == 1 {
+		notes = append(notes, "Pick one")
+	} else if panel.MaxRoles > 1 {
+		notes = append(notes, fmt.Sprintf("Pick up to %d", panel.MaxRoles))
+	}
+	if panel.RequiredRoleID != "" {
+		if role, err := s.State.Role(panel.GuildID, panel.RequiredRoleID); err == nil {
+			notes = append(notes, "Requires "+role.Name)
+		}
+	}
+	if panel.TempDuration > 0 {
+		notes = append(notes, "Roles expire after "+formatDuration(time.Duration(panel.TempDuration)*time.Second))
+	}
+	if len(notes) > 0 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: strings.Join(notes, " • ")}
+	}
+
+	if len(panel.Roles) == 0 {
+		return embed, nil
+	}
+
+	if panel.Style == "select" {
+		maxValues := len(panel.Roles)
+		if panel.MaxRoles > 0 && panel.MaxRoles < maxValues {
+			maxValues = panel.MaxRoles
+		}
+		minValues := 0
+
+		options := make([]discordgo.SelectMenuOption, 0, len(panel.Roles))
+		for _, r := range panel.Roles {
+			options = append(options, discordgo.SelectMenuOption{
+				Label:       truncate(rolePanelLabel(s, panel.GuildID, r), 100),
+				Value:       r.RoleID,
+				Description: truncate(r.Description, 100),
+				Emoji:       componentEmoji(r.Emoji),
+			})
+		}
+		return embed, []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    fmt.Sprintf("rolepanel:%d", panel.ID),
+					Placeholder: "Choose your roles",
+					MinValues:   &minValues,
+					MaxValues:   maxValues,
+					Options:     options,
+				},
+			}},
+		}
+	}
+
+	var rows []discordgo.MessageComponent
+	var row []discordgo.MessageComponent
+	for _, r := range panel.Roles {
+		row = append(row, discordgo.Button{
+			Label:    truncate(rolePanelLabel(s, panel.GuildID, r), 80),
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("rolepanel:%d:%s", panel.ID, r.RoleID),
+			Emoji:    componentEmoji(r.Emoji),
+		})
+		if len(row) == 5 {
+			rows = append(rows, discordgo.ActionsRow{Components: row})
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, discordgo.ActionsRow{Components: row})
+	}
+	return embed, rows
+}
+
+// publishRolePanel posts a panel to channelID, or updates its existing message.
+// An empty channelID keeps the panel where it already is.
+func (b *Bot) publishRolePanel(s *discordgo.Session, panelID int64, channelID string) (*database.RolePanel, error) {
+	panel, err := b.DB.GetRolePanel(panelID)
+	if err != nil {
+		return nil, err
+	}
+	if panel == nil {
+		return nil, fmt.Errorf("role panel %d not found", panelID)
+	}
+	if channelID == "" {
+		channelID = panel.ChannelID
+	}
+	if channelID == "" {
+		return nil, errors.New("role panel has not been posted yet")
+	}
+
+	embed, components := renderRolePanel(s, panel)
+
+	// Edit in place when staying in the same channel
+	if panel.MessageID != "" && channelID == panel.ChannelID {
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         panel.MessageID,
+			Channel:    panel.ChannelID,
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &components,
+		})
+		if err == nil {
+			return panel, nil
+		}
+	} else if panel.MessageID != "" {
+		s.ChannelMessageDelete(panel.ChannelID, panel.MessageID)
+	}
+
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	panel.ChannelID, panel.MessageID = channelID, msg.ID
+	return panel, b.DB.SetRolePanelMessage(panel.ID, channelID, msg.ID)
+}
+
+// PublishRolePanel re-renders a panel after it was edited outside Discord (e.g. the dashboard)
+func (b *Bot) PublishRolePanel(panelID int64, channelID string) error {
+	_, err := b.publishRolePanel(b.Session, panelID, channelID)
+	return err
+}
+
+// handleRolePanelComponent handles role panel buttons ("rolepanel:<panel>:<role>") and selects ("rolepanel:<panel>")
+func (ch *CommandHandler) handleRolePanelComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	data := i.MessageComponentData()
+	parts := strings.Split(data.CustomID, ":")
+	if len(parts) < 2 {
+		return
+	}
+	panelID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return
+	}
+
+	panel, err := ch.bot.DB.GetRolePanel(panelID)
+	if err != nil || panel == nil || panel.GuildID != i.GuildID {
+		respondEphemeral(s, i, "This role panel no longer exists.")
+		return
+	}
+
+	if panel.RequiredRoleID != "" && !hasRole(i.Member.Roles, panel.RequiredRoleID) {
+		respondEphemeral(s, i, fmt.Sprintf("You need the <@&%s> role to use this panel.", panel.RequiredRoleID))
+		return
+	}
+
+	offered := make(map[string]bool, len(panel.Roles))
+	for _, r := range panel.Roles {
+		offered[r.RoleID] = true
+	}
+	var held []string
+	for _, roleID := range i.Member.Roles {
+		if offered[roleID] {
+			held = append(held, roleID)
+		}
+	}
+
+	// Work out the roles the member should hold from this panel
+	var want []string
+	if len(parts) == 3 {
+		roleID := parts[2]
+		if !offered[roleID] {
+			respondEphemeral(s, i, "That role is no longer offered on this panel.")
+			return
+		}
+		if hasRole(held, roleID) {
+			for _, id := range held {
+				if id != roleID {
+					want = append(want, id)
+				}
+			}
+		} else if panel.MaxRoles == 1 {
+			want = []string{roleID} // Swap
+		} else if panel.MaxRoles > 1 && len(held) >= panel.MaxRoles {
+			respondEphemeral(s, i, fmt.Sprintf("You can only have %d roles from this panel. Remove one first.", panel.MaxRoles))
+			return
+		} else {
+			want = append(held, roleID)
+		}
+	} else {
+		for _, roleID := range data.Values {
+			if offered[roleID] {
+				want = append(want, roleID)
+			}
+		}
+		if panel.MaxRoles > 0 && len(want) > panel.MaxRoles {
+			want = want[:panel.MaxRoles]
+		}
+	}
+
+	var added, removed, failed []string
+	for _, roleID := range want {
+		if hasRole(held, roleID) {
+			continue
+		}
+		if err := s.GuildMemberRoleAdd(i.GuildID, i.Member.User.ID, roleID); err != nil {
+			failed = append(failed, roleID)
+			continue
+		}
+		added = append(added, roleID)
+		if panel.TempDuration > 0 {
+			target := i.Member.User.ID + ":" + roleID
+			ch.bot.DB.DeleteScheduledEventByTarget(i.GuildID, eventTempRole, target)
+			ch.bot.DB.AddScheduledEvent(i.GuildID, eventTempRole, target,
+				time.Now().Add(time.Duration(panel.TempDuration)*time.Second).UnixMilli())
+		}
+	}
+	for _, roleID := range held {
+		if hasRole(want, roleID) {
+			continue
+		}
+		if err := s.GuildMemberRoleRemove(i.GuildID, i.Member.User.ID, roleID); err != nil {
+			failed = append(failed, roleID)
+			continue
+		}
+		removed = append(removed, roleID)
+		ch.bot.DB.DeleteScheduledEventByTarget(i.GuildID, eventTempRole, i.Member.User.ID+":"+roleID)
+	}
+
+	var lines []string
+	if len(added) > 0 {
+		line := "Added " + formatRoleMentions(added)
+		if panel.TempDuration > 0 {
+			line += fmt.Sprintf(" (expires <t:%s:R>)", formatUnixTime(time.Now().Add(time.Duration(panel.TempDuration)*time.Second)))
+		}
+		lines = append(lines, line)
+	}
+	if len(removed) > 0 {
+		lines = append(lines, "Removed "+formatRoleMentions(removed))
+	}
+	if len(failed) > 0 {
+		lines = append(lines, "Couldn't update "+formatRoleMentions(failed)+" - my role may be too low.")
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No changes.")
+	}
+	respondEphemeral(s, i, strings.Join(lines, "\n"))
+}
+
+// removeTempRole removes an expired temporary panel role
+func (b *Bot) removeTempRole(guildID, target string) {
+	userID, roleID, ok := strings.Cut(target, ":")
+	if !ok {
+		return
+	}
+	b.Session.GuildMemberRoleRemove(guildID, userID, roleID)
+}
@@ -0,0 +1,254 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxScheduledMessages caps how many unsent messages a member can have per server
+const maxScheduledMessages = 20
+
+// scheduledAttachmentDir holds attachments of scheduled messages until they're sent.
+// It lives in the archive directory but isn't subject to its retention limit.
+func (b *Bot) scheduledAttachmentDir() string {
+	return filepath.Join(b.Config().Archive.Dir, "scheduled")
+}
+
+// saveScheduledAttachment downloads an attachment so it can be re-uploaded once
+// the CDN link it came from has expired
+func (b *Bot) saveScheduledAttachment(guildID string, att *discordgo.MessageAttachment) (database.DeletedAttachment, error) {
+	saved := database.DeletedAttachment{
+		Filename:    att.Filename,
+		URL:         att.URL,
+		ContentType: att.ContentType,
+		Size:        att.Size,
+	}
+	if att.Size > b.Config().Archive.MaxFileMB*1024*1024 {
+		return saved, fmt.Errorf("attachments can be at most %d MB", b.Config().Archive.MaxFileMB)
+	}
+
+	path := filepath.Join(b.scheduledAttachmentDir(), guildID, fmt.Sprintf("%s_%s", att.ID, filepath.Base(att.Filename)))
+	b.downloadAttachment(att.URL, path)
+	if _, err := os.Stat(path); err != nil {
+		return saved, fmt.Errorf("the attachment couldn't be downloaded")
+	}
+	saved.ArchivePath = path
+	return saved, nil
+}
+
+// removeScheduledAttachments deletes the saved copies of a scheduled message's attachments
+func removeScheduledAttachments(sm *database.ScheduledMessage) {
+	for _, att := range sm.Attachments {
+		if att.ArchivePath != "" {
+			os.Remove(att.ArchivePath)
+		}
+	}
+}
+
+func (b *Bot) processScheduledMessages() {
+	messages, err := b.DB.GetPendingScheduledMessages()
+	if err != nil {
+		return
+	}
+
+	for _, msg := range messages {
+		b.sendScheduledMessage(&msg)
+		b.DB.MarkScheduledMessageExecuted(msg.ID)
+		removeScheduledAttachments(&msg)
+	}
+}
+
+// sendScheduledMessage posts a scheduled message with its embed and attachments
+func (b *Bot) sendScheduledMessage(sm *database.ScheduledMessage) {
+	send := &discordgo.MessageSend{Content: sm.Message}
+	if embed := fillEmbed(sm.Embed, func(text string) string { return text }); embed != nil {
+		send.Embeds = []*discordgo.MessageEmbed{embed}
+	}
+	var guild *discordgo.Guild
+	if sm.GuildID != nil {
+		guild, _ = b.Session.State.Guild(*sm.GuildID)
+	}
+	files, handles, skipped := archivedFiles(sm.Attachments, int64(uploadLimit(guild)))
+	defer func() {
+		for _, f := range handles {
+			f.Close()
+		}
+	}()
+	send.Files = files
+	for _, att := range skipped {
+		send.Content = strings.TrimSpace(send.Content + "\n" + att.URL)
+	}
+
+	if send.Content == "" && len(send.Embeds) == 0 && len(send.Files) == 0 {
+		return
+	}
+	b.Session.ChannelMessageSendComplex(sm.ChannelID, send)
+}
+
+// encodeScheduledEmbed validates embed JSON and returns it in the form it's stored in
+func encodeScheduledEmbed(raw string) (string, error) {
+	embed, err := parseResponseEmbed(raw)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(embed)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// describeScheduledMessage summarizes a scheduled message on one line
+func describeScheduledMessage(sm *database.ScheduledMessage) string {
+	var parts []string
+	if sm.Message != "" {
+		parts = append(parts, truncate(strings.ReplaceAll(sm.Message, "\n", " "), 80))
+	}
+	if sm.Embed != "" {
+		parts = append(parts, "*[embed]*")
+	}
+	if n := len(sm.Attachments); n > 0 {
+		parts = append(parts, fmt.Sprintf("*[%d attachment(s)]*", n))
+	}
+	return fmt.Sprintf("**#%d** in <#%s> <t:%d:R>: %s", sm.ID, sm.ChannelID, sm.ScheduledFor.Unix(), strings.Join(parts, " "))
+}
+
+// renderScheduleList builds the /schedule list embed with a cancel button per message
+func renderScheduleList(messages []database.ScheduledMessage, everyone bool) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	title := "Your Scheduled Messages"
+	if everyone {
+		title = "Scheduled Messages"
+	}
+	embed := &discordgo.MessageEmbed{Title: title, Color: 0x5865F2}
+	if len(messages) == 0 {
+		embed.Description = "Nothing is scheduled. Use `/schedule create` to schedule a message."
+		return embed, []discordgo.MessageComponent{}
+	}
+
+	var lines []string
+	var rows []discordgo.MessageComponent
+	var buttons []discordgo.MessageComponent
+	for idx, sm := range messages {
+		line := describeScheduledMessage(&sm)
+		if everyone {
+			line += fmt.Sprintf(" — <@%s>", sm.UserID)
+		}
+		lines = append(lines, line)
+
+		// Discord allows five rows of five buttons
+		if idx >= 25 {
+			continue
+		}
+		customID := fmt.Sprintf("schedule:cancel:%d", sm.ID)
+		if everyone {
+			customID += ":all"
+		}
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("Cancel #%d", sm.ID),
+			Style:    discordgo.DangerButton,
+			CustomID: customID,
+		})
+		if len(buttons) == 5 {
+			rows = append(rows, discordgo.ActionsRow{Components: buttons})
+			buttons = nil
+		}
+	}
+	if len(buttons) > 0 {
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+	embed.Description = truncate(strings.Join(lines, "\n"), 4096)
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: "Change one with /schedule edit"}
+	return embed, rows
+}
+
+// canManageScheduledMessage reports whether a member may edit or cancel a scheduled message
+func canManageScheduledMessage(s *discordgo.Session, guildID, userID string, sm *database.ScheduledMessage) bool {
+	if sm.GuildID == nil || *sm.GuildID != guildID {
+		return false
+	}
+	return sm.UserID == userID || isModerator(s, guildID, userID)
+}
+
+// handleScheduleCancel handles the cancel buttons of /schedule list ("schedule:cancel:<id>",
+// with ":all" appended on a moderator's list of everyone's messages)
+func (ch *CommandHandler) handleScheduleCancel(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) < 3 || parts[1] != "cancel" {
+		return
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return
+	}
+
+	sm, err := ch.bot.DB.GetScheduledMessage(id)
+	if err != nil || sm == nil {
+		respondEphemeral(s, i, "That message has already been sent or cancelled.")
+		return
+	}
+	if !canManageScheduledMessage(s, i.GuildID, i.Member.User.ID, sm) {
+		respondEphemeral(s, i, "You can only cancel your own scheduled messages.")
+		return
+	}
+	if cancelled, err := ch.bot.DB.CancelScheduledMessage(id); err != nil || !cancelled {
+		respondEphemeral(s, i, "That message has already been sent or cancelled.")
+		return
+	}
+	removeScheduledAttachments(sm)
+
+	// Refresh the list the button was on
+	everyone := len(parts) == 4 && parts[3] == "all"
+	userID := i.Member.User.ID
+	if everyone {
+		userID = ""
+	}
+	messages, err := ch.bot.DB.GetUpcomingScheduledMessages(i.GuildID, userID, 25)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Cancelled scheduled message **#%d**.", id))
+		return
+	}
+	embed, components := renderScheduleList(messages, everyone)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
+
+// scheduleTime parses a delay such as "1h30m" into the time it ends
+func scheduleTime(input string) (time.Time, bool) {
+	duration, err := parseDuration(input)
+	if err != nil || duration <= 0 {
+		return time.Time{}, false
+	}
+	return time.Now().Add(duration), true
+}
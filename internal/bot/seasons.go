@@ -0,0 +1,141 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// seasonStartFor returns the start of the season containing t: Monday for weekly seasons,
+// the 1st of the month for monthly ones, both at midnight UTC to line up with xp_history days
+func seasonStartFor(t time.Time, period string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if period == "weekly" {
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day.AddDate(0, 0, 1-day.Day())
+}
+
+// seasonEndFor returns when a season that began at start ends
+func seasonEndFor(start time.Time, period string) time.Time {
+	if period == "weekly" {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// checkXPSeasons ends seasons whose period has passed
+func (b *Bot) checkXPSeasons() {
+	configs, err := b.DB.GetEnabledXPSeasonConfigs()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for idx := range configs {
+		cfg := &configs[idx]
+		if cfg.SeasonStart == 0 {
+			cfg.SeasonStart = seasonStartFor(now, cfg.Period).UnixMilli()
+			b.DB.SetXPSeasonConfig(cfg)
+			continue
+		}
+
+		// Catching up after downtime ends one season per tick
+		end := seasonEndFor(time.UnixMilli(cfg.SeasonStart).UTC(), cfg.Period)
+		if now.Before(end) {
+			continue
+		}
+		if err := b.endXPSeason(cfg, end); err != nil {
+			log.Printf("Failed to end XP season for guild %s: %v", cfg.GuildID, err)
+		}
+	}
+}
+
+// endXPSeason archives the standings, moves the winner role and announces the results
+func (b *Bot) endXPSeason(cfg *database.XPSeasonConfig, end time.Time) error {
+	start := time.UnixMilli(cfg.SeasonStart).UTC()
+	standings, err := b.DB.GetXPGainsBetween(cfg.GuildID, start, end, max(cfg.TopCount, 1))
+	if err != nil {
+		return err
+	}
+
+	previousWinner := cfg.LastWinnerID
+	cfg.LastWinnerID = ""
+	if len(standings) > 0 {
+		cfg.LastWinnerID = standings[0].UserID
+	}
+	if err := b.DB.ArchiveXPSeason(cfg, end.UnixMilli(), standings); err != nil {
+		return err
+	}
+
+	if cfg.WinnerRoleID != "" {
+		if previousWinner != "" && previousWinner != cfg.LastWinnerID {
+			b.Session.GuildMemberRoleRemove(cfg.GuildID, previousWinner, cfg.WinnerRoleID)
+		}
+		if cfg.LastWinnerID != "" {
+			b.Session.GuildMemberRoleAdd(cfg.GuildID, cfg.LastWinnerID, cfg.WinnerRoleID)
+		}
+	}
+
+	if cfg.ChannelID != "" {
+		b.Session.ChannelMessageSendEmbed(cfg.ChannelID, seasonResultsEmbed(cfg, start, end, standings))
+	}
+	return nil
+}
+
+// seasonResultsEmbed renders a finished season's standings
+func seasonResultsEmbed(cfg *database.XPSeasonConfig, start, end time.Time, standings []database.UserXP) *discordgo.MessageEmbed {
+	var desc strings.Builder
+	if len(standings) == 0 {
+		desc.WriteString("Nobody earned XP this season.")
+	}
+	for idx, ux := range standings {
+		desc.WriteString(fmt.Sprintf("%s <@%s> - %d XP\n", seasonPlace(idx+1), ux.UserID, ux.XP))
+	}
+	if cfg.WinnerRoleID != "" && len(standings) > 0 {
+		desc.WriteString(fmt.Sprintf("\n<@%s> wins <@&%s> for the next season!", standings[0].UserID, cfg.WinnerRoleID))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "🏆 Season Results",
+		Description: desc.String(),
+		Color:       0xFFD700,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%s season %s – %s • Lifetime XP is kept", strings.Title(cfg.Period),
+				start.Format("Jan 2"), end.AddDate(0, 0, -1).Format("Jan 2, 2006")),
+		},
+	}
+}
+
+func seasonPlace(position int) string {
+	switch position {
+	case 1:
+		return ":first_place:"
+	case 2:
+		return ":second_place:"
+	case 3:
+		return ":third_place:"
+	}
+	return fmt.Sprintf("**#%d**", position)
+}
@@ -0,0 +1,383 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// serverSnapshot is the structure of a guild as stored in a server backup
+type serverSnapshot struct {
+	GuildName string            `json:"guild_name"`
+	Settings  snapshotSettings  `json:"settings"`
+	Roles     []snapshotRole    `json:"roles"`
+	Channels  []snapshotChannel `json:"channels"`
+}
+
+type snapshotSettings struct {
+	VerificationLevel           discordgo.VerificationLevel          `json:"verification_level"`
+	DefaultMessageNotifications discordgo.MessageNotifications       `json:"default_message_notifications"`
+	ExplicitContentFilter       discordgo.ExplicitContentFilterLevel `json:"explicit_content_filter"`
+	AfkChannelID                string                               `json:"afk_channel_id,omitempty"`
+	AfkTimeout                  int                                  `json:"afk_timeout"`
+	SystemChannelID             string                               `json:"system_channel_id,omitempty"`
+	SystemChannelFlags          discordgo.SystemChannelFlag          `json:"system_channel_flags"`
+}
+
+type snapshotRole struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Color       int    `json:"color"`
+	Hoist       bool   `json:"hoist"`
+	Mentionable bool   `json:"mentionable"`
+	Permissions int64  `json:"permissions,string"`
+	Position    int    `json:"position"`
+	Managed     bool   `json:"managed,omitempty"` // Bot and integration roles, which can't be recreated
+}
+
+type snapshotChannel struct {
+	ID               string                           `json:"id"`
+	Name             string                           `json:"name"`
+	Type             discordgo.ChannelType            `json:"type"`
+	ParentID         string                           `json:"parent_id,omitempty"`
+	Position         int                              `json:"position"`
+	Topic            string                           `json:"topic,omitempty"`
+	NSFW             bool                             `json:"nsfw,omitempty"`
+	Bitrate          int                              `json:"bitrate,omitempty"`
+	UserLimit        int                              `json:"user_limit,omitempty"`
+	RateLimitPerUser int                              `json:"rate_limit_per_user,omitempty"`
+	Overwrites       []*discordgo.PermissionOverwrite `json:"overwrites,omitempty"`
+}
+
+// restoreResult counts what a restore changed
+type restoreResult struct {
+	RolesCreated    int
+	RolesUpdated    int
+	ChannelsCreated int
+	ChannelsUpdated int
+	ChannelsDeleted int
+	Failed          []string
+}
+
+// Guilds with a restore in progress, so two can't run over each other
+var activeRestores sync.Map
+
+// snapshotGuild captures a guild's roles, channels and settings
+func snapshotGuild(s *discordgo.Session, guildID string) (*serverSnapshot, error) {
+	guild, err := s.Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.GuildRoles(guildID)
+	if err != nil {
+		return nil, err
+	}
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &serverSnapshot{
+		GuildName: guild.Name,
+		Settings: snapshotSettings{
+			VerificationLevel:           guild.VerificationLevel,
+			DefaultMessageNotifications: guild.DefaultMessageNotifications,
+			ExplicitContentFilter:       guild.ExplicitContentFilter,
+			AfkChannelID:                guild.AfkChannelID,
+			AfkTimeout:                  guild.AfkTimeout,
+			SystemChannelID:             guild.SystemChannelID,
+			SystemChannelFlags:          guild.SystemChannelFlags,
+		},
+	}
+	for _, r := range roles {
+		snap.Roles = append(snap.Roles, snapshotRole{
+			ID:          r.ID,
+			Name:        r.Name,
+			Color:       r.Color,
+			Hoist:       r.Hoist,
+			Mentionable: r.Mentionable,
+			Permissions: r.Permissions,
+			Position:    r.Position,
+			Managed:     r.Managed,
+		})
+	}
+	for _, c := range channels {
+		if c.IsThread() {
+			continue
+		}
+		snap.Channels = append(snap.Channels, snapshotChannel{
+			ID:               c.ID,
+			Name:             c.Name,
+			Type:             c.Type,
+			ParentID:         c.ParentID,
+			Position:         c.Position,
+			Topic:            c.Topic,
+			NSFW:             c.NSFW,
+			Bitrate:          c.Bitrate,
+			UserLimit:        c.UserLimit,
+			RateLimitPerUser: c.RateLimitPerUser,
+			Overwrites:       c.PermissionOverwrites,
+		})
+	}
+	sort.Slice(snap.Roles, func(a, b int) bool { return snap.Roles[a].Position < snap.Roles[b].Position })
+	sort.Slice(snap.Channels, func(a, b int) bool { return snap.Channels[a].Position < snap.Channels[b].Position })
+	return snap, nil
+}
+
+// restoreGuild brings a guild back in line with a snapshot. Roles and channels
+// that still exist are edited back, missing ones are recreated (with new IDs
+// that overwrites and parents are remapped to), and with deleteExtra, channels
+// that aren't in the snapshot are deleted. Nothing above the bot's own top
+// role can be touched.
+func restoreGuild(s *discordgo.Session, guildID string, snap *serverSnapshot, deleteExtra bool) (*restoreResult, error) {
+	guild, err := s.Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.GuildRoles(guildID)
+	if err != nil {
+		return nil, err
+	}
+	guild.Roles = roles
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		return nil, err
+	}
+	self, err := guildMember(s, guildID, s.State.User.ID)
+	if err != nil {
+		return nil, err
+	}
+	botTop := topRolePosition(guild, self)
+
+	result := &restoreResult{}
+	roleIDs := make(map[string]string) // Snapshot role ID -> current role ID
+
+	currentRoles := make(map[string]*discordgo.Role, len(roles))
+	for _, r := range roles {
+		currentRoles[r.ID] = r
+	}
+	var created []*discordgo.Role
+	for _, sr := range snap.Roles {
+		if sr.Managed {
+			if _, ok := currentRoles[sr.ID]; ok {
+				roleIDs[sr.ID] = sr.ID
+			}
+			continue
+		}
+		params := &discordgo.RoleParams{
+			Name:        sr.Name,
+			Color:       &sr.Color,
+			Hoist:       &sr.Hoist,
+			Permissions: &sr.Permissions,
+			Mentionable: &sr.Mentionable,
+		}
+
+		current, exists := currentRoles[sr.ID]
+		switch {
+		case exists && current.Position >= botTop && sr.ID != guildID:
+			// Above the bot, so leave it be
+			roleIDs[sr.ID] = sr.ID
+		case exists:
+			roleIDs[sr.ID] = sr.ID
+			if roleMatches(current, sr) {
+				continue
+			}
+			if sr.ID == guildID {
+				// @everyone only has permissions to restore
+				params = &discordgo.RoleParams{Permissions: &sr.Permissions}
+			}
+			if _, err := s.GuildRoleEdit(guildID, sr.ID, params); err != nil {
+				result.Failed = append(result.Failed, "role @"+sr.Name)
+				continue
+			}
+			result.RolesUpdated++
+		default:
+			role, err := s.GuildRoleCreate(guildID, params)
+			if err != nil {
+				result.Failed = append(result.Failed, "role @"+sr.Name)
+				continue
+			}
+			roleIDs[sr.ID] = role.ID
+			role.Position = max(1, min(sr.Position, botTop-1))
+			created = append(created, role)
+			result.RolesCreated++
+		}
+	}
+	if len(created) > 0 {
+		if _, err := s.GuildRoleReorder(guildID, created); err != nil {
+			result.Failed = append(result.Failed, "role order")
+		}
+	}
+
+	// Categories go first so the channels inside them have a parent to point at
+	ordered := slices.Clone(snap.Channels)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		return ordered[a].Type == discordgo.ChannelTypeGuildCategory && ordered[b].Type != discordgo.ChannelTypeGuildCategory
+	})
+
+	currentChannels := make(map[string]*discordgo.Channel, len(channels))
+	for _, c := range channels {
+		currentChannels[c.ID] = c
+	}
+	channelIDs := make(map[string]string) // Snapshot channel ID -> current channel ID
+	kept := make(map[string]bool)
+	for _, sc := range ordered {
+		var overwrites []*discordgo.PermissionOverwrite
+		for _, o := range sc.Overwrites {
+			id := o.ID
+			if o.Type == discordgo.PermissionOverwriteTypeRole {
+				if id = roleIDs[o.ID]; id == "" {
+					continue
+				}
+			}
+			overwrites = append(overwrites, &discordgo.PermissionOverwrite{ID: id, Type: o.Type, Allow: o.Allow, Deny: o.Deny})
+		}
+		parentID := channelIDs[sc.ParentID]
+
+		if current, ok := currentChannels[sc.ID]; ok {
+			channelIDs[sc.ID] = sc.ID
+			kept[sc.ID] = true
+			if channelMatches(current, sc, parentID, overwrites) {
+				continue
+			}
+			position, rateLimit, nsfw := sc.Position, sc.RateLimitPerUser, sc.NSFW
+			_, err := s.ChannelEdit(sc.ID, &discordgo.ChannelEdit{
+				Name:                 sc.Name,
+				Topic:                sc.Topic,
+				NSFW:                 &nsfw,
+				Position:             &position,
+				Bitrate:              sc.Bitrate,
+				UserLimit:            sc.UserLimit,
+				PermissionOverwrites: overwrites,
+				ParentID:             parentID,
+				RateLimitPerUser:     &rateLimit,
+			})
+			if err != nil {
+				result.Failed = append(result.Failed, "#"+sc.Name)
+				continue
+			}
+			result.ChannelsUpdated++
+			continue
+		}
+
+		channel, err := s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
+			Name:                 sc.Name,
+			Type:                 sc.Type,
+			Topic:                sc.Topic,
+			Bitrate:              sc.Bitrate,
+			UserLimit:            sc.UserLimit,
+			RateLimitPerUser:     sc.RateLimitPerUser,
+			Position:             sc.Position,
+			PermissionOverwrites: overwrites,
+			ParentID:             parentID,
+			NSFW:                 sc.NSFW,
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, "#"+sc.Name)
+			continue
+		}
+		channelIDs[sc.ID] = channel.ID
+		kept[channel.ID] = true
+		result.ChannelsCreated++
+	}
+
+	if deleteExtra {
+		// Children before categories, so nothing is left dangling mid-way
+		sort.SliceStable(channels, func(a, b int) bool {
+			return channels[a].Type != discordgo.ChannelTypeGuildCategory && channels[b].Type == discordgo.ChannelTypeGuildCategory
+		})
+		for _, c := range channels {
+			if kept[c.ID] || c.IsThread() {
+				continue
+			}
+			if _, err := s.ChannelDelete(c.ID); err != nil {
+				result.Failed = append(result.Failed, "delete #"+c.Name)
+				continue
+			}
+			result.ChannelsDeleted++
+		}
+	}
+
+	level := snap.Settings.VerificationLevel
+	_, err = s.GuildEdit(guildID, &discordgo.GuildParams{
+		VerificationLevel:           &level,
+		DefaultMessageNotifications: int(snap.Settings.DefaultMessageNotifications),
+		ExplicitContentFilter:       int(snap.Settings.ExplicitContentFilter),
+		AfkChannelID:                channelIDs[snap.Settings.AfkChannelID],
+		AfkTimeout:                  snap.Settings.AfkTimeout,
+		SystemChannelID:             channelIDs[snap.Settings.SystemChannelID],
+		SystemChannelFlags:          snap.Settings.SystemChannelFlags,
+	})
+	if err != nil {
+		result.Failed = append(result.Failed, "server settings")
+	}
+
+	return result, nil
+}
+
+// roleMatches reports whether a role already looks the way the snapshot has it
+func roleMatches(r *discordgo.Role, sr snapshotRole) bool {
+	return r.Name == sr.Name && r.Color == sr.Color && r.Hoist == sr.Hoist &&
+		r.Mentionable == sr.Mentionable && r.Permissions == sr.Permissions
+}
+
+// channelMatches reports whether a channel already looks the way the snapshot has it
+func channelMatches(c *discordgo.Channel, sc snapshotChannel, parentID string, overwrites []*discordgo.PermissionOverwrite) bool {
+	if c.Name != sc.Name || c.Topic != sc.Topic || c.NSFW != sc.NSFW || c.ParentID != parentID ||
+		c.Position != sc.Position || c.RateLimitPerUser != sc.RateLimitPerUser ||
+		c.Bitrate != sc.Bitrate || c.UserLimit != sc.UserLimit || len(c.PermissionOverwrites) != len(overwrites) {
+		return false
+	}
+	for _, want := range overwrites {
+		found := false
+		for _, have := range c.PermissionOverwrites {
+			if have.ID == want.ID && have.Type == want.Type && have.Allow == want.Allow && have.Deny == want.Deny {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// describeRestore summarises a restore for its result embed
+func describeRestore(r *restoreResult) string {
+	text := fmt.Sprintf("**Roles:** %d recreated, %d restored\n**Channels:** %d recreated, %d restored",
+		r.RolesCreated, r.RolesUpdated, r.ChannelsCreated, r.ChannelsUpdated)
+	if r.ChannelsDeleted > 0 {
+		text += fmt.Sprintf(", %d deleted", r.ChannelsDeleted)
+	}
+	if len(r.Failed) > 0 {
+		failed := r.Failed
+		more := ""
+		if len(failed) > 15 {
+			more = fmt.Sprintf(" and %d more", len(failed)-15)
+			failed = failed[:15]
+		}
+		text += fmt.Sprintf("\n\n**Couldn't restore:** %s%s", truncate(strings.Join(failed, ", "), 900), more)
+	}
+	return text
+}
@@ -0,0 +1,185 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+)
+
+// sponsorBlockCategory is a kind of segment SponsorBlock users mark in videos
+type sponsorBlockCategory struct {
+	Name  string // API name
+	Label string
+}
+
+var sponsorBlockCategories = []sponsorBlockCategory{
+	{"sponsor", "Sponsor"},
+	{"selfpromo", "Self Promotion"},
+	{"interaction", "Interaction Reminder"},
+	{"intro", "Intro"},
+	{"outro", "Outro"},
+	{"preview", "Preview/Recap"},
+	{"music_offtopic", "Non-Music Section"},
+	{"filler", "Filler"},
+}
+
+// defaultSponsorBlockCategories are skipped when a server hasn't picked its own
+var defaultSponsorBlockCategories = []string{"sponsor", "selfpromo", "interaction", "intro", "outro", "music_offtopic"}
+
+// sponsorBlockPoll is how often the current position is checked against the segments
+const sponsorBlockPoll = 500 * time.Millisecond
+
+// sponsorSegment is a stretch of a track to skip, in seconds
+type sponsorSegment struct {
+	Start float64
+	End   float64
+}
+
+// sponsorBlockSelection returns the categories a guild has chosen to skip, whether or not SponsorBlock is on
+func sponsorBlockSelection(settings *database.MusicSettings) []string {
+	if len(settings.SponsorBlockCategories) == 0 {
+		return defaultSponsorBlockCategories
+	}
+	return settings.SponsorBlockCategories
+}
+
+// sponsorBlockFor returns the categories a guild skips, nil when SponsorBlock is off
+func sponsorBlockFor(settings *database.MusicSettings) []string {
+	if !settings.SponsorBlock {
+		return nil
+	}
+	return sponsorBlockSelection(settings)
+}
+
+// fetchSponsorSegments looks up the skippable segments of a YouTube video
+func fetchSponsorSegments(videoID string, categories []string) ([]sponsorSegment, error) {
+	cats, _ := json.Marshal(categories)
+	query := url.Values{"videoID": {videoID}, "categories": {string(cats)}, "actionType": {"skip"}}
+	resp, err := httpClient.Get("https://sponsor.ajay.app/api/skipSegments?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Videos nobody has submitted segments for come back as a 404
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sponsorblock returned %s", resp.Status)
+	}
+
+	var result []struct {
+		Segment [2]float64 `json:"segment"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	segments := make([]sponsorSegment, 0, len(result))
+	for _, r := range result {
+		segments = append(segments, sponsorSegment{Start: r.Segment[0], End: r.Segment[1]})
+	}
+	return segments, nil
+}
+
+// SetSponsorBlock sets the SponsorBlock categories to skip, nil to turn skipping off
+func (p *MusicPlayer) SetSponsorBlock(categories []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sponsorBlock = categories
+	p.segmentsTrack = nil
+}
+
+// sponsorSegmentsFor returns the segments to skip in a track, fetching them
+// the first time the track starts and reusing them when it restarts after a seek
+func (p *MusicPlayer) sponsorSegmentsFor(track *Track, categories []string) []sponsorSegment {
+	p.mu.RLock()
+	if p.segmentsTrack == track {
+		defer p.mu.RUnlock()
+		return p.segments
+	}
+	p.mu.RUnlock()
+
+	var segments []sponsorSegment
+	if m := youtubeIDRegex.FindStringSubmatch(track.PageURL); m != nil {
+		var err error
+		if segments, err = fetchSponsorSegments(m[1], categories); err != nil {
+			fmt.Printf("SponsorBlock lookup failed for %s: %v\n", m[1], err)
+		}
+	}
+
+	p.mu.Lock()
+	p.segmentsTrack = track
+	p.segments = segments
+	p.mu.Unlock()
+	return segments
+}
+
+// skipSponsorSegments jumps past any segment playback enters until done is closed
+func (p *MusicPlayer) skipSponsorSegments(track *Track, categories []string, done <-chan struct{}) {
+	segments := p.sponsorSegmentsFor(track, categories)
+	if len(segments) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(sponsorBlockPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		position := p.Position().Seconds()
+		idx := slices.IndexFunc(segments, func(seg sponsorSegment) bool {
+			// Leave a little slack so landing right at the end doesn't skip again
+			return position >= seg.Start && position < seg.End-1
+		})
+		if idx >= 0 {
+			// The seek restarts the stream, which starts a new watcher
+			p.skipSegment(track, segments[idx].End)
+			return
+		}
+	}
+}
+
+// skipSegment moves playback of track to end, finishing it if the segment runs to the end
+func (p *MusicPlayer) skipSegment(track *Track, end float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.nowPlaying != track || p.stream == nil {
+		return
+	}
+	if track.Duration > 0 && int(end) >= track.Duration-1 {
+		// Ends the track as if it had played out, so loops still repeat it
+		p.stream.Stop()
+		return
+	}
+	p.seekPending = true
+	p.seekTarget = int(end + 0.5)
+	p.stream.Stop()
+	p.isPaused = false
+}
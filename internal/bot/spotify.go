@@ -0,0 +1,360 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// spotifyMaxTracks caps how many tracks of an album or playlist are queued
+const spotifyMaxTracks = 200
+
+var spotifyURLRegex = regexp.MustCompile(`(?:open\.spotify\.com/(?:intl-[a-z-]+/)?|spotify:)(track|album|playlist)[/:]([A-Za-z0-9]+)`)
+
+// SpotifyTrack is the metadata needed to find a track on YouTube
+type SpotifyTrack struct {
+	Name     string
+	Artists  []string
+	Duration int // seconds
+}
+
+// SearchQuery returns a yt-dlp search for the track's closest YouTube match
+func (t SpotifyTrack) SearchQuery() string {
+	return fmt.Sprintf("ytsearch1:%s - %s audio", strings.Join(t.Artists, ", "), t.Name)
+}
+
+// SpotifyClient resolves Spotify links using the client credentials flow
+type SpotifyClient struct {
+	clientID     string
+	clientSecret string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewSpotifyClient creates a client, or returns nil if no credentials are configured
+func NewSpotifyClient(clientID, clientSecret string) *SpotifyClient {
+	if clientID == "" || clientSecret == "" {
+		return nil
+	}
+	return &SpotifyClient{clientID: clientID, clientSecret: clientSecret}
+}
+
+// ParseSpotifyURL extracts the kind (track, album, playlist) and ID from a Spotify link
+func ParseSpotifyURL(link string) (kind, id string, ok bool) {
+	m := spotifyURLRegex.FindStringSubmatch(link)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+func (c *SpotifyClient) accessToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	req, err := http.NewRequest("POST", "https://accounts.spotify.com/api/token",
+		strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("spotify auth failed: %s", resp.Status)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	c.token = result.AccessToken
+	// Refresh a minute early so requests never race the expiry
+	c.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn-60) * time.Second)
+	return c.token, nil
+}
+
+func (c *SpotifyClient) get(endpoint string, v interface{}) error {
+	token, err := c.accessToken()
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "https://api.spotify.com/v1/" + endpoint
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusNotFound:
+		return fmt.Errorf("not found on Spotify (private playlists can't be read)")
+	default:
+		return fmt.Errorf("spotify API error: %s", resp.Status)
+	}
+}
+
+type spotifyTrackObject struct {
+	Name       string `json:"name"`
+	DurationMS int    `json:"duration_ms"`
+	Artists    []struct {
+		Name string `json:"name"`
+	} `json:"artists"`
+}
+
+func (o spotifyTrackObject) toTrack() SpotifyTrack {
+	t := SpotifyTrack{Name: o.Name, Duration: o.DurationMS / 1000}
+	for _, a := range o.Artists {
+		t.Artists = append(t.Artists, a.Name)
+	}
+	return t
+}
+
+// Resolve returns the title of the linked item and the tracks it contains,
+// following pagination up to spotifyMaxTracks
+func (c *SpotifyClient) Resolve(kind, id string) (string, []SpotifyTrack, error) {
+	switch kind {
+	case "track":
+		var track spotifyTrackObject
+		if err := c.get("tracks/"+id, &track); err != nil {
+			return "", nil, err
+		}
+		t := track.toTrack()
+		return t.Name, []SpotifyTrack{t}, nil
+
+	case "album":
+		var album struct {
+			Name   string `json:"name"`
+			Tracks struct {
+				Items []spotifyTrackObject `json:"items"`
+				Next  string               `json:"next"`
+			} `json:"tracks"`
+		}
+		if err := c.get("albums/"+id, &album); err != nil {
+			return "", nil, err
+		}
+
+		var tracks []SpotifyTrack
+		page := album.Tracks
+		for {
+			for _, item := range page.Items {
+				tracks = append(tracks, item.toTrack())
+			}
+			if page.Next == "" || len(tracks) >= spotifyMaxTracks {
+				break
+			}
+			next := page.Next
+			page.Items, page.Next = nil, ""
+			if err := c.get(next, &page); err != nil {
+				return "", nil, err
+			}
+		}
+		return album.Name, capSpotifyTracks(tracks), nil
+
+	case "playlist":
+		type playlistPage struct {
+			Items []struct {
+				Track *spotifyTrackObject `json:"track"`
+			} `json:"items"`
+			Next string `json:"next"`
+		}
+		var playlist struct {
+			Name   string       `json:"name"`
+			Tracks playlistPage `json:"tracks"`
+		}
+		if err := c.get("playlists/"+id, &playlist); err != nil {
+			return "", nil, err
+		}
+
+		var tracks []SpotifyTrack
+		page := playlist.Tracks
+		for {
+			for _, item := range page.Items {
+				// Local files and removed tracks come back without metadata
+				if item.Track != nil && item.Track.Name != "" {
+					tracks = append(tracks, item.Track.toTrack())
+				}
+			}
+			if page.Next == "" || len(tracks) >= spotifyMaxTracks {
+				break
+			}
+			next := page.Next
+			page = playlistPage{}
+			if err := c.get(next, &page); err != nil {
+				return "", nil, err
+			}
+		}
+		return playlist.Name, capSpotifyTracks(tracks), nil
+	}
+
+	return "", nil, fmt.Errorf("unsupported Spotify link type: %s", kind)
+}
+
+func capSpotifyTracks(tracks []SpotifyTrack) []SpotifyTrack {
+	if len(tracks) > spotifyMaxTracks {
+		return tracks[:spotifyMaxTracks]
+	}
+	return tracks
+}
+
+// playSpotify resolves a Spotify link and queues the YouTube match for each track,
+// reporting progress on the deferred response while large collections load
+func (ch *CommandHandler) playSpotify(s *discordgo.Session, i *discordgo.InteractionCreate, player *MusicPlayer, channelID, kind, id string) {
+	if ch.bot.Spotify == nil {
+		editResponse(s, i, "Spotify links aren't supported because no Spotify credentials are configured.")
+		return
+	}
+
+	title, tracks, err := ch.bot.Spotify.Resolve(kind, id)
+	if err != nil {
+		editResponse(s, i, "Failed to read Spotify link: "+err.Error())
+		return
+	}
+	if len(tracks) == 0 {
+		editResponse(s, i, "That Spotify link has no playable tracks.")
+		return
+	}
+
+	var first *Track
+	queued, skipped := 0, 0
+	lastUpdate := time.Now()
+	limits := ch.queueLimitsFor(s, i.GuildID, i.Member.User.ID)
+	limited := ""
+	for _, st := range tracks {
+		info, err := ExtractInfo(st.SearchQuery(), ch.bot.Config().APIs.YouTubeAPIKey, ch.bot.Config().APIs.SoundCloudAuthToken)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		track := &Track{
+			Title:       info.Title,
+			URL:         info.URL,
+			Duration:    info.Duration,
+			Thumbnail:   info.Thumbnail,
+			Requester:   i.Member.User.Username,
+			RequesterID: i.Member.User.ID,
+			Source:      "Spotify",
+			PageURL:     info.WebpageURL,
+		}
+		if track.Duration == 0 {
+			track.Duration = st.Duration
+		}
+		reason, full := limits.check(player, track)
+		if reason != "" {
+			limited = reason
+			if full {
+				break
+			}
+			continue
+		}
+		if err := ch.queueTrack(i, player, channelID, track); err != nil {
+			editResponse(s, i, "Failed to start playback: "+err.Error())
+			return
+		}
+		if first == nil {
+			first = track
+		}
+		queued++
+
+		if len(tracks) > 1 && time.Since(lastUpdate) > 3*time.Second {
+			editResponse(s, i, fmt.Sprintf("⏳ Finding **%s** on YouTube... %d/%d", title, queued+skipped, len(tracks)))
+			lastUpdate = time.Now()
+		}
+	}
+
+	if queued == 0 {
+		if limited != "" {
+			editResponse(s, i, limited)
+		} else {
+			editResponse(s, i, "Couldn't find any of those tracks on YouTube.")
+		}
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Added to Queue",
+		Description: first.Title,
+		Color:       0x1DB954, // Spotify green
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Duration", Value: formatMusicDuration(first.Duration), Inline: true},
+			{Name: "Requested by", Value: i.Member.User.Username, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Matched from Spotify"},
+	}
+	if len(tracks) > 1 {
+		embed.Title = fmt.Sprintf("Spotify %s Queued", strings.ToUpper(kind[:1])+kind[1:])
+		embed.Description = title
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Queued", Value: fmt.Sprintf("%d tracks", queued), Inline: true},
+			{Name: "Requested by", Value: i.Member.User.Username, Inline: true},
+		}
+		if skipped > 0 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name: "Not Found", Value: fmt.Sprintf("%d tracks", skipped), Inline: true,
+			})
+		}
+		if limited != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Limited", Value: limited})
+		}
+	}
+	if first.Thumbnail != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: first.Thumbnail}
+	}
+
+	content := ""
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		Embeds:  &[]*discordgo.MessageEmbed{embed},
+	})
+}
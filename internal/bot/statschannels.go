@@ -0,0 +1,172 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// statsChannelInterval is how often stats channels are renamed. Discord only allows
+// two renames per channel every 10 minutes, so there's no point going faster.
+const statsChannelInterval = 10 * time.Minute
+
+// statsChannelDefaults are the channel names used when a stats channel has no template
+var statsChannelDefaults = map[string]string{
+	database.StatsChannelMembers: "Members: {count}",
+	database.StatsChannelHumans:  "Humans: {count}",
+	database.StatsChannelBots:    "Bots: {count}",
+	database.StatsChannelGoal:    "Goal: {count}/{goal}",
+}
+
+// statsChannelTracker remembers rate limits between passes and which guilds have had
+// their member list requested, which is needed to count bots
+var statsChannelTracker = struct {
+	sync.Mutex
+	running bool
+	retryAt map[string]time.Time
+	chunked map[string]bool
+}{retryAt: make(map[string]time.Time), chunked: make(map[string]bool)}
+
+// guildMemberCounts returns a guild's member and bot counts from the state cache.
+// The first time a guild's cache is incomplete its full member list is requested,
+// so bot counts are right from the next pass on.
+func guildMemberCounts(s *discordgo.Session, guildID string) (total, bots int, ok bool) {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	s.State.RLock()
+	total = guild.MemberCount
+	cached := len(guild.Members)
+	for _, member := range guild.Members {
+		if member.User != nil && member.User.Bot {
+			bots++
+		}
+	}
+	s.State.RUnlock()
+
+	if cached < total {
+		statsChannelTracker.Lock()
+		requested := statsChannelTracker.chunked[guildID]
+		statsChannelTracker.chunked[guildID] = true
+		statsChannelTracker.Unlock()
+		if !requested {
+			s.RequestGuildMembers(guildID, "", 0, "", false)
+		}
+	}
+	return total, bots, true
+}
+
+// statsChannelName fills in a stats channel's template. Besides {count} it takes
+// {members}, {humans} and {bots}, and for goal channels {goal}, {remaining} and {percent}.
+func statsChannelName(c *database.StatsChannel, total, bots int) string {
+	template := c.Template
+	if template == "" {
+		template = statsChannelDefaults[c.Kind]
+	}
+
+	count := total
+	switch c.Kind {
+	case database.StatsChannelHumans:
+		count = total - bots
+	case database.StatsChannelBots:
+		count = bots
+	}
+
+	remaining, percent := 0, 100
+	if c.Goal > 0 {
+		remaining = max(c.Goal-total, 0)
+		percent = min(total*100/c.Goal, 100)
+	}
+
+	name := strings.NewReplacer(
+		"{count}", strconv.Itoa(count),
+		"{members}", strconv.Itoa(total),
+		"{humans}", strconv.Itoa(total-bots),
+		"{bots}", strconv.Itoa(bots),
+		"{goal}", strconv.Itoa(c.Goal),
+		"{remaining}", strconv.Itoa(remaining),
+		"{percent}", strconv.Itoa(percent),
+	).Replace(template)
+	return truncate(strings.TrimSpace(name), 100)
+}
+
+// renameStatsChannel renames a stats channel if its count changed. Rate limits aren't
+// waited out; the channel is skipped until Discord allows it again.
+func (b *Bot) renameStatsChannel(s *discordgo.Session, c *database.StatsChannel) error {
+	total, bots, ok := guildMemberCounts(s, c.GuildID)
+	if !ok {
+		return nil
+	}
+	name := statsChannelName(c, total, bots)
+	if channel, err := s.State.Channel(c.ChannelID); err == nil && channel.Name == name {
+		return nil
+	}
+
+	statsChannelTracker.Lock()
+	retryAt := statsChannelTracker.retryAt[c.ChannelID]
+	statsChannelTracker.Unlock()
+	if time.Now().Before(retryAt) {
+		return nil
+	}
+
+	_, err := s.ChannelEdit(c.ChannelID, &discordgo.ChannelEdit{Name: name}, discordgo.WithRetryOnRatelimit(false))
+	var rateLimit *discordgo.RateLimitError
+	if errors.As(err, &rateLimit) {
+		statsChannelTracker.Lock()
+		statsChannelTracker.retryAt[c.ChannelID] = time.Now().Add(rateLimit.RetryAfter)
+		statsChannelTracker.Unlock()
+	}
+	return err
+}
+
+// updateStatsChannels renames every stats channel whose count changed and forgets
+// channels that were deleted
+func (b *Bot) updateStatsChannels() {
+	statsChannelTracker.Lock()
+	if statsChannelTracker.running {
+		statsChannelTracker.Unlock()
+		return
+	}
+	statsChannelTracker.running = true
+	statsChannelTracker.Unlock()
+	defer func() {
+		statsChannelTracker.Lock()
+		statsChannelTracker.running = false
+		statsChannelTracker.Unlock()
+	}()
+
+	channels, err := b.DB.GetAllStatsChannels()
+	if err != nil {
+		return
+	}
+
+	for _, c := range channels {
+		err := b.renameStatsChannel(b.Session, c)
+		if restErr, ok := err.(*discordgo.RESTError); ok && restErr.Response != nil && restErr.Response.StatusCode == 404 {
+			b.DB.DeleteStatsChannel(c.ChannelID)
+		}
+	}
+}
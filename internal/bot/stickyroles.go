@@ -0,0 +1,123 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// snapshotMember saves a member's roles and nickname for guilds with sticky roles enabled.
+// The state cache drops members before leave handlers run, so snapshots are kept up to date instead.
+func (b *Bot) snapshotMember(member *discordgo.Member) {
+	if member == nil || member.User == nil || member.User.Bot {
+		return
+	}
+	cfg, err := b.DB.GetStickyRolesConfig(member.GuildID)
+	if err != nil || !cfg.Enabled {
+		return
+	}
+	b.DB.SaveMemberSnapshot(member.GuildID, member.User.ID, member.Roles, member.Nick)
+}
+
+// snapshotGuildMembers saves every cached member of a guild, e.g. when sticky roles is first enabled
+func (b *Bot) snapshotGuildMembers(s *discordgo.Session, guildID string) int {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return 0
+	}
+
+	s.State.RLock()
+	members := make([]*discordgo.Member, len(guild.Members))
+	copy(members, guild.Members)
+	s.State.RUnlock()
+
+	count := 0
+	for _, member := range members {
+		if member.User == nil || member.User.Bot {
+			continue
+		}
+		if err := b.DB.SaveMemberSnapshot(guildID, member.User.ID, member.Roles, member.Nick); err == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// markMemberLeft keeps a leaving member's snapshot for restoring on rejoin
+func (b *Bot) markMemberLeft(guildID, userID string) {
+	cfg, err := b.DB.GetStickyRolesConfig(guildID)
+	if err != nil || !cfg.Enabled {
+		return
+	}
+	b.DB.MarkMemberLeft(guildID, userID)
+}
+
+// restoreStickyRoles gives a rejoining member their saved roles and nickname
+func (b *Bot) restoreStickyRoles(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if m.User.Bot {
+		return
+	}
+	cfg, err := b.DB.GetStickyRolesConfig(m.GuildID)
+	if err != nil || !cfg.Enabled {
+		return
+	}
+	snapshot, err := b.DB.GetLeftMemberSnapshot(m.GuildID, m.User.ID)
+	if err != nil || snapshot == nil {
+		return
+	}
+
+	excluded := make(map[string]bool, len(cfg.ExcludedRoles))
+	for _, roleID := range cfg.ExcludedRoles {
+		excluded[roleID] = true
+	}
+
+	roles := append([]string{}, m.Roles...)
+	restored := 0
+	for _, roleID := range snapshot.Roles {
+		if roleID == "" || excluded[roleID] || hasRole(roles, roleID) {
+			continue
+		}
+		// Skip deleted roles and integration roles, which can't be assigned
+		role, err := s.State.Role(m.GuildID, roleID)
+		if err != nil || role.Managed || role.ID == m.GuildID {
+			continue
+		}
+		roles = append(roles, roleID)
+		restored++
+	}
+
+	params := &discordgo.GuildMemberParams{}
+	if restored > 0 {
+		params.Roles = &roles
+	}
+	if cfg.RestoreNickname && snapshot.Nickname != "" {
+		params.Nick = snapshot.Nickname
+	}
+
+	if params.Roles != nil || params.Nick != "" {
+		_, err := s.GuildMemberEdit(m.GuildID, m.User.ID, params, discordgo.WithAuditLogReason("Sticky roles: restoring roles from previous membership"))
+		if err != nil {
+			log.Printf("Sticky roles: failed to restore %s in %s: %v", m.User.ID, m.GuildID, err)
+			return
+		}
+	}
+
+	// Start tracking the new membership
+	b.DB.SaveMemberSnapshot(m.GuildID, m.User.ID, roles, params.Nick)
+}
@@ -0,0 +1,179 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Suggestion statuses and how they are shown
+var suggestionStatuses = map[string]struct {
+	Label string
+	Color int
+}{
+	"pending":     {"Pending", 0x5865F2},
+	"considered":  {"Under Consideration", 0xFEE75C},
+	"approved":    {"Approved", 0x57F287},
+	"denied":      {"Denied", 0xED4245},
+	"implemented": {"Implemented", 0x9B59B6},
+}
+
+// suggestionClosed reports whether a suggestion no longer takes votes
+func suggestionClosed(status string) bool {
+	return status == "denied" || status == "implemented"
+}
+
+// renderSuggestion builds the embed and vote buttons for a suggestion
+func renderSuggestion(s *discordgo.Session, sg *database.Suggestion) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	status := suggestionStatuses[sg.Status]
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Suggestion #%d", sg.Number),
+		Description: sg.Content,
+		Color:       status.Color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: status.Label, Inline: true},
+			{Name: "Votes", Value: fmt.Sprintf("👍 %d • 👎 %d", sg.Upvotes, sg.Downvotes), Inline: true},
+		},
+		Timestamp: sg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if user, err := s.User(sg.UserID); err == nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: user.Username, IconURL: avatarURL(user)}
+	}
+	if sg.StaffID != "" {
+		value := fmt.Sprintf("<@%s>", sg.StaffID)
+		if sg.StaffReason != "" {
+			value += ": " + sg.StaffReason
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Staff Response", Value: truncate(value, 1024)})
+	}
+
+	closed := suggestionClosed(sg.Status)
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    strconv.Itoa(sg.Upvotes),
+				Emoji:    &discordgo.ComponentEmoji{Name: "👍"},
+				Style:    discordgo.SuccessButton,
+				CustomID: fmt.Sprintf("suggest:up:%d", sg.ID),
+				Disabled: closed,
+			},
+			discordgo.Button{
+				Label:    strconv.Itoa(sg.Downvotes),
+				Emoji:    &discordgo.ComponentEmoji{Name: "👎"},
+				Style:    discordgo.DangerButton,
+				CustomID: fmt.Sprintf("suggest:down:%d", sg.ID),
+				Disabled: closed,
+			},
+		}},
+	}
+	return embed, components
+}
+
+// updateSuggestionMessage re-renders a posted suggestion
+func (b *Bot) updateSuggestionMessage(s *discordgo.Session, sg *database.Suggestion) {
+	if sg.MessageID == "" {
+		return
+	}
+	embed, components := renderSuggestion(s, sg)
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         sg.MessageID,
+		Channel:    sg.ChannelID,
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+}
+
+// handleSuggestionVote handles the vote buttons ("suggest:up:<id>" / "suggest:down:<id>")
+func (ch *CommandHandler) handleSuggestionVote(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 3 {
+		return
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return
+	}
+
+	sg, err := ch.bot.DB.GetSuggestion(id)
+	if err != nil || sg == nil {
+		respondEphemeral(s, i, "This suggestion no longer exists.")
+		return
+	}
+	if suggestionClosed(sg.Status) {
+		respondEphemeral(s, i, "Voting on this suggestion is closed.")
+		return
+	}
+
+	vote := 1
+	if parts[1] == "down" {
+		vote = -1
+	}
+	if err := ch.bot.DB.ToggleSuggestionVote(sg.ID, i.Member.User.ID, vote); err != nil {
+		respondEphemeral(s, i, "Failed to record your vote.")
+		return
+	}
+
+	sg, err = ch.bot.DB.GetSuggestion(id)
+	if err != nil || sg == nil {
+		return
+	}
+	embed, components := renderSuggestion(s, sg)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+}
+
+// notifySuggestionAuthor DMs the author about a status change
+func (b *Bot) notifySuggestionAuthor(s *discordgo.Session, sg *database.Suggestion) {
+	channel, err := s.UserChannelCreate(sg.UserID)
+	if err != nil {
+		return
+	}
+
+	guildName := sg.GuildID
+	if guild, err := s.State.Guild(sg.GuildID); err == nil {
+		guildName = guild.Name
+	}
+
+	status := suggestionStatuses[sg.Status]
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Your suggestion #%d was marked %s", sg.Number, strings.ToLower(status.Label)),
+		Description: truncate(sg.Content, 2048),
+		Color:       status.Color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: guildName},
+	}
+	if sg.StaffReason != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Reason", Value: truncate(sg.StaffReason, 1024)})
+	}
+	if sg.MessageID != "" {
+		embed.URL = fmt.Sprintf("https://discord.com/channels/%s/%s/%s", sg.GuildID, sg.ChannelID, sg.MessageID)
+	}
+	s.ChannelMessageSendEmbed(channel.ID, embed)
+}
@@ -0,0 +1,124 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// templatePattern matches {name} and {name:argument} placeholders
+var templatePattern = regexp.MustCompile(`\{([a-z]+)(?::([^{}]*))?\}`)
+
+// templateContext is what a tag or custom command response is rendered against
+type templateContext struct {
+	Session   *discordgo.Session
+	GuildID   string
+	ChannelID string
+	User      *discordgo.User
+	Member    *discordgo.Member
+	Args      []string
+}
+
+// renderTemplate fills in the placeholders of a tag or custom command response.
+// Unknown placeholders are left as written so ordinary braces survive.
+//
+//	{user} {username} {userid} {nickname}   the member who ran it
+//	{server} {serverid} {membercount}        the server
+//	{channel} {channelid}                    where it was run
+//	{args} {arg:N}                           everything after the name, or the Nth word
+//	{random:a|b|c}                           one of the options
+//	{range:1-100}                            a number in the range
+//	{choose}                                 one of the words passed as arguments
+func renderTemplate(text string, tctx *templateContext) string {
+	var guild *discordgo.Guild
+	if tctx.Session != nil && tctx.GuildID != "" {
+		guild, _ = tctx.Session.State.Guild(tctx.GuildID)
+	}
+
+	return templatePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := templatePattern.FindStringSubmatch(match)
+		name, arg := parts[1], parts[2]
+
+		switch name {
+		case "user":
+			return tctx.User.Mention()
+		case "username":
+			return tctx.User.Username
+		case "userid":
+			return tctx.User.ID
+		case "nickname":
+			if tctx.Member != nil && tctx.Member.Nick != "" {
+				return tctx.Member.Nick
+			}
+			if tctx.User.GlobalName != "" {
+				return tctx.User.GlobalName
+			}
+			return tctx.User.Username
+		case "server":
+			if guild != nil {
+				return guild.Name
+			}
+			return tctx.GuildID
+		case "serverid":
+			return tctx.GuildID
+		case "membercount":
+			if guild != nil {
+				return strconv.Itoa(guild.MemberCount)
+			}
+		case "channel":
+			return "<#" + tctx.ChannelID + ">"
+		case "channelid":
+			return tctx.ChannelID
+		case "args":
+			return strings.Join(tctx.Args, " ")
+		case "arg":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return match
+			}
+			if n <= len(tctx.Args) {
+				return tctx.Args[n-1]
+			}
+			return ""
+		case "random":
+			if arg == "" {
+				return match
+			}
+			options := strings.Split(arg, "|")
+			return options[rand.Intn(len(options))]
+		case "range":
+			lo, hi, ok := strings.Cut(arg, "-")
+			low, err1 := strconv.Atoi(strings.TrimSpace(lo))
+			high, err2 := strconv.Atoi(strings.TrimSpace(hi))
+			if !ok || err1 != nil || err2 != nil || high < low {
+				return match
+			}
+			return strconv.Itoa(low + rand.Intn(high-low+1))
+		case "choose":
+			if len(tctx.Args) == 0 {
+				return ""
+			}
+			return tctx.Args[rand.Intn(len(tctx.Args))]
+		}
+		return match
+	})
+}
@@ -0,0 +1,141 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// ticketInactivityCheckInterval is how often open tickets are checked for inactivity
+const ticketInactivityCheckInterval = 5 * time.Minute
+
+// checkInactiveTickets warns tickets that have gone quiet and closes those still quiet after the warning
+func (b *Bot) checkInactiveTickets() {
+	configs, err := b.DB.GetTicketAutoCloseConfigs()
+	if err != nil {
+		log.Printf("Failed to get ticket auto-close configs: %v", err)
+		return
+	}
+
+	for idx := range configs {
+		config := &configs[idx]
+		tickets, err := b.DB.GetOpenTickets(config.GuildID)
+		if err != nil {
+			continue
+		}
+		for t := range tickets {
+			b.checkTicketActivity(config, &tickets[t])
+		}
+	}
+}
+
+func (b *Bot) checkTicketActivity(config *database.TicketConfig, ticket *database.Ticket) {
+	s := b.Session
+
+	// State doesn't track last message IDs, so ask Discord
+	channel, err := s.Channel(ticket.ChannelID)
+	if err != nil {
+		// The thread or channel was deleted by hand
+		if restErr, ok := err.(*discordgo.RESTError); ok && restErr.Response != nil && restErr.Response.StatusCode == 404 {
+			b.DB.CloseTicket(ticket.ID, "")
+		}
+		return
+	}
+
+	lastActivity := ticket.CreatedAt
+	if channel.LastMessageID != "" {
+		if t, err := discordgo.SnowflakeTimestamp(channel.LastMessageID); err == nil {
+			lastActivity = t
+		}
+	}
+
+	if ticket.InactivityWarnedAt == nil {
+		if time.Since(lastActivity) < time.Duration(config.InactiveHours)*time.Hour {
+			return
+		}
+		msg, err := s.ChannelMessageSendComplex(ticket.ChannelID, &discordgo.MessageSend{
+			Content: fmt.Sprintf("<@%s>", ticket.OpenerID),
+			Embeds: []*discordgo.MessageEmbed{{
+				Title: "Ticket Inactive",
+				Description: fmt.Sprintf("This ticket has had no messages for %dh and will be closed <t:%d:R>. Send a message or press the button below to keep it open.",
+					config.InactiveHours, time.Now().Add(time.Duration(config.InactiveGraceHours)*time.Hour).Unix()),
+				Color: 0xFEE75C,
+			}},
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{ticket.OpenerID}},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Keep Open", Style: discordgo.PrimaryButton, CustomID: "ticket:keepopen", Emoji: &discordgo.ComponentEmoji{Name: "⏳"}},
+				}},
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to warn inactive ticket %d: %v", ticket.ID, err)
+			return
+		}
+		b.DB.SetTicketInactivityWarning(ticket.ID, msg.ID)
+		return
+	}
+
+	// Anyone talking after the warning keeps the ticket open
+	if channel.LastMessageID != ticket.InactivityWarningID && lastActivity.After(*ticket.InactivityWarnedAt) {
+		b.DB.ClearTicketInactivityWarning(ticket.ID)
+		s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    ticket.ChannelID,
+			ID:         ticket.InactivityWarningID,
+			Components: &[]discordgo.MessageComponent{},
+		})
+		return
+	}
+
+	if time.Since(*ticket.InactivityWarnedAt) < time.Duration(config.InactiveGraceHours)*time.Hour {
+		return
+	}
+
+	if err := b.DB.CloseTicket(ticket.ID, s.State.User.ID); err != nil {
+		return
+	}
+	embed := ticketClosedEmbed(ticket, s.State.User.ID,
+		fmt.Sprintf("No activity for %dh", config.InactiveHours+config.InactiveGraceHours))
+	s.ChannelMessageSendEmbed(ticket.ChannelID, embed)
+	go b.Commands.finishTicketClose(s, ticket, config, embed)
+}
+
+// cancelTicketAutoClose handles the Keep Open button on an inactivity warning
+func (ch *CommandHandler) cancelTicketAutoClose(s *discordgo.Session, i *discordgo.InteractionCreate, ticket *database.Ticket) {
+	if ticket.InactivityWarnedAt == nil {
+		respondEphemeral(s, i, "This ticket isn't scheduled to close.")
+		return
+	}
+	if err := ch.bot.DB.ClearTicketInactivityWarning(ticket.ID); err != nil {
+		respondEphemeral(s, i, "Failed to keep the ticket open.")
+		return
+	}
+
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         i.Message.ID,
+		Components: &[]discordgo.MessageComponent{},
+	})
+	// A fresh message here also restarts the inactivity timer
+	respondEmbed(s, i, successEmbed("Auto-Close Cancelled",
+		fmt.Sprintf("%s kept this ticket open.", i.Member.User.Mention())))
+}
@@ -0,0 +1,215 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxTranscriptMessages caps how far back a ticket transcript reaches
+const maxTranscriptMessages = 5000
+
+// fetchChannelHistory returns up to limit of a channel's most recent messages,
+// oldest first, stopping at the first message sent before since when it's set
+func fetchChannelHistory(s *discordgo.Session, channelID string, limit int, since time.Time) ([]*discordgo.Message, error) {
+	var messages []*discordgo.Message
+	before := ""
+	for len(messages) < limit {
+		count := min(100, limit-len(messages))
+		batch, err := s.ChannelMessages(channelID, count, before, "", "")
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range batch {
+			if !since.IsZero() && m.Timestamp.Before(since) {
+				slices.Reverse(messages)
+				return messages, nil
+			}
+			messages = append(messages, m)
+		}
+		if len(batch) < count {
+			break
+		}
+		before = batch[len(batch)-1].ID
+	}
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+// transcriptEmbedText flattens an embed's title, description and fields
+func transcriptEmbedText(e *discordgo.MessageEmbed) string {
+	var parts []string
+	if e.Title != "" {
+		parts = append(parts, e.Title)
+	}
+	if e.Description != "" {
+		parts = append(parts, e.Description)
+	}
+	for _, f := range e.Fields {
+		parts = append(parts, f.Name+": "+f.Value)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// transcriptContent flattens a message's text, embeds and attachments into lines
+func transcriptContent(m *discordgo.Message) []string {
+	var lines []string
+	if m.Content != "" {
+		lines = append(lines, m.Content)
+	}
+	for _, e := range m.Embeds {
+		if text := transcriptEmbedText(e); text != "" {
+			lines = append(lines, "[Embed] "+text)
+		}
+	}
+	for _, a := range m.Attachments {
+		lines = append(lines, "[Attachment] "+a.URL)
+	}
+	return lines
+}
+
+// transcriptPage renders messages as a standalone HTML page. attachmentLink
+// picks where each attachment links to; nil links to Discord's CDN
+func transcriptPage(title, subtitle string, messages []*discordgo.Message, attachmentLink func(*discordgo.MessageAttachment) string) string {
+	var page strings.Builder
+	fmt.Fprintf(&page, `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>%s</title>
+<style>
+body{background:#313338;color:#dbdee1;font-family:"gg sans","Helvetica Neue",Helvetica,Arial,sans-serif;margin:0;padding:20px}
+h1{font-size:20px;margin:0 0 4px}
+.meta{color:#949ba4;font-size:13px;margin-bottom:20px}
+.msg{display:flex;gap:12px;padding:6px 0}
+.msg img.avatar{width:40px;height:40px;border-radius:50%%}
+.author{font-weight:600;color:#f2f3f5}
+.time{color:#949ba4;font-size:12px;margin-left:6px}
+.content{white-space:pre-wrap;word-wrap:break-word}
+.embed{border-left:4px solid #ff69b4;background:#2b2d31;padding:6px 10px;margin-top:4px;white-space:pre-wrap}
+a{color:#00a8fc}
+</style></head><body>
+<h1>%s</h1>
+<div class="meta">%s</div>
+`, html.EscapeString(title), html.EscapeString(title), html.EscapeString(subtitle))
+
+	for _, m := range messages {
+		if m.Author == nil {
+			continue
+		}
+		fmt.Fprintf(&page, `<div class="msg"><img class="avatar" src="%s" alt=""><div><span class="author">%s</span><span class="time">%s</span>`,
+			html.EscapeString(avatarURL(m.Author)), html.EscapeString(m.Author.Username), m.Timestamp.UTC().Format("2006-01-02 15:04"))
+		if m.Content != "" {
+			fmt.Fprintf(&page, `<div class="content">%s</div>`, html.EscapeString(m.Content))
+		}
+		for _, e := range m.Embeds {
+			if embedText := transcriptEmbedText(e); embedText != "" {
+				fmt.Fprintf(&page, `<div class="embed">%s</div>`, html.EscapeString(embedText))
+			}
+		}
+		for _, a := range m.Attachments {
+			link := a.URL
+			if attachmentLink != nil {
+				link = attachmentLink(a)
+			}
+			fmt.Fprintf(&page, `<div class="content"><a href="%s">%s</a></div>`, html.EscapeString(link), html.EscapeString(a.Filename))
+		}
+		page.WriteString("</div></div>\n")
+	}
+	page.WriteString("</body></html>\n")
+
+	return page.String()
+}
+
+// buildTicketTranscript renders a ticket's messages as plain text and as a standalone HTML page
+func buildTicketTranscript(ticket *database.Ticket, guildName string, messages []*discordgo.Message) (string, string) {
+	title := fmt.Sprintf("Ticket #%d - %s", ticket.Number, ticket.Subject)
+	opened := ticket.CreatedAt.UTC().Format("2006-01-02 15:04 MST")
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s\nServer: %s\nOpened: %s\n\n", title, guildName, opened)
+	for _, m := range messages {
+		if m.Author == nil {
+			continue
+		}
+		fmt.Fprintf(&text, "[%s] %s: %s\n", m.Timestamp.UTC().Format("2006-01-02 15:04"), m.Author.Username, strings.Join(transcriptContent(m), "\n    "))
+	}
+
+	page := transcriptPage(title, fmt.Sprintf("%s · opened %s · %d messages", guildName, opened, len(messages)), messages, nil)
+	return text.String(), page
+}
+
+// transcriptFiles returns fresh readers for each send, since a reader can only be uploaded once
+func transcriptFiles(ticket *database.Ticket, text, page string) []*discordgo.File {
+	name := fmt.Sprintf("ticket-%04d", ticket.Number)
+	return []*discordgo.File{
+		{Name: name + ".html", ContentType: "text/html", Reader: bytes.NewReader([]byte(page))},
+		{Name: name + ".txt", ContentType: "text/plain", Reader: bytes.NewReader([]byte(text))},
+	}
+}
+
+// saveTicketTranscript posts the transcript of a closed ticket to the transcript channel and DMs it to the opener
+func (ch *CommandHandler) saveTicketTranscript(s *discordgo.Session, ticket *database.Ticket, config *database.TicketConfig, channel *discordgo.Channel, embed *discordgo.MessageEmbed) {
+	messages, err := fetchChannelHistory(s, channel.ID, maxTranscriptMessages, time.Time{})
+	if err != nil {
+		log.Printf("Failed to fetch messages for ticket %d: %v", ticket.ID, err)
+		return
+	}
+
+	guildName := ticket.GuildID
+	if guild, err := s.State.Guild(ticket.GuildID); err == nil {
+		guildName = guild.Name
+	}
+	text, page := buildTicketTranscript(ticket, guildName, messages)
+
+	// Channel tickets are deleted, so they fall back to the log channel
+	transcriptChannelID := ""
+	if config != nil {
+		transcriptChannelID = config.TranscriptChannelID
+		if transcriptChannelID == "" && !channel.IsThread() {
+			transcriptChannelID = config.ChannelID
+		}
+	}
+
+	if transcriptChannelID != "" {
+		msg, err := s.ChannelMessageSendComplex(transcriptChannelID, &discordgo.MessageSend{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Files:  transcriptFiles(ticket, text, page),
+		})
+		if err != nil {
+			log.Printf("Failed to post transcript for ticket %d: %v", ticket.ID, err)
+		} else if err := ch.bot.DB.SetTicketTranscript(ticket.ID, msg.ChannelID, msg.ID); err != nil {
+			log.Printf("Failed to save transcript reference for ticket %d: %v", ticket.ID, err)
+		}
+	}
+
+	dm, err := s.UserChannelCreate(ticket.OpenerID)
+	if err != nil {
+		return
+	}
+	s.ChannelMessageSendComplex(dm.ID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("Your ticket **#%d** in **%s** was closed on %s. Here's a copy of the conversation.",
+			ticket.Number, guildName, time.Now().UTC().Format("2006-01-02 15:04 MST")),
+		Files: transcriptFiles(ticket, text, page),
+	})
+}
@@ -0,0 +1,183 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+)
+
+// ttsMaxLength caps how much text one /tts clip reads out
+const ttsMaxLength = 300
+
+// ttsFileTTL is how long synthesized clips are kept, long enough to wait their
+// turn in the queue and survive a loop or two
+const ttsFileTTL = time.Hour
+
+// ttsDir holds synthesized clips until cleanTTSFiles removes them
+var ttsDir = filepath.Join(os.TempDir(), "himiko-tts")
+
+// openAIVoices are the voices the OpenAI speech API offers
+var openAIVoices = []string{"alloy", "ash", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer"}
+
+// espeakVoicePattern matches espeak-ng voice names such as "en", "en-us" or "en-gb+f3"
+var espeakVoicePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]+)*(\+[a-z0-9]+)?$`)
+
+// ttsDefaultVoices is each engine's voice when the guild hasn't picked one
+var ttsDefaultVoices = map[string]string{
+	database.TTSEngineEspeak: "en-us",
+	database.TTSEngineOpenAI: "alloy",
+}
+
+// ttsVoice returns the voice a guild's clips are read in
+func ttsVoice(settings *database.TTSSettings) string {
+	if settings.Voice != "" {
+		return settings.Voice
+	}
+	return ttsDefaultVoices[settings.Engine]
+}
+
+// validTTSVoice reports whether an engine has the named voice
+func validTTSVoice(engine, voice string) bool {
+	if engine == database.TTSEngineOpenAI {
+		return slices.Contains(openAIVoices, voice)
+	}
+	return espeakVoicePattern.MatchString(voice)
+}
+
+// synthesizeSpeech reads text out with the guild's engine and voice, returning
+// the path of the audio file it wrote
+func (b *Bot) synthesizeSpeech(ctx context.Context, guildID string, settings *database.TTSSettings, text string) (string, error) {
+	if err := os.MkdirAll(ttsDir, 0755); err != nil {
+		return "", err
+	}
+
+	if settings.Engine == database.TTSEngineOpenAI {
+		return b.synthesizeOpenAI(ctx, guildID, ttsVoice(settings), text)
+	}
+	return synthesizeEspeak(ctx, guildID, ttsVoice(settings), text)
+}
+
+// synthesizeEspeak runs espeak-ng locally. The text goes in on stdin so it can
+// never be read as a command line flag.
+func synthesizeEspeak(ctx context.Context, guildID, voice, text string) (string, error) {
+	path := filepath.Join(ttsDir, fmt.Sprintf("%s-%d.wav", guildID, time.Now().UnixNano()))
+
+	cmd := exec.CommandContext(ctx, "espeak-ng", "-v", voice, "-w", path, "--stdin")
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(path)
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return "", fmt.Errorf("espeak-ng: %s", msg)
+		}
+		return "", fmt.Errorf("espeak-ng: %w", err)
+	}
+	return path, nil
+}
+
+// synthesizeOpenAI asks the OpenAI speech API for an MP3 of the text
+func (b *Bot) synthesizeOpenAI(ctx context.Context, guildID, voice, text string) (string, error) {
+	apiKey := b.openAIKey(guildID)
+	if apiKey == "" {
+		return "", errAINotConfigured
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"model":           "tts-1",
+		"voice":           voice,
+		"input":           text,
+		"response_format": "mp3",
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(b.Config().APIs.OpenAIBaseURL, "/")+"/audio/speech", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := aiClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to contact speech service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&apiErr)
+		if apiErr.Error.Message != "" {
+			return "", fmt.Errorf("speech service: %s", apiErr.Error.Message)
+		}
+		return "", fmt.Errorf("speech service returned HTTP %d", resp.StatusCode)
+	}
+
+	path := filepath.Join(ttsDir, fmt.Sprintf("%s-%d.mp3", guildID, time.Now().UnixNano()))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, io.LimitReader(resp.Body, 10<<20))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// cleanTTSFiles removes clips older than ttsFileTTL
+func cleanTTSFiles() {
+	entries, err := os.ReadDir(ttsDir)
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-ttsFileTTL)
+	removed := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if os.Remove(filepath.Join(ttsDir, entry.Name())) == nil {
+			removed++
+		}
+	}
+	if removed > 0 {
+		log.Printf("[TTS] Removed %d old clips", removed)
+	}
+}
@@ -18,6 +18,7 @@ package bot
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -103,6 +104,51 @@ func editResponseEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, emb
 	})
 }
 
+// progressMessage is a channel message that a long-running command edits as it goes.
+// Interaction tokens expire after 15 minutes, so work that can outlast one reports here instead.
+type progressMessage struct {
+	s         *discordgo.Session
+	channelID string
+	messageID string
+}
+
+// postProgress sends a progress message to a channel
+func postProgress(s *discordgo.Session, channelID, content string, components []discordgo.MessageComponent) (*progressMessage, error) {
+	msg, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    content,
+		Components: components,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &progressMessage{s: s, channelID: channelID, messageID: msg.ID}, nil
+}
+
+// update replaces the progress text and buttons; nil components removes the buttons
+func (p *progressMessage) update(content string, components []discordgo.MessageComponent) {
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	p.s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    p.channelID,
+		ID:         p.messageID,
+		Content:    &content,
+		Components: &components,
+	})
+}
+
+// finish replaces the progress text and buttons with a result embed
+func (p *progressMessage) finish(embed *discordgo.MessageEmbed) {
+	content := ""
+	p.s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    p.channelID,
+		ID:         p.messageID,
+		Content:    &content,
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &[]discordgo.MessageComponent{},
+	})
+}
+
 func respondAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, choices []*discordgo.ApplicationCommandOptionChoice) {
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
@@ -133,6 +179,16 @@ func getIntOption(i *discordgo.InteractionCreate, name string) int64 {
 	return 0
 }
 
+func getNumberOption(i *discordgo.InteractionCreate, name string) float64 {
+	options := getOptions(i)
+	for _, opt := range options {
+		if opt.Name == name {
+			return opt.FloatValue()
+		}
+	}
+	return 0
+}
+
 func getBoolOption(i *discordgo.InteractionCreate, name string) bool {
 	options := getOptions(i)
 	for _, opt := range options {
@@ -199,6 +255,31 @@ func getSubcommandName(i *discordgo.InteractionCreate) string {
 	return ""
 }
 
+// getSubcommandGroup returns the subcommand group and the subcommand chosen inside it
+func getSubcommandGroup(i *discordgo.InteractionCreate) (string, string) {
+	options := i.ApplicationCommandData().Options
+	if len(options) > 0 && options[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup && len(options[0].Options) > 0 {
+		return options[0].Name, options[0].Options[0].Name
+	}
+	return "", ""
+}
+
+// getModalValue returns the value of a modal text input by its custom ID
+func getModalValue(i *discordgo.InteractionCreate, customID string) string {
+	for _, component := range i.ModalSubmitData().Components {
+		row, ok := component.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c := range row.Components {
+			if input, ok := c.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
+
 // Permission helpers
 func hasPermission(s *discordgo.Session, guildID, userID string, permission int64) bool {
 	member, err := s.GuildMember(guildID, userID)
@@ -233,6 +314,75 @@ func hasPermission(s *discordgo.Session, guildID, userID string, permission int6
 	return false
 }
 
+// checkModerationTarget explains why moderatorID may not use action (e.g.
+// "kick") on targetID, or returns "" if they may. Members can't target
+// themselves, the bot or the server owner, and both the moderator's and the
+// bot's highest role must be above the target's. Targets that aren't in the
+// server only get the identity checks.
+func checkModerationTarget(s *discordgo.Session, guildID, moderatorID, targetID, action string) string {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		guild, err = s.Guild(guildID)
+		if err != nil {
+			return "Couldn't load this server to check roles."
+		}
+	}
+
+	switch targetID {
+	case moderatorID:
+		return fmt.Sprintf("You can't %s yourself.", action)
+	case s.State.User.ID:
+		return fmt.Sprintf("I can't %s myself.", action)
+	case guild.OwnerID:
+		return fmt.Sprintf("You can't %s the server owner.", action)
+	}
+
+	target, err := guildMember(s, guildID, targetID)
+	if err != nil {
+		// Not in the server, so there are no roles to compare
+		return ""
+	}
+	targetTop := topRolePosition(guild, target)
+
+	if moderatorID != guild.OwnerID {
+		moderator, err := guildMember(s, guildID, moderatorID)
+		if err != nil {
+			return "Couldn't look up your roles."
+		}
+		if topRolePosition(guild, moderator) <= targetTop {
+			return fmt.Sprintf("You can only %s members whose highest role is below yours.", action)
+		}
+	}
+
+	self, err := guildMember(s, guildID, s.State.User.ID)
+	if err != nil {
+		return "Couldn't look up my roles."
+	}
+	if topRolePosition(guild, self) <= targetTop {
+		return fmt.Sprintf("I can't %s that member because their highest role is at or above mine. Move my role higher in Server Settings → Roles.", action)
+	}
+	return ""
+}
+
+// guildMember returns a member from the state cache, falling back to the API
+func guildMember(s *discordgo.Session, guildID, userID string) (*discordgo.Member, error) {
+	if member, err := s.State.Member(guildID, userID); err == nil {
+		return member, nil
+	}
+	return s.GuildMember(guildID, userID)
+}
+
+// topRolePosition returns the position of a member's highest role, 0 being @everyone
+func topRolePosition(guild *discordgo.Guild, member *discordgo.Member) int {
+	top := 0
+	for _, role := range guild.Roles {
+		if role.Position > top && slices.Contains(member.Roles, role.ID) {
+			top = role.Position
+		}
+	}
+	return top
+}
+
 func isAdmin(s *discordgo.Session, guildID, userID string) bool {
 	return hasPermission(s, guildID, userID, discordgo.PermissionAdministrator)
 }
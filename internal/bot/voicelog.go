@@ -0,0 +1,236 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// voiceSession is a member's current stay in voice, across channel moves
+type voiceSession struct {
+	JoinedAt time.Time
+	Channels int // Channels visited during the session
+}
+
+// VoiceSessionTracker remembers when members joined voice so leaves can report a duration
+type VoiceSessionTracker struct {
+	mu       sync.Mutex
+	sessions map[string]*voiceSession // guildID:userID -> session
+}
+
+// NewVoiceSessionTracker creates a new voice session tracker
+func NewVoiceSessionTracker() *VoiceSessionTracker {
+	return &VoiceSessionTracker{
+		sessions: make(map[string]*voiceSession),
+	}
+}
+
+// Global voice session tracker
+var voiceSessions = NewVoiceSessionTracker()
+
+func (t *VoiceSessionTracker) start(guildID, userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessions[guildID+":"+userID] = &voiceSession{JoinedAt: time.Now(), Channels: 1}
+}
+
+func (t *VoiceSessionTracker) move(guildID, userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if session, ok := t.sessions[guildID+":"+userID]; ok {
+		session.Channels++
+	}
+}
+
+// end removes and returns the session, or nil if the join was not seen (e.g. before a restart)
+func (t *VoiceSessionTracker) end(guildID, userID string) *voiceSession {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := guildID + ":" + userID
+	session := t.sessions[key]
+	delete(t.sessions, key)
+	return session
+}
+
+// onVoiceStateUpdate logs voice joins, leaves, moves, server mute/deafen and stream/video changes
+func (b *Bot) onVoiceStateUpdate(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	if v.GuildID == "" || v.UserID == "" {
+		return
+	}
+
+	if s.State.User != nil && v.UserID == s.State.User.ID {
+		b.onBotVoiceStateUpdate(v)
+	}
+
+	before := v.BeforeUpdate
+	beforeChannel := ""
+	if before != nil {
+		beforeChannel = before.ChannelID
+	}
+
+	// Track sessions regardless of config so durations are right once logging is enabled
+	switch {
+	case beforeChannel == "" && v.ChannelID != "":
+		voiceSessions.start(v.GuildID, v.UserID)
+	case beforeChannel != "" && v.ChannelID != "" && beforeChannel != v.ChannelID:
+		voiceSessions.move(v.GuildID, v.UserID)
+	}
+	var session *voiceSession
+	if beforeChannel != "" && v.ChannelID == "" {
+		session = voiceSessions.end(v.GuildID, v.UserID)
+		if session != nil {
+			b.trackAchievementStat(v.GuildID, v.UserID, statVoiceMinutes, int64(time.Since(session.JoinedAt).Minutes()), "")
+		}
+	}
+
+	cfg := b.getLogConfig(v.GuildID, "")
+	if cfg == nil {
+		return
+	}
+
+	user := voiceStateUser(s, v.VoiceState)
+	if user != nil && user.Bot {
+		return
+	}
+	member := fmt.Sprintf("<@%s> (`%s`)", v.UserID, v.UserID)
+
+	switch {
+	case beforeChannel == "" && v.ChannelID != "":
+		if cfg.VoiceJoin {
+			b.sendVoiceLog(s, cfg, user, &discordgo.MessageEmbed{
+				Title:       "Joined Voice",
+				Description: fmt.Sprintf("%s joined <#%s>", member, v.ChannelID),
+				Color:       0x00FF00,
+			})
+		}
+		return
+
+	case beforeChannel != "" && v.ChannelID == "":
+		if !cfg.VoiceLeave && !cfg.VoiceSession {
+			return
+		}
+		embed := &discordgo.MessageEmbed{
+			Title:       "Left Voice",
+			Description: fmt.Sprintf("%s left <#%s>", member, beforeChannel),
+			Color:       0xFF0000,
+		}
+		if cfg.VoiceSession && session != nil {
+			embed.Fields = append(embed.Fields,
+				&discordgo.MessageEmbedField{Name: "Session Duration", Value: formatVoiceDuration(time.Since(session.JoinedAt)), Inline: true},
+				&discordgo.MessageEmbedField{Name: "Channels", Value: fmt.Sprintf("%d", session.Channels), Inline: true},
+				&discordgo.MessageEmbedField{Name: "Joined", Value: fmt.Sprintf("<t:%s:t>", formatUnixTime(session.JoinedAt)), Inline: true},
+			)
+		} else if !cfg.VoiceLeave {
+			return // Only session summaries are enabled and the join was not seen
+		}
+		b.sendVoiceLog(s, cfg, user, embed)
+		return
+
+	case beforeChannel != "" && beforeChannel != v.ChannelID:
+		if cfg.VoiceMove {
+			b.sendVoiceLog(s, cfg, user, &discordgo.MessageEmbed{
+				Title:       "Moved Voice Channel",
+				Description: member,
+				Color:       0x5865F2,
+				Fields: []*discordgo.MessageEmbedField{
+					{Name: "From", Value: fmt.Sprintf("<#%s>", beforeChannel), Inline: true},
+					{Name: "To", Value: fmt.Sprintf("<#%s>", v.ChannelID), Inline: true},
+				},
+			})
+		}
+		return
+	}
+
+	// Same channel: state flags changed
+	if before == nil || v.ChannelID == "" {
+		return
+	}
+
+	if cfg.VoiceMute && (before.Mute != v.Mute || before.Deaf != v.Deaf) {
+		var changes []string
+		if before.Mute != v.Mute {
+			changes = append(changes, toggledLabel("Server muted", "Server unmuted", v.Mute))
+		}
+		if before.Deaf != v.Deaf {
+			changes = append(changes, toggledLabel("Server deafened", "Server undeafened", v.Deaf))
+		}
+		entry := findAuditEntry(s, v.GuildID, v.UserID, discordgo.AuditLogActionMemberUpdate, auditLogMaxAge)
+
+		b.sendVoiceLog(s, cfg, user, &discordgo.MessageEmbed{
+			Title:       "Voice State Changed",
+			Description: fmt.Sprintf("%s in <#%s>\n%s", member, v.ChannelID, strings.Join(changes, "\n")),
+			Color:       0xFFA500,
+			Fields:      []*discordgo.MessageEmbedField{auditActorField(entry)},
+		})
+	}
+
+	if cfg.VoiceStream && (before.SelfStream != v.SelfStream || before.SelfVideo != v.SelfVideo) {
+		var changes []string
+		if before.SelfStream != v.SelfStream {
+			changes = append(changes, toggledLabel("Started streaming", "Stopped streaming", v.SelfStream))
+		}
+		if before.SelfVideo != v.SelfVideo {
+			changes = append(changes, toggledLabel("Turned camera on", "Turned camera off", v.SelfVideo))
+		}
+
+		b.sendVoiceLog(s, cfg, user, &discordgo.MessageEmbed{
+			Title:       "Voice Activity",
+			Description: fmt.Sprintf("%s in <#%s>\n%s", member, v.ChannelID, strings.Join(changes, "\n")),
+			Color:       0x9B59B6,
+		})
+	}
+}
+
+// sendVoiceLog adds the member's avatar and sends the embed to the voice log route
+func (b *Bot) sendVoiceLog(s *discordgo.Session, cfg *database.LoggingConfig, user *discordgo.User, embed *discordgo.MessageEmbed) {
+	if user != nil {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: avatarURL(user)}
+	}
+	b.sendLog(s, cfg, logCategoryVoice, embed)
+}
+
+// voiceStateUser resolves the user behind a voice state from the payload or the state cache
+func voiceStateUser(s *discordgo.Session, vs *discordgo.VoiceState) *discordgo.User {
+	if vs.Member != nil && vs.Member.User != nil {
+		return vs.Member.User
+	}
+	if member, err := s.State.Member(vs.GuildID, vs.UserID); err == nil {
+		return member.User
+	}
+	return nil
+}
+
+func toggledLabel(on, off string, enabled bool) string {
+	if enabled {
+		return on
+	}
+	return off
+}
+
+// formatVoiceDuration formats a session length, keeping seconds for short sessions
+func formatVoiceDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return formatDuration(d)
+}
@@ -0,0 +1,91 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"bytes"
+	"image"
+	"sync"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/blubskye/himiko/internal/rankcard"
+	"github.com/bwmarrin/discordgo"
+)
+
+// welcomeBackgrounds caches decoded welcome card backgrounds by URL, so a wave of
+// joins doesn't download the same picture for every member
+var welcomeBackgrounds = struct {
+	sync.Mutex
+	images map[string]image.Image
+}{images: make(map[string]image.Image)}
+
+// welcomeBackground fetches a welcome card background, from the cache if possible
+func welcomeBackground(url string) (image.Image, error) {
+	welcomeBackgrounds.Lock()
+	img, ok := welcomeBackgrounds.images[url]
+	welcomeBackgrounds.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	img, err := fetchImage(url)
+	if err != nil {
+		return nil, err
+	}
+
+	welcomeBackgrounds.Lock()
+	// Backgrounds are only swapped now and then, so starting over beats tracking usage
+	if len(welcomeBackgrounds.images) >= 50 {
+		welcomeBackgrounds.images = make(map[string]image.Image)
+	}
+	welcomeBackgrounds.images[url] = img
+	welcomeBackgrounds.Unlock()
+	return img, nil
+}
+
+// renderWelcomeCard draws a member's welcome card in the guild's style
+func (b *Bot) renderWelcomeCard(settings *database.WelcomeCardSettings, user *discordgo.User, memberNumber int) (*bytes.Buffer, error) {
+	card := &rankcard.WelcomeCard{
+		Title:        settings.Title,
+		Username:     user.Username,
+		MemberNumber: memberNumber,
+		Background:   rankcard.DefaultBackground,
+		Accent:       rankcard.DefaultAccent,
+	}
+	if settings.Background != nil {
+		card.Background = rankcard.FromInt(*settings.Background)
+	}
+	if settings.Accent != nil {
+		card.Accent = rankcard.FromInt(*settings.Accent)
+	}
+
+	// Missing images fall back to the plain color and placeholder avatar
+	if settings.BackgroundURL != "" {
+		if bg, err := welcomeBackground(settings.BackgroundURL); err == nil {
+			card.BackgroundImage = bg
+		}
+	}
+	if avatar, err := fetchImage(user.AvatarURL("256")); err == nil {
+		card.Avatar = avatar
+	}
+
+	var buf bytes.Buffer
+	if err := rankcard.RenderWelcome(&buf, card); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
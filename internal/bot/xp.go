@@ -0,0 +1,431 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Message XP: a random amount per message, at most once per cooldown
+const (
+	messageXPMin      = 15
+	messageXPMax      = 25
+	messageXPCooldown = time.Minute
+)
+
+const defaultLevelUpMessage = "🎉 {user} reached **level {level}**!"
+
+// XPTracker rate limits message XP and times voice XP intervals
+type XPTracker struct {
+	mu          sync.Mutex
+	lastMessage map[string]time.Time // guildID:userID -> last message that earned XP
+	voiceSince  map[string]time.Time // guildID:userID -> start of the current voice XP interval
+}
+
+// NewXPTracker creates a new XP tracker
+func NewXPTracker() *XPTracker {
+	return &XPTracker{
+		lastMessage: make(map[string]time.Time),
+		voiceSince:  make(map[string]time.Time),
+	}
+}
+
+// Global XP tracker
+var xpTracker = NewXPTracker()
+
+// allowMessage reports whether a message may earn XP and starts the cooldown if so
+func (t *XPTracker) allowMessage(guildID, userID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := guildID + ":" + userID
+	if time.Since(t.lastMessage[key]) < messageXPCooldown {
+		return false
+	}
+	t.lastMessage[key] = time.Now()
+	return true
+}
+
+// voiceIntervalElapsed reports whether a member has completed a voice XP interval, starting a new one if so
+func (t *XPTracker) voiceIntervalElapsed(key string, interval time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	since, ok := t.voiceSince[key]
+	if !ok {
+		t.voiceSince[key] = time.Now()
+		return false
+	}
+	if time.Since(since) < interval {
+		return false
+	}
+	t.voiceSince[key] = since.Add(interval)
+	return true
+}
+
+// pruneVoice forgets members no longer in voice so rejoining starts a fresh interval
+func (t *XPTracker) pruneVoice(present map[string]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.voiceSince {
+		if !present[key] {
+			delete(t.voiceSince, key)
+		}
+	}
+}
+
+// xpMultiplier combines the member's highest role multiplier, the channel multiplier
+// (falling back to the parent channel for threads) and the strongest running boost
+func (b *Bot) xpMultiplier(guildID, channelID string, roles []string) float64 {
+	multiplier, err := b.DB.GetActiveXPBoost(guildID)
+	if err != nil {
+		multiplier = 1
+	}
+
+	multipliers, err := b.DB.GetXPMultipliers(guildID)
+	if err != nil || len(multipliers) == 0 {
+		return multiplier
+	}
+
+	channels := make(map[string]float64)
+	roleBest, hasRole := 0.0, false
+	for _, m := range multipliers {
+		switch m.TargetType {
+		case "channel":
+			channels[m.TargetID] = m.Multiplier
+		case "role":
+			for _, r := range roles {
+				if r == m.TargetID && (!hasRole || m.Multiplier > roleBest) {
+					roleBest, hasRole = m.Multiplier, true
+				}
+			}
+		}
+	}
+	if hasRole {
+		multiplier *= roleBest
+	}
+
+	if m, ok := channels[channelID]; ok {
+		multiplier *= m
+	} else if ch, err := b.Session.State.Channel(channelID); err == nil && ch.ParentID != "" {
+		if m, ok := channels[ch.ParentID]; ok {
+			multiplier *= m
+		}
+	}
+	return multiplier
+}
+
+// xpExcluded reports whether any of the roles, or the channel (including its parent
+// channel and category), is on the guild's no-XP list
+func (b *Bot) xpExcluded(guildID, channelID string, roles []string) bool {
+	exclusions, err := b.DB.GetXPExclusions(guildID)
+	if err != nil || len(exclusions) == 0 {
+		return false
+	}
+
+	excluded := make(map[string]bool, len(exclusions))
+	for _, e := range exclusions {
+		excluded[e.TargetType+":"+e.TargetID] = true
+	}
+	for _, r := range roles {
+		if excluded["role:"+r] {
+			return true
+		}
+	}
+
+	// Walk up from a thread to its channel and category
+	for depth := 0; channelID != "" && depth < 3; depth++ {
+		if excluded["channel:"+channelID] {
+			return true
+		}
+		ch, err := b.Session.State.Channel(channelID)
+		if err != nil {
+			break
+		}
+		channelID = ch.ParentID
+	}
+	return false
+}
+
+// awardMessageXP gives XP for chatting, at most once per cooldown
+func (b *Bot) awardMessageXP(m *discordgo.MessageCreate) {
+	if m.GuildID == "" || m.Member == nil {
+		return
+	}
+	if b.xpExcluded(m.GuildID, m.ChannelID, m.Member.Roles) {
+		return
+	}
+	if !xpTracker.allowMessage(m.GuildID, m.Author.ID) {
+		return
+	}
+
+	base := messageXPMin + rand.Intn(messageXPMax-messageXPMin+1)
+	amount := int64(math.Round(float64(base) * b.xpMultiplier(m.GuildID, m.ChannelID, m.Member.Roles)))
+	b.grantXP(m.GuildID, m.Author.ID, m.ChannelID, amount)
+}
+
+// awardVoiceXP gives XP to members in voice channels of guilds with voice XP enabled,
+// skipping members that the guild's anti-abuse rules exclude
+func (b *Bot) awardVoiceXP() {
+	// Snapshot voice states so the state lock isn't held during database work
+	type guildVoice struct {
+		id, afkChannelID string
+		states           []discordgo.VoiceState
+	}
+	var guilds []guildVoice
+	b.Session.State.RLock()
+	for _, guild := range b.Session.State.Guilds {
+		gv := guildVoice{id: guild.ID, afkChannelID: guild.AfkChannelID}
+		for _, vs := range guild.VoiceStates {
+			gv.states = append(gv.states, *vs)
+		}
+		guilds = append(guilds, gv)
+	}
+	b.Session.State.RUnlock()
+
+	present := make(map[string]bool)
+	for _, guild := range guilds {
+		if len(guild.states) == 0 {
+			continue
+		}
+		cfg, err := b.DB.GetVoiceXPConfig(guild.id)
+		if err != nil || !cfg.Enabled || cfg.XPRate <= 0 {
+			continue
+		}
+		interval := time.Duration(max(cfg.IntervalMins, 1)) * time.Minute
+
+		excluded := make(map[string]bool, len(cfg.ExcludedChannels))
+		for _, id := range cfg.ExcludedChannels {
+			excluded[id] = true
+		}
+
+		// Resolve members once and count the humans in each channel
+		members := make(map[string]*discordgo.Member, len(guild.states))
+		humans := make(map[string]int)
+		for _, vs := range guild.states {
+			member, err := b.Session.State.Member(guild.id, vs.UserID)
+			if err != nil || member.User == nil || member.User.Bot {
+				continue
+			}
+			members[vs.UserID] = member
+			humans[vs.ChannelID]++
+		}
+
+		for _, vs := range guild.states {
+			member := members[vs.UserID]
+			if member == nil || vs.ChannelID == "" || excluded[vs.ChannelID] {
+				continue
+			}
+			if cfg.IgnoreAFK && vs.ChannelID == guild.afkChannelID {
+				continue
+			}
+			if cfg.IgnoreAlone && humans[vs.ChannelID] < 2 {
+				continue
+			}
+			if cfg.IgnoreDeafened && (vs.SelfDeaf || vs.Deaf) {
+				continue
+			}
+			if b.xpExcluded(guild.id, "", member.Roles) {
+				continue
+			}
+
+			key := guild.id + ":" + vs.UserID
+			present[key] = true
+			if !xpTracker.voiceIntervalElapsed(key, interval) {
+				continue
+			}
+
+			amount := int64(math.Round(float64(cfg.XPRate) * b.xpMultiplier(guild.id, vs.ChannelID, member.Roles)))
+			if cfg.DailyCap > 0 {
+				today, err := b.DB.GetVoiceXPToday(guild.id, vs.UserID)
+				if err != nil || today >= int64(cfg.DailyCap) {
+					continue
+				}
+				amount = min(amount, int64(cfg.DailyCap)-today)
+			}
+			if amount <= 0 {
+				continue
+			}
+			b.DB.RecordVoiceXP(guild.id, vs.UserID, amount)
+			b.grantXP(guild.id, vs.UserID, "", amount)
+		}
+	}
+
+	xpTracker.pruneVoice(present)
+}
+
+// grantXP adds XP and handles level ups. channelID is where level ups are announced, or empty for none.
+func (b *Bot) grantXP(guildID, userID, channelID string, amount int64) {
+	if amount <= 0 {
+		return
+	}
+	ux, err := b.DB.AddUserXP(guildID, userID, amount)
+	if err != nil {
+		return
+	}
+	if previous := database.CalculateLevel(ux.XP - amount); ux.Level > previous {
+		b.onLevelUp(guildID, userID, channelID, ux.Level)
+	}
+}
+
+// onLevelUp grants level rank roles and announces the new level
+func (b *Bot) onLevelUp(guildID, userID, channelID string, level int) {
+	b.raiseAchievementStat(guildID, userID, statLevel, int64(level), channelID)
+
+	rewarded := false
+	if ranks, err := b.DB.GetRanksForLevel(guildID, level); err == nil {
+		for _, rank := range ranks {
+			rewarded = rewarded || rank.Level == level
+		}
+	}
+	b.applyLevelRanks(guildID, userID, level)
+
+	b.announceLevelUp(guildID, userID, channelID, level, rewarded)
+}
+
+// applyLevelRanks brings a member's rank roles in line with their level and the guild's rank mode
+func (b *Bot) applyLevelRanks(guildID, userID string, level int) (added, removed int, err error) {
+	ranks, err := b.DB.GetLevelRanks(guildID)
+	if err != nil || len(ranks) == 0 {
+		return 0, 0, err
+	}
+	cfg, err := b.DB.GetLevelRankConfig(guildID)
+	if err != nil {
+		return 0, 0, err
+	}
+	member, err := b.Session.State.Member(guildID, userID)
+	if err != nil {
+		if member, err = b.Session.GuildMember(guildID, userID); err != nil {
+			return 0, 0, err
+		}
+	}
+	added, removed = b.syncMemberRanks(guildID, member, level, ranks, cfg)
+	return added, removed, nil
+}
+
+// syncMemberRanks adds and removes rank roles for a member, returning how many changed
+func (b *Bot) syncMemberRanks(guildID string, member *discordgo.Member, level int, ranks []database.LevelRank, cfg *database.LevelRankConfig) (added, removed int) {
+	toAdd, toRemove := rankRoleChanges(ranks, cfg, level, member.Roles)
+	for _, roleID := range toAdd {
+		if b.Session.GuildMemberRoleAdd(guildID, member.User.ID, roleID) == nil {
+			added++
+		}
+	}
+	for _, roleID := range toRemove {
+		if b.Session.GuildMemberRoleRemove(guildID, member.User.ID, roleID) == nil {
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// rankRoleChanges works out which rank roles a member at level should gain and lose.
+// When stacking, every earned rank is kept; otherwise only ranks at the highest earned level.
+// Unearned ranks are only taken away when the guild removes roles on level loss.
+func rankRoleChanges(ranks []database.LevelRank, cfg *database.LevelRankConfig, level int, current []string) (add, remove []string) {
+	highest := 0
+	for _, rank := range ranks {
+		if rank.Level <= level {
+			highest = max(highest, rank.Level)
+		}
+	}
+
+	has := make(map[string]bool, len(current))
+	for _, id := range current {
+		has[id] = true
+	}
+
+	for _, rank := range ranks {
+		earned := rank.Level <= level
+		want := earned && (cfg.Stack || rank.Level == highest)
+		switch {
+		case want && !has[rank.RoleID]:
+			add = append(add, rank.RoleID)
+		case !want && has[rank.RoleID] && (earned || cfg.RemoveOnLoss):
+			remove = append(remove, rank.RoleID)
+		}
+	}
+	return add, remove
+}
+
+// announceLevelUp sends the guild's level-up message. Levels that unlock a rank role are
+// always announced; others only every cfg.EveryLevels levels.
+func (b *Bot) announceLevelUp(guildID, userID, channelID string, level int, rewarded bool) {
+	cfg, err := b.DB.GetLevelUpConfig(guildID)
+	if err != nil || !cfg.Enabled {
+		return
+	}
+	if cfg.EveryLevels > 1 && level%cfg.EveryLevels != 0 && !rewarded {
+		return
+	}
+
+	target := channelID
+	switch cfg.Destination {
+	case "dm":
+		dm, err := b.Session.UserChannelCreate(userID)
+		if err != nil {
+			return
+		}
+		target = dm.ID
+	case "channel":
+		target = cfg.ChannelID
+	}
+	// Voice XP has no current channel
+	if target == "" {
+		return
+	}
+
+	message := cfg.Message
+	if message == "" {
+		message = defaultLevelUpMessage
+	}
+	username, server := userID, guildID
+	if member, err := b.Session.State.Member(guildID, userID); err == nil && member.User != nil {
+		username = member.User.Username
+	}
+	if guild, err := b.Session.State.Guild(guildID); err == nil {
+		server = guild.Name
+	}
+	message = strings.NewReplacer(
+		"{user}", "<@"+userID+">",
+		"{username}", username,
+		"{level}", strconv.Itoa(level),
+		"{server}", server,
+	).Replace(message)
+
+	send := &discordgo.MessageSend{
+		Content: message,
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Users: []string{userID},
+		},
+	}
+	if cfg.UseEmbed {
+		send.Content = ""
+		send.Embeds = []*discordgo.MessageEmbed{{
+			Title:       "Level Up!",
+			Description: message,
+			Color:       0x5865F2,
+		}}
+	}
+	b.Session.ChannelMessageSendComplex(target, send)
+}
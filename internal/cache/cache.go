@@ -0,0 +1,266 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package cache is an optional Redis layer shared by every bot process and the
+// dashboard: settings are cached in Redis with a short-lived local copy, writes
+// invalidate both through pub/sub, and cooldowns are counted in Redis so they
+// hold across shards. A nil *Cache is valid: it caches nothing, and Take reports
+// ErrDisabled so callers keep their own count.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrDisabled is returned by Take on a nil cache, so callers fall back to counting locally
+var ErrDisabled = errors.New("cache: redis is not configured")
+
+// How long a process trusts its local copy, in case an invalidation is missed while reconnecting
+const localTTL = 30 * time.Second
+
+type localEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// Cache wraps a Redis connection with a local read-through copy
+type Cache struct {
+	client *redisClient
+	prefix string
+
+	mu    sync.RWMutex
+	local map[string]localEntry
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// New connects to Redis and starts listening for invalidations from other processes
+func New(addr, password string, db int, prefix string) (*Cache, error) {
+	c := &Cache{
+		client: newRedisClient(addr, password, db),
+		prefix: prefix,
+		local:  make(map[string]localEntry),
+		stop:   make(chan struct{}),
+	}
+
+	if _, err := c.client.do("PING"); err != nil {
+		return nil, err
+	}
+
+	go c.listen()
+	return c, nil
+}
+
+// Close stops the invalidation listener and drops idle connections
+func (c *Cache) Close() {
+	if c == nil {
+		return
+	}
+	c.once.Do(func() {
+		close(c.stop)
+		c.client.close()
+	})
+}
+
+// GetJSON loads a cached value into v, reporting whether it was found
+func (c *Cache) GetJSON(key string, v interface{}) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.RLock()
+	entry, ok := c.local[key]
+	c.mu.RUnlock()
+	if ok && time.Now().Before(entry.expires) {
+		return json.Unmarshal(entry.data, v) == nil
+	}
+
+	reply, err := c.client.do("GET", c.prefix+key)
+	if err != nil {
+		log.Printf("[Cache] GET %s: %v", key, err)
+		return false
+	}
+	s, ok := reply.(string)
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		return false
+	}
+	c.storeLocal(key, []byte(s), localTTL)
+	return true
+}
+
+// SetJSON caches v for ttl
+func (c *Cache) SetJSON(key string, v interface{}, ttl time.Duration) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if _, err := c.client.do("SET", c.prefix+key, string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10)); err != nil {
+		log.Printf("[Cache] SET %s: %v", key, err)
+		return
+	}
+	c.storeLocal(key, data, min(ttl, localTTL))
+}
+
+// Invalidate drops a cached value here, in Redis and in every other process
+func (c *Cache) Invalidate(key string) {
+	if c == nil {
+		return
+	}
+
+	c.dropLocal(key)
+	if _, err := c.client.do("DEL", c.prefix+key); err != nil {
+		log.Printf("[Cache] DEL %s: %v", key, err)
+	}
+	if _, err := c.client.do("PUBLISH", c.invalidateChannel(), key); err != nil {
+		log.Printf("[Cache] PUBLISH %s: %v", key, err)
+	}
+}
+
+// Take starts a cooldown shared by every process and returns zero, or how long
+// is left if it's already running
+func (c *Cache) Take(key string, cooldown time.Duration) (time.Duration, error) {
+	if c == nil {
+		return 0, ErrDisabled
+	}
+
+	reply, err := c.client.do("SET", c.prefix+key, "1", "PX", strconv.FormatInt(cooldown.Milliseconds(), 10), "NX")
+	if err != nil {
+		return 0, err
+	}
+	if reply != nil {
+		return 0, nil
+	}
+
+	reply, err = c.client.do("PTTL", c.prefix+key)
+	if err != nil {
+		return 0, err
+	}
+	ms, _ := reply.(int64)
+	if ms <= 0 {
+		// Expired between the two commands
+		return 0, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (c *Cache) storeLocal(key string, data []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// Drop expired copies now and then so the map doesn't grow forever
+	if len(c.local) > 5000 {
+		for k, e := range c.local {
+			if !now.Before(e.expires) {
+				delete(c.local, k)
+			}
+		}
+	}
+	c.local[key] = localEntry{data: data, expires: now.Add(ttl)}
+}
+
+func (c *Cache) dropLocal(key string) {
+	c.mu.Lock()
+	delete(c.local, key)
+	c.mu.Unlock()
+}
+
+func (c *Cache) invalidateChannel() string {
+	return c.prefix + "invalidate"
+}
+
+// listen keeps a subscription open for invalidations, reconnecting with backoff
+func (c *Cache) listen() {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := c.subscribe()
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+
+		log.Printf("[Cache] Invalidation subscription lost: %v", err)
+
+		// Anything could have changed while we weren't listening
+		c.mu.Lock()
+		c.local = make(map[string]localEntry)
+		c.mu.Unlock()
+
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func (c *Cache) subscribe() error {
+	rc, err := c.client.dial()
+	if err != nil {
+		return err
+	}
+	defer rc.conn.Close()
+
+	// Unblock the read below on shutdown
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-c.stop:
+			rc.conn.Close()
+		case <-done:
+		}
+	}()
+
+	if _, err := rc.do("SUBSCRIBE", c.invalidateChannel()); err != nil {
+		return err
+	}
+	rc.conn.SetDeadline(time.Time{})
+
+	for {
+		reply, err := rc.read()
+		if err != nil {
+			return err
+		}
+		msg, ok := reply.([]interface{})
+		if !ok || len(msg) != 3 || msg[0] != "message" {
+			continue
+		}
+		if key, ok := msg[2].(string); ok {
+			c.dropLocal(key)
+		}
+	}
+}
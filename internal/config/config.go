@@ -28,12 +28,20 @@ import (
 
 const MaxConfigBackups = 3
 
+// PresenceStatus is one entry in the rotating bot status
+type PresenceStatus struct {
+	Type string `json:"type"` // playing, watching, listening or competing
+	Text string `json:"text"`
+}
+
 type Config struct {
 	Token        string   `json:"token"`
 	Prefix       string   `json:"prefix"`
 	DatabasePath string   `json:"database_path"`
 	OwnerID      string   `json:"owner_id"`       // Single owner (backwards compatible)
 	OwnerIDs     []string `json:"owner_ids"`      // Multiple owners
+	DevGuildID   string   `json:"dev_guild_id"`   // Register slash commands only in this guild, for development
+	HomeGuildID  string   `json:"home_guild_id"`  // Guild where the owner-only /owner command is registered
 
 	// API Keys for various services
 	APIs struct {
@@ -44,6 +52,12 @@ type Config struct {
 		OpenAIKey          string `json:"openai_api_key"`
 		OpenAIBaseURL      string `json:"openai_base_url"`
 		OpenAIModel        string `json:"openai_model"`
+		AIProvider         string `json:"ai_provider"`     // Default provider for /ask: "openai", "anthropic" or "ollama"
+		AnthropicKey       string `json:"anthropic_api_key"`
+		AnthropicBaseURL   string `json:"anthropic_base_url"`
+		AnthropicModel     string `json:"anthropic_model"`
+		OllamaURL          string `json:"ollama_url"`      // Local Ollama server, guilds can only pick Ollama when set
+		OllamaModel        string `json:"ollama_model"`
 		YouTubeAPIKey      string `json:"youtube_api_key"`
 		SoundCloudAuthToken string `json:"soundcloud_auth_token"`
 	} `json:"apis"`
@@ -52,6 +66,7 @@ type Config struct {
 	Features struct {
 		DMLogging           bool   `json:"dm_logging"`
 		CommandHistory      bool   `json:"command_history"`
+		CommandHistoryDays  int    `json:"command_history_days"` // Days to keep command history (default: 90)
 		DeleteTimer         int    `json:"delete_timer"` // seconds, 0 = disabled
 		WebhookNotify       bool   `json:"webhook_notify"`
 		WebhookURL          string `json:"webhook_url"`
@@ -59,9 +74,19 @@ type Config struct {
 		AutoUpdateApply     bool   `json:"auto_update_apply"`     // Automatically apply updates (requires restart)
 		UpdateCheckHours    int    `json:"update_check_hours"`    // Hours between periodic update checks (0 = disabled)
 		UpdateNotifyChannel string `json:"update_notify_channel"` // Channel ID to post update notifications
+		UpdateChannel       string `json:"update_channel"`        // Release channel: "stable" (default) or "beta"
+		UpdatePublicKey     string `json:"update_public_key"`     // ed25519 key (hex/base64) releases must be signed with
 		DebugMode           bool   `json:"debug_mode"`            // Enable verbose logging and stack traces
 	} `json:"features"`
 
+	// Music playback backend
+	Music struct {
+		Backend          string `json:"backend"`           // "ffmpeg" (default, in-process) or "lavalink"
+		LavalinkHost     string `json:"lavalink_host"`     // Lavalink node address (host:port)
+		LavalinkPassword string `json:"lavalink_password"` // Lavalink node password
+		LavalinkSecure   bool   `json:"lavalink_secure"`   // Connect to the node over https/wss
+	} `json:"music"`
+
 	// Web server configuration
 	WebServer struct {
 		Enabled     bool   `json:"enabled"`      // Enable/disable the web server
@@ -69,8 +94,32 @@ type Config struct {
 		Host        string `json:"host"`         // Host to bind to (default: "127.0.0.1" for local only)
 		SecretKey   string `json:"secret_key"`   // Secret key for session encryption
 		AllowRemote bool   `json:"allow_remote"` // Allow connections from non-localhost (for NGINX proxy)
+		WebhookHost string `json:"webhook_host"` // Host for the incoming webhook listener (default: "127.0.0.1")
+		WebhookPort int    `json:"webhook_port"` // Port for incoming webhooks such as the GitHub relay, kept apart from the dashboard (0 = off)
 	} `json:"webserver"`
 
+	// Local cache of message attachments so deleted images can be re-uploaded to logs
+	Archive struct {
+		Dir            string `json:"dir"`             // Directory for cached attachments (default: "attachments")
+		RetentionHours int    `json:"retention_hours"` // Hours to keep cached attachments (default: 24)
+		MaxFileMB      int    `json:"max_file_mb"`     // Skip attachments larger than this (default: 8)
+	} `json:"archive"`
+
+	// Rotating bot status
+	Presence struct {
+		Interval int              `json:"interval"` // Seconds between status changes (default: 60)
+		Statuses []PresenceStatus `json:"statuses"` // Shown in order; {guilds}, {users}, {version} and {prefix} are filled in
+	} `json:"presence"`
+
+	// Optional Redis for sharing settings and cooldowns between bot processes and the dashboard
+	Redis struct {
+		Enabled  bool   `json:"enabled"`
+		Addr     string `json:"addr"`     // host:port (default: localhost:6379)
+		Password string `json:"password"` // Leave empty if Redis has no AUTH
+		DB       int    `json:"db"`       // Database number
+		Prefix   string `json:"prefix"`   // Key prefix, so several bots can share one Redis (default: "himiko:")
+	} `json:"redis"`
+
 	// Field-level encryption for sensitive database data
 	Encryption struct {
 		Enabled bool   `json:"enabled"` // Enable/disable field encryption
@@ -90,7 +139,15 @@ func Load(path string) (*Config, error) {
 		}
 		cfg.APIs.OpenAIBaseURL = "https://api.openai.com/v1"
 		cfg.APIs.OpenAIModel = "gpt-3.5-turbo"
+		cfg.APIs.AIProvider = "openai"
+		cfg.APIs.AnthropicBaseURL = "https://api.anthropic.com"
+		cfg.APIs.AnthropicModel = "claude-3-5-haiku-latest"
+		cfg.APIs.OllamaModel = "llama3.2"
 		cfg.Features.CommandHistory = true
+		cfg.Features.CommandHistoryDays = 90
+		cfg.Archive.Dir = "attachments"
+		cfg.Archive.RetentionHours = 24
+		cfg.Archive.MaxFileMB = 8
 
 		data, err := json.MarshalIndent(cfg, "", "  ")
 		if err != nil {
@@ -125,6 +182,18 @@ func Load(path string) (*Config, error) {
 	if cfg.APIs.OpenAIModel == "" {
 		cfg.APIs.OpenAIModel = "gpt-3.5-turbo"
 	}
+	if cfg.APIs.AIProvider == "" {
+		cfg.APIs.AIProvider = "openai"
+	}
+	if cfg.APIs.AnthropicBaseURL == "" {
+		cfg.APIs.AnthropicBaseURL = "https://api.anthropic.com"
+	}
+	if cfg.APIs.AnthropicModel == "" {
+		cfg.APIs.AnthropicModel = "claude-3-5-haiku-latest"
+	}
+	if cfg.APIs.OllamaModel == "" {
+		cfg.APIs.OllamaModel = "llama3.2"
+	}
 	// Set webserver defaults
 	if cfg.WebServer.Port == 0 {
 		cfg.WebServer.Port = 8080
@@ -132,6 +201,34 @@ func Load(path string) (*Config, error) {
 	if cfg.WebServer.Host == "" {
 		cfg.WebServer.Host = "127.0.0.1"
 	}
+	if cfg.WebServer.WebhookHost == "" {
+		cfg.WebServer.WebhookHost = "127.0.0.1"
+	}
+	// Set attachment archive defaults
+	if cfg.Archive.Dir == "" {
+		cfg.Archive.Dir = "attachments"
+	}
+	if cfg.Archive.RetentionHours == 0 {
+		cfg.Archive.RetentionHours = 24
+	}
+	if cfg.Archive.MaxFileMB == 0 {
+		cfg.Archive.MaxFileMB = 8
+	}
+	if cfg.Features.CommandHistoryDays <= 0 {
+		cfg.Features.CommandHistoryDays = 90
+	}
+	if cfg.Presence.Interval <= 0 {
+		cfg.Presence.Interval = 60
+	}
+	if cfg.Redis.Addr == "" {
+		cfg.Redis.Addr = "localhost:6379"
+	}
+	if cfg.Redis.Prefix == "" {
+		cfg.Redis.Prefix = "himiko:"
+	}
+	if cfg.Features.UpdateChannel == "" {
+		cfg.Features.UpdateChannel = "stable"
+	}
 
 	// Check if migration is needed (new fields added)
 	migrated := migrateConfig(&cfg, data, path)
@@ -167,6 +264,11 @@ func migrateConfig(cfg *Config, originalData []byte, path string) bool {
 		}
 	}
 
+	// Check for archive section (attachment archival)
+	if _, exists := rawMap["archive"]; !exists {
+		needsMigration = true
+	}
+
 	if needsMigration {
 		// Backup old config
 		backupPath := fmt.Sprintf("%s.backup.%s", path, time.Now().Format("20060102-150405"))
@@ -229,6 +331,14 @@ func cleanupOldBackups(configPath string) {
 	}
 }
 
+// Clone returns a copy of the config that can be edited without touching the original
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.OwnerIDs = append([]string(nil), c.OwnerIDs...)
+	clone.Presence.Statuses = append([]PresenceStatus(nil), c.Presence.Statuses...)
+	return &clone
+}
+
 func (c *Config) Save(path string) error {
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
@@ -17,19 +17,27 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/blubskye/himiko/internal/cache"
 	"github.com/blubskye/himiko/internal/crypto"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 type DB struct {
 	*sql.DB
 	path      string
 	encryptor *crypto.FieldEncryptor
+	cache     *cache.Cache // Optional Redis layer for hot settings, nil when disabled
 }
 
 // New creates a new database connection without encryption.
@@ -68,6 +76,23 @@ func (d *DB) GetPath() string {
 	return d.path
 }
 
+// SetCache shares hot settings with other processes through Redis
+func (d *DB) SetCache(c *cache.Cache) {
+	d.cache = c
+}
+
+// How long settings stay in Redis; writes invalidate them straight away
+const settingsCacheTTL = 10 * time.Minute
+
+// Backup writes a consistent snapshot of the database to dest, replacing any existing file
+func (d *DB) Backup(dest string) error {
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_, err := d.Exec(`VACUUM INTO ?`, dest)
+	return err
+}
+
 // IsEncryptionEnabled returns whether field-level encryption is enabled
 func (d *DB) IsEncryptionEnabled() bool {
 	return d.encryptor.IsEnabled()
@@ -140,6 +165,12 @@ func (d *DB) migrate() error {
 		executed_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Users who asked not to have their commands recorded
+	CREATE TABLE IF NOT EXISTS command_history_optout (
+		user_id TEXT PRIMARY KEY,
+		opted_out_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
 	-- Warnings
 	CREATE TABLE IF NOT EXISTS warnings (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -150,6 +181,19 @@ func (d *DB) migrate() error {
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Audit trail of warning edits and deletions
+	CREATE TABLE IF NOT EXISTS warning_edits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		warning_id INTEGER NOT NULL,
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		editor_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		old_reason TEXT,
+		new_reason TEXT,
+		edited_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
 	-- Deleted messages log (for snipe command)
 	CREATE TABLE IF NOT EXISTS deleted_messages (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -157,9 +201,24 @@ func (d *DB) migrate() error {
 		channel_id TEXT NOT NULL,
 		user_id TEXT NOT NULL,
 		content TEXT NOT NULL,
+		attachments TEXT,
+		stickers TEXT,
+		embeds TEXT,
 		deleted_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Edited messages (for editsnipe and edit logs)
+	CREATE TABLE IF NOT EXISTS edited_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT,
+		channel_id TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		before_content TEXT NOT NULL,
+		after_content TEXT NOT NULL,
+		edited_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
 	-- User profiles/notes
 	CREATE TABLE IF NOT EXISTS user_notes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -168,7 +227,8 @@ func (d *DB) migrate() error {
 		note TEXT NOT NULL,
 		created_by TEXT NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		UNIQUE(guild_id, user_id)
+		updated_by TEXT DEFAULT '',
+		updated_at DATETIME
 	);
 
 	-- Scheduled messages
@@ -190,6 +250,19 @@ func (d *DB) migrate() error {
 		set_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Mentions of AFK members, summarised for them when they return
+	CREATE TABLE IF NOT EXISTS afk_mentions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		author_id TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_afk_mentions_user ON afk_mentions(user_id);
+
 	-- Reminders
 	CREATE TABLE IF NOT EXISTS reminders (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -213,6 +286,18 @@ func (d *DB) migrate() error {
 		UNIQUE(guild_id, name)
 	);
 
+	-- Alternate names for tags
+	CREATE TABLE IF NOT EXISTS tag_aliases (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		alias TEXT NOT NULL,
+		tag_id INTEGER NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(guild_id, alias)
+	);
+	CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag ON tag_aliases(tag_id);
+
 	-- Keyword notifications
 	CREATE TABLE IF NOT EXISTS keyword_notifications (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -223,11 +308,38 @@ func (d *DB) migrate() error {
 		UNIQUE(user_id, keyword)
 	);
 
+	-- How each member wants keyword alerts delivered
+	CREATE TABLE IF NOT EXISTS keyword_settings (
+		user_id TEXT PRIMARY KEY,
+		quiet_start INTEGER DEFAULT -1,
+		quiet_end INTEGER DEFAULT -1,
+		digest_minutes INTEGER DEFAULT 0,
+		ignored_channels TEXT DEFAULT ''
+	);
+
+	-- Keyword alerts held back for a digest or until quiet hours end
+	CREATE TABLE IF NOT EXISTS keyword_digest (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		author_id TEXT NOT NULL,
+		keyword TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_keyword_digest_user ON keyword_digest(user_id);
+
 	CREATE INDEX IF NOT EXISTS idx_custom_commands_guild ON custom_commands(guild_id);
 	CREATE INDEX IF NOT EXISTS idx_warnings_guild_user ON warnings(guild_id, user_id);
+	CREATE INDEX IF NOT EXISTS idx_warning_edits_guild_user ON warning_edits(guild_id, user_id);
+	CREATE INDEX IF NOT EXISTS idx_user_notes_guild_user ON user_notes(guild_id, user_id);
 	CREATE INDEX IF NOT EXISTS idx_deleted_messages_channel ON deleted_messages(channel_id);
+	CREATE INDEX IF NOT EXISTS idx_deleted_messages_guild ON deleted_messages(guild_id, deleted_at);
 	CREATE INDEX IF NOT EXISTS idx_scheduled_messages_time ON scheduled_messages(scheduled_for);
 	CREATE INDEX IF NOT EXISTS idx_reminders_time ON reminders(remind_at);
+	CREATE INDEX IF NOT EXISTS idx_command_history_executed ON command_history(executed_at);
 
 	-- XP/Leveling system
 	CREATE TABLE IF NOT EXISTS user_xp (
@@ -265,6 +377,43 @@ func (d *DB) migrate() error {
 		UNIQUE(guild_id, channel_id)
 	);
 
+	-- Voice channels renamed to show live server statistics
+	CREATE TABLE IF NOT EXISTS stats_channels (
+		channel_id TEXT PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		template TEXT DEFAULT '',
+		goal INTEGER DEFAULT 0,
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_stats_channels_guild ON stats_channels(guild_id);
+
+	-- Channels where new messages get a thread opened on them
+	CREATE TABLE IF NOT EXISTS autothread_channels (
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		mode TEXT DEFAULT 'all',
+		name_template TEXT DEFAULT '',
+		archive_minutes INTEGER DEFAULT 1440,
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (guild_id, channel_id)
+	);
+
+	-- GitHub repositories whose webhook deliveries are relayed into a channel
+	CREATE TABLE IF NOT EXISTS github_relays (
+		guild_id TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		events TEXT DEFAULT '',
+		secret TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (guild_id, repo)
+	);
+	CREATE INDEX IF NOT EXISTS idx_github_relays_repo ON github_relays(repo);
+
 	-- Logging configuration
 	CREATE TABLE IF NOT EXISTS logging_config (
 		guild_id TEXT PRIMARY KEY,
@@ -277,7 +426,20 @@ func (d *DB) migrate() error {
 		nickname_change INTEGER DEFAULT 1,
 		avatar_change INTEGER DEFAULT 0,
 		presence_change INTEGER DEFAULT 0,
-		presence_batch_mins INTEGER DEFAULT 5
+		presence_batch_mins INTEGER DEFAULT 5,
+		role_change INTEGER DEFAULT 1,
+		moderation INTEGER DEFAULT 1,
+		message_log_channel_id TEXT,
+		member_log_channel_id TEXT,
+		voice_log_channel_id TEXT,
+		mod_log_channel_id TEXT,
+		automod_log_channel_id TEXT,
+		archive_attachments INTEGER DEFAULT 0,
+		voice_move INTEGER DEFAULT 1,
+		voice_mute INTEGER DEFAULT 0,
+		voice_stream INTEGER DEFAULT 0,
+		voice_session INTEGER DEFAULT 1,
+		use_webhooks INTEGER DEFAULT 0
 	);
 
 	-- Disabled log channels (channels to ignore for logging)
@@ -287,6 +449,23 @@ func (d *DB) migrate() error {
 		PRIMARY KEY (guild_id, channel_id)
 	);
 
+	-- Webhooks created by the bot for log delivery, one per log channel
+	CREATE TABLE IF NOT EXISTS log_webhooks (
+		channel_id TEXT PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		webhook_id TEXT NOT NULL,
+		webhook_token TEXT NOT NULL
+	);
+
+	-- Webhook username/avatar per log category ('default' applies to all)
+	CREATE TABLE IF NOT EXISTS log_identities (
+		guild_id TEXT NOT NULL,
+		category TEXT NOT NULL,
+		username TEXT,
+		avatar_url TEXT,
+		PRIMARY KEY (guild_id, category)
+	);
+
 	-- Voice XP configuration
 	CREATE TABLE IF NOT EXISTS voice_xp_config (
 		guild_id TEXT PRIMARY KEY,
@@ -306,6 +485,13 @@ func (d *DB) migrate() error {
 		UNIQUE(guild_id, role_id)
 	);
 
+	-- How level rank roles are given and taken away
+	CREATE TABLE IF NOT EXISTS level_rank_config (
+		guild_id TEXT PRIMARY KEY,
+		stack INTEGER DEFAULT 1,
+		remove_on_loss INTEGER DEFAULT 0
+	);
+
 	-- DM forwarding configuration
 	CREATE TABLE IF NOT EXISTS dm_config (
 		guild_id TEXT PRIMARY KEY,
@@ -320,9 +506,19 @@ func (d *DB) migrate() error {
 		ban_type TEXT NOT NULL,
 		reason TEXT,
 		banned_by TEXT NOT NULL,
+		category TEXT DEFAULT 'other',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Global blocklist opt-in per guild (entries come from bot_bans)
+	CREATE TABLE IF NOT EXISTS blocklist_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 0,
+		action TEXT DEFAULT 'ban',
+		log_channel_id TEXT,
+		alert_role_id TEXT
+	);
+
 	-- Moderation actions tracking
 	CREATE TABLE IF NOT EXISTS mod_actions (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -365,6 +561,41 @@ func (d *DB) migrate() error {
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Tickets opened as private threads or channels
+	CREATE TABLE IF NOT EXISTS tickets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		number INTEGER NOT NULL,
+		channel_id TEXT DEFAULT '',
+		opener_id TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		status TEXT DEFAULT 'open',
+		claimed_by TEXT DEFAULT '',
+		closed_by TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		closed_at DATETIME,
+		UNIQUE(guild_id, number)
+	);
+	CREATE INDEX IF NOT EXISTS idx_tickets_channel ON tickets(channel_id);
+	CREATE INDEX IF NOT EXISTS idx_tickets_guild_opener ON tickets(guild_id, opener_id, status);
+
+	-- Ticket panels: posted "Open Ticket" buttons with their own routing
+	CREATE TABLE IF NOT EXISTS ticket_panels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		message_id TEXT DEFAULT '',
+		title TEXT NOT NULL,
+		description TEXT DEFAULT '',
+		button_label TEXT DEFAULT 'Open Ticket',
+		mode TEXT DEFAULT 'thread',
+		category_id TEXT DEFAULT '',
+		support_role_id TEXT DEFAULT '',
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_ticket_panels_guild ON ticket_panels(guild_id);
+
 	-- Anti-raid configuration
 	CREATE TABLE IF NOT EXISTS antiraid_config (
 		guild_id TEXT PRIMARY KEY,
@@ -438,6 +669,36 @@ func (d *DB) migrate() error {
 		PRIMARY KEY (guild_id, user_id)
 	);
 
+	-- Messages per channel per hour, pruned after a while
+	CREATE TABLE IF NOT EXISTS activity_hourly (
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		hour INTEGER NOT NULL,
+		messages INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, channel_id, day, hour)
+	);
+
+	-- Daily server totals, kept for good
+	CREATE TABLE IF NOT EXISTS activity_daily (
+		guild_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		messages INTEGER DEFAULT 0,
+		joins INTEGER DEFAULT 0,
+		leaves INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, day)
+	);
+
+	-- Messages per member per day, replaces user_activity.message_count
+	CREATE TABLE IF NOT EXISTS activity_member_daily (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		messages INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id, day)
+	);
+	CREATE INDEX IF NOT EXISTS idx_activity_member_daily_guild_day ON activity_member_daily(guild_id, day);
+
 	-- User timezone settings
 	CREATE TABLE IF NOT EXISTS user_timezones (
 		user_id TEXT PRIMARY KEY,
@@ -481,6 +742,64 @@ func (d *DB) migrate() error {
 		played_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Music: Saved per-user playlists
+	CREATE TABLE IF NOT EXISTS music_playlists (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		owner_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		public INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(guild_id, owner_id, name)
+	);
+
+	CREATE TABLE IF NOT EXISTS music_playlist_tracks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		playlist_id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		url TEXT NOT NULL,
+		duration INTEGER DEFAULT 0,
+		is_local INTEGER DEFAULT 0,
+		position INTEGER NOT NULL,
+		added_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Music: Index of the files in a guild's music folder
+	CREATE TABLE IF NOT EXISTS local_tracks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		path TEXT NOT NULL,
+		title TEXT NOT NULL,
+		artist TEXT DEFAULT '',
+		album TEXT DEFAULT '',
+		track_number INTEGER DEFAULT 0,
+		duration INTEGER DEFAULT 0,
+		mod_time INTEGER NOT NULL,
+		indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(guild_id, path)
+	);
+
+	-- Music: Per-guild radio station presets
+	CREATE TABLE IF NOT EXISTS radio_stations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		name TEXT NOT NULL COLLATE NOCASE,
+		url TEXT NOT NULL,
+		added_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(guild_id, name)
+	);
+
+	-- Music: Cached lyrics lookups
+	CREATE TABLE IF NOT EXISTS lyrics_cache (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query TEXT NOT NULL UNIQUE,
+		title TEXT NOT NULL,
+		artist TEXT NOT NULL,
+		lyrics TEXT NOT NULL,
+		cached_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
 	-- Disabled commands/categories per guild
 	CREATE TABLE IF NOT EXISTS guild_disabled_commands (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -504,2110 +823,8097 @@ func (d *DB) migrate() error {
 	CREATE INDEX IF NOT EXISTS idx_user_activity_guild ON user_activity(guild_id);
 	CREATE INDEX IF NOT EXISTS idx_music_queue_guild ON music_queue(guild_id, position);
 	CREATE INDEX IF NOT EXISTS idx_music_history_guild ON music_history(guild_id);
+	CREATE INDEX IF NOT EXISTS idx_music_playlist_tracks ON music_playlist_tracks(playlist_id, position);
 	CREATE INDEX IF NOT EXISTS idx_disabled_commands_guild ON guild_disabled_commands(guild_id);
+	CREATE INDEX IF NOT EXISTS idx_edited_messages_channel ON edited_messages(channel_id, edited_at);
+	CREATE INDEX IF NOT EXISTS idx_edited_messages_message ON edited_messages(message_id);
 
-	-- Encryption metadata (tracks if data has been migrated to encrypted)
-	CREATE TABLE IF NOT EXISTS encryption_metadata (
-		key TEXT PRIMARY KEY,
-		value TEXT NOT NULL,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	-- Invite tracking (which invite each member joined through)
+	CREATE TABLE IF NOT EXISTS invite_joins (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		inviter_id TEXT,
+		invite_code TEXT,
+		joined_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
-	`
-
-	_, err := d.Exec(schema)
-	if err != nil {
-		return err
-	}
+	CREATE INDEX IF NOT EXISTS idx_invite_joins_guild ON invite_joins(guild_id, inviter_id);
+	CREATE INDEX IF NOT EXISTS idx_invite_joins_user ON invite_joins(guild_id, user_id);
 
-	// Run migrations for new columns
-	migrations := []string{
-		`ALTER TABLE guild_settings ADD COLUMN join_dm_title TEXT`,
-		`ALTER TABLE guild_settings ADD COLUMN join_dm_message TEXT`,
-	}
+	-- Reaction role messages and their emoji bindings
+	CREATE TABLE IF NOT EXISTS reaction_role_messages (
+		message_id TEXT PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		mode TEXT DEFAULT 'normal',
+		max_roles INTEGER DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS reaction_roles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		emoji TEXT NOT NULL,
+		role_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(message_id, emoji)
+	);
+	CREATE INDEX IF NOT EXISTS idx_reaction_role_messages_guild ON reaction_role_messages(guild_id);
 
-	for _, migration := range migrations {
-		d.Exec(migration) // Ignore errors - column may already exist
-	}
+	-- Button/select menu role panels
+	CREATE TABLE IF NOT EXISTS role_panels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT,
+		message_id TEXT,
+		title TEXT NOT NULL,
+		description TEXT,
+		style TEXT DEFAULT 'buttons',
+		required_role_id TEXT,
+		max_roles INTEGER DEFAULT 0,
+		temp_duration INTEGER DEFAULT 0,
+		created_by TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS role_panel_roles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		panel_id INTEGER NOT NULL,
+		role_id TEXT NOT NULL,
+		label TEXT,
+		emoji TEXT,
+		description TEXT,
+		UNIQUE(panel_id, role_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_role_panels_guild ON role_panels(guild_id);
 
-	return nil
-}
+	-- Sticky roles: restore roles and nickname when members rejoin
+	CREATE TABLE IF NOT EXISTS sticky_roles_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 0,
+		restore_nickname INTEGER DEFAULT 1,
+		excluded_roles TEXT DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS member_snapshots (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		roles TEXT DEFAULT '',
+		nickname TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		left_at DATETIME,
+		PRIMARY KEY (guild_id, user_id)
+	);
 
-// IsDataMigrated checks if data has been migrated to encrypted format
-func (d *DB) IsDataMigrated() bool {
-	var value string
-	err := d.QueryRow(`SELECT value FROM encryption_metadata WHERE key = 'encrypted'`).Scan(&value)
-	return err == nil && value == "true"
-}
+	-- Suggestions
+	CREATE TABLE IF NOT EXISTS suggestion_config (
+		guild_id TEXT PRIMARY KEY,
+		channel_id TEXT,
+		enabled INTEGER DEFAULT 0,
+		dm_author INTEGER DEFAULT 1
+	);
+	CREATE TABLE IF NOT EXISTS suggestions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		number INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		content TEXT NOT NULL,
+		channel_id TEXT,
+		message_id TEXT,
+		status TEXT DEFAULT 'pending',
+		staff_id TEXT,
+		staff_reason TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(guild_id, number)
+	);
+	CREATE TABLE IF NOT EXISTS suggestion_votes (
+		suggestion_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		vote INTEGER NOT NULL,
+		PRIMARY KEY (suggestion_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_suggestions_status ON suggestions(guild_id, status);
 
-// SetDataMigrated marks that data has been migrated to encrypted format
-func (d *DB) SetDataMigrated(migrated bool) error {
-	val := "false"
-	if migrated {
-		val = "true"
-	}
-	_, err := d.Exec(`INSERT OR REPLACE INTO encryption_metadata (key, value, updated_at) VALUES ('encrypted', ?, CURRENT_TIMESTAMP)`, val)
-	return err
-}
+	-- Birthdays
+	CREATE TABLE IF NOT EXISTS birthday_config (
+		guild_id TEXT PRIMARY KEY,
+		channel_id TEXT,
+		role_id TEXT,
+		message TEXT,
+		enabled INTEGER DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS birthdays (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		month INTEGER NOT NULL,
+		day INTEGER NOT NULL,
+		year INTEGER DEFAULT 0,
+		last_announced INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_birthdays_date ON birthdays(month, day);
 
-// MigrateToEncrypted encrypts all sensitive fields in the database.
-// This is a one-time migration when encryption is first enabled.
-// It's safe to call multiple times - already encrypted data is skipped.
-func (d *DB) MigrateToEncrypted() error {
-	if !d.IsEncryptionEnabled() {
-		return fmt.Errorf("encryption is not enabled")
-	}
+	-- Button polls
+	CREATE TABLE IF NOT EXISTS polls (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		message_id TEXT,
+		user_id TEXT NOT NULL,
+		question TEXT NOT NULL,
+		options TEXT NOT NULL,
+		anonymous INTEGER DEFAULT 0,
+		closed INTEGER DEFAULT 0,
+		ends_at INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS poll_votes (
+		poll_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		option_index INTEGER NOT NULL,
+		PRIMARY KEY (poll_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_polls_guild ON polls(guild_id, created_at);
 
-	if d.IsDataMigrated() {
-		return nil // Already migrated
-	}
+	-- Discord guild scheduled events
+	CREATE TABLE IF NOT EXISTS guild_event_config (
+		guild_id TEXT PRIMARY KEY,
+		channel_id TEXT,
+		ping_role_id TEXT,
+		reminder_minutes INTEGER DEFAULT 15,
+		enabled INTEGER DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS guild_event_announcements (
+		event_id TEXT PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		message_id TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS guild_event_rsvps (
+		event_id TEXT NOT NULL,
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (event_id, user_id)
+	);
 
-	fmt.Println("[Database] Starting encryption migration...")
+	-- Counting and word-chain game channels
+	CREATE TABLE IF NOT EXISTS game_channels (
+		channel_id TEXT PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		game_type TEXT NOT NULL,
+		current_count INTEGER DEFAULT 0,
+		last_user_id TEXT,
+		last_word TEXT,
+		high_score INTEGER DEFAULT 0,
+		high_score_at DATETIME,
+		strict INTEGER DEFAULT 0,
+		allow_consecutive INTEGER DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS game_words (
+		channel_id TEXT NOT NULL,
+		word TEXT NOT NULL,
+		PRIMARY KEY (channel_id, word)
+	);
 
-	// Migrate guild_settings (welcome_message, join_dm_title, join_dm_message)
-	if err := d.migrateEncryptGuildSettings(); err != nil {
-		return fmt.Errorf("failed to migrate guild_settings: %w", err)
-	}
+	-- Economy
+	CREATE TABLE IF NOT EXISTS economy_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 1,
+		currency_name TEXT DEFAULT 'coins',
+		currency_symbol TEXT DEFAULT '🪙',
+		daily_amount INTEGER DEFAULT 100,
+		work_min INTEGER DEFAULT 20,
+		work_max INTEGER DEFAULT 80,
+		work_cooldown_mins INTEGER DEFAULT 60,
+		chat_amount INTEGER DEFAULT 2,
+		chat_cooldown_secs INTEGER DEFAULT 60
+	);
+	CREATE TABLE IF NOT EXISTS economy_accounts (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		balance INTEGER DEFAULT 0,
+		daily_streak INTEGER DEFAULT 0,
+		last_daily INTEGER DEFAULT 0,
+		last_work INTEGER DEFAULT 0,
+		last_chat INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id)
+	);
+	CREATE TABLE IF NOT EXISTS economy_transactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		related_user_id TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_economy_balance ON economy_accounts(guild_id, balance);
+	CREATE INDEX IF NOT EXISTS idx_economy_transactions ON economy_transactions(guild_id, user_id);
 
-	// Migrate warnings (reason)
-	if err := d.migrateEncryptWarnings(); err != nil {
-		return fmt.Errorf("failed to migrate warnings: %w", err)
+	-- Role shop
+	CREATE TABLE IF NOT EXISTS shop_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		role_id TEXT NOT NULL,
+		price INTEGER NOT NULL,
+		stock INTEGER DEFAULT -1,
+		duration_secs INTEGER DEFAULT 0,
+		description TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(guild_id, role_id)
+	);
+	CREATE TABLE IF NOT EXISTS shop_purchases (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		item_id INTEGER NOT NULL,
+		role_id TEXT NOT NULL,
+		price INTEGER NOT NULL,
+		expires_at INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Achievements
+	CREATE TABLE IF NOT EXISTS achievement_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 1,
+		announce INTEGER DEFAULT 0,
+		channel_id TEXT
+	);
+	CREATE TABLE IF NOT EXISTS achievement_stats (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		stat TEXT NOT NULL,
+		value INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id, stat)
+	);
+	CREATE TABLE IF NOT EXISTS user_achievements (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		achievement_id TEXT NOT NULL,
+		earned_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (guild_id, user_id, achievement_id)
+	);
+	CREATE TABLE IF NOT EXISTS custom_achievements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT,
+		emoji TEXT,
+		stat TEXT NOT NULL,
+		threshold INTEGER NOT NULL,
+		role_id TEXT
+	);
+
+	-- Rank card customization (per user, across guilds)
+	CREATE TABLE IF NOT EXISTS rank_card_settings (
+		user_id TEXT PRIMARY KEY,
+		background INTEGER,
+		accent INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Per guild look of the image card attached to welcome messages
+	CREATE TABLE IF NOT EXISTS welcome_card_settings (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 0,
+		background INTEGER,
+		accent INTEGER,
+		background_url TEXT DEFAULT '',
+		title TEXT DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Welcome, leave and join DM messages; welcome and join DM text also live in guild_settings
+	CREATE TABLE IF NOT EXISTS greeting_messages (
+		guild_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		channel_id TEXT DEFAULT '',
+		content TEXT DEFAULT '',
+		embed TEXT DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (guild_id, kind)
+	);
+
+	-- Which AI provider /ask uses in a guild
+	CREATE TABLE IF NOT EXISTS ai_settings (
+		guild_id TEXT PRIMARY KEY,
+		provider TEXT DEFAULT '',
+		model TEXT DEFAULT '',
+		api_key TEXT DEFAULT '',
+		updated_by TEXT DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Recent /ask exchanges, replayed as context for the next question in the channel
+	CREATE TABLE IF NOT EXISTS ai_conversations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		user_name TEXT DEFAULT '',
+		prompt TEXT NOT NULL,
+		reply TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_ai_conversations_channel ON ai_conversations(channel_id, id);
+
+	-- Daily AI usage per guild and feature, checked against the guild's budgets
+	CREATE TABLE IF NOT EXISTS ai_usage (
+		guild_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		feature TEXT NOT NULL,
+		requests INTEGER DEFAULT 0,
+		tokens INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, day, feature)
+	);
+
+	-- Daily AI usage per member, across all features
+	CREATE TABLE IF NOT EXISTS ai_user_usage (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		requests INTEGER DEFAULT 0,
+		tokens INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id, day)
+	);
+	CREATE INDEX IF NOT EXISTS idx_ai_user_usage_guild_day ON ai_user_usage(guild_id, day);
+
+	-- AI moderation: score messages with a moderation model and act on high scores
+	CREATE TABLE IF NOT EXISTS ai_moderation_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 0,
+		flag_threshold REAL DEFAULT 0.5,
+		delete_threshold REAL DEFAULT 0.8,
+		warn_threshold REAL DEFAULT 0,
+		flag_channel_id TEXT DEFAULT '',
+		categories TEXT DEFAULT ''
+	);
+
+	-- Every message AI moderation acted on
+	CREATE TABLE IF NOT EXISTS ai_moderation_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		content TEXT DEFAULT '',
+		category TEXT NOT NULL,
+		score REAL NOT NULL,
+		action TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_ai_moderation_log_guild ON ai_moderation_log(guild_id, created_at);
+
+	-- Text-to-speech played through the music player
+	CREATE TABLE IF NOT EXISTS tts_settings (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 0,
+		engine TEXT DEFAULT 'espeak',
+		voice TEXT DEFAULT ''
+	);
+
+	-- DMs sent to members who are warned, timed out, kicked or banned
+	CREATE TABLE IF NOT EXISTS mod_dm_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 0,
+		appeal_url TEXT DEFAULT ''
+	);
+
+	-- Channels whose slowmode follows their message rate
+	CREATE TABLE IF NOT EXISTS auto_slowmode_channels (
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		threshold INTEGER DEFAULT 30,
+		max_seconds INTEGER DEFAULT 30,
+		base_seconds INTEGER DEFAULT 0,
+		paused_until DATETIME,
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (guild_id, channel_id)
+	);
+
+	-- Member reports of messages and users, posted to a staff channel
+	CREATE TABLE IF NOT EXISTS report_config (
+		guild_id TEXT PRIMARY KEY,
+		channel_id TEXT,
+		enabled INTEGER DEFAULT 0,
+		allow_anonymous INTEGER DEFAULT 1
+	);
+	CREATE TABLE IF NOT EXISTS reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		channel_id TEXT DEFAULT '',
+		message_id TEXT DEFAULT '',
+		reporter_id TEXT NOT NULL,
+		reason TEXT,
+		anonymous INTEGER DEFAULT 0,
+		status TEXT DEFAULT 'open',
+		report_count INTEGER DEFAULT 1,
+		staff_channel_id TEXT DEFAULT '',
+		staff_message_id TEXT DEFAULT '',
+		handled_by TEXT DEFAULT '',
+		handled_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS report_reporters (
+		report_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		PRIMARY KEY (report_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_reports_open ON reports(guild_id, status);
+
+	-- Memorable messages saved to a guild's quote board
+	CREATE TABLE IF NOT EXISTS quotes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		number INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		content TEXT NOT NULL,
+		channel_id TEXT DEFAULT '',
+		message_id TEXT DEFAULT '',
+		added_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(guild_id, number)
+	);
+	CREATE INDEX IF NOT EXISTS idx_quotes_message ON quotes(guild_id, message_id);
+	CREATE TABLE IF NOT EXISTS quote_config (
+		guild_id TEXT PRIMARY KEY,
+		channel_id TEXT,
+		daily_enabled INTEGER DEFAULT 0,
+		post_hour INTEGER DEFAULT 12,
+		last_posted TEXT DEFAULT ''
+	);
+
+	-- Recently removed reactions (for reactionsnipe), only kept where enabled
+	CREATE TABLE IF NOT EXISTS reaction_snipe_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS removed_reactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		emoji TEXT NOT NULL,
+		removed_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_removed_reactions_channel ON removed_reactions(channel_id, removed_at);
+
+	-- Snapshots of a server's roles, channels and settings, stored as JSON
+	CREATE TABLE IF NOT EXISTS server_backups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		role_count INTEGER DEFAULT 0,
+		channel_count INTEGER DEFAULT 0,
+		data TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_server_backups_guild ON server_backups(guild_id);
+
+	-- Canned moderation reasons offered when warning, timing out, kicking or banning
+	CREATE TABLE IF NOT EXISTS reason_presets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		name TEXT NOT NULL COLLATE NOCASE,
+		reason TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(guild_id, name)
+	);
+
+	-- Daily XP gains, used for weekly/monthly leaderboards
+	CREATE TABLE IF NOT EXISTS xp_history (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		xp INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id, day)
+	);
+	CREATE INDEX IF NOT EXISTS idx_xp_history_guild_day ON xp_history(guild_id, day);
+
+	-- XP multipliers for roles and channels
+	CREATE TABLE IF NOT EXISTS xp_multipliers (
+		guild_id TEXT NOT NULL,
+		target_type TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		multiplier REAL NOT NULL,
+		PRIMARY KEY (guild_id, target_type, target_id)
+	);
+
+	-- Time-boxed server-wide XP boosts
+	CREATE TABLE IF NOT EXISTS xp_boosts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		name TEXT,
+		multiplier REAL NOT NULL,
+		starts_at INTEGER NOT NULL,
+		ends_at INTEGER NOT NULL,
+		created_by TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_xp_boosts_guild ON xp_boosts(guild_id, ends_at);
+
+	-- Channels and roles that never earn XP
+	CREATE TABLE IF NOT EXISTS xp_exclusions (
+		guild_id TEXT NOT NULL,
+		target_type TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		PRIMARY KEY (guild_id, target_type, target_id)
+	);
+
+	-- XP seasons: standings are XP gained since season_start (Unix ms, UTC midnight)
+	CREATE TABLE IF NOT EXISTS xp_season_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 0,
+		period TEXT DEFAULT 'monthly',
+		channel_id TEXT,
+		winner_role_id TEXT,
+		top_count INTEGER DEFAULT 10,
+		season_start INTEGER DEFAULT 0,
+		last_winner_id TEXT
+	);
+
+	-- Final standings of past seasons
+	CREATE TABLE IF NOT EXISTS xp_season_archive (
+		guild_id TEXT NOT NULL,
+		season_start INTEGER NOT NULL,
+		season_end INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		xp INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, season_start, position)
+	);
+
+	-- Level-up announcement settings
+	CREATE TABLE IF NOT EXISTS levelup_config (
+		guild_id TEXT PRIMARY KEY,
+		enabled INTEGER DEFAULT 1,
+		destination TEXT DEFAULT 'current',
+		channel_id TEXT,
+		message TEXT,
+		use_embed INTEGER DEFAULT 0,
+		every_levels INTEGER DEFAULT 1
+	);
+
+	-- Voice XP earned per day, for the daily cap
+	CREATE TABLE IF NOT EXISTS voice_xp_daily (
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		xp INTEGER DEFAULT 0,
+		PRIMARY KEY (guild_id, user_id, day)
+	);
+
+	-- Encryption metadata (tracks if data has been migrated to encrypted)
+	CREATE TABLE IF NOT EXISTS encryption_metadata (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	_, err := d.Exec(schema)
+	if err != nil {
+		return err
 	}
 
-	// Migrate deleted_messages (content)
-	if err := d.migrateEncryptDeletedMessages(); err != nil {
-		return fmt.Errorf("failed to migrate deleted_messages: %w", err)
+	// Run migrations for new columns
+	migrations := []string{
+		`ALTER TABLE guild_settings ADD COLUMN join_dm_title TEXT`,
+		`ALTER TABLE guild_settings ADD COLUMN join_dm_message TEXT`,
+		`ALTER TABLE bot_bans ADD COLUMN category TEXT DEFAULT 'other'`,
+		`ALTER TABLE logging_config ADD COLUMN role_change INTEGER DEFAULT 1`,
+		`ALTER TABLE logging_config ADD COLUMN moderation INTEGER DEFAULT 1`,
+		`ALTER TABLE logging_config ADD COLUMN message_log_channel_id TEXT`,
+		`ALTER TABLE logging_config ADD COLUMN member_log_channel_id TEXT`,
+		`ALTER TABLE logging_config ADD COLUMN voice_log_channel_id TEXT`,
+		`ALTER TABLE logging_config ADD COLUMN mod_log_channel_id TEXT`,
+		`ALTER TABLE logging_config ADD COLUMN automod_log_channel_id TEXT`,
+		`ALTER TABLE logging_config ADD COLUMN archive_attachments INTEGER DEFAULT 0`,
+		`ALTER TABLE deleted_messages ADD COLUMN attachments TEXT`,
+		`ALTER TABLE logging_config ADD COLUMN voice_move INTEGER DEFAULT 1`,
+		`ALTER TABLE logging_config ADD COLUMN voice_mute INTEGER DEFAULT 0`,
+		`ALTER TABLE logging_config ADD COLUMN voice_stream INTEGER DEFAULT 0`,
+		`ALTER TABLE logging_config ADD COLUMN voice_session INTEGER DEFAULT 1`,
+		`ALTER TABLE logging_config ADD COLUMN use_webhooks INTEGER DEFAULT 0`,
+		`ALTER TABLE voice_xp_config ADD COLUMN ignore_alone INTEGER DEFAULT 1`,
+		`ALTER TABLE voice_xp_config ADD COLUMN ignore_deafened INTEGER DEFAULT 1`,
+		`ALTER TABLE voice_xp_config ADD COLUMN excluded_channels TEXT DEFAULT ''`,
+		`ALTER TABLE voice_xp_config ADD COLUMN daily_cap INTEGER DEFAULT 0`,
+		`ALTER TABLE music_settings ADD COLUMN playlist_limit INTEGER DEFAULT 10`,
+		`ALTER TABLE music_settings ADD COLUMN playlist_track_limit INTEGER DEFAULT 100`,
+		`ALTER TABLE music_settings ADD COLUMN stay_connected INTEGER DEFAULT 0`,
+		`ALTER TABLE music_settings ADD COLUMN autoplay INTEGER DEFAULT 0`,
+		`ALTER TABLE music_settings ADD COLUMN audio_filters TEXT DEFAULT ''`,
+		`ALTER TABLE music_settings ADD COLUMN text_channels TEXT DEFAULT ''`,
+		`ALTER TABLE music_settings ADD COLUMN normalize INTEGER DEFAULT 0`,
+		`ALTER TABLE music_settings ADD COLUMN max_queue_length INTEGER DEFAULT 0`,
+		`ALTER TABLE music_settings ADD COLUMN max_track_duration INTEGER DEFAULT 0`,
+		`ALTER TABLE music_settings ADD COLUMN max_user_tracks INTEGER DEFAULT 0`,
+		`ALTER TABLE music_settings ADD COLUMN sponsorblock INTEGER DEFAULT 0`,
+		`ALTER TABLE music_settings ADD COLUMN sponsorblock_categories TEXT DEFAULT ''`,
+		`ALTER TABLE ticket_config ADD COLUMN mode TEXT DEFAULT 'thread'`,
+		`ALTER TABLE ticket_config ADD COLUMN category_id TEXT DEFAULT ''`,
+		`ALTER TABLE ticket_config ADD COLUMN support_role_id TEXT DEFAULT ''`,
+		`ALTER TABLE tickets ADD COLUMN support_role_id TEXT DEFAULT ''`,
+		`ALTER TABLE tickets ADD COLUMN panel_id INTEGER DEFAULT 0`,
+		`ALTER TABLE ticket_config ADD COLUMN transcript_channel_id TEXT DEFAULT ''`,
+		`ALTER TABLE tickets ADD COLUMN transcript_channel_id TEXT DEFAULT ''`,
+		`ALTER TABLE tickets ADD COLUMN transcript_message_id TEXT DEFAULT ''`,
+		`ALTER TABLE ticket_config ADD COLUMN inactive_hours INTEGER DEFAULT 0`,
+		`ALTER TABLE ticket_config ADD COLUMN inactive_grace_hours INTEGER DEFAULT 24`,
+		`ALTER TABLE tickets ADD COLUMN inactivity_warned_at DATETIME`,
+		`ALTER TABLE tickets ADD COLUMN inactivity_warning_id TEXT DEFAULT ''`,
+		`ALTER TABLE tags ADD COLUMN embed TEXT DEFAULT ''`,
+		`ALTER TABLE custom_commands ADD COLUMN embed TEXT DEFAULT ''`,
+		`ALTER TABLE custom_commands ADD COLUMN allowed_roles TEXT DEFAULT ''`,
+		`ALTER TABLE custom_commands ADD COLUMN allowed_channels TEXT DEFAULT ''`,
+		`ALTER TABLE custom_commands ADD COLUMN cooldown_seconds INTEGER DEFAULT 0`,
+		`ALTER TABLE custom_commands ADD COLUMN delete_invocation INTEGER DEFAULT 0`,
+		`ALTER TABLE mention_responses ADD COLUMN match_mode TEXT DEFAULT 'contains'`,
+		`ALTER TABLE mention_responses ADD COLUMN cooldown_seconds INTEGER DEFAULT 0`,
+		`ALTER TABLE keyword_notifications ADD COLUMN match_mode TEXT DEFAULT 'contains'`,
+		`ALTER TABLE keyword_notifications ADD COLUMN case_sensitive INTEGER DEFAULT 0`,
+		`ALTER TABLE afk_status ADD COLUMN guild_id TEXT DEFAULT ''`,
+		`ALTER TABLE afk_status ADD COLUMN nick_guild_id TEXT DEFAULT ''`,
+		`ALTER TABLE afk_status ADD COLUMN original_nick TEXT DEFAULT ''`,
+		`ALTER TABLE ai_settings ADD COLUMN system_prompt TEXT DEFAULT ''`,
+		`ALTER TABLE ai_settings ADD COLUMN memory_size INTEGER DEFAULT 10`,
+		`ALTER TABLE ai_settings ADD COLUMN memory_hours INTEGER DEFAULT 24`,
+		`ALTER TABLE ai_settings ADD COLUMN summary_role_id TEXT DEFAULT ''`,
+		`ALTER TABLE ai_settings ADD COLUMN summary_daily_limit INTEGER DEFAULT 20`,
+		`ALTER TABLE ai_settings ADD COLUMN summary_token_budget INTEGER DEFAULT 100000`,
+		`ALTER TABLE ai_settings ADD COLUMN daily_token_budget INTEGER DEFAULT 0`,
+		`ALTER TABLE ai_settings ADD COLUMN user_daily_token_budget INTEGER DEFAULT 0`,
+		`ALTER TABLE command_history ADD COLUMN duration_ms INTEGER`,
+		`ALTER TABLE command_history ADD COLUMN failed INTEGER DEFAULT 0`,
+		`ALTER TABLE mod_actions ADD COLUMN dm_status TEXT DEFAULT ''`,
+		`ALTER TABLE scheduled_messages ADD COLUMN embed TEXT DEFAULT ''`,
+		`ALTER TABLE scheduled_messages ADD COLUMN attachments TEXT DEFAULT ''`,
+		`ALTER TABLE mod_actions ADD COLUMN undone_by TEXT DEFAULT ''`,
+		`ALTER TABLE deleted_messages ADD COLUMN stickers TEXT`,
+		`ALTER TABLE deleted_messages ADD COLUMN embeds TEXT`,
+		`ALTER TABLE mod_actions ADD COLUMN warning_id INTEGER DEFAULT 0`,
+		`ALTER TABLE user_activity ADD COLUMN legacy_messages INTEGER DEFAULT 0`,
 	}
 
-	// Migrate user_notes (note)
-	if err := d.migrateEncryptUserNotes(); err != nil {
+	for _, migration := range migrations {
+		d.Exec(migration) // Ignore errors - column may already exist
+	}
+
+	if err := d.rebuildUserNotes(); err != nil {
 		return fmt.Errorf("failed to migrate user_notes: %w", err)
 	}
 
-	// Migrate scheduled_messages (message)
-	if err := d.migrateEncryptScheduledMessages(); err != nil {
-		return fmt.Errorf("failed to migrate scheduled_messages: %w", err)
+	if err := d.moveLegacyMessageCounts(); err != nil {
+		return fmt.Errorf("failed to migrate user_activity message counts: %w", err)
+	}
+
+	return nil
+}
+
+// moveLegacyMessageCounts keeps the old all-time message counts as a per-member baseline.
+// They have no dates, so they can't go into activity_member_daily without faking a day.
+func (d *DB) moveLegacyMessageCounts() error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE user_activity SET legacy_messages = COALESCE(legacy_messages, 0) + message_count, message_count = 0
+		WHERE message_count > 0`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebuildUserNotes drops the old one-note-per-user UNIQUE constraint, which SQLite can only remove by copying the table
+func (d *DB) rebuildUserNotes() error {
+	var schema string
+	if err := d.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'user_notes'`).Scan(&schema); err != nil {
+		return err
+	}
+	if !strings.Contains(schema, "UNIQUE") {
+		return nil
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE user_notes_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			guild_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			note TEXT NOT NULL,
+			created_by TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT DEFAULT '',
+			updated_at DATETIME
+		)`,
+		`INSERT INTO user_notes_new (id, guild_id, user_id, note, created_by, created_at)
+			SELECT id, guild_id, user_id, note, created_by, created_at FROM user_notes`,
+		`DROP TABLE user_notes`,
+		`ALTER TABLE user_notes_new RENAME TO user_notes`,
+		`CREATE INDEX IF NOT EXISTS idx_user_notes_guild_user ON user_notes(guild_id, user_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// IsDataMigrated checks if data has been migrated to encrypted format
+func (d *DB) IsDataMigrated() bool {
+	var value string
+	err := d.QueryRow(`SELECT value FROM encryption_metadata WHERE key = 'encrypted'`).Scan(&value)
+	return err == nil && value == "true"
+}
+
+// SetDataMigrated marks that data has been migrated to encrypted format
+func (d *DB) SetDataMigrated(migrated bool) error {
+	val := "false"
+	if migrated {
+		val = "true"
+	}
+	_, err := d.Exec(`INSERT OR REPLACE INTO encryption_metadata (key, value, updated_at) VALUES ('encrypted', ?, CURRENT_TIMESTAMP)`, val)
+	return err
+}
+
+// MigrateToEncrypted encrypts all sensitive fields in the database.
+// This is a one-time migration when encryption is first enabled.
+// It's safe to call multiple times - already encrypted data is skipped.
+func (d *DB) MigrateToEncrypted() error {
+	if !d.IsEncryptionEnabled() {
+		return fmt.Errorf("encryption is not enabled")
+	}
+
+	if d.IsDataMigrated() {
+		return nil // Already migrated
+	}
+
+	fmt.Println("[Database] Starting encryption migration...")
+
+	// Migrate guild_settings (welcome_message, join_dm_title, join_dm_message)
+	if err := d.migrateEncryptGuildSettings(); err != nil {
+		return fmt.Errorf("failed to migrate guild_settings: %w", err)
+	}
+
+	// Migrate warnings (reason)
+	if err := d.migrateEncryptWarnings(); err != nil {
+		return fmt.Errorf("failed to migrate warnings: %w", err)
+	}
+
+	// Migrate deleted_messages (content)
+	if err := d.migrateEncryptDeletedMessages(); err != nil {
+		return fmt.Errorf("failed to migrate deleted_messages: %w", err)
+	}
+
+	// Migrate edited_messages (before_content, after_content)
+	if err := d.migrateEncryptEditedMessages(); err != nil {
+		return fmt.Errorf("failed to migrate edited_messages: %w", err)
+	}
+
+	// Migrate user_notes (note)
+	if err := d.migrateEncryptUserNotes(); err != nil {
+		return fmt.Errorf("failed to migrate user_notes: %w", err)
+	}
+
+	// Migrate scheduled_messages (message)
+	if err := d.migrateEncryptScheduledMessages(); err != nil {
+		return fmt.Errorf("failed to migrate scheduled_messages: %w", err)
+	}
+
+	// Migrate afk_status (message)
+	if err := d.migrateEncryptAFKStatus(); err != nil {
+		return fmt.Errorf("failed to migrate afk_status: %w", err)
+	}
+
+	// Migrate reminders (message)
+	if err := d.migrateEncryptReminders(); err != nil {
+		return fmt.Errorf("failed to migrate reminders: %w", err)
+	}
+
+	// Migrate tags (content)
+	if err := d.migrateEncryptTags(); err != nil {
+		return fmt.Errorf("failed to migrate tags: %w", err)
+	}
+
+	// Migrate custom_commands (response)
+	if err := d.migrateEncryptCustomCommands(); err != nil {
+		return fmt.Errorf("failed to migrate custom_commands: %w", err)
+	}
+
+	// Migrate bot_bans (reason)
+	if err := d.migrateEncryptBotBans(); err != nil {
+		return fmt.Errorf("failed to migrate bot_bans: %w", err)
+	}
+
+	// Migrate mod_actions (reason)
+	if err := d.migrateEncryptModActions(); err != nil {
+		return fmt.Errorf("failed to migrate mod_actions: %w", err)
+	}
+
+	// Migrate mention_responses (trigger, response, image_url)
+	if err := d.migrateEncryptMentionResponses(); err != nil {
+		return fmt.Errorf("failed to migrate mention_responses: %w", err)
+	}
+
+	// Migrate regex_filters (reason)
+	if err := d.migrateEncryptRegexFilters(); err != nil {
+		return fmt.Errorf("failed to migrate regex_filters: %w", err)
+	}
+
+	// Mark as migrated
+	if err := d.SetDataMigrated(true); err != nil {
+		return fmt.Errorf("failed to mark migration complete: %w", err)
+	}
+
+	fmt.Println("[Database] Encryption migration complete!")
+	return nil
+}
+
+func (d *DB) migrateEncryptGuildSettings() error {
+	rows, err := d.Query(`SELECT guild_id, welcome_message, join_dm_title, join_dm_message FROM guild_settings`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var guildID string
+		var welcomeMsg, joinTitle, joinMsg *string
+		if err := rows.Scan(&guildID, &welcomeMsg, &joinTitle, &joinMsg); err != nil {
+			return err
+		}
+
+		// Only encrypt if not already encrypted
+		needsUpdate := false
+		if welcomeMsg != nil && *welcomeMsg != "" && !d.IsDataEncrypted(*welcomeMsg) {
+			*welcomeMsg = d.Encrypt(*welcomeMsg)
+			needsUpdate = true
+		}
+		if joinTitle != nil && *joinTitle != "" && !d.IsDataEncrypted(*joinTitle) {
+			*joinTitle = d.Encrypt(*joinTitle)
+			needsUpdate = true
+		}
+		if joinMsg != nil && *joinMsg != "" && !d.IsDataEncrypted(*joinMsg) {
+			*joinMsg = d.Encrypt(*joinMsg)
+			needsUpdate = true
+		}
+
+		if needsUpdate {
+			_, err = d.Exec(`UPDATE guild_settings SET welcome_message = ?, join_dm_title = ?, join_dm_message = ? WHERE guild_id = ?`,
+				welcomeMsg, joinTitle, joinMsg, guildID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptWarnings() error {
+	rows, err := d.Query(`SELECT id, reason FROM warnings WHERE reason IS NOT NULL AND reason != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var reason string
+		if err := rows.Scan(&id, &reason); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(reason) {
+			_, err = d.Exec(`UPDATE warnings SET reason = ? WHERE id = ?`, d.Encrypt(reason), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptDeletedMessages() error {
+	rows, err := d.Query(`SELECT id, content FROM deleted_messages WHERE content != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(content) {
+			_, err = d.Exec(`UPDATE deleted_messages SET content = ? WHERE id = ?`, d.Encrypt(content), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Attachment metadata contains CDN URLs and filenames
+	attRows, err := d.Query(`SELECT id, attachments FROM deleted_messages WHERE attachments IS NOT NULL AND attachments != ''`)
+	if err != nil {
+		return err
+	}
+	defer attRows.Close()
+
+	for attRows.Next() {
+		var id int64
+		var attachments string
+		if err := attRows.Scan(&id, &attachments); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(attachments) {
+			_, err = d.Exec(`UPDATE deleted_messages SET attachments = ? WHERE id = ?`, d.Encrypt(attachments), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return attRows.Err()
+}
+
+func (d *DB) migrateEncryptEditedMessages() error {
+	rows, err := d.Query(`SELECT id, before_content, after_content FROM edited_messages`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var before, after string
+		if err := rows.Scan(&id, &before, &after); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(before) || !d.IsDataEncrypted(after) {
+			if !d.IsDataEncrypted(before) {
+				before = d.Encrypt(before)
+			}
+			if !d.IsDataEncrypted(after) {
+				after = d.Encrypt(after)
+			}
+			_, err = d.Exec(`UPDATE edited_messages SET before_content = ?, after_content = ? WHERE id = ?`, before, after, id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptUserNotes() error {
+	rows, err := d.Query(`SELECT id, note FROM user_notes WHERE note != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var note string
+		if err := rows.Scan(&id, &note); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(note) {
+			_, err = d.Exec(`UPDATE user_notes SET note = ? WHERE id = ?`, d.Encrypt(note), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptScheduledMessages() error {
+	rows, err := d.Query(`SELECT id, message FROM scheduled_messages WHERE message != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var message string
+		if err := rows.Scan(&id, &message); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(message) {
+			_, err = d.Exec(`UPDATE scheduled_messages SET message = ? WHERE id = ?`, d.Encrypt(message), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptAFKStatus() error {
+	rows, err := d.Query(`SELECT user_id, message FROM afk_status WHERE message IS NOT NULL AND message != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, message string
+		if err := rows.Scan(&userID, &message); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(message) {
+			_, err = d.Exec(`UPDATE afk_status SET message = ? WHERE user_id = ?`, d.Encrypt(message), userID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptReminders() error {
+	rows, err := d.Query(`SELECT id, message FROM reminders WHERE message != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var message string
+		if err := rows.Scan(&id, &message); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(message) {
+			_, err = d.Exec(`UPDATE reminders SET message = ? WHERE id = ?`, d.Encrypt(message), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptTags() error {
+	rows, err := d.Query(`SELECT id, content FROM tags WHERE content != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(content) {
+			_, err = d.Exec(`UPDATE tags SET content = ? WHERE id = ?`, d.Encrypt(content), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptCustomCommands() error {
+	rows, err := d.Query(`SELECT id, response FROM custom_commands WHERE response != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var response string
+		if err := rows.Scan(&id, &response); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(response) {
+			_, err = d.Exec(`UPDATE custom_commands SET response = ? WHERE id = ?`, d.Encrypt(response), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptBotBans() error {
+	rows, err := d.Query(`SELECT target_id, reason FROM bot_bans WHERE reason IS NOT NULL AND reason != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetID, reason string
+		if err := rows.Scan(&targetID, &reason); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(reason) {
+			_, err = d.Exec(`UPDATE bot_bans SET reason = ? WHERE target_id = ?`, d.Encrypt(reason), targetID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptModActions() error {
+	rows, err := d.Query(`SELECT id, reason FROM mod_actions WHERE reason IS NOT NULL AND reason != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var reason string
+		if err := rows.Scan(&id, &reason); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(reason) {
+			_, err = d.Exec(`UPDATE mod_actions SET reason = ? WHERE id = ?`, d.Encrypt(reason), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptMentionResponses() error {
+	rows, err := d.Query(`SELECT id, trigger_text, response, image_url FROM mention_responses`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var trigger, response string
+		var imageURL *string
+		if err := rows.Scan(&id, &trigger, &response, &imageURL); err != nil {
+			return err
+		}
+
+		needsUpdate := false
+		if !d.IsDataEncrypted(trigger) {
+			trigger = d.Encrypt(trigger)
+			needsUpdate = true
+		}
+		if !d.IsDataEncrypted(response) {
+			response = d.Encrypt(response)
+			needsUpdate = true
+		}
+		if imageURL != nil && *imageURL != "" && !d.IsDataEncrypted(*imageURL) {
+			*imageURL = d.Encrypt(*imageURL)
+			needsUpdate = true
+		}
+
+		if needsUpdate {
+			_, err = d.Exec(`UPDATE mention_responses SET trigger_text = ?, response = ?, image_url = ? WHERE id = ?`,
+				trigger, response, imageURL, id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (d *DB) migrateEncryptRegexFilters() error {
+	rows, err := d.Query(`SELECT id, reason FROM regex_filters WHERE reason IS NOT NULL AND reason != ''`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var reason string
+		if err := rows.Scan(&id, &reason); err != nil {
+			return err
+		}
+		if !d.IsDataEncrypted(reason) {
+			_, err = d.Exec(`UPDATE regex_filters SET reason = ? WHERE id = ?`, d.Encrypt(reason), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// Guild Settings
+func (d *DB) GetGuildSettings(guildID string) (*GuildSettings, error) {
+	// The cache holds the row as stored, so encrypted fields stay encrypted in Redis
+	key := "guild_settings:" + guildID
+	var gs GuildSettings
+	if !d.cache.GetJSON(key, &gs) {
+		err := d.QueryRow(`SELECT guild_id, prefix, mod_log_channel, welcome_channel, welcome_message, join_dm_title, join_dm_message
+			FROM guild_settings WHERE guild_id = ?`, guildID).Scan(
+			&gs.GuildID, &gs.Prefix, &gs.ModLogChannel, &gs.WelcomeChannel, &gs.WelcomeMessage, &gs.JoinDMTitle, &gs.JoinDMMessage)
+		if err == sql.ErrNoRows {
+			gs = GuildSettings{GuildID: guildID, Prefix: "/"}
+		} else if err != nil {
+			return nil, err
+		}
+		d.cache.SetJSON(key, &gs, settingsCacheTTL)
+	}
+
+	// Decrypt sensitive fields
+	gs.WelcomeMessage = d.DecryptNullable(gs.WelcomeMessage)
+	gs.JoinDMTitle = d.DecryptNullable(gs.JoinDMTitle)
+	gs.JoinDMMessage = d.DecryptNullable(gs.JoinDMMessage)
+	return &gs, nil
+}
+
+func (d *DB) SetGuildSettings(gs *GuildSettings) error {
+	// Encrypt sensitive fields
+	welcomeMsg := d.EncryptNullable(gs.WelcomeMessage)
+	joinTitle := d.EncryptNullable(gs.JoinDMTitle)
+	joinMsg := d.EncryptNullable(gs.JoinDMMessage)
+
+	_, err := d.Exec(`INSERT INTO guild_settings (guild_id, prefix, mod_log_channel, welcome_channel, welcome_message, join_dm_title, join_dm_message, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		prefix = excluded.prefix,
+		mod_log_channel = excluded.mod_log_channel,
+		welcome_channel = excluded.welcome_channel,
+		welcome_message = excluded.welcome_message,
+		join_dm_title = excluded.join_dm_title,
+		join_dm_message = excluded.join_dm_message,
+		updated_at = CURRENT_TIMESTAMP`,
+		gs.GuildID, gs.Prefix, gs.ModLogChannel, gs.WelcomeChannel, welcomeMsg, joinTitle, joinMsg)
+	if err == nil {
+		d.cache.Invalidate("guild_settings:" + gs.GuildID)
+	}
+	return err
+}
+
+// Custom Commands
+const customCommandColumns = `id, guild_id, name, response, created_by, use_count, COALESCE(embed, ''),
+	COALESCE(allowed_roles, ''), COALESCE(allowed_channels, ''), COALESCE(cooldown_seconds, 0), COALESCE(delete_invocation, 0)`
+
+func (d *DB) scanCustomCommand(scanner interface{ Scan(...interface{}) error }) (*CustomCommand, error) {
+	var cc CustomCommand
+	var roles, channels string
+	err := scanner.Scan(&cc.ID, &cc.GuildID, &cc.Name, &cc.Response, &cc.CreatedBy, &cc.UseCount, &cc.Embed,
+		&roles, &channels, &cc.CooldownSeconds, &cc.DeleteInvocation)
+	if err != nil {
+		return nil, err
+	}
+	cc.Response = d.Decrypt(cc.Response)
+	cc.Embed = d.Decrypt(cc.Embed)
+	if roles != "" {
+		cc.AllowedRoles = strings.Split(roles, ",")
+	}
+	if channels != "" {
+		cc.AllowedChannels = strings.Split(channels, ",")
+	}
+	return &cc, nil
+}
+
+func (d *DB) GetCustomCommand(guildID, name string) (*CustomCommand, error) {
+	cc, err := d.scanCustomCommand(d.QueryRow(`SELECT `+customCommandColumns+`
+		FROM custom_commands WHERE guild_id = ? AND name = ? COLLATE NOCASE`, guildID, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return cc, err
+}
+
+func (d *DB) CreateCustomCommand(guildID, name, response, createdBy string) error {
+	_, err := d.Exec(`INSERT INTO custom_commands (guild_id, name, response, created_by) VALUES (?, ?, ?, ?)`,
+		guildID, name, d.Encrypt(response), createdBy)
+	return err
+}
+
+func (d *DB) DeleteCustomCommand(guildID, name string) error {
+	_, err := d.Exec(`DELETE FROM custom_commands WHERE guild_id = ? AND name = ?`, guildID, name)
+	return err
+}
+
+func (d *DB) ListCustomCommands(guildID string) ([]CustomCommand, error) {
+	rows, err := d.Query(`SELECT `+customCommandColumns+`
+		FROM custom_commands WHERE guild_id = ? ORDER BY name`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commands []CustomCommand
+	for rows.Next() {
+		cc, err := d.scanCustomCommand(rows)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, *cc)
+	}
+	return commands, rows.Err()
+}
+
+// SetCustomCommandEmbed stores the raw embed JSON sent with a custom command, empty clears it
+func (d *DB) SetCustomCommandEmbed(id int64, embedJSON string) error {
+	_, err := d.Exec(`UPDATE custom_commands SET embed = ? WHERE id = ?`, d.Encrypt(embedJSON), id)
+	return err
+}
+
+// SetCustomCommandRestrictions saves who can use a custom command, where, and how often
+func (d *DB) SetCustomCommandRestrictions(cc *CustomCommand) error {
+	_, err := d.Exec(`UPDATE custom_commands SET allowed_roles = ?, allowed_channels = ?, cooldown_seconds = ?,
+		delete_invocation = ? WHERE id = ?`,
+		strings.Join(cc.AllowedRoles, ","), strings.Join(cc.AllowedChannels, ","), cc.CooldownSeconds,
+		cc.DeleteInvocation, cc.ID)
+	return err
+}
+
+func (d *DB) IncrementCommandUse(guildID, name string) error {
+	_, err := d.Exec(`UPDATE custom_commands SET use_count = use_count + 1 WHERE guild_id = ? AND name = ?`,
+		guildID, name)
+	return err
+}
+
+// Command History
+
+// LogCommand records a command run, unless the user has opted out of command history
+func (d *DB) LogCommand(guildID, channelID, userID, command, args string, duration time.Duration, failed bool) error {
+	if d.IsCommandHistoryOptedOut(userID) {
+		return nil
+	}
+	_, err := d.Exec(`INSERT INTO command_history (guild_id, channel_id, user_id, command, args, duration_ms, failed)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		guildID, channelID, userID, command, args, duration.Milliseconds(), failed)
+	return err
+}
+
+// GetCommandUsageStats aggregates command_history since the given time into
+// per-command usage, failure and latency figures, most used first. An empty
+// guildID covers every server.
+func (d *DB) GetCommandUsageStats(guildID string, since time.Time) ([]CommandUsageStat, error) {
+	query := `SELECT command, duration_ms, failed, executed_at FROM command_history WHERE executed_at >= ?`
+	args := []interface{}{since.UTC().Format("2006-01-02 15:04:05")}
+	if guildID != "" {
+		query += ` AND guild_id = ?`
+		args = append(args, guildID)
+	}
+
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byCommand := make(map[string]*CommandUsageStat)
+	durations := make(map[string][]int64)
+	for rows.Next() {
+		var command string
+		var durationMs sql.NullInt64
+		var failed sql.NullBool
+		var executedAt time.Time
+		if err := rows.Scan(&command, &durationMs, &failed, &executedAt); err != nil {
+			return nil, err
+		}
+		stat, ok := byCommand[command]
+		if !ok {
+			stat = &CommandUsageStat{Command: command}
+			byCommand[command] = stat
+		}
+		stat.Uses++
+		if failed.Bool {
+			stat.Failures++
+		}
+		if executedAt.After(stat.LastUsed) {
+			stat.LastUsed = executedAt
+		}
+		// Rows logged before timings were recorded have no duration
+		if durationMs.Valid {
+			durations[command] = append(durations[command], durationMs.Int64)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := make([]CommandUsageStat, 0, len(byCommand))
+	for command, stat := range byCommand {
+		if timings := durations[command]; len(timings) > 0 {
+			slices.Sort(timings)
+			var total int64
+			for _, ms := range timings {
+				total += ms
+			}
+			stat.AvgMs = total / int64(len(timings))
+			// Nearest-rank percentile
+			stat.P95Ms = timings[(len(timings)*95+99)/100-1]
+		}
+		stats = append(stats, *stat)
+	}
+	slices.SortFunc(stats, func(a, b CommandUsageStat) int {
+		if a.Uses != b.Uses {
+			return b.Uses - a.Uses
+		}
+		return strings.Compare(a.Command, b.Command)
+	})
+	return stats, nil
+}
+
+func (d *DB) GetCommandHistory(guildID string, limit int) ([]CommandHistory, error) {
+	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, command, args, executed_at
+		FROM command_history WHERE guild_id = ? ORDER BY executed_at DESC LIMIT ?`, guildID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]CommandHistory, 0, limit)
+	for rows.Next() {
+		var ch CommandHistory
+		if err := rows.Scan(&ch.ID, &ch.GuildID, &ch.ChannelID, &ch.UserID, &ch.Command, &ch.Args, &ch.ExecutedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, ch)
+	}
+	return history, rows.Err()
+}
+
+// GetUserCommandHistory returns a user's most recent commands in a guild
+func (d *DB) GetUserCommandHistory(guildID, userID string, limit int) ([]CommandHistory, error) {
+	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, command, args, executed_at
+		FROM command_history WHERE guild_id = ? AND user_id = ? ORDER BY executed_at DESC LIMIT ?`, guildID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []CommandHistory
+	for rows.Next() {
+		var ch CommandHistory
+		if err := rows.Scan(&ch.ID, &ch.GuildID, &ch.ChannelID, &ch.UserID, &ch.Command, &ch.Args, &ch.ExecutedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, ch)
+	}
+	return history, rows.Err()
+}
+
+func (d *DB) IsCommandHistoryOptedOut(userID string) bool {
+	var count int
+	d.QueryRow(`SELECT COUNT(*) FROM command_history_optout WHERE user_id = ?`, userID).Scan(&count)
+	return count > 0
+}
+
+// SetCommandHistoryOptOut stops or resumes recording a user's commands in every server
+func (d *DB) SetCommandHistoryOptOut(userID string, optOut bool) error {
+	if !optOut {
+		_, err := d.Exec(`DELETE FROM command_history_optout WHERE user_id = ?`, userID)
+		return err
+	}
+	_, err := d.Exec(`INSERT OR IGNORE INTO command_history_optout (user_id) VALUES (?)`, userID)
+	return err
+}
+
+// DeleteUserCommandHistory removes everything recorded about a user's commands, returning how many were removed
+func (d *DB) DeleteUserCommandHistory(userID string) (int64, error) {
+	result, err := d.Exec(`DELETE FROM command_history WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CleanOldCommandHistory drops command history older than the retention period
+func (d *DB) CleanOldCommandHistory(olderThan time.Duration) error {
+	cutoff := time.Now().Add(-olderThan).UTC().Format("2006-01-02 15:04:05")
+	_, err := d.Exec(`DELETE FROM command_history WHERE executed_at < ?`, cutoff)
+	return err
+}
+
+// Warnings
+func (d *DB) AddWarning(guildID, userID, moderatorID, reason string) error {
+	_, err := d.CreateWarning(guildID, userID, moderatorID, reason)
+	return err
+}
+
+// CreateWarning adds a warning and returns its ID
+func (d *DB) CreateWarning(guildID, userID, moderatorID, reason string) (int64, error) {
+	res, err := d.Exec(`INSERT INTO warnings (guild_id, user_id, moderator_id, reason) VALUES (?, ?, ?, ?)`,
+		guildID, userID, moderatorID, d.Encrypt(reason))
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (d *DB) GetWarnings(guildID, userID string) ([]Warning, error) {
+	rows, err := d.Query(`SELECT id, guild_id, user_id, moderator_id, reason, created_at
+		FROM warnings WHERE guild_id = ? AND user_id = ? ORDER BY created_at DESC`, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warnings []Warning
+	for rows.Next() {
+		var w Warning
+		if err := rows.Scan(&w.ID, &w.GuildID, &w.UserID, &w.ModeratorID, &w.Reason, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		w.Reason = d.DecryptNullable(w.Reason)
+		warnings = append(warnings, w)
+	}
+	return warnings, rows.Err()
+}
+
+func (d *DB) ClearWarnings(guildID, userID string) error {
+	_, err := d.Exec(`DELETE FROM warnings WHERE guild_id = ? AND user_id = ?`, guildID, userID)
+	return err
+}
+
+func (d *DB) DeleteWarning(id int64) error {
+	_, err := d.Exec(`DELETE FROM warnings WHERE id = ?`, id)
+	return err
+}
+
+// GetWarning returns one warning in a guild, or nil if there's no such warning
+func (d *DB) GetWarning(guildID string, id int64) (*Warning, error) {
+	var w Warning
+	err := d.QueryRow(`SELECT id, guild_id, user_id, moderator_id, reason, created_at
+		FROM warnings WHERE guild_id = ? AND id = ?`, guildID, id).Scan(
+		&w.ID, &w.GuildID, &w.UserID, &w.ModeratorID, &w.Reason, &w.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	w.Reason = d.DecryptNullable(w.Reason)
+	return &w, nil
+}
+
+// EditWarning changes a warning's reason and records the old and new reason in its audit trail
+func (d *DB) EditWarning(w *Warning, reason, editorID string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE warnings SET reason = ? WHERE id = ?`, d.Encrypt(reason), w.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO warning_edits (warning_id, guild_id, user_id, editor_id, action, old_reason, new_reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, w.ID, w.GuildID, w.UserID, editorID, WarningEdited,
+		d.EncryptNullable(w.Reason), d.Encrypt(reason)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveWarning deletes a warning, keeping its reason in the audit trail
+func (d *DB) RemoveWarning(w *Warning, editorID string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM warnings WHERE id = ?`, w.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO warning_edits (warning_id, guild_id, user_id, editor_id, action, old_reason)
+		VALUES (?, ?, ?, ?, ?, ?)`, w.ID, w.GuildID, w.UserID, editorID, WarningDeleted,
+		d.EncryptNullable(w.Reason)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetWarningEdits returns the audit trail of a member's warnings, oldest first
+func (d *DB) GetWarningEdits(guildID, userID string) ([]WarningEdit, error) {
+	rows, err := d.Query(`SELECT id, warning_id, guild_id, user_id, editor_id, action, old_reason, new_reason, edited_at
+		FROM warning_edits WHERE guild_id = ? AND user_id = ? ORDER BY id`, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []WarningEdit
+	for rows.Next() {
+		var e WarningEdit
+		if err := rows.Scan(&e.ID, &e.WarningID, &e.GuildID, &e.UserID, &e.EditorID, &e.Action,
+			&e.OldReason, &e.NewReason, &e.EditedAt); err != nil {
+			return nil, err
+		}
+		e.OldReason = d.DecryptNullable(e.OldReason)
+		e.NewReason = d.DecryptNullable(e.NewReason)
+		edits = append(edits, e)
+	}
+	return edits, rows.Err()
+}
+
+// Deleted Messages (for snipe)
+func (d *DB) LogDeletedMessage(guildID, channelID, userID, content string) error {
+	return d.LogDeletedMessageWithAttachments(guildID, channelID, userID, content, nil)
+}
+
+// LogDeletedMessageWithAttachments stores a deleted message along with its attachment metadata
+func (d *DB) LogDeletedMessageWithAttachments(guildID, channelID, userID, content string, attachments []DeletedAttachment) error {
+	return d.LogDeletedMessageDetails(guildID, channelID, userID, content, attachments, nil, nil)
+}
+
+// LogDeletedMessageDetails stores a deleted message with its attachments, sticker names and embed summaries
+func (d *DB) LogDeletedMessageDetails(guildID, channelID, userID, content string, attachments []DeletedAttachment, stickers, embeds []string) error {
+	attachmentData, err := d.encryptJSONList(attachments, len(attachments))
+	if err != nil {
+		return err
+	}
+	stickerData, err := d.encryptJSONList(stickers, len(stickers))
+	if err != nil {
+		return err
+	}
+	embedData, err := d.encryptJSONList(embeds, len(embeds))
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Exec(`INSERT INTO deleted_messages (guild_id, channel_id, user_id, content, attachments, stickers, embeds) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		guildID, channelID, userID, d.Encrypt(content), attachmentData, stickerData, embedData)
+	return err
+}
+
+// encryptJSONList encodes and encrypts a list for storage, or returns nil if it's empty
+func (d *DB) encryptJSONList(list interface{}, length int) (*string, error) {
+	if length == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	encoded := d.Encrypt(string(data))
+	return &encoded, nil
+}
+
+func (d *DB) GetDeletedMessages(channelID string, limit int) ([]DeletedMessage, error) {
+	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, content, attachments, stickers, embeds, deleted_at
+		FROM deleted_messages WHERE channel_id = ? ORDER BY deleted_at DESC LIMIT ?`, channelID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]DeletedMessage, 0, limit)
+	for rows.Next() {
+		var dm DeletedMessage
+		var attachments, stickers, embeds sql.NullString
+		if err := rows.Scan(&dm.ID, &dm.GuildID, &dm.ChannelID, &dm.UserID, &dm.Content, &attachments, &stickers, &embeds, &dm.DeletedAt); err != nil {
+			return nil, err
+		}
+		dm.Content = d.Decrypt(dm.Content)
+		if attachments.Valid && attachments.String != "" {
+			json.Unmarshal([]byte(d.Decrypt(attachments.String)), &dm.Attachments)
+		}
+		if stickers.Valid && stickers.String != "" {
+			json.Unmarshal([]byte(d.Decrypt(stickers.String)), &dm.Stickers)
+		}
+		if embeds.Valid && embeds.String != "" {
+			json.Unmarshal([]byte(d.Decrypt(embeds.String)), &dm.Embeds)
+		}
+		messages = append(messages, dm)
+	}
+	return messages, rows.Err()
+}
+
+func (d *DB) CleanOldDeletedMessages(olderThan time.Duration) error {
+	cutoff := time.Now().Add(-olderThan)
+	_, err := d.Exec(`DELETE FROM deleted_messages WHERE deleted_at < ?`, cutoff)
+	return err
+}
+
+func (d *DB) LogEditedMessage(guildID, channelID, messageID, userID, before, after string) error {
+	_, err := d.Exec(`INSERT INTO edited_messages (guild_id, channel_id, message_id, user_id, before_content, after_content)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		guildID, channelID, messageID, userID, d.Encrypt(before), d.Encrypt(after))
+	return err
+}
+
+func (d *DB) GetEditedMessages(channelID string, limit int) ([]EditedMessage, error) {
+	return d.queryEditedMessages(`SELECT id, guild_id, channel_id, message_id, user_id, before_content, after_content, edited_at
+		FROM edited_messages WHERE channel_id = ? ORDER BY edited_at DESC, id DESC LIMIT ?`, channelID, limit)
+}
+
+// GetMessageEditHistory returns every stored revision of a message, oldest first
+func (d *DB) GetMessageEditHistory(messageID string) ([]EditedMessage, error) {
+	return d.queryEditedMessages(`SELECT id, guild_id, channel_id, message_id, user_id, before_content, after_content, edited_at
+		FROM edited_messages WHERE message_id = ? ORDER BY edited_at ASC, id ASC`, messageID)
+}
+
+func (d *DB) queryEditedMessages(query string, args ...interface{}) ([]EditedMessage, error) {
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []EditedMessage
+	for rows.Next() {
+		var em EditedMessage
+		if err := rows.Scan(&em.ID, &em.GuildID, &em.ChannelID, &em.MessageID, &em.UserID,
+			&em.Before, &em.After, &em.EditedAt); err != nil {
+			return nil, err
+		}
+		em.Before = d.Decrypt(em.Before)
+		em.After = d.Decrypt(em.After)
+		messages = append(messages, em)
+	}
+	return messages, rows.Err()
+}
+
+func (d *DB) CleanOldEditedMessages(olderThan time.Duration) error {
+	cutoff := time.Now().Add(-olderThan)
+	_, err := d.Exec(`DELETE FROM edited_messages WHERE edited_at < ?`, cutoff)
+	return err
+}
+
+// Removed Reactions (for reactionsnipe)
+func (d *DB) IsReactionSnipeEnabled(guildID string) bool {
+	var enabled bool
+	d.QueryRow(`SELECT enabled FROM reaction_snipe_config WHERE guild_id = ?`, guildID).Scan(&enabled)
+	return enabled
+}
+
+func (d *DB) SetReactionSnipeEnabled(guildID string, enabled bool) error {
+	_, err := d.Exec(`INSERT INTO reaction_snipe_config (guild_id, enabled) VALUES (?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET enabled = excluded.enabled`, guildID, enabled)
+	if err == nil && !enabled {
+		_, err = d.Exec(`DELETE FROM removed_reactions WHERE guild_id = ?`, guildID)
+	}
+	return err
+}
+
+func (d *DB) LogRemovedReaction(r *RemovedReaction) error {
+	_, err := d.Exec(`INSERT INTO removed_reactions (guild_id, channel_id, message_id, user_id, emoji, removed_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, r.GuildID, r.ChannelID, r.MessageID, r.UserID, r.Emoji, r.RemovedAt)
+	return err
+}
+
+// GetRemovedReactions returns a channel's newest removed reactions since the given time
+func (d *DB) GetRemovedReactions(channelID string, since time.Time, limit int) ([]RemovedReaction, error) {
+	rows, err := d.Query(`SELECT guild_id, channel_id, message_id, user_id, emoji, removed_at FROM removed_reactions
+		WHERE channel_id = ? AND removed_at >= ? ORDER BY removed_at DESC, id DESC LIMIT ?`, channelID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reactions []RemovedReaction
+	for rows.Next() {
+		var r RemovedReaction
+		if err := rows.Scan(&r.GuildID, &r.ChannelID, &r.MessageID, &r.UserID, &r.Emoji, &r.RemovedAt); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, r)
+	}
+	return reactions, rows.Err()
+}
+
+func (d *DB) CleanOldRemovedReactions(olderThan time.Duration) error {
+	cutoff := time.Now().Add(-olderThan)
+	_, err := d.Exec(`DELETE FROM removed_reactions WHERE removed_at < ?`, cutoff)
+	return err
+}
+
+// Scheduled Messages
+
+// ScheduleMessage stores a message to send later and fills in its ID
+func (d *DB) ScheduleMessage(sm *ScheduledMessage) error {
+	attachments, err := d.encodeScheduledAttachments(sm.Attachments)
+	if err != nil {
+		return err
+	}
+	result, err := d.Exec(`INSERT INTO scheduled_messages (guild_id, channel_id, user_id, message, embed, attachments, scheduled_for)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		sm.GuildID, sm.ChannelID, sm.UserID, d.Encrypt(sm.Message), d.Encrypt(sm.Embed), attachments, sm.ScheduledFor)
+	if err != nil {
+		return err
+	}
+	sm.ID, err = result.LastInsertId()
+	return err
+}
+
+func (d *DB) encodeScheduledAttachments(attachments []DeletedAttachment) (string, error) {
+	if len(attachments) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(attachments)
+	if err != nil {
+		return "", err
+	}
+	return d.Encrypt(string(data)), nil
+}
+
+const scheduledMessageColumns = `id, guild_id, channel_id, user_id, message, COALESCE(embed, ''), COALESCE(attachments, ''), scheduled_for`
+
+func (d *DB) queryScheduledMessages(query string, args ...interface{}) ([]ScheduledMessage, error) {
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []ScheduledMessage
+	for rows.Next() {
+		var sm ScheduledMessage
+		var attachments string
+		if err := rows.Scan(&sm.ID, &sm.GuildID, &sm.ChannelID, &sm.UserID, &sm.Message, &sm.Embed, &attachments, &sm.ScheduledFor); err != nil {
+			return nil, err
+		}
+		sm.Message = d.Decrypt(sm.Message)
+		sm.Embed = d.Decrypt(sm.Embed)
+		if attachments != "" {
+			json.Unmarshal([]byte(d.Decrypt(attachments)), &sm.Attachments)
+		}
+		messages = append(messages, sm)
+	}
+	return messages, rows.Err()
+}
+
+func (d *DB) GetPendingScheduledMessages() ([]ScheduledMessage, error) {
+	return d.queryScheduledMessages(`SELECT `+scheduledMessageColumns+`
+		FROM scheduled_messages WHERE executed = 0 AND scheduled_for <= ? ORDER BY scheduled_for`, time.Now())
+}
+
+// GetScheduledMessage returns a message that hasn't been sent yet, nil if there is none
+func (d *DB) GetScheduledMessage(id int64) (*ScheduledMessage, error) {
+	messages, err := d.queryScheduledMessages(`SELECT `+scheduledMessageColumns+`
+		FROM scheduled_messages WHERE id = ? AND executed = 0`, id)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+// GetUpcomingScheduledMessages lists a guild's unsent messages, only userID's when it's set
+func (d *DB) GetUpcomingScheduledMessages(guildID, userID string, limit int) ([]ScheduledMessage, error) {
+	query := `SELECT ` + scheduledMessageColumns + ` FROM scheduled_messages WHERE guild_id = ? AND executed = 0`
+	args := []interface{}{guildID}
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	args = append(args, limit)
+	return d.queryScheduledMessages(query+` ORDER BY scheduled_for LIMIT ?`, args...)
+}
+
+// CountUpcomingScheduledMessages counts a member's unsent messages in a guild
+func (d *DB) CountUpcomingScheduledMessages(guildID, userID string) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM scheduled_messages WHERE guild_id = ? AND user_id = ? AND executed = 0`,
+		guildID, userID).Scan(&count)
+	return count, err
+}
+
+// UpdateScheduledMessage changes a message that hasn't been sent yet and reports whether it still could be
+func (d *DB) UpdateScheduledMessage(sm *ScheduledMessage) (bool, error) {
+	attachments, err := d.encodeScheduledAttachments(sm.Attachments)
+	if err != nil {
+		return false, err
+	}
+	result, err := d.Exec(`UPDATE scheduled_messages SET channel_id = ?, message = ?, embed = ?, attachments = ?, scheduled_for = ?
+		WHERE id = ? AND executed = 0`,
+		sm.ChannelID, d.Encrypt(sm.Message), d.Encrypt(sm.Embed), attachments, sm.ScheduledFor, sm.ID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// CancelScheduledMessage deletes a message that hasn't been sent yet and reports whether it was still pending
+func (d *DB) CancelScheduledMessage(id int64) (bool, error) {
+	result, err := d.Exec(`DELETE FROM scheduled_messages WHERE id = ? AND executed = 0`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+func (d *DB) MarkScheduledMessageExecuted(id int64) error {
+	_, err := d.Exec(`UPDATE scheduled_messages SET executed = 1 WHERE id = ?`, id)
+	return err
+}
+
+// AFK Status
+func (d *DB) SetAFK(afk *AFKStatus) error {
+	_, err := d.Exec(`INSERT INTO afk_status (user_id, message, guild_id, nick_guild_id, original_nick) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET message = excluded.message, guild_id = excluded.guild_id,
+		nick_guild_id = excluded.nick_guild_id, original_nick = excluded.original_nick, set_at = CURRENT_TIMESTAMP`,
+		afk.UserID, d.EncryptNullable(afk.Message), afk.GuildID, afk.NickGuildID, afk.OriginalNick)
+	return err
+}
+
+func (d *DB) GetAFK(userID string) (*AFKStatus, error) {
+	var afk AFKStatus
+	err := d.QueryRow(`SELECT user_id, message, set_at, COALESCE(guild_id, ''), COALESCE(nick_guild_id, ''),
+		COALESCE(original_nick, '') FROM afk_status WHERE user_id = ?`, userID).Scan(
+		&afk.UserID, &afk.Message, &afk.SetAt, &afk.GuildID, &afk.NickGuildID, &afk.OriginalNick)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err == nil {
+		afk.Message = d.DecryptNullable(afk.Message)
+	}
+	return &afk, err
+}
+
+func (d *DB) RemoveAFK(userID string) error {
+	_, err := d.Exec(`DELETE FROM afk_status WHERE user_id = ?`, userID)
+	return err
+}
+
+// AddAFKMention records a message that mentioned an AFK member
+func (d *DB) AddAFKMention(am *AFKMention) error {
+	_, err := d.Exec(`INSERT INTO afk_mentions (user_id, guild_id, channel_id, message_id, author_id, content)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		am.UserID, am.GuildID, am.ChannelID, am.MessageID, am.AuthorID, d.Encrypt(am.Content))
+	return err
+}
+
+// GetAFKMentions returns the mentions a member got while AFK, oldest first
+func (d *DB) GetAFKMentions(userID string) ([]AFKMention, error) {
+	rows, err := d.Query(`SELECT id, user_id, guild_id, channel_id, message_id, author_id, content, created_at
+		FROM afk_mentions WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mentions []AFKMention
+	for rows.Next() {
+		var am AFKMention
+		if err := rows.Scan(&am.ID, &am.UserID, &am.GuildID, &am.ChannelID, &am.MessageID, &am.AuthorID,
+			&am.Content, &am.CreatedAt); err != nil {
+			return nil, err
+		}
+		am.Content = d.Decrypt(am.Content)
+		mentions = append(mentions, am)
+	}
+	return mentions, rows.Err()
+}
+
+func (d *DB) ClearAFKMentions(userID string) error {
+	_, err := d.Exec(`DELETE FROM afk_mentions WHERE user_id = ?`, userID)
+	return err
+}
+
+// Reminders
+func (d *DB) AddReminder(userID, channelID, message string, remindAt time.Time) error {
+	_, err := d.Exec(`INSERT INTO reminders (user_id, channel_id, message, remind_at) VALUES (?, ?, ?, ?)`,
+		userID, channelID, d.Encrypt(message), remindAt)
+	return err
+}
+
+func (d *DB) GetPendingReminders() ([]Reminder, error) {
+	rows, err := d.Query(`SELECT id, user_id, channel_id, message, remind_at
+		FROM reminders WHERE completed = 0 AND remind_at <= ? ORDER BY remind_at`, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		var r Reminder
+		if err := rows.Scan(&r.ID, &r.UserID, &r.ChannelID, &r.Message, &r.RemindAt); err != nil {
+			return nil, err
+		}
+		r.Message = d.Decrypt(r.Message)
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
+
+func (d *DB) MarkReminderCompleted(id int64) error {
+	_, err := d.Exec(`UPDATE reminders SET completed = 1 WHERE id = ?`, id)
+	return err
+}
+
+// Tags
+// GetTag returns a tag by its name or one of its aliases
+func (d *DB) GetTag(guildID, name string) (*Tag, error) {
+	var t Tag
+	err := d.QueryRow(`SELECT id, guild_id, name, content, created_by, use_count, created_at, COALESCE(embed, '')
+		FROM tags WHERE guild_id = ? AND (name = ? OR id = (SELECT tag_id FROM tag_aliases WHERE guild_id = ? AND alias = ?))
+		ORDER BY name = ? DESC LIMIT 1`, guildID, name, guildID, name, name).Scan(
+		&t.ID, &t.GuildID, &t.Name, &t.Content, &t.CreatedBy, &t.UseCount, &t.CreatedAt, &t.Embed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err == nil {
+		t.Content = d.Decrypt(t.Content)
+		t.Embed = d.Decrypt(t.Embed)
+	}
+	return &t, err
+}
+
+// TagNameTaken reports whether a name is already used by a tag or alias
+func (d *DB) TagNameTaken(guildID, name string) (bool, error) {
+	var count int
+	err := d.QueryRow(`SELECT (SELECT COUNT(*) FROM tags WHERE guild_id = ? AND name = ?) +
+		(SELECT COUNT(*) FROM tag_aliases WHERE guild_id = ? AND alias = ?)`,
+		guildID, name, guildID, name).Scan(&count)
+	return count > 0, err
+}
+
+func (d *DB) CreateTagAlias(guildID, alias string, tagID int64, createdBy string) error {
+	_, err := d.Exec(`INSERT INTO tag_aliases (guild_id, alias, tag_id, created_by) VALUES (?, ?, ?, ?)`,
+		guildID, alias, tagID, createdBy)
+	return err
+}
+
+// DeleteTagAlias removes an alias, reporting whether it existed
+func (d *DB) DeleteTagAlias(guildID, alias string) (bool, error) {
+	result, err := d.Exec(`DELETE FROM tag_aliases WHERE guild_id = ? AND alias = ?`, guildID, alias)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetTagAliases returns a guild's aliases keyed by tag ID
+func (d *DB) GetTagAliases(guildID string) (map[int64][]string, error) {
+	rows, err := d.Query(`SELECT tag_id, alias FROM tag_aliases WHERE guild_id = ? ORDER BY alias`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[int64][]string)
+	for rows.Next() {
+		var tagID int64
+		var alias string
+		if err := rows.Scan(&tagID, &alias); err != nil {
+			return nil, err
+		}
+		aliases[tagID] = append(aliases[tagID], alias)
+	}
+	return aliases, rows.Err()
+}
+
+// SetTagOwner transfers a tag to another member
+func (d *DB) SetTagOwner(tagID int64, userID string) error {
+	_, err := d.Exec(`UPDATE tags SET created_by = ? WHERE id = ?`, userID, tagID)
+	return err
+}
+
+func (d *DB) CreateTag(guildID, name, content, createdBy string) error {
+	_, err := d.Exec(`INSERT INTO tags (guild_id, name, content, created_by) VALUES (?, ?, ?, ?)`,
+		guildID, name, d.Encrypt(content), createdBy)
+	return err
+}
+
+func (d *DB) DeleteTag(guildID, name string) error {
+	_, err := d.Exec(`DELETE FROM tag_aliases WHERE tag_id IN (SELECT id FROM tags WHERE guild_id = ? AND name = ?)`, guildID, name)
+	if err != nil {
+		return err
+	}
+	_, err = d.Exec(`DELETE FROM tags WHERE guild_id = ? AND name = ?`, guildID, name)
+	return err
+}
+
+func (d *DB) ListTags(guildID string) ([]Tag, error) {
+	rows, err := d.Query(`SELECT id, guild_id, name, content, created_by, use_count, created_at, COALESCE(embed, '')
+		FROM tags WHERE guild_id = ? ORDER BY name`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.ID, &t.GuildID, &t.Name, &t.Content, &t.CreatedBy, &t.UseCount, &t.CreatedAt, &t.Embed); err != nil {
+			return nil, err
+		}
+		t.Content = d.Decrypt(t.Content)
+		t.Embed = d.Decrypt(t.Embed)
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// SetTagEmbed stores the raw embed JSON sent with a tag, empty clears it
+func (d *DB) SetTagEmbed(tagID int64, embedJSON string) error {
+	_, err := d.Exec(`UPDATE tags SET embed = ? WHERE id = ?`, d.Encrypt(embedJSON), tagID)
+	return err
+}
+
+func (d *DB) IncrementTagUse(guildID, name string) error {
+	_, err := d.Exec(`UPDATE tags SET use_count = use_count + 1 WHERE guild_id = ? AND name = ?`,
+		guildID, name)
+	return err
+}
+
+// Keyword Notifications
+func (d *DB) AddKeywordNotification(kn *KeywordNotification) error {
+	_, err := d.Exec(`INSERT INTO keyword_notifications (user_id, guild_id, keyword, match_mode, case_sensitive)
+		VALUES (?, ?, ?, ?, ?)`,
+		kn.UserID, kn.GuildID, kn.Keyword, kn.MatchMode, kn.CaseSensitive)
+	return err
+}
+
+const keywordNotificationColumns = `id, user_id, guild_id, keyword, COALESCE(match_mode, 'contains'), COALESCE(case_sensitive, 0)`
+
+func scanKeywordNotifications(rows *sql.Rows) ([]KeywordNotification, error) {
+	defer rows.Close()
+
+	var notifications []KeywordNotification
+	for rows.Next() {
+		var kn KeywordNotification
+		if err := rows.Scan(&kn.ID, &kn.UserID, &kn.GuildID, &kn.Keyword, &kn.MatchMode, &kn.CaseSensitive); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, kn)
+	}
+	return notifications, rows.Err()
+}
+
+func (d *DB) RemoveKeywordNotification(userID, keyword string) error {
+	_, err := d.Exec(`DELETE FROM keyword_notifications WHERE user_id = ? AND keyword = ?`, userID, keyword)
+	return err
+}
+
+func (d *DB) GetKeywordNotifications(userID string) ([]KeywordNotification, error) {
+	rows, err := d.Query(`SELECT `+keywordNotificationColumns+` FROM keyword_notifications WHERE user_id = ? ORDER BY keyword`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanKeywordNotifications(rows)
+}
+
+// GetGuildKeywordNotifications returns the keywords that apply in a guild, including ones tracked everywhere
+func (d *DB) GetGuildKeywordNotifications(guildID string) ([]KeywordNotification, error) {
+	rows, err := d.Query(`SELECT `+keywordNotificationColumns+` FROM keyword_notifications
+		WHERE guild_id = ? OR guild_id IS NULL OR guild_id = ''`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return scanKeywordNotifications(rows)
+}
+
+func (d *DB) GetAllKeywordNotifications() ([]KeywordNotification, error) {
+	rows, err := d.Query(`SELECT ` + keywordNotificationColumns + ` FROM keyword_notifications`)
+	if err != nil {
+		return nil, err
+	}
+	return scanKeywordNotifications(rows)
+}
+
+// GetKeywordSettings returns a member's keyword alert preferences, or the defaults if they haven't set any
+func (d *DB) GetKeywordSettings(userID string) (*KeywordSettings, error) {
+	ks := KeywordSettings{UserID: userID}
+	var ignored string
+	err := d.QueryRow(`SELECT quiet_start, quiet_end, digest_minutes, COALESCE(ignored_channels, '')
+		FROM keyword_settings WHERE user_id = ?`, userID).Scan(
+		&ks.QuietStart, &ks.QuietEnd, &ks.DigestMinutes, &ignored)
+	if err == sql.ErrNoRows {
+		return &KeywordSettings{UserID: userID, QuietStart: -1, QuietEnd: -1}, nil
+	}
+	if ignored != "" {
+		ks.IgnoredChannels = strings.Split(ignored, ",")
+	}
+	return &ks, err
+}
+
+func (d *DB) SetKeywordSettings(ks *KeywordSettings) error {
+	_, err := d.Exec(`INSERT INTO keyword_settings (user_id, quiet_start, quiet_end, digest_minutes, ignored_channels)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET quiet_start = excluded.quiet_start, quiet_end = excluded.quiet_end,
+		digest_minutes = excluded.digest_minutes, ignored_channels = excluded.ignored_channels`,
+		ks.UserID, ks.QuietStart, ks.QuietEnd, ks.DigestMinutes, strings.Join(ks.IgnoredChannels, ","))
+	return err
+}
+
+// QueueKeywordDigest holds a keyword alert back to be sent with others later
+func (d *DB) QueueKeywordDigest(e *KeywordDigestEntry) error {
+	_, err := d.Exec(`INSERT INTO keyword_digest (user_id, guild_id, channel_id, message_id, author_id, keyword, content)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.UserID, e.GuildID, e.ChannelID, e.MessageID, e.AuthorID, e.Keyword, d.Encrypt(e.Content))
+	return err
+}
+
+// GetKeywordDigests returns every held back keyword alert, oldest first
+func (d *DB) GetKeywordDigests() ([]KeywordDigestEntry, error) {
+	rows, err := d.Query(`SELECT id, user_id, guild_id, channel_id, message_id, author_id, keyword, content, created_at
+		FROM keyword_digest ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []KeywordDigestEntry
+	for rows.Next() {
+		var e KeywordDigestEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.GuildID, &e.ChannelID, &e.MessageID, &e.AuthorID,
+			&e.Keyword, &e.Content, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Content = d.Decrypt(e.Content)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ClearKeywordDigest removes a member's held back alerts up to and including lastID once they're sent
+func (d *DB) ClearKeywordDigest(userID string, lastID int64) error {
+	_, err := d.Exec(`DELETE FROM keyword_digest WHERE user_id = ? AND id <= ?`, userID, lastID)
+	return err
+}
+
+// ============ User Notes ============
+
+// AddUserNote saves a staff note about a member and returns its ID
+func (d *DB) AddUserNote(guildID, userID, note, createdBy string) (int64, error) {
+	result, err := d.Exec(`INSERT INTO user_notes (guild_id, user_id, note, created_by) VALUES (?, ?, ?, ?)`,
+		guildID, userID, d.Encrypt(note), createdBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetUserNote returns one note in a guild, or nil if there's no such note
+func (d *DB) GetUserNote(guildID string, id int64) (*UserNote, error) {
+	var n UserNote
+	err := d.QueryRow(`SELECT id, guild_id, user_id, note, created_by, created_at, COALESCE(updated_by, ''), updated_at
+		FROM user_notes WHERE guild_id = ? AND id = ?`, guildID, id).Scan(
+		&n.ID, &n.GuildID, &n.UserID, &n.Note, &n.CreatedBy, &n.CreatedAt, &n.UpdatedBy, &n.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	n.Note = d.Decrypt(n.Note)
+	return &n, nil
+}
+
+// GetUserNotesPage returns a page of a member's notes, newest first
+func (d *DB) GetUserNotesPage(guildID, userID string, limit, offset int) ([]UserNote, error) {
+	rows, err := d.Query(`SELECT id, guild_id, user_id, note, created_by, created_at, COALESCE(updated_by, ''), updated_at
+		FROM user_notes WHERE guild_id = ? AND user_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		guildID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []UserNote
+	for rows.Next() {
+		var n UserNote
+		if err := rows.Scan(&n.ID, &n.GuildID, &n.UserID, &n.Note, &n.CreatedBy, &n.CreatedAt, &n.UpdatedBy, &n.UpdatedAt); err != nil {
+			return nil, err
+		}
+		n.Note = d.Decrypt(n.Note)
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// CountUserNotes returns how many notes a member has in a guild
+func (d *DB) CountUserNotes(guildID, userID string) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM user_notes WHERE guild_id = ? AND user_id = ?`, guildID, userID).Scan(&count)
+	return count, err
+}
+
+// UpdateUserNote replaces a note's text and records who edited it
+func (d *DB) UpdateUserNote(guildID string, id int64, note, updatedBy string) error {
+	_, err := d.Exec(`UPDATE user_notes SET note = ?, updated_by = ?, updated_at = CURRENT_TIMESTAMP WHERE guild_id = ? AND id = ?`,
+		d.Encrypt(note), updatedBy, guildID, id)
+	return err
+}
+
+// DeleteUserNote removes a note from a guild
+func (d *DB) DeleteUserNote(guildID string, id int64) error {
+	_, err := d.Exec(`DELETE FROM user_notes WHERE guild_id = ? AND id = ?`, guildID, id)
+	return err
+}
+
+// ============ XP/Leveling System ============
+
+func (d *DB) GetUserXP(guildID, userID string) (*UserXP, error) {
+	var ux UserXP
+	err := d.QueryRow(`SELECT guild_id, user_id, xp, level, updated_at FROM user_xp WHERE guild_id = ? AND user_id = ?`,
+		guildID, userID).Scan(&ux.GuildID, &ux.UserID, &ux.XP, &ux.Level, &ux.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return &UserXP{GuildID: guildID, UserID: userID, XP: 0, Level: 0}, nil
+	}
+	return &ux, err
+}
+
+func (d *DB) SetUserXP(guildID, userID string, xp int64, level int) error {
+	_, err := d.Exec(`INSERT INTO user_xp (guild_id, user_id, xp, level, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(guild_id, user_id) DO UPDATE SET
+		xp = excluded.xp, level = excluded.level, updated_at = CURRENT_TIMESTAMP`,
+		guildID, userID, xp, level)
+	return err
+}
+
+func (d *DB) AddUserXP(guildID, userID string, amount int64) (*UserXP, error) {
+	ux, err := d.GetUserXP(guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	ux.XP += amount
+	// Calculate new level
+	ux.Level = CalculateLevel(ux.XP)
+	if err := d.SetUserXP(guildID, userID, ux.XP, ux.Level); err != nil {
+		return ux, err
+	}
+	if amount > 0 {
+		_, err = d.Exec(`INSERT INTO xp_history (guild_id, user_id, day, xp) VALUES (?, ?, date('now'), ?)
+			ON CONFLICT(guild_id, user_id, day) DO UPDATE SET xp = xp + excluded.xp`,
+			guildID, userID, amount)
+	}
+	return ux, err
+}
+
+func (d *DB) GetGuildLeaderboard(guildID string, limit int) ([]UserXP, error) {
+	rows, err := d.Query(`SELECT guild_id, user_id, xp, level, updated_at FROM user_xp
+		WHERE guild_id = ? ORDER BY xp DESC LIMIT ?`, guildID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaderboard := make([]UserXP, 0, limit)
+	for rows.Next() {
+		var ux UserXP
+		if err := rows.Scan(&ux.GuildID, &ux.UserID, &ux.XP, &ux.Level, &ux.UpdatedAt); err != nil {
+			return nil, err
+		}
+		leaderboard = append(leaderboard, ux)
+	}
+	return leaderboard, rows.Err()
+}
+
+// xpLeaderboardQuery ranks members by total XP, or by XP gained in the last days days when days > 0
+func xpLeaderboardQuery(days int) (string, []interface{}) {
+	if days <= 0 {
+		return `SELECT user_id, xp, level FROM user_xp WHERE guild_id = ? AND xp > 0`, nil
+	}
+	return `SELECT h.user_id AS user_id, SUM(h.xp) AS xp, COALESCE(MAX(u.level), 0) AS level FROM xp_history h
+		LEFT JOIN user_xp u ON u.guild_id = h.guild_id AND u.user_id = h.user_id
+		WHERE h.guild_id = ? AND h.day > date('now', ?)
+		GROUP BY h.user_id`, []interface{}{fmt.Sprintf("-%d days", days)}
+}
+
+// GetXPLeaderboardPage returns one page of the leaderboard. For periodic views XP is the amount gained in the period.
+func (d *DB) GetXPLeaderboardPage(guildID string, days, limit, offset int) ([]UserXP, error) {
+	query, args := xpLeaderboardQuery(days)
+	args = append([]interface{}{guildID}, args...)
+	rows, err := d.Query(`SELECT user_id, xp, level FROM (`+query+`) ORDER BY xp DESC, user_id LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leaderboard []UserXP
+	for rows.Next() {
+		ux := UserXP{GuildID: guildID}
+		if err := rows.Scan(&ux.UserID, &ux.XP, &ux.Level); err != nil {
+			return nil, err
+		}
+		leaderboard = append(leaderboard, ux)
+	}
+	return leaderboard, rows.Err()
+}
+
+// CountXPLeaderboard returns how many members are on the leaderboard
+func (d *DB) CountXPLeaderboard(guildID string, days int) (int, error) {
+	query, args := xpLeaderboardQuery(days)
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM (`+query+`)`, append([]interface{}{guildID}, args...)...).Scan(&count)
+	return count, err
+}
+
+// GetXPLeaderboardPosition returns a member's 1-based position on the leaderboard, or 0 if they aren't on it
+func (d *DB) GetXPLeaderboardPosition(guildID, userID string, days int) (int, error) {
+	query, args := xpLeaderboardQuery(days)
+	args = append([]interface{}{guildID}, args...)
+	var position int
+	err := d.QueryRow(`SELECT pos FROM (
+		SELECT user_id, ROW_NUMBER() OVER (ORDER BY xp DESC, user_id) AS pos FROM (`+query+`)
+	) WHERE user_id = ?`, append(args, userID)...).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return position, err
+}
+
+func (d *DB) GetUserRank(guildID, userID string) (int, error) {
+	var rank int
+	err := d.QueryRow(`SELECT COUNT(*) + 1 FROM user_xp WHERE guild_id = ? AND xp > (
+		SELECT COALESCE(xp, 0) FROM user_xp WHERE guild_id = ? AND user_id = ?
+	)`, guildID, guildID, userID).Scan(&rank)
+	return rank, err
+}
+
+// CalculateLevel calculates level from XP using formula: level = floor((sqrt(1 + 8*xp/50) - 1) / 2)
+func CalculateLevel(xp int64) int {
+	if xp <= 0 {
+		return 0
+	}
+	// level = floor((sqrt(1 + 8*xp/50) - 1) / 2)
+	import_val := float64(1 + 8*xp/50)
+	level := int((sqrt(import_val) - 1) / 2)
+	if level < 0 {
+		return 0
+	}
+	return level
+}
+
+// XPForLevel returns XP needed for a specific level
+func XPForLevel(level int) int64 {
+	// XP = 5*level^2 + 50*level + 100
+	return int64(5*level*level + 50*level + 100)
+}
+
+// ============ Regex Filters ============
+
+func (d *DB) AddRegexFilter(guildID, pattern, action, reason, createdBy string) error {
+	_, err := d.Exec(`INSERT INTO regex_filters (guild_id, pattern, action, reason, created_by) VALUES (?, ?, ?, ?, ?)`,
+		guildID, pattern, action, d.Encrypt(reason), createdBy)
+	return err
+}
+
+func (d *DB) RemoveRegexFilter(guildID string, id int64) error {
+	_, err := d.Exec(`DELETE FROM regex_filters WHERE guild_id = ? AND id = ?`, guildID, id)
+	return err
+}
+
+func (d *DB) GetRegexFilters(guildID string) ([]RegexFilter, error) {
+	rows, err := d.Query(`SELECT id, guild_id, pattern, action, reason, created_by, created_at
+		FROM regex_filters WHERE guild_id = ? ORDER BY id`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var filters []RegexFilter
+	for rows.Next() {
+		var f RegexFilter
+		if err := rows.Scan(&f.ID, &f.GuildID, &f.Pattern, &f.Action, &f.Reason, &f.CreatedBy, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		f.Reason = d.Decrypt(f.Reason)
+		filters = append(filters, f)
+	}
+	return filters, rows.Err()
+}
+
+// ============ Auto-Clean Channels ============
+
+func (d *DB) AddAutoCleanChannel(guildID, channelID, createdBy string, intervalHours, warningMinutes int) error {
+	nextRun := time.Now().Add(time.Duration(intervalHours) * time.Hour)
+	_, err := d.Exec(`INSERT INTO autoclean_channels (guild_id, channel_id, interval_hours, warning_minutes, next_run, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, channel_id) DO UPDATE SET
+		interval_hours = excluded.interval_hours, warning_minutes = excluded.warning_minutes, next_run = excluded.next_run`,
+		guildID, channelID, intervalHours, warningMinutes, nextRun, createdBy)
+	return err
+}
+
+func (d *DB) RemoveAutoCleanChannel(guildID, channelID string) error {
+	_, err := d.Exec(`DELETE FROM autoclean_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID)
+	return err
+}
+
+func (d *DB) GetAutoCleanChannels(guildID string) ([]AutoCleanChannel, error) {
+	rows, err := d.Query(`SELECT id, guild_id, channel_id, interval_hours, warning_minutes, next_run, clean_message, clean_image, created_by, created_at
+		FROM autoclean_channels WHERE guild_id = ? ORDER BY id`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []AutoCleanChannel
+	for rows.Next() {
+		var c AutoCleanChannel
+		if err := rows.Scan(&c.ID, &c.GuildID, &c.ChannelID, &c.IntervalHours, &c.WarningMinutes, &c.NextRun, &c.CleanMessage, &c.CleanImage, &c.CreatedBy, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, rows.Err()
+}
+
+func (d *DB) GetPendingAutoCleanChannels() ([]AutoCleanChannel, error) {
+	rows, err := d.Query(`SELECT id, guild_id, channel_id, interval_hours, warning_minutes, next_run, clean_message, clean_image, created_by, created_at
+		FROM autoclean_channels WHERE next_run <= ? ORDER BY next_run`, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []AutoCleanChannel
+	for rows.Next() {
+		var c AutoCleanChannel
+		if err := rows.Scan(&c.ID, &c.GuildID, &c.ChannelID, &c.IntervalHours, &c.WarningMinutes, &c.NextRun, &c.CleanMessage, &c.CleanImage, &c.CreatedBy, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, rows.Err()
+}
+
+func (d *DB) UpdateAutoCleanNextRun(id int64, nextRun time.Time) error {
+	_, err := d.Exec(`UPDATE autoclean_channels SET next_run = ? WHERE id = ?`, nextRun, id)
+	return err
+}
+
+func (d *DB) SetAutoCleanMessage(guildID, channelID string, enabled bool) error {
+	val := 0
+	if enabled {
+		val = 1
+	}
+	_, err := d.Exec(`UPDATE autoclean_channels SET clean_message = ? WHERE guild_id = ? AND channel_id = ?`,
+		val, guildID, channelID)
+	return err
+}
+
+func (d *DB) SetAutoCleanImage(guildID, channelID string, enabled bool) error {
+	val := 0
+	if enabled {
+		val = 1
+	}
+	_, err := d.Exec(`UPDATE autoclean_channels SET clean_image = ? WHERE guild_id = ? AND channel_id = ?`,
+		val, guildID, channelID)
+	return err
+}
+
+// ============ Stats Channels ============
+
+const statsChannelColumns = `channel_id, guild_id, kind, COALESCE(template, ''), COALESCE(goal, 0), created_by, created_at`
+
+func scanStatsChannel(scanner interface{ Scan(...interface{}) error }) (*StatsChannel, error) {
+	var c StatsChannel
+	err := scanner.Scan(&c.ChannelID, &c.GuildID, &c.Kind, &c.Template, &c.Goal, &c.CreatedBy, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (d *DB) queryStatsChannels(query string, args ...interface{}) ([]*StatsChannel, error) {
+	rows, err := d.Query(`SELECT `+statsChannelColumns+` FROM stats_channels `+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []*StatsChannel
+	for rows.Next() {
+		c, err := scanStatsChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, rows.Err()
+}
+
+func (d *DB) SetStatsChannel(c *StatsChannel) error {
+	_, err := d.Exec(`INSERT INTO stats_channels (channel_id, guild_id, kind, template, goal, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(channel_id) DO UPDATE SET kind = excluded.kind, template = excluded.template, goal = excluded.goal`,
+		c.ChannelID, c.GuildID, c.Kind, c.Template, c.Goal, c.CreatedBy)
+	return err
+}
+
+// GetStatsChannel returns a stats channel of a guild, nil if the channel isn't one
+func (d *DB) GetStatsChannel(guildID, channelID string) (*StatsChannel, error) {
+	c, err := scanStatsChannel(d.QueryRow(`SELECT `+statsChannelColumns+`
+		FROM stats_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (d *DB) GetStatsChannels(guildID string) ([]*StatsChannel, error) {
+	return d.queryStatsChannels(`WHERE guild_id = ? ORDER BY created_at`, guildID)
+}
+
+// GetAllStatsChannels returns every stats channel, for the periodic rename
+func (d *DB) GetAllStatsChannels() ([]*StatsChannel, error) {
+	return d.queryStatsChannels(`ORDER BY guild_id, created_at`)
+}
+
+func (d *DB) DeleteStatsChannel(channelID string) error {
+	_, err := d.Exec(`DELETE FROM stats_channels WHERE channel_id = ?`, channelID)
+	return err
+}
+
+// ============ Auto-Thread Channels ============
+
+const autoThreadColumns = `guild_id, channel_id, COALESCE(mode, 'all'), COALESCE(name_template, ''),
+	COALESCE(archive_minutes, 1440), created_by, created_at`
+
+func scanAutoThreadChannel(scanner interface{ Scan(...interface{}) error }) (*AutoThreadChannel, error) {
+	var c AutoThreadChannel
+	err := scanner.Scan(&c.GuildID, &c.ChannelID, &c.Mode, &c.NameTemplate, &c.ArchiveMinutes, &c.CreatedBy, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (d *DB) SetAutoThreadChannel(c *AutoThreadChannel) error {
+	_, err := d.Exec(`INSERT INTO autothread_channels (guild_id, channel_id, mode, name_template, archive_minutes, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, channel_id) DO UPDATE SET
+		mode = excluded.mode, name_template = excluded.name_template, archive_minutes = excluded.archive_minutes`,
+		c.GuildID, c.ChannelID, c.Mode, c.NameTemplate, c.ArchiveMinutes, c.CreatedBy)
+	return err
+}
+
+func (d *DB) RemoveAutoThreadChannel(guildID, channelID string) (bool, error) {
+	result, err := d.Exec(`DELETE FROM autothread_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetAutoThreadChannel returns a channel's auto-thread settings, nil if it has none
+func (d *DB) GetAutoThreadChannel(guildID, channelID string) (*AutoThreadChannel, error) {
+	c, err := scanAutoThreadChannel(d.QueryRow(`SELECT `+autoThreadColumns+`
+		FROM autothread_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (d *DB) GetAutoThreadChannels(guildID string) ([]*AutoThreadChannel, error) {
+	rows, err := d.Query(`SELECT `+autoThreadColumns+`
+		FROM autothread_channels WHERE guild_id = ? ORDER BY created_at`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []*AutoThreadChannel
+	for rows.Next() {
+		c, err := scanAutoThreadChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, rows.Err()
+}
+
+// ============ GitHub Relays ============
+
+const gitHubRelayColumns = `guild_id, repo, channel_id, COALESCE(events, ''), secret, created_by, created_at`
+
+func (d *DB) queryGitHubRelays(query string, args ...interface{}) ([]*GitHubRelay, error) {
+	rows, err := d.Query(`SELECT `+gitHubRelayColumns+` FROM github_relays `+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relays []*GitHubRelay
+	for rows.Next() {
+		var r GitHubRelay
+		if err := rows.Scan(&r.GuildID, &r.Repo, &r.ChannelID, &r.Events, &r.Secret, &r.CreatedBy, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.Secret = d.Decrypt(r.Secret)
+		relays = append(relays, &r)
+	}
+	return relays, rows.Err()
+}
+
+// SetGitHubRelay adds or replaces a guild's relay for a repository. Repo names
+// are stored lowercased as GitHub treats them case-insensitively.
+func (d *DB) SetGitHubRelay(r *GitHubRelay) error {
+	_, err := d.Exec(`INSERT INTO github_relays (guild_id, repo, channel_id, events, secret, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, repo) DO UPDATE SET
+		channel_id = excluded.channel_id, events = excluded.events, secret = excluded.secret`,
+		r.GuildID, strings.ToLower(r.Repo), r.ChannelID, r.Events, d.Encrypt(r.Secret), r.CreatedBy)
+	return err
+}
+
+func (d *DB) RemoveGitHubRelay(guildID, repo string) (bool, error) {
+	result, err := d.Exec(`DELETE FROM github_relays WHERE guild_id = ? AND repo = ?`, guildID, strings.ToLower(repo))
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetGitHubRelay returns a guild's relay for a repository, nil if there is none
+func (d *DB) GetGitHubRelay(guildID, repo string) (*GitHubRelay, error) {
+	relays, err := d.queryGitHubRelays(`WHERE guild_id = ? AND repo = ?`, guildID, strings.ToLower(repo))
+	if err != nil || len(relays) == 0 {
+		return nil, err
+	}
+	return relays[0], nil
+}
+
+func (d *DB) GetGitHubRelays(guildID string) ([]*GitHubRelay, error) {
+	return d.queryGitHubRelays(`WHERE guild_id = ? ORDER BY repo`, guildID)
+}
+
+// GetGitHubRelaysForRepo returns every guild's relay for a repository
+func (d *DB) GetGitHubRelaysForRepo(repo string) ([]*GitHubRelay, error) {
+	return d.queryGitHubRelays(`WHERE repo = ?`, strings.ToLower(repo))
+}
+
+// ============ Logging Configuration ============
+
+func (d *DB) GetLoggingConfig(guildID string) (*LoggingConfig, error) {
+	var lc LoggingConfig
+	err := d.QueryRow(`SELECT guild_id, log_channel_id, enabled, message_delete, message_edit,
+		voice_join, voice_leave, nickname_change, avatar_change, presence_change, presence_batch_mins,
+		role_change, moderation, message_log_channel_id, member_log_channel_id, voice_log_channel_id,
+		mod_log_channel_id, automod_log_channel_id, archive_attachments,
+		voice_move, voice_mute, voice_stream, voice_session, use_webhooks
+		FROM logging_config WHERE guild_id = ?`, guildID).Scan(
+		&lc.GuildID, &lc.LogChannelID, &lc.Enabled, &lc.MessageDelete, &lc.MessageEdit,
+		&lc.VoiceJoin, &lc.VoiceLeave, &lc.NicknameChange, &lc.AvatarChange, &lc.PresenceChange, &lc.PresenceBatchMins,
+		&lc.RoleChange, &lc.Moderation, &lc.MessageLogChannelID, &lc.MemberLogChannelID, &lc.VoiceLogChannelID,
+		&lc.ModLogChannelID, &lc.AutomodLogChannelID, &lc.ArchiveAttachments,
+		&lc.VoiceMove, &lc.VoiceMute, &lc.VoiceStream, &lc.VoiceSession, &lc.UseWebhooks)
+	if err == sql.ErrNoRows {
+		return &LoggingConfig{GuildID: guildID}, nil
+	}
+	return &lc, err
+}
+
+func (d *DB) SetLoggingConfig(lc *LoggingConfig) error {
+	_, err := d.Exec(`INSERT INTO logging_config (guild_id, log_channel_id, enabled, message_delete, message_edit,
+		voice_join, voice_leave, nickname_change, avatar_change, presence_change, presence_batch_mins,
+		role_change, moderation, message_log_channel_id, member_log_channel_id, voice_log_channel_id,
+		mod_log_channel_id, automod_log_channel_id, archive_attachments,
+		voice_move, voice_mute, voice_stream, voice_session, use_webhooks)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		log_channel_id = excluded.log_channel_id, enabled = excluded.enabled,
+		message_delete = excluded.message_delete, message_edit = excluded.message_edit,
+		voice_join = excluded.voice_join, voice_leave = excluded.voice_leave,
+		nickname_change = excluded.nickname_change, avatar_change = excluded.avatar_change,
+		presence_change = excluded.presence_change, presence_batch_mins = excluded.presence_batch_mins,
+		role_change = excluded.role_change, moderation = excluded.moderation,
+		message_log_channel_id = excluded.message_log_channel_id, member_log_channel_id = excluded.member_log_channel_id,
+		voice_log_channel_id = excluded.voice_log_channel_id, mod_log_channel_id = excluded.mod_log_channel_id,
+		automod_log_channel_id = excluded.automod_log_channel_id, archive_attachments = excluded.archive_attachments,
+		voice_move = excluded.voice_move, voice_mute = excluded.voice_mute,
+		voice_stream = excluded.voice_stream, voice_session = excluded.voice_session,
+		use_webhooks = excluded.use_webhooks`,
+		lc.GuildID, lc.LogChannelID, lc.Enabled, lc.MessageDelete, lc.MessageEdit,
+		lc.VoiceJoin, lc.VoiceLeave, lc.NicknameChange, lc.AvatarChange, lc.PresenceChange, lc.PresenceBatchMins,
+		lc.RoleChange, lc.Moderation, lc.MessageLogChannelID, lc.MemberLogChannelID, lc.VoiceLogChannelID,
+		lc.ModLogChannelID, lc.AutomodLogChannelID, lc.ArchiveAttachments,
+		lc.VoiceMove, lc.VoiceMute, lc.VoiceStream, lc.VoiceSession, lc.UseWebhooks)
+	return err
+}
+
+func (d *DB) SetLogChannel(guildID, channelID string) error {
+	_, err := d.Exec(`INSERT INTO logging_config (guild_id, log_channel_id, enabled)
+		VALUES (?, ?, 1)
+		ON CONFLICT(guild_id) DO UPDATE SET log_channel_id = excluded.log_channel_id, enabled = 1`,
+		guildID, channelID)
+	return err
+}
+
+// logChannelColumns maps log categories to their routing column
+var logChannelColumns = map[string]string{
+	"message": "message_log_channel_id",
+	"member":  "member_log_channel_id",
+	"voice":   "voice_log_channel_id",
+	"mod":     "mod_log_channel_id",
+	"automod": "automod_log_channel_id",
+}
+
+// SetCategoryLogChannel routes a log category to its own channel. An empty channelID clears the route.
+func (d *DB) SetCategoryLogChannel(guildID, category, channelID string) error {
+	column, ok := logChannelColumns[category]
+	if !ok {
+		return fmt.Errorf("unknown log category: %s", category)
+	}
+
+	var value interface{}
+	if channelID != "" {
+		value = channelID
+	}
+
+	_, err := d.Exec(`INSERT INTO logging_config (guild_id, `+column+`)
+		VALUES (?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET `+column+` = excluded.`+column,
+		guildID, value)
+	return err
+}
+
+func (d *DB) ToggleLogging(guildID string, enabled bool) error {
+	val := 0
+	if enabled {
+		val = 1
+	}
+	_, err := d.Exec(`UPDATE logging_config SET enabled = ? WHERE guild_id = ?`, val, guildID)
+	return err
+}
+
+func (d *DB) AddDisabledLogChannel(guildID, channelID string) error {
+	_, err := d.Exec(`INSERT OR IGNORE INTO disabled_log_channels (guild_id, channel_id) VALUES (?, ?)`,
+		guildID, channelID)
+	return err
+}
+
+func (d *DB) RemoveDisabledLogChannel(guildID, channelID string) error {
+	_, err := d.Exec(`DELETE FROM disabled_log_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID)
+	return err
+}
+
+func (d *DB) IsLogChannelDisabled(guildID, channelID string) (bool, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM disabled_log_channels WHERE guild_id = ? AND channel_id = ?`,
+		guildID, channelID).Scan(&count)
+	return count > 0, err
+}
+
+func (d *DB) GetDisabledLogChannels(guildID string) ([]string, error) {
+	rows, err := d.Query(`SELECT channel_id FROM disabled_log_channels WHERE guild_id = ?`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []string
+	for rows.Next() {
+		var channelID string
+		if err := rows.Scan(&channelID); err != nil {
+			return nil, err
+		}
+		channels = append(channels, channelID)
+	}
+	return channels, rows.Err()
+}
+
+// ============ Log Webhooks ============
+
+// GetLogWebhook returns the bot-managed webhook for a log channel, or nil if none exists
+func (d *DB) GetLogWebhook(channelID string) (*LogWebhook, error) {
+	var wh LogWebhook
+	err := d.QueryRow(`SELECT channel_id, guild_id, webhook_id, webhook_token FROM log_webhooks WHERE channel_id = ?`,
+		channelID).Scan(&wh.ChannelID, &wh.GuildID, &wh.WebhookID, &wh.WebhookToken)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	wh.WebhookToken = d.Decrypt(wh.WebhookToken)
+	return &wh, nil
+}
+
+func (d *DB) SetLogWebhook(wh *LogWebhook) error {
+	_, err := d.Exec(`INSERT INTO log_webhooks (channel_id, guild_id, webhook_id, webhook_token)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(channel_id) DO UPDATE SET
+		guild_id = excluded.guild_id, webhook_id = excluded.webhook_id, webhook_token = excluded.webhook_token`,
+		wh.ChannelID, wh.GuildID, wh.WebhookID, d.Encrypt(wh.WebhookToken))
+	return err
+}
+
+func (d *DB) DeleteLogWebhook(channelID string) error {
+	_, err := d.Exec(`DELETE FROM log_webhooks WHERE channel_id = ?`, channelID)
+	return err
+}
+
+// GetLogIdentity returns the webhook identity for a category, falling back to the guild default
+func (d *DB) GetLogIdentity(guildID, category string) (*LogIdentity, error) {
+	var li LogIdentity
+	var username, avatarURL sql.NullString
+	err := d.QueryRow(`SELECT guild_id, category, username, avatar_url FROM log_identities
+		WHERE guild_id = ? AND category IN (?, 'default')
+		ORDER BY category = 'default' LIMIT 1`, guildID, category).Scan(&li.GuildID, &li.Category, &username, &avatarURL)
+	if err == sql.ErrNoRows {
+		return &LogIdentity{GuildID: guildID, Category: category}, nil
+	}
+	li.Username = username.String
+	li.AvatarURL = avatarURL.String
+	return &li, err
+}
+
+// SetLogIdentity sets the webhook identity for a category; empty username and avatar clear it
+func (d *DB) SetLogIdentity(li *LogIdentity) error {
+	if li.Username == "" && li.AvatarURL == "" {
+		_, err := d.Exec(`DELETE FROM log_identities WHERE guild_id = ? AND category = ?`, li.GuildID, li.Category)
+		return err
+	}
+	_, err := d.Exec(`INSERT INTO log_identities (guild_id, category, username, avatar_url)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, category) DO UPDATE SET
+		username = excluded.username, avatar_url = excluded.avatar_url`,
+		li.GuildID, li.Category, nullString(li.Username), nullString(li.AvatarURL))
+	return err
+}
+
+// ============ Log Search ============
+
+// SearchDeletedMessages returns a page of deleted messages and the total number of matches
+func (d *DB) SearchDeletedMessages(q LogSearchQuery) ([]DeletedMessage, int, error) {
+	where := `guild_id = ?`
+	args := []interface{}{q.GuildID}
+	if q.UserID != "" {
+		where += ` AND user_id = ?`
+		args = append(args, q.UserID)
+	}
+	if !q.Since.IsZero() {
+		where += ` AND deleted_at >= ?`
+		args = append(args, sqliteTime(q.Since))
+	}
+	if !q.Until.IsZero() {
+		where += ` AND deleted_at <= ?`
+		args = append(args, sqliteTime(q.Until))
+	}
+
+	var total int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM deleted_messages WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, content, attachments, deleted_at
+		FROM deleted_messages WHERE `+where+` ORDER BY deleted_at DESC, id DESC LIMIT ? OFFSET ?`,
+		append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var messages []DeletedMessage
+	for rows.Next() {
+		var dm DeletedMessage
+		var attachments sql.NullString
+		if err := rows.Scan(&dm.ID, &dm.GuildID, &dm.ChannelID, &dm.UserID, &dm.Content, &attachments, &dm.DeletedAt); err != nil {
+			return nil, 0, err
+		}
+		dm.Content = d.Decrypt(dm.Content)
+		if attachments.Valid && attachments.String != "" {
+			json.Unmarshal([]byte(d.Decrypt(attachments.String)), &dm.Attachments)
+		}
+		messages = append(messages, dm)
+	}
+	return messages, total, rows.Err()
+}
+
+// sqliteTime formats a time like CURRENT_TIMESTAMP so DATETIME columns compare correctly
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// SearchModActions returns a page of mod actions and the total number of matches
+func (d *DB) SearchModActions(q LogSearchQuery) ([]ModAction, int, error) {
+	where := `guild_id = ?`
+	args := []interface{}{q.GuildID}
+	if q.UserID != "" {
+		where += ` AND moderator_id = ?`
+		args = append(args, q.UserID)
+	}
+	if q.Action != "" {
+		where += ` AND action = ?`
+		args = append(args, q.Action)
+	}
+	if !q.Since.IsZero() {
+		where += ` AND timestamp >= ?`
+		args = append(args, q.Since.UnixMilli())
+	}
+	if !q.Until.IsZero() {
+		where += ` AND timestamp <= ?`
+		args = append(args, q.Until.UnixMilli())
+	}
+
+	var total int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM mod_actions WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := d.Query(`SELECT id, guild_id, moderator_id, target_id, action, reason, timestamp, created_at,
+		COALESCE(dm_status, ''), COALESCE(undone_by, '')
+		FROM mod_actions WHERE `+where+` ORDER BY timestamp DESC, id DESC LIMIT ? OFFSET ?`,
+		append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var actions []ModAction
+	for rows.Next() {
+		var ma ModAction
+		if err := rows.Scan(&ma.ID, &ma.GuildID, &ma.ModeratorID, &ma.TargetID, &ma.Action, &ma.Reason, &ma.Timestamp, &ma.CreatedAt, &ma.DMStatus, &ma.UndoneBy); err != nil {
+			return nil, 0, err
+		}
+		ma.Reason = d.DecryptNullable(ma.Reason)
+		actions = append(actions, ma)
+	}
+	return actions, total, rows.Err()
+}
+
+// SearchMemberJoins returns a page of recorded joins and the total number of matches
+func (d *DB) SearchMemberJoins(q LogSearchQuery) ([]MemberJoin, int, error) {
+	where := `guild_id = ?`
+	args := []interface{}{q.GuildID}
+	if q.UserID != "" {
+		where += ` AND user_id = ?`
+		args = append(args, q.UserID)
+	}
+	if !q.Since.IsZero() {
+		where += ` AND joined_at >= ?`
+		args = append(args, q.Since.UnixMilli())
+	}
+	if !q.Until.IsZero() {
+		where += ` AND joined_at <= ?`
+		args = append(args, q.Until.UnixMilli())
+	}
+
+	var total int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM member_joins WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := d.Query(`SELECT id, guild_id, user_id, joined_at, account_created_at
+		FROM member_joins WHERE `+where+` ORDER BY joined_at DESC, id DESC LIMIT ? OFFSET ?`,
+		append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var joins []MemberJoin
+	for rows.Next() {
+		var mj MemberJoin
+		if err := rows.Scan(&mj.ID, &mj.GuildID, &mj.UserID, &mj.JoinedAt, &mj.AccountCreatedAt); err != nil {
+			return nil, 0, err
+		}
+		joins = append(joins, mj)
+	}
+	return joins, total, rows.Err()
+}
+
+// ============ Voice XP Configuration ============
+
+func (d *DB) GetVoiceXPConfig(guildID string) (*VoiceXPConfig, error) {
+	var vc VoiceXPConfig
+	var excluded string
+	err := d.QueryRow(`SELECT guild_id, enabled, xp_rate, interval_mins, ignore_afk,
+		ignore_alone, ignore_deafened, COALESCE(excluded_channels, ''), daily_cap
+		FROM voice_xp_config WHERE guild_id = ?`, guildID).Scan(
+		&vc.GuildID, &vc.Enabled, &vc.XPRate, &vc.IntervalMins, &vc.IgnoreAFK,
+		&vc.IgnoreAlone, &vc.IgnoreDeafened, &excluded, &vc.DailyCap)
+	if err == sql.ErrNoRows {
+		return &VoiceXPConfig{GuildID: guildID, Enabled: false, XPRate: 10, IntervalMins: 5, IgnoreAFK: true,
+			IgnoreAlone: true, IgnoreDeafened: true}, nil
+	}
+	if excluded != "" {
+		vc.ExcludedChannels = strings.Split(excluded, ",")
+	}
+	return &vc, err
+}
+
+func (d *DB) SetVoiceXPConfig(vc *VoiceXPConfig) error {
+	_, err := d.Exec(`INSERT INTO voice_xp_config (guild_id, enabled, xp_rate, interval_mins, ignore_afk,
+		ignore_alone, ignore_deafened, excluded_channels, daily_cap)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, xp_rate = excluded.xp_rate,
+		interval_mins = excluded.interval_mins, ignore_afk = excluded.ignore_afk,
+		ignore_alone = excluded.ignore_alone, ignore_deafened = excluded.ignore_deafened,
+		excluded_channels = excluded.excluded_channels, daily_cap = excluded.daily_cap`,
+		vc.GuildID, vc.Enabled, vc.XPRate, vc.IntervalMins, vc.IgnoreAFK,
+		vc.IgnoreAlone, vc.IgnoreDeafened, strings.Join(vc.ExcludedChannels, ","), vc.DailyCap)
+	return err
+}
+
+// GetVoiceXPToday returns how much voice XP a member has earned today (UTC)
+func (d *DB) GetVoiceXPToday(guildID, userID string) (int64, error) {
+	var xp int64
+	err := d.QueryRow(`SELECT xp FROM voice_xp_daily WHERE guild_id = ? AND user_id = ? AND day = date('now')`,
+		guildID, userID).Scan(&xp)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return xp, err
+}
+
+func (d *DB) RecordVoiceXP(guildID, userID string, amount int64) error {
+	_, err := d.Exec(`INSERT INTO voice_xp_daily (guild_id, user_id, day, xp) VALUES (?, ?, date('now'), ?)
+		ON CONFLICT(guild_id, user_id, day) DO UPDATE SET xp = xp + excluded.xp`,
+		guildID, userID, amount)
+	return err
+}
+
+// CleanOldVoiceXPDaily drops daily voice XP totals from before today
+func (d *DB) CleanOldVoiceXPDaily() error {
+	_, err := d.Exec(`DELETE FROM voice_xp_daily WHERE day < date('now')`)
+	return err
+}
+
+// ============ Level Ranks ============
+
+func (d *DB) GetLevelRankConfig(guildID string) (*LevelRankConfig, error) {
+	var cfg LevelRankConfig
+	err := d.QueryRow(`SELECT guild_id, stack, remove_on_loss FROM level_rank_config WHERE guild_id = ?`,
+		guildID).Scan(&cfg.GuildID, &cfg.Stack, &cfg.RemoveOnLoss)
+	if err == sql.ErrNoRows {
+		return &LevelRankConfig{GuildID: guildID, Stack: true}, nil
+	}
+	return &cfg, err
+}
+
+func (d *DB) SetLevelRankConfig(cfg *LevelRankConfig) error {
+	_, err := d.Exec(`INSERT INTO level_rank_config (guild_id, stack, remove_on_loss) VALUES (?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET stack = excluded.stack, remove_on_loss = excluded.remove_on_loss`,
+		cfg.GuildID, cfg.Stack, cfg.RemoveOnLoss)
+	return err
+}
+
+func (d *DB) AddLevelRank(guildID, roleID string, level int) error {
+	_, err := d.Exec(`INSERT INTO level_ranks (guild_id, role_id, level)
+		VALUES (?, ?, ?)
+		ON CONFLICT(guild_id, role_id) DO UPDATE SET level = excluded.level`,
+		guildID, roleID, level)
+	return err
+}
+
+func (d *DB) RemoveLevelRank(guildID, roleID string) error {
+	_, err := d.Exec(`DELETE FROM level_ranks WHERE guild_id = ? AND role_id = ?`, guildID, roleID)
+	return err
+}
+
+func (d *DB) GetLevelRanks(guildID string) ([]LevelRank, error) {
+	rows, err := d.Query(`SELECT id, guild_id, role_id, level, created_at
+		FROM level_ranks WHERE guild_id = ? ORDER BY level`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranks []LevelRank
+	for rows.Next() {
+		var r LevelRank
+		if err := rows.Scan(&r.ID, &r.GuildID, &r.RoleID, &r.Level, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		ranks = append(ranks, r)
+	}
+	return ranks, rows.Err()
+}
+
+func (d *DB) GetRanksForLevel(guildID string, level int) ([]LevelRank, error) {
+	rows, err := d.Query(`SELECT id, guild_id, role_id, level, created_at
+		FROM level_ranks WHERE guild_id = ? AND level <= ? ORDER BY level`, guildID, level)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranks []LevelRank
+	for rows.Next() {
+		var r LevelRank
+		if err := rows.Scan(&r.ID, &r.GuildID, &r.RoleID, &r.Level, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		ranks = append(ranks, r)
+	}
+	return ranks, rows.Err()
+}
+
+// ============ DM Forwarding ============
+
+func (d *DB) GetDMConfig(guildID string) (*DMConfig, error) {
+	var dc DMConfig
+	err := d.QueryRow(`SELECT guild_id, channel_id, enabled FROM dm_config WHERE guild_id = ?`, guildID).Scan(
+		&dc.GuildID, &dc.ChannelID, &dc.Enabled)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &dc, err
+}
+
+func (d *DB) SetDMConfig(guildID, channelID string, enabled bool) error {
+	_, err := d.Exec(`INSERT INTO dm_config (guild_id, channel_id, enabled)
+		VALUES (?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET channel_id = excluded.channel_id, enabled = excluded.enabled`,
+		guildID, channelID, enabled)
+	return err
+}
+
+func (d *DB) GetAllDMConfigs() ([]DMConfig, error) {
+	rows, err := d.Query(`SELECT guild_id, channel_id, enabled FROM dm_config WHERE enabled = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []DMConfig
+	for rows.Next() {
+		var dc DMConfig
+		if err := rows.Scan(&dc.GuildID, &dc.ChannelID, &dc.Enabled); err != nil {
+			return nil, err
+		}
+		configs = append(configs, dc)
+	}
+	return configs, rows.Err()
+}
+
+// ============ Bot Bans ============
+
+func (d *DB) AddBotBan(targetID, banType, reason, bannedBy string) error {
+	return d.AddBotBanWithCategory(targetID, banType, reason, bannedBy, "other")
+}
+
+func (d *DB) AddBotBanWithCategory(targetID, banType, reason, bannedBy, category string) error {
+	_, err := d.Exec(`INSERT INTO bot_bans (target_id, ban_type, reason, banned_by, category)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(target_id) DO UPDATE SET ban_type = excluded.ban_type, reason = excluded.reason,
+		category = excluded.category`,
+		targetID, banType, d.Encrypt(reason), bannedBy, category)
+	if err == nil {
+		d.cache.Invalidate("blocklist_entry:" + targetID)
+	}
+	return err
+}
+
+func (d *DB) RemoveBotBan(targetID string) error {
+	_, err := d.Exec(`DELETE FROM bot_bans WHERE target_id = ?`, targetID)
+	if err == nil {
+		d.cache.Invalidate("blocklist_entry:" + targetID)
+	}
+	return err
+}
+
+func (d *DB) IsBotBanned(targetID string) (bool, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM bot_bans WHERE target_id = ?`, targetID).Scan(&count)
+	return count > 0, err
+}
+
+func (d *DB) GetBotBan(targetID string) (*BotBan, error) {
+	var bb BotBan
+	var category sql.NullString
+	err := d.QueryRow(`SELECT id, target_id, ban_type, reason, banned_by, category, created_at FROM bot_bans WHERE target_id = ?`,
+		targetID).Scan(&bb.ID, &bb.TargetID, &bb.BanType, &bb.Reason, &bb.BannedBy, &category, &bb.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err == nil {
+		bb.Reason = d.Decrypt(bb.Reason)
+		bb.Category = category.String
+	}
+	return &bb, err
+}
+
+func (d *DB) GetBotBans(banType string) ([]BotBan, error) {
+	var rows *sql.Rows
+	var err error
+	if banType != "" {
+		rows, err = d.Query(`SELECT id, target_id, ban_type, reason, banned_by, category, created_at FROM bot_bans WHERE ban_type = ? ORDER BY created_at DESC`, banType)
+	} else {
+		rows, err = d.Query(`SELECT id, target_id, ban_type, reason, banned_by, category, created_at FROM bot_bans ORDER BY created_at DESC`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []BotBan
+	for rows.Next() {
+		var bb BotBan
+		var category sql.NullString
+		if err := rows.Scan(&bb.ID, &bb.TargetID, &bb.BanType, &bb.Reason, &bb.BannedBy, &category, &bb.CreatedAt); err != nil {
+			return nil, err
+		}
+		bb.Reason = d.Decrypt(bb.Reason)
+		bb.Category = category.String
+		bans = append(bans, bb)
+	}
+	return bans, rows.Err()
+}
+
+// GetBlocklistEntry returns the bot ban for a user if they are on the global blocklist
+func (d *DB) GetBlocklistEntry(userID string) (*BotBan, error) {
+	// Looked up on every message in opted-in guilds, so misses are cached too (as an empty TargetID).
+	// The cache holds the row as stored, so the reason stays encrypted in Redis.
+	key := "blocklist_entry:" + userID
+	var bb BotBan
+	if !d.cache.GetJSON(key, &bb) {
+		var category sql.NullString
+		err := d.QueryRow(`SELECT id, target_id, ban_type, reason, banned_by, category, created_at
+			FROM bot_bans WHERE target_id = ? AND ban_type = 'user'`, userID).Scan(
+			&bb.ID, &bb.TargetID, &bb.BanType, &bb.Reason, &bb.BannedBy, &category, &bb.CreatedAt)
+		if err == sql.ErrNoRows {
+			bb = BotBan{}
+		} else if err != nil {
+			return nil, err
+		}
+		bb.Category = category.String
+		d.cache.SetJSON(key, &bb, settingsCacheTTL)
+	}
+
+	if bb.TargetID == "" {
+		return nil, nil
+	}
+	bb.Reason = d.Decrypt(bb.Reason)
+	return &bb, nil
+}
+
+// ============ Global Blocklist ============
+
+func (d *DB) GetBlocklistConfig(guildID string) (*BlocklistConfig, error) {
+	// Read on every message, so it's worth sharing through the cache
+	key := "blocklist_config:" + guildID
+	var cfg BlocklistConfig
+	if d.cache.GetJSON(key, &cfg) {
+		return &cfg, nil
+	}
+
+	var logChannel, alertRole sql.NullString
+	err := d.QueryRow(`SELECT guild_id, enabled, action, log_channel_id, alert_role_id
+		FROM blocklist_config WHERE guild_id = ?`, guildID).Scan(
+		&cfg.GuildID, &cfg.Enabled, &cfg.Action, &logChannel, &alertRole)
+	if err == sql.ErrNoRows {
+		cfg = BlocklistConfig{GuildID: guildID, Enabled: false, Action: "ban"}
+		err = nil
+	}
+	cfg.LogChannelID = logChannel.String
+	cfg.AlertRoleID = alertRole.String
+	if err == nil {
+		d.cache.SetJSON(key, &cfg, settingsCacheTTL)
+	}
+	return &cfg, err
+}
+
+func (d *DB) SetBlocklistConfig(cfg *BlocklistConfig) error {
+	_, err := d.Exec(`INSERT INTO blocklist_config (guild_id, enabled, action, log_channel_id, alert_role_id)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, action = excluded.action,
+		log_channel_id = excluded.log_channel_id, alert_role_id = excluded.alert_role_id`,
+		cfg.GuildID, cfg.Enabled, cfg.Action, cfg.LogChannelID, cfg.AlertRoleID)
+	if err == nil {
+		d.cache.Invalidate("blocklist_config:" + cfg.GuildID)
+	}
+	return err
+}
+
+// ============ Invite Tracking ============
+
+func (d *DB) RecordInviteJoin(guildID, userID, inviterID, inviteCode string) error {
+	_, err := d.Exec(`INSERT INTO invite_joins (guild_id, user_id, inviter_id, invite_code) VALUES (?, ?, ?, ?)`,
+		guildID, userID, nullString(inviterID), nullString(inviteCode))
+	return err
+}
+
+// GetInviteJoin returns the most recent invite a member joined through
+func (d *DB) GetInviteJoin(guildID, userID string) (*InviteJoin, error) {
+	var ij InviteJoin
+	var inviter, code sql.NullString
+	err := d.QueryRow(`SELECT id, guild_id, user_id, inviter_id, invite_code, joined_at FROM invite_joins
+		WHERE guild_id = ? AND user_id = ? ORDER BY joined_at DESC, id DESC LIMIT 1`, guildID, userID).Scan(
+		&ij.ID, &ij.GuildID, &ij.UserID, &inviter, &code, &ij.JoinedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	ij.InviterID = inviter.String
+	ij.InviteCode = code.String
+	return &ij, err
+}
+
+// GetInviteLeaderboard returns inviters ranked by the number of members they brought in
+func (d *DB) GetInviteLeaderboard(guildID string, limit int) ([]InviterCount, error) {
+	rows, err := d.Query(`SELECT inviter_id, COUNT(*) as invites, COUNT(DISTINCT user_id) as unique_invites
+		FROM invite_joins WHERE guild_id = ? AND inviter_id IS NOT NULL
+		GROUP BY inviter_id ORDER BY unique_invites DESC LIMIT ?`, guildID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []InviterCount
+	for rows.Next() {
+		var ic InviterCount
+		if err := rows.Scan(&ic.InviterID, &ic.Total, &ic.Unique); err != nil {
+			return nil, err
+		}
+		counts = append(counts, ic)
+	}
+	return counts, rows.Err()
+}
+
+// GetInviterStats returns the total and unique number of joins attributed to an inviter
+func (d *DB) GetInviterStats(guildID, inviterID string) (*InviterCount, error) {
+	ic := InviterCount{InviterID: inviterID}
+	err := d.QueryRow(`SELECT COUNT(*), COUNT(DISTINCT user_id) FROM invite_joins WHERE guild_id = ? AND inviter_id = ?`,
+		guildID, inviterID).Scan(&ic.Total, &ic.Unique)
+	return &ic, err
+}
+
+// nullString converts an empty string to a NULL value
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Helper function for sqrt
+func sqrt(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	z := x / 2
+	for i := 0; i < 10; i++ {
+		z = (z + x/z) / 2
+	}
+	return z
+}
+
+// ============ Reaction Roles ============
+
+// AddReactionRole binds an emoji on a message to a role, creating the message entry if needed
+func (d *DB) AddReactionRole(guildID, channelID, messageID, emoji, roleID string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO reaction_role_messages (message_id, guild_id, channel_id) VALUES (?, ?, ?)`,
+		messageID, guildID, channelID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO reaction_roles (guild_id, message_id, emoji, role_id) VALUES (?, ?, ?, ?)
+		ON CONFLICT(message_id, emoji) DO UPDATE SET role_id = excluded.role_id`,
+		guildID, messageID, emoji, roleID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveReactionRole removes an emoji binding; the message entry goes with its last binding
+func (d *DB) RemoveReactionRole(messageID, emoji string) (bool, error) {
+	result, err := d.Exec(`DELETE FROM reaction_roles WHERE message_id = ? AND emoji = ?`, messageID, emoji)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+
+	_, err = d.Exec(`DELETE FROM reaction_role_messages WHERE message_id = ?
+		AND NOT EXISTS (SELECT 1 FROM reaction_roles WHERE message_id = ?)`, messageID, messageID)
+	return affected > 0, err
+}
+
+// DeleteReactionRoleMessage removes a message and all of its bindings
+func (d *DB) DeleteReactionRoleMessage(messageID string) error {
+	if _, err := d.Exec(`DELETE FROM reaction_roles WHERE message_id = ?`, messageID); err != nil {
+		return err
+	}
+	_, err := d.Exec(`DELETE FROM reaction_role_messages WHERE message_id = ?`, messageID)
+	return err
+}
+
+// GetReactionRoleMessage returns a reaction role message, or nil if the message has no bindings
+func (d *DB) GetReactionRoleMessage(messageID string) (*ReactionRoleMessage, error) {
+	var rm ReactionRoleMessage
+	err := d.QueryRow(`SELECT message_id, guild_id, channel_id, mode, max_roles FROM reaction_role_messages WHERE message_id = ?`,
+		messageID).Scan(&rm.MessageID, &rm.GuildID, &rm.ChannelID, &rm.Mode, &rm.MaxRoles)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &rm, err
+}
+
+func (d *DB) GetReactionRoleMessages(guildID string) ([]ReactionRoleMessage, error) {
+	rows, err := d.Query(`SELECT message_id, guild_id, channel_id, mode, max_roles FROM reaction_role_messages WHERE guild_id = ?`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []ReactionRoleMessage
+	for rows.Next() {
+		var rm ReactionRoleMessage
+		if err := rows.Scan(&rm.MessageID, &rm.GuildID, &rm.ChannelID, &rm.Mode, &rm.MaxRoles); err != nil {
+			return nil, err
+		}
+		messages = append(messages, rm)
+	}
+	return messages, rows.Err()
+}
+
+// SetReactionRoleMode sets the mode of a reaction role message
+func (d *DB) SetReactionRoleMode(messageID, mode string, maxRoles int) error {
+	_, err := d.Exec(`UPDATE reaction_role_messages SET mode = ?, max_roles = ? WHERE message_id = ?`, mode, maxRoles, messageID)
+	return err
+}
+
+func (d *DB) GetReactionRoles(messageID string) ([]ReactionRole, error) {
+	rows, err := d.Query(`SELECT id, guild_id, message_id, emoji, role_id FROM reaction_roles WHERE message_id = ? ORDER BY id`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []ReactionRole
+	for rows.Next() {
+		var rr ReactionRole
+		if err := rows.Scan(&rr.ID, &rr.GuildID, &rr.MessageID, &rr.Emoji, &rr.RoleID); err != nil {
+			return nil, err
+		}
+		roles = append(roles, rr)
+	}
+	return roles, rows.Err()
+}
+
+// ============ Role Panels ============
+
+const rolePanelColumns = `id, guild_id, channel_id, message_id, title, description, style,
+	required_role_id, max_roles, temp_duration, created_by, created_at`
+
+func scanRolePanel(scanner interface{ Scan(...interface{}) error }) (*RolePanel, error) {
+	var p RolePanel
+	var channelID, messageID, description, requiredRoleID, createdBy sql.NullString
+	err := scanner.Scan(&p.ID, &p.GuildID, &channelID, &messageID, &p.Title, &description, &p.Style,
+		&requiredRoleID, &p.MaxRoles, &p.TempDuration, &createdBy, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.ChannelID = channelID.String
+	p.MessageID = messageID.String
+	p.Description = description.String
+	p.RequiredRoleID = requiredRoleID.String
+	p.CreatedBy = createdBy.String
+	return &p, nil
+}
+
+func (d *DB) CreateRolePanel(p *RolePanel) (int64, error) {
+	result, err := d.Exec(`INSERT INTO role_panels (guild_id, title, description, style, required_role_id, max_roles, temp_duration, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.GuildID, p.Title, nullString(p.Description), p.Style, nullString(p.RequiredRoleID), p.MaxRoles, p.TempDuration, p.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateRolePanel saves a panel's display and behaviour settings
+func (d *DB) UpdateRolePanel(p *RolePanel) error {
+	_, err := d.Exec(`UPDATE role_panels SET title = ?, description = ?, style = ?, required_role_id = ?,
+		max_roles = ?, temp_duration = ? WHERE id = ? AND guild_id = ?`,
+		p.Title, nullString(p.Description), p.Style, nullString(p.RequiredRoleID), p.MaxRoles, p.TempDuration, p.ID, p.GuildID)
+	return err
+}
+
+// SetRolePanelMessage records where a panel has been posted
+func (d *DB) SetRolePanelMessage(panelID int64, channelID, messageID string) error {
+	_, err := d.Exec(`UPDATE role_panels SET channel_id = ?, message_id = ? WHERE id = ?`, channelID, messageID, panelID)
+	return err
+}
+
+// GetRolePanel returns a panel with its roles, or nil if it does not exist
+func (d *DB) GetRolePanel(panelID int64) (*RolePanel, error) {
+	p, err := scanRolePanel(d.QueryRow(`SELECT `+rolePanelColumns+` FROM role_panels WHERE id = ?`, panelID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Roles, err = d.getRolePanelRoles(p.ID)
+	return p, err
+}
+
+func (d *DB) GetRolePanels(guildID string) ([]RolePanel, error) {
+	rows, err := d.Query(`SELECT `+rolePanelColumns+` FROM role_panels WHERE guild_id = ? ORDER BY id`, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var panels []RolePanel
+	for rows.Next() {
+		p, err := scanRolePanel(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		panels = append(panels, *p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for idx := range panels {
+		if panels[idx].Roles, err = d.getRolePanelRoles(panels[idx].ID); err != nil {
+			return nil, err
+		}
+	}
+	return panels, nil
+}
+
+func (d *DB) DeleteRolePanel(guildID string, panelID int64) error {
+	result, err := d.Exec(`DELETE FROM role_panels WHERE id = ? AND guild_id = ?`, panelID, guildID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		_, err = d.Exec(`DELETE FROM role_panel_roles WHERE panel_id = ?`, panelID)
+	}
+	return err
+}
+
+// AddRolePanelRole adds a role to a panel, updating its label if already present
+func (d *DB) AddRolePanelRole(r *RolePanelRole) error {
+	_, err := d.Exec(`INSERT INTO role_panel_roles (panel_id, role_id, label, emoji, description) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(panel_id, role_id) DO UPDATE SET
+		label = excluded.label, emoji = excluded.emoji, description = excluded.description`,
+		r.PanelID, r.RoleID, nullString(r.Label), nullString(r.Emoji), nullString(r.Description))
+	return err
+}
+
+func (d *DB) RemoveRolePanelRole(panelID int64, roleID string) (bool, error) {
+	result, err := d.Exec(`DELETE FROM role_panel_roles WHERE panel_id = ? AND role_id = ?`, panelID, roleID)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (d *DB) getRolePanelRoles(panelID int64) ([]RolePanelRole, error) {
+	rows, err := d.Query(`SELECT id, panel_id, role_id, label, emoji, description FROM role_panel_roles WHERE panel_id = ? ORDER BY id`, panelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []RolePanelRole
+	for rows.Next() {
+		var r RolePanelRole
+		var label, emoji, description sql.NullString
+		if err := rows.Scan(&r.ID, &r.PanelID, &r.RoleID, &label, &emoji, &description); err != nil {
+			return nil, err
+		}
+		r.Label = label.String
+		r.Emoji = emoji.String
+		r.Description = description.String
+		roles = append(roles, r)
+	}
+	return roles, rows.Err()
+}
+
+// ============ Sticky Roles ============
+
+func (d *DB) GetStickyRolesConfig(guildID string) (*StickyRolesConfig, error) {
+	var cfg StickyRolesConfig
+	var excluded string
+	err := d.QueryRow(`SELECT guild_id, enabled, restore_nickname, excluded_roles FROM sticky_roles_config WHERE guild_id = ?`,
+		guildID).Scan(&cfg.GuildID, &cfg.Enabled, &cfg.RestoreNickname, &excluded)
+	if err == sql.ErrNoRows {
+		return &StickyRolesConfig{GuildID: guildID, RestoreNickname: true}, nil
+	}
+	if excluded != "" {
+		cfg.ExcludedRoles = strings.Split(excluded, ",")
+	}
+	return &cfg, err
+}
+
+func (d *DB) SetStickyRolesConfig(cfg *StickyRolesConfig) error {
+	_, err := d.Exec(`INSERT INTO sticky_roles_config (guild_id, enabled, restore_nickname, excluded_roles)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, restore_nickname = excluded.restore_nickname, excluded_roles = excluded.excluded_roles`,
+		cfg.GuildID, cfg.Enabled, cfg.RestoreNickname, strings.Join(cfg.ExcludedRoles, ","))
+	return err
+}
+
+// SaveMemberSnapshot stores a member's current roles and nickname
+func (d *DB) SaveMemberSnapshot(guildID, userID string, roles []string, nickname string) error {
+	_, err := d.Exec(`INSERT INTO member_snapshots (guild_id, user_id, roles, nickname, updated_at, left_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, NULL)
+		ON CONFLICT(guild_id, user_id) DO UPDATE SET
+		roles = excluded.roles, nickname = excluded.nickname, updated_at = excluded.updated_at, left_at = NULL`,
+		guildID, userID, strings.Join(roles, ","), nullString(nickname))
+	return err
+}
+
+// MarkMemberLeft flags a snapshot as restorable on rejoin
+func (d *DB) MarkMemberLeft(guildID, userID string) error {
+	_, err := d.Exec(`UPDATE member_snapshots SET left_at = CURRENT_TIMESTAMP WHERE guild_id = ? AND user_id = ?`, guildID, userID)
+	return err
+}
+
+// GetLeftMemberSnapshot returns the snapshot of a member who left, or nil if there is none
+func (d *DB) GetLeftMemberSnapshot(guildID, userID string) (*MemberSnapshot, error) {
+	var ms MemberSnapshot
+	var roles string
+	var nickname sql.NullString
+	err := d.QueryRow(`SELECT guild_id, user_id, roles, nickname, left_at FROM member_snapshots
+		WHERE guild_id = ? AND user_id = ? AND left_at IS NOT NULL`, guildID, userID).Scan(
+		&ms.GuildID, &ms.UserID, &roles, &nickname, &ms.LeftAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if roles != "" {
+		ms.Roles = strings.Split(roles, ",")
+	}
+	ms.Nickname = nickname.String
+	return &ms, nil
+}
+
+// ============ Suggestions ============
+
+func (d *DB) GetSuggestionConfig(guildID string) (*SuggestionConfig, error) {
+	var cfg SuggestionConfig
+	var channelID sql.NullString
+	err := d.QueryRow(`SELECT guild_id, channel_id, enabled, dm_author FROM suggestion_config WHERE guild_id = ?`,
+		guildID).Scan(&cfg.GuildID, &channelID, &cfg.Enabled, &cfg.DMAuthor)
+	if err == sql.ErrNoRows {
+		return &SuggestionConfig{GuildID: guildID, DMAuthor: true}, nil
+	}
+	cfg.ChannelID = channelID.String
+	return &cfg, err
+}
+
+func (d *DB) SetSuggestionConfig(cfg *SuggestionConfig) error {
+	_, err := d.Exec(`INSERT INTO suggestion_config (guild_id, channel_id, enabled, dm_author)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		channel_id = excluded.channel_id, enabled = excluded.enabled, dm_author = excluded.dm_author`,
+		cfg.GuildID, nullString(cfg.ChannelID), cfg.Enabled, cfg.DMAuthor)
+	return err
+}
+
+// CreateSuggestion stores a suggestion with the next number for its guild
+func (d *DB) CreateSuggestion(guildID, userID, content string) (*Suggestion, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var number int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(number), 0) + 1 FROM suggestions WHERE guild_id = ?`, guildID).Scan(&number); err != nil {
+		return nil, err
+	}
+	result, err := tx.Exec(`INSERT INTO suggestions (guild_id, number, user_id, content) VALUES (?, ?, ?, ?)`,
+		guildID, number, userID, content)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &Suggestion{
+		ID:        id,
+		GuildID:   guildID,
+		Number:    number,
+		UserID:    userID,
+		Content:   content,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func (d *DB) SetSuggestionMessage(id int64, channelID, messageID string) error {
+	_, err := d.Exec(`UPDATE suggestions SET channel_id = ?, message_id = ? WHERE id = ?`, channelID, messageID, id)
+	return err
+}
+
+// SetSuggestionStatus records a staff decision on a suggestion
+func (d *DB) SetSuggestionStatus(id int64, status, staffID, reason string) error {
+	_, err := d.Exec(`UPDATE suggestions SET status = ?, staff_id = ?, staff_reason = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		status, staffID, nullString(reason), id)
+	return err
+}
+
+const suggestionColumns = `id, guild_id, number, user_id, content, channel_id, message_id, status, staff_id, staff_reason,
+	(SELECT COUNT(*) FROM suggestion_votes WHERE suggestion_id = suggestions.id AND vote > 0),
+	(SELECT COUNT(*) FROM suggestion_votes WHERE suggestion_id = suggestions.id AND vote < 0),
+	created_at`
+
+func scanSuggestion(scanner interface{ Scan(...interface{}) error }) (*Suggestion, error) {
+	var sg Suggestion
+	var channelID, messageID, staffID, staffReason sql.NullString
+	err := scanner.Scan(&sg.ID, &sg.GuildID, &sg.Number, &sg.UserID, &sg.Content, &channelID, &messageID, &sg.Status,
+		&staffID, &staffReason, &sg.Upvotes, &sg.Downvotes, &sg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	sg.ChannelID = channelID.String
+	sg.MessageID = messageID.String
+	sg.StaffID = staffID.String
+	sg.StaffReason = staffReason.String
+	return &sg, nil
+}
+
+// GetSuggestion returns a suggestion by ID, or nil if it does not exist
+func (d *DB) GetSuggestion(id int64) (*Suggestion, error) {
+	sg, err := scanSuggestion(d.QueryRow(`SELECT `+suggestionColumns+` FROM suggestions WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sg, err
+}
+
+// GetSuggestionByNumber returns a guild's suggestion by number, or nil if it does not exist
+func (d *DB) GetSuggestionByNumber(guildID string, number int) (*Suggestion, error) {
+	sg, err := scanSuggestion(d.QueryRow(`SELECT `+suggestionColumns+` FROM suggestions WHERE guild_id = ? AND number = ?`, guildID, number))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sg, err
+}
+
+// GetSuggestions lists a guild's suggestions, newest first; an empty status returns all
+func (d *DB) GetSuggestions(guildID, status string, limit int) ([]Suggestion, error) {
+	query := `SELECT ` + suggestionColumns + ` FROM suggestions WHERE guild_id = ?`
+	args := []interface{}{guildID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY number DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []Suggestion
+	for rows.Next() {
+		sg, err := scanSuggestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, *sg)
+	}
+	return suggestions, rows.Err()
+}
+
+// ToggleSuggestionVote records a vote (1 or -1); voting the same way again removes the vote
+func (d *DB) ToggleSuggestionVote(suggestionID int64, userID string, vote int) error {
+	var current int
+	err := d.QueryRow(`SELECT vote FROM suggestion_votes WHERE suggestion_id = ? AND user_id = ?`, suggestionID, userID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if current == vote {
+		_, err = d.Exec(`DELETE FROM suggestion_votes WHERE suggestion_id = ? AND user_id = ?`, suggestionID, userID)
+		return err
+	}
+	_, err = d.Exec(`INSERT INTO suggestion_votes (suggestion_id, user_id, vote) VALUES (?, ?, ?)
+		ON CONFLICT(suggestion_id, user_id) DO UPDATE SET vote = excluded.vote`, suggestionID, userID, vote)
+	return err
+}
+
+// ============ Birthdays ============
+
+func (d *DB) GetBirthdayConfig(guildID string) (*BirthdayConfig, error) {
+	var cfg BirthdayConfig
+	var channelID, roleID, message sql.NullString
+	err := d.QueryRow(`SELECT guild_id, channel_id, role_id, message, enabled FROM birthday_config WHERE guild_id = ?`,
+		guildID).Scan(&cfg.GuildID, &channelID, &roleID, &message, &cfg.Enabled)
+	if err == sql.ErrNoRows {
+		return &BirthdayConfig{GuildID: guildID}, nil
+	}
+	cfg.ChannelID = channelID.String
+	cfg.RoleID = roleID.String
+	cfg.Message = message.String
+	return &cfg, err
+}
+
+func (d *DB) SetBirthdayConfig(cfg *BirthdayConfig) error {
+	_, err := d.Exec(`INSERT INTO birthday_config (guild_id, channel_id, role_id, message, enabled)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		channel_id = excluded.channel_id, role_id = excluded.role_id,
+		message = excluded.message, enabled = excluded.enabled`,
+		cfg.GuildID, nullString(cfg.ChannelID), nullString(cfg.RoleID), nullString(cfg.Message), cfg.Enabled)
+	return err
+}
+
+// SetBirthday stores a member's birthday; year is 0 when not given
+func (d *DB) SetBirthday(guildID, userID string, month, day, year int) error {
+	_, err := d.Exec(`INSERT INTO birthdays (guild_id, user_id, month, day, year)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, user_id) DO UPDATE SET
+		month = excluded.month, day = excluded.day, year = excluded.year`,
+		guildID, userID, month, day, year)
+	return err
+}
+
+func (d *DB) DeleteBirthday(guildID, userID string) error {
+	_, err := d.Exec(`DELETE FROM birthdays WHERE guild_id = ? AND user_id = ?`, guildID, userID)
+	return err
+}
+
+// birthdayColumns selects a birthday with the owner's timezone from user_timezones
+const birthdayColumns = `b.guild_id, b.user_id, b.month, b.day, b.year, b.last_announced, COALESCE(t.timezone, '')
+	FROM birthdays b LEFT JOIN user_timezones t ON t.user_id = b.user_id`
+
+func scanBirthdays(rows *sql.Rows) ([]Birthday, error) {
+	defer rows.Close()
+	var birthdays []Birthday
+	for rows.Next() {
+		var b Birthday
+		if err := rows.Scan(&b.GuildID, &b.UserID, &b.Month, &b.Day, &b.Year, &b.LastAnnounced, &b.Timezone); err != nil {
+			return nil, err
+		}
+		birthdays = append(birthdays, b)
+	}
+	return birthdays, rows.Err()
+}
+
+func (d *DB) GetBirthday(guildID, userID string) (*Birthday, error) {
+	rows, err := d.Query(`SELECT `+birthdayColumns+` WHERE b.guild_id = ? AND b.user_id = ?`, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	birthdays, err := scanBirthdays(rows)
+	if err != nil || len(birthdays) == 0 {
+		return nil, err
+	}
+	return &birthdays[0], nil
+}
+
+// GetGuildBirthdays returns a guild's birthdays in calendar order
+func (d *DB) GetGuildBirthdays(guildID string) ([]Birthday, error) {
+	rows, err := d.Query(`SELECT `+birthdayColumns+` WHERE b.guild_id = ? ORDER BY b.month, b.day`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return scanBirthdays(rows)
+}
+
+// GetBirthdaysInMonths returns birthdays in the given months for guilds with announcements enabled
+func (d *DB) GetBirthdaysInMonths(months []int) ([]Birthday, error) {
+	if len(months) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.Repeat("?, ", len(months)-1) + "?"
+	args := make([]interface{}, len(months))
+	for i, m := range months {
+		args[i] = m
+	}
+	rows, err := d.Query(`SELECT `+birthdayColumns+`
+		JOIN birthday_config c ON c.guild_id = b.guild_id AND c.enabled = 1
+		WHERE b.month IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanBirthdays(rows)
+}
+
+// MarkBirthdayAnnounced records the year a birthday was last announced
+func (d *DB) MarkBirthdayAnnounced(guildID, userID string, year int) error {
+	_, err := d.Exec(`UPDATE birthdays SET last_announced = ? WHERE guild_id = ? AND user_id = ?`, year, guildID, userID)
+	return err
+}
+
+// ============ Polls ============
+
+// CreatePoll stores a poll and returns its ID
+func (d *DB) CreatePoll(p *Poll) (int64, error) {
+	options, err := json.Marshal(p.Options)
+	if err != nil {
+		return 0, err
+	}
+	result, err := d.Exec(`INSERT INTO polls (guild_id, channel_id, user_id, question, options, anonymous, ends_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		p.GuildID, p.ChannelID, p.UserID, p.Question, string(options), p.Anonymous, p.EndsAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (d *DB) SetPollMessage(id int64, messageID string) error {
+	_, err := d.Exec(`UPDATE polls SET message_id = ? WHERE id = ?`, messageID, id)
+	return err
+}
+
+func (d *DB) ClosePoll(id int64) error {
+	_, err := d.Exec(`UPDATE polls SET closed = 1 WHERE id = ?`, id)
+	return err
+}
+
+const pollColumns = `id, guild_id, channel_id, message_id, user_id, question, options, anonymous, closed, ends_at, created_at`
+
+func scanPoll(scanner interface{ Scan(...interface{}) error }) (*Poll, error) {
+	var p Poll
+	var messageID sql.NullString
+	var options string
+	err := scanner.Scan(&p.ID, &p.GuildID, &p.ChannelID, &messageID, &p.UserID, &p.Question, &options,
+		&p.Anonymous, &p.Closed, &p.EndsAt, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.MessageID = messageID.String
+	json.Unmarshal([]byte(options), &p.Options)
+	return &p, nil
+}
+
+// GetPoll returns a poll by ID, or nil if it does not exist
+func (d *DB) GetPoll(id int64) (*Poll, error) {
+	p, err := scanPoll(d.QueryRow(`SELECT `+pollColumns+` FROM polls WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// GetGuildPolls lists a guild's polls, newest first
+func (d *DB) GetGuildPolls(guildID string, limit, offset int) ([]Poll, error) {
+	rows, err := d.Query(`SELECT `+pollColumns+` FROM polls WHERE guild_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		guildID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var polls []Poll
+	for rows.Next() {
+		p, err := scanPoll(rows)
+		if err != nil {
+			return nil, err
+		}
+		polls = append(polls, *p)
+	}
+	return polls, rows.Err()
+}
+
+// TogglePollVote sets a member's vote; voting for the same option again removes the vote
+func (d *DB) TogglePollVote(pollID int64, userID string, option int) error {
+	var current int
+	err := d.QueryRow(`SELECT option_index FROM poll_votes WHERE poll_id = ? AND user_id = ?`, pollID, userID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil && current == option {
+		_, err = d.Exec(`DELETE FROM poll_votes WHERE poll_id = ? AND user_id = ?`, pollID, userID)
+		return err
+	}
+	_, err = d.Exec(`INSERT INTO poll_votes (poll_id, user_id, option_index) VALUES (?, ?, ?)
+		ON CONFLICT(poll_id, user_id) DO UPDATE SET option_index = excluded.option_index`, pollID, userID, option)
+	return err
+}
+
+// GetPollVotes returns the voters for each option index
+func (d *DB) GetPollVotes(pollID int64) (map[int][]string, error) {
+	rows, err := d.Query(`SELECT option_index, user_id FROM poll_votes WHERE poll_id = ? ORDER BY rowid`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	votes := make(map[int][]string)
+	for rows.Next() {
+		var option int
+		var userID string
+		if err := rows.Scan(&option, &userID); err != nil {
+			return nil, err
+		}
+		votes[option] = append(votes[option], userID)
+	}
+	return votes, rows.Err()
+}
+
+// ============ Guild Scheduled Events ============
+
+func (d *DB) GetGuildEventConfig(guildID string) (*GuildEventConfig, error) {
+	var cfg GuildEventConfig
+	var channelID, pingRoleID sql.NullString
+	err := d.QueryRow(`SELECT guild_id, channel_id, ping_role_id, reminder_minutes, enabled
+		FROM guild_event_config WHERE guild_id = ?`, guildID).Scan(
+		&cfg.GuildID, &channelID, &pingRoleID, &cfg.ReminderMinutes, &cfg.Enabled)
+	if err == sql.ErrNoRows {
+		return &GuildEventConfig{GuildID: guildID, ReminderMinutes: 15}, nil
+	}
+	cfg.ChannelID = channelID.String
+	cfg.PingRoleID = pingRoleID.String
+	return &cfg, err
+}
+
+func (d *DB) SetGuildEventConfig(cfg *GuildEventConfig) error {
+	_, err := d.Exec(`INSERT INTO guild_event_config (guild_id, channel_id, ping_role_id, reminder_minutes, enabled)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		channel_id = excluded.channel_id, ping_role_id = excluded.ping_role_id,
+		reminder_minutes = excluded.reminder_minutes, enabled = excluded.enabled`,
+		cfg.GuildID, nullString(cfg.ChannelID), nullString(cfg.PingRoleID), cfg.ReminderMinutes, cfg.Enabled)
+	return err
+}
+
+func (d *DB) SetGuildEventAnnouncement(a *GuildEventAnnouncement) error {
+	_, err := d.Exec(`INSERT INTO guild_event_announcements (event_id, guild_id, channel_id, message_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(event_id) DO UPDATE SET channel_id = excluded.channel_id, message_id = excluded.message_id`,
+		a.EventID, a.GuildID, a.ChannelID, a.MessageID)
+	return err
+}
+
+// GetGuildEventAnnouncement returns an event's announcement message, or nil if it was never announced
+func (d *DB) GetGuildEventAnnouncement(eventID string) (*GuildEventAnnouncement, error) {
+	var a GuildEventAnnouncement
+	err := d.QueryRow(`SELECT event_id, guild_id, channel_id, message_id FROM guild_event_announcements WHERE event_id = ?`,
+		eventID).Scan(&a.EventID, &a.GuildID, &a.ChannelID, &a.MessageID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &a, err
+}
+
+// DeleteGuildEventData removes an event's announcement and RSVPs
+func (d *DB) DeleteGuildEventData(eventID string) error {
+	if _, err := d.Exec(`DELETE FROM guild_event_announcements WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	_, err := d.Exec(`DELETE FROM guild_event_rsvps WHERE event_id = ?`, eventID)
+	return err
+}
+
+func (d *DB) AddGuildEventRSVP(guildID, eventID, userID string) error {
+	_, err := d.Exec(`INSERT OR IGNORE INTO guild_event_rsvps (event_id, guild_id, user_id) VALUES (?, ?, ?)`,
+		eventID, guildID, userID)
+	return err
+}
+
+func (d *DB) RemoveGuildEventRSVP(eventID, userID string) error {
+	_, err := d.Exec(`DELETE FROM guild_event_rsvps WHERE event_id = ? AND user_id = ?`, eventID, userID)
+	return err
+}
+
+// GetGuildEventRSVPs returns the users interested in an event, earliest first
+func (d *DB) GetGuildEventRSVPs(eventID string) ([]string, error) {
+	rows, err := d.Query(`SELECT user_id FROM guild_event_rsvps WHERE event_id = ? ORDER BY created_at`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, rows.Err()
+}
+
+// ============ Game Channels ============
+
+const gameChannelColumns = `channel_id, guild_id, game_type, current_count, last_user_id, last_word,
+	high_score, high_score_at, strict, allow_consecutive`
+
+func scanGameChannel(scanner interface{ Scan(...interface{}) error }) (*GameChannel, error) {
+	var g GameChannel
+	var lastUserID, lastWord sql.NullString
+	var highScoreAt sql.NullTime
+	err := scanner.Scan(&g.ChannelID, &g.GuildID, &g.GameType, &g.Count, &lastUserID, &lastWord,
+		&g.HighScore, &highScoreAt, &g.Strict, &g.AllowConsecutive)
+	if err != nil {
+		return nil, err
+	}
+	g.LastUserID = lastUserID.String
+	g.LastWord = lastWord.String
+	if highScoreAt.Valid {
+		g.HighScoreAt = highScoreAt.Time
+	}
+	return &g, nil
+}
+
+// GetGameChannel returns the game configured in a channel, or nil if there is none
+func (d *DB) GetGameChannel(channelID string) (*GameChannel, error) {
+	g, err := scanGameChannel(d.QueryRow(`SELECT `+gameChannelColumns+` FROM game_channels WHERE channel_id = ?`, channelID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return g, err
+}
+
+func (d *DB) GetGuildGameChannels(guildID string) ([]GameChannel, error) {
+	rows, err := d.Query(`SELECT `+gameChannelColumns+` FROM game_channels WHERE guild_id = ?`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []GameChannel
+	for rows.Next() {
+		g, err := scanGameChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, *g)
+	}
+	return games, rows.Err()
+}
+
+// SetGameChannel creates or reconfigures a game channel; changing the game type resets its state
+func (d *DB) SetGameChannel(g *GameChannel) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow(`SELECT game_type FROM game_channels WHERE channel_id = ?`, g.ChannelID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil && current != g.GameType {
+		if _, err := tx.Exec(`DELETE FROM game_channels WHERE channel_id = ?`, g.ChannelID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM game_words WHERE channel_id = ?`, g.ChannelID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO game_channels (channel_id, guild_id, game_type, strict, allow_consecutive)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(channel_id) DO UPDATE SET strict = excluded.strict, allow_consecutive = excluded.allow_consecutive`,
+		g.ChannelID, g.GuildID, g.GameType, g.Strict, g.AllowConsecutive)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *DB) DeleteGameChannel(channelID string) error {
+	if _, err := d.Exec(`DELETE FROM game_channels WHERE channel_id = ?`, channelID); err != nil {
+		return err
+	}
+	_, err := d.Exec(`DELETE FROM game_words WHERE channel_id = ?`, channelID)
+	return err
+}
+
+// AdvanceGame records a correct move and raises the high score if it was beaten
+func (d *DB) AdvanceGame(channelID, userID, word string, count int) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE game_channels SET current_count = ?, last_user_id = ?, last_word = ?,
+		high_score_at = CASE WHEN ? > high_score THEN CURRENT_TIMESTAMP ELSE high_score_at END,
+		high_score = MAX(high_score, ?)
+		WHERE channel_id = ?`, count, userID, nullString(word), count, count, channelID)
+	if err != nil {
+		return err
+	}
+	if word != "" {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO game_words (channel_id, word) VALUES (?, ?)`, channelID, word); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ResetGame starts a game channel over, keeping its high score
+func (d *DB) ResetGame(channelID string) error {
+	if _, err := d.Exec(`UPDATE game_channels SET current_count = 0, last_user_id = NULL, last_word = NULL
+		WHERE channel_id = ?`, channelID); err != nil {
+		return err
+	}
+	_, err := d.Exec(`DELETE FROM game_words WHERE channel_id = ?`, channelID)
+	return err
+}
+
+// IsGameWordUsed reports whether a word has already been played in the current chain
+func (d *DB) IsGameWordUsed(channelID, word string) (bool, error) {
+	var exists int
+	err := d.QueryRow(`SELECT 1 FROM game_words WHERE channel_id = ? AND word = ?`, channelID, word).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ============ Economy ============
+
+// ErrInsufficientFunds is returned when a balance change would go below zero
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrOnCooldown is returned when a reward is claimed before its cooldown has passed
+var ErrOnCooldown = errors.New("reward on cooldown")
+
+func (d *DB) GetEconomyConfig(guildID string) (*EconomyConfig, error) {
+	var cfg EconomyConfig
+	err := d.QueryRow(`SELECT guild_id, enabled, currency_name, currency_symbol, daily_amount, work_min, work_max,
+		work_cooldown_mins, chat_amount, chat_cooldown_secs FROM economy_config WHERE guild_id = ?`, guildID).Scan(
+		&cfg.GuildID, &cfg.Enabled, &cfg.CurrencyName, &cfg.CurrencySymbol, &cfg.DailyAmount, &cfg.WorkMin, &cfg.WorkMax,
+		&cfg.WorkCooldownMins, &cfg.ChatAmount, &cfg.ChatCooldownSecs)
+	if err == sql.ErrNoRows {
+		return &EconomyConfig{
+			GuildID:          guildID,
+			Enabled:          true,
+			CurrencyName:     "coins",
+			CurrencySymbol:   "🪙",
+			DailyAmount:      100,
+			WorkMin:          20,
+			WorkMax:          80,
+			WorkCooldownMins: 60,
+			ChatAmount:       2,
+			ChatCooldownSecs: 60,
+		}, nil
+	}
+	return &cfg, err
+}
+
+func (d *DB) SetEconomyConfig(cfg *EconomyConfig) error {
+	_, err := d.Exec(`INSERT INTO economy_config (guild_id, enabled, currency_name, currency_symbol, daily_amount,
+		work_min, work_max, work_cooldown_mins, chat_amount, chat_cooldown_secs)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, currency_name = excluded.currency_name, currency_symbol = excluded.currency_symbol,
+		daily_amount = excluded.daily_amount, work_min = excluded.work_min, work_max = excluded.work_max,
+		work_cooldown_mins = excluded.work_cooldown_mins, chat_amount = excluded.chat_amount,
+		chat_cooldown_secs = excluded.chat_cooldown_secs`,
+		cfg.GuildID, cfg.Enabled, cfg.CurrencyName, cfg.CurrencySymbol, cfg.DailyAmount,
+		cfg.WorkMin, cfg.WorkMax, cfg.WorkCooldownMins, cfg.ChatAmount, cfg.ChatCooldownSecs)
+	return err
+}
+
+const economyAccountColumns = `guild_id, user_id, balance, daily_streak, last_daily, last_work, last_chat`
+
+func scanEconomyAccount(scanner interface{ Scan(...interface{}) error }) (*EconomyAccount, error) {
+	var a EconomyAccount
+	err := scanner.Scan(&a.GuildID, &a.UserID, &a.Balance, &a.DailyStreak, &a.LastDaily, &a.LastWork, &a.LastChat)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetEconomyAccount returns a member's account, or an empty one if they have never earned anything
+func (d *DB) GetEconomyAccount(guildID, userID string) (*EconomyAccount, error) {
+	a, err := scanEconomyAccount(d.QueryRow(`SELECT `+economyAccountColumns+`
+		FROM economy_accounts WHERE guild_id = ? AND user_id = ?`, guildID, userID))
+	if err == sql.ErrNoRows {
+		return &EconomyAccount{GuildID: guildID, UserID: userID}, nil
+	}
+	return a, err
+}
+
+// adjustBalance changes a balance inside a transaction and records it in the ledger
+func adjustBalance(tx *sql.Tx, guildID, userID string, amount int64, kind, relatedUserID string) (int64, error) {
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO economy_accounts (guild_id, user_id) VALUES (?, ?)`, guildID, userID); err != nil {
+		return 0, err
+	}
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM economy_accounts WHERE guild_id = ? AND user_id = ?`,
+		guildID, userID).Scan(&balance); err != nil {
+		return 0, err
+	}
+	if balance+amount < 0 {
+		return balance, ErrInsufficientFunds
+	}
+	balance += amount
+	if _, err := tx.Exec(`UPDATE economy_accounts SET balance = ? WHERE guild_id = ? AND user_id = ?`,
+		balance, guildID, userID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`INSERT INTO economy_transactions (guild_id, user_id, amount, kind, related_user_id)
+		VALUES (?, ?, ?, ?, ?)`, guildID, userID, amount, kind, nullString(relatedUserID)); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// AdjustBalance adds (or with a negative amount, removes) currency and returns the new balance.
+// It returns ErrInsufficientFunds rather than letting a balance go negative.
+func (d *DB) AdjustBalance(guildID, userID string, amount int64, kind string) (int64, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	balance, err := adjustBalance(tx, guildID, userID, amount, kind, "")
+	if err != nil {
+		return balance, err
+	}
+	return balance, tx.Commit()
+}
+
+// TransferBalance moves currency between members atomically
+func (d *DB) TransferBalance(guildID, fromUserID, toUserID string, amount int64) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := adjustBalance(tx, guildID, fromUserID, -amount, "transfer", toUserID); err != nil {
+		return err
+	}
+	if _, err := adjustBalance(tx, guildID, toUserID, amount, "transfer", fromUserID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// economyRewardColumns maps reward kinds to their last-claimed column
+var economyRewardColumns = map[string]string{
+	"daily": "last_daily",
+	"work":  "last_work",
+	"chat":  "last_chat",
+}
+
+// ClaimReward pays out a cooldown-limited reward (daily, work or chat) and returns the updated account.
+// It returns ErrOnCooldown with the current account if the cooldown hasn't passed.
+// Claiming a daily within two cooldowns of the last one extends the streak.
+func (d *DB) ClaimReward(guildID, userID, kind string, amount int64, cooldown time.Duration) (*EconomyAccount, error) {
+	column, ok := economyRewardColumns[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown reward %q", kind)
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO economy_accounts (guild_id, user_id) VALUES (?, ?)`, guildID, userID); err != nil {
+		return nil, err
+	}
+	account, err := scanEconomyAccount(tx.QueryRow(`SELECT `+economyAccountColumns+`
+		FROM economy_accounts WHERE guild_id = ? AND user_id = ?`, guildID, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	var last int64
+	switch kind {
+	case "daily":
+		last = account.LastDaily
+	case "work":
+		last = account.LastWork
+	case "chat":
+		last = account.LastChat
+	}
+	if now-last < cooldown.Milliseconds() {
+		return account, ErrOnCooldown
+	}
+
+	if kind == "daily" {
+		if now-last < 2*cooldown.Milliseconds() {
+			account.DailyStreak++
+		} else {
+			account.DailyStreak = 1
+		}
+	}
+	if _, err := tx.Exec(`UPDATE economy_accounts SET `+column+` = ?, daily_streak = ? WHERE guild_id = ? AND user_id = ?`,
+		now, account.DailyStreak, guildID, userID); err != nil {
+		return nil, err
+	}
+	if account.Balance, err = adjustBalance(tx, guildID, userID, amount, kind, ""); err != nil {
+		return nil, err
+	}
+	return account, tx.Commit()
+}
+
+// GetEconomyLeaderboard returns the richest members of a guild
+func (d *DB) GetEconomyLeaderboard(guildID string, limit, offset int) ([]EconomyAccount, error) {
+	rows, err := d.Query(`SELECT `+economyAccountColumns+` FROM economy_accounts
+		WHERE guild_id = ? AND balance > 0 ORDER BY balance DESC LIMIT ? OFFSET ?`, guildID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []EconomyAccount
+	for rows.Next() {
+		a, err := scanEconomyAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *a)
+	}
+	return accounts, rows.Err()
+}
+
+// ============ Role Shop ============
+
+// ErrOutOfStock is returned when buying a shop item with no stock left
+var ErrOutOfStock = errors.New("out of stock")
+
+// SetShopItem adds a role to the shop or updates its listing
+func (d *DB) SetShopItem(item *ShopItem) error {
+	_, err := d.Exec(`INSERT INTO shop_items (guild_id, role_id, price, stock, duration_secs, description)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, role_id) DO UPDATE SET
+		price = excluded.price, stock = excluded.stock, duration_secs = excluded.duration_secs,
+		description = excluded.description`,
+		item.GuildID, item.RoleID, item.Price, item.Stock, item.DurationSecs, nullString(item.Description))
+	return err
+}
+
+func (d *DB) DeleteShopItem(guildID, roleID string) (bool, error) {
+	result, err := d.Exec(`DELETE FROM shop_items WHERE guild_id = ? AND role_id = ?`, guildID, roleID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+const shopItemColumns = `id, guild_id, role_id, price, stock, duration_secs, description`
+
+func scanShopItem(scanner interface{ Scan(...interface{}) error }) (*ShopItem, error) {
+	var item ShopItem
+	var description sql.NullString
+	err := scanner.Scan(&item.ID, &item.GuildID, &item.RoleID, &item.Price, &item.Stock, &item.DurationSecs, &description)
+	if err != nil {
+		return nil, err
+	}
+	item.Description = description.String
+	return &item, nil
+}
+
+// GetShopItemByRole returns the shop listing for a role, or nil if it isn't for sale
+func (d *DB) GetShopItemByRole(guildID, roleID string) (*ShopItem, error) {
+	item, err := scanShopItem(d.QueryRow(`SELECT `+shopItemColumns+` FROM shop_items WHERE guild_id = ? AND role_id = ?`,
+		guildID, roleID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
+// GetShopItems lists a guild's shop, cheapest first
+func (d *DB) GetShopItems(guildID string) ([]ShopItem, error) {
+	rows, err := d.Query(`SELECT `+shopItemColumns+` FROM shop_items WHERE guild_id = ? ORDER BY price, id`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ShopItem
+	for rows.Next() {
+		item, err := scanShopItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// BuyShopItem charges a member, takes one from stock and records the purchase in a single transaction.
+// It returns ErrInsufficientFunds or ErrOutOfStock if the purchase can't be made.
+func (d *DB) BuyShopItem(item *ShopItem, userID string) (*ShopPurchase, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if item.Stock >= 0 {
+		result, err := tx.Exec(`UPDATE shop_items SET stock = stock - 1 WHERE id = ? AND stock > 0`, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 0 {
+			return nil, ErrOutOfStock
+		}
+	}
+	if _, err := adjustBalance(tx, item.GuildID, userID, -item.Price, "shop", ""); err != nil {
+		return nil, err
+	}
+
+	purchase := &ShopPurchase{
+		GuildID: item.GuildID,
+		UserID:  userID,
+		ItemID:  item.ID,
+		RoleID:  item.RoleID,
+		Price:   item.Price,
+	}
+	if item.DurationSecs > 0 {
+		purchase.ExpiresAt = time.Now().Add(time.Duration(item.DurationSecs) * time.Second).UnixMilli()
+	}
+	result, err := tx.Exec(`INSERT INTO shop_purchases (guild_id, user_id, item_id, role_id, price, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, purchase.GuildID, userID, item.ID, item.RoleID, item.Price, purchase.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if purchase.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return purchase, tx.Commit()
+}
+
+// RefundShopPurchase undoes a purchase whose role couldn't be granted
+func (d *DB) RefundShopPurchase(purchase *ShopPurchase) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := adjustBalance(tx, purchase.GuildID, purchase.UserID, purchase.Price, "refund", ""); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE shop_items SET stock = stock + 1 WHERE id = ? AND stock >= 0`, purchase.ItemID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM shop_purchases WHERE id = ?`, purchase.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ============ Achievements ============
+
+func (d *DB) GetAchievementConfig(guildID string) (*AchievementConfig, error) {
+	var cfg AchievementConfig
+	var channelID sql.NullString
+	err := d.QueryRow(`SELECT guild_id, enabled, announce, channel_id FROM achievement_config WHERE guild_id = ?`,
+		guildID).Scan(&cfg.GuildID, &cfg.Enabled, &cfg.Announce, &channelID)
+	if err == sql.ErrNoRows {
+		return &AchievementConfig{GuildID: guildID, Enabled: true}, nil
+	}
+	cfg.ChannelID = channelID.String
+	return &cfg, err
+}
+
+func (d *DB) SetAchievementConfig(cfg *AchievementConfig) error {
+	_, err := d.Exec(`INSERT INTO achievement_config (guild_id, enabled, announce, channel_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, announce = excluded.announce, channel_id = excluded.channel_id`,
+		cfg.GuildID, cfg.Enabled, cfg.Announce, nullString(cfg.ChannelID))
+	return err
+}
+
+// IncrementAchievementStat adds to a member's stat and returns the new value
+func (d *DB) IncrementAchievementStat(guildID, userID, stat string, delta int64) (int64, error) {
+	var value int64
+	err := d.QueryRow(`INSERT INTO achievement_stats (guild_id, user_id, stat, value) VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, user_id, stat) DO UPDATE SET value = value + excluded.value
+		RETURNING value`, guildID, userID, stat, delta).Scan(&value)
+	return value, err
+}
+
+// RaiseAchievementStat sets a stat to value if it is higher and returns the previous value
+func (d *DB) RaiseAchievementStat(guildID, userID, stat string, value int64) (int64, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var previous int64
+	err = tx.QueryRow(`SELECT value FROM achievement_stats WHERE guild_id = ? AND user_id = ? AND stat = ?`,
+		guildID, userID, stat).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if value > previous {
+		if _, err := tx.Exec(`INSERT INTO achievement_stats (guild_id, user_id, stat, value) VALUES (?, ?, ?, ?)
+			ON CONFLICT(guild_id, user_id, stat) DO UPDATE SET value = excluded.value`,
+			guildID, userID, stat, value); err != nil {
+			return 0, err
+		}
+	}
+	return previous, tx.Commit()
+}
+
+func (d *DB) GetAchievementStats(guildID, userID string) (map[string]int64, error) {
+	rows, err := d.Query(`SELECT stat, value FROM achievement_stats WHERE guild_id = ? AND user_id = ?`, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int64)
+	for rows.Next() {
+		var stat string
+		var value int64
+		if err := rows.Scan(&stat, &value); err != nil {
+			return nil, err
+		}
+		stats[stat] = value
+	}
+	return stats, rows.Err()
+}
+
+// AwardAchievement records an achievement and reports whether it was newly earned
+func (d *DB) AwardAchievement(guildID, userID, achievementID string) (bool, error) {
+	result, err := d.Exec(`INSERT OR IGNORE INTO user_achievements (guild_id, user_id, achievement_id) VALUES (?, ?, ?)`,
+		guildID, userID, achievementID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetUserAchievements returns the achievements a member has earned, oldest first
+func (d *DB) GetUserAchievements(guildID, userID string) ([]UserAchievement, error) {
+	rows, err := d.Query(`SELECT achievement_id, earned_at FROM user_achievements
+		WHERE guild_id = ? AND user_id = ? ORDER BY earned_at`, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var achievements []UserAchievement
+	for rows.Next() {
+		var a UserAchievement
+		if err := rows.Scan(&a.AchievementID, &a.EarnedAt); err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, a)
+	}
+	return achievements, rows.Err()
+}
+
+func (d *DB) CreateCustomAchievement(a *CustomAchievement) (int64, error) {
+	result, err := d.Exec(`INSERT INTO custom_achievements (guild_id, name, description, emoji, stat, threshold, role_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		a.GuildID, a.Name, nullString(a.Description), nullString(a.Emoji), a.Stat, a.Threshold, nullString(a.RoleID))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// DeleteCustomAchievement removes a custom achievement and every award of it
+func (d *DB) DeleteCustomAchievement(guildID string, id int64) (bool, error) {
+	result, err := d.Exec(`DELETE FROM custom_achievements WHERE guild_id = ? AND id = ?`, guildID, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	_, err = d.Exec(`DELETE FROM user_achievements WHERE guild_id = ? AND achievement_id = ?`,
+		guildID, fmt.Sprintf("custom:%d", id))
+	return true, err
+}
+
+func (d *DB) GetCustomAchievements(guildID string) ([]CustomAchievement, error) {
+	rows, err := d.Query(`SELECT id, guild_id, name, description, emoji, stat, threshold, role_id
+		FROM custom_achievements WHERE guild_id = ? ORDER BY stat, threshold`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var achievements []CustomAchievement
+	for rows.Next() {
+		var a CustomAchievement
+		var description, emoji, roleID sql.NullString
+		if err := rows.Scan(&a.ID, &a.GuildID, &a.Name, &description, &emoji, &a.Stat, &a.Threshold, &roleID); err != nil {
+			return nil, err
+		}
+		a.Description = description.String
+		a.Emoji = emoji.String
+		a.RoleID = roleID.String
+		achievements = append(achievements, a)
+	}
+	return achievements, rows.Err()
+}
+
+// ============ XP Multipliers ============
+
+func (d *DB) SetXPMultiplier(guildID, targetType, targetID string, multiplier float64) error {
+	_, err := d.Exec(`INSERT INTO xp_multipliers (guild_id, target_type, target_id, multiplier) VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, target_type, target_id) DO UPDATE SET multiplier = excluded.multiplier`,
+		guildID, targetType, targetID, multiplier)
+	return err
+}
+
+// RemoveXPMultiplier deletes a multiplier, reporting whether one existed
+func (d *DB) RemoveXPMultiplier(guildID, targetType, targetID string) (bool, error) {
+	res, err := d.Exec(`DELETE FROM xp_multipliers WHERE guild_id = ? AND target_type = ? AND target_id = ?`,
+		guildID, targetType, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (d *DB) GetXPMultipliers(guildID string) ([]XPMultiplier, error) {
+	rows, err := d.Query(`SELECT guild_id, target_type, target_id, multiplier FROM xp_multipliers
+		WHERE guild_id = ? ORDER BY target_type, multiplier DESC`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var multipliers []XPMultiplier
+	for rows.Next() {
+		var m XPMultiplier
+		if err := rows.Scan(&m.GuildID, &m.TargetType, &m.TargetID, &m.Multiplier); err != nil {
+			return nil, err
+		}
+		multipliers = append(multipliers, m)
+	}
+	return multipliers, rows.Err()
+}
+
+func (d *DB) CreateXPBoost(b *XPBoost) (int64, error) {
+	res, err := d.Exec(`INSERT INTO xp_boosts (guild_id, name, multiplier, starts_at, ends_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		b.GuildID, b.Name, b.Multiplier, b.StartsAt, b.EndsAt, b.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// DeleteXPBoost cancels a boost, reporting whether it existed
+func (d *DB) DeleteXPBoost(guildID string, id int64) (bool, error) {
+	res, err := d.Exec(`DELETE FROM xp_boosts WHERE guild_id = ? AND id = ?`, guildID, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetXPBoosts returns running and upcoming boosts
+func (d *DB) GetXPBoosts(guildID string) ([]XPBoost, error) {
+	rows, err := d.Query(`SELECT id, guild_id, COALESCE(name, ''), multiplier, starts_at, ends_at, COALESCE(created_by, '')
+		FROM xp_boosts WHERE guild_id = ? AND ends_at > ? ORDER BY starts_at`, guildID, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var boosts []XPBoost
+	for rows.Next() {
+		var b XPBoost
+		if err := rows.Scan(&b.ID, &b.GuildID, &b.Name, &b.Multiplier, &b.StartsAt, &b.EndsAt, &b.CreatedBy); err != nil {
+			return nil, err
+		}
+		boosts = append(boosts, b)
+	}
+	return boosts, rows.Err()
+}
+
+// GetActiveXPBoost returns the highest running boost multiplier, or 1 when none is running
+func (d *DB) GetActiveXPBoost(guildID string) (float64, error) {
+	now := time.Now().UnixMilli()
+	var multiplier float64
+	err := d.QueryRow(`SELECT COALESCE(MAX(multiplier), 1) FROM xp_boosts
+		WHERE guild_id = ? AND starts_at <= ? AND ends_at > ?`, guildID, now, now).Scan(&multiplier)
+	return multiplier, err
+}
+
+// ============ XP Exclusions ============
+
+func (d *DB) AddXPExclusion(guildID, targetType, targetID string) error {
+	_, err := d.Exec(`INSERT OR IGNORE INTO xp_exclusions (guild_id, target_type, target_id) VALUES (?, ?, ?)`,
+		guildID, targetType, targetID)
+	return err
+}
+
+// RemoveXPExclusion deletes an exclusion, reporting whether one existed
+func (d *DB) RemoveXPExclusion(guildID, targetType, targetID string) (bool, error) {
+	res, err := d.Exec(`DELETE FROM xp_exclusions WHERE guild_id = ? AND target_type = ? AND target_id = ?`,
+		guildID, targetType, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (d *DB) GetXPExclusions(guildID string) ([]XPExclusion, error) {
+	rows, err := d.Query(`SELECT guild_id, target_type, target_id FROM xp_exclusions
+		WHERE guild_id = ? ORDER BY target_type`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exclusions []XPExclusion
+	for rows.Next() {
+		var e XPExclusion
+		if err := rows.Scan(&e.GuildID, &e.TargetType, &e.TargetID); err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, e)
+	}
+	return exclusions, rows.Err()
+}
+
+// ============ Level-Up Announcements ============
+
+func (d *DB) GetLevelUpConfig(guildID string) (*LevelUpConfig, error) {
+	var cfg LevelUpConfig
+	var channelID, message sql.NullString
+	err := d.QueryRow(`SELECT guild_id, enabled, destination, channel_id, message, use_embed, every_levels
+		FROM levelup_config WHERE guild_id = ?`, guildID).Scan(
+		&cfg.GuildID, &cfg.Enabled, &cfg.Destination, &channelID, &message, &cfg.UseEmbed, &cfg.EveryLevels)
+	if err == sql.ErrNoRows {
+		return &LevelUpConfig{GuildID: guildID, Enabled: true, Destination: "current", EveryLevels: 1}, nil
+	}
+	cfg.ChannelID = channelID.String
+	cfg.Message = message.String
+	return &cfg, err
+}
+
+func (d *DB) SetLevelUpConfig(cfg *LevelUpConfig) error {
+	_, err := d.Exec(`INSERT INTO levelup_config (guild_id, enabled, destination, channel_id, message, use_embed, every_levels)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, destination = excluded.destination, channel_id = excluded.channel_id,
+		message = excluded.message, use_embed = excluded.use_embed, every_levels = excluded.every_levels`,
+		cfg.GuildID, cfg.Enabled, cfg.Destination, nullString(cfg.ChannelID), nullString(cfg.Message),
+		cfg.UseEmbed, cfg.EveryLevels)
+	return err
+}
+
+// ============ XP Seasons ============
+
+func (d *DB) GetXPSeasonConfig(guildID string) (*XPSeasonConfig, error) {
+	var cfg XPSeasonConfig
+	var channelID, roleID, lastWinner sql.NullString
+	err := d.QueryRow(`SELECT guild_id, enabled, period, channel_id, winner_role_id, top_count, season_start, last_winner_id
+		FROM xp_season_config WHERE guild_id = ?`, guildID).Scan(
+		&cfg.GuildID, &cfg.Enabled, &cfg.Period, &channelID, &roleID, &cfg.TopCount, &cfg.SeasonStart, &lastWinner)
+	if err == sql.ErrNoRows {
+		return &XPSeasonConfig{GuildID: guildID, Period: "monthly", TopCount: 10}, nil
+	}
+	cfg.ChannelID = channelID.String
+	cfg.WinnerRoleID = roleID.String
+	cfg.LastWinnerID = lastWinner.String
+	return &cfg, err
+}
+
+func (d *DB) SetXPSeasonConfig(cfg *XPSeasonConfig) error {
+	_, err := d.Exec(`INSERT INTO xp_season_config (guild_id, enabled, period, channel_id, winner_role_id, top_count, season_start, last_winner_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, period = excluded.period, channel_id = excluded.channel_id,
+		winner_role_id = excluded.winner_role_id, top_count = excluded.top_count,
+		season_start = excluded.season_start, last_winner_id = excluded.last_winner_id`,
+		cfg.GuildID, cfg.Enabled, cfg.Period, nullString(cfg.ChannelID), nullString(cfg.WinnerRoleID),
+		cfg.TopCount, cfg.SeasonStart, nullString(cfg.LastWinnerID))
+	return err
+}
+
+func (d *DB) GetEnabledXPSeasonConfigs() ([]XPSeasonConfig, error) {
+	rows, err := d.Query(`SELECT guild_id FROM xp_season_config WHERE enabled = 1`)
+	if err != nil {
+		return nil, err
+	}
+	var guildIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		guildIDs = append(guildIDs, id)
+	}
+	rows.Close()
+
+	configs := make([]XPSeasonConfig, 0, len(guildIDs))
+	for _, id := range guildIDs {
+		cfg, err := d.GetXPSeasonConfig(id)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, *cfg)
+	}
+	return configs, nil
+}
+
+// GetXPGainsBetween ranks members by XP gained on UTC days in [from, to)
+func (d *DB) GetXPGainsBetween(guildID string, from, to time.Time, limit int) ([]UserXP, error) {
+	rows, err := d.Query(`SELECT h.user_id, SUM(h.xp) AS gained, COALESCE(MAX(u.level), 0) FROM xp_history h
+		LEFT JOIN user_xp u ON u.guild_id = h.guild_id AND u.user_id = h.user_id
+		WHERE h.guild_id = ? AND h.day >= ? AND h.day < ?
+		GROUP BY h.user_id ORDER BY gained DESC, h.user_id LIMIT ?`,
+		guildID, from.UTC().Format("2006-01-02"), to.UTC().Format("2006-01-02"), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var standings []UserXP
+	for rows.Next() {
+		ux := UserXP{GuildID: guildID}
+		if err := rows.Scan(&ux.UserID, &ux.XP, &ux.Level); err != nil {
+			return nil, err
+		}
+		standings = append(standings, ux)
+	}
+	return standings, rows.Err()
+}
+
+// ArchiveXPSeason stores a season's final standings and starts the next season
+func (d *DB) ArchiveXPSeason(cfg *XPSeasonConfig, seasonEnd int64, standings []UserXP) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for idx, ux := range standings {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO xp_season_archive (guild_id, season_start, season_end, position, user_id, xp)
+			VALUES (?, ?, ?, ?, ?, ?)`, cfg.GuildID, cfg.SeasonStart, seasonEnd, idx+1, ux.UserID, ux.XP); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE xp_season_config SET season_start = ?, last_winner_id = ? WHERE guild_id = ?`,
+		seasonEnd, nullString(cfg.LastWinnerID), cfg.GuildID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetXPSeasonArchive returns archived standings for the most recent seasons, newest first
+func (d *DB) GetXPSeasonArchive(guildID string, seasons, perSeason int) ([]XPSeasonEntry, error) {
+	rows, err := d.Query(`SELECT guild_id, season_start, season_end, position, user_id, xp FROM xp_season_archive
+		WHERE guild_id = ? AND position <= ? AND season_start IN (
+			SELECT DISTINCT season_start FROM xp_season_archive WHERE guild_id = ? ORDER BY season_start DESC LIMIT ?
+		) ORDER BY season_start DESC, position`, guildID, perSeason, guildID, seasons)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []XPSeasonEntry
+	for rows.Next() {
+		var e XPSeasonEntry
+		if err := rows.Scan(&e.GuildID, &e.SeasonStart, &e.SeasonEnd, &e.Position, &e.UserID, &e.XP); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ============ Rank Cards ============
+
+// GetRankCardSettings returns a user's rank card colors. Unset colors are nil.
+func (d *DB) GetRankCardSettings(userID string) (*RankCardSettings, error) {
+	rc := RankCardSettings{UserID: userID}
+	var background, accent sql.NullInt64
+	err := d.QueryRow(`SELECT background, accent FROM rank_card_settings WHERE user_id = ?`, userID).Scan(&background, &accent)
+	if err == sql.ErrNoRows {
+		return &rc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if background.Valid {
+		v := int(background.Int64)
+		rc.Background = &v
+	}
+	if accent.Valid {
+		v := int(accent.Int64)
+		rc.Accent = &v
+	}
+	return &rc, nil
+}
+
+func (d *DB) SetRankCardSettings(rc *RankCardSettings) error {
+	_, err := d.Exec(`INSERT INTO rank_card_settings (user_id, background, accent, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id) DO UPDATE SET background = excluded.background, accent = excluded.accent, updated_at = CURRENT_TIMESTAMP`,
+		rc.UserID, rc.Background, rc.Accent)
+	return err
+}
+
+func (d *DB) DeleteRankCardSettings(userID string) error {
+	_, err := d.Exec(`DELETE FROM rank_card_settings WHERE user_id = ?`, userID)
+	return err
+}
+
+// GetWelcomeCardSettings returns a guild's welcome card settings. Unset colors are nil.
+func (d *DB) GetWelcomeCardSettings(guildID string) (*WelcomeCardSettings, error) {
+	wc := WelcomeCardSettings{GuildID: guildID}
+	var background, accent sql.NullInt64
+	err := d.QueryRow(`SELECT enabled, background, accent, COALESCE(background_url, ''), COALESCE(title, '')
+		FROM welcome_card_settings WHERE guild_id = ?`, guildID).Scan(
+		&wc.Enabled, &background, &accent, &wc.BackgroundURL, &wc.Title)
+	if err == sql.ErrNoRows {
+		return &wc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if background.Valid {
+		v := int(background.Int64)
+		wc.Background = &v
+	}
+	if accent.Valid {
+		v := int(accent.Int64)
+		wc.Accent = &v
+	}
+	return &wc, nil
+}
+
+func (d *DB) SetWelcomeCardSettings(wc *WelcomeCardSettings) error {
+	_, err := d.Exec(`INSERT INTO welcome_card_settings (guild_id, enabled, background, accent, background_url, title, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(guild_id) DO UPDATE SET enabled = excluded.enabled, background = excluded.background,
+		accent = excluded.accent, background_url = excluded.background_url, title = excluded.title,
+		updated_at = CURRENT_TIMESTAMP`,
+		wc.GuildID, wc.Enabled, wc.Background, wc.Accent, wc.BackgroundURL, wc.Title)
+	return err
+}
+
+// GetGreetingMessage returns a guild's welcome, leave or join DM message, empty if it isn't set up
+func (d *DB) GetGreetingMessage(guildID, kind string) (*GreetingMessage, error) {
+	gm := GreetingMessage{GuildID: guildID, Kind: kind}
+	err := d.QueryRow(`SELECT COALESCE(channel_id, ''), COALESCE(content, ''), COALESCE(embed, '')
+		FROM greeting_messages WHERE guild_id = ? AND kind = ?`, guildID, kind).Scan(
+		&gm.ChannelID, &gm.Content, &gm.Embed)
+	if err == sql.ErrNoRows {
+		return &gm, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	gm.Content = d.Decrypt(gm.Content)
+	gm.Embed = d.Decrypt(gm.Embed)
+	return &gm, nil
+}
+
+func (d *DB) SetGreetingMessage(gm *GreetingMessage) error {
+	_, err := d.Exec(`INSERT INTO greeting_messages (guild_id, kind, channel_id, content, embed, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(guild_id, kind) DO UPDATE SET channel_id = excluded.channel_id, content = excluded.content,
+		embed = excluded.embed, updated_at = CURRENT_TIMESTAMP`,
+		gm.GuildID, gm.Kind, gm.ChannelID, d.Encrypt(gm.Content), d.Encrypt(gm.Embed))
+	return err
+}
+
+func (d *DB) DeleteGreetingMessage(guildID, kind string) error {
+	_, err := d.Exec(`DELETE FROM greeting_messages WHERE guild_id = ? AND kind = ?`, guildID, kind)
+	return err
+}
+
+// ============ Moderation Actions ============
+
+func (d *DB) AddModAction(guildID, moderatorID, targetID, action string, reason *string, timestamp int64) error {
+	_, err := d.CreateModAction(guildID, moderatorID, targetID, action, reason, timestamp)
+	return err
+}
+
+// CreateModAction records a mod action and returns its case ID
+func (d *DB) CreateModAction(guildID, moderatorID, targetID, action string, reason *string, timestamp int64) (int64, error) {
+	encReason := d.EncryptNullable(reason)
+	res, err := d.Exec(`INSERT INTO mod_actions (guild_id, moderator_id, target_id, action, reason, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
+		guildID, moderatorID, targetID, action, encReason, timestamp)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// SetModActionDMStatus records whether the target of a case was told about it
+func (d *DB) SetModActionDMStatus(id int64, status string) error {
+	_, err := d.Exec(`UPDATE mod_actions SET dm_status = ? WHERE id = ?`, status, id)
+	return err
+}
+
+// SetModActionWarningID links a warn case to the warning it created
+func (d *DB) SetModActionWarningID(id, warningID int64) error {
+	_, err := d.Exec(`UPDATE mod_actions SET warning_id = ? WHERE id = ?`, warningID, id)
+	return err
+}
+
+// GetModAction returns a guild's case by ID, nil if there is none
+func (d *DB) GetModAction(guildID string, id int64) (*ModAction, error) {
+	var ma ModAction
+	err := d.QueryRow(`SELECT id, guild_id, moderator_id, target_id, action, reason, timestamp, created_at,
+		COALESCE(dm_status, ''), COALESCE(undone_by, ''), COALESCE(warning_id, 0)
+		FROM mod_actions WHERE guild_id = ? AND id = ?`, guildID, id).Scan(
+		&ma.ID, &ma.GuildID, &ma.ModeratorID, &ma.TargetID, &ma.Action, &ma.Reason, &ma.Timestamp, &ma.CreatedAt, &ma.DMStatus, &ma.UndoneBy, &ma.WarningID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ma.Reason = d.DecryptNullable(ma.Reason)
+	return &ma, nil
+}
+
+// GetLastUndoableModAction returns a moderator's newest ban, warn or timeout
+// since the given Unix millisecond timestamp that hasn't been undone, nil if there is none
+func (d *DB) GetLastUndoableModAction(guildID, moderatorID string, since int64) (*ModAction, error) {
+	var id int64
+	err := d.QueryRow(`SELECT id FROM mod_actions
+		WHERE guild_id = ? AND moderator_id = ? AND action IN ('ban', 'warn', 'timeout')
+		AND COALESCE(undone_by, '') = '' AND timestamp >= ?
+		ORDER BY timestamp DESC, id DESC LIMIT 1`, guildID, moderatorID, since).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d.GetModAction(guildID, id)
+}
+
+// MarkModActionUndone records who undid a case, reporting false if it was already undone
+func (d *DB) MarkModActionUndone(id int64, undoneBy string) (bool, error) {
+	result, err := d.Exec(`UPDATE mod_actions SET undone_by = ? WHERE id = ? AND COALESCE(undone_by, '') = ''`, undoneBy, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (d *DB) ModActionExists(guildID, targetID, action string, timestamp int64) (bool, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM mod_actions WHERE guild_id = ? AND target_id = ? AND action = ? AND timestamp = ?`,
+		guildID, targetID, action, timestamp).Scan(&count)
+	return count > 0, err
+}
+
+func (d *DB) GetModActionsCount(guildID string) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM mod_actions WHERE guild_id = ?`, guildID).Scan(&count)
+	return count, err
+}
+
+// GetModStats totals a guild's mod actions since a Unix millisecond timestamp, 0 for all time.
+// Each moderator's count over the same length of time before since is filled in to show their trend.
+// limit caps the number of moderators returned, 0 returns them all.
+func (d *DB) GetModStats(guildID string, since int64, limit int) (*ModStats, error) {
+	stats := &ModStats{
+		ActionCounts: make(map[string]int),
+		TopMods:      []ModeratorCount{},
+	}
+
+	rows, err := d.Query(`SELECT moderator_id, action, COUNT(*) FROM mod_actions
+		WHERE guild_id = ? AND timestamp >= ? GROUP BY moderator_id, action`, guildID, since)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	modMap := make(map[string]*ModeratorCount)
+	for rows.Next() {
+		var modID, action string
+		var count int
+		if err := rows.Scan(&modID, &action, &count); err != nil {
+			return stats, err
+		}
+		mod, ok := modMap[modID]
+		if !ok {
+			mod = &ModeratorCount{ModeratorID: modID, Actions: make(map[string]int)}
+			modMap[modID] = mod
+		}
+		mod.Count += count
+		mod.Actions[action] += count
+		stats.ActionCounts[action] += count
+		stats.TotalActions += count
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	// Moderators who were active before the range but not during it still show up, with a count of 0
+	if since > 0 {
+		previousSince := since - (time.Now().UnixMilli() - since)
+		prevRows, err := d.Query(`SELECT moderator_id, COUNT(*) FROM mod_actions
+			WHERE guild_id = ? AND timestamp >= ? AND timestamp < ? GROUP BY moderator_id`, guildID, previousSince, since)
+		if err != nil {
+			return stats, err
+		}
+		defer prevRows.Close()
+		for prevRows.Next() {
+			var modID string
+			var count int
+			if err := prevRows.Scan(&modID, &count); err != nil {
+				return stats, err
+			}
+			mod, ok := modMap[modID]
+			if !ok {
+				mod = &ModeratorCount{ModeratorID: modID, Actions: make(map[string]int)}
+				modMap[modID] = mod
+			}
+			mod.PreviousCount = count
+		}
+		if err := prevRows.Err(); err != nil {
+			return stats, err
+		}
+	}
+
+	for _, mod := range modMap {
+		stats.TopMods = append(stats.TopMods, *mod)
+	}
+	slices.SortFunc(stats.TopMods, func(a, b ModeratorCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return b.PreviousCount - a.PreviousCount
+	})
+	if limit > 0 && len(stats.TopMods) > limit {
+		stats.TopMods = stats.TopMods[:limit]
+	}
+
+	return stats, nil
+}
+
+// GetModActivityByDay counts each moderator's actions per UTC day since a Unix millisecond timestamp
+func (d *DB) GetModActivityByDay(guildID string, since int64) ([]ModActivityDay, error) {
+	rows, err := d.Query(`SELECT date(timestamp / 1000, 'unixepoch') AS day, moderator_id, COUNT(*)
+		FROM mod_actions WHERE guild_id = ? AND timestamp >= ?
+		GROUP BY day, moderator_id ORDER BY day`, guildID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []ModActivityDay
+	for rows.Next() {
+		var a ModActivityDay
+		if err := rows.Scan(&a.Day, &a.ModeratorID, &a.Count); err != nil {
+			return nil, err
+		}
+		days = append(days, a)
+	}
+	return days, rows.Err()
+}
+
+func (d *DB) GetModActionsForTarget(guildID, targetID string) ([]ModAction, error) {
+	rows, err := d.Query(`SELECT id, guild_id, moderator_id, target_id, action, reason, timestamp, created_at,
+		COALESCE(dm_status, ''), COALESCE(undone_by, '')
+		FROM mod_actions WHERE guild_id = ? AND target_id = ? ORDER BY timestamp DESC`, guildID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []ModAction
+	for rows.Next() {
+		var ma ModAction
+		if err := rows.Scan(&ma.ID, &ma.GuildID, &ma.ModeratorID, &ma.TargetID, &ma.Action, &ma.Reason, &ma.Timestamp, &ma.CreatedAt, &ma.DMStatus, &ma.UndoneBy); err != nil {
+			return nil, err
+		}
+		ma.Reason = d.DecryptNullable(ma.Reason)
+		actions = append(actions, ma)
+	}
+	return actions, rows.Err()
+}
+
+// ============ Mention Responses ============
+
+func (d *DB) AddMentionResponse(mr *MentionResponse) error {
+	_, err := d.Exec(`INSERT INTO mention_responses (guild_id, trigger_text, response, image_url, created_by, match_mode, cooldown_seconds)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, trigger_text) DO UPDATE SET response = excluded.response, image_url = excluded.image_url,
+		match_mode = excluded.match_mode, cooldown_seconds = excluded.cooldown_seconds`,
+		mr.GuildID, d.Encrypt(mr.TriggerText), d.Encrypt(mr.Response), d.EncryptNullable(mr.ImageURL), mr.CreatedBy,
+		mr.MatchMode, mr.CooldownSeconds)
+	return err
+}
+
+const mentionResponseColumns = `id, guild_id, trigger_text, response, image_url, created_by, created_at,
+	COALESCE(match_mode, 'contains'), COALESCE(cooldown_seconds, 0)`
+
+func (d *DB) scanMentionResponse(scanner interface{ Scan(...interface{}) error }) (*MentionResponse, error) {
+	var mr MentionResponse
+	err := scanner.Scan(&mr.ID, &mr.GuildID, &mr.TriggerText, &mr.Response, &mr.ImageURL, &mr.CreatedBy, &mr.CreatedAt,
+		&mr.MatchMode, &mr.CooldownSeconds)
+	if err != nil {
+		return nil, err
+	}
+	mr.TriggerText = d.Decrypt(mr.TriggerText)
+	mr.Response = d.Decrypt(mr.Response)
+	mr.ImageURL = d.DecryptNullable(mr.ImageURL)
+	return &mr, nil
+}
+
+func (d *DB) RemoveMentionResponse(guildID, trigger string) error {
+	// Need to encrypt trigger for lookup since it's stored encrypted
+	_, err := d.Exec(`DELETE FROM mention_responses WHERE guild_id = ? AND trigger_text = ?`, guildID, d.Encrypt(trigger))
+	return err
+}
+
+func (d *DB) GetMentionResponses(guildID string) ([]MentionResponse, error) {
+	rows, err := d.Query(`SELECT `+mentionResponseColumns+`
+		FROM mention_responses WHERE guild_id = ? ORDER BY trigger_text`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var responses []MentionResponse
+	for rows.Next() {
+		mr, err := d.scanMentionResponse(rows)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *mr)
+	}
+	return responses, rows.Err()
+}
+
+func (d *DB) GetMentionResponse(guildID, trigger string) (*MentionResponse, error) {
+	// Need to encrypt trigger for lookup since it's stored encrypted
+	mr, err := d.scanMentionResponse(d.QueryRow(`SELECT `+mentionResponseColumns+`
+		FROM mention_responses WHERE guild_id = ? AND trigger_text = ?`, guildID, d.Encrypt(trigger)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return mr, err
+}
+
+// ============ Spam Filter ============
+
+func (d *DB) GetSpamFilterConfig(guildID string) (*SpamFilterConfig, error) {
+	var sf SpamFilterConfig
+	err := d.QueryRow(`SELECT guild_id, enabled, max_mentions, max_links, max_emojis, action
+		FROM spam_filter_config WHERE guild_id = ?`, guildID).Scan(
+		&sf.GuildID, &sf.Enabled, &sf.MaxMentions, &sf.MaxLinks, &sf.MaxEmojis, &sf.Action)
+	if err == sql.ErrNoRows {
+		return &SpamFilterConfig{GuildID: guildID, Enabled: false, MaxMentions: 5, MaxLinks: 3, MaxEmojis: 10, Action: "delete"}, nil
+	}
+	return &sf, err
+}
+
+func (d *DB) SetSpamFilterConfig(sf *SpamFilterConfig) error {
+	_, err := d.Exec(`INSERT INTO spam_filter_config (guild_id, enabled, max_mentions, max_links, max_emojis, action)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, max_mentions = excluded.max_mentions,
+		max_links = excluded.max_links, max_emojis = excluded.max_emojis, action = excluded.action`,
+		sf.GuildID, sf.Enabled, sf.MaxMentions, sf.MaxLinks, sf.MaxEmojis, sf.Action)
+	return err
+}
+
+// ============ Ticket System ============
+
+func (d *DB) GetTicketConfig(guildID string) (*TicketConfig, error) {
+	tc, err := scanTicketConfig(d.QueryRow(`SELECT `+ticketConfigColumns+` FROM ticket_config WHERE guild_id = ?`, guildID))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	return tc, err
+}
 
-	// Migrate afk_status (message)
-	if err := d.migrateEncryptAFKStatus(); err != nil {
-		return fmt.Errorf("failed to migrate afk_status: %w", err)
-	}
+const ticketConfigColumns = `guild_id, channel_id, enabled, COALESCE(mode, 'thread'),
+	COALESCE(category_id, ''), COALESCE(support_role_id, ''), COALESCE(transcript_channel_id, ''),
+	COALESCE(inactive_hours, 0), COALESCE(inactive_grace_hours, 24)`
 
-	// Migrate reminders (message)
-	if err := d.migrateEncryptReminders(); err != nil {
-		return fmt.Errorf("failed to migrate reminders: %w", err)
+func scanTicketConfig(scanner interface{ Scan(...interface{}) error }) (*TicketConfig, error) {
+	var tc TicketConfig
+	err := scanner.Scan(&tc.GuildID, &tc.ChannelID, &tc.Enabled, &tc.Mode, &tc.CategoryID, &tc.SupportRoleID,
+		&tc.TranscriptChannelID, &tc.InactiveHours, &tc.InactiveGraceHours)
+	if err != nil {
+		return nil, err
 	}
+	return &tc, nil
+}
 
-	// Migrate tags (content)
-	if err := d.migrateEncryptTags(); err != nil {
-		return fmt.Errorf("failed to migrate tags: %w", err)
+// GetTicketAutoCloseConfigs returns the enabled ticket configs that close inactive tickets
+func (d *DB) GetTicketAutoCloseConfigs() ([]TicketConfig, error) {
+	rows, err := d.Query(`SELECT ` + ticketConfigColumns + ` FROM ticket_config WHERE enabled = 1 AND inactive_hours > 0`)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Migrate custom_commands (response)
-	if err := d.migrateEncryptCustomCommands(); err != nil {
-		return fmt.Errorf("failed to migrate custom_commands: %w", err)
+	var configs []TicketConfig
+	for rows.Next() {
+		tc, err := scanTicketConfig(rows)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, *tc)
 	}
+	return configs, rows.Err()
+}
 
-	// Migrate bot_bans (reason)
-	if err := d.migrateEncryptBotBans(); err != nil {
-		return fmt.Errorf("failed to migrate bot_bans: %w", err)
+func (d *DB) SetTicketConfig(tc *TicketConfig) error {
+	if tc.Mode == "" {
+		tc.Mode = TicketModeThread
 	}
-
-	// Migrate mod_actions (reason)
-	if err := d.migrateEncryptModActions(); err != nil {
-		return fmt.Errorf("failed to migrate mod_actions: %w", err)
+	if tc.InactiveGraceHours <= 0 {
+		tc.InactiveGraceHours = 24
 	}
+	_, err := d.Exec(`INSERT INTO ticket_config (guild_id, channel_id, enabled, mode, category_id, support_role_id,
+		transcript_channel_id, inactive_hours, inactive_grace_hours)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		channel_id = excluded.channel_id, enabled = excluded.enabled, mode = excluded.mode,
+		category_id = excluded.category_id, support_role_id = excluded.support_role_id,
+		transcript_channel_id = excluded.transcript_channel_id, inactive_hours = excluded.inactive_hours,
+		inactive_grace_hours = excluded.inactive_grace_hours`,
+		tc.GuildID, tc.ChannelID, tc.Enabled, tc.Mode, tc.CategoryID, tc.SupportRoleID, tc.TranscriptChannelID,
+		tc.InactiveHours, tc.InactiveGraceHours)
+	return err
+}
 
-	// Migrate mention_responses (trigger, response, image_url)
-	if err := d.migrateEncryptMentionResponses(); err != nil {
-		return fmt.Errorf("failed to migrate mention_responses: %w", err)
+func (d *DB) DeleteTicketConfig(guildID string) error {
+	_, err := d.Exec(`DELETE FROM ticket_config WHERE guild_id = ?`, guildID)
+	return err
+}
+
+const ticketColumns = `id, guild_id, number, channel_id, opener_id, subject, status, claimed_by, closed_by,
+	created_at, closed_at, COALESCE(support_role_id, ''), COALESCE(panel_id, 0),
+	COALESCE(transcript_channel_id, ''), COALESCE(transcript_message_id, ''),
+	inactivity_warned_at, COALESCE(inactivity_warning_id, '')`
+
+func scanTicket(scanner interface{ Scan(...interface{}) error }) (*Ticket, error) {
+	var t Ticket
+	var closedAt, warnedAt sql.NullTime
+	err := scanner.Scan(&t.ID, &t.GuildID, &t.Number, &t.ChannelID, &t.OpenerID, &t.Subject,
+		&t.Status, &t.ClaimedBy, &t.ClosedBy, &t.CreatedAt, &closedAt, &t.SupportRoleID, &t.PanelID,
+		&t.TranscriptChannelID, &t.TranscriptMessageID, &warnedAt, &t.InactivityWarningID)
+	if err != nil {
+		return nil, err
 	}
+	if closedAt.Valid {
+		t.ClosedAt = &closedAt.Time
+	}
+	if warnedAt.Valid {
+		t.InactivityWarnedAt = &warnedAt.Time
+	}
+	return &t, nil
+}
 
-	// Migrate regex_filters (reason)
-	if err := d.migrateEncryptRegexFilters(); err != nil {
-		return fmt.Errorf("failed to migrate regex_filters: %w", err)
+// CreateTicket reserves the guild's next ticket number; the channel is attached once it exists
+func (d *DB) CreateTicket(t *Ticket) (*Ticket, error) {
+	result, err := d.Exec(`INSERT INTO tickets (guild_id, number, opener_id, subject, support_role_id, panel_id)
+		SELECT ?, COALESCE(MAX(number), 0) + 1, ?, ?, ?, ? FROM tickets WHERE guild_id = ?`,
+		t.GuildID, t.OpenerID, t.Subject, t.SupportRoleID, t.PanelID, t.GuildID)
+	if err != nil {
+		return nil, err
 	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return scanTicket(d.QueryRow(`SELECT `+ticketColumns+` FROM tickets WHERE id = ?`, id))
+}
 
-	// Mark as migrated
-	if err := d.SetDataMigrated(true); err != nil {
-		return fmt.Errorf("failed to mark migration complete: %w", err)
+// SetTicketChannel attaches the thread or channel a ticket was opened in
+func (d *DB) SetTicketChannel(id int64, channelID string) error {
+	_, err := d.Exec(`UPDATE tickets SET channel_id = ? WHERE id = ?`, channelID, id)
+	return err
+}
+
+// DeleteTicket removes a ticket row whose channel could not be created
+func (d *DB) DeleteTicket(id int64) error {
+	_, err := d.Exec(`DELETE FROM tickets WHERE id = ?`, id)
+	return err
+}
+
+// GetTicketByChannel returns the ticket opened in a thread or channel, or nil if it is not one
+func (d *DB) GetTicketByChannel(channelID string) (*Ticket, error) {
+	t, err := scanTicket(d.QueryRow(`SELECT `+ticketColumns+` FROM tickets WHERE channel_id = ?`, channelID))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
+	return t, err
+}
 
-	fmt.Println("[Database] Encryption migration complete!")
-	return nil
+// GetOpenTicketByUser returns a member's open ticket in a guild, or nil if they have none
+func (d *DB) GetOpenTicketByUser(guildID, userID string) (*Ticket, error) {
+	t, err := scanTicket(d.QueryRow(`SELECT `+ticketColumns+` FROM tickets
+		WHERE guild_id = ? AND opener_id = ? AND status = ? ORDER BY id DESC LIMIT 1`,
+		guildID, userID, TicketStatusOpen))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
 }
 
-func (d *DB) migrateEncryptGuildSettings() error {
-	rows, err := d.Query(`SELECT guild_id, welcome_message, join_dm_title, join_dm_message FROM guild_settings`)
+// GetGuildTickets returns a page of a guild's tickets, newest first
+func (d *DB) GetGuildTickets(guildID string, limit, offset int) ([]Ticket, error) {
+	rows, err := d.Query(`SELECT `+ticketColumns+` FROM tickets WHERE guild_id = ? AND channel_id != ''
+		ORDER BY id DESC LIMIT ? OFFSET ?`, guildID, limit, offset)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
+	var tickets []Ticket
 	for rows.Next() {
-		var guildID string
-		var welcomeMsg, joinTitle, joinMsg *string
-		if err := rows.Scan(&guildID, &welcomeMsg, &joinTitle, &joinMsg); err != nil {
-			return err
-		}
-
-		// Only encrypt if not already encrypted
-		needsUpdate := false
-		if welcomeMsg != nil && *welcomeMsg != "" && !d.IsDataEncrypted(*welcomeMsg) {
-			*welcomeMsg = d.Encrypt(*welcomeMsg)
-			needsUpdate = true
-		}
-		if joinTitle != nil && *joinTitle != "" && !d.IsDataEncrypted(*joinTitle) {
-			*joinTitle = d.Encrypt(*joinTitle)
-			needsUpdate = true
-		}
-		if joinMsg != nil && *joinMsg != "" && !d.IsDataEncrypted(*joinMsg) {
-			*joinMsg = d.Encrypt(*joinMsg)
-			needsUpdate = true
-		}
-
-		if needsUpdate {
-			_, err = d.Exec(`UPDATE guild_settings SET welcome_message = ?, join_dm_title = ?, join_dm_message = ? WHERE guild_id = ?`,
-				welcomeMsg, joinTitle, joinMsg, guildID)
-			if err != nil {
-				return err
-			}
+		t, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
 		}
+		tickets = append(tickets, *t)
 	}
-	return rows.Err()
+	return tickets, rows.Err()
 }
 
-func (d *DB) migrateEncryptWarnings() error {
-	rows, err := d.Query(`SELECT id, reason FROM warnings WHERE reason IS NOT NULL AND reason != ''`)
+// SetTicketTranscript records the message a closed ticket's transcript was posted as
+func (d *DB) SetTicketTranscript(id int64, channelID, messageID string) error {
+	_, err := d.Exec(`UPDATE tickets SET transcript_channel_id = ?, transcript_message_id = ? WHERE id = ?`,
+		channelID, messageID, id)
+	return err
+}
+
+// GetOpenTickets returns every open ticket in a guild
+func (d *DB) GetOpenTickets(guildID string) ([]Ticket, error) {
+	rows, err := d.Query(`SELECT `+ticketColumns+` FROM tickets WHERE guild_id = ? AND status = ? AND channel_id != ''`,
+		guildID, TicketStatusOpen)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
+	var tickets []Ticket
 	for rows.Next() {
-		var id int64
-		var reason string
-		if err := rows.Scan(&id, &reason); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(reason) {
-			_, err = d.Exec(`UPDATE warnings SET reason = ? WHERE id = ?`, d.Encrypt(reason), id)
-			if err != nil {
-				return err
-			}
+		t, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
 		}
+		tickets = append(tickets, *t)
 	}
-	return rows.Err()
+	return tickets, rows.Err()
 }
 
-func (d *DB) migrateEncryptDeletedMessages() error {
-	rows, err := d.Query(`SELECT id, content FROM deleted_messages WHERE content != ''`)
+// SetTicketInactivityWarning records that a ticket was warned it will be closed for inactivity
+func (d *DB) SetTicketInactivityWarning(id int64, messageID string) error {
+	_, err := d.Exec(`UPDATE tickets SET inactivity_warned_at = CURRENT_TIMESTAMP, inactivity_warning_id = ? WHERE id = ?`,
+		messageID, id)
+	return err
+}
+
+// ClearTicketInactivityWarning cancels a pending inactivity close
+func (d *DB) ClearTicketInactivityWarning(id int64) error {
+	_, err := d.Exec(`UPDATE tickets SET inactivity_warned_at = NULL, inactivity_warning_id = '' WHERE id = ?`, id)
+	return err
+}
+
+// CountOpenTickets returns how many tickets are open in a guild
+func (d *DB) CountOpenTickets(guildID string) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM tickets WHERE guild_id = ? AND status = ?`,
+		guildID, TicketStatusOpen).Scan(&count)
+	return count, err
+}
+
+// ClaimTicket records the staff member handling a ticket, reporting false if someone else already has it
+func (d *DB) ClaimTicket(id int64, userID string) (bool, error) {
+	result, err := d.Exec(`UPDATE tickets SET claimed_by = ? WHERE id = ? AND claimed_by = ''`, userID, id)
 	if err != nil {
-		return err
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UnclaimTicket releases a ticket's claim
+func (d *DB) UnclaimTicket(id int64) error {
+	_, err := d.Exec(`UPDATE tickets SET claimed_by = '' WHERE id = ?`, id)
+	return err
+}
+
+// GetTicketStaffStats returns how many tickets each staff member has claimed and resolved, busiest first
+func (d *DB) GetTicketStaffStats(guildID string) ([]TicketStaffStats, error) {
+	rows, err := d.Query(`SELECT claimed_by, COUNT(*),
+		SUM(CASE WHEN status = ? THEN 1 ELSE 0 END),
+		COALESCE(AVG(CASE WHEN status = ? THEN (julianday(closed_at) - julianday(created_at)) * 86400 END), 0)
+		FROM tickets WHERE guild_id = ? AND claimed_by != ''
+		GROUP BY claimed_by ORDER BY COUNT(*) DESC`,
+		TicketStatusClosed, TicketStatusClosed, guildID)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
+	var stats []TicketStaffStats
 	for rows.Next() {
-		var id int64
-		var content string
-		if err := rows.Scan(&id, &content); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(content) {
-			_, err = d.Exec(`UPDATE deleted_messages SET content = ? WHERE id = ?`, d.Encrypt(content), id)
-			if err != nil {
-				return err
-			}
+		var st TicketStaffStats
+		var avg float64
+		if err := rows.Scan(&st.UserID, &st.Claimed, &st.Resolved, &avg); err != nil {
+			return nil, err
 		}
+		st.AvgResolveSeconds = int64(avg)
+		stats = append(stats, st)
 	}
-	return rows.Err()
+	return stats, rows.Err()
 }
 
-func (d *DB) migrateEncryptUserNotes() error {
-	rows, err := d.Query(`SELECT id, note FROM user_notes WHERE note != ''`)
+// CloseTicket marks a ticket closed by the given user
+func (d *DB) CloseTicket(id int64, closedBy string) error {
+	_, err := d.Exec(`UPDATE tickets SET status = ?, closed_by = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		TicketStatusClosed, closedBy, id)
+	return err
+}
+
+const ticketPanelColumns = `id, guild_id, channel_id, message_id, title, description, button_label,
+	mode, category_id, support_role_id, created_by, created_at`
+
+func scanTicketPanel(scanner interface{ Scan(...interface{}) error }) (*TicketPanel, error) {
+	var p TicketPanel
+	err := scanner.Scan(&p.ID, &p.GuildID, &p.ChannelID, &p.MessageID, &p.Title, &p.Description, &p.ButtonLabel,
+		&p.Mode, &p.CategoryID, &p.SupportRoleID, &p.CreatedBy, &p.CreatedAt)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer rows.Close()
+	return &p, nil
+}
 
-	for rows.Next() {
-		var id int64
-		var note string
-		if err := rows.Scan(&id, &note); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(note) {
-			_, err = d.Exec(`UPDATE user_notes SET note = ? WHERE id = ?`, d.Encrypt(note), id)
-			if err != nil {
-				return err
-			}
-		}
+func (d *DB) CreateTicketPanel(p *TicketPanel) (int64, error) {
+	result, err := d.Exec(`INSERT INTO ticket_panels (guild_id, channel_id, title, description, button_label,
+		mode, category_id, support_role_id, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.GuildID, p.ChannelID, p.Title, p.Description, p.ButtonLabel, p.Mode, p.CategoryID, p.SupportRoleID, p.CreatedBy)
+	if err != nil {
+		return 0, err
 	}
-	return rows.Err()
+	return result.LastInsertId()
 }
 
-func (d *DB) migrateEncryptScheduledMessages() error {
-	rows, err := d.Query(`SELECT id, message FROM scheduled_messages WHERE message != ''`)
+// SetTicketPanelMessage records the message a panel was posted as
+func (d *DB) SetTicketPanelMessage(panelID int64, messageID string) error {
+	_, err := d.Exec(`UPDATE ticket_panels SET message_id = ? WHERE id = ?`, messageID, panelID)
+	return err
+}
+
+// GetTicketPanel returns a ticket panel, or nil if it does not exist
+func (d *DB) GetTicketPanel(panelID int64) (*TicketPanel, error) {
+	p, err := scanTicketPanel(d.QueryRow(`SELECT `+ticketPanelColumns+` FROM ticket_panels WHERE id = ?`, panelID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+func (d *DB) GetTicketPanels(guildID string) ([]TicketPanel, error) {
+	rows, err := d.Query(`SELECT `+ticketPanelColumns+` FROM ticket_panels WHERE guild_id = ? ORDER BY id`, guildID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
+	var panels []TicketPanel
 	for rows.Next() {
-		var id int64
-		var message string
-		if err := rows.Scan(&id, &message); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(message) {
-			_, err = d.Exec(`UPDATE scheduled_messages SET message = ? WHERE id = ?`, d.Encrypt(message), id)
-			if err != nil {
-				return err
-			}
+		p, err := scanTicketPanel(rows)
+		if err != nil {
+			return nil, err
 		}
+		panels = append(panels, *p)
 	}
-	return rows.Err()
+	return panels, rows.Err()
+}
+
+func (d *DB) DeleteTicketPanel(guildID string, panelID int64) error {
+	_, err := d.Exec(`DELETE FROM ticket_panels WHERE id = ? AND guild_id = ?`, panelID, guildID)
+	return err
+}
+
+// ============ Anti-Raid System ============
+
+func (d *DB) GetAntiRaidConfig(guildID string) (*AntiRaidConfig, error) {
+	var cfg AntiRaidConfig
+	var silentRole, alertRole, logChannel sql.NullString
+	err := d.QueryRow(`SELECT guild_id, enabled, raid_time, raid_size, auto_silence,
+		lockdown_duration, silent_role_id, alert_role_id, log_channel_id, action
+		FROM antiraid_config WHERE guild_id = ?`, guildID).Scan(
+		&cfg.GuildID, &cfg.Enabled, &cfg.RaidTime, &cfg.RaidSize, &cfg.AutoSilence,
+		&cfg.LockdownDuration, &silentRole, &alertRole, &logChannel, &cfg.Action)
+	if err == sql.ErrNoRows {
+		return &AntiRaidConfig{
+			GuildID:          guildID,
+			Enabled:          false,
+			RaidTime:         300,
+			RaidSize:         5,
+			AutoSilence:      0,
+			LockdownDuration: 120,
+			Action:           "silence",
+		}, nil
+	}
+	if silentRole.Valid {
+		cfg.SilentRoleID = silentRole.String
+	}
+	if alertRole.Valid {
+		cfg.AlertRoleID = alertRole.String
+	}
+	if logChannel.Valid {
+		cfg.LogChannelID = logChannel.String
+	}
+	return &cfg, err
+}
+
+func (d *DB) SetAntiRaidConfig(cfg *AntiRaidConfig) error {
+	_, err := d.Exec(`INSERT INTO antiraid_config (guild_id, enabled, raid_time, raid_size, auto_silence,
+		lockdown_duration, silent_role_id, alert_role_id, log_channel_id, action)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, raid_time = excluded.raid_time, raid_size = excluded.raid_size,
+		auto_silence = excluded.auto_silence, lockdown_duration = excluded.lockdown_duration,
+		silent_role_id = excluded.silent_role_id, alert_role_id = excluded.alert_role_id,
+		log_channel_id = excluded.log_channel_id, action = excluded.action`,
+		cfg.GuildID, cfg.Enabled, cfg.RaidTime, cfg.RaidSize, cfg.AutoSilence,
+		cfg.LockdownDuration, cfg.SilentRoleID, cfg.AlertRoleID, cfg.LogChannelID, cfg.Action)
+	return err
 }
 
-func (d *DB) migrateEncryptAFKStatus() error {
-	rows, err := d.Query(`SELECT user_id, message FROM afk_status WHERE message IS NOT NULL AND message != ''`)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
+func (d *DB) RecordMemberJoin(guildID, userID string, joinedAt, accountCreatedAt int64) error {
+	_, err := d.Exec(`INSERT INTO member_joins (guild_id, user_id, joined_at, account_created_at)
+		VALUES (?, ?, ?, ?)`, guildID, userID, joinedAt, accountCreatedAt)
+	return err
+}
 
-	for rows.Next() {
-		var userID, message string
-		if err := rows.Scan(&userID, &message); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(message) {
-			_, err = d.Exec(`UPDATE afk_status SET message = ? WHERE user_id = ?`, d.Encrypt(message), userID)
-			if err != nil {
-				return err
-			}
-		}
-	}
-	return rows.Err()
+func (d *DB) CountRecentJoins(guildID string, sinceTimestamp int64) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM member_joins WHERE guild_id = ? AND joined_at >= ?`,
+		guildID, sinceTimestamp).Scan(&count)
+	return count, err
 }
 
-func (d *DB) migrateEncryptReminders() error {
-	rows, err := d.Query(`SELECT id, message FROM reminders WHERE message != ''`)
+func (d *DB) GetRecentJoins(guildID string, sinceTimestamp int64) ([]MemberJoin, error) {
+	rows, err := d.Query(`SELECT id, guild_id, user_id, joined_at, account_created_at
+		FROM member_joins WHERE guild_id = ? AND joined_at >= ? ORDER BY joined_at DESC`,
+		guildID, sinceTimestamp)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
+	var joins []MemberJoin
 	for rows.Next() {
-		var id int64
-		var message string
-		if err := rows.Scan(&id, &message); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(message) {
-			_, err = d.Exec(`UPDATE reminders SET message = ? WHERE id = ?`, d.Encrypt(message), id)
-			if err != nil {
-				return err
-			}
+		var mj MemberJoin
+		if err := rows.Scan(&mj.ID, &mj.GuildID, &mj.UserID, &mj.JoinedAt, &mj.AccountCreatedAt); err != nil {
+			return nil, err
 		}
+		joins = append(joins, mj)
 	}
-	return rows.Err()
+	return joins, rows.Err()
 }
 
-func (d *DB) migrateEncryptTags() error {
-	rows, err := d.Query(`SELECT id, content FROM tags WHERE content != ''`)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var id int64
-		var content string
-		if err := rows.Scan(&id, &content); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(content) {
-			_, err = d.Exec(`UPDATE tags SET content = ? WHERE id = ?`, d.Encrypt(content), id)
-			if err != nil {
-				return err
-			}
-		}
-	}
-	return rows.Err()
+func (d *DB) CleanOldJoins(guildID string, beforeTimestamp int64) error {
+	_, err := d.Exec(`DELETE FROM member_joins WHERE guild_id = ? AND joined_at < ?`,
+		guildID, beforeTimestamp)
+	return err
 }
 
-func (d *DB) migrateEncryptCustomCommands() error {
-	rows, err := d.Query(`SELECT id, response FROM custom_commands WHERE response != ''`)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
+// ============ Anti-Spam System ============
 
-	for rows.Next() {
-		var id int64
-		var response string
-		if err := rows.Scan(&id, &response); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(response) {
-			_, err = d.Exec(`UPDATE custom_commands SET response = ? WHERE id = ?`, d.Encrypt(response), id)
-			if err != nil {
-				return err
-			}
-		}
+func (d *DB) GetAntiSpamConfig(guildID string) (*AntiSpamConfig, error) {
+	var cfg AntiSpamConfig
+	var silentRole sql.NullString
+	err := d.QueryRow(`SELECT guild_id, enabled, base_pressure, image_pressure, link_pressure,
+		ping_pressure, length_pressure, line_pressure, repeat_pressure, max_pressure,
+		pressure_decay, action, silent_role_id FROM antispam_config WHERE guild_id = ?`, guildID).Scan(
+		&cfg.GuildID, &cfg.Enabled, &cfg.BasePressure, &cfg.ImagePressure, &cfg.LinkPressure,
+		&cfg.PingPressure, &cfg.LengthPressure, &cfg.LinePressure, &cfg.RepeatPressure,
+		&cfg.MaxPressure, &cfg.PressureDecay, &cfg.Action, &silentRole)
+	if err == sql.ErrNoRows {
+		return &AntiSpamConfig{
+			GuildID:        guildID,
+			Enabled:        false,
+			BasePressure:   10.0,
+			ImagePressure:  8.33,
+			LinkPressure:   8.33,
+			PingPressure:   2.5,
+			LengthPressure: 0.00625,
+			LinePressure:   0.71,
+			RepeatPressure: 10.0,
+			MaxPressure:    60.0,
+			PressureDecay:  2.5,
+			Action:         "delete",
+		}, nil
 	}
-	return rows.Err()
+	if silentRole.Valid {
+		cfg.SilentRoleID = silentRole.String
+	}
+	return &cfg, err
 }
 
-func (d *DB) migrateEncryptBotBans() error {
-	rows, err := d.Query(`SELECT target_id, reason FROM bot_bans WHERE reason IS NOT NULL AND reason != ''`)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
+func (d *DB) SetAntiSpamConfig(cfg *AntiSpamConfig) error {
+	_, err := d.Exec(`INSERT INTO antispam_config (guild_id, enabled, base_pressure, image_pressure,
+		link_pressure, ping_pressure, length_pressure, line_pressure, repeat_pressure,
+		max_pressure, pressure_decay, action, silent_role_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		enabled = excluded.enabled, base_pressure = excluded.base_pressure,
+		image_pressure = excluded.image_pressure, link_pressure = excluded.link_pressure,
+		ping_pressure = excluded.ping_pressure, length_pressure = excluded.length_pressure,
+		line_pressure = excluded.line_pressure, repeat_pressure = excluded.repeat_pressure,
+		max_pressure = excluded.max_pressure, pressure_decay = excluded.pressure_decay,
+		action = excluded.action, silent_role_id = excluded.silent_role_id`,
+		cfg.GuildID, cfg.Enabled, cfg.BasePressure, cfg.ImagePressure, cfg.LinkPressure,
+		cfg.PingPressure, cfg.LengthPressure, cfg.LinePressure, cfg.RepeatPressure,
+		cfg.MaxPressure, cfg.PressureDecay, cfg.Action, cfg.SilentRoleID)
+	return err
+}
 
-	for rows.Next() {
-		var targetID, reason string
-		if err := rows.Scan(&targetID, &reason); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(reason) {
-			_, err = d.Exec(`UPDATE bot_bans SET reason = ? WHERE target_id = ?`, d.Encrypt(reason), targetID)
-			if err != nil {
-				return err
-			}
-		}
-	}
-	return rows.Err()
+// ============ Scheduled Events ============
+
+func (d *DB) AddScheduledEvent(guildID, eventType, targetID string, executeAt int64) error {
+	_, err := d.Exec(`INSERT INTO scheduled_events (guild_id, event_type, target_id, execute_at)
+		VALUES (?, ?, ?, ?)`, guildID, eventType, targetID, executeAt)
+	return err
 }
 
-func (d *DB) migrateEncryptModActions() error {
-	rows, err := d.Query(`SELECT id, reason FROM mod_actions WHERE reason IS NOT NULL AND reason != ''`)
+func (d *DB) GetDueEvents(beforeTimestamp int64) ([]ScheduledEvent, error) {
+	rows, err := d.Query(`SELECT id, guild_id, event_type, target_id, execute_at
+		FROM scheduled_events WHERE execute_at <= ?`, beforeTimestamp)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
+	var events []ScheduledEvent
 	for rows.Next() {
-		var id int64
-		var reason string
-		if err := rows.Scan(&id, &reason); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(reason) {
-			_, err = d.Exec(`UPDATE mod_actions SET reason = ? WHERE id = ?`, d.Encrypt(reason), id)
-			if err != nil {
-				return err
-			}
+		var ev ScheduledEvent
+		if err := rows.Scan(&ev.ID, &ev.GuildID, &ev.EventType, &ev.TargetID, &ev.ExecuteAt); err != nil {
+			return nil, err
 		}
+		events = append(events, ev)
 	}
-	return rows.Err()
+	return events, rows.Err()
 }
 
-func (d *DB) migrateEncryptMentionResponses() error {
-	rows, err := d.Query(`SELECT id, trigger_text, response, image_url FROM mention_responses`)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
+func (d *DB) DeleteScheduledEvent(id int64) error {
+	_, err := d.Exec(`DELETE FROM scheduled_events WHERE id = ?`, id)
+	return err
+}
 
-	for rows.Next() {
-		var id int64
-		var trigger, response string
-		var imageURL *string
-		if err := rows.Scan(&id, &trigger, &response, &imageURL); err != nil {
-			return err
-		}
+func (d *DB) DeleteScheduledEventByTarget(guildID, eventType, targetID string) error {
+	_, err := d.Exec(`DELETE FROM scheduled_events WHERE guild_id = ? AND event_type = ? AND target_id = ?`,
+		guildID, eventType, targetID)
+	return err
+}
 
-		needsUpdate := false
-		if !d.IsDataEncrypted(trigger) {
-			trigger = d.Encrypt(trigger)
-			needsUpdate = true
-		}
-		if !d.IsDataEncrypted(response) {
-			response = d.Encrypt(response)
-			needsUpdate = true
-		}
-		if imageURL != nil && *imageURL != "" && !d.IsDataEncrypted(*imageURL) {
-			*imageURL = d.Encrypt(*imageURL)
-			needsUpdate = true
-		}
+// ============ User Aliases ============
 
-		if needsUpdate {
-			_, err = d.Exec(`UPDATE mention_responses SET trigger_text = ?, response = ?, image_url = ? WHERE id = ?`,
-				trigger, response, imageURL, id)
-			if err != nil {
-				return err
-			}
+func (d *DB) RecordAlias(userID, alias, aliasType string) error {
+	_, err := d.Exec(`INSERT INTO user_aliases (user_id, alias, alias_type, last_seen, use_count)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP, 1)
+		ON CONFLICT(user_id, alias, alias_type) DO UPDATE SET
+		last_seen = CURRENT_TIMESTAMP, use_count = use_count + 1`,
+		userID, alias, aliasType)
+	return err
+}
+
+func (d *DB) GetUserAliases(userID string, limit int) ([]UserAlias, error) {
+	rows, err := d.Query(`SELECT id, user_id, alias, alias_type, first_seen, last_seen, use_count
+		FROM user_aliases WHERE user_id = ? ORDER BY use_count DESC, last_seen DESC LIMIT ?`,
+		userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make([]UserAlias, 0, limit)
+	for rows.Next() {
+		var a UserAlias
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Alias, &a.AliasType, &a.FirstSeen, &a.LastSeen, &a.UseCount); err != nil {
+			return nil, err
 		}
+		aliases = append(aliases, a)
 	}
-	return rows.Err()
+	return aliases, rows.Err()
 }
 
-func (d *DB) migrateEncryptRegexFilters() error {
-	rows, err := d.Query(`SELECT id, reason FROM regex_filters WHERE reason IS NOT NULL AND reason != ''`)
+func (d *DB) SearchUserByAlias(alias string) ([]string, error) {
+	rows, err := d.Query(`SELECT DISTINCT user_id FROM user_aliases WHERE alias LIKE ? LIMIT 10`,
+		"%"+alias+"%")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
+	userIDs := make([]string, 0, 10)
 	for rows.Next() {
-		var id int64
-		var reason string
-		if err := rows.Scan(&id, &reason); err != nil {
-			return err
-		}
-		if !d.IsDataEncrypted(reason) {
-			_, err = d.Exec(`UPDATE regex_filters SET reason = ? WHERE id = ?`, d.Encrypt(reason), id)
-			if err != nil {
-				return err
-			}
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
 		}
+		userIDs = append(userIDs, userID)
 	}
-	return rows.Err()
+	return userIDs, rows.Err()
 }
 
-// Guild Settings
-func (d *DB) GetGuildSettings(guildID string) (*GuildSettings, error) {
-	var gs GuildSettings
-	err := d.QueryRow(`SELECT guild_id, prefix, mod_log_channel, welcome_channel, welcome_message, join_dm_title, join_dm_message
-		FROM guild_settings WHERE guild_id = ?`, guildID).Scan(
-		&gs.GuildID, &gs.Prefix, &gs.ModLogChannel, &gs.WelcomeChannel, &gs.WelcomeMessage, &gs.JoinDMTitle, &gs.JoinDMMessage)
-	if err == sql.ErrNoRows {
-		return &GuildSettings{GuildID: guildID, Prefix: "/"}, nil
-	}
-	if err == nil {
-		// Decrypt sensitive fields
-		gs.WelcomeMessage = d.DecryptNullable(gs.WelcomeMessage)
-		gs.JoinDMTitle = d.DecryptNullable(gs.JoinDMTitle)
-		gs.JoinDMMessage = d.DecryptNullable(gs.JoinDMMessage)
-	}
-	return &gs, err
-}
+// ============ User Activity ============
 
-func (d *DB) SetGuildSettings(gs *GuildSettings) error {
-	// Encrypt sensitive fields
-	welcomeMsg := d.EncryptNullable(gs.WelcomeMessage)
-	joinTitle := d.EncryptNullable(gs.JoinDMTitle)
-	joinMsg := d.EncryptNullable(gs.JoinDMMessage)
+func (d *DB) UpdateUserActivity(guildID, userID string, isMessage bool) error {
+	now := time.Now()
 
-	_, err := d.Exec(`INSERT INTO guild_settings (guild_id, prefix, mod_log_channel, welcome_channel, welcome_message, join_dm_title, join_dm_message, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(guild_id) DO UPDATE SET
-		prefix = excluded.prefix,
-		mod_log_channel = excluded.mod_log_channel,
-		welcome_channel = excluded.welcome_channel,
-		welcome_message = excluded.welcome_message,
-		join_dm_title = excluded.join_dm_title,
-		join_dm_message = excluded.join_dm_message,
-		updated_at = CURRENT_TIMESTAMP`,
-		gs.GuildID, gs.Prefix, gs.ModLogChannel, gs.WelcomeChannel, welcomeMsg, joinTitle, joinMsg)
+	// Message counts are kept in activity_member_daily, plus legacy_messages from before it existed
+	if isMessage {
+		_, err := d.Exec(`INSERT INTO user_activity (guild_id, user_id, first_seen, first_message, last_seen, message_count)
+			VALUES (?, ?, ?, ?, ?, 0)
+			ON CONFLICT(guild_id, user_id) DO UPDATE SET
+			last_seen = ?,
+			first_message = COALESCE(first_message, ?)`,
+			guildID, userID, now, now, now, now, now)
+		return err
+	}
+
+	_, err := d.Exec(`INSERT INTO user_activity (guild_id, user_id, first_seen, last_seen, message_count)
+		VALUES (?, ?, ?, ?, 0)
+		ON CONFLICT(guild_id, user_id) DO UPDATE SET last_seen = ?`,
+		guildID, userID, now, now, now)
 	return err
 }
 
-// Custom Commands
-func (d *DB) GetCustomCommand(guildID, name string) (*CustomCommand, error) {
-	var cc CustomCommand
-	err := d.QueryRow(`SELECT id, guild_id, name, response, created_by, use_count
-		FROM custom_commands WHERE guild_id = ? AND name = ?`, guildID, name).Scan(
-		&cc.ID, &cc.GuildID, &cc.Name, &cc.Response, &cc.CreatedBy, &cc.UseCount)
+func (d *DB) GetUserActivity(guildID, userID string) (*UserActivity, error) {
+	var ua UserActivity
+	err := d.QueryRow(`SELECT guild_id, user_id, first_seen, first_message, last_seen,
+		COALESCE(u.legacy_messages, 0) + (SELECT COALESCE(SUM(messages), 0) FROM activity_member_daily a WHERE a.guild_id = u.guild_id AND a.user_id = u.user_id)
+		FROM user_activity u WHERE guild_id = ? AND user_id = ?`, guildID, userID).Scan(
+		&ua.GuildID, &ua.UserID, &ua.FirstSeen, &ua.FirstMessage, &ua.LastSeen, &ua.MessageCount)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err == nil {
-		cc.Response = d.Decrypt(cc.Response)
-	}
-	return &cc, err
-}
-
-func (d *DB) CreateCustomCommand(guildID, name, response, createdBy string) error {
-	_, err := d.Exec(`INSERT INTO custom_commands (guild_id, name, response, created_by) VALUES (?, ?, ?, ?)`,
-		guildID, name, d.Encrypt(response), createdBy)
-	return err
-}
-
-func (d *DB) DeleteCustomCommand(guildID, name string) error {
-	_, err := d.Exec(`DELETE FROM custom_commands WHERE guild_id = ? AND name = ?`, guildID, name)
-	return err
+	return &ua, err
 }
 
-func (d *DB) ListCustomCommands(guildID string) ([]CustomCommand, error) {
-	rows, err := d.Query(`SELECT id, guild_id, name, response, created_by, use_count
-		FROM custom_commands WHERE guild_id = ? ORDER BY name`, guildID)
+func (d *DB) GetNewestMembers(guildID string, limit int) ([]UserActivity, error) {
+	rows, err := d.Query(`SELECT guild_id, user_id, first_seen, first_message, last_seen,
+		COALESCE(u.legacy_messages, 0) + (SELECT COALESCE(SUM(messages), 0) FROM activity_member_daily a WHERE a.guild_id = u.guild_id AND a.user_id = u.user_id)
+		FROM user_activity u WHERE guild_id = ? ORDER BY first_seen DESC LIMIT ?`,
+		guildID, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var commands []CustomCommand
+	activities := make([]UserActivity, 0, limit)
 	for rows.Next() {
-		var cc CustomCommand
-		if err := rows.Scan(&cc.ID, &cc.GuildID, &cc.Name, &cc.Response, &cc.CreatedBy, &cc.UseCount); err != nil {
+		var ua UserActivity
+		if err := rows.Scan(&ua.GuildID, &ua.UserID, &ua.FirstSeen, &ua.FirstMessage, &ua.LastSeen, &ua.MessageCount); err != nil {
 			return nil, err
 		}
-		cc.Response = d.Decrypt(cc.Response)
-		commands = append(commands, cc)
+		activities = append(activities, ua)
 	}
-	return commands, rows.Err()
+	return activities, rows.Err()
 }
 
-func (d *DB) IncrementCommandUse(guildID, name string) error {
-	_, err := d.Exec(`UPDATE custom_commands SET use_count = use_count + 1 WHERE guild_id = ? AND name = ?`,
-		guildID, name)
-	return err
-}
+// ============ Activity Analytics ============
 
-// Command History
-func (d *DB) LogCommand(guildID, channelID, userID, command, args string) error {
-	_, err := d.Exec(`INSERT INTO command_history (guild_id, channel_id, user_id, command, args) VALUES (?, ?, ?, ?, ?)`,
-		guildID, channelID, userID, command, args)
-	return err
+// FlushActivity adds a batch of counted activity to the rollup tables in one transaction
+func (d *DB) FlushActivity(batch *ActivityBatch) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, h := range batch.Hours {
+		if _, err := tx.Exec(`INSERT INTO activity_hourly (guild_id, channel_id, day, hour, messages) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(guild_id, channel_id, day, hour) DO UPDATE SET messages = messages + excluded.messages`,
+			h.GuildID, h.ChannelID, h.Day, h.Hour, h.Messages); err != nil {
+			return err
+		}
+	}
+	for _, day := range batch.Days {
+		if _, err := tx.Exec(`INSERT INTO activity_daily (guild_id, day, messages, joins, leaves) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(guild_id, day) DO UPDATE SET messages = messages + excluded.messages,
+			joins = joins + excluded.joins, leaves = leaves + excluded.leaves`,
+			day.GuildID, day.Day, day.Messages, day.Joins, day.Leaves); err != nil {
+			return err
+		}
+	}
+	for _, m := range batch.Members {
+		if _, err := tx.Exec(`INSERT INTO activity_member_daily (guild_id, user_id, day, messages) VALUES (?, ?, ?, ?)
+			ON CONFLICT(guild_id, user_id, day) DO UPDATE SET messages = messages + excluded.messages`,
+			m.GuildID, m.UserID, m.Day, m.Messages); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func (d *DB) GetCommandHistory(guildID string, limit int) ([]CommandHistory, error) {
-	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, command, args, executed_at
-		FROM command_history WHERE guild_id = ? ORDER BY executed_at DESC LIMIT ?`, guildID, limit)
+// GetActivityDays returns a guild's daily totals from sinceDay (YYYY-MM-DD) on, oldest
+// first. Days without any activity are missing.
+func (d *DB) GetActivityDays(guildID, sinceDay string) ([]ActivityDay, error) {
+	rows, err := d.Query(`SELECT guild_id, day, messages, joins, leaves FROM activity_daily
+		WHERE guild_id = ? AND day >= ? ORDER BY day`, guildID, sinceDay)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	history := make([]CommandHistory, 0, limit)
+	var days []ActivityDay
 	for rows.Next() {
-		var ch CommandHistory
-		if err := rows.Scan(&ch.ID, &ch.GuildID, &ch.ChannelID, &ch.UserID, &ch.Command, &ch.Args, &ch.ExecutedAt); err != nil {
+		var day ActivityDay
+		if err := rows.Scan(&day.GuildID, &day.Day, &day.Messages, &day.Joins, &day.Leaves); err != nil {
 			return nil, err
 		}
-		history = append(history, ch)
+		days = append(days, day)
 	}
-	return history, rows.Err()
-}
-
-// Warnings
-func (d *DB) AddWarning(guildID, userID, moderatorID, reason string) error {
-	_, err := d.Exec(`INSERT INTO warnings (guild_id, user_id, moderator_id, reason) VALUES (?, ?, ?, ?)`,
-		guildID, userID, moderatorID, d.Encrypt(reason))
-	return err
+	return days, rows.Err()
 }
 
-func (d *DB) GetWarnings(guildID, userID string) ([]Warning, error) {
-	rows, err := d.Query(`SELECT id, guild_id, user_id, moderator_id, reason, created_at
-		FROM warnings WHERE guild_id = ? AND user_id = ? ORDER BY created_at DESC`, guildID, userID)
+// GetChannelActivityDays returns a channel's messages per day from sinceDay on
+func (d *DB) GetChannelActivityDays(guildID, channelID, sinceDay string) (map[string]int, error) {
+	rows, err := d.Query(`SELECT day, SUM(messages) FROM activity_hourly
+		WHERE guild_id = ? AND channel_id = ? AND day >= ? GROUP BY day`, guildID, channelID, sinceDay)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var warnings []Warning
+	days := make(map[string]int)
 	for rows.Next() {
-		var w Warning
-		if err := rows.Scan(&w.ID, &w.GuildID, &w.UserID, &w.ModeratorID, &w.Reason, &w.CreatedAt); err != nil {
+		var day string
+		var messages int
+		if err := rows.Scan(&day, &messages); err != nil {
 			return nil, err
 		}
-		w.Reason = d.DecryptNullable(w.Reason)
-		warnings = append(warnings, w)
+		days[day] = messages
 	}
-	return warnings, rows.Err()
+	return days, rows.Err()
 }
 
-func (d *DB) ClearWarnings(guildID, userID string) error {
-	_, err := d.Exec(`DELETE FROM warnings WHERE guild_id = ? AND user_id = ?`, guildID, userID)
-	return err
+// GetActivityHours returns messages per hour of the day (UTC) from sinceDay on,
+// optionally for a single channel
+func (d *DB) GetActivityHours(guildID, channelID, sinceDay string) ([24]int, error) {
+	var hours [24]int
+	query := `SELECT hour, SUM(messages) FROM activity_hourly WHERE guild_id = ? AND day >= ?`
+	args := []interface{}{guildID, sinceDay}
+	if channelID != "" {
+		query += ` AND channel_id = ?`
+		args = append(args, channelID)
+	}
+	rows, err := d.Query(query+` GROUP BY hour`, args...)
+	if err != nil {
+		return hours, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hour, messages int
+		if err := rows.Scan(&hour, &messages); err != nil {
+			return hours, err
+		}
+		if hour >= 0 && hour < 24 {
+			hours[hour] = messages
+		}
+	}
+	return hours, rows.Err()
 }
 
-func (d *DB) DeleteWarning(id int64) error {
-	_, err := d.Exec(`DELETE FROM warnings WHERE id = ?`, id)
-	return err
+// GetTopActivityChannels returns the channels with the most messages from sinceDay on
+func (d *DB) GetTopActivityChannels(guildID, sinceDay string, limit int) ([]ActivityCount, error) {
+	return d.queryActivityCounts(`SELECT channel_id, SUM(messages) AS total FROM activity_hourly
+		WHERE guild_id = ? AND day >= ? GROUP BY channel_id ORDER BY total DESC LIMIT ?`, guildID, sinceDay, limit)
 }
 
-// Deleted Messages (for snipe)
-func (d *DB) LogDeletedMessage(guildID, channelID, userID, content string) error {
-	_, err := d.Exec(`INSERT INTO deleted_messages (guild_id, channel_id, user_id, content) VALUES (?, ?, ?, ?)`,
-		guildID, channelID, userID, d.Encrypt(content))
-	return err
+// GetTopActivityMembers returns the members with the most messages from sinceDay on
+func (d *DB) GetTopActivityMembers(guildID, sinceDay string, limit int) ([]ActivityCount, error) {
+	return d.queryActivityCounts(`SELECT user_id, SUM(messages) AS total FROM activity_member_daily
+		WHERE guild_id = ? AND day >= ? GROUP BY user_id ORDER BY total DESC LIMIT ?`, guildID, sinceDay, limit)
 }
 
-func (d *DB) GetDeletedMessages(channelID string, limit int) ([]DeletedMessage, error) {
-	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, content, deleted_at
-		FROM deleted_messages WHERE channel_id = ? ORDER BY deleted_at DESC LIMIT ?`, channelID, limit)
+func (d *DB) queryActivityCounts(query string, args ...interface{}) ([]ActivityCount, error) {
+	rows, err := d.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	messages := make([]DeletedMessage, 0, limit)
+	var counts []ActivityCount
 	for rows.Next() {
-		var dm DeletedMessage
-		if err := rows.Scan(&dm.ID, &dm.GuildID, &dm.ChannelID, &dm.UserID, &dm.Content, &dm.DeletedAt); err != nil {
+		var c ActivityCount
+		if err := rows.Scan(&c.ID, &c.Messages); err != nil {
 			return nil, err
 		}
-		dm.Content = d.Decrypt(dm.Content)
-		messages = append(messages, dm)
+		counts = append(counts, c)
 	}
-	return messages, rows.Err()
+	return counts, rows.Err()
 }
 
-func (d *DB) CleanOldDeletedMessages(olderThan time.Duration) error {
-	cutoff := time.Now().Add(-olderThan)
-	_, err := d.Exec(`DELETE FROM deleted_messages WHERE deleted_at < ?`, cutoff)
+// CountActiveMembers returns how many members sent a message from sinceDay on
+func (d *DB) CountActiveMembers(guildID, sinceDay string) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(DISTINCT user_id) FROM activity_member_daily WHERE guild_id = ? AND day >= ?`,
+		guildID, sinceDay).Scan(&count)
+	return count, err
+}
+
+// CleanOldActivityHours drops per-channel hourly counts from before beforeDay. Daily
+// totals are kept.
+func (d *DB) CleanOldActivityHours(beforeDay string) error {
+	_, err := d.Exec(`DELETE FROM activity_hourly WHERE day < ?`, beforeDay)
 	return err
 }
 
-// Scheduled Messages
-func (d *DB) ScheduleMessage(guildID, channelID, userID, message string, scheduledFor time.Time) error {
-	_, err := d.Exec(`INSERT INTO scheduled_messages (guild_id, channel_id, user_id, message, scheduled_for) VALUES (?, ?, ?, ?, ?)`,
-		guildID, channelID, userID, d.Encrypt(message), scheduledFor)
+// ============ User Timezones ============
+
+func (d *DB) SetUserTimezone(userID, timezone string) error {
+	_, err := d.Exec(`INSERT INTO user_timezones (user_id, timezone, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id) DO UPDATE SET timezone = excluded.timezone, updated_at = CURRENT_TIMESTAMP`,
+		userID, timezone)
 	return err
 }
 
-func (d *DB) GetPendingScheduledMessages() ([]ScheduledMessage, error) {
-	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, message, scheduled_for
-		FROM scheduled_messages WHERE executed = 0 AND scheduled_for <= ? ORDER BY scheduled_for`, time.Now())
-	if err != nil {
-		return nil, err
+func (d *DB) GetUserTimezone(userID string) (string, error) {
+	var tz string
+	err := d.QueryRow(`SELECT timezone FROM user_timezones WHERE user_id = ?`, userID).Scan(&tz)
+	if err == sql.ErrNoRows {
+		return "", nil
 	}
-	defer rows.Close()
+	return tz, err
+}
 
-	var messages []ScheduledMessage
-	for rows.Next() {
-		var sm ScheduledMessage
-		if err := rows.Scan(&sm.ID, &sm.GuildID, &sm.ChannelID, &sm.UserID, &sm.Message, &sm.ScheduledFor); err != nil {
-			return nil, err
-		}
-		sm.Message = d.Decrypt(sm.Message)
-		messages = append(messages, sm)
+func (d *DB) DeleteUserTimezone(userID string) error {
+	_, err := d.Exec(`DELETE FROM user_timezones WHERE user_id = ?`, userID)
+	return err
+}
+
+// ============ Music Settings ============
+
+func (d *DB) GetMusicSettings(guildID string) (*MusicSettings, error) {
+	var ms MusicSettings
+	var textChannels, sponsorBlockCategories string
+	err := d.QueryRow(`SELECT guild_id, dj_role_id, mod_role_id, volume, music_folder,
+		COALESCE(playlist_limit, 10), COALESCE(playlist_track_limit, 100), COALESCE(stay_connected, 0),
+		COALESCE(autoplay, 0), COALESCE(audio_filters, ''), COALESCE(text_channels, ''),
+		COALESCE(normalize, 0), COALESCE(max_queue_length, 0), COALESCE(max_track_duration, 0), COALESCE(max_user_tracks, 0),
+		COALESCE(sponsorblock, 0), COALESCE(sponsorblock_categories, '')
+		FROM music_settings WHERE guild_id = ?`, guildID).Scan(
+		&ms.GuildID, &ms.DJRoleID, &ms.ModRoleID, &ms.Volume, &ms.MusicFolder,
+		&ms.PlaylistLimit, &ms.PlaylistTrackLimit, &ms.StayConnected, &ms.Autoplay, &ms.AudioFilters, &textChannels,
+		&ms.Normalize, &ms.MaxQueueLength, &ms.MaxTrackDuration, &ms.MaxUserTracks,
+		&ms.SponsorBlock, &sponsorBlockCategories)
+	if err == sql.ErrNoRows {
+		return &MusicSettings{GuildID: guildID, Volume: 50, PlaylistLimit: 10, PlaylistTrackLimit: 100}, nil
 	}
-	return messages, rows.Err()
+	if textChannels != "" {
+		ms.TextChannels = strings.Split(textChannels, ",")
+	}
+	if sponsorBlockCategories != "" {
+		ms.SponsorBlockCategories = strings.Split(sponsorBlockCategories, ",")
+	}
+	return &ms, err
 }
 
-func (d *DB) MarkScheduledMessageExecuted(id int64) error {
-	_, err := d.Exec(`UPDATE scheduled_messages SET executed = 1 WHERE id = ?`, id)
+func (d *DB) SetMusicSettings(ms *MusicSettings) error {
+	_, err := d.Exec(`INSERT INTO music_settings (guild_id, dj_role_id, mod_role_id, volume, music_folder,
+		playlist_limit, playlist_track_limit, stay_connected, autoplay, audio_filters, text_channels, normalize,
+		max_queue_length, max_track_duration, max_user_tracks, sponsorblock, sponsorblock_categories, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		dj_role_id = excluded.dj_role_id, mod_role_id = excluded.mod_role_id,
+		volume = excluded.volume, music_folder = excluded.music_folder,
+		playlist_limit = excluded.playlist_limit, playlist_track_limit = excluded.playlist_track_limit,
+		stay_connected = excluded.stay_connected, autoplay = excluded.autoplay,
+		audio_filters = excluded.audio_filters, text_channels = excluded.text_channels,
+		normalize = excluded.normalize, max_queue_length = excluded.max_queue_length,
+		max_track_duration = excluded.max_track_duration, max_user_tracks = excluded.max_user_tracks,
+		sponsorblock = excluded.sponsorblock, sponsorblock_categories = excluded.sponsorblock_categories,
+		updated_at = CURRENT_TIMESTAMP`,
+		ms.GuildID, ms.DJRoleID, ms.ModRoleID, ms.Volume, ms.MusicFolder,
+		ms.PlaylistLimit, ms.PlaylistTrackLimit, ms.StayConnected, ms.Autoplay, ms.AudioFilters, strings.Join(ms.TextChannels, ","),
+		ms.Normalize, ms.MaxQueueLength, ms.MaxTrackDuration, ms.MaxUserTracks,
+		ms.SponsorBlock, strings.Join(ms.SponsorBlockCategories, ","))
 	return err
 }
 
-// AFK Status
-func (d *DB) SetAFK(userID, message string) error {
-	_, err := d.Exec(`INSERT INTO afk_status (user_id, message) VALUES (?, ?)
-		ON CONFLICT(user_id) DO UPDATE SET message = excluded.message, set_at = CURRENT_TIMESTAMP`,
-		userID, d.Encrypt(message))
+func (d *DB) UpdateMusicVolume(guildID string, volume int) error {
+	_, err := d.Exec(`INSERT INTO music_settings (guild_id, volume, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(guild_id) DO UPDATE SET volume = excluded.volume, updated_at = CURRENT_TIMESTAMP`,
+		guildID, volume)
 	return err
 }
 
-func (d *DB) GetAFK(userID string) (*AFKStatus, error) {
-	var afk AFKStatus
-	err := d.QueryRow(`SELECT user_id, message, set_at FROM afk_status WHERE user_id = ?`, userID).Scan(
-		&afk.UserID, &afk.Message, &afk.SetAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err == nil {
-		afk.Message = d.DecryptNullable(afk.Message)
-	}
-	return &afk, err
+func (d *DB) UpdateMusicRoles(guildID string, djRoleID, modRoleID *string) error {
+	_, err := d.Exec(`INSERT INTO music_settings (guild_id, dj_role_id, mod_role_id, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		dj_role_id = excluded.dj_role_id, mod_role_id = excluded.mod_role_id,
+		updated_at = CURRENT_TIMESTAMP`,
+		guildID, djRoleID, modRoleID)
+	return err
 }
 
-func (d *DB) RemoveAFK(userID string) error {
-	_, err := d.Exec(`DELETE FROM afk_status WHERE user_id = ?`, userID)
+// ============ Music Queue ============
+
+// AddToMusicQueue appends an item to the guild's queue and sets item.ID
+func (d *DB) AddToMusicQueue(item *MusicQueueItem) error {
+	result, err := d.Exec(`INSERT INTO music_queue (guild_id, channel_id, user_id, title, url, duration, thumbnail, is_local, position)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?,
+			COALESCE((SELECT MAX(position) + 1 FROM music_queue WHERE guild_id = ?), 0))`,
+		item.GuildID, item.ChannelID, item.UserID, item.Title, item.URL, item.Duration, item.Thumbnail, item.IsLocal, item.GuildID)
+	if err != nil {
+		return err
+	}
+	item.ID, err = result.LastInsertId()
 	return err
 }
 
-// Reminders
-func (d *DB) AddReminder(userID, channelID, message string, remindAt time.Time) error {
-	_, err := d.Exec(`INSERT INTO reminders (user_id, channel_id, message, remind_at) VALUES (?, ?, ?, ?)`,
-		userID, channelID, d.Encrypt(message), remindAt)
-	return err
+// ReorderMusicQueue moves the given queue items to the end of the queue in the order listed
+func (d *DB) ReorderMusicQueue(guildID string, ids []int64) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var base int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM music_queue WHERE guild_id = ?`, guildID).Scan(&base); err != nil {
+		return err
+	}
+	for idx, id := range ids {
+		if _, err := tx.Exec(`UPDATE music_queue SET position = ? WHERE guild_id = ? AND id = ?`, base+idx, guildID, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func (d *DB) GetPendingReminders() ([]Reminder, error) {
-	rows, err := d.Query(`SELECT id, user_id, channel_id, message, remind_at
-		FROM reminders WHERE completed = 0 AND remind_at <= ? ORDER BY remind_at`, time.Now())
+func (d *DB) GetMusicQueue(guildID string) ([]MusicQueueItem, error) {
+	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, title, url, duration, thumbnail, is_local, position, added_at
+		FROM music_queue WHERE guild_id = ? ORDER BY position ASC`, guildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var reminders []Reminder
+	var items []MusicQueueItem
 	for rows.Next() {
-		var r Reminder
-		if err := rows.Scan(&r.ID, &r.UserID, &r.ChannelID, &r.Message, &r.RemindAt); err != nil {
+		var item MusicQueueItem
+		if err := rows.Scan(&item.ID, &item.GuildID, &item.ChannelID, &item.UserID, &item.Title, &item.URL,
+			&item.Duration, &item.Thumbnail, &item.IsLocal, &item.Position, &item.AddedAt); err != nil {
 			return nil, err
 		}
-		r.Message = d.Decrypt(r.Message)
-		reminders = append(reminders, r)
+		items = append(items, item)
 	}
-	return reminders, rows.Err()
+	return items, rows.Err()
 }
 
-func (d *DB) MarkReminderCompleted(id int64) error {
-	_, err := d.Exec(`UPDATE reminders SET completed = 1 WHERE id = ?`, id)
-	return err
-}
+func (d *DB) RemoveFromMusicQueue(guildID string, position int) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-// Tags
-func (d *DB) GetTag(guildID, name string) (*Tag, error) {
-	var t Tag
-	err := d.QueryRow(`SELECT id, guild_id, name, content, created_by, use_count
-		FROM tags WHERE guild_id = ? AND name = ?`, guildID, name).Scan(
-		&t.ID, &t.GuildID, &t.Name, &t.Content, &t.CreatedBy, &t.UseCount)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	_, err = tx.Exec(`DELETE FROM music_queue WHERE guild_id = ? AND position = ?`, guildID, position)
+	if err != nil {
+		return err
 	}
-	if err == nil {
-		t.Content = d.Decrypt(t.Content)
+
+	_, err = tx.Exec(`UPDATE music_queue SET position = position - 1 WHERE guild_id = ? AND position > ?`, guildID, position)
+	if err != nil {
+		return err
 	}
-	return &t, err
-}
 
-func (d *DB) CreateTag(guildID, name, content, createdBy string) error {
-	_, err := d.Exec(`INSERT INTO tags (guild_id, name, content, created_by) VALUES (?, ?, ?, ?)`,
-		guildID, name, d.Encrypt(content), createdBy)
-	return err
+	return tx.Commit()
 }
 
-func (d *DB) DeleteTag(guildID, name string) error {
-	_, err := d.Exec(`DELETE FROM tags WHERE guild_id = ? AND name = ?`, guildID, name)
+func (d *DB) ClearMusicQueue(guildID string) error {
+	_, err := d.Exec(`DELETE FROM music_queue WHERE guild_id = ?`, guildID)
 	return err
 }
 
-func (d *DB) ListTags(guildID string) ([]Tag, error) {
-	rows, err := d.Query(`SELECT id, guild_id, name, content, created_by, use_count
-		FROM tags WHERE guild_id = ? ORDER BY name`, guildID)
+func (d *DB) MoveToTopMusicQueue(guildID string, position int) error {
+	tx, err := d.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var tags []Tag
-	for rows.Next() {
-		var t Tag
-		if err := rows.Scan(&t.ID, &t.GuildID, &t.Name, &t.Content, &t.CreatedBy, &t.UseCount); err != nil {
-			return nil, err
-		}
-		t.Content = d.Decrypt(t.Content)
-		tags = append(tags, t)
+	// Temporarily set position to -1
+	_, err = tx.Exec(`UPDATE music_queue SET position = -1 WHERE guild_id = ? AND position = ?`, guildID, position)
+	if err != nil {
+		return err
 	}
-	return tags, rows.Err()
-}
 
-func (d *DB) IncrementTagUse(guildID, name string) error {
-	_, err := d.Exec(`UPDATE tags SET use_count = use_count + 1 WHERE guild_id = ? AND name = ?`,
-		guildID, name)
-	return err
-}
+	// Increment all positions that were less than the moved item
+	_, err = tx.Exec(`UPDATE music_queue SET position = position + 1 WHERE guild_id = ? AND position >= 0 AND position < ?`, guildID, position)
+	if err != nil {
+		return err
+	}
 
-// Keyword Notifications
-func (d *DB) AddKeywordNotification(userID, guildID, keyword string) error {
-	_, err := d.Exec(`INSERT INTO keyword_notifications (user_id, guild_id, keyword) VALUES (?, ?, ?)`,
-		userID, guildID, keyword)
-	return err
+	// Set the moved item to position 0
+	_, err = tx.Exec(`UPDATE music_queue SET position = 0 WHERE guild_id = ? AND position = -1`, guildID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (d *DB) RemoveKeywordNotification(userID, keyword string) error {
-	_, err := d.Exec(`DELETE FROM keyword_notifications WHERE user_id = ? AND keyword = ?`, userID, keyword)
+// ============ Music History ============
+
+func (d *DB) AddToMusicHistory(guildID, userID, title, url string) error {
+	_, err := d.Exec(`INSERT INTO music_history (guild_id, user_id, title, url) VALUES (?, ?, ?, ?)`,
+		guildID, userID, title, url)
 	return err
 }
 
-func (d *DB) GetKeywordNotifications(userID string) ([]KeywordNotification, error) {
-	rows, err := d.Query(`SELECT id, user_id, guild_id, keyword FROM keyword_notifications WHERE user_id = ?`, userID)
+func (d *DB) GetMusicHistory(guildID string, limit int) ([]MusicHistory, error) {
+	rows, err := d.Query(`SELECT id, guild_id, user_id, title, url, played_at
+		FROM music_history WHERE guild_id = ? ORDER BY played_at DESC LIMIT ?`, guildID, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var notifications []KeywordNotification
+	items := make([]MusicHistory, 0, limit)
 	for rows.Next() {
-		var kn KeywordNotification
-		if err := rows.Scan(&kn.ID, &kn.UserID, &kn.GuildID, &kn.Keyword); err != nil {
+		var item MusicHistory
+		if err := rows.Scan(&item.ID, &item.GuildID, &item.UserID, &item.Title, &item.URL, &item.PlayedAt); err != nil {
 			return nil, err
 		}
-		notifications = append(notifications, kn)
+		items = append(items, item)
 	}
-	return notifications, rows.Err()
+	return items, rows.Err()
 }
 
-func (d *DB) GetAllKeywordNotifications() ([]KeywordNotification, error) {
-	rows, err := d.Query(`SELECT id, user_id, guild_id, keyword FROM keyword_notifications`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+// ============ Music Playlists ============
 
-	var notifications []KeywordNotification
+const playlistColumns = `p.id, p.guild_id, p.owner_id, p.name, p.public, p.created_at,
+	(SELECT COUNT(*) FROM music_playlist_tracks t WHERE t.playlist_id = p.id)`
+
+func scanPlaylists(rows *sql.Rows) ([]Playlist, error) {
+	defer rows.Close()
+	var playlists []Playlist
 	for rows.Next() {
-		var kn KeywordNotification
-		if err := rows.Scan(&kn.ID, &kn.UserID, &kn.GuildID, &kn.Keyword); err != nil {
+		var p Playlist
+		if err := rows.Scan(&p.ID, &p.GuildID, &p.OwnerID, &p.Name, &p.Public, &p.CreatedAt, &p.TrackCount); err != nil {
 			return nil, err
 		}
-		notifications = append(notifications, kn)
+		playlists = append(playlists, p)
 	}
-	return notifications, rows.Err()
+	return playlists, rows.Err()
 }
 
-// ============ XP/Leveling System ============
-
-func (d *DB) GetUserXP(guildID, userID string) (*UserXP, error) {
-	var ux UserXP
-	err := d.QueryRow(`SELECT guild_id, user_id, xp, level, updated_at FROM user_xp WHERE guild_id = ? AND user_id = ?`,
-		guildID, userID).Scan(&ux.GuildID, &ux.UserID, &ux.XP, &ux.Level, &ux.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return &UserXP{GuildID: guildID, UserID: userID, XP: 0, Level: 0}, nil
+// CreatePlaylist creates an empty playlist owned by a user
+func (d *DB) CreatePlaylist(guildID, ownerID, name string, public bool) (int64, error) {
+	result, err := d.Exec(`INSERT INTO music_playlists (guild_id, owner_id, name, public) VALUES (?, ?, ?, ?)`,
+		guildID, ownerID, name, public)
+	if err != nil {
+		return 0, err
 	}
-	return &ux, err
+	return result.LastInsertId()
 }
 
-func (d *DB) SetUserXP(guildID, userID string, xp int64, level int) error {
-	_, err := d.Exec(`INSERT INTO user_xp (guild_id, user_id, xp, level, updated_at)
-		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(guild_id, user_id) DO UPDATE SET
-		xp = excluded.xp, level = excluded.level, updated_at = CURRENT_TIMESTAMP`,
-		guildID, userID, xp, level)
-	return err
+// GetPlaylist returns a user's playlist by name, or nil if it doesn't exist
+func (d *DB) GetPlaylist(guildID, ownerID, name string) (*Playlist, error) {
+	var p Playlist
+	err := d.QueryRow(`SELECT `+playlistColumns+`
+		FROM music_playlists p WHERE p.guild_id = ? AND p.owner_id = ? AND p.name = ? COLLATE NOCASE`,
+		guildID, ownerID, name).Scan(&p.ID, &p.GuildID, &p.OwnerID, &p.Name, &p.Public, &p.CreatedAt, &p.TrackCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &p, err
 }
 
-func (d *DB) AddUserXP(guildID, userID string, amount int64) (*UserXP, error) {
-	ux, err := d.GetUserXP(guildID, userID)
+// GetUserPlaylists returns a user's playlists in a guild
+func (d *DB) GetUserPlaylists(guildID, ownerID string) ([]Playlist, error) {
+	rows, err := d.Query(`SELECT `+playlistColumns+`
+		FROM music_playlists p WHERE p.guild_id = ? AND p.owner_id = ? ORDER BY p.name`, guildID, ownerID)
 	if err != nil {
 		return nil, err
 	}
-	ux.XP += amount
-	// Calculate new level
-	ux.Level = CalculateLevel(ux.XP)
-	err = d.SetUserXP(guildID, userID, ux.XP, ux.Level)
-	return ux, err
+	return scanPlaylists(rows)
 }
 
-func (d *DB) GetGuildLeaderboard(guildID string, limit int) ([]UserXP, error) {
-	rows, err := d.Query(`SELECT guild_id, user_id, xp, level, updated_at FROM user_xp
-		WHERE guild_id = ? ORDER BY xp DESC LIMIT ?`, guildID, limit)
+// GetPublicPlaylists returns every playlist shared with a guild
+func (d *DB) GetPublicPlaylists(guildID string) ([]Playlist, error) {
+	rows, err := d.Query(`SELECT `+playlistColumns+`
+		FROM music_playlists p WHERE p.guild_id = ? AND p.public = 1 ORDER BY p.name`, guildID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	leaderboard := make([]UserXP, 0, limit)
-	for rows.Next() {
-		var ux UserXP
-		if err := rows.Scan(&ux.GuildID, &ux.UserID, &ux.XP, &ux.Level, &ux.UpdatedAt); err != nil {
-			return nil, err
-		}
-		leaderboard = append(leaderboard, ux)
-	}
-	return leaderboard, rows.Err()
+	return scanPlaylists(rows)
 }
 
-func (d *DB) GetUserRank(guildID, userID string) (int, error) {
-	var rank int
-	err := d.QueryRow(`SELECT COUNT(*) + 1 FROM user_xp WHERE guild_id = ? AND xp > (
-		SELECT COALESCE(xp, 0) FROM user_xp WHERE guild_id = ? AND user_id = ?
-	)`, guildID, guildID, userID).Scan(&rank)
-	return rank, err
+// SetPlaylistPublic shares or unshares a playlist with the guild
+func (d *DB) SetPlaylistPublic(playlistID int64, public bool) error {
+	_, err := d.Exec(`UPDATE music_playlists SET public = ? WHERE id = ?`, public, playlistID)
+	return err
 }
 
-// CalculateLevel calculates level from XP using formula: level = floor((sqrt(1 + 8*xp/50) - 1) / 2)
-func CalculateLevel(xp int64) int {
-	if xp <= 0 {
-		return 0
-	}
-	// level = floor((sqrt(1 + 8*xp/50) - 1) / 2)
-	import_val := float64(1 + 8*xp/50)
-	level := int((sqrt(import_val) - 1) / 2)
-	if level < 0 {
-		return 0
+// DeletePlaylist removes a playlist and its tracks
+func (d *DB) DeletePlaylist(playlistID int64) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
 	}
-	return level
-}
-
-// XPForLevel returns XP needed for a specific level
-func XPForLevel(level int) int64 {
-	// XP = 5*level^2 + 50*level + 100
-	return int64(5*level*level + 50*level + 100)
-}
-
-// ============ Regex Filters ============
+	defer tx.Rollback()
 
-func (d *DB) AddRegexFilter(guildID, pattern, action, reason, createdBy string) error {
-	_, err := d.Exec(`INSERT INTO regex_filters (guild_id, pattern, action, reason, created_by) VALUES (?, ?, ?, ?, ?)`,
-		guildID, pattern, action, d.Encrypt(reason), createdBy)
-	return err
+	if _, err := tx.Exec(`DELETE FROM music_playlist_tracks WHERE playlist_id = ?`, playlistID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM music_playlists WHERE id = ?`, playlistID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (d *DB) RemoveRegexFilter(guildID string, id int64) error {
-	_, err := d.Exec(`DELETE FROM regex_filters WHERE guild_id = ? AND id = ?`, guildID, id)
+// AddPlaylistTrack appends a track to the end of a playlist
+func (d *DB) AddPlaylistTrack(t *PlaylistTrack) error {
+	_, err := d.Exec(`INSERT INTO music_playlist_tracks (playlist_id, title, url, duration, is_local, position)
+		VALUES (?, ?, ?, ?, ?,
+			COALESCE((SELECT MAX(position) + 1 FROM music_playlist_tracks WHERE playlist_id = ?), 0))`,
+		t.PlaylistID, t.Title, t.URL, t.Duration, t.IsLocal, t.PlaylistID)
 	return err
 }
 
-func (d *DB) GetRegexFilters(guildID string) ([]RegexFilter, error) {
-	rows, err := d.Query(`SELECT id, guild_id, pattern, action, reason, created_by, created_at
-		FROM regex_filters WHERE guild_id = ? ORDER BY id`, guildID)
+// GetPlaylistTracks returns a playlist's tracks in order
+func (d *DB) GetPlaylistTracks(playlistID int64) ([]PlaylistTrack, error) {
+	rows, err := d.Query(`SELECT id, playlist_id, title, url, duration, is_local, position
+		FROM music_playlist_tracks WHERE playlist_id = ? ORDER BY position ASC`, playlistID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var filters []RegexFilter
+	var tracks []PlaylistTrack
 	for rows.Next() {
-		var f RegexFilter
-		if err := rows.Scan(&f.ID, &f.GuildID, &f.Pattern, &f.Action, &f.Reason, &f.CreatedBy, &f.CreatedAt); err != nil {
+		var t PlaylistTrack
+		if err := rows.Scan(&t.ID, &t.PlaylistID, &t.Title, &t.URL, &t.Duration, &t.IsLocal, &t.Position); err != nil {
 			return nil, err
 		}
-		f.Reason = d.Decrypt(f.Reason)
-		filters = append(filters, f)
-	}
-	return filters, rows.Err()
-}
-
-// ============ Auto-Clean Channels ============
-
-func (d *DB) AddAutoCleanChannel(guildID, channelID, createdBy string, intervalHours, warningMinutes int) error {
-	nextRun := time.Now().Add(time.Duration(intervalHours) * time.Hour)
-	_, err := d.Exec(`INSERT INTO autoclean_channels (guild_id, channel_id, interval_hours, warning_minutes, next_run, created_by)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(guild_id, channel_id) DO UPDATE SET
-		interval_hours = excluded.interval_hours, warning_minutes = excluded.warning_minutes, next_run = excluded.next_run`,
-		guildID, channelID, intervalHours, warningMinutes, nextRun, createdBy)
-	return err
+		tracks = append(tracks, t)
+	}
+	return tracks, rows.Err()
 }
 
-func (d *DB) RemoveAutoCleanChannel(guildID, channelID string) error {
-	_, err := d.Exec(`DELETE FROM autoclean_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID)
-	return err
+// ============ Local Music Library ============
+
+const localTrackColumns = `id, guild_id, path, title, artist, album, track_number, duration, mod_time`
+
+func scanLocalTracks(rows *sql.Rows) ([]LocalTrack, error) {
+	defer rows.Close()
+	var tracks []LocalTrack
+	for rows.Next() {
+		var t LocalTrack
+		if err := rows.Scan(&t.ID, &t.GuildID, &t.Path, &t.Title, &t.Artist, &t.Album,
+			&t.TrackNumber, &t.Duration, &t.ModTime); err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, rows.Err()
 }
 
-func (d *DB) GetAutoCleanChannels(guildID string) ([]AutoCleanChannel, error) {
-	rows, err := d.Query(`SELECT id, guild_id, channel_id, interval_hours, warning_minutes, next_run, clean_message, clean_image, created_by, created_at
-		FROM autoclean_channels WHERE guild_id = ? ORDER BY id`, guildID)
+// GetMusicFolders returns the music folder of every guild that has one set, keyed by guild
+func (d *DB) GetMusicFolders() (map[string]string, error) {
+	rows, err := d.Query(`SELECT guild_id, music_folder FROM music_settings
+		WHERE music_folder IS NOT NULL AND music_folder != ''`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var channels []AutoCleanChannel
+	folders := make(map[string]string)
 	for rows.Next() {
-		var c AutoCleanChannel
-		if err := rows.Scan(&c.ID, &c.GuildID, &c.ChannelID, &c.IntervalHours, &c.WarningMinutes, &c.NextRun, &c.CleanMessage, &c.CleanImage, &c.CreatedBy, &c.CreatedAt); err != nil {
+		var guildID, folder string
+		if err := rows.Scan(&guildID, &folder); err != nil {
 			return nil, err
 		}
-		channels = append(channels, c)
+		folders[guildID] = folder
 	}
-	return channels, rows.Err()
+	return folders, rows.Err()
 }
 
-func (d *DB) GetPendingAutoCleanChannels() ([]AutoCleanChannel, error) {
-	rows, err := d.Query(`SELECT id, guild_id, channel_id, interval_hours, warning_minutes, next_run, clean_message, clean_image, created_by, created_at
-		FROM autoclean_channels WHERE next_run <= ? ORDER BY next_run`, time.Now())
+// GetLocalTrackModTimes returns the indexed modification time of each file in a guild's library, keyed by path
+func (d *DB) GetLocalTrackModTimes(guildID string) (map[string]int64, error) {
+	rows, err := d.Query(`SELECT path, mod_time FROM local_tracks WHERE guild_id = ?`, guildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var channels []AutoCleanChannel
+	modTimes := make(map[string]int64)
 	for rows.Next() {
-		var c AutoCleanChannel
-		if err := rows.Scan(&c.ID, &c.GuildID, &c.ChannelID, &c.IntervalHours, &c.WarningMinutes, &c.NextRun, &c.CleanMessage, &c.CleanImage, &c.CreatedBy, &c.CreatedAt); err != nil {
+		var path string
+		var modTime int64
+		if err := rows.Scan(&path, &modTime); err != nil {
 			return nil, err
 		}
-		channels = append(channels, c)
+		modTimes[path] = modTime
 	}
-	return channels, rows.Err()
+	return modTimes, rows.Err()
 }
 
-func (d *DB) UpdateAutoCleanNextRun(id int64, nextRun time.Time) error {
-	_, err := d.Exec(`UPDATE autoclean_channels SET next_run = ? WHERE id = ?`, nextRun, id)
+// SaveLocalTrack adds a file to the library index or refreshes its metadata
+func (d *DB) SaveLocalTrack(t *LocalTrack) error {
+	_, err := d.Exec(`INSERT INTO local_tracks (guild_id, path, title, artist, album, track_number, duration, mod_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, path) DO UPDATE SET
+		title = excluded.title, artist = excluded.artist, album = excluded.album,
+		track_number = excluded.track_number, duration = excluded.duration, mod_time = excluded.mod_time,
+		indexed_at = CURRENT_TIMESTAMP`,
+		t.GuildID, t.Path, t.Title, t.Artist, t.Album, t.TrackNumber, t.Duration, t.ModTime)
 	return err
 }
 
-func (d *DB) SetAutoCleanMessage(guildID, channelID string, enabled bool) error {
-	val := 0
-	if enabled {
-		val = 1
-	}
-	_, err := d.Exec(`UPDATE autoclean_channels SET clean_message = ? WHERE guild_id = ? AND channel_id = ?`,
-		val, guildID, channelID)
+// DeleteLocalTrack drops a file that's gone from the music folder out of the index
+func (d *DB) DeleteLocalTrack(guildID, path string) error {
+	_, err := d.Exec(`DELETE FROM local_tracks WHERE guild_id = ? AND path = ?`, guildID, path)
 	return err
 }
 
-func (d *DB) SetAutoCleanImage(guildID, channelID string, enabled bool) error {
-	val := 0
-	if enabled {
-		val = 1
+// GetLocalTrack looks up an indexed file by its path in the music folder
+func (d *DB) GetLocalTrack(guildID, path string) (*LocalTrack, error) {
+	var t LocalTrack
+	err := d.QueryRow(`SELECT `+localTrackColumns+` FROM local_tracks WHERE guild_id = ? AND path = ?`,
+		guildID, path).Scan(&t.ID, &t.GuildID, &t.Path, &t.Title, &t.Artist, &t.Album,
+		&t.TrackNumber, &t.Duration, &t.ModTime)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	_, err := d.Exec(`UPDATE autoclean_channels SET clean_image = ? WHERE guild_id = ? AND channel_id = ?`,
-		val, guildID, channelID)
-	return err
+	return &t, err
 }
 
-// ============ Logging Configuration ============
+// SearchLocalTracks matches a query against the path and tags of a guild's
+// indexed files, returning up to limit of them and the total number of matches
+func (d *DB) SearchLocalTracks(guildID, query string, limit int) ([]LocalTrack, int, error) {
+	const match = `guild_id = ? AND (path LIKE '%' || ? || '%' OR title LIKE '%' || ? || '%'
+		OR artist LIKE '%' || ? || '%' OR album LIKE '%' || ? || '%')`
 
-func (d *DB) GetLoggingConfig(guildID string) (*LoggingConfig, error) {
-	var lc LoggingConfig
-	err := d.QueryRow(`SELECT guild_id, log_channel_id, enabled, message_delete, message_edit,
-		voice_join, voice_leave, nickname_change, avatar_change, presence_change, presence_batch_mins
-		FROM logging_config WHERE guild_id = ?`, guildID).Scan(
-		&lc.GuildID, &lc.LogChannelID, &lc.Enabled, &lc.MessageDelete, &lc.MessageEdit,
-		&lc.VoiceJoin, &lc.VoiceLeave, &lc.NicknameChange, &lc.AvatarChange, &lc.PresenceChange, &lc.PresenceBatchMins)
-	if err == sql.ErrNoRows {
-		return &LoggingConfig{GuildID: guildID}, nil
+	var total int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM local_tracks WHERE `+match,
+		guildID, query, query, query, query).Scan(&total); err != nil {
+		return nil, 0, err
 	}
-	return &lc, err
+
+	rows, err := d.Query(`SELECT `+localTrackColumns+` FROM local_tracks WHERE `+match+`
+		ORDER BY artist COLLATE NOCASE, album COLLATE NOCASE, track_number, path LIMIT ?`,
+		guildID, query, query, query, query, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	tracks, err := scanLocalTracks(rows)
+	return tracks, total, err
 }
 
-func (d *DB) SetLoggingConfig(lc *LoggingConfig) error {
-	_, err := d.Exec(`INSERT INTO logging_config (guild_id, log_channel_id, enabled, message_delete, message_edit,
-		voice_join, voice_leave, nickname_change, avatar_change, presence_change, presence_batch_mins)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(guild_id) DO UPDATE SET
-		log_channel_id = excluded.log_channel_id, enabled = excluded.enabled,
-		message_delete = excluded.message_delete, message_edit = excluded.message_edit,
-		voice_join = excluded.voice_join, voice_leave = excluded.voice_leave,
-		nickname_change = excluded.nickname_change, avatar_change = excluded.avatar_change,
-		presence_change = excluded.presence_change, presence_batch_mins = excluded.presence_batch_mins`,
-		lc.GuildID, lc.LogChannelID, lc.Enabled, lc.MessageDelete, lc.MessageEdit,
-		lc.VoiceJoin, lc.VoiceLeave, lc.NicknameChange, lc.AvatarChange, lc.PresenceChange, lc.PresenceBatchMins)
-	return err
+// GetLocalArtists lists the artists in a guild's library with their track counts
+func (d *DB) GetLocalArtists(guildID string) ([]LibraryGroup, error) {
+	rows, err := d.Query(`SELECT artist, '', COUNT(*), COALESCE(SUM(duration), 0) FROM local_tracks
+		WHERE guild_id = ? AND artist != '' GROUP BY artist COLLATE NOCASE ORDER BY artist COLLATE NOCASE`, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLibraryGroups(rows)
 }
 
-func (d *DB) SetLogChannel(guildID, channelID string) error {
-	_, err := d.Exec(`INSERT INTO logging_config (guild_id, log_channel_id, enabled)
-		VALUES (?, ?, 1)
-		ON CONFLICT(guild_id) DO UPDATE SET log_channel_id = excluded.log_channel_id, enabled = 1`,
-		guildID, channelID)
-	return err
+// GetLocalAlbums lists the albums in a guild's library, only those by artist if it's set
+func (d *DB) GetLocalAlbums(guildID, artist string) ([]LibraryGroup, error) {
+	rows, err := d.Query(`SELECT album, MIN(artist), COUNT(*), COALESCE(SUM(duration), 0) FROM local_tracks
+		WHERE guild_id = ? AND album != '' AND (? = '' OR artist = ? COLLATE NOCASE)
+		GROUP BY album COLLATE NOCASE ORDER BY album COLLATE NOCASE`, guildID, artist, artist)
+	if err != nil {
+		return nil, err
+	}
+	return scanLibraryGroups(rows)
 }
 
-func (d *DB) ToggleLogging(guildID string, enabled bool) error {
-	val := 0
-	if enabled {
-		val = 1
+func scanLibraryGroups(rows *sql.Rows) ([]LibraryGroup, error) {
+	defer rows.Close()
+	var groups []LibraryGroup
+	for rows.Next() {
+		var g LibraryGroup
+		if err := rows.Scan(&g.Name, &g.Artist, &g.Tracks, &g.Duration); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
 	}
-	_, err := d.Exec(`UPDATE logging_config SET enabled = ? WHERE guild_id = ?`, val, guildID)
-	return err
+	return groups, rows.Err()
 }
 
-func (d *DB) AddDisabledLogChannel(guildID, channelID string) error {
-	_, err := d.Exec(`INSERT OR IGNORE INTO disabled_log_channels (guild_id, channel_id) VALUES (?, ?)`,
-		guildID, channelID)
-	return err
+// GetLocalAlbumTracks returns an album's indexed tracks in track order
+func (d *DB) GetLocalAlbumTracks(guildID, album string) ([]LocalTrack, error) {
+	rows, err := d.Query(`SELECT `+localTrackColumns+` FROM local_tracks
+		WHERE guild_id = ? AND album = ? COLLATE NOCASE ORDER BY track_number, path`, guildID, album)
+	if err != nil {
+		return nil, err
+	}
+	return scanLocalTracks(rows)
 }
 
-func (d *DB) RemoveDisabledLogChannel(guildID, channelID string) error {
-	_, err := d.Exec(`DELETE FROM disabled_log_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID)
+// ============ Radio Stations ============
+
+// SetRadioStation adds a radio preset to a guild, replacing the URL of one with the same name
+func (d *DB) SetRadioStation(guildID, name, url, addedBy string) error {
+	_, err := d.Exec(`INSERT INTO radio_stations (guild_id, name, url, added_by) VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, name) DO UPDATE SET url = excluded.url, added_by = excluded.added_by`,
+		guildID, name, url, addedBy)
 	return err
 }
 
-func (d *DB) IsLogChannelDisabled(guildID, channelID string) (bool, error) {
-	var count int
-	err := d.QueryRow(`SELECT COUNT(*) FROM disabled_log_channels WHERE guild_id = ? AND channel_id = ?`,
-		guildID, channelID).Scan(&count)
-	return count > 0, err
+// RemoveRadioStation deletes a guild's radio preset, reporting whether it existed
+func (d *DB) RemoveRadioStation(guildID, name string) (bool, error) {
+	result, err := d.Exec(`DELETE FROM radio_stations WHERE guild_id = ? AND name = ?`, guildID, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
 }
 
-func (d *DB) GetDisabledLogChannels(guildID string) ([]string, error) {
-	rows, err := d.Query(`SELECT channel_id FROM disabled_log_channels WHERE guild_id = ?`, guildID)
+// GetRadioStations returns a guild's radio presets sorted by name
+func (d *DB) GetRadioStations(guildID string) ([]RadioStation, error) {
+	rows, err := d.Query(`SELECT id, guild_id, name, url, added_by, created_at
+		FROM radio_stations WHERE guild_id = ? ORDER BY name`, guildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var channels []string
+	var stations []RadioStation
 	for rows.Next() {
-		var channelID string
-		if err := rows.Scan(&channelID); err != nil {
+		var r RadioStation
+		if err := rows.Scan(&r.ID, &r.GuildID, &r.Name, &r.URL, &r.AddedBy, &r.CreatedAt); err != nil {
 			return nil, err
 		}
-		channels = append(channels, channelID)
+		stations = append(stations, r)
 	}
-	return channels, rows.Err()
+	return stations, rows.Err()
 }
 
-// ============ Voice XP Configuration ============
+// ============ Lyrics Cache ============
 
-func (d *DB) GetVoiceXPConfig(guildID string) (*VoiceXPConfig, error) {
-	var vc VoiceXPConfig
-	err := d.QueryRow(`SELECT guild_id, enabled, xp_rate, interval_mins, ignore_afk
-		FROM voice_xp_config WHERE guild_id = ?`, guildID).Scan(
-		&vc.GuildID, &vc.Enabled, &vc.XPRate, &vc.IntervalMins, &vc.IgnoreAFK)
+// GetCachedLyrics returns cached lyrics for a normalized search query, or nil if not cached
+func (d *DB) GetCachedLyrics(query string) (*CachedLyrics, error) {
+	var l CachedLyrics
+	err := d.QueryRow(`SELECT id, query, title, artist, lyrics, cached_at FROM lyrics_cache WHERE query = ?`, query).Scan(
+		&l.ID, &l.Query, &l.Title, &l.Artist, &l.Lyrics, &l.CachedAt)
 	if err == sql.ErrNoRows {
-		return &VoiceXPConfig{GuildID: guildID, Enabled: false, XPRate: 10, IntervalMins: 5, IgnoreAFK: true}, nil
+		return nil, nil
 	}
-	return &vc, err
+	return &l, err
 }
 
-func (d *DB) SetVoiceXPConfig(vc *VoiceXPConfig) error {
-	_, err := d.Exec(`INSERT INTO voice_xp_config (guild_id, enabled, xp_rate, interval_mins, ignore_afk)
-		VALUES (?, ?, ?, ?, ?)
-		ON CONFLICT(guild_id) DO UPDATE SET
-		enabled = excluded.enabled, xp_rate = excluded.xp_rate,
-		interval_mins = excluded.interval_mins, ignore_afk = excluded.ignore_afk`,
-		vc.GuildID, vc.Enabled, vc.XPRate, vc.IntervalMins, vc.IgnoreAFK)
+// GetCachedLyricsByID returns a cached lyrics entry by ID, or nil if it has expired
+func (d *DB) GetCachedLyricsByID(id int64) (*CachedLyrics, error) {
+	var l CachedLyrics
+	err := d.QueryRow(`SELECT id, query, title, artist, lyrics, cached_at FROM lyrics_cache WHERE id = ?`, id).Scan(
+		&l.ID, &l.Query, &l.Title, &l.Artist, &l.Lyrics, &l.CachedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &l, err
+}
+
+// CacheLyrics stores lyrics for a query and sets l.ID
+func (d *DB) CacheLyrics(l *CachedLyrics) error {
+	err := d.QueryRow(`INSERT INTO lyrics_cache (query, title, artist, lyrics) VALUES (?, ?, ?, ?)
+		ON CONFLICT(query) DO UPDATE SET title = excluded.title, artist = excluded.artist,
+		lyrics = excluded.lyrics, cached_at = CURRENT_TIMESTAMP
+		RETURNING id`, l.Query, l.Title, l.Artist, l.Lyrics).Scan(&l.ID)
 	return err
 }
 
-// ============ Level Ranks ============
+// CleanOldLyricsCache drops cached lyrics older than maxAge
+func (d *DB) CleanOldLyricsCache(maxAge time.Duration) error {
+	_, err := d.Exec(`DELETE FROM lyrics_cache WHERE cached_at < ?`, time.Now().Add(-maxAge).UTC().Format("2006-01-02 15:04:05"))
+	return err
+}
 
-func (d *DB) AddLevelRank(guildID, roleID string, level int) error {
-	_, err := d.Exec(`INSERT INTO level_ranks (guild_id, role_id, level)
-		VALUES (?, ?, ?)
-		ON CONFLICT(guild_id, role_id) DO UPDATE SET level = excluded.level`,
-		guildID, roleID, level)
+// ============ Disabled Commands/Categories ============
+
+// IsCommandDisabled checks if a specific command is disabled for a guild
+func (d *DB) IsCommandDisabled(guildID, commandName string) bool {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM guild_disabled_commands WHERE guild_id = ? AND command_name = ?`,
+		guildID, commandName).Scan(&count)
+	if err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// IsCategoryDisabled checks if a category is disabled for a guild
+func (d *DB) IsCategoryDisabled(guildID, category string) bool {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM guild_disabled_commands WHERE guild_id = ? AND category = ?`,
+		guildID, category).Scan(&count)
+	if err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// DisableCommand disables a specific command for a guild
+func (d *DB) DisableCommand(guildID, commandName string) error {
+	_, err := d.Exec(`INSERT OR IGNORE INTO guild_disabled_commands (guild_id, command_name) VALUES (?, ?)`,
+		guildID, commandName)
 	return err
 }
 
-func (d *DB) RemoveLevelRank(guildID, roleID string) error {
-	_, err := d.Exec(`DELETE FROM level_ranks WHERE guild_id = ? AND role_id = ?`, guildID, roleID)
+// EnableCommand enables a previously disabled command for a guild
+func (d *DB) EnableCommand(guildID, commandName string) error {
+	_, err := d.Exec(`DELETE FROM guild_disabled_commands WHERE guild_id = ? AND command_name = ?`,
+		guildID, commandName)
 	return err
 }
 
-func (d *DB) GetLevelRanks(guildID string) ([]LevelRank, error) {
-	rows, err := d.Query(`SELECT id, guild_id, role_id, level, created_at
-		FROM level_ranks WHERE guild_id = ? ORDER BY level`, guildID)
+// DisableCategory disables an entire category for a guild
+func (d *DB) DisableCategory(guildID, category string) error {
+	_, err := d.Exec(`INSERT OR IGNORE INTO guild_disabled_commands (guild_id, category) VALUES (?, ?)`,
+		guildID, category)
+	return err
+}
+
+// EnableCategory enables a previously disabled category for a guild
+func (d *DB) EnableCategory(guildID, category string) error {
+	_, err := d.Exec(`DELETE FROM guild_disabled_commands WHERE guild_id = ? AND category = ?`,
+		guildID, category)
+	return err
+}
+
+// GetDisabledCommands returns all disabled command names for a guild
+func (d *DB) GetDisabledCommands(guildID string) ([]string, error) {
+	rows, err := d.Query(`SELECT command_name FROM guild_disabled_commands WHERE guild_id = ? AND command_name IS NOT NULL`,
+		guildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var ranks []LevelRank
+	var commands []string
 	for rows.Next() {
-		var r LevelRank
-		if err := rows.Scan(&r.ID, &r.GuildID, &r.RoleID, &r.Level, &r.CreatedAt); err != nil {
+		var cmd string
+		if err := rows.Scan(&cmd); err != nil {
 			return nil, err
 		}
-		ranks = append(ranks, r)
+		commands = append(commands, cmd)
 	}
-	return ranks, rows.Err()
+	return commands, rows.Err()
 }
 
-func (d *DB) GetRanksForLevel(guildID string, level int) ([]LevelRank, error) {
-	rows, err := d.Query(`SELECT id, guild_id, role_id, level, created_at
-		FROM level_ranks WHERE guild_id = ? AND level <= ? ORDER BY level`, guildID, level)
+// GetDisabledCategories returns all disabled category names for a guild
+func (d *DB) GetDisabledCategories(guildID string) ([]string, error) {
+	rows, err := d.Query(`SELECT category FROM guild_disabled_commands WHERE guild_id = ? AND category IS NOT NULL`,
+		guildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var ranks []LevelRank
+	var categories []string
 	for rows.Next() {
-		var r LevelRank
-		if err := rows.Scan(&r.ID, &r.GuildID, &r.RoleID, &r.Level, &r.CreatedAt); err != nil {
+		var cat string
+		if err := rows.Scan(&cat); err != nil {
 			return nil, err
 		}
-		ranks = append(ranks, r)
+		categories = append(categories, cat)
 	}
-	return ranks, rows.Err()
+	return categories, rows.Err()
 }
 
-// ============ DM Forwarding ============
+// SetDisabledCommands replaces all disabled commands for a guild
+func (d *DB) SetDisabledCommands(guildID string, commands []string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-func (d *DB) GetDMConfig(guildID string) (*DMConfig, error) {
-	var dc DMConfig
-	err := d.QueryRow(`SELECT guild_id, channel_id, enabled FROM dm_config WHERE guild_id = ?`, guildID).Scan(
-		&dc.GuildID, &dc.ChannelID, &dc.Enabled)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	// Delete all existing disabled commands (not categories)
+	_, err = tx.Exec(`DELETE FROM guild_disabled_commands WHERE guild_id = ? AND command_name IS NOT NULL`, guildID)
+	if err != nil {
+		return err
 	}
-	return &dc, err
-}
 
-func (d *DB) SetDMConfig(guildID, channelID string, enabled bool) error {
-	_, err := d.Exec(`INSERT INTO dm_config (guild_id, channel_id, enabled)
-		VALUES (?, ?, ?)
-		ON CONFLICT(guild_id) DO UPDATE SET channel_id = excluded.channel_id, enabled = excluded.enabled`,
-		guildID, channelID, enabled)
-	return err
+	// Insert new disabled commands
+	for _, cmd := range commands {
+		_, err = tx.Exec(`INSERT INTO guild_disabled_commands (guild_id, command_name) VALUES (?, ?)`, guildID, cmd)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (d *DB) GetAllDMConfigs() ([]DMConfig, error) {
-	rows, err := d.Query(`SELECT guild_id, channel_id, enabled FROM dm_config WHERE enabled = 1`)
+// SetDisabledCategories replaces all disabled categories for a guild
+func (d *DB) SetDisabledCategories(guildID string, categories []string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Delete all existing disabled categories (not commands)
+	_, err = tx.Exec(`DELETE FROM guild_disabled_commands WHERE guild_id = ? AND category IS NOT NULL`, guildID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	var configs []DMConfig
-	for rows.Next() {
-		var dc DMConfig
-		if err := rows.Scan(&dc.GuildID, &dc.ChannelID, &dc.Enabled); err != nil {
-			return nil, err
+	// Insert new disabled categories
+	for _, cat := range categories {
+		_, err = tx.Exec(`INSERT INTO guild_disabled_commands (guild_id, category) VALUES (?, ?)`, guildID, cat)
+		if err != nil {
+			return err
 		}
-		configs = append(configs, dc)
 	}
-	return configs, rows.Err()
-}
-
-// ============ Bot Bans ============
 
-func (d *DB) AddBotBan(targetID, banType, reason, bannedBy string) error {
-	_, err := d.Exec(`INSERT INTO bot_bans (target_id, ban_type, reason, banned_by)
-		VALUES (?, ?, ?, ?)
-		ON CONFLICT(target_id) DO UPDATE SET ban_type = excluded.ban_type, reason = excluded.reason`,
-		targetID, banType, d.Encrypt(reason), bannedBy)
-	return err
+	return tx.Commit()
 }
 
-func (d *DB) RemoveBotBan(targetID string) error {
-	_, err := d.Exec(`DELETE FROM bot_bans WHERE target_id = ?`, targetID)
+// ============ AI Settings ============
+
+// GetAISettings returns a guild's AI settings, the bot's defaults if it has none
+func (d *DB) GetAISettings(guildID string) (*AISettings, error) {
+	as := AISettings{GuildID: guildID, MemorySize: 10, MemoryHours: 24, SummaryDailyLimit: 20, SummaryTokenBudget: 100000}
+	err := d.QueryRow(`SELECT COALESCE(provider, ''), COALESCE(model, ''), COALESCE(api_key, ''), COALESCE(updated_by, ''),
+		COALESCE(system_prompt, ''), COALESCE(memory_size, 10), COALESCE(memory_hours, 24),
+		COALESCE(summary_role_id, ''), COALESCE(summary_daily_limit, 20), COALESCE(summary_token_budget, 100000),
+		COALESCE(daily_token_budget, 0), COALESCE(user_daily_token_budget, 0)
+		FROM ai_settings WHERE guild_id = ?`, guildID).Scan(&as.Provider, &as.Model, &as.APIKey, &as.UpdatedBy,
+		&as.SystemPrompt, &as.MemorySize, &as.MemoryHours,
+		&as.SummaryRoleID, &as.SummaryDailyLimit, &as.SummaryTokenBudget,
+		&as.DailyTokenBudget, &as.UserDailyTokenBudget)
+	if err == sql.ErrNoRows {
+		return &as, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	as.APIKey = d.Decrypt(as.APIKey)
+	as.SystemPrompt = d.Decrypt(as.SystemPrompt)
+	return &as, nil
+}
+
+func (d *DB) SetAISettings(as *AISettings) error {
+	_, err := d.Exec(`INSERT INTO ai_settings (guild_id, provider, model, api_key, updated_by, system_prompt,
+		memory_size, memory_hours, summary_role_id, summary_daily_limit, summary_token_budget,
+		daily_token_budget, user_daily_token_budget, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(guild_id) DO UPDATE SET provider = excluded.provider, model = excluded.model,
+		api_key = excluded.api_key, updated_by = excluded.updated_by, system_prompt = excluded.system_prompt,
+		memory_size = excluded.memory_size, memory_hours = excluded.memory_hours,
+		summary_role_id = excluded.summary_role_id, summary_daily_limit = excluded.summary_daily_limit,
+		summary_token_budget = excluded.summary_token_budget, daily_token_budget = excluded.daily_token_budget,
+		user_daily_token_budget = excluded.user_daily_token_budget, updated_at = CURRENT_TIMESTAMP`,
+		as.GuildID, as.Provider, as.Model, d.Encrypt(as.APIKey), as.UpdatedBy, d.Encrypt(as.SystemPrompt),
+		as.MemorySize, as.MemoryHours, as.SummaryRoleID, as.SummaryDailyLimit, as.SummaryTokenBudget,
+		as.DailyTokenBudget, as.UserDailyTokenBudget)
 	return err
 }
 
-func (d *DB) IsBotBanned(targetID string) (bool, error) {
-	var count int
-	err := d.QueryRow(`SELECT COUNT(*) FROM bot_bans WHERE target_id = ?`, targetID).Scan(&count)
-	return count > 0, err
-}
+// AddAIExchange remembers a question and its answer, keeping at most keep exchanges per channel
+func (d *DB) AddAIExchange(ex *AIExchange, keep int) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-func (d *DB) GetBotBan(targetID string) (*BotBan, error) {
-	var bb BotBan
-	err := d.QueryRow(`SELECT id, target_id, ban_type, reason, banned_by, created_at FROM bot_bans WHERE target_id = ?`,
-		targetID).Scan(&bb.ID, &bb.TargetID, &bb.BanType, &bb.Reason, &bb.BannedBy, &bb.CreatedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	_, err = tx.Exec(`INSERT INTO ai_conversations (guild_id, channel_id, user_id, user_name, prompt, reply)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		ex.GuildID, ex.ChannelID, ex.UserID, ex.UserName, d.Encrypt(ex.Prompt), d.Encrypt(ex.Reply))
+	if err != nil {
+		return err
 	}
-	if err == nil {
-		bb.Reason = d.Decrypt(bb.Reason)
+
+	_, err = tx.Exec(`DELETE FROM ai_conversations WHERE channel_id = ? AND id NOT IN (
+		SELECT id FROM ai_conversations WHERE channel_id = ? ORDER BY id DESC LIMIT ?)`,
+		ex.ChannelID, ex.ChannelID, keep)
+	if err != nil {
+		return err
 	}
-	return &bb, err
+
+	return tx.Commit()
 }
 
-func (d *DB) GetBotBans(banType string) ([]BotBan, error) {
-	var rows *sql.Rows
-	var err error
-	if banType != "" {
-		rows, err = d.Query(`SELECT id, target_id, ban_type, reason, banned_by, created_at FROM bot_bans WHERE ban_type = ? ORDER BY created_at DESC`, banType)
-	} else {
-		rows, err = d.Query(`SELECT id, target_id, ban_type, reason, banned_by, created_at FROM bot_bans ORDER BY created_at DESC`)
-	}
+// GetAIExchanges returns a channel's last limit exchanges newer than since, oldest first
+func (d *DB) GetAIExchanges(channelID string, limit int, since time.Time) ([]*AIExchange, error) {
+	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, COALESCE(user_name, ''), prompt, reply, created_at
+		FROM ai_conversations WHERE channel_id = ? AND created_at >= ?
+		ORDER BY id DESC LIMIT ?`, channelID, since.UTC().Format("2006-01-02 15:04:05"), limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var bans []BotBan
+	var exchanges []*AIExchange
 	for rows.Next() {
-		var bb BotBan
-		if err := rows.Scan(&bb.ID, &bb.TargetID, &bb.BanType, &bb.Reason, &bb.BannedBy, &bb.CreatedAt); err != nil {
+		var ex AIExchange
+		if err := rows.Scan(&ex.ID, &ex.GuildID, &ex.ChannelID, &ex.UserID, &ex.UserName, &ex.Prompt, &ex.Reply, &ex.CreatedAt); err != nil {
 			return nil, err
 		}
-		bb.Reason = d.Decrypt(bb.Reason)
-		bans = append(bans, bb)
+		ex.Prompt = d.Decrypt(ex.Prompt)
+		ex.Reply = d.Decrypt(ex.Reply)
+		exchanges = append(exchanges, &ex)
 	}
-	return bans, rows.Err()
+	slices.Reverse(exchanges)
+	return exchanges, rows.Err()
 }
 
-// Helper function for sqrt
-func sqrt(x float64) float64 {
-	if x < 0 {
-		return 0
+// ClearAIConversation forgets a channel's exchanges, or the whole guild's when channelID is empty
+func (d *DB) ClearAIConversation(guildID, channelID string) (int64, error) {
+	var result sql.Result
+	var err error
+	if channelID == "" {
+		result, err = d.Exec(`DELETE FROM ai_conversations WHERE guild_id = ?`, guildID)
+	} else {
+		result, err = d.Exec(`DELETE FROM ai_conversations WHERE guild_id = ? AND channel_id = ?`, guildID, channelID)
 	}
-	z := x / 2
-	for i := 0; i < 10; i++ {
-		z = (z + x/z) / 2
+	if err != nil {
+		return 0, err
 	}
-	return z
+	return result.RowsAffected()
 }
 
-// ============ Moderation Actions ============
+// GetAIUsage returns a guild's usage of an AI feature on a day (YYYY-MM-DD, UTC)
+func (d *DB) GetAIUsage(guildID, day, feature string) (*AIUsage, error) {
+	u := AIUsage{GuildID: guildID, Day: day, Feature: feature}
+	err := d.QueryRow(`SELECT requests, tokens FROM ai_usage WHERE guild_id = ? AND day = ? AND feature = ?`,
+		guildID, day, feature).Scan(&u.Requests, &u.Tokens)
+	if err == sql.ErrNoRows {
+		return &u, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
 
-func (d *DB) AddModAction(guildID, moderatorID, targetID, action string, reason *string, timestamp int64) error {
-	encReason := d.EncryptNullable(reason)
-	_, err := d.Exec(`INSERT INTO mod_actions (guild_id, moderator_id, target_id, action, reason, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
-		guildID, moderatorID, targetID, action, encReason, timestamp)
+// AddAIUsage counts one request and its tokens against a guild's daily usage
+func (d *DB) AddAIUsage(guildID, day, feature string, tokens int) error {
+	_, err := d.Exec(`INSERT INTO ai_usage (guild_id, day, feature, requests, tokens) VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT(guild_id, day, feature) DO UPDATE SET requests = requests + 1, tokens = tokens + excluded.tokens`,
+		guildID, day, feature, tokens)
 	return err
 }
 
-func (d *DB) ModActionExists(guildID, targetID, action string, timestamp int64) (bool, error) {
-	var count int
-	err := d.QueryRow(`SELECT COUNT(*) FROM mod_actions WHERE guild_id = ? AND target_id = ? AND action = ? AND timestamp = ?`,
-		guildID, targetID, action, timestamp).Scan(&count)
-	return count > 0, err
+// AddAIUserUsage counts one request and its tokens against a member's daily usage
+func (d *DB) AddAIUserUsage(guildID, userID, day string, tokens int) error {
+	_, err := d.Exec(`INSERT INTO ai_user_usage (guild_id, user_id, day, requests, tokens) VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT(guild_id, user_id, day) DO UPDATE SET requests = requests + 1, tokens = tokens + excluded.tokens`,
+		guildID, userID, day, tokens)
+	return err
 }
 
-func (d *DB) GetModActionsCount(guildID string) (int, error) {
-	var count int
-	err := d.QueryRow(`SELECT COUNT(*) FROM mod_actions WHERE guild_id = ?`, guildID).Scan(&count)
-	return count, err
+// GetAIUsageTotal returns a guild's usage of every AI feature from a day onwards
+func (d *DB) GetAIUsageTotal(guildID, since string) (*AIUsage, error) {
+	u := AIUsage{GuildID: guildID, Day: since}
+	err := d.QueryRow(`SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(tokens), 0)
+		FROM ai_usage WHERE guild_id = ? AND day >= ?`, guildID, since).Scan(&u.Requests, &u.Tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
 }
 
-func (d *DB) GetModStats(guildID string) (*ModStats, error) {
-	stats := &ModStats{
-		ActionCounts: make(map[string]int),
-		TopMods:      []ModeratorCount{},
+// GetAIUserUsageTotal returns a member's AI usage from a day onwards
+func (d *DB) GetAIUserUsageTotal(guildID, userID, since string) (*AIUsage, error) {
+	u := AIUsage{GuildID: guildID, UserID: userID, Day: since}
+	err := d.QueryRow(`SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(tokens), 0)
+		FROM ai_user_usage WHERE guild_id = ? AND user_id = ? AND day >= ?`, guildID, userID, since).Scan(&u.Requests, &u.Tokens)
+	if err != nil {
+		return nil, err
 	}
+	return &u, nil
+}
 
-	// Get total count
-	d.QueryRow(`SELECT COUNT(*) FROM mod_actions WHERE guild_id = ?`, guildID).Scan(&stats.TotalActions)
-
-	// Get action counts
-	rows, err := d.Query(`SELECT action, COUNT(*) as count FROM mod_actions WHERE guild_id = ? GROUP BY action`, guildID)
+// GetAIUsageByFeature returns a guild's usage from a day onwards, one entry per feature
+func (d *DB) GetAIUsageByFeature(guildID, since string) ([]*AIUsage, error) {
+	rows, err := d.Query(`SELECT feature, SUM(requests), SUM(tokens) FROM ai_usage
+		WHERE guild_id = ? AND day >= ? GROUP BY feature ORDER BY SUM(tokens) DESC`, guildID, since)
 	if err != nil {
-		return stats, err
+		return nil, err
 	}
 	defer rows.Close()
 
+	var usage []*AIUsage
 	for rows.Next() {
-		var action string
-		var count int
-		if err := rows.Scan(&action, &count); err == nil {
-			stats.ActionCounts[action] = count
+		u := AIUsage{GuildID: guildID, Day: since}
+		if err := rows.Scan(&u.Feature, &u.Requests, &u.Tokens); err != nil {
+			return nil, err
 		}
+		usage = append(usage, &u)
 	}
+	return usage, rows.Err()
+}
 
-	// Get top moderators
-	rows, err = d.Query(`SELECT moderator_id, COUNT(*) as count FROM mod_actions WHERE guild_id = ? GROUP BY moderator_id ORDER BY count DESC LIMIT 10`, guildID)
+// GetTopAIUsers returns the members who used the most AI tokens from a day onwards
+func (d *DB) GetTopAIUsers(guildID, since string, limit int) ([]*AIUsage, error) {
+	rows, err := d.Query(`SELECT user_id, SUM(requests), SUM(tokens) FROM ai_user_usage
+		WHERE guild_id = ? AND day >= ? GROUP BY user_id ORDER BY SUM(tokens) DESC LIMIT ?`, guildID, since, limit)
 	if err != nil {
-		return stats, err
+		return nil, err
 	}
 	defer rows.Close()
 
-	modMap := make(map[string]*ModeratorCount)
+	var usage []*AIUsage
 	for rows.Next() {
-		var modID string
-		var count int
-		if err := rows.Scan(&modID, &count); err == nil {
-			modMap[modID] = &ModeratorCount{
-				ModeratorID: modID,
-				Count:       count,
-				Actions:     make(map[string]int),
-			}
+		u := AIUsage{GuildID: guildID, Day: since}
+		if err := rows.Scan(&u.UserID, &u.Requests, &u.Tokens); err != nil {
+			return nil, err
 		}
+		usage = append(usage, &u)
 	}
+	return usage, rows.Err()
+}
 
-	// Get per-moderator action breakdown
-	for modID := range modMap {
-		rows, err := d.Query(`SELECT action, COUNT(*) as count FROM mod_actions WHERE guild_id = ? AND moderator_id = ? GROUP BY action`, guildID, modID)
-		if err != nil {
-			continue
-		}
-		for rows.Next() {
-			var action string
-			var count int
-			if err := rows.Scan(&action, &count); err == nil {
-				modMap[modID].Actions[action] = count
-			}
-		}
-		rows.Close()
-		stats.TopMods = append(stats.TopMods, *modMap[modID])
+// CleanOldAIUsage drops usage counts from before the given day (YYYY-MM-DD)
+func (d *DB) CleanOldAIUsage(before string) error {
+	if _, err := d.Exec(`DELETE FROM ai_usage WHERE day < ?`, before); err != nil {
+		return err
 	}
+	_, err := d.Exec(`DELETE FROM ai_user_usage WHERE day < ?`, before)
+	return err
+}
 
-	return stats, nil
+// CleanOldAIConversations drops exchanges older than their guild's memory retention
+func (d *DB) CleanOldAIConversations() error {
+	_, err := d.Exec(`DELETE FROM ai_conversations WHERE created_at < datetime('now',
+		'-' || COALESCE((SELECT memory_hours FROM ai_settings s WHERE s.guild_id = ai_conversations.guild_id), 24) || ' hours')`)
+	return err
 }
 
-func (d *DB) GetModActionsForTarget(guildID, targetID string) ([]ModAction, error) {
-	rows, err := d.Query(`SELECT id, guild_id, moderator_id, target_id, action, reason, timestamp, created_at
-		FROM mod_actions WHERE guild_id = ? AND target_id = ? ORDER BY timestamp DESC`, guildID, targetID)
-	if err != nil {
-		return nil, err
+// ============ AI Moderation ============
+
+func (d *DB) GetAIModerationConfig(guildID string) (*AIModerationConfig, error) {
+	// Read on every message, so it's worth sharing through the cache
+	key := "ai_moderation_config:" + guildID
+	var cfg AIModerationConfig
+	if d.cache.GetJSON(key, &cfg) {
+		return &cfg, nil
 	}
-	defer rows.Close()
 
-	var actions []ModAction
-	for rows.Next() {
-		var ma ModAction
-		if err := rows.Scan(&ma.ID, &ma.GuildID, &ma.ModeratorID, &ma.TargetID, &ma.Action, &ma.Reason, &ma.Timestamp, &ma.CreatedAt); err != nil {
-			return nil, err
-		}
-		ma.Reason = d.DecryptNullable(ma.Reason)
-		actions = append(actions, ma)
+	cfg = AIModerationConfig{GuildID: guildID, FlagThreshold: 0.5, DeleteThreshold: 0.8}
+	err := d.QueryRow(`SELECT enabled, flag_threshold, delete_threshold, warn_threshold,
+		COALESCE(flag_channel_id, ''), COALESCE(categories, '')
+		FROM ai_moderation_config WHERE guild_id = ?`, guildID).Scan(
+		&cfg.Enabled, &cfg.FlagThreshold, &cfg.DeleteThreshold, &cfg.WarnThreshold,
+		&cfg.FlagChannelID, &cfg.Categories)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
 	}
-	return actions, rows.Err()
+	d.cache.SetJSON(key, &cfg, settingsCacheTTL)
+	return &cfg, nil
 }
 
-// ============ Mention Responses ============
-
-func (d *DB) AddMentionResponse(guildID, trigger, response string, imageURL *string, createdBy string) error {
-	_, err := d.Exec(`INSERT INTO mention_responses (guild_id, trigger_text, response, image_url, created_by)
-		VALUES (?, ?, ?, ?, ?)
-		ON CONFLICT(guild_id, trigger_text) DO UPDATE SET response = excluded.response, image_url = excluded.image_url`,
-		guildID, d.Encrypt(trigger), d.Encrypt(response), d.EncryptNullable(imageURL), createdBy)
+func (d *DB) SetAIModerationConfig(cfg *AIModerationConfig) error {
+	_, err := d.Exec(`INSERT INTO ai_moderation_config (guild_id, enabled, flag_threshold, delete_threshold,
+		warn_threshold, flag_channel_id, categories)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET enabled = excluded.enabled, flag_threshold = excluded.flag_threshold,
+		delete_threshold = excluded.delete_threshold, warn_threshold = excluded.warn_threshold,
+		flag_channel_id = excluded.flag_channel_id, categories = excluded.categories`,
+		cfg.GuildID, cfg.Enabled, cfg.FlagThreshold, cfg.DeleteThreshold, cfg.WarnThreshold,
+		cfg.FlagChannelID, cfg.Categories)
+	if err == nil {
+		d.cache.Invalidate("ai_moderation_config:" + cfg.GuildID)
+	}
 	return err
 }
 
-func (d *DB) RemoveMentionResponse(guildID, trigger string) error {
-	// Need to encrypt trigger for lookup since it's stored encrypted
-	_, err := d.Exec(`DELETE FROM mention_responses WHERE guild_id = ? AND trigger_text = ?`, guildID, d.Encrypt(trigger))
+func (d *DB) AddAIModerationLog(entry *AIModerationLog) error {
+	_, err := d.Exec(`INSERT INTO ai_moderation_log (guild_id, channel_id, message_id, user_id, content, category, score, action)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.GuildID, entry.ChannelID, entry.MessageID, entry.UserID, d.Encrypt(entry.Content),
+		entry.Category, entry.Score, entry.Action)
 	return err
 }
 
-func (d *DB) GetMentionResponses(guildID string) ([]MentionResponse, error) {
-	rows, err := d.Query(`SELECT id, guild_id, trigger_text, response, image_url, created_by, created_at
-		FROM mention_responses WHERE guild_id = ? ORDER BY trigger_text`, guildID)
+// GetAIModerationLog returns a guild's most recent AI moderation entries, only
+// the given user's when userID is set
+func (d *DB) GetAIModerationLog(guildID, userID string, limit int) ([]*AIModerationLog, error) {
+	query := `SELECT id, guild_id, channel_id, message_id, user_id, COALESCE(content, ''), category, score, action, created_at
+		FROM ai_moderation_log WHERE guild_id = ?`
+	args := []interface{}{guildID}
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var responses []MentionResponse
+	var entries []*AIModerationLog
 	for rows.Next() {
-		var mr MentionResponse
-		if err := rows.Scan(&mr.ID, &mr.GuildID, &mr.TriggerText, &mr.Response, &mr.ImageURL, &mr.CreatedBy, &mr.CreatedAt); err != nil {
+		var e AIModerationLog
+		if err := rows.Scan(&e.ID, &e.GuildID, &e.ChannelID, &e.MessageID, &e.UserID, &e.Content,
+			&e.Category, &e.Score, &e.Action, &e.CreatedAt); err != nil {
 			return nil, err
 		}
-		mr.TriggerText = d.Decrypt(mr.TriggerText)
-		mr.Response = d.Decrypt(mr.Response)
-		mr.ImageURL = d.DecryptNullable(mr.ImageURL)
-		responses = append(responses, mr)
-	}
-	return responses, rows.Err()
-}
-
-func (d *DB) GetMentionResponse(guildID, trigger string) (*MentionResponse, error) {
-	var mr MentionResponse
-	// Need to encrypt trigger for lookup since it's stored encrypted
-	err := d.QueryRow(`SELECT id, guild_id, trigger_text, response, image_url, created_by, created_at
-		FROM mention_responses WHERE guild_id = ? AND trigger_text = ?`, guildID, d.Encrypt(trigger)).Scan(
-		&mr.ID, &mr.GuildID, &mr.TriggerText, &mr.Response, &mr.ImageURL, &mr.CreatedBy, &mr.CreatedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err == nil {
-		mr.TriggerText = d.Decrypt(mr.TriggerText)
-		mr.Response = d.Decrypt(mr.Response)
-		mr.ImageURL = d.DecryptNullable(mr.ImageURL)
+		e.Content = d.Decrypt(e.Content)
+		entries = append(entries, &e)
 	}
-	return &mr, err
+	return entries, rows.Err()
 }
 
-// ============ Spam Filter ============
+// ============ Text-to-Speech ============
 
-func (d *DB) GetSpamFilterConfig(guildID string) (*SpamFilterConfig, error) {
-	var sf SpamFilterConfig
-	err := d.QueryRow(`SELECT guild_id, enabled, max_mentions, max_links, max_emojis, action
-		FROM spam_filter_config WHERE guild_id = ?`, guildID).Scan(
-		&sf.GuildID, &sf.Enabled, &sf.MaxMentions, &sf.MaxLinks, &sf.MaxEmojis, &sf.Action)
+func (d *DB) GetTTSSettings(guildID string) (*TTSSettings, error) {
+	ts := TTSSettings{GuildID: guildID, Engine: TTSEngineEspeak}
+	err := d.QueryRow(`SELECT enabled, COALESCE(engine, 'espeak'), COALESCE(voice, '')
+		FROM tts_settings WHERE guild_id = ?`, guildID).Scan(&ts.Enabled, &ts.Engine, &ts.Voice)
 	if err == sql.ErrNoRows {
-		return &SpamFilterConfig{GuildID: guildID, Enabled: false, MaxMentions: 5, MaxLinks: 3, MaxEmojis: 10, Action: "delete"}, nil
+		return &ts, nil
 	}
-	return &sf, err
-}
-
-func (d *DB) SetSpamFilterConfig(sf *SpamFilterConfig) error {
-	_, err := d.Exec(`INSERT INTO spam_filter_config (guild_id, enabled, max_mentions, max_links, max_emojis, action)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(guild_id) DO UPDATE SET
-		enabled = excluded.enabled, max_mentions = excluded.max_mentions,
-		max_links = excluded.max_links, max_emojis = excluded.max_emojis, action = excluded.action`,
-		sf.GuildID, sf.Enabled, sf.MaxMentions, sf.MaxLinks, sf.MaxEmojis, sf.Action)
-	return err
-}
-
-// ============ Ticket System ============
-
-func (d *DB) GetTicketConfig(guildID string) (*TicketConfig, error) {
-	var tc TicketConfig
-	err := d.QueryRow(`SELECT guild_id, channel_id, enabled FROM ticket_config WHERE guild_id = ?`, guildID).Scan(
-		&tc.GuildID, &tc.ChannelID, &tc.Enabled)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	if err != nil {
+		return nil, err
 	}
-	return &tc, err
-}
-
-func (d *DB) SetTicketConfig(guildID, channelID string, enabled bool) error {
-	_, err := d.Exec(`INSERT INTO ticket_config (guild_id, channel_id, enabled)
-		VALUES (?, ?, ?)
-		ON CONFLICT(guild_id) DO UPDATE SET
-		channel_id = excluded.channel_id, enabled = excluded.enabled`,
-		guildID, channelID, enabled)
-	return err
+	return &ts, nil
 }
 
-func (d *DB) DeleteTicketConfig(guildID string) error {
-	_, err := d.Exec(`DELETE FROM ticket_config WHERE guild_id = ?`, guildID)
+func (d *DB) SetTTSSettings(ts *TTSSettings) error {
+	_, err := d.Exec(`INSERT INTO tts_settings (guild_id, enabled, engine, voice) VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET enabled = excluded.enabled, engine = excluded.engine, voice = excluded.voice`,
+		ts.GuildID, ts.Enabled, ts.Engine, ts.Voice)
 	return err
 }
 
-// ============ Anti-Raid System ============
+// ============ Moderation DMs ============
 
-func (d *DB) GetAntiRaidConfig(guildID string) (*AntiRaidConfig, error) {
-	var cfg AntiRaidConfig
-	var silentRole, alertRole, logChannel sql.NullString
-	err := d.QueryRow(`SELECT guild_id, enabled, raid_time, raid_size, auto_silence,
-		lockdown_duration, silent_role_id, alert_role_id, log_channel_id, action
-		FROM antiraid_config WHERE guild_id = ?`, guildID).Scan(
-		&cfg.GuildID, &cfg.Enabled, &cfg.RaidTime, &cfg.RaidSize, &cfg.AutoSilence,
-		&cfg.LockdownDuration, &silentRole, &alertRole, &logChannel, &cfg.Action)
+func (d *DB) GetModDMConfig(guildID string) (*ModDMConfig, error) {
+	cfg := ModDMConfig{GuildID: guildID}
+	err := d.QueryRow(`SELECT enabled, COALESCE(appeal_url, '') FROM mod_dm_config WHERE guild_id = ?`, guildID).
+		Scan(&cfg.Enabled, &cfg.AppealURL)
 	if err == sql.ErrNoRows {
-		return &AntiRaidConfig{
-			GuildID:          guildID,
-			Enabled:          false,
-			RaidTime:         300,
-			RaidSize:         5,
-			AutoSilence:      0,
-			LockdownDuration: 120,
-			Action:           "silence",
-		}, nil
-	}
-	if silentRole.Valid {
-		cfg.SilentRoleID = silentRole.String
-	}
-	if alertRole.Valid {
-		cfg.AlertRoleID = alertRole.String
+		return &cfg, nil
 	}
-	if logChannel.Valid {
-		cfg.LogChannelID = logChannel.String
+	if err != nil {
+		return nil, err
 	}
-	return &cfg, err
-}
-
-func (d *DB) SetAntiRaidConfig(cfg *AntiRaidConfig) error {
-	_, err := d.Exec(`INSERT INTO antiraid_config (guild_id, enabled, raid_time, raid_size, auto_silence,
-		lockdown_duration, silent_role_id, alert_role_id, log_channel_id, action)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(guild_id) DO UPDATE SET
-		enabled = excluded.enabled, raid_time = excluded.raid_time, raid_size = excluded.raid_size,
-		auto_silence = excluded.auto_silence, lockdown_duration = excluded.lockdown_duration,
-		silent_role_id = excluded.silent_role_id, alert_role_id = excluded.alert_role_id,
-		log_channel_id = excluded.log_channel_id, action = excluded.action`,
-		cfg.GuildID, cfg.Enabled, cfg.RaidTime, cfg.RaidSize, cfg.AutoSilence,
-		cfg.LockdownDuration, cfg.SilentRoleID, cfg.AlertRoleID, cfg.LogChannelID, cfg.Action)
-	return err
+	return &cfg, nil
 }
 
-func (d *DB) RecordMemberJoin(guildID, userID string, joinedAt, accountCreatedAt int64) error {
-	_, err := d.Exec(`INSERT INTO member_joins (guild_id, user_id, joined_at, account_created_at)
-		VALUES (?, ?, ?, ?)`, guildID, userID, joinedAt, accountCreatedAt)
+func (d *DB) SetModDMConfig(cfg *ModDMConfig) error {
+	_, err := d.Exec(`INSERT INTO mod_dm_config (guild_id, enabled, appeal_url) VALUES (?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET enabled = excluded.enabled, appeal_url = excluded.appeal_url`,
+		cfg.GuildID, cfg.Enabled, cfg.AppealURL)
 	return err
 }
 
-func (d *DB) CountRecentJoins(guildID string, sinceTimestamp int64) (int, error) {
-	var count int
-	err := d.QueryRow(`SELECT COUNT(*) FROM member_joins WHERE guild_id = ? AND joined_at >= ?`,
-		guildID, sinceTimestamp).Scan(&count)
-	return count, err
+// ============ Reason Presets ============
+
+// AddReasonPreset creates a preset, or replaces the reason of one with the same name
+func (d *DB) AddReasonPreset(guildID, name, reason, createdBy string) error {
+	_, err := d.Exec(`INSERT INTO reason_presets (guild_id, name, reason, created_by) VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id, name) DO UPDATE SET reason = excluded.reason`,
+		guildID, name, reason, createdBy)
+	return err
 }
 
-func (d *DB) GetRecentJoins(guildID string, sinceTimestamp int64) ([]MemberJoin, error) {
-	rows, err := d.Query(`SELECT id, guild_id, user_id, joined_at, account_created_at
-		FROM member_joins WHERE guild_id = ? AND joined_at >= ? ORDER BY joined_at DESC`,
-		guildID, sinceTimestamp)
+func (d *DB) GetReasonPresets(guildID string) ([]ReasonPreset, error) {
+	rows, err := d.Query(`SELECT id, guild_id, name, reason, created_by, created_at
+		FROM reason_presets WHERE guild_id = ? ORDER BY name`, guildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var joins []MemberJoin
+	var presets []ReasonPreset
 	for rows.Next() {
-		var mj MemberJoin
-		if err := rows.Scan(&mj.ID, &mj.GuildID, &mj.UserID, &mj.JoinedAt, &mj.AccountCreatedAt); err != nil {
+		var p ReasonPreset
+		if err := rows.Scan(&p.ID, &p.GuildID, &p.Name, &p.Reason, &p.CreatedBy, &p.CreatedAt); err != nil {
 			return nil, err
 		}
-		joins = append(joins, mj)
+		presets = append(presets, p)
 	}
-	return joins, rows.Err()
+	return presets, rows.Err()
 }
 
-func (d *DB) CleanOldJoins(guildID string, beforeTimestamp int64) error {
-	_, err := d.Exec(`DELETE FROM member_joins WHERE guild_id = ? AND joined_at < ?`,
-		guildID, beforeTimestamp)
-	return err
+// GetReasonPreset looks up a preset by name, ignoring case. It returns nil if there is none.
+func (d *DB) GetReasonPreset(guildID, name string) (*ReasonPreset, error) {
+	var p ReasonPreset
+	err := d.QueryRow(`SELECT id, guild_id, name, reason, created_by, created_at
+		FROM reason_presets WHERE guild_id = ? AND name = ?`, guildID, name).
+		Scan(&p.ID, &p.GuildID, &p.Name, &p.Reason, &p.CreatedBy, &p.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
 }
 
-// ============ Anti-Spam System ============
+func (d *DB) CountReasonPresets(guildID string) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM reason_presets WHERE guild_id = ?`, guildID).Scan(&count)
+	return count, err
+}
 
-func (d *DB) GetAntiSpamConfig(guildID string) (*AntiSpamConfig, error) {
-	var cfg AntiSpamConfig
-	var silentRole sql.NullString
-	err := d.QueryRow(`SELECT guild_id, enabled, base_pressure, image_pressure, link_pressure,
-		ping_pressure, length_pressure, line_pressure, repeat_pressure, max_pressure,
-		pressure_decay, action, silent_role_id FROM antispam_config WHERE guild_id = ?`, guildID).Scan(
-		&cfg.GuildID, &cfg.Enabled, &cfg.BasePressure, &cfg.ImagePressure, &cfg.LinkPressure,
-		&cfg.PingPressure, &cfg.LengthPressure, &cfg.LinePressure, &cfg.RepeatPressure,
-		&cfg.MaxPressure, &cfg.PressureDecay, &cfg.Action, &silentRole)
-	if err == sql.ErrNoRows {
-		return &AntiSpamConfig{
-			GuildID:        guildID,
-			Enabled:        false,
-			BasePressure:   10.0,
-			ImagePressure:  8.33,
-			LinkPressure:   8.33,
-			PingPressure:   2.5,
-			LengthPressure: 0.00625,
-			LinePressure:   0.71,
-			RepeatPressure: 10.0,
-			MaxPressure:    60.0,
-			PressureDecay:  2.5,
-			Action:         "delete",
-		}, nil
+// DeleteReasonPreset removes a preset and reports whether it existed
+func (d *DB) DeleteReasonPreset(guildID, name string) (bool, error) {
+	res, err := d.Exec(`DELETE FROM reason_presets WHERE guild_id = ? AND name = ?`, guildID, name)
+	if err != nil {
+		return false, err
 	}
-	if silentRole.Valid {
-		cfg.SilentRoleID = silentRole.String
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ============ Auto Slowmode ============
+
+const autoSlowmodeColumns = `guild_id, channel_id, threshold, max_seconds, base_seconds, paused_until, created_by, created_at`
+
+func scanAutoSlowmodeChannel(scanner interface{ Scan(...interface{}) error }) (*AutoSlowmodeChannel, error) {
+	var c AutoSlowmodeChannel
+	var pausedUntil sql.NullTime
+	err := scanner.Scan(&c.GuildID, &c.ChannelID, &c.Threshold, &c.MaxSeconds, &c.BaseSeconds, &pausedUntil, &c.CreatedBy, &c.CreatedAt)
+	if err != nil {
+		return nil, err
 	}
-	return &cfg, err
+	if pausedUntil.Valid {
+		c.PausedUntil = &pausedUntil.Time
+	}
+	return &c, nil
 }
 
-func (d *DB) SetAntiSpamConfig(cfg *AntiSpamConfig) error {
-	_, err := d.Exec(`INSERT INTO antispam_config (guild_id, enabled, base_pressure, image_pressure,
-		link_pressure, ping_pressure, length_pressure, line_pressure, repeat_pressure,
-		max_pressure, pressure_decay, action, silent_role_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(guild_id) DO UPDATE SET
-		enabled = excluded.enabled, base_pressure = excluded.base_pressure,
-		image_pressure = excluded.image_pressure, link_pressure = excluded.link_pressure,
-		ping_pressure = excluded.ping_pressure, length_pressure = excluded.length_pressure,
-		line_pressure = excluded.line_pressure, repeat_pressure = excluded.repeat_pressure,
-		max_pressure = excluded.max_pressure, pressure_decay = excluded.pressure_decay,
-		action = excluded.action, silent_role_id = excluded.silent_role_id`,
-		cfg.GuildID, cfg.Enabled, cfg.BasePressure, cfg.ImagePressure, cfg.LinkPressure,
-		cfg.PingPressure, cfg.LengthPressure, cfg.LinePressure, cfg.RepeatPressure,
-		cfg.MaxPressure, cfg.PressureDecay, cfg.Action, cfg.SilentRoleID)
+// SetAutoSlowmodeChannel adds or updates a channel's automatic slowmode. Any pause is kept.
+func (d *DB) SetAutoSlowmodeChannel(c *AutoSlowmodeChannel) error {
+	_, err := d.Exec(`INSERT INTO auto_slowmode_channels (guild_id, channel_id, threshold, max_seconds, base_seconds, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(guild_id, channel_id) DO UPDATE SET
+		threshold = excluded.threshold, max_seconds = excluded.max_seconds, base_seconds = excluded.base_seconds`,
+		c.GuildID, c.ChannelID, c.Threshold, c.MaxSeconds, c.BaseSeconds, c.CreatedBy)
 	return err
 }
 
-// ============ Scheduled Events ============
+func (d *DB) RemoveAutoSlowmodeChannel(guildID, channelID string) (bool, error) {
+	result, err := d.Exec(`DELETE FROM auto_slowmode_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
 
-func (d *DB) AddScheduledEvent(guildID, eventType, targetID string, executeAt int64) error {
-	_, err := d.Exec(`INSERT INTO scheduled_events (guild_id, event_type, target_id, execute_at)
-		VALUES (?, ?, ?, ?)`, guildID, eventType, targetID, executeAt)
+// PauseAutoSlowmode stops automatic changes in a channel until the given time, nil resumes them
+func (d *DB) PauseAutoSlowmode(guildID, channelID string, until *time.Time) error {
+	var pausedUntil interface{}
+	if until != nil {
+		pausedUntil = until.UTC()
+	}
+	_, err := d.Exec(`UPDATE auto_slowmode_channels SET paused_until = ? WHERE guild_id = ? AND channel_id = ?`,
+		pausedUntil, guildID, channelID)
 	return err
 }
 
-func (d *DB) GetDueEvents(beforeTimestamp int64) ([]ScheduledEvent, error) {
-	rows, err := d.Query(`SELECT id, guild_id, event_type, target_id, execute_at
-		FROM scheduled_events WHERE execute_at <= ?`, beforeTimestamp)
+// GetAutoSlowmodeChannel returns a channel's automatic slowmode settings, nil if it has none
+func (d *DB) GetAutoSlowmodeChannel(guildID, channelID string) (*AutoSlowmodeChannel, error) {
+	c, err := scanAutoSlowmodeChannel(d.QueryRow(`SELECT `+autoSlowmodeColumns+`
+		FROM auto_slowmode_channels WHERE guild_id = ? AND channel_id = ?`, guildID, channelID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+func (d *DB) GetAutoSlowmodeChannels(guildID string) ([]*AutoSlowmodeChannel, error) {
+	rows, err := d.Query(`SELECT `+autoSlowmodeColumns+`
+		FROM auto_slowmode_channels WHERE guild_id = ? ORDER BY created_at`, guildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var events []ScheduledEvent
+	var channels []*AutoSlowmodeChannel
 	for rows.Next() {
-		var ev ScheduledEvent
-		if err := rows.Scan(&ev.ID, &ev.GuildID, &ev.EventType, &ev.TargetID, &ev.ExecuteAt); err != nil {
+		c, err := scanAutoSlowmodeChannel(rows)
+		if err != nil {
 			return nil, err
 		}
-		events = append(events, ev)
+		channels = append(channels, c)
 	}
-	return events, rows.Err()
-}
-
-func (d *DB) DeleteScheduledEvent(id int64) error {
-	_, err := d.Exec(`DELETE FROM scheduled_events WHERE id = ?`, id)
-	return err
+	return channels, rows.Err()
 }
 
-func (d *DB) DeleteScheduledEventByTarget(guildID, eventType, targetID string) error {
-	_, err := d.Exec(`DELETE FROM scheduled_events WHERE guild_id = ? AND event_type = ? AND target_id = ?`,
-		guildID, eventType, targetID)
-	return err
-}
+// ============ Guild Export ============
 
-// ============ User Aliases ============
+// ExportGuild collects every row from tables with a guild_id column, keyed by
+// table name. Encrypted fields are decrypted so the export stands on its own.
+func (d *DB) ExportGuild(guildID string) (map[string][]map[string]interface{}, error) {
+	tables, err := d.guildTables()
+	if err != nil {
+		return nil, err
+	}
 
-func (d *DB) RecordAlias(userID, alias, aliasType string) error {
-	_, err := d.Exec(`INSERT INTO user_aliases (user_id, alias, alias_type, last_seen, use_count)
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP, 1)
-		ON CONFLICT(user_id, alias, alias_type) DO UPDATE SET
-		last_seen = CURRENT_TIMESTAMP, use_count = use_count + 1`,
-		userID, alias, aliasType)
-	return err
+	export := make(map[string][]map[string]interface{})
+	for _, table := range tables {
+		rows, err := d.exportRows(table, guildID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		if len(rows) > 0 {
+			export[table] = rows
+		}
+	}
+	return export, nil
 }
 
-func (d *DB) GetUserAliases(userID string, limit int) ([]UserAlias, error) {
-	rows, err := d.Query(`SELECT id, user_id, alias, alias_type, first_seen, last_seen, use_count
-		FROM user_aliases WHERE user_id = ? ORDER BY use_count DESC, last_seen DESC LIMIT ?`,
-		userID, limit)
+// guildTables lists the tables that have a guild_id column
+func (d *DB) guildTables() ([]string, error) {
+	rows, err := d.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	aliases := make([]UserAlias, 0, limit)
+	var all []string
 	for rows.Next() {
-		var a UserAlias
-		if err := rows.Scan(&a.ID, &a.UserID, &a.Alias, &a.AliasType, &a.FirstSeen, &a.LastSeen, &a.UseCount); err != nil {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
 			return nil, err
 		}
-		aliases = append(aliases, a)
+		all = append(all, name)
 	}
-	return aliases, rows.Err()
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var tables []string
+	for _, name := range all {
+		var n int
+		if err := d.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = 'guild_id'`, name).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			tables = append(tables, name)
+		}
+	}
+	return tables, nil
 }
 
-func (d *DB) SearchUserByAlias(alias string) ([]string, error) {
-	rows, err := d.Query(`SELECT DISTINCT user_id FROM user_aliases WHERE alias LIKE ? LIMIT 10`,
-		"%"+alias+"%")
+func (d *DB) exportRows(table, guildID string) ([]map[string]interface{}, error) {
+	// Table names come from sqlite_master, never from user input
+	rows, err := d.Query(`SELECT * FROM "`+table+`" WHERE guild_id = ?`, guildID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	userIDs := make([]string, 0, 10)
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
 	for rows.Next() {
-		var userID string
-		if err := rows.Scan(&userID); err != nil {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
 			return nil, err
 		}
-		userIDs = append(userIDs, userID)
+
+		row := make(map[string]interface{}, len(cols))
+		for i, col := range cols {
+			switch v := values[i].(type) {
+			case []byte:
+				row[col] = d.exportString(string(v))
+			case string:
+				row[col] = d.exportString(v)
+			default:
+				row[col] = v
+			}
+		}
+		result = append(result, row)
 	}
-	return userIDs, rows.Err()
+	return result, rows.Err()
 }
 
-// ============ User Activity ============
+func (d *DB) exportString(s string) string {
+	if d.IsDataEncrypted(s) {
+		return d.Decrypt(s)
+	}
+	return s
+}
 
-func (d *DB) UpdateUserActivity(guildID, userID string, isMessage bool) error {
-	now := time.Now()
+// ============ SQL Console ============
 
-	if isMessage {
-		_, err := d.Exec(`INSERT INTO user_activity (guild_id, user_id, first_seen, first_message, last_seen, message_count)
-			VALUES (?, ?, ?, ?, ?, 1)
-			ON CONFLICT(guild_id, user_id) DO UPDATE SET
-			last_seen = ?,
-			message_count = message_count + 1,
-			first_message = COALESCE(first_message, ?)`,
-			guildID, userID, now, now, now, now, now)
-		return err
-	}
+// ErrSQLWrites is returned by QueryReadOnly when a statement would modify the database
+var ErrSQLWrites = errors.New("statement modifies the database")
 
-	_, err := d.Exec(`INSERT INTO user_activity (guild_id, user_id, first_seen, last_seen, message_count)
-		VALUES (?, ?, ?, ?, 0)
-		ON CONFLICT(guild_id, user_id) DO UPDATE SET last_seen = ?`,
-		guildID, userID, now, now, now)
-	return err
-}
+// ErrSQLMultipleStatements is returned by QueryReadOnly for input holding more than one statement
+var ErrSQLMultipleStatements = errors.New("only one statement can be run at a time")
 
-func (d *DB) GetUserActivity(guildID, userID string) (*UserActivity, error) {
-	var ua UserActivity
-	err := d.QueryRow(`SELECT guild_id, user_id, first_seen, first_message, last_seen, message_count
-		FROM user_activity WHERE guild_id = ? AND user_id = ?`, guildID, userID).Scan(
-		&ua.GuildID, &ua.UserID, &ua.FirstSeen, &ua.FirstMessage, &ua.LastSeen, &ua.MessageCount)
-	if err == sql.ErrNoRows {
-		return nil, nil
+// QueryReadOnly runs a statement on a connection that refuses writes, returning
+// at most maxRows rows. Statements that would write fail with ErrSQLWrites.
+// PRAGMA statements are treated as writes, since one can switch the read-only guard off.
+func (d *DB) QueryReadOnly(query string, maxRows int) (*SQLResult, error) {
+	keyword, single := parseSQLStatement(query)
+	if !single {
+		return nil, ErrSQLMultipleStatements
+	}
+	if keyword == "PRAGMA" {
+		return nil, ErrSQLWrites
 	}
-	return &ua, err
-}
 
-func (d *DB) GetNewestMembers(guildID string, limit int) ([]UserActivity, error) {
-	rows, err := d.Query(`SELECT guild_id, user_id, first_seen, first_message, last_seen, message_count
-		FROM user_activity WHERE guild_id = ? ORDER BY first_seen DESC LIMIT ?`,
-		guildID, limit)
+	ctx := context.Background()
+	conn, err := d.Conn(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA query_only = ON`); err != nil {
+		return nil, err
+	}
+	// The connection goes back to the pool, so it must accept writes again
+	defer conn.ExecContext(ctx, `PRAGMA query_only = OFF`)
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, sqlConsoleError(err)
+	}
 	defer rows.Close()
 
-	activities := make([]UserActivity, 0, limit)
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SQLResult{Columns: cols}
 	for rows.Next() {
-		var ua UserActivity
-		if err := rows.Scan(&ua.GuildID, &ua.UserID, &ua.FirstSeen, &ua.FirstMessage, &ua.LastSeen, &ua.MessageCount); err != nil {
+		if len(result.Rows) >= maxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
 			return nil, err
 		}
-		activities = append(activities, ua)
+
+		row := make([]string, len(cols))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+				row[i] = "NULL"
+			case []byte:
+				row[i] = string(v)
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		result.Rows = append(result.Rows, row)
 	}
-	return activities, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, sqlConsoleError(err)
+	}
+	return result, nil
 }
 
-// ============ User Timezones ============
-
-func (d *DB) SetUserTimezone(userID, timezone string) error {
-	_, err := d.Exec(`INSERT INTO user_timezones (user_id, timezone, updated_at)
-		VALUES (?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(user_id) DO UPDATE SET timezone = excluded.timezone, updated_at = CURRENT_TIMESTAMP`,
-		userID, timezone)
-	return err
+// ExecConsole runs a statement that writes, once the owner has confirmed it
+func (d *DB) ExecConsole(query string) (*SQLResult, error) {
+	res, err := d.Exec(query)
+	if err != nil {
+		return nil, err
+	}
+	affected, _ := res.RowsAffected()
+	return &SQLResult{RowsAffected: affected}, nil
 }
 
-func (d *DB) GetUserTimezone(userID string) (string, error) {
-	var tz string
-	err := d.QueryRow(`SELECT timezone FROM user_timezones WHERE user_id = ?`, userID).Scan(&tz)
-	if err == sql.ErrNoRows {
-		return "", nil
+// parseSQLStatement returns the leading keyword of a query, upper-cased, and whether
+// the query is a single statement. Semicolons inside quotes and comments don't count.
+func parseSQLStatement(query string) (keyword string, single bool) {
+	var ended, sawKeyword bool
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return keyword, true
+			}
+			i += end + 3
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		case c == ';':
+			ended = true
+			continue
+		}
+
+		// Anything else after a semicolon starts another statement
+		if ended {
+			return keyword, false
+		}
+
+		switch c {
+		case '\'', '"', '`', '[':
+			closer := c
+			if c == '[' {
+				closer = ']'
+			}
+			end := strings.IndexByte(query[i+1:], closer)
+			if end < 0 {
+				return keyword, true
+			}
+			i += end + 1
+		default:
+			if !sawKeyword {
+				sawKeyword = true
+				start := i
+				for i < len(query) && (query[i] == '_' || query[i] >= 'a' && query[i] <= 'z' ||
+					query[i] >= 'A' && query[i] <= 'Z') {
+					i++
+				}
+				keyword = strings.ToUpper(query[start:i])
+				i--
+			}
+		}
 	}
-	return tz, err
+	return keyword, true
 }
 
-func (d *DB) DeleteUserTimezone(userID string) error {
-	_, err := d.Exec(`DELETE FROM user_timezones WHERE user_id = ?`, userID)
+func sqlConsoleError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrReadonly {
+		return ErrSQLWrites
+	}
 	return err
 }
 
-// ============ Music Settings ============
+// ============ Reports ============
 
-func (d *DB) GetMusicSettings(guildID string) (*MusicSettings, error) {
-	var ms MusicSettings
-	err := d.QueryRow(`SELECT guild_id, dj_role_id, mod_role_id, volume, music_folder
-		FROM music_settings WHERE guild_id = ?`, guildID).Scan(
-		&ms.GuildID, &ms.DJRoleID, &ms.ModRoleID, &ms.Volume, &ms.MusicFolder)
+// ErrAlreadyReported is returned by FileReport when the reporter already reported the same open report
+var ErrAlreadyReported = errors.New("already reported")
+
+func (d *DB) GetReportConfig(guildID string) (*ReportConfig, error) {
+	cfg := ReportConfig{GuildID: guildID, AllowAnonymous: true}
+	var channelID sql.NullString
+	err := d.QueryRow(`SELECT channel_id, enabled, allow_anonymous FROM report_config WHERE guild_id = ?`,
+		guildID).Scan(&channelID, &cfg.Enabled, &cfg.AllowAnonymous)
 	if err == sql.ErrNoRows {
-		return &MusicSettings{GuildID: guildID, Volume: 50}, nil
+		return &cfg, nil
 	}
-	return &ms, err
+	cfg.ChannelID = channelID.String
+	return &cfg, err
 }
 
-func (d *DB) SetMusicSettings(ms *MusicSettings) error {
-	_, err := d.Exec(`INSERT INTO music_settings (guild_id, dj_role_id, mod_role_id, volume, music_folder, updated_at)
-		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+func (d *DB) SetReportConfig(cfg *ReportConfig) error {
+	_, err := d.Exec(`INSERT INTO report_config (guild_id, channel_id, enabled, allow_anonymous)
+		VALUES (?, ?, ?, ?)
 		ON CONFLICT(guild_id) DO UPDATE SET
-		dj_role_id = excluded.dj_role_id, mod_role_id = excluded.mod_role_id,
-		volume = excluded.volume, music_folder = excluded.music_folder,
-		updated_at = CURRENT_TIMESTAMP`,
-		ms.GuildID, ms.DJRoleID, ms.ModRoleID, ms.Volume, ms.MusicFolder)
+		channel_id = excluded.channel_id, enabled = excluded.enabled, allow_anonymous = excluded.allow_anonymous`,
+		cfg.GuildID, nullString(cfg.ChannelID), cfg.Enabled, cfg.AllowAnonymous)
 	return err
 }
 
-func (d *DB) UpdateMusicVolume(guildID string, volume int) error {
-	_, err := d.Exec(`INSERT INTO music_settings (guild_id, volume, updated_at)
-		VALUES (?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(guild_id) DO UPDATE SET volume = excluded.volume, updated_at = CURRENT_TIMESTAMP`,
-		guildID, volume)
-	return err
+const reportColumns = `id, guild_id, target_id, COALESCE(channel_id, ''), COALESCE(message_id, ''), reporter_id,
+	COALESCE(reason, ''), anonymous, status, report_count, COALESCE(staff_channel_id, ''), COALESCE(staff_message_id, ''),
+	COALESCE(handled_by, ''), handled_at, created_at`
+
+func (d *DB) scanReport(scanner interface{ Scan(...interface{}) error }) (*Report, error) {
+	var r Report
+	var handledAt sql.NullTime
+	err := scanner.Scan(&r.ID, &r.GuildID, &r.TargetID, &r.ChannelID, &r.MessageID, &r.ReporterID,
+		&r.Reason, &r.Anonymous, &r.Status, &r.ReportCount, &r.StaffChannelID, &r.StaffMessageID,
+		&r.HandledBy, &handledAt, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.Reason = d.Decrypt(r.Reason)
+	if handledAt.Valid {
+		r.HandledAt = &handledAt.Time
+	}
+	return &r, nil
 }
 
-func (d *DB) UpdateMusicRoles(guildID string, djRoleID, modRoleID *string) error {
-	_, err := d.Exec(`INSERT INTO music_settings (guild_id, dj_role_id, mod_role_id, updated_at)
-		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(guild_id) DO UPDATE SET
-		dj_role_id = excluded.dj_role_id, mod_role_id = excluded.mod_role_id,
-		updated_at = CURRENT_TIMESTAMP`,
-		guildID, djRoleID, modRoleID)
-	return err
+// FileReport records a report. An open report about the same message, or the
+// same member when no message is given, is reused and its count raised instead
+// of creating a duplicate; created tells which happened. A reporter can only
+// count once towards a report.
+func (d *DB) FileReport(r *Report) (report *Report, created bool, err error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	var existingID int64
+	err = tx.QueryRow(`SELECT id FROM reports
+		WHERE guild_id = ? AND status = ? AND target_id = ? AND message_id = ?
+		ORDER BY id LIMIT 1`, r.GuildID, ReportOpen, r.TargetID, r.MessageID).Scan(&existingID)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.Exec(`INSERT INTO reports (guild_id, target_id, channel_id, message_id, reporter_id, reason, anonymous, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.GuildID, r.TargetID, r.ChannelID, r.MessageID, r.ReporterID, d.Encrypt(r.Reason), r.Anonymous, ReportOpen)
+		if err != nil {
+			return nil, false, err
+		}
+		if existingID, err = res.LastInsertId(); err != nil {
+			return nil, false, err
+		}
+		created = true
+	case err != nil:
+		return nil, false, err
+	default:
+		var seen int
+		tx.QueryRow(`SELECT COUNT(*) FROM report_reporters WHERE report_id = ? AND user_id = ?`, existingID, r.ReporterID).Scan(&seen)
+		if seen > 0 {
+			return nil, false, ErrAlreadyReported
+		}
+		if _, err := tx.Exec(`UPDATE reports SET report_count = report_count + 1 WHERE id = ?`, existingID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO report_reporters (report_id, user_id) VALUES (?, ?)`, existingID, r.ReporterID); err != nil {
+		return nil, false, err
+	}
+	report, err = d.scanReport(tx.QueryRow(`SELECT `+reportColumns+` FROM reports WHERE id = ?`, existingID))
+	if err != nil {
+		return nil, false, err
+	}
+	return report, created, tx.Commit()
 }
 
-// ============ Music Queue ============
+// GetReport returns a report by ID, nil if it doesn't exist
+func (d *DB) GetReport(id int64) (*Report, error) {
+	r, err := d.scanReport(d.QueryRow(`SELECT `+reportColumns+` FROM reports WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
 
-func (d *DB) AddToMusicQueue(item *MusicQueueItem) error {
-	_, err := d.Exec(`INSERT INTO music_queue (guild_id, channel_id, user_id, title, url, duration, thumbnail, is_local, position)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?,
-			COALESCE((SELECT MAX(position) + 1 FROM music_queue WHERE guild_id = ?), 0))`,
-		item.GuildID, item.ChannelID, item.UserID, item.Title, item.URL, item.Duration, item.Thumbnail, item.IsLocal, item.GuildID)
+// SetReportMessage records where a report was posted for staff
+func (d *DB) SetReportMessage(id int64, channelID, messageID string) error {
+	_, err := d.Exec(`UPDATE reports SET staff_channel_id = ?, staff_message_id = ? WHERE id = ?`, channelID, messageID, id)
 	return err
 }
 
-func (d *DB) GetMusicQueue(guildID string) ([]MusicQueueItem, error) {
-	rows, err := d.Query(`SELECT id, guild_id, channel_id, user_id, title, url, duration, thumbnail, is_local, position, added_at
-		FROM music_queue WHERE guild_id = ? ORDER BY position ASC`, guildID)
+// CloseReport resolves or dismisses an open report and reports whether it was still open
+func (d *DB) CloseReport(id int64, status, handledBy string) (bool, error) {
+	res, err := d.Exec(`UPDATE reports SET status = ?, handled_by = ?, handled_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?`, status, handledBy, id, ReportOpen)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetReports returns a guild's newest reports, optionally only those with the given status
+func (d *DB) GetReports(guildID, status string, limit int) ([]Report, error) {
+	query := `SELECT ` + reportColumns + ` FROM reports WHERE guild_id = ?`
+	args := []interface{}{guildID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var items []MusicQueueItem
+	var reports []Report
 	for rows.Next() {
-		var item MusicQueueItem
-		if err := rows.Scan(&item.ID, &item.GuildID, &item.ChannelID, &item.UserID, &item.Title, &item.URL,
-			&item.Duration, &item.Thumbnail, &item.IsLocal, &item.Position, &item.AddedAt); err != nil {
+		r, err := d.scanReport(rows)
+		if err != nil {
 			return nil, err
 		}
-		items = append(items, item)
+		reports = append(reports, *r)
 	}
-	return items, rows.Err()
+	return reports, rows.Err()
 }
 
-func (d *DB) RemoveFromMusicQueue(guildID string, position int) error {
-	tx, err := d.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	_, err = tx.Exec(`DELETE FROM music_queue WHERE guild_id = ? AND position = ?`, guildID, position)
-	if err != nil {
-		return err
-	}
+// ============ Quotes ============
 
-	_, err = tx.Exec(`UPDATE music_queue SET position = position - 1 WHERE guild_id = ? AND position > ?`, guildID, position)
-	if err != nil {
-		return err
-	}
+// ErrQuoteExists is returned by AddQuote when the message is already on the quote board
+var ErrQuoteExists = errors.New("quote already exists")
 
-	return tx.Commit()
-}
+const quoteColumns = `id, guild_id, number, user_id, content, COALESCE(channel_id, ''), COALESCE(message_id, ''), added_by, created_at`
 
-func (d *DB) ClearMusicQueue(guildID string) error {
-	_, err := d.Exec(`DELETE FROM music_queue WHERE guild_id = ?`, guildID)
-	return err
+func scanQuotes(rows *sql.Rows) ([]Quote, error) {
+	defer rows.Close()
+	var quotes []Quote
+	for rows.Next() {
+		var q Quote
+		if err := rows.Scan(&q.ID, &q.GuildID, &q.Number, &q.UserID, &q.Content, &q.ChannelID, &q.MessageID, &q.AddedBy, &q.CreatedAt); err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, rows.Err()
 }
 
-func (d *DB) MoveToTopMusicQueue(guildID string, position int) error {
+// AddQuote saves a quote under the guild's next number, which is filled in on q
+func (d *DB) AddQuote(q *Quote) error {
 	tx, err := d.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Temporarily set position to -1
-	_, err = tx.Exec(`UPDATE music_queue SET position = -1 WHERE guild_id = ? AND position = ?`, guildID, position)
-	if err != nil {
+	if q.MessageID != "" {
+		var existing int
+		tx.QueryRow(`SELECT COUNT(*) FROM quotes WHERE guild_id = ? AND message_id = ?`, q.GuildID, q.MessageID).Scan(&existing)
+		if existing > 0 {
+			return ErrQuoteExists
+		}
+	}
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(number), 0) + 1 FROM quotes WHERE guild_id = ?`, q.GuildID).Scan(&q.Number); err != nil {
 		return err
 	}
-
-	// Increment all positions that were less than the moved item
-	_, err = tx.Exec(`UPDATE music_queue SET position = position + 1 WHERE guild_id = ? AND position >= 0 AND position < ?`, guildID, position)
+	result, err := tx.Exec(`INSERT INTO quotes (guild_id, number, user_id, content, channel_id, message_id, added_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, q.GuildID, q.Number, q.UserID, q.Content, q.ChannelID, q.MessageID, q.AddedBy)
 	if err != nil {
 		return err
 	}
-
-	// Set the moved item to position 0
-	_, err = tx.Exec(`UPDATE music_queue SET position = 0 WHERE guild_id = ? AND position = -1`, guildID)
-	if err != nil {
+	if q.ID, err = result.LastInsertId(); err != nil {
 		return err
 	}
-
+	q.CreatedAt = time.Now()
 	return tx.Commit()
 }
 
-// ============ Music History ============
-
-func (d *DB) AddToMusicHistory(guildID, userID, title, url string) error {
-	_, err := d.Exec(`INSERT INTO music_history (guild_id, user_id, title, url) VALUES (?, ?, ?, ?)`,
-		guildID, userID, title, url)
-	return err
-}
-
-func (d *DB) GetMusicHistory(guildID string, limit int) ([]MusicHistory, error) {
-	rows, err := d.Query(`SELECT id, guild_id, user_id, title, url, played_at
-		FROM music_history WHERE guild_id = ? ORDER BY played_at DESC LIMIT ?`, guildID, limit)
+// GetQuote returns a quote by its guild number, nil if there is none
+func (d *DB) GetQuote(guildID string, number int) (*Quote, error) {
+	rows, err := d.Query(`SELECT `+quoteColumns+` FROM quotes WHERE guild_id = ? AND number = ?`, guildID, number)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	items := make([]MusicHistory, 0, limit)
-	for rows.Next() {
-		var item MusicHistory
-		if err := rows.Scan(&item.ID, &item.GuildID, &item.UserID, &item.Title, &item.URL, &item.PlayedAt); err != nil {
-			return nil, err
-		}
-		items = append(items, item)
+	quotes, err := scanQuotes(rows)
+	if err != nil || len(quotes) == 0 {
+		return nil, err
 	}
-	return items, rows.Err()
+	return &quotes[0], nil
 }
 
-// ============ Disabled Commands/Categories ============
-
-// IsCommandDisabled checks if a specific command is disabled for a guild
-func (d *DB) IsCommandDisabled(guildID, commandName string) bool {
-	var count int
-	err := d.QueryRow(`SELECT COUNT(*) FROM guild_disabled_commands WHERE guild_id = ? AND command_name = ?`,
-		guildID, commandName).Scan(&count)
+// GetRandomQuote picks a random quote, only from userID when it's set. It returns nil if there are none.
+func (d *DB) GetRandomQuote(guildID, userID string) (*Quote, error) {
+	query := `SELECT ` + quoteColumns + ` FROM quotes WHERE guild_id = ?`
+	args := []interface{}{guildID}
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	rows, err := d.Query(query+` ORDER BY RANDOM() LIMIT 1`, args...)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	return count > 0
+	quotes, err := scanQuotes(rows)
+	if err != nil || len(quotes) == 0 {
+		return nil, err
+	}
+	return &quotes[0], nil
 }
 
-// IsCategoryDisabled checks if a category is disabled for a guild
-func (d *DB) IsCategoryDisabled(guildID, category string) bool {
-	var count int
-	err := d.QueryRow(`SELECT COUNT(*) FROM guild_disabled_commands WHERE guild_id = ? AND category = ?`,
-		guildID, category).Scan(&count)
+// SearchQuotes returns the newest quotes containing the text
+func (d *DB) SearchQuotes(guildID, text string, limit int) ([]Quote, error) {
+	rows, err := d.Query(`SELECT `+quoteColumns+` FROM quotes
+		WHERE guild_id = ? AND content LIKE '%' || ? || '%' ORDER BY number DESC LIMIT ?`, guildID, text, limit)
 	if err != nil {
-		return false
+		return nil, err
 	}
-	return count > 0
+	return scanQuotes(rows)
 }
 
-// DisableCommand disables a specific command for a guild
-func (d *DB) DisableCommand(guildID, commandName string) error {
-	_, err := d.Exec(`INSERT OR IGNORE INTO guild_disabled_commands (guild_id, command_name) VALUES (?, ?)`,
-		guildID, commandName)
-	return err
+// CountQuotes returns how many quotes a guild has
+func (d *DB) CountQuotes(guildID string) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM quotes WHERE guild_id = ?`, guildID).Scan(&count)
+	return count, err
 }
 
-// EnableCommand enables a previously disabled command for a guild
-func (d *DB) EnableCommand(guildID, commandName string) error {
-	_, err := d.Exec(`DELETE FROM guild_disabled_commands WHERE guild_id = ? AND command_name = ?`,
-		guildID, commandName)
-	return err
+// DeleteQuote removes a quote and reports whether it existed
+func (d *DB) DeleteQuote(guildID string, number int) (bool, error) {
+	result, err := d.Exec(`DELETE FROM quotes WHERE guild_id = ? AND number = ?`, guildID, number)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
 }
 
-// DisableCategory disables an entire category for a guild
-func (d *DB) DisableCategory(guildID, category string) error {
-	_, err := d.Exec(`INSERT OR IGNORE INTO guild_disabled_commands (guild_id, category) VALUES (?, ?)`,
-		guildID, category)
-	return err
+func (d *DB) GetQuoteConfig(guildID string) (*QuoteConfig, error) {
+	cfg := QuoteConfig{GuildID: guildID, PostHour: 12}
+	var channelID sql.NullString
+	err := d.QueryRow(`SELECT channel_id, daily_enabled, post_hour, COALESCE(last_posted, '') FROM quote_config WHERE guild_id = ?`,
+		guildID).Scan(&channelID, &cfg.DailyEnabled, &cfg.PostHour, &cfg.LastPosted)
+	if err == sql.ErrNoRows {
+		return &cfg, nil
+	}
+	cfg.ChannelID = channelID.String
+	return &cfg, err
 }
 
-// EnableCategory enables a previously disabled category for a guild
-func (d *DB) EnableCategory(guildID, category string) error {
-	_, err := d.Exec(`DELETE FROM guild_disabled_commands WHERE guild_id = ? AND category = ?`,
-		guildID, category)
+func (d *DB) SetQuoteConfig(cfg *QuoteConfig) error {
+	_, err := d.Exec(`INSERT INTO quote_config (guild_id, channel_id, daily_enabled, post_hour)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(guild_id) DO UPDATE SET
+		channel_id = excluded.channel_id, daily_enabled = excluded.daily_enabled, post_hour = excluded.post_hour`,
+		cfg.GuildID, nullString(cfg.ChannelID), cfg.DailyEnabled, cfg.PostHour)
 	return err
 }
 
-// GetDisabledCommands returns all disabled command names for a guild
-func (d *DB) GetDisabledCommands(guildID string) ([]string, error) {
-	rows, err := d.Query(`SELECT command_name FROM guild_disabled_commands WHERE guild_id = ? AND command_name IS NOT NULL`,
-		guildID)
+// GetDueDailyQuotes returns daily quote settings that haven't posted on day and whose hour has come
+func (d *DB) GetDueDailyQuotes(day string, hour int) ([]QuoteConfig, error) {
+	rows, err := d.Query(`SELECT guild_id, channel_id, daily_enabled, post_hour, COALESCE(last_posted, '') FROM quote_config
+		WHERE daily_enabled = 1 AND channel_id IS NOT NULL AND channel_id != ''
+		AND post_hour <= ? AND COALESCE(last_posted, '') < ?`, hour, day)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var commands []string
+	var configs []QuoteConfig
 	for rows.Next() {
-		var cmd string
-		if err := rows.Scan(&cmd); err != nil {
+		var cfg QuoteConfig
+		if err := rows.Scan(&cfg.GuildID, &cfg.ChannelID, &cfg.DailyEnabled, &cfg.PostHour, &cfg.LastPosted); err != nil {
 			return nil, err
 		}
-		commands = append(commands, cmd)
+		configs = append(configs, cfg)
 	}
-	return commands, rows.Err()
+	return configs, rows.Err()
 }
 
-// GetDisabledCategories returns all disabled category names for a guild
-func (d *DB) GetDisabledCategories(guildID string) ([]string, error) {
-	rows, err := d.Query(`SELECT category FROM guild_disabled_commands WHERE guild_id = ? AND category IS NOT NULL`,
-		guildID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var categories []string
-	for rows.Next() {
-		var cat string
-		if err := rows.Scan(&cat); err != nil {
-			return nil, err
-		}
-		categories = append(categories, cat)
-	}
-	return categories, rows.Err()
+// MarkDailyQuotePosted records the day a guild's daily quote was posted
+func (d *DB) MarkDailyQuotePosted(guildID, day string) error {
+	_, err := d.Exec(`UPDATE quote_config SET last_posted = ? WHERE guild_id = ?`, day, guildID)
+	return err
 }
 
-// SetDisabledCommands replaces all disabled commands for a guild
-func (d *DB) SetDisabledCommands(guildID string, commands []string) error {
-	tx, err := d.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+// ===== Server Backups =====
 
-	// Delete all existing disabled commands (not categories)
-	_, err = tx.Exec(`DELETE FROM guild_disabled_commands WHERE guild_id = ? AND command_name IS NOT NULL`, guildID)
+// CreateServerBackup stores a server snapshot, filling in its ID
+func (d *DB) CreateServerBackup(b *ServerBackup) error {
+	result, err := d.Exec(`INSERT INTO server_backups (guild_id, name, created_by, role_count, channel_count, data)
+		VALUES (?, ?, ?, ?, ?, ?)`, b.GuildID, b.Name, b.CreatedBy, b.RoleCount, b.ChannelCount, b.Data)
 	if err != nil {
 		return err
 	}
-
-	// Insert new disabled commands
-	for _, cmd := range commands {
-		_, err = tx.Exec(`INSERT INTO guild_disabled_commands (guild_id, command_name) VALUES (?, ?)`, guildID, cmd)
-		if err != nil {
-			return err
-		}
-	}
-
-	return tx.Commit()
+	b.ID, err = result.LastInsertId()
+	b.CreatedAt = time.Now()
+	return err
 }
 
-// SetDisabledCategories replaces all disabled categories for a guild
-func (d *DB) SetDisabledCategories(guildID string, categories []string) error {
-	tx, err := d.Begin()
+// GetServerBackup returns a guild's backup including its snapshot, nil if there is none
+func (d *DB) GetServerBackup(guildID string, id int64) (*ServerBackup, error) {
+	var b ServerBackup
+	err := d.QueryRow(`SELECT id, guild_id, name, created_by, role_count, channel_count, data, created_at
+		FROM server_backups WHERE guild_id = ? AND id = ?`, guildID, id).Scan(
+		&b.ID, &b.GuildID, &b.Name, &b.CreatedBy, &b.RoleCount, &b.ChannelCount, &b.Data, &b.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer tx.Rollback()
+	return &b, nil
+}
 
-	// Delete all existing disabled categories (not commands)
-	_, err = tx.Exec(`DELETE FROM guild_disabled_commands WHERE guild_id = ? AND category IS NOT NULL`, guildID)
+// GetServerBackups lists a guild's backups, newest first, without their snapshots
+func (d *DB) GetServerBackups(guildID string) ([]ServerBackup, error) {
+	rows, err := d.Query(`SELECT id, guild_id, name, created_by, role_count, channel_count, created_at
+		FROM server_backups WHERE guild_id = ? ORDER BY id DESC`, guildID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Insert new disabled categories
-	for _, cat := range categories {
-		_, err = tx.Exec(`INSERT INTO guild_disabled_commands (guild_id, category) VALUES (?, ?)`, guildID, cat)
-		if err != nil {
-			return err
+	var backups []ServerBackup
+	for rows.Next() {
+		var b ServerBackup
+		if err := rows.Scan(&b.ID, &b.GuildID, &b.Name, &b.CreatedBy, &b.RoleCount, &b.ChannelCount, &b.CreatedAt); err != nil {
+			return nil, err
 		}
+		backups = append(backups, b)
 	}
+	return backups, rows.Err()
+}
 
-	return tx.Commit()
+func (d *DB) CountServerBackups(guildID string) int {
+	var count int
+	d.QueryRow(`SELECT COUNT(*) FROM server_backups WHERE guild_id = ?`, guildID).Scan(&count)
+	return count
+}
+
+// DeleteServerBackup removes a guild's backup, reporting whether it existed
+func (d *DB) DeleteServerBackup(guildID string, id int64) (bool, error) {
+	result, err := d.Exec(`DELETE FROM server_backups WHERE guild_id = ? AND id = ?`, guildID, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
 }
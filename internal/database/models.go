@@ -35,6 +35,13 @@ type CustomCommand struct {
 	Response  string
 	CreatedBy string
 	UseCount  int
+	Embed     string // Raw Discord embed JSON, empty when the command is plain text
+
+	// Restrictions, an empty list allows everyone/everywhere
+	AllowedRoles     []string
+	AllowedChannels  []string
+	CooldownSeconds  int  // Per member
+	DeleteInvocation bool // Delete the message that triggered the command
 }
 
 type CommandHistory struct {
@@ -47,6 +54,24 @@ type CommandHistory struct {
 	ExecutedAt time.Time
 }
 
+// CommandUsageStat is the usage of one command over a period, built from command_history
+type CommandUsageStat struct {
+	Command  string
+	Uses     int
+	Failures int
+	AvgMs    int64
+	P95Ms    int64
+	LastUsed time.Time
+}
+
+// ErrorRate is the share of uses that failed, from 0 to 1
+func (c CommandUsageStat) ErrorRate() float64 {
+	if c.Uses == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Uses)
+}
+
 type Warning struct {
 	ID          int64
 	GuildID     string
@@ -56,13 +81,76 @@ type Warning struct {
 	CreatedAt   time.Time
 }
 
+// UserNote is a staff note about a member; a member can have any number of them
+type UserNote struct {
+	ID        int64
+	GuildID   string
+	UserID    string
+	Note      string
+	CreatedBy string
+	CreatedAt time.Time
+	UpdatedBy string
+	UpdatedAt *time.Time
+}
+
+// Warning audit trail actions
+const (
+	WarningEdited  = "edit"
+	WarningDeleted = "delete"
+)
+
+// WarningEdit is one change made to a warning after it was issued
+type WarningEdit struct {
+	ID        int64
+	WarningID int64
+	GuildID   string
+	UserID    string
+	EditorID  string
+	Action    string
+	OldReason *string
+	NewReason *string
+	EditedAt  time.Time
+}
+
 type DeletedMessage struct {
+	ID          int64
+	GuildID     *string
+	ChannelID   string
+	UserID      string
+	Content     string
+	Attachments []DeletedAttachment
+	Stickers    []string // Sticker names
+	Embeds      []string // One line summary per embed
+	DeletedAt   time.Time
+}
+
+// Attachment metadata for a deleted message
+type DeletedAttachment struct {
+	Filename    string `json:"filename"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	ArchivePath string `json:"archive_path,omitempty"` // Local cached copy, if archived
+}
+
+type EditedMessage struct {
 	ID        int64
 	GuildID   *string
 	ChannelID string
+	MessageID string
 	UserID    string
-	Content   string
-	DeletedAt time.Time
+	Before    string
+	After     string
+	EditedAt  time.Time
+}
+
+type RemovedReaction struct {
+	GuildID   string
+	ChannelID string
+	MessageID string
+	UserID    string
+	Emoji     string // Ready to show: a unicode emoji or <:name:id>
+	RemovedAt time.Time
 }
 
 type ScheduledMessage struct {
@@ -71,13 +159,30 @@ type ScheduledMessage struct {
 	ChannelID    string
 	UserID       string
 	Message      string
+	Embed        string              // Embed JSON, empty for none
+	Attachments  []DeletedAttachment // Saved when scheduled and re-uploaded from ArchivePath
 	ScheduledFor time.Time
 }
 
 type AFKStatus struct {
-	UserID  string
-	Message *string
-	SetAt   time.Time
+	UserID       string
+	Message      *string
+	SetAt        time.Time
+	GuildID      string // Server the status applies in, empty for every server
+	NickGuildID  string // Server where the [AFK] nickname was applied, empty if it wasn't
+	OriginalNick string // Nickname to restore there on return
+}
+
+// AFKMention is a message that mentioned a member while they were AFK
+type AFKMention struct {
+	ID        int64
+	UserID    string
+	GuildID   string
+	ChannelID string
+	MessageID string
+	AuthorID  string
+	Content   string
+	CreatedAt time.Time
 }
 
 type Reminder struct {
@@ -95,13 +200,46 @@ type Tag struct {
 	Content   string
 	CreatedBy string
 	UseCount  int
+	CreatedAt time.Time
+	Embed     string // Raw Discord embed JSON, empty when the tag is plain text
 }
 
 type KeywordNotification struct {
-	ID      int64
-	UserID  string
-	GuildID *string
-	Keyword string
+	ID            int64
+	UserID        string
+	GuildID       *string // nil to match in every server
+	Keyword       string
+	MatchMode     string // contains, word or regex
+	CaseSensitive bool
+}
+
+// Keyword match modes
+const (
+	KeywordMatchContains = "contains"
+	KeywordMatchWord     = "word"
+	KeywordMatchRegex    = "regex"
+)
+
+// KeywordSettings is how a member wants their keyword alerts delivered
+type KeywordSettings struct {
+	UserID          string
+	QuietStart      int // Hour in the member's timezone, -1 when quiet hours are off
+	QuietEnd        int
+	DigestMinutes   int // Batch alerts into one DM this often, 0 sends each right away
+	IgnoredChannels []string
+}
+
+// KeywordDigestEntry is a keyword alert waiting to be sent in a digest
+type KeywordDigestEntry struct {
+	ID        int64
+	UserID    string
+	GuildID   string
+	ChannelID string
+	MessageID string
+	AuthorID  string
+	Keyword   string
+	Content   string
+	CreatedAt time.Time
 }
 
 // XP/Leveling
@@ -138,6 +276,63 @@ type AutoCleanChannel struct {
 	CreatedAt       time.Time
 }
 
+// What a stats channel counts
+const (
+	StatsChannelMembers = "members"
+	StatsChannelHumans  = "humans"
+	StatsChannelBots    = "bots"
+	StatsChannelGoal    = "goal"
+)
+
+// Stats Channel, a voice channel whose name shows a live count
+type StatsChannel struct {
+	ChannelID string
+	GuildID   string
+	Kind      string // StatsChannelMembers, StatsChannelHumans, StatsChannelBots or StatsChannelGoal
+	Template  string // Channel name with {count} etc., empty for the kind's default
+	Goal      int    // Member goal, goal channels only
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// Which messages get a thread in an auto-thread channel
+const (
+	AutoThreadAll         = "all"
+	AutoThreadAttachments = "attachments"
+)
+
+// Auto-Thread Channel
+type AutoThreadChannel struct {
+	GuildID        string
+	ChannelID      string
+	Mode           string // AutoThreadAll or AutoThreadAttachments
+	NameTemplate   string // Empty for the default name
+	ArchiveMinutes int    // 60, 1440, 4320 or 10080
+	CreatedBy      string
+	CreatedAt      time.Time
+}
+
+// GitHub webhook events that can be relayed
+const (
+	GitHubEventPush        = "push"
+	GitHubEventRelease     = "release"
+	GitHubEventIssues      = "issues"
+	GitHubEventPullRequest = "pull_request"
+)
+
+var GitHubEvents = []string{GitHubEventPush, GitHubEventRelease, GitHubEventIssues, GitHubEventPullRequest}
+
+// GitHubRelay posts a repository's webhook deliveries to a channel
+type GitHubRelay struct {
+	GuildID   string
+	Repo      string // owner/name, lowercased
+	ChannelID string
+	Events    string // Comma-separated GitHubEvents, empty for all
+	Secret    string // Webhook secret used to verify deliveries
+	CreatedBy string
+	CreatedAt time.Time
+}
+
 // Logging Configuration
 type LoggingConfig struct {
 	GuildID           string
@@ -151,6 +346,47 @@ type LoggingConfig struct {
 	AvatarChange      bool
 	PresenceChange    bool
 	PresenceBatchMins int
+	RoleChange        bool
+	Moderation        bool // bans, unbans, kicks
+	// Per-category channels; nil falls back to LogChannelID
+	MessageLogChannelID *string
+	MemberLogChannelID  *string
+	VoiceLogChannelID   *string
+	ModLogChannelID     *string
+	AutomodLogChannelID *string
+	ArchiveAttachments  bool // Cache attachments so deleted images can be re-uploaded
+	VoiceMove           bool // Switching between voice channels
+	VoiceMute           bool // Server mute/deafen
+	VoiceStream         bool // Go Live and camera start/stop
+	VoiceSession        bool // Session duration summary on leave
+	UseWebhooks         bool // Deliver logs through bot-managed webhooks
+}
+
+// Log Webhook - webhook created by the bot in a log channel
+type LogWebhook struct {
+	ChannelID    string
+	GuildID      string
+	WebhookID    string
+	WebhookToken string
+}
+
+// Log Identity - webhook username/avatar for a log category
+type LogIdentity struct {
+	GuildID   string
+	Category  string // message, member, voice, mod, automod, or default
+	Username  string
+	AvatarURL string
+}
+
+// Log Search filter; zero values are ignored
+type LogSearchQuery struct {
+	GuildID string
+	UserID  string // Author for deleted messages, moderator for mod actions
+	Action  string // Mod action type
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+	Offset  int
 }
 
 // Disabled Log Channels
@@ -166,6 +402,12 @@ type VoiceXPConfig struct {
 	XPRate       int
 	IntervalMins int
 	IgnoreAFK    bool
+
+	// Anti-abuse rules
+	IgnoreAlone      bool     // Skip members with no other humans in their channel
+	IgnoreDeafened   bool     // Skip self or server deafened members
+	ExcludedChannels []string // Voice channels that never earn XP
+	DailyCap         int      // Max voice XP per member per day, 0 for no cap
 }
 
 // Level Ranks
@@ -177,6 +419,13 @@ type LevelRank struct {
 	CreatedAt time.Time
 }
 
+// Level rank reward behavior
+type LevelRankConfig struct {
+	GuildID      string
+	Stack        bool // Keep every earned rank role; otherwise only the highest
+	RemoveOnLoss bool // Take rank roles away when a member drops below their level
+}
+
 // DM Forwarding Configuration
 type DMConfig struct {
 	GuildID   string
@@ -191,9 +440,343 @@ type BotBan struct {
 	BanType   string // user, server
 	Reason    string
 	BannedBy  string
+	Category  string // raid, scam, spam, other
+	CreatedAt time.Time
+}
+
+// Global Blocklist opt-in
+type BlocklistConfig struct {
+	GuildID      string
+	Enabled      bool
+	Action       string // log, kick, ban
+	LogChannelID string // Channel for staff notifications
+	AlertRoleID  string // Role to ping on a blocklist hit
+}
+
+// Reaction Role Message - a message with emoji role bindings
+type ReactionRoleMessage struct {
+	MessageID string
+	GuildID   string
+	ChannelID string
+	Mode      string // normal, unique, limit, verify, drop
+	MaxRoles  int    // Used by limit mode
+}
+
+// Reaction Role - an emoji on a message bound to a role
+type ReactionRole struct {
+	ID        int64
+	GuildID   string
+	MessageID string
+	Emoji     string // Unicode emoji or name:id for custom emoji
+	RoleID    string
+}
+
+// Role Panel - a message with role buttons or a role select menu
+type RolePanel struct {
+	ID             int64
+	GuildID        string
+	ChannelID      string // Empty until posted
+	MessageID      string
+	Title          string
+	Description    string
+	Style          string // buttons, select
+	RequiredRoleID string // Members must hold this role to use the panel
+	MaxRoles       int    // Roles from the panel a member may hold; 0 = no limit, 1 = pick one
+	TempDuration   int    // Seconds before granted roles are removed; 0 = permanent
+	CreatedBy      string
+	CreatedAt      time.Time
+	Roles          []RolePanelRole
+}
+
+// Role Panel Role - a role offered on a panel
+type RolePanelRole struct {
+	ID          int64
+	PanelID     int64
+	RoleID      string
+	Label       string // Defaults to the role name
+	Emoji       string // Unicode emoji or name:id for custom emoji
+	Description string // Shown under the option in select menus
+}
+
+// Sticky Roles Config
+type StickyRolesConfig struct {
+	GuildID         string
+	Enabled         bool
+	RestoreNickname bool
+	ExcludedRoles   []string // Never restored (e.g. punishment or staff roles)
+}
+
+// Member Snapshot - roles and nickname saved for sticky roles
+type MemberSnapshot struct {
+	GuildID  string
+	UserID   string
+	Roles    []string
+	Nickname string
+	LeftAt   time.Time
+}
+
+// Suggestion Config
+type SuggestionConfig struct {
+	GuildID   string
+	ChannelID string
+	Enabled   bool
+	DMAuthor  bool // DM the author when staff update the status
+}
+
+// Suggestion
+type Suggestion struct {
+	ID          int64
+	GuildID     string
+	Number      int // Per-guild suggestion number
+	UserID      string
+	Content     string
+	ChannelID   string
+	MessageID   string
+	Status      string // pending, approved, denied, implemented, considered
+	StaffID     string
+	StaffReason string
+	Upvotes     int
+	Downvotes   int
+	CreatedAt   time.Time
+}
+
+// Birthday Config
+type BirthdayConfig struct {
+	GuildID   string
+	ChannelID string
+	RoleID    string // Granted for 24 hours on the member's birthday
+	Message   string // Announcement template; supports {user}, {username} and {age}
+	Enabled   bool
+}
+
+// Birthday
+type Birthday struct {
+	GuildID       string
+	UserID        string
+	Month         int
+	Day           int
+	Year          int    // 0 when the member didn't give a birth year
+	LastAnnounced int    // Year of the last announcement
+	Timezone      string // From user_timezones; empty means UTC
+}
+
+// Poll
+type Poll struct {
+	ID        int64
+	GuildID   string
+	ChannelID string
+	MessageID string
+	UserID    string
+	Question  string
+	Options   []string
+	Anonymous bool // Hide who voted for what
+	Closed    bool
+	EndsAt    int64 // Auto-close time in ms, 0 for none
 	CreatedAt time.Time
 }
 
+// Guild Event Config
+type GuildEventConfig struct {
+	GuildID         string
+	ChannelID       string // Announcement and reminder channel
+	PingRoleID      string
+	ReminderMinutes int // Minutes before start to post a reminder, 0 to disable
+	Enabled         bool
+}
+
+// Guild Event Announcement
+type GuildEventAnnouncement struct {
+	EventID   string
+	GuildID   string
+	ChannelID string
+	MessageID string
+}
+
+// Game Channel (counting or word chain)
+type GameChannel struct {
+	ChannelID        string
+	GuildID          string
+	GameType         string // counting, wordchain
+	Count            int    // Current number, or current chain length for word chain
+	LastUserID       string
+	LastWord         string
+	HighScore        int
+	HighScoreAt      time.Time
+	Strict           bool // Delete messages that aren't moves
+	AllowConsecutive bool // Allow the same member to play twice in a row
+}
+
+// Economy Config
+type EconomyConfig struct {
+	GuildID          string
+	Enabled          bool
+	CurrencyName     string
+	CurrencySymbol   string
+	DailyAmount      int64
+	WorkMin          int64
+	WorkMax          int64
+	WorkCooldownMins int
+	ChatAmount       int64 // Paid per message, at most once per ChatCooldownSecs
+	ChatCooldownSecs int
+}
+
+// Economy Account
+type EconomyAccount struct {
+	GuildID     string
+	UserID      string
+	Balance     int64
+	DailyStreak int
+	LastDaily   int64 // Last claim times in ms
+	LastWork    int64
+	LastChat    int64
+}
+
+// Shop Item (a purchasable role)
+type ShopItem struct {
+	ID           int64
+	GuildID      string
+	RoleID       string
+	Price        int64
+	Stock        int // -1 for unlimited
+	DurationSecs int // How long the role lasts, 0 for permanent
+	Description  string
+}
+
+// Shop Purchase
+type ShopPurchase struct {
+	ID        int64
+	GuildID   string
+	UserID    string
+	ItemID    int64
+	RoleID    string
+	Price     int64
+	ExpiresAt int64 // Role expiry in ms, 0 for permanent
+}
+
+// Achievement Config
+type AchievementConfig struct {
+	GuildID   string
+	Enabled   bool
+	Announce  bool   // Post unlocks publicly
+	ChannelID string // Announcement channel; empty uses the channel the unlock happened in
+}
+
+// User Achievement
+type UserAchievement struct {
+	AchievementID string // Built-in ID, or "custom:<id>"
+	EarnedAt      time.Time
+}
+
+// Custom Achievement
+type CustomAchievement struct {
+	ID          int64
+	GuildID     string
+	Name        string
+	Description string
+	Emoji       string
+	Stat        string // messages, commands, voice_minutes, songs, level
+	Threshold   int64
+	RoleID      string // Optional role reward
+}
+
+// XP multiplier for a role or channel
+type XPMultiplier struct {
+	GuildID    string
+	TargetType string // "role" or "channel"
+	TargetID   string
+	Multiplier float64
+}
+
+// Channel or role that never earns XP
+type XPExclusion struct {
+	GuildID    string
+	TargetType string // "role" or "channel"
+	TargetID   string
+}
+
+// Server-wide XP boost event
+type XPBoost struct {
+	ID         int64
+	GuildID    string
+	Name       string
+	Multiplier float64
+	StartsAt   int64 // Unix ms
+	EndsAt     int64 // Unix ms
+	CreatedBy  string
+}
+
+// Level-up announcement settings
+type LevelUpConfig struct {
+	GuildID     string
+	Enabled     bool
+	Destination string // "current", "dm" or "channel"
+	ChannelID   string // Used when Destination is "channel"
+	Message     string // Template; supports {user}, {username}, {level} and {server}
+	UseEmbed    bool
+	EveryLevels int // Only announce levels divisible by this
+}
+
+// XP season settings; standings are XP gained since SeasonStart
+type XPSeasonConfig struct {
+	GuildID      string
+	Enabled      bool
+	Period       string // "weekly" or "monthly"
+	ChannelID    string // Winner announcements
+	WinnerRoleID string // Moved to each season's winner
+	TopCount     int    // Places archived and announced
+	SeasonStart  int64  // Unix ms, UTC midnight
+	LastWinnerID string
+}
+
+// Archived season standing
+type XPSeasonEntry struct {
+	GuildID     string
+	SeasonStart int64 // Unix ms
+	SeasonEnd   int64 // Unix ms
+	Position    int
+	UserID      string
+	XP          int64
+}
+
+// Rank card colors as 0xRRGGBB, nil means the default
+type RankCardSettings struct {
+	UserID     string
+	Background *int
+	Accent     *int
+}
+
+// Welcome card look for a guild, colors as 0xRRGGBB with nil meaning the default
+type WelcomeCardSettings struct {
+	GuildID       string
+	Enabled       bool
+	Background    *int
+	Accent        *int
+	BackgroundURL string // Image drawn behind the card instead of the background color
+	Title         string // Heading above the username, empty for "WELCOME"
+}
+
+// Kinds of greeting message
+const (
+	GreetingWelcome   = "welcome"
+	GreetingLeave     = "leave"
+	GreetingLeaveKick = "leave_kick" // Sent instead of the leave message when the member was kicked
+	GreetingLeaveBan  = "leave_ban"  // Sent instead of the leave message when the member was banned
+	GreetingJoinDM    = "joindm"
+)
+
+// GreetingKinds lists every kind of greeting message
+var GreetingKinds = []string{GreetingWelcome, GreetingLeave, GreetingLeaveKick, GreetingLeaveBan, GreetingJoinDM}
+
+// Welcome, leave or join DM message. The welcome channel and text and the join DM
+// title and text stay in GuildSettings, so for those only the embed is used here.
+type GreetingMessage struct {
+	GuildID   string
+	Kind      string
+	ChannelID string // Leave messages only, the kick and ban variants use the leave channel
+	Content   string // Leave messages and their variants only
+	Embed     string // Embed JSON, sent along with the text
+}
+
 // Moderation Actions
 type ModAction struct {
 	ID          int64
@@ -204,8 +787,17 @@ type ModAction struct {
 	Reason      *string
 	Timestamp   int64
 	CreatedAt   time.Time
+	DMStatus    string // ModDMSent or ModDMFailed, empty when no DM was attempted
+	UndoneBy    string // Moderator who reversed it with /undo, if anyone
+	WarningID   int64  // Warning created by a warn case, 0 otherwise
 }
 
+// Whether the target of a mod action was sent a DM about it
+const (
+	ModDMSent   = "sent"
+	ModDMFailed = "failed"
+)
+
 // Mod Stats
 type ModStats struct {
 	TotalActions int
@@ -214,9 +806,17 @@ type ModStats struct {
 }
 
 type ModeratorCount struct {
+	ModeratorID   string
+	Count         int
+	Actions       map[string]int
+	PreviousCount int // Actions in the same length of time before the range, for trends
+}
+
+// ModActivityDay is how many actions one moderator took on one UTC day (YYYY-MM-DD)
+type ModActivityDay struct {
+	Day         string
 	ModeratorID string
 	Count       int
-	Actions     map[string]int
 }
 
 // Mention Responses
@@ -228,8 +828,20 @@ type MentionResponse struct {
 	ImageURL    *string
 	CreatedBy   string
 	CreatedAt   time.Time
+	MatchMode   string // contains, exact, startswith, wildcard or regex
+	// Seconds before the trigger can fire again in the same channel
+	CooldownSeconds int
 }
 
+// Mention response match modes
+const (
+	MentionMatchContains   = "contains"
+	MentionMatchExact      = "exact"
+	MentionMatchStartsWith = "startswith"
+	MentionMatchWildcard   = "wildcard"
+	MentionMatchRegex      = "regex"
+)
+
 // Spam Filter Config
 type SpamFilterConfig struct {
 	GuildID     string
@@ -242,9 +854,74 @@ type SpamFilterConfig struct {
 
 // Ticket System Config
 type TicketConfig struct {
+	GuildID       string
+	ChannelID     string // parent channel for threads, or the log channel in channel mode
+	Enabled       bool
+	Mode          string // thread, channel
+	CategoryID    string // category new ticket channels are created under
+	SupportRoleID string
+
+	TranscriptChannelID string // where transcripts of closed tickets are posted
+
+	InactiveHours      int // close tickets after this long without messages; 0 = never
+	InactiveGraceHours int // time between the inactivity warning and the close
+}
+
+// Ticket modes and statuses
+const (
+	TicketModeThread  = "thread"
+	TicketModeChannel = "channel"
+
+	TicketStatusOpen   = "open"
+	TicketStatusClosed = "closed"
+)
+
+// Ticket is a single support ticket and the thread or channel it lives in
+type Ticket struct {
+	ID        int64
 	GuildID   string
+	Number    int
 	ChannelID string
-	Enabled   bool
+	OpenerID  string
+	Subject   string
+	Status    string
+	ClaimedBy string
+	ClosedBy  string
+	CreatedAt time.Time
+	ClosedAt  *time.Time
+
+	SupportRoleID string // role pulled in when the ticket was opened
+	PanelID       int64  // panel the ticket was opened from; 0 = /ticket open
+
+	TranscriptChannelID string // where the transcript was posted; empty if it was only DMed
+	TranscriptMessageID string
+
+	InactivityWarnedAt  *time.Time // set while an inactivity close is pending
+	InactivityWarningID string
+}
+
+// TicketStaffStats summarises the tickets one staff member has claimed
+type TicketStaffStats struct {
+	UserID            string
+	Claimed           int
+	Resolved          int   // claimed tickets that have since been closed
+	AvgResolveSeconds int64 // average time from open to close for resolved tickets
+}
+
+// TicketPanel is a posted "Open Ticket" button with its own routing
+type TicketPanel struct {
+	ID            int64
+	GuildID       string
+	ChannelID     string
+	MessageID     string
+	Title         string
+	Description   string
+	ButtonLabel   string
+	Mode          string // thread, channel
+	CategoryID    string
+	SupportRoleID string
+	CreatedBy     string
+	CreatedAt     time.Time
 }
 
 // Anti-Raid Config
@@ -317,6 +994,45 @@ type UserActivity struct {
 	MessageCount int
 }
 
+// ActivityHour is messages sent in a channel during one hour (UTC)
+type ActivityHour struct {
+	GuildID   string
+	ChannelID string
+	Day       string // YYYY-MM-DD
+	Hour      int
+	Messages  int
+}
+
+// ActivityDay is a guild's totals for one day (UTC)
+type ActivityDay struct {
+	GuildID  string
+	Day      string // YYYY-MM-DD
+	Messages int
+	Joins    int
+	Leaves   int
+}
+
+// ActivityMemberDay is messages sent by a member during one day (UTC)
+type ActivityMemberDay struct {
+	GuildID  string
+	UserID   string
+	Day      string // YYYY-MM-DD
+	Messages int
+}
+
+// ActivityBatch is activity counted in memory since the last flush
+type ActivityBatch struct {
+	Hours   []ActivityHour
+	Days    []ActivityDay
+	Members []ActivityMemberDay
+}
+
+// ActivityCount is a channel or member with their message count
+type ActivityCount struct {
+	ID       string
+	Messages int
+}
+
 // Music Settings - per-guild music configuration
 type MusicSettings struct {
 	GuildID     string
@@ -324,6 +1040,20 @@ type MusicSettings struct {
 	ModRoleID   *string
 	Volume      int
 	MusicFolder *string
+
+	PlaylistLimit      int      // Max playlists per member
+	PlaylistTrackLimit int      // Max tracks per playlist
+	StayConnected      bool     // 24/7 mode: stay in voice when the queue ends
+	Autoplay           bool     // Queue a related track when the queue runs out
+	AudioFilters       string   // Active /filter spec, e.g. "bassboost,speed=1.25"
+	TextChannels       []string // Text channels music commands are limited to, empty for anywhere
+	Normalize          bool     // Even out loudness between tracks with ffmpeg loudnorm
+	MaxQueueLength     int      // Max tracks waiting in the queue, 0 for no limit
+	MaxTrackDuration   int      // Longest track in seconds members can queue, 0 for no limit
+	MaxUserTracks      int      // Max queued tracks per member, 0 for no limit
+	SponsorBlock       bool     // Skip segments marked on SponsorBlock in YouTube tracks
+
+	SponsorBlockCategories []string // Segment categories to skip, empty for the defaults
 }
 
 // Music Queue Item
@@ -351,6 +1081,69 @@ type MusicHistory struct {
 	PlayedAt time.Time
 }
 
+// CachedLyrics - lyrics fetched for a search query
+type CachedLyrics struct {
+	ID       int64
+	Query    string
+	Title    string
+	Artist   string
+	Lyrics   string
+	CachedAt time.Time
+}
+
+// Playlist - a member's saved list of tracks
+type Playlist struct {
+	ID         int64
+	GuildID    string
+	OwnerID    string
+	Name       string
+	Public     bool
+	TrackCount int
+	CreatedAt  time.Time
+}
+
+// PlaylistTrack - a single entry in a saved playlist
+type PlaylistTrack struct {
+	ID         int64
+	PlaylistID int64
+	Title      string
+	URL        string // Page URL or local path, resolved again at play time
+	Duration   int
+	IsLocal    bool
+	Position   int
+}
+
+// LocalTrack - an indexed file in a guild's music folder
+type LocalTrack struct {
+	ID          int64
+	GuildID     string
+	Path        string // Relative to the music folder
+	Title       string
+	Artist      string
+	Album       string
+	TrackNumber int
+	Duration    int
+	ModTime     int64 // File modification time when indexed, unix seconds
+}
+
+// LibraryGroup - an artist or album in a guild's music library
+type LibraryGroup struct {
+	Name     string
+	Artist   string // Album artist, empty when grouping by artist
+	Tracks   int
+	Duration int
+}
+
+// RadioStation - a guild's named preset for an internet radio stream
+type RadioStation struct {
+	ID        int64
+	GuildID   string
+	Name      string
+	URL       string
+	AddedBy   string
+	CreatedAt time.Time
+}
+
 // Disabled Commands/Categories - for per-guild command enable/disable
 type DisabledCommand struct {
 	ID          int64
@@ -359,3 +1152,230 @@ type DisabledCommand struct {
 	Category    *string // nil for individual command disable
 	CreatedAt   time.Time
 }
+
+// Invite Join - which invite a member joined through
+type InviteJoin struct {
+	ID         int64
+	GuildID    string
+	UserID     string
+	InviterID  string
+	InviteCode string // Vanity code when joined through the vanity URL
+	JoinedAt   time.Time
+}
+
+// Inviter Count - invite leaderboard entry
+type InviterCount struct {
+	InviterID string
+	Total     int // All attributed joins, including rejoins
+	Unique    int // Distinct members
+}
+
+// AI providers /ask can use
+const (
+	AIProviderOpenAI    = "openai"
+	AIProviderAnthropic = "anthropic"
+	AIProviderOllama    = "ollama"
+)
+
+// AI Settings - a guild's choice of AI provider and how /ask behaves.
+// Empty provider, model and key fall back to the config.
+type AISettings struct {
+	GuildID      string
+	Provider     string // AIProviderOpenAI, AIProviderAnthropic or AIProviderOllama
+	Model        string
+	APIKey       string // The guild's own key, stored encrypted
+	UpdatedBy    string
+	SystemPrompt string // Empty for the built-in prompt
+	MemorySize   int    // Past exchanges sent as context, 0 turns memory off
+	MemoryHours  int    // Exchanges older than this are forgotten
+
+	// /summarize: moderators can always use it, SummaryRoleID opens it to a role
+	// (the guild ID for everyone). Limits are per UTC day, 0 for unlimited.
+	SummaryRoleID      string
+	SummaryDailyLimit  int
+	SummaryTokenBudget int
+
+	// Estimated tokens per UTC day across every AI command, 0 for unlimited
+	DailyTokenBudget     int // The whole guild
+	UserDailyTokenBudget int // Each member
+}
+
+// AI features whose usage is tracked
+const (
+	AIFeatureAsk       = "ask"
+	AIFeatureSummarize = "summarize"
+	AIFeatureTTS       = "tts"
+)
+
+// AI Usage - AI requests and tokens used by a guild or member
+type AIUsage struct {
+	GuildID  string
+	UserID   string // Set for per-member usage
+	Day      string // YYYY-MM-DD, UTC; the first day for totals over a range
+	Feature  string // Empty for totals across features
+	Requests int
+	Tokens   int // Estimated from prompt and reply length
+}
+
+// AI Exchange - a remembered /ask question and answer
+type AIExchange struct {
+	ID        int64
+	GuildID   string
+	ChannelID string
+	UserID    string
+	UserName  string
+	Prompt    string
+	Reply     string
+	CreatedAt time.Time
+}
+
+// AI moderation score categories, each covering its subcategories (e.g. "hate/threatening")
+var AIModerationCategories = []string{"harassment", "hate", "self-harm", "sexual", "violence", "illicit"}
+
+// AI Moderation Config - scores messages with a moderation model. Each threshold
+// is a score from 0 to 1 that triggers its action, 0 turns the action off.
+type AIModerationConfig struct {
+	GuildID         string
+	Enabled         bool
+	FlagThreshold   float64 // Report the message to staff
+	DeleteThreshold float64 // Delete the message
+	WarnThreshold   float64 // Delete the message and warn its author
+	FlagChannelID   string  // Empty for the automod log
+	Categories      string  // Comma-separated AIModerationCategories, empty for all
+}
+
+// AI Moderation Log - a message AI moderation acted on
+type AIModerationLog struct {
+	ID        int64
+	GuildID   string
+	ChannelID string
+	MessageID string
+	UserID    string
+	Content   string // Truncated message text, stored encrypted
+	Category  string // Highest scoring category
+	Score     float64
+	Action    string // "flag", "delete" or "warn"
+	CreatedAt time.Time
+}
+
+// Text-to-speech engines
+const (
+	TTSEngineEspeak = "espeak" // Local espeak-ng install
+	TTSEngineOpenAI = "openai" // OpenAI speech API, uses the guild's or bot's OpenAI key
+)
+
+// TTS Settings - whether /tts works in a guild and how it sounds
+type TTSSettings struct {
+	GuildID string
+	Enabled bool
+	Engine  string // TTSEngineEspeak or TTSEngineOpenAI
+	Voice   string // Engine-specific voice name, empty for the engine's default
+}
+
+// Moderation DMs - tell members when they're warned, timed out, kicked or banned
+type ModDMConfig struct {
+	GuildID   string
+	Enabled   bool
+	AppealURL string // Shown in the DM when set
+}
+
+// Auto Slowmode - a channel whose slowmode rises and falls with its message rate
+type AutoSlowmodeChannel struct {
+	GuildID     string
+	ChannelID   string
+	Threshold   int        // Messages per minute that start raising slowmode
+	MaxSeconds  int        // Highest slowmode it will set
+	BaseSeconds int        // Slowmode to return to once things calm down
+	PausedUntil *time.Time // Set while a moderator has taken manual control
+	CreatedBy   string
+	CreatedAt   time.Time
+}
+
+// Paused reports whether automatic changes are on hold
+func (c *AutoSlowmodeChannel) Paused() bool {
+	return c.PausedUntil != nil && time.Now().Before(*c.PausedUntil)
+}
+
+// Reason Presets - canned moderation reasons, e.g. one per server rule
+type ReasonPreset struct {
+	ID        int64
+	GuildID   string
+	Name      string // Short label like "r1" or "spam", unique per guild ignoring case
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// SQL Console - output of a statement run from the owner SQL console
+type SQLResult struct {
+	Columns      []string
+	Rows         [][]string
+	Truncated    bool  // More rows matched than were returned
+	RowsAffected int64 // For statements that write
+}
+
+// Reports - members flagging a message or user to staff
+type ReportConfig struct {
+	GuildID        string
+	ChannelID      string // Staff channel reports are posted to
+	Enabled        bool
+	AllowAnonymous bool // Reporters may hide their name from staff
+}
+
+// Report statuses
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+type Report struct {
+	ID             int64
+	GuildID        string
+	TargetID       string // Member being reported
+	ChannelID      string // Channel of the reported message, empty for a user report
+	MessageID      string
+	ReporterID     string // First reporter, kept even when anonymous
+	Reason         string
+	Anonymous      bool
+	Status         string // ReportOpen, ReportResolved or ReportDismissed
+	ReportCount    int    // How many members reported it
+	StaffChannelID string
+	StaffMessageID string
+	HandledBy      string
+	HandledAt      *time.Time
+	CreatedAt      time.Time
+}
+
+// Quote board - memorable messages saved per guild
+type Quote struct {
+	ID        int64
+	GuildID   string
+	Number    int    // Per-guild quote number
+	UserID    string // Who said it
+	Content   string
+	ChannelID string // Where it was said; empty when the message is unknown
+	MessageID string
+	AddedBy   string
+	CreatedAt time.Time
+}
+
+type QuoteConfig struct {
+	GuildID      string
+	ChannelID    string // Channel the daily quote is posted in
+	DailyEnabled bool
+	PostHour     int    // UTC hour the daily quote goes out
+	LastPosted   string // Day (YYYY-MM-DD) of the last daily quote
+}
+
+// Server backup - a snapshot of a guild's roles, channels and settings
+type ServerBackup struct {
+	ID           int64
+	GuildID      string
+	Name         string
+	CreatedBy    string
+	RoleCount    int
+	ChannelCount int
+	Data         string // JSON snapshot, only loaded by GetServerBackup
+	CreatedAt    time.Time
+}
@@ -0,0 +1,247 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package rankcard renders XP rank cards and welcome cards as PNG images
+package rankcard
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Card dimensions in pixels
+const (
+	Width  = 800
+	Height = 200
+)
+
+// Default card colors, used when a user hasn't customized their card
+var (
+	DefaultBackground = color.RGBA{0x23, 0x27, 0x2A, 0xFF}
+	DefaultAccent     = color.RGBA{0x58, 0x65, 0xF2, 0xFF}
+)
+
+// Card holds everything shown on a rank card
+type Card struct {
+	Username   string
+	Avatar     image.Image // Optional, a placeholder circle is drawn when nil
+	Rank       int
+	Level      int
+	LevelXP    int64 // XP earned towards the next level
+	LevelNeed  int64 // XP needed to go from this level to the next
+	TotalXP    int64
+	Background color.RGBA
+	Accent     color.RGBA
+}
+
+// Render draws the card and writes it to w as a PNG
+func Render(w io.Writer, c *Card) error {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	fillRect(img, img.Bounds(), c.Background)
+
+	// Inner panel, slightly lighter than the background
+	panel := image.Rect(12, 12, Width-12, Height-12)
+	fillRoundRect(img, panel, 16, blend(c.Background, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, 0.06))
+
+	// Avatar with an accent ring
+	const avatarSize = 140
+	ax, ay := 30, (Height-avatarSize)/2
+	fillCircle(img, ax+avatarSize/2, ay+avatarSize/2, avatarSize/2+5, c.Accent)
+	if c.Avatar != nil {
+		drawCircleImage(img, c.Avatar, ax, ay, avatarSize)
+	} else {
+		fillCircle(img, ax+avatarSize/2, ay+avatarSize/2, avatarSize/2, blend(c.Background, c.Accent, 0.3))
+	}
+
+	text := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	muted := color.RGBA{0xB9, 0xBB, 0xBE, 0xFF}
+	left, right := 200, Width-36
+
+	// Rank and level, right aligned on the top line
+	level := fmt.Sprintf("LEVEL %d", c.Level)
+	x := right - textWidth(level, 4)
+	drawText(img, x, 36, 4, level, c.Accent)
+	rank := fmt.Sprintf("RANK #%d", c.Rank)
+	x -= textWidth(rank, 3) + 24
+	drawText(img, x, 40, 3, rank, muted)
+
+	// Username fills whatever space is left on the second line
+	name := fitText(c.Username, 4, right-left)
+	drawText(img, left, 88, 4, name, text)
+
+	// XP counter above the bar
+	progress := fmt.Sprintf("%s / %s XP", compact(c.LevelXP), compact(c.LevelNeed))
+	drawText(img, right-textWidth(progress, 2), 126, 2, progress, muted)
+	drawText(img, left, 126, 2, fmt.Sprintf("TOTAL %s XP", compact(c.TotalXP)), muted)
+
+	// Progress bar
+	bar := image.Rect(left, 148, right, 172)
+	fillRoundRect(img, bar, bar.Dy()/2, blend(c.Background, color.RGBA{0, 0, 0, 0xFF}, 0.35))
+	if c.LevelNeed > 0 && c.LevelXP > 0 {
+		filled := int(int64(bar.Dx()) * min(c.LevelXP, c.LevelNeed) / c.LevelNeed)
+		filled = max(filled, bar.Dy()) // Keep the rounded ends intact for tiny amounts
+		fillRoundRect(img, image.Rect(bar.Min.X, bar.Min.Y, bar.Min.X+filled, bar.Max.Y), bar.Dy()/2, c.Accent)
+	}
+
+	return png.Encode(w, img)
+}
+
+// ParseHexColor parses colors like "#5865F2" or "5865F2"
+func ParseHexColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	var r, g, b uint8
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	if _, err := fmt.Sscanf(s, "%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{r, g, b, 0xFF}, nil
+}
+
+// FromInt converts a 0xRRGGBB integer to a color
+func FromInt(c int) color.RGBA {
+	return color.RGBA{uint8(c >> 16), uint8(c >> 8), uint8(c), 0xFF}
+}
+
+// ToInt converts a color to a 0xRRGGBB integer
+func ToInt(c color.RGBA) int {
+	return int(c.R)<<16 | int(c.G)<<8 | int(c.B)
+}
+
+// compact formats large numbers as 1.2K, 3.4M
+func compact(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 10_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// fillRoundRect fills r with corners of the given radius
+func fillRoundRect(img *image.RGBA, r image.Rectangle, radius int, c color.RGBA) {
+	radius = min(radius, r.Dx()/2, r.Dy()/2)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			// Distance from the nearest corner circle center, if the pixel is in a corner
+			cx := min(max(x, r.Min.X+radius), r.Max.X-radius-1)
+			cy := min(max(y, r.Min.Y+radius), r.Max.Y-radius-1)
+			dx, dy := x-cx, y-cy
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+func fillCircle(img *image.RGBA, cx, cy, radius int, c color.RGBA) {
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			dx, dy := x-cx, y-cy
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// drawCircleImage scales src to size x size with nearest neighbour sampling and draws it clipped to a circle
+func drawCircleImage(img *image.RGBA, src image.Image, x0, y0, size int) {
+	b := src.Bounds()
+	radius := size / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := x-radius, y-radius
+			if dx*dx+dy*dy > radius*radius {
+				continue
+			}
+			sx := b.Min.X + x*b.Dx()/size
+			sy := b.Min.Y + y*b.Dy()/size
+			r, g, bl, a := src.At(sx, sy).RGBA()
+			if a == 0 {
+				continue
+			}
+			// Composite over what's already there so transparent avatars keep the ring color
+			under := img.RGBAAt(x0+x, y0+y)
+			alpha := float64(a) / 0xFFFF
+			img.SetRGBA(x0+x, y0+y, color.RGBA{
+				uint8(float64(r>>8) + float64(under.R)*(1-alpha)),
+				uint8(float64(g>>8) + float64(under.G)*(1-alpha)),
+				uint8(float64(bl>>8) + float64(under.B)*(1-alpha)),
+				0xFF,
+			})
+		}
+	}
+}
+
+// blend mixes amount of b into a
+func blend(a, b color.RGBA, amount float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x)*(1-amount) + float64(y)*amount)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xFF}
+}
+
+// drawText draws text with its top left corner at x, y, each font pixel scaled to scale x scale
+func drawText(img *image.RGBA, x, y, scale int, text string, c color.RGBA) {
+	for _, r := range text {
+		glyph, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		for row, line := range glyph {
+			for col, px := range line {
+				if px == '#' {
+					fillRect(img, image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale), c)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+func textWidth(text string, scale int) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n*(glyphWidth+1) - 1) * scale
+}
+
+// fitText shortens text with an ellipsis until it fits in width pixels
+func fitText(text string, scale, width int) string {
+	runes := []rune(text)
+	if textWidth(text, scale) <= width {
+		return text
+	}
+	for len(runes) > 0 && textWidth(string(runes)+"...", scale) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
@@ -0,0 +1,119 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rankcard
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Welcome card dimensions in pixels
+const (
+	WelcomeWidth  = 960
+	WelcomeHeight = 360
+)
+
+// DefaultWelcomeTitle is the heading used when a guild hasn't set its own
+const DefaultWelcomeTitle = "WELCOME"
+
+// WelcomeCard holds everything shown on a welcome card
+type WelcomeCard struct {
+	Title           string
+	Username        string
+	MemberNumber    int
+	Avatar          image.Image // Optional, a placeholder circle is drawn when nil
+	BackgroundImage image.Image // Optional, drawn dimmed over the background color
+	Background      color.RGBA
+	Accent          color.RGBA
+}
+
+// RenderWelcome draws a welcome card and writes it to w as a PNG
+func RenderWelcome(w io.Writer, c *WelcomeCard) error {
+	img := image.NewRGBA(image.Rect(0, 0, WelcomeWidth, WelcomeHeight))
+	fillRect(img, img.Bounds(), c.Background)
+	if c.BackgroundImage != nil {
+		drawCoverImage(img, c.BackgroundImage)
+		// Dim the picture so white text stays readable on bright backgrounds
+		dim(img, 0.45)
+	}
+
+	// Avatar with an accent ring, centered at the top
+	const avatarSize = 150
+	ax, ay := (WelcomeWidth-avatarSize)/2, 28
+	fillCircle(img, ax+avatarSize/2, ay+avatarSize/2, avatarSize/2+6, c.Accent)
+	if c.Avatar != nil {
+		drawCircleImage(img, c.Avatar, ax, ay, avatarSize)
+	} else {
+		fillCircle(img, ax+avatarSize/2, ay+avatarSize/2, avatarSize/2, blend(c.Background, c.Accent, 0.3))
+	}
+
+	text := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	muted := color.RGBA{0xD0, 0xD2, 0xD6, 0xFF}
+	width := WelcomeWidth - 80
+
+	title := c.Title
+	if title == "" {
+		title = DefaultWelcomeTitle
+	}
+	drawCentered(img, 202, 6, fitText(title, 6, width), c.Accent)
+	drawCentered(img, 262, 4, fitText(c.Username, 4, width), text)
+	if c.MemberNumber > 0 {
+		drawCentered(img, 308, 3, fmt.Sprintf("MEMBER #%d", c.MemberNumber), muted)
+	}
+
+	return png.Encode(w, img)
+}
+
+// drawCentered draws text horizontally centered with its top at y
+func drawCentered(img *image.RGBA, y, scale int, text string, c color.RGBA) {
+	drawText(img, (img.Bounds().Dx()-textWidth(text, scale))/2, y, scale, text, c)
+}
+
+// drawCoverImage scales src with nearest neighbour sampling to cover the whole
+// image, cropping whatever overflows evenly from both sides
+func drawCoverImage(img *image.RGBA, src image.Image) {
+	b, dst := src.Bounds(), img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return
+	}
+
+	scale := max(float64(dst.Dx())/float64(b.Dx()), float64(dst.Dy())/float64(b.Dy()))
+	offX := (float64(b.Dx())*scale - float64(dst.Dx())) / 2
+	offY := (float64(b.Dy())*scale - float64(dst.Dy())) / 2
+	for y := dst.Min.Y; y < dst.Max.Y; y++ {
+		sy := min(b.Min.Y+int((float64(y)+offY)/scale), b.Max.Y-1)
+		for x := dst.Min.X; x < dst.Max.X; x++ {
+			sx := min(b.Min.X+int((float64(x)+offX)/scale), b.Max.X-1)
+			r, g, bl, _ := src.At(sx, sy).RGBA()
+			img.SetRGBA(x, y, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), 0xFF})
+		}
+	}
+}
+
+// dim darkens every pixel by amount
+func dim(img *image.RGBA, amount float64) {
+	black := color.RGBA{0, 0, 0, 0xFF}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetRGBA(x, y, blend(img.RGBAAt(x, y), black, amount))
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State file written next to the binary when an update is installed
+const stateFileName = "himiko-update.json"
+
+// State records the last installed update so the new process can announce it
+// and an owner can roll it back
+type State struct {
+	PreviousVersion string    `json:"previous_version"`
+	NewVersion      string    `json:"new_version"`
+	Channel         string    `json:"channel"`
+	ReleaseNotes    string    `json:"release_notes"`
+	ReleaseURL      string    `json:"release_url"`
+	DBBackup        string    `json:"db_backup,omitempty"` // Database snapshot taken before the update
+	AppliedAt       time.Time `json:"applied_at"`
+	Announced       bool      `json:"announced"`
+}
+
+func statePath() (string, error) {
+	execPath, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(execPath), stateFileName), nil
+}
+
+// LoadState returns the last update state, or nil if no update has been recorded
+func LoadState() (*State, error) {
+	path, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse update state: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveState records the update state next to the binary
+func SaveState(state *State) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// ClearState forgets the last update
+func ClearState() error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// CanRollback reports whether the binary replaced by the last update is still on disk
+func CanRollback() bool {
+	execPath, err := executablePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(execPath + ".old")
+	return err == nil
+}
+
+// Rollback puts the binary replaced by the last update back in place.
+// The rejected binary is kept as .failed for inspection.
+func Rollback() error {
+	execPath, err := executablePath()
+	if err != nil {
+		return err
+	}
+
+	backupPath := execPath + ".old"
+	if _, err := os.Stat(backupPath); err != nil {
+		return fmt.Errorf("no previous binary to roll back to")
+	}
+
+	failedPath := execPath + ".failed"
+	os.Remove(failedPath)
+	if err := os.Rename(execPath, failedPath); err != nil {
+		return fmt.Errorf("failed to move current binary aside: %w", err)
+	}
+
+	if err := os.Rename(backupPath, execPath); err != nil {
+		os.Rename(failedPath, execPath)
+		return fmt.Errorf("failed to restore previous binary: %w", err)
+	}
+	return nil
+}
+
+// RestoreDatabase replaces the database file with a backup. The database must be closed.
+func RestoreDatabase(backupPath, dbPath string) error {
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read database backup: %w", err)
+	}
+
+	// Stale WAL files would be replayed over the restored copy
+	os.Remove(dbPath + "-wal")
+	os.Remove(dbPath + "-shm")
+
+	tmpPath := dbPath + ".restore"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+	return nil
+}
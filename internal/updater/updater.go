@@ -26,22 +26,39 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
 const (
-	GitHubRepo    = "blubskye/himiko"
-	GitHubAPIURL  = "https://api.github.com/repos/" + GitHubRepo + "/releases/latest"
+	GitHubRepo     = "blubskye/himiko"
+	GitHubAPIURL   = "https://api.github.com/repos/" + GitHubRepo + "/releases/latest"
+	GitHubListURL  = "https://api.github.com/repos/" + GitHubRepo + "/releases?per_page=20"
 	CurrentVersion = "1.7.1"
 )
 
+// Release channels
+const (
+	ChannelStable = "stable" // Only full releases
+	ChannelBeta   = "beta"   // Full releases and pre-releases
+)
+
+// Maximum size accepted for a release archive
+const maxDownloadSize = 200 << 20
+
+var httpClient = &http.Client{Timeout: 10 * time.Minute}
+
 // Release represents a GitHub release
 type Release struct {
-	TagName string  `json:"tag_name"`
-	Name    string  `json:"name"`
-	Body    string  `json:"body"`
-	Assets  []Asset `json:"assets"`
+	TagName    string  `json:"tag_name"`
+	Name       string  `json:"name"`
+	Body       string  `json:"body"`
+	HTMLURL    string  `json:"html_url"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
 }
 
 // Asset represents a release asset
@@ -54,29 +71,33 @@ type Asset struct {
 // UpdateInfo contains information about an available update
 type UpdateInfo struct {
 	Available      bool
+	Channel        string
 	CurrentVersion string
 	NewVersion     string
+	Prerelease     bool
 	ReleaseNotes   string
+	ReleaseURL     string
 	DownloadURL    string
 	AssetName      string
 	Size           int64
+	ChecksumURL    string // checksums.txt listing the SHA-256 of every asset
+	SignatureURL   string // Detached ed25519 signature of the checksums file
 }
 
-// CheckForUpdate checks GitHub for a newer release
-func CheckForUpdate() (*UpdateInfo, error) {
-	resp, err := http.Get(GitHubAPIURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check for updates: %w", err)
-	}
-	defer resp.Body.Close()
+// ValidChannel reports whether channel is a known release channel
+func ValidChannel(channel string) bool {
+	return channel == ChannelStable || channel == ChannelBeta
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+// CheckForUpdate checks GitHub for a newer release on the given channel
+func CheckForUpdate(channel string) (*UpdateInfo, error) {
+	if !ValidChannel(channel) {
+		channel = ChannelStable
 	}
 
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to parse release info: %w", err)
+	release, err := latestRelease(channel)
+	if err != nil {
+		return nil, err
 	}
 
 	// Clean version tags (remove 'v' prefix if present)
@@ -84,9 +105,12 @@ func CheckForUpdate() (*UpdateInfo, error) {
 	currentVersion := strings.TrimPrefix(CurrentVersion, "v")
 
 	info := &UpdateInfo{
+		Channel:        channel,
 		CurrentVersion: currentVersion,
 		NewVersion:     newVersion,
+		Prerelease:     release.Prerelease,
 		ReleaseNotes:   release.Body,
+		ReleaseURL:     release.HTMLURL,
 	}
 
 	// Check if newer version
@@ -95,136 +119,190 @@ func CheckForUpdate() (*UpdateInfo, error) {
 		return info, nil
 	}
 
-	// Find appropriate asset for current OS/arch
-	assetName := getAssetName()
+	// Find asset matching our platform pattern
 	for _, asset := range release.Assets {
-		if asset.Name == assetName {
+		if matchesAssetPattern(asset.Name) {
 			info.Available = true
 			info.DownloadURL = asset.BrowserDownloadURL
 			info.AssetName = asset.Name
 			info.Size = asset.Size
-			return info, nil
 		}
 	}
+	if !info.Available {
+		return nil, fmt.Errorf("no compatible release found for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
 
-	return nil, fmt.Errorf("no compatible release found for %s/%s", runtime.GOOS, runtime.GOARCH)
-}
-
-// getAssetName returns the expected asset name for current platform
-func getAssetName() string {
-	switch runtime.GOOS {
-	case "windows":
-		return fmt.Sprintf("himiko-v%s-windows-%s.zip", CurrentVersion, runtime.GOARCH)
-	case "linux":
-		return fmt.Sprintf("himiko-v%s-linux-%s.zip", CurrentVersion, runtime.GOARCH)
-	case "darwin":
-		return fmt.Sprintf("himiko-v%s-darwin-%s.zip", CurrentVersion, runtime.GOARCH)
-	default:
-		return ""
+	for _, asset := range release.Assets {
+		switch strings.ToLower(asset.Name) {
+		case "checksums.txt", "sha256sums", "sha256sums.txt":
+			info.ChecksumURL = asset.BrowserDownloadURL
+		case "checksums.txt.sig", "sha256sums.sig", "sha256sums.txt.sig":
+			info.SignatureURL = asset.BrowserDownloadURL
+		}
 	}
+
+	return info, nil
 }
 
-// getExpectedAssetPattern returns a pattern to match assets for current platform
-func getExpectedAssetPattern() string {
-	switch runtime.GOOS {
-	case "windows":
-		return fmt.Sprintf("himiko-v*-windows-%s.zip", runtime.GOARCH)
-	case "linux":
-		return fmt.Sprintf("himiko-v*-linux-%s.zip", runtime.GOARCH)
-	case "darwin":
-		return fmt.Sprintf("himiko-v*-darwin-%s.zip", runtime.GOARCH)
-	default:
-		return ""
+// latestRelease returns the newest published release on a channel
+func latestRelease(channel string) (*Release, error) {
+	if channel == ChannelStable {
+		var release Release
+		if err := getJSON(GitHubAPIURL, &release); err != nil {
+			return nil, err
+		}
+		return &release, nil
 	}
-}
 
-// matchesAssetPattern checks if an asset name matches our platform
-func matchesAssetPattern(name string) bool {
-	goos := runtime.GOOS
-	goarch := runtime.GOARCH
+	// The latest endpoint skips pre-releases, so beta walks the release list
+	var releases []Release
+	if err := getJSON(GitHubListURL, &releases); err != nil {
+		return nil, err
+	}
 
-	expectedSuffix := fmt.Sprintf("-%s-%s.zip", goos, goarch)
-	return strings.HasPrefix(name, "himiko-v") && strings.HasSuffix(name, expectedSuffix)
+	var newest *Release
+	for i := range releases {
+		r := &releases[i]
+		if r.Draft {
+			continue
+		}
+		if newest == nil || isNewerVersion(newest.TagName, r.TagName) {
+			newest = r
+		}
+	}
+	if newest == nil {
+		return nil, fmt.Errorf("no releases published yet")
+	}
+	return newest, nil
 }
 
-// CheckForUpdateByPattern checks for updates matching platform pattern
-func CheckForUpdateByPattern() (*UpdateInfo, error) {
-	resp, err := http.Get(GitHubAPIURL)
+// getJSON fetches a GitHub API endpoint and decodes the response
+func getJSON(url string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check for updates: %w", err)
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "Himiko/"+CurrentVersion)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+		return fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
 	}
 
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to parse release info: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse release info: %w", err)
 	}
+	return nil
+}
 
-	newVersion := strings.TrimPrefix(release.TagName, "v")
-	currentVersion := strings.TrimPrefix(CurrentVersion, "v")
+// matchesAssetPattern checks if an asset name matches our platform
+func matchesAssetPattern(name string) bool {
+	goos := runtime.GOOS
+	goarch := runtime.GOARCH
 
-	info := &UpdateInfo{
-		CurrentVersion: currentVersion,
-		NewVersion:     newVersion,
-		ReleaseNotes:   release.Body,
-	}
+	expectedSuffix := fmt.Sprintf("-%s-%s.zip", goos, goarch)
+	return strings.HasPrefix(name, "himiko-v") && strings.HasSuffix(name, expectedSuffix)
+}
 
-	if !isNewerVersion(currentVersion, newVersion) {
-		info.Available = false
-		return info, nil
-	}
+// isNewerVersion compares semver versions, treating pre-releases as older
+// than the release they lead up to (1.8.0-beta.2 < 1.8.0)
+func isNewerVersion(current, new string) bool {
+	return compareVersions(new, current) > 0
+}
 
-	// Find asset matching our platform pattern
-	for _, asset := range release.Assets {
-		if matchesAssetPattern(asset.Name) {
-			info.Available = true
-			info.DownloadURL = asset.BrowserDownloadURL
-			info.AssetName = asset.Name
-			info.Size = asset.Size
-			return info, nil
+// compareVersions returns -1, 0 or 1 as a is older, equal to or newer than b
+func compareVersions(a, b string) int {
+	aCore, aPre := parseVersion(a)
+	bCore, bPre := parseVersion(b)
+
+	for i := 0; i < 3; i++ {
+		if aCore[i] != bCore[i] {
+			if aCore[i] > bCore[i] {
+				return 1
+			}
+			return -1
 		}
 	}
 
-	return nil, fmt.Errorf("no compatible release found for %s/%s", runtime.GOOS, runtime.GOARCH)
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return comparePrerelease(aPre, bPre)
 }
 
-// isNewerVersion compares semver versions
-func isNewerVersion(current, new string) bool {
-	currentParts := parseVersion(current)
-	newParts := parseVersion(new)
-
-	for i := 0; i < 3; i++ {
-		if newParts[i] > currentParts[i] {
-			return true
-		}
-		if newParts[i] < currentParts[i] {
-			return false
+// comparePrerelease orders dot-separated pre-release identifiers per semver
+func comparePrerelease(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				if aNum > bNum {
+					return 1
+				}
+				return -1
+			}
+		case aErr == nil:
+			return -1 // Numeric identifiers sort before alphanumeric ones
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+				return c
+			}
 		}
 	}
-	return false
+
+	switch {
+	case len(aParts) > len(bParts):
+		return 1
+	case len(aParts) < len(bParts):
+		return -1
+	}
+	return 0
 }
 
-// parseVersion parses a semver string into [major, minor, patch]
-func parseVersion(v string) [3]int {
+// parseVersion parses a semver string into [major, minor, patch] and its pre-release tag
+func parseVersion(v string) ([3]int, string) {
 	var parts [3]int
 	v = strings.TrimPrefix(v, "v")
 
+	// Build metadata doesn't affect precedence
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, _ := strings.Cut(v, "-")
+
 	fmt.Sscanf(v, "%d.%d.%d", &parts[0], &parts[1], &parts[2])
-	return parts
+	return parts, pre
 }
 
 // DownloadUpdate downloads the update to a temporary file
 func DownloadUpdate(info *UpdateInfo, progressFn func(downloaded, total int64)) (string, error) {
-	resp, err := http.Get(info.DownloadURL)
+	resp, err := httpClient.Get(info.DownloadURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to download update: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download update: status %d", resp.StatusCode)
+	}
+
 	// Create temp file
 	tmpFile, err := os.CreateTemp("", "himiko-update-*.zip")
 	if err != nil {
@@ -234,9 +312,10 @@ func DownloadUpdate(info *UpdateInfo, progressFn func(downloaded, total int64))
 
 	// Download with progress
 	var downloaded int64
+	body := io.LimitReader(resp.Body, maxDownloadSize+1)
 	buf := make([]byte, 32*1024)
 	for {
-		n, err := resp.Body.Read(buf)
+		n, err := body.Read(buf)
 		if n > 0 {
 			_, writeErr := tmpFile.Write(buf[:n])
 			if writeErr != nil {
@@ -244,6 +323,10 @@ func DownloadUpdate(info *UpdateInfo, progressFn func(downloaded, total int64))
 				return "", fmt.Errorf("failed to write update: %w", writeErr)
 			}
 			downloaded += int64(n)
+			if downloaded > maxDownloadSize {
+				os.Remove(tmpFile.Name())
+				return "", fmt.Errorf("update archive is larger than %d MB", maxDownloadSize>>20)
+			}
 			if progressFn != nil {
 				progressFn(downloaded, info.Size)
 			}
@@ -263,13 +346,9 @@ func DownloadUpdate(info *UpdateInfo, progressFn func(downloaded, total int64))
 // ApplyUpdate extracts the update and replaces the binary
 func ApplyUpdate(zipPath string) error {
 	// Get current executable path
-	execPath, err := os.Executable()
+	execPath, err := executablePath()
 	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
-	}
-	execPath, err = filepath.EvalSymlinks(execPath)
-	if err != nil {
-		return fmt.Errorf("failed to resolve executable path: %w", err)
+		return err
 	}
 
 	execDir := filepath.Dir(execPath)
@@ -284,11 +363,9 @@ func ApplyUpdate(zipPath string) error {
 
 	// Find the binary in the zip
 	var binaryFile *zip.File
-	binaryName := "himiko-linux-amd64"
+	binaryName := fmt.Sprintf("himiko-%s-%s", runtime.GOOS, runtime.GOARCH)
 	if runtime.GOOS == "windows" {
-		binaryName = "himiko-windows-amd64.exe"
-	} else if runtime.GOOS == "darwin" {
-		binaryName = "himiko-darwin-amd64"
+		binaryName += ".exe"
 	}
 
 	for _, f := range r.File {
@@ -305,6 +382,7 @@ func ApplyUpdate(zipPath string) error {
 	// Extract to temp file
 	tmpBinary := filepath.Join(execDir, execName+".new")
 	if err := extractFile(binaryFile, tmpBinary); err != nil {
+		os.Remove(tmpBinary)
 		return fmt.Errorf("failed to extract binary: %w", err)
 	}
 
@@ -316,8 +394,9 @@ func ApplyUpdate(zipPath string) error {
 		}
 	}
 
-	// Backup old binary
+	// Keep the running binary as the rollback target, replacing the one from the last update
 	backupPath := execPath + ".old"
+	os.Remove(backupPath)
 	if err := os.Rename(execPath, backupPath); err != nil {
 		os.Remove(tmpBinary)
 		return fmt.Errorf("failed to backup old binary: %w", err)
@@ -330,9 +409,6 @@ func ApplyUpdate(zipPath string) error {
 		return fmt.Errorf("failed to install new binary: %w", err)
 	}
 
-	// Remove backup
-	os.Remove(backupPath)
-
 	// Clean up zip
 	os.Remove(zipPath)
 
@@ -353,10 +429,23 @@ func extractFile(f *zip.File, destPath string) error {
 	}
 	defer outFile.Close()
 
-	_, err = io.Copy(outFile, rc)
+	_, err = io.Copy(outFile, io.LimitReader(rc, maxDownloadSize))
 	return err
 }
 
+// executablePath returns the resolved path of the running binary
+func executablePath() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	execPath, err = filepath.EvalSymlinks(execPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve executable path: %w", err)
+	}
+	return execPath, nil
+}
+
 // GetCurrentVersion returns the current version
 func GetCurrentVersion() string {
 	return CurrentVersion
@@ -365,18 +454,14 @@ func GetCurrentVersion() string {
 // RelaunchAfterUpdate relaunches the bot executable after an update
 // This uses exec on Unix systems to replace the current process
 func RelaunchAfterUpdate() error {
-	execPath, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
-	}
-	execPath, err = filepath.EvalSymlinks(execPath)
+	execPath, err := executablePath()
 	if err != nil {
-		return fmt.Errorf("failed to resolve executable path: %w", err)
+		return err
 	}
 
 	// On Windows, we need to start a new process and exit
 	if runtime.GOOS == "windows" {
-		cmd := exec.Command(execPath)
+		cmd := exec.Command(execPath, os.Args[1:]...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		cmd.Stdin = os.Stdin
@@ -390,5 +475,5 @@ func RelaunchAfterUpdate() error {
 
 	// On Unix systems, use syscall.Exec to replace the current process
 	// This preserves the PID and cleanly transitions to the new binary
-	return syscall.Exec(execPath, []string{execPath}, os.Environ())
+	return syscall.Exec(execPath, append([]string{execPath}, os.Args[1:]...), os.Environ())
 }
@@ -0,0 +1,153 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Checksum and signature files are small, anything bigger is refused
+const maxChecksumFileSize = 1 << 20
+
+// VerifyUpdate checks a downloaded archive against the release's checksums file.
+// When publicKey is set the checksums file must also carry a valid ed25519
+// signature from that key, so a tampered release can't supply its own checksums.
+func VerifyUpdate(info *UpdateInfo, archivePath, publicKey string) error {
+	if info.ChecksumURL == "" {
+		return fmt.Errorf("release v%s has no checksums file", info.NewVersion)
+	}
+
+	checksums, err := fetchSmall(info.ChecksumURL)
+	if err != nil {
+		return fmt.Errorf("failed to download checksums: %w", err)
+	}
+
+	if publicKey != "" {
+		if info.SignatureURL == "" {
+			return fmt.Errorf("release v%s is not signed", info.NewVersion)
+		}
+		sig, err := fetchSmall(info.SignatureURL)
+		if err != nil {
+			return fmt.Errorf("failed to download signature: %w", err)
+		}
+		if err := verifySignature(publicKey, checksums, sig); err != nil {
+			return err
+		}
+	}
+
+	expected, err := findChecksum(checksums, info.AssetName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to hash update: %w", err)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s", info.AssetName)
+	}
+	return nil
+}
+
+// findChecksum looks up an asset in a sha256sum-style file ("<hex>  <name>")
+func findChecksum(checksums []byte, assetName string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary mode with a leading '*'
+		if strings.TrimPrefix(fields[1], "*") == assetName {
+			sum := strings.ToLower(fields[0])
+			if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+				return "", fmt.Errorf("malformed checksum for %s", assetName)
+			}
+			return sum, nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", assetName)
+}
+
+// verifySignature checks a detached ed25519 signature, accepting raw, hex or base64 encodings
+func verifySignature(publicKey string, message, sig []byte) error {
+	key, err := decodeKeyMaterial(publicKey, ed25519.PublicKeySize)
+	if err != nil {
+		return fmt.Errorf("invalid update public key: %w", err)
+	}
+	signature, err := decodeKeyMaterial(string(sig), ed25519.SignatureSize)
+	if err != nil {
+		return fmt.Errorf("invalid release signature: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), message, signature) {
+		return fmt.Errorf("release signature does not match the update public key")
+	}
+	return nil
+}
+
+func decodeKeyMaterial(s string, size int) ([]byte, error) {
+	if len(s) == size {
+		return []byte(s), nil
+	}
+	s = strings.TrimSpace(s)
+	if b, err := hex.DecodeString(s); err == nil && len(b) == size {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && len(b) == size {
+		return b, nil
+	}
+	return nil, fmt.Errorf("expected %d bytes as hex or base64", size)
+}
+
+// fetchSmall downloads a small release asset into memory
+func fetchSmall(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChecksumFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxChecksumFileSize {
+		return nil, fmt.Errorf("file too large")
+	}
+	return data, nil
+}
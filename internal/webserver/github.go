@@ -0,0 +1,323 @@
+// Himiko Discord Bot
+// Copyright (C) 2025 Himiko Contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package webserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blubskye/himiko/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
+
+// GitHub caps webhook payloads at 25 MB
+const gitHubMaxPayload = 25 << 20
+
+// Embed colours, matching GitHub's own state colours
+const (
+	gitHubColorBlue   = 0x0969DA
+	gitHubColorGreen  = 0x1F883D
+	gitHubColorRed    = 0xCF222E
+	gitHubColorPurple = 0x8250DF
+)
+
+type gitHubUser struct {
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+	HTMLURL   string `json:"html_url"`
+}
+
+type gitHubPayload struct {
+	Action     string `json:"action"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+	Sender gitHubUser `json:"sender"`
+
+	// push
+	Ref     string `json:"ref"`
+	Compare string `json:"compare"`
+	Deleted bool   `json:"deleted"`
+	Forced  bool   `json:"forced"`
+	Commits []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name     string `json:"name"`
+			Username string `json:"username"`
+		} `json:"author"`
+	} `json:"commits"`
+
+	// release
+	Release struct {
+		TagName    string `json:"tag_name"`
+		Name       string `json:"name"`
+		Body       string `json:"body"`
+		HTMLURL    string `json:"html_url"`
+		Prerelease bool   `json:"prerelease"`
+	} `json:"release"`
+
+	// issues
+	Issue gitHubIssue `json:"issue"`
+
+	// pull_request
+	PullRequest struct {
+		gitHubIssue
+		Merged bool `json:"merged"`
+		Head   struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+}
+
+type gitHubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// handleGitHubWebhook receives GitHub webhook deliveries and relays them to
+// every guild that maps the repository to a channel. Each relay has its own
+// secret, so a delivery only reaches the relays whose secret signed it.
+func (s *Server) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, gitHubMaxPayload+1))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > gitHubMaxPayload {
+		http.Error(w, "Payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var payload gitHubPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	if payload.Repository.FullName == "" {
+		http.Error(w, "Missing repository", http.StatusBadRequest)
+		return
+	}
+
+	relays, err := s.db.GetGitHubRelaysForRepo(payload.Repository.FullName)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	signature := r.Header.Get("X-Hub-Signature-256")
+	var verified []*database.GitHubRelay
+	for _, relay := range relays {
+		if verifyGitHubSignature(relay.Secret, body, signature) {
+			verified = append(verified, relay)
+		}
+	}
+	if len(verified) == 0 {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	if event == "ping" {
+		s.jsonResponse(w, map[string]string{"status": "pong"})
+		return
+	}
+
+	embed := gitHubEmbed(event, &payload)
+	if embed == nil {
+		s.jsonResponse(w, map[string]string{"status": "ignored"})
+		return
+	}
+
+	sent := 0
+	for _, relay := range verified {
+		if !gitHubRelays(relay, event) {
+			continue
+		}
+		if _, err := s.session.ChannelMessageSendEmbed(relay.ChannelID, embed); err != nil {
+			log.Printf("[GitHub] Failed to relay %s for %s to channel %s: %v", event, relay.Repo, relay.ChannelID, err)
+			continue
+		}
+		sent++
+	}
+
+	s.jsonResponse(w, map[string]interface{}{"status": "ok", "relayed": sent})
+}
+
+// verifyGitHubSignature checks an X-Hub-Signature-256 header against the body
+func verifyGitHubSignature(secret string, body []byte, signature string) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok || secret == "" {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// gitHubRelays reports whether a relay forwards the given event
+func gitHubRelays(relay *database.GitHubRelay, event string) bool {
+	if relay.Events == "" {
+		return slices.Contains(database.GitHubEvents, event)
+	}
+	return slices.Contains(strings.Split(relay.Events, ","), event)
+}
+
+// gitHubEmbed formats a delivery, nil for events and actions that aren't relayed
+func gitHubEmbed(event string, p *gitHubPayload) *discordgo.MessageEmbed {
+	repo := p.Repository.FullName
+	embed := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    p.Sender.Login,
+			URL:     p.Sender.HTMLURL,
+			IconURL: p.Sender.AvatarURL,
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	switch event {
+	case database.GitHubEventPush:
+		branch, isBranch := strings.CutPrefix(p.Ref, "refs/heads/")
+		if !isBranch || p.Deleted || len(p.Commits) == 0 {
+			return nil
+		}
+		noun := "commit"
+		if len(p.Commits) != 1 {
+			noun = "commits"
+		}
+		embed.Title = fmt.Sprintf("[%s:%s] %d new %s", repo, branch, len(p.Commits), noun)
+		if p.Forced {
+			embed.Title += " (force-pushed)"
+		}
+		embed.URL = p.Compare
+		embed.Color = gitHubColorBlue
+
+		var lines []string
+		for i, c := range p.Commits {
+			if i == 5 {
+				lines = append(lines, fmt.Sprintf("...and %d more", len(p.Commits)-5))
+				break
+			}
+			message, _, _ := strings.Cut(c.Message, "\n")
+			author := c.Author.Username
+			if author == "" {
+				author = c.Author.Name
+			}
+			lines = append(lines, fmt.Sprintf("[`%.7s`](%s) %s - %s", c.ID, c.URL, truncateText(message, 70), author))
+		}
+		embed.Description = strings.Join(lines, "\n")
+
+	case database.GitHubEventRelease:
+		if p.Action != "published" {
+			return nil
+		}
+		name := p.Release.Name
+		if name == "" {
+			name = p.Release.TagName
+		}
+		kind := "release"
+		if p.Release.Prerelease {
+			kind = "pre-release"
+		}
+		embed.Title = truncateText(fmt.Sprintf("[%s] New %s: %s", repo, kind, name), 256)
+		embed.URL = p.Release.HTMLURL
+		embed.Description = truncateText(p.Release.Body, 1000)
+		embed.Color = gitHubColorGreen
+
+	case database.GitHubEventIssues:
+		state, color, ok := gitHubIssueState(p.Action, false)
+		if !ok {
+			return nil
+		}
+		embed.Title = truncateText(fmt.Sprintf("[%s] Issue %s: #%d %s", repo, state, p.Issue.Number, p.Issue.Title), 256)
+		embed.URL = p.Issue.HTMLURL
+		embed.Color = color
+		if p.Action == "opened" {
+			embed.Description = truncateText(p.Issue.Body, 500)
+		}
+
+	case database.GitHubEventPullRequest:
+		pr := &p.PullRequest
+		state, color, ok := gitHubIssueState(p.Action, pr.Merged)
+		if !ok {
+			return nil
+		}
+		embed.Title = truncateText(fmt.Sprintf("[%s] Pull request %s: #%d %s", repo, state, pr.Number, pr.Title), 256)
+		embed.URL = pr.HTMLURL
+		embed.Color = color
+		if p.Action == "opened" {
+			embed.Description = truncateText(pr.Body, 500)
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: pr.Head.Ref + " → " + pr.Base.Ref}
+		}
+
+	default:
+		return nil
+	}
+
+	return embed
+}
+
+// gitHubIssueState maps an issue or pull request action to its display state
+func gitHubIssueState(action string, merged bool) (string, int, bool) {
+	switch action {
+	case "opened":
+		return "opened", gitHubColorGreen, true
+	case "reopened":
+		return "reopened", gitHubColorGreen, true
+	case "closed":
+		if merged {
+			return "merged", gitHubColorPurple, true
+		}
+		return "closed", gitHubColorRed, true
+	}
+	return "", 0, false
+}
+
+// truncateText shortens s to at most max runes, marking the cut with an ellipsis
+func truncateText(s string, max int) string {
+	s = strings.TrimSpace(s)
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -20,10 +20,13 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
@@ -39,7 +42,9 @@ type Server struct {
 	db             *database.DB
 	session        *discordgo.Session
 	httpServer     *http.Server
+	webhookServer  *http.Server // Incoming webhooks only, so it can be exposed without the dashboard
 	statsCollector *StatsCollector
+	publishPanel   func(panelID int64, channelID string) error
 	running        bool
 	mu             sync.RWMutex
 }
@@ -53,6 +58,11 @@ func New(cfg *config.Config, db *database.DB, session *discordgo.Session) *Serve
 	}
 }
 
+// SetRolePanelPublisher sets the callback used to post or refresh a role panel in Discord
+func (s *Server) SetRolePanelPublisher(fn func(panelID int64, channelID string) error) {
+	s.publishPanel = fn
+}
+
 // InitStats initializes the stats collector with the bot start time
 func (s *Server) InitStats(startTime time.Time, version string) {
 	dbPath := ""
@@ -108,19 +118,30 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/guilds", s.handleAPIGuilds)
 	mux.HandleFunc("/api/guild/", s.handleAPIGuild)
 	mux.HandleFunc("/api/guild/settings/", s.handleAPIGuildSettings)
+	mux.HandleFunc("/api/guild/greetings/", s.handleAPIGreetings)
 	mux.HandleFunc("/api/stats", s.handleAPIStats)
 
 	// Config API endpoints
 	mux.HandleFunc("/api/guild/logging/", s.handleAPILoggingConfig)
+	mux.HandleFunc("/api/guild/logsearch/", s.handleAPILogSearch)
+	mux.HandleFunc("/api/guild/polls/", s.handleAPIPolls)
 	mux.HandleFunc("/api/guild/antiraid/", s.handleAPIAntiRaidConfig)
 	mux.HandleFunc("/api/guild/antispam/", s.handleAPIAntiSpamConfig)
 	mux.HandleFunc("/api/guild/spamfilter/", s.handleAPISpamFilterConfig)
 	mux.HandleFunc("/api/guild/voicexp/", s.handleAPIVoiceXPConfig)
+	mux.HandleFunc("/api/guild/economy/", s.handleAPIEconomyConfig)
+	mux.HandleFunc("/api/guild/music/", s.handleAPIMusicConfig)
 	mux.HandleFunc("/api/guild/autoclean/", s.handleAPIAutoCleanConfig)
 	mux.HandleFunc("/api/guild/ticket/", s.handleAPITicketConfig)
+	mux.HandleFunc("/api/guild/tickets/", s.handleAPITickets)
 	mux.HandleFunc("/api/guild/regex/", s.handleAPIRegexFilters)
 	mux.HandleFunc("/api/guild/ranks/", s.handleAPILevelRanks)
+	mux.HandleFunc("/api/guild/xpexclusions/", s.handleAPIXPExclusions)
+	mux.HandleFunc("/api/guild/rolepanels/", s.handleAPIRolePanels)
+	mux.HandleFunc("/api/guild/rolepanelroles/", s.handleAPIRolePanelRoles)
 	mux.HandleFunc("/api/guild/commands/", s.handleAPICommandConfig)
+	mux.HandleFunc("/api/guild/activity/", s.handleAPIActivity)
+	mux.HandleFunc("/api/guild/modstats/", s.handleAPIModStats)
 
 	// Helper endpoints
 	mux.HandleFunc("/api/commands/list", s.handleAPICommandsList)
@@ -133,6 +154,7 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/stats/history/hour", s.handleAPIStatsHourly)
 	mux.HandleFunc("/api/stats/history/day", s.handleAPIStatsDaily)
 	mux.HandleFunc("/api/stats/database", s.handleAPIStatsDatabase)
+	mux.HandleFunc("/api/stats/commands", s.handleAPIStatsCommands)
 
 	addr := fmt.Sprintf("%s:%d", s.config.WebServer.Host, s.config.WebServer.Port)
 
@@ -158,6 +180,28 @@ func (s *Server) Start() error {
 		}
 	}()
 
+	// The dashboard has no login, so webhooks from outside get a listener of their own
+	if s.config.WebServer.WebhookPort != 0 {
+		webhookMux := http.NewServeMux()
+		webhookMux.HandleFunc("/webhooks/github", s.handleGitHubWebhook)
+
+		webhookAddr := fmt.Sprintf("%s:%d", s.config.WebServer.WebhookHost, s.config.WebServer.WebhookPort)
+		s.webhookServer = &http.Server{
+			Addr:         webhookAddr,
+			Handler:      s.middleware(webhookMux),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+
+		go func() {
+			log.Printf("[WebServer] Accepting webhooks on http://%s", webhookAddr)
+			if err := s.webhookServer.ListenAndServe(); err != http.ErrServerClosed {
+				log.Printf("[WebServer] Webhook listener error: %v", err)
+			}
+		}()
+	}
+
 	return nil
 }
 
@@ -178,6 +222,12 @@ func (s *Server) Stop() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if s.webhookServer != nil {
+		if err := s.webhookServer.Shutdown(ctx); err != nil {
+			return fmt.Errorf("failed to shutdown webhook listener: %w", err)
+		}
+		s.webhookServer = nil
+	}
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown server: %w", err)
 	}
@@ -402,6 +452,120 @@ func (s *Server) handleAPILoggingConfig(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// handleAPILogSearch searches stored logs with pagination.
+// Query params: type (deleted, modactions, joins), user, action, since, until (unix seconds), page, per_page
+func (s *Server) handleAPILogSearch(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/logsearch/"):]
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	page, _ := strconv.Atoi(params.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(params.Get("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 25
+	}
+
+	q := database.LogSearchQuery{
+		GuildID: guildID,
+		UserID:  params.Get("user"),
+		Action:  params.Get("action"),
+		Limit:   perPage,
+		Offset:  (page - 1) * perPage,
+	}
+	if since, err := strconv.ParseInt(params.Get("since"), 10, 64); err == nil {
+		q.Since = time.Unix(since, 0)
+	}
+	if until, err := strconv.ParseInt(params.Get("until"), 10, 64); err == nil {
+		q.Until = time.Unix(until, 0)
+	}
+
+	var results interface{}
+	var total int
+	var err error
+	switch params.Get("type") {
+	case "deleted":
+		results, total, err = s.db.SearchDeletedMessages(q)
+	case "modactions":
+		results, total, err = s.db.SearchModActions(q)
+	case "joins":
+		results, total, err = s.db.SearchMemberJoins(q)
+	default:
+		http.Error(w, "type must be deleted, modactions or joins", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to search logs", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"results":  results,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+		"pages":    (total + perPage - 1) / perPage,
+	})
+}
+
+// handleAPIPolls lists a guild's polls with their results
+func (s *Server) handleAPIPolls(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/polls/"):]
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	page, _ := strconv.Atoi(params.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(params.Get("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 25
+	}
+
+	polls, err := s.db.GetGuildPolls(guildID, perPage, (page-1)*perPage)
+	if err != nil {
+		http.Error(w, "Failed to get polls", http.StatusInternalServerError)
+		return
+	}
+
+	type pollResult struct {
+		database.Poll
+		Votes  []int
+		Voters map[int][]string `json:",omitempty"`
+	}
+	results := make([]pollResult, 0, len(polls))
+	for _, p := range polls {
+		votes, err := s.db.GetPollVotes(p.ID)
+		if err != nil {
+			http.Error(w, "Failed to get poll votes", http.StatusInternalServerError)
+			return
+		}
+		result := pollResult{Poll: p, Votes: make([]int, len(p.Options))}
+		for idx := range p.Options {
+			result.Votes[idx] = len(votes[idx])
+		}
+		if !p.Anonymous {
+			result.Voters = votes
+		}
+		results = append(results, result)
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"polls":    results,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
 // handleAPIAntiRaidConfig handles anti-raid configuration
 func (s *Server) handleAPIAntiRaidConfig(w http.ResponseWriter, r *http.Request) {
 	guildID := r.URL.Path[len("/api/guild/antiraid/"):]
@@ -514,6 +678,91 @@ func (s *Server) handleAPIVoiceXPConfig(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// handleAPIMusicConfig handles the music command channels, volume normalization and queue limits
+func (s *Server) handleAPIMusicConfig(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/music/"):]
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := s.db.GetMusicSettings(guildID)
+		if err != nil {
+			http.Error(w, "Failed to get config", http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, settings)
+	case http.MethodPost, http.MethodPut:
+		var update struct {
+			TextChannels       []string
+			Normalize          bool
+			MaxQueueLength     int
+			MaxTrackDuration   int
+			MaxUserTracks      int
+			PlaylistLimit      int
+			PlaylistTrackLimit int
+		}
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if update.MaxQueueLength < 0 || update.MaxTrackDuration < 0 || update.MaxUserTracks < 0 ||
+			update.PlaylistLimit < 1 || update.PlaylistTrackLimit < 1 {
+			http.Error(w, "Invalid limits", http.StatusBadRequest)
+			return
+		}
+		// Roles and the rest of the playback settings aren't edited here
+		settings, err := s.db.GetMusicSettings(guildID)
+		if err != nil {
+			http.Error(w, "Failed to get config", http.StatusInternalServerError)
+			return
+		}
+		settings.TextChannels = update.TextChannels
+		settings.Normalize = update.Normalize
+		settings.MaxQueueLength = update.MaxQueueLength
+		settings.MaxTrackDuration = update.MaxTrackDuration
+		settings.MaxUserTracks = update.MaxUserTracks
+		settings.PlaylistLimit = update.PlaylistLimit
+		settings.PlaylistTrackLimit = update.PlaylistTrackLimit
+		if err := s.db.SetMusicSettings(settings); err != nil {
+			http.Error(w, "Failed to save config", http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPIEconomyConfig handles economy earn rate configuration
+func (s *Server) handleAPIEconomyConfig(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/economy/"):]
+	switch r.Method {
+	case http.MethodGet:
+		config, err := s.db.GetEconomyConfig(guildID)
+		if err != nil {
+			http.Error(w, "Failed to get config", http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, config)
+	case http.MethodPost, http.MethodPut:
+		var config database.EconomyConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if config.WorkMax < config.WorkMin || config.DailyAmount < 0 || config.ChatAmount < 0 || config.WorkCooldownMins < 1 {
+			http.Error(w, "Invalid earn rates", http.StatusBadRequest)
+			return
+		}
+		config.GuildID = guildID
+		if err := s.db.SetEconomyConfig(&config); err != nil {
+			http.Error(w, "Failed to save config", http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 // handleAPIAutoCleanConfig handles auto-clean configuration
 func (s *Server) handleAPIAutoCleanConfig(w http.ResponseWriter, r *http.Request) {
 	guildID := r.URL.Path[len("/api/guild/autoclean/"):]
@@ -571,12 +820,50 @@ func (s *Server) handleAPITicketConfig(w http.ResponseWriter, r *http.Request) {
 		var req struct {
 			ChannelID string `json:"channel_id"`
 			Enabled   bool   `json:"enabled"`
+			Mode      string `json:"mode"`
+
+			TranscriptChannelID string `json:"transcript_channel_id"`
+			InactiveHours       *int   `json:"inactive_hours"`
+			InactiveGraceHours  *int   `json:"inactive_grace_hours"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid JSON", http.StatusBadRequest)
 			return
 		}
-		if err := s.db.SetTicketConfig(guildID, req.ChannelID, req.Enabled); err != nil {
+		if req.Mode != "" && req.Mode != database.TicketModeThread && req.Mode != database.TicketModeChannel {
+			http.Error(w, "Invalid ticket mode", http.StatusBadRequest)
+			return
+		}
+		if req.InactiveHours != nil && (*req.InactiveHours < 0 || *req.InactiveHours > 720) {
+			http.Error(w, "Inactivity hours must be between 0 and 720", http.StatusBadRequest)
+			return
+		}
+		if req.InactiveGraceHours != nil && (*req.InactiveGraceHours < 1 || *req.InactiveGraceHours > 168) {
+			http.Error(w, "Warning hours must be between 1 and 168", http.StatusBadRequest)
+			return
+		}
+		// Category and support role are only set from Discord, so keep whatever is saved
+		config, err := s.db.GetTicketConfig(guildID)
+		if err != nil {
+			http.Error(w, "Failed to get config", http.StatusInternalServerError)
+			return
+		}
+		if config == nil {
+			config = &database.TicketConfig{GuildID: guildID}
+		}
+		config.ChannelID = req.ChannelID
+		config.Enabled = req.Enabled
+		config.TranscriptChannelID = req.TranscriptChannelID
+		if req.InactiveHours != nil {
+			config.InactiveHours = *req.InactiveHours
+		}
+		if req.InactiveGraceHours != nil {
+			config.InactiveGraceHours = *req.InactiveGraceHours
+		}
+		if req.Mode != "" {
+			config.Mode = req.Mode
+		}
+		if err := s.db.SetTicketConfig(config); err != nil {
 			http.Error(w, "Failed to save config", http.StatusInternalServerError)
 			return
 		}
@@ -586,6 +873,294 @@ func (s *Server) handleAPITicketConfig(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleAPIGreetings reads and saves the welcome, leave and join DM embeds and the leave
+// message. The welcome and join DM text are part of the guild settings.
+func (s *Server) handleAPIGreetings(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/greetings/"):]
+	kinds := database.GreetingKinds
+
+	type greeting struct {
+		ChannelID string `json:"channel_id"`
+		Content   string `json:"content"`
+		Embed     string `json:"embed"`
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resp := make(map[string]greeting, len(kinds))
+		for _, kind := range kinds {
+			gm, err := s.db.GetGreetingMessage(guildID, kind)
+			if err != nil {
+				http.Error(w, "Failed to get greetings", http.StatusInternalServerError)
+				return
+			}
+			resp[kind] = greeting{ChannelID: gm.ChannelID, Content: gm.Content, Embed: gm.Embed}
+		}
+		s.jsonResponse(w, resp)
+	case http.MethodPost, http.MethodPut:
+		var req map[string]greeting
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		var messages []*database.GreetingMessage
+		for _, kind := range kinds {
+			g, ok := req[kind]
+			if !ok {
+				continue
+			}
+			gm := &database.GreetingMessage{GuildID: guildID, Kind: kind}
+			switch kind {
+			case database.GreetingLeave:
+				gm.ChannelID, gm.Content = g.ChannelID, g.Content
+			case database.GreetingLeaveKick, database.GreetingLeaveBan:
+				gm.Content = g.Content
+			}
+			if g.Embed != "" {
+				// Stored the same way the bot saves them, so anything it can't send is refused here
+				var embed discordgo.MessageEmbed
+				if err := json.Unmarshal([]byte(g.Embed), &embed); err != nil {
+					http.Error(w, "Invalid "+kind+" embed JSON", http.StatusBadRequest)
+					return
+				}
+				if embed.Title == "" && embed.Description == "" && len(embed.Fields) == 0 && embed.Image == nil {
+					http.Error(w, "The "+kind+" embed needs a title, description, field or image", http.StatusBadRequest)
+					return
+				}
+				embed.Type = discordgo.EmbedTypeRich
+				raw, _ := json.Marshal(&embed)
+				gm.Embed = string(raw)
+			}
+			messages = append(messages, gm)
+		}
+
+		for _, gm := range messages {
+			if err := s.db.SetGreetingMessage(gm); err != nil {
+				http.Error(w, "Failed to save greetings", http.StatusInternalServerError)
+				return
+			}
+		}
+		s.jsonResponse(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPIActivity returns a guild's daily activity, messages by hour of the day and
+// busiest channels for the dashboard charts. ?days= picks the period, 7 to 90 days.
+func (s *Server) handleAPIActivity(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/activity/"):]
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	days = min(max(days, 7), 90)
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+
+	rows, err := s.db.GetActivityDays(guildID, since)
+	if err != nil {
+		http.Error(w, "Failed to get activity", http.StatusInternalServerError)
+		return
+	}
+	hours, err := s.db.GetActivityHours(guildID, "", since)
+	if err != nil {
+		http.Error(w, "Failed to get activity", http.StatusInternalServerError)
+		return
+	}
+	top, err := s.db.GetTopActivityChannels(guildID, since, 10)
+	if err != nil {
+		http.Error(w, "Failed to get activity", http.StatusInternalServerError)
+		return
+	}
+
+	type day struct {
+		Day      string `json:"day"`
+		Messages int    `json:"messages"`
+		Joins    int    `json:"joins"`
+		Leaves   int    `json:"leaves"`
+	}
+	type channel struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Messages int    `json:"messages"`
+	}
+
+	// Days without activity have no row, fill them in so the chart has no gaps
+	byDay := make(map[string]database.ActivityDay, len(rows))
+	for _, row := range rows {
+		byDay[row.Day] = row
+	}
+	series := make([]day, days)
+	for n := range series {
+		label := now.AddDate(0, 0, n-(days-1)).Format("2006-01-02")
+		row := byDay[label]
+		series[n] = day{Day: label, Messages: row.Messages, Joins: row.Joins, Leaves: row.Leaves}
+	}
+
+	channels := make([]channel, 0, len(top))
+	for _, c := range top {
+		name := c.ID
+		if ch, err := s.session.State.Channel(c.ID); err == nil {
+			name = "#" + ch.Name
+		}
+		channels = append(channels, channel{ID: c.ID, Name: name, Messages: c.Messages})
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"days":     series,
+		"hours":    hours,
+		"channels": channels,
+	})
+}
+
+// handleAPIModStats exports moderation stats for the last N days (0 for all time) as JSON or CSV.
+// The JSON also has each moderator's actions per day so activity can be charted over time.
+func (s *Server) handleAPIModStats(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/modstats/"):]
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	days = max(days, 0)
+	var since int64
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days).UnixMilli()
+	}
+
+	stats, err := s.db.GetModStats(guildID, since, 0)
+	if err != nil {
+		http.Error(w, "Failed to get mod stats", http.StatusInternalServerError)
+		return
+	}
+
+	moderatorName := func(id string) string {
+		if member, err := s.session.State.Member(guildID, id); err == nil && member.User != nil {
+			return member.User.Username
+		}
+		return id
+	}
+
+	// Every action seen becomes a column, in a stable order
+	actions := make([]string, 0, len(stats.ActionCounts))
+	for action := range stats.ActionCounts {
+		actions = append(actions, action)
+	}
+	slices.Sort(actions)
+
+	filename := fmt.Sprintf("modstats-%s-%s", guildID, time.Now().UTC().Format("2006-01-02"))
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+
+		out := csv.NewWriter(w)
+		header := []string{"moderator_id", "moderator", "total", "previous_period"}
+		out.Write(append(header, actions...))
+		for _, mod := range stats.TopMods {
+			row := []string{mod.ModeratorID, moderatorName(mod.ModeratorID), strconv.Itoa(mod.Count), strconv.Itoa(mod.PreviousCount)}
+			for _, action := range actions {
+				row = append(row, strconv.Itoa(mod.Actions[action]))
+			}
+			out.Write(row)
+		}
+		out.Flush()
+		return
+	}
+
+	activity, err := s.db.GetModActivityByDay(guildID, since)
+	if err != nil {
+		http.Error(w, "Failed to get mod stats", http.StatusInternalServerError)
+		return
+	}
+
+	type moderator struct {
+		ID            string         `json:"id"`
+		Name          string         `json:"name"`
+		Total         int            `json:"total"`
+		PreviousTotal int            `json:"previous_period"`
+		Actions       map[string]int `json:"actions"`
+		Daily         map[string]int `json:"daily"`
+	}
+
+	daily := make(map[string]map[string]int)
+	for _, a := range activity {
+		if daily[a.ModeratorID] == nil {
+			daily[a.ModeratorID] = make(map[string]int)
+		}
+		daily[a.ModeratorID][a.Day] = a.Count
+	}
+
+	moderators := make([]moderator, 0, len(stats.TopMods))
+	for _, mod := range stats.TopMods {
+		moderators = append(moderators, moderator{
+			ID:            mod.ModeratorID,
+			Name:          moderatorName(mod.ModeratorID),
+			Total:         mod.Count,
+			PreviousTotal: mod.PreviousCount,
+			Actions:       mod.Actions,
+			Daily:         daily[mod.ModeratorID],
+		})
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+	s.jsonResponse(w, map[string]interface{}{
+		"days":          days,
+		"total_actions": stats.TotalActions,
+		"actions":       stats.ActionCounts,
+		"moderators":    moderators,
+	})
+}
+
+// handleAPITickets lists a guild's tickets with links to their transcripts
+func (s *Server) handleAPITickets(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/tickets/"):]
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	page, _ := strconv.Atoi(params.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(params.Get("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 25
+	}
+
+	tickets, err := s.db.GetGuildTickets(guildID, perPage, (page-1)*perPage)
+	if err != nil {
+		http.Error(w, "Failed to get tickets", http.StatusInternalServerError)
+		return
+	}
+
+	type ticketResult struct {
+		database.Ticket
+		TranscriptURL string `json:",omitempty"`
+	}
+	results := make([]ticketResult, 0, len(tickets))
+	for _, t := range tickets {
+		result := ticketResult{Ticket: t}
+		if t.TranscriptMessageID != "" {
+			result.TranscriptURL = fmt.Sprintf("https://discord.com/channels/%s/%s/%s", t.GuildID, t.TranscriptChannelID, t.TranscriptMessageID)
+		}
+		results = append(results, result)
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"tickets":  results,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
 // handleAPIRegexFilters handles regex filter configuration
 func (s *Server) handleAPIRegexFilters(w http.ResponseWriter, r *http.Request) {
 	guildID := r.URL.Path[len("/api/guild/regex/"):]
@@ -673,6 +1248,175 @@ func (s *Server) handleAPILevelRanks(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleAPIXPExclusions handles no-XP channels and roles
+func (s *Server) handleAPIXPExclusions(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/xpexclusions/"):]
+	switch r.Method {
+	case http.MethodGet:
+		exclusions, err := s.db.GetXPExclusions(guildID)
+		if err != nil {
+			http.Error(w, "Failed to get exclusions", http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, exclusions)
+	case http.MethodPost, http.MethodDelete:
+		var req struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if (req.Type != "channel" && req.Type != "role") || req.ID == "" {
+			http.Error(w, "Type must be channel or role", http.StatusBadRequest)
+			return
+		}
+		var err error
+		if r.Method == http.MethodPost {
+			err = s.db.AddXPExclusion(guildID, req.Type, req.ID)
+		} else {
+			_, err = s.db.RemoveXPExclusion(guildID, req.Type, req.ID)
+		}
+		if err != nil {
+			http.Error(w, "Failed to update exclusions", http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPIRolePanels handles role panel create/update/publish/delete
+func (s *Server) handleAPIRolePanels(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/rolepanels/"):]
+	switch r.Method {
+	case http.MethodGet:
+		panels, err := s.db.GetRolePanels(guildID)
+		if err != nil {
+			http.Error(w, "Failed to get role panels", http.StatusInternalServerError)
+			return
+		}
+		s.jsonResponse(w, panels)
+	case http.MethodPost:
+		var req struct {
+			database.RolePanel
+			Publish   bool   `json:"publish"`
+			ChannelID string `json:"channel_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		panel := req.RolePanel
+		panel.GuildID = guildID
+		if panel.Style != "select" {
+			panel.Style = "buttons"
+		}
+
+		if panel.ID == 0 {
+			if panel.Title == "" {
+				http.Error(w, "Title required", http.StatusBadRequest)
+				return
+			}
+			panel.CreatedBy = "web"
+			id, err := s.db.CreateRolePanel(&panel)
+			if err != nil {
+				http.Error(w, "Failed to create panel", http.StatusInternalServerError)
+				return
+			}
+			panel.ID = id
+		} else {
+			existing, err := s.db.GetRolePanel(panel.ID)
+			if err != nil || existing == nil || existing.GuildID != guildID {
+				http.Error(w, "Panel not found", http.StatusNotFound)
+				return
+			}
+			if err := s.db.UpdateRolePanel(&panel); err != nil {
+				http.Error(w, "Failed to save panel", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if req.Publish || req.ChannelID != "" {
+			if s.publishPanel == nil {
+				http.Error(w, "Bot is not available", http.StatusServiceUnavailable)
+				return
+			}
+			if err := s.publishPanel(panel.ID, req.ChannelID); err != nil {
+				http.Error(w, "Failed to post panel: "+err.Error(), http.StatusBadGateway)
+				return
+			}
+		}
+		s.jsonResponse(w, map[string]interface{}{"status": "ok", "id": panel.ID})
+	case http.MethodDelete:
+		var req struct {
+			ID int64 `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		panel, err := s.db.GetRolePanel(req.ID)
+		if err != nil || panel == nil || panel.GuildID != guildID {
+			http.Error(w, "Panel not found", http.StatusNotFound)
+			return
+		}
+		if err := s.db.DeleteRolePanel(guildID, req.ID); err != nil {
+			http.Error(w, "Failed to delete panel", http.StatusInternalServerError)
+			return
+		}
+		if panel.MessageID != "" {
+			s.session.ChannelMessageDelete(panel.ChannelID, panel.MessageID)
+		}
+		s.jsonResponse(w, map[string]string{"status": "ok"})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPIRolePanelRoles adds and removes roles on a role panel
+func (s *Server) handleAPIRolePanelRoles(w http.ResponseWriter, r *http.Request) {
+	guildID := r.URL.Path[len("/api/guild/rolepanelroles/"):]
+	var req database.RolePanelRole
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	panel, err := s.db.GetRolePanel(req.PanelID)
+	if err != nil || panel == nil || panel.GuildID != guildID {
+		http.Error(w, "Panel not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		if req.RoleID == "" || len(panel.Roles) >= 25 {
+			http.Error(w, "Role required (max 25 per panel)", http.StatusBadRequest)
+			return
+		}
+		if err := s.db.AddRolePanelRole(&req); err != nil {
+			http.Error(w, "Failed to add role", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		if _, err := s.db.RemoveRolePanelRole(req.PanelID, req.RoleID); err != nil {
+			http.Error(w, "Failed to remove role", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Keep a posted panel in sync
+	if panel.MessageID != "" && s.publishPanel != nil {
+		s.publishPanel(panel.ID, "")
+	}
+	s.jsonResponse(w, map[string]string{"status": "ok"})
+}
+
 // handleAPICommandConfig handles command enable/disable configuration
 func (s *Server) handleAPICommandConfig(w http.ResponseWriter, r *http.Request) {
 	guildID := r.URL.Path[len("/api/guild/commands/"):]
@@ -717,32 +1461,42 @@ func (s *Server) handleAPICommandsList(w http.ResponseWriter, r *http.Request) {
 	// Static list of commands by category - this matches the bot's actual commands
 	commands := map[string][]string{
 		"Admin": {"kick", "ban", "unban", "timeout", "untimeout", "purge", "slowmode",
-			"warn", "warnings", "clearwarnings", "lock", "unlock", "bans", "hackban",
-			"softban", "massrole", "chanlockdown", "chanunlock", "syncperms"},
-		"Info":          {"help", "botinfo", "serverinfo", "userinfo", "avatar", "roleinfo", "channelinfo", "emojiinfo", "inviteinfo", "roles", "membercount"},
-		"XP":            {"rank", "leaderboard", "xp", "setxp", "addxp", "removexp", "resetxp", "setlevel", "massaddxp"},
-		"Logging":       {"setlogchannel", "togglelogging", "logconfig", "disablechannellog", "enablechannellog", "logstatus"},
+			"warn", "warnings", "clearwarnings", "warning", "lock", "unlock", "bans", "hackban",
+			"softban", "massrole", "chanlockdown", "chanunlock", "syncperms", "clonechannel", "moddm", "reasonpreset", "autoslowmode", "serverbackup", "undo"},
+		"Info":          {"help", "botinfo", "serverinfo", "userinfo", "avatar", "roleinfo", "channelinfo", "emojiinfo", "inviteinfo", "roles", "membercount", "invites", "activity"},
+		"XP":            {"rank", "rankcard", "leaderboard", "xp", "setxp", "addxp", "removexp", "resetxp", "setlevel", "massaddxp", "xpmultiplier", "xpboost", "xpconfig", "season"},
+		"Logging":       {"setlogchannel", "togglelogging", "logconfig", "disablechannellog", "enablechannellog", "logstatus", "logsearch"},
 		"Filters":       {"addfilter", "removefilter", "listfilters", "testfilter"},
-		"Anti-Raid":     {"antiraid", "silence", "unsilence", "getraid"},
+		"Anti-Raid":     {"antiraid", "silence", "unsilence", "getraid", "blocklist"},
 		"Anti-Spam":     {"antispam"},
-		"Ranks":         {"addrank", "removerank", "listranks", "syncranks", "applyranks"},
+		"Ranks":         {"addrank", "removerank", "listranks", "syncranks", "applyranks", "rankconfig"},
 		"VoiceXP":       {"voicexp"},
 		"AutoClean":     {"autoclean", "setcleanmessage", "setcleanimage"},
-		"Ticket":        {"setticket", "disableticket", "ticketstatus", "ticket"},
-		"Settings":      {"setprefix", "setmodlog", "setwelcome", "disablewelcome", "settings", "setjoindm", "disablejoindm"},
-		"Moderation":    {"modstats", "spamfilter"},
+		"AutoThread":    {"autothread"},
+		"GitHub":        {"githubrelay"},
+		"Ticket":        {"setticket", "disableticket", "ticketstatus", "ticket", "ticketpanel"},
+		"Settings":      {"setprefix", "setmodlog", "setwelcome", "welcome", "welcomecard", "disablewelcome", "settings", "setjoindm", "disablejoindm", "statschannels"},
+		"Moderation":    {"modstats", "spamfilter", "aimod", "report", "reports", "archive", "notes"},
 		"DM":            {"setdmchannel", "disabledm", "dmstatus"},
 		"BotBan":        {"botban", "botunban", "botbanlist"},
-		"Misc":          {"snipe", "tag", "customcmd", "mentionresponse"},
-		"AI":            {"ai"},
+		"Roles":         {"reactionrole", "rolepanel", "stickyroles"},
+		"Suggestions":   {"suggest", "suggestion"},
+		"Quotes":        {"quote"},
+		"Birthdays":     {"birthday"},
+		"Events":        {"event"},
+		"Games":         {"game"},
+		"Economy":       {"balance", "daily", "work", "pay", "richest", "economy", "shop", "buy"},
+		"Achievements":  {"badges", "achievements"},
+		"Misc":          {"snipe", "editsnipe", "reactionsnipe", "tag", "customcmd", "mentionresponse"},
+		"AI":            {"ask", "ai", "summarize", "aiusage"},
 		"Fun":           {"8ball", "coinflip", "dice", "roll", "rps", "random", "joke", "rate", "ship", "iq", "gay", "pp", "hug", "slap", "pat", "kiss", "f", "choose"},
 		"Text":          {"ascii", "zalgo", "reverse", "upsidedown", "morse", "vaporwave", "owo", "mock", "leet", "regional", "spoiler", "space", "fancy", "encode", "decode", "codeblock", "hyperlink"},
-		"Random":        {"cat", "dog", "fox", "bird", "duck", "shiba", "meme", "quote", "fact", "advice", "dadjoke"},
+		"Random":        {"cat", "dog", "fox", "bird", "duck", "shiba", "meme", "inspire", "fact", "advice", "dadjoke"},
 		"Images":        {"resize", "rotate", "flip", "invert", "grayscale", "blur", "sharpen", "brightness", "contrast", "saturate"},
 		"Lookup":        {"steam", "minecraft", "npm", "pypi", "github", "weather", "urban", "define", "wikipedia", "anime", "manga"},
 		"Tools":         {"qr", "color", "math", "base64", "hash", "timestamp", "snowflake", "permissions", "ping", "uptime"},
-		"Utility":       {"afk", "remind", "poll", "giveaway", "timezone", "time", "countdown", "note", "notes", "deletenote"},
-		"Music":         {"play", "skip", "stop", "pause", "resume", "queue", "nowplaying", "volume", "shuffle", "loop", "clear", "remove", "move", "seek", "lyrics", "playlist"},
+		"Utility":       {"afk", "remind", "poll", "emoji", "giveaway", "timezone", "time", "countdown"},
+		"Music":         {"play", "playnext", "skip", "skipto", "stop", "pause", "resume", "queue", "nowplaying", "volume", "shuffle", "loop", "clear", "remove", "move", "seek", "forward", "rewind", "replay", "lyrics", "playlist", "247", "autoplay", "filter", "musicconfig", "normalize", "radio", "library", "sponsorblock", "tts", "ttsconfig"},
 		"Configuration": {"mentionresponse"},
 	}
 
@@ -763,9 +1517,19 @@ func (s *Server) handleAPIChannels(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Text channels by default, or voice channels with ?type=voice
+	wanted := func(t discordgo.ChannelType) bool {
+		return t == discordgo.ChannelTypeGuildText || t == discordgo.ChannelTypeGuildNews
+	}
+	if r.URL.Query().Get("type") == "voice" {
+		wanted = func(t discordgo.ChannelType) bool {
+			return t == discordgo.ChannelTypeGuildVoice || t == discordgo.ChannelTypeGuildStageVoice
+		}
+	}
+
 	var channels []map[string]interface{}
 	for _, ch := range guild.Channels {
-		if ch.Type == discordgo.ChannelTypeGuildText || ch.Type == discordgo.ChannelTypeGuildNews {
+		if wanted(ch.Type) {
 			channels = append(channels, map[string]interface{}{
 				"id":   ch.ID,
 				"name": ch.Name,
@@ -912,6 +1676,50 @@ func (s *Server) handleAPIStatsDatabase(w http.ResponseWriter, r *http.Request)
 	s.jsonResponse(w, dbStats)
 }
 
+// handleAPIStatsCommands returns per-command usage, error rates and latency from command history
+func (s *Server) handleAPIStatsCommands(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	days = min(max(days, 1), 90)
+
+	stats, err := s.db.GetCommandUsageStats(r.URL.Query().Get("guild"), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		http.Error(w, "Failed to get command stats", http.StatusInternalServerError)
+		return
+	}
+
+	type command struct {
+		Command   string  `json:"command"`
+		Uses      int     `json:"uses"`
+		Failures  int     `json:"failures"`
+		ErrorRate float64 `json:"error_rate"`
+		AvgMs     int64   `json:"avg_ms"`
+		P95Ms     int64   `json:"p95_ms"`
+		LastUsed  string  `json:"last_used"`
+	}
+	commands := make([]command, 0, len(stats))
+	for _, stat := range stats {
+		commands = append(commands, command{
+			Command:   stat.Command,
+			Uses:      stat.Uses,
+			Failures:  stat.Failures,
+			ErrorRate: stat.ErrorRate(),
+			AvgMs:     stat.AvgMs,
+			P95Ms:     stat.P95Ms,
+			LastUsed:  stat.LastUsed.Format(time.RFC3339),
+		})
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"days":     days,
+		"commands": commands,
+	})
+}
+
 // jsonResponse sends a JSON response
 func (s *Server) jsonResponse(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -1171,6 +1979,10 @@ const dashboardHTML = `<!DOCTYPE html>
             border-radius: 10px;
         }
         .db-stats h4 { margin-bottom: 15px; }
+        .usage-table { width: 100%; border-collapse: collapse; font-size: 14px; }
+        .usage-table th, .usage-table td { padding: 8px 10px; text-align: left; border-bottom: 1px solid var(--bg-card); }
+        .usage-table th { color: var(--text-secondary); font-weight: 600; }
+        .usage-table tr.failing td { color: #ff6b6b; }
         .db-stats-grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(150px, 1fr)); gap: 10px; }
         .db-stat-item {
             background: var(--bg-card);
@@ -1213,6 +2025,7 @@ const dashboardHTML = `<!DOCTYPE html>
         <div class="main-tabs">
             <button class="main-tab active" onclick="switchMainTab('servers')">Servers</button>
             <button class="main-tab" onclick="switchMainTab('stats')">Live Stats</button>
+            <button class="main-tab" onclick="switchMainTab('usage')">Command Usage</button>
         </div>
         <div id="servers-content" class="main-content active">
             <div class="stats-grid">
@@ -1253,6 +2066,28 @@ const dashboardHTML = `<!DOCTYPE html>
                 <div class="db-stats-grid" id="db-stats-grid"><div class="loading">Loading...</div></div>
             </div>
         </div>
+        <div id="usage-content" class="main-content">
+            <div style="display:flex;justify-content:space-between;align-items:center;margin-bottom:15px;">
+                <div class="section-title" style="margin:0;">Command Usage (all servers)</div>
+                <select id="usage-days" onchange="fetchCommandUsage()">
+                    <option value="1">24 hours</option>
+                    <option value="7" selected>7 days</option>
+                    <option value="30">30 days</option>
+                    <option value="90">90 days</option>
+                </select>
+            </div>
+            <div class="stats-grid">
+                <div class="stat-card"><h3>Uses</h3><div class="value" id="usage-total">-</div></div>
+                <div class="stat-card"><h3>Failed</h3><div class="value" id="usage-failed">-</div></div>
+                <div class="stat-card"><h3>Commands Used</h3><div class="value" id="usage-count">-</div></div>
+            </div>
+            <div class="db-stats">
+                <table class="usage-table">
+                    <thead><tr><th>Command</th><th>Uses</th><th>Failed</th><th>Error Rate</th><th>Avg</th><th>p95</th><th>Last Used</th></tr></thead>
+                    <tbody id="usage-rows"><tr><td colspan="7" class="loading">Loading...</td></tr></tbody>
+                </table>
+            </div>
+        </div>
     </main>
     <div id="guild-modal" class="modal">
         <div class="modal-content">
@@ -1268,6 +2103,7 @@ const dashboardHTML = `<!DOCTYPE html>
                 <div class="tab" data-tab="xp">XP & Ranks</div>
                 <div class="tab" data-tab="features">Features</div>
                 <div class="tab" data-tab="commands">Commands</div>
+                <div class="tab" data-tab="activity">Activity</div>
             </div>
             <div id="tab-basic" class="tab-content active">
                 <div class="section-title">General Settings</div>
@@ -1280,9 +2116,20 @@ const dashboardHTML = `<!DOCTYPE html>
                     <div class="form-group"><label>Welcome Channel</label><select id="setting-welcome-channel"><option value="">Disabled</option></select></div>
                 </div>
                 <div class="form-group"><label>Welcome Message (use {user}, {username}, {server})</label><textarea id="setting-welcome-message" placeholder="Welcome to {server}, {user}!"></textarea></div>
+                <div class="form-group"><label>Welcome Embed JSON (optional, sent with the message)</label><textarea id="setting-welcome-embed" placeholder='{"title": "Welcome, {username}!"}'></textarea></div>
+                <div class="section-title">Leave Messages</div>
+                <div class="form-row">
+                    <div class="form-group"><label>Leave Channel</label><select id="setting-leave-channel"><option value="">Disabled</option></select></div>
+                </div>
+                <div class="form-group"><label>Leave Message (use {user}, {username}, {userid}, {membercount}, {duration})</label><textarea id="setting-leave-message" placeholder="**{username}** has left the server."></textarea></div>
+                <div class="form-group"><label>Leave Embed JSON (optional)</label><textarea id="setting-leave-embed"></textarea></div>
+                <div class="form-group"><label>Kick Message (optional, also {moderator} and {reason})</label><textarea id="setting-leave-kick-message" placeholder="**{username}** was kicked."></textarea></div>
+                <div class="form-group"><label>Ban Message (optional, also {moderator} and {reason})</label><textarea id="setting-leave-ban-message" placeholder="**{username}** was banned."></textarea></div>
                 <div class="section-title">Join DM</div>
                 <div class="form-group"><label>DM Title</label><input type="text" id="setting-joindm-title" placeholder="Welcome!"></div>
                 <div class="form-group"><label>DM Message</label><textarea id="setting-joindm-message" placeholder="Thanks for joining {server}!"></textarea></div>
+                <div class="form-group"><label>DM Embed JSON (optional, replaces the title and message)</label><textarea id="setting-joindm-embed"></textarea></div>
+                <div style="font-size:12px;color:var(--text-secondary);">Embeds can be built with Discohook and previewed with <code>/welcome test</code>.</div>
                 <div style="display:flex;gap:10px;justify-content:flex-end;margin-top:20px;">
                     <button class="btn btn-primary" onclick="saveBasicSettings()">Save Settings</button>
                 </div>
@@ -1292,12 +2139,37 @@ const dashboardHTML = `<!DOCTYPE html>
                 <div class="form-row">
                     <div class="form-group"><label>Log Channel</label><select id="logging-channel"><option value="">None</option></select></div>
                 </div>
+                <div class="form-row">
+                    <div class="form-group"><label>Message Logs</label><select id="logging-message-channel"><option value="">Default</option></select></div>
+                    <div class="form-group"><label>Member Logs</label><select id="logging-member-channel"><option value="">Default</option></select></div>
+                    <div class="form-group"><label>Voice Logs</label><select id="logging-voice-channel"><option value="">Default</option></select></div>
+                </div>
+                <div class="form-row">
+                    <div class="form-group"><label>Mod Logs</label><select id="logging-mod-channel"><option value="">Default</option></select></div>
+                    <div class="form-group"><label>Automod Logs</label><select id="logging-automod-channel"><option value="">Default</option></select></div>
+                </div>
                 <div class="toggle-row"><span>Logging Enabled</span><div class="toggle" id="logging-enabled" onclick="toggleSwitch(this)"></div></div>
                 <div class="toggle-row"><span>Message Deletes</span><div class="toggle" id="logging-delete" onclick="toggleSwitch(this)"></div></div>
                 <div class="toggle-row"><span>Message Edits</span><div class="toggle" id="logging-edit" onclick="toggleSwitch(this)"></div></div>
                 <div class="toggle-row"><span>Voice Join</span><div class="toggle" id="logging-voicejoin" onclick="toggleSwitch(this)"></div></div>
                 <div class="toggle-row"><span>Voice Leave</span><div class="toggle" id="logging-voiceleave" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Voice Move</span><div class="toggle" id="logging-voicemove" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Voice Mute/Deafen</span><div class="toggle" id="logging-voicemute" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Voice Stream/Video</span><div class="toggle" id="logging-voicestream" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Voice Session Duration</span><div class="toggle" id="logging-voicesession" onclick="toggleSwitch(this)"></div></div>
                 <div class="toggle-row"><span>Nickname Changes</span><div class="toggle" id="logging-nickname" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Role Changes</span><div class="toggle" id="logging-rolechange" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Bans &amp; Kicks</span><div class="toggle" id="logging-moderation" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Archive Deleted Attachments</span><div class="toggle" id="logging-attachments" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Deliver via Webhooks</span><div class="toggle" id="logging-webhooks" onclick="toggleSwitch(this)"></div></div>
+                <div class="section-title">Moderation Stats</div>
+                <div class="form-row">
+                    <div class="form-group"><label>Range</label><select id="modstats-days"><option value="7">Last 7 days</option><option value="30" selected>Last 30 days</option><option value="90">Last 90 days</option><option value="0">All time</option></select></div>
+                </div>
+                <div style="display:flex;gap:10px;margin-bottom:20px;">
+                    <button class="btn btn-secondary" onclick="exportModStats('csv')">Export CSV</button>
+                    <button class="btn btn-secondary" onclick="exportModStats('json')">Export JSON</button>
+                </div>
                 <div class="section-title">Anti-Raid</div>
                 <div class="toggle-row"><span>Anti-Raid Enabled</span><div class="toggle" id="antiraid-enabled" onclick="toggleSwitch(this)"></div></div>
                 <div class="form-row">
@@ -1353,9 +2225,23 @@ const dashboardHTML = `<!DOCTYPE html>
                     <div class="form-group"><label>Interval (minutes)</label><input type="number" id="voicexp-interval" min="1" max="60" value="5"></div>
                 </div>
                 <div class="toggle-row"><span>Ignore AFK Channel</span><div class="toggle" id="voicexp-ignoreafk" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Ignore Members Alone in a Channel</span><div class="toggle" id="voicexp-ignorealone" onclick="toggleSwitch(this)"></div></div>
+                <div class="toggle-row"><span>Ignore Deafened Members</span><div class="toggle" id="voicexp-ignoredeafened" onclick="toggleSwitch(this)"></div></div>
+                <div class="form-row">
+                    <div class="form-group"><label>Daily Cap (0 = no cap)</label><input type="number" id="voicexp-dailycap" min="0" value="0"></div>
+                    <div class="form-group"><label>Excluded Channels (Ctrl+click to select several)</label><select id="voicexp-excluded" multiple size="4"></select></div>
+                </div>
                 <div style="display:flex;gap:10px;justify-content:flex-end;margin-top:15px;">
                     <button class="btn btn-primary" onclick="saveVoiceXPSettings()">Save Voice XP</button>
                 </div>
+                <div class="section-title">No-XP Channels &amp; Roles</div>
+                <div class="add-form">
+                    <select id="xpexclude-channel"><option value="">Select Channel</option></select>
+                    <button class="btn btn-primary btn-sm" onclick="addXPExclusion('channel')">Exclude Channel</button>
+                    <select id="xpexclude-role"><option value="">Select Role</option></select>
+                    <button class="btn btn-primary btn-sm" onclick="addXPExclusion('role')">Exclude Role</button>
+                </div>
+                <div id="xpexclusions-list"></div>
                 <div class="section-title">Level Ranks (Role Rewards)</div>
                 <div class="add-form">
                     <select id="rank-role"><option value="">Select Role</option></select>
@@ -1376,9 +2262,73 @@ const dashboardHTML = `<!DOCTYPE html>
                 <div class="section-title">Ticket System</div>
                 <div class="toggle-row"><span>Tickets Enabled</span><div class="toggle" id="ticket-enabled" onclick="toggleSwitch(this)"></div></div>
                 <div class="form-group"><label>Ticket Channel</label><select id="ticket-channel"><option value="">Select Channel</option></select></div>
+                <div class="form-group"><label>Transcript Channel</label><select id="ticket-transcript-channel"><option value="">DM to opener only</option></select></div>
+                <div class="form-row">
+                    <div class="form-group"><label>Auto-Close After (hours inactive, 0 = off)</label><input type="number" id="ticket-inactive-hours" min="0" max="720" value="0"></div>
+                    <div class="form-group"><label>Warning Before Close (hours)</label><input type="number" id="ticket-inactive-grace" min="1" max="168" value="24"></div>
+                </div>
+                <div class="form-group"><label>Open Tickets As</label><select id="ticket-mode"><option value="thread">Private Threads</option><option value="channel">Channels</option></select></div>
                 <div style="display:flex;gap:10px;justify-content:flex-end;margin-top:15px;">
                     <button class="btn btn-primary" onclick="saveTicketSettings()">Save Ticket Settings</button>
                 </div>
+                <div class="form-group"><label>Recent Tickets</label></div>
+                <div id="tickets-list"></div>
+                <div class="section-title">Economy</div>
+                <div class="toggle-row"><span>Economy Enabled</span><div class="toggle" id="economy-enabled" onclick="toggleSwitch(this)"></div></div>
+                <div class="form-row">
+                    <div class="form-group"><label>Currency Name</label><input type="text" id="economy-name" maxlength="32" value="coins"></div>
+                    <div class="form-group"><label>Currency Symbol</label><input type="text" id="economy-symbol" maxlength="64" value="🪙"></div>
+                </div>
+                <div class="form-row">
+                    <div class="form-group"><label>Daily Reward</label><input type="number" id="economy-daily" min="0" value="100"></div>
+                    <div class="form-group"><label>Work Min</label><input type="number" id="economy-workmin" min="0" value="20"></div>
+                    <div class="form-group"><label>Work Max</label><input type="number" id="economy-workmax" min="0" value="80"></div>
+                    <div class="form-group"><label>Work Cooldown (minutes)</label><input type="number" id="economy-workcooldown" min="1" value="60"></div>
+                </div>
+                <div class="form-row">
+                    <div class="form-group"><label>Chat Reward (per message)</label><input type="number" id="economy-chat" min="0" value="2"></div>
+                    <div class="form-group"><label>Chat Cooldown (seconds)</label><input type="number" id="economy-chatcooldown" min="0" value="60"></div>
+                </div>
+                <div style="display:flex;gap:10px;justify-content:flex-end;margin-top:15px;">
+                    <button class="btn btn-primary" onclick="saveEconomySettings()">Save Economy</button>
+                </div>
+                <div class="section-title">Music</div>
+                <div class="toggle-row"><span>Volume Normalization</span><div class="toggle" id="music-normalize" onclick="toggleSwitch(this)"></div></div>
+                <div class="form-group"><label>Music Command Channels (Ctrl+click to select several, none = any channel)</label><select id="music-channels" multiple size="4"></select></div>
+                <div class="form-row">
+                    <div class="form-group"><label>Max Queue Length (0 = no limit)</label><input type="number" id="music-maxqueue" min="0" max="1000" value="0"></div>
+                    <div class="form-group"><label>Max Track Length (minutes, 0 = no limit)</label><input type="number" id="music-maxduration" min="0" max="600" value="0"></div>
+                    <div class="form-group"><label>Max Tracks per Member (0 = no limit)</label><input type="number" id="music-maxuser" min="0" max="100" value="0"></div>
+                </div>
+                <div class="form-row">
+                    <div class="form-group"><label>Playlists per Member</label><input type="number" id="music-playlists" min="1" max="100" value="10"></div>
+                    <div class="form-group"><label>Tracks per Playlist</label><input type="number" id="music-playlisttracks" min="1" max="500" value="100"></div>
+                </div>
+                <div style="display:flex;gap:10px;justify-content:flex-end;margin-top:15px;">
+                    <button class="btn btn-primary" onclick="saveMusicSettings()">Save Music</button>
+                </div>
+                <div class="section-title">Role Panels</div>
+                <div class="add-form">
+                    <input type="text" id="rolepanel-title" placeholder="Panel title" maxlength="256">
+                    <select id="rolepanel-style"><option value="buttons">Buttons</option><option value="select">Select Menu</option></select>
+                    <input type="number" id="rolepanel-max" placeholder="Max roles (0 = no limit)" min="0" max="25" value="0">
+                    <button class="btn btn-primary btn-sm" onclick="createRolePanel()">Create Panel</button>
+                </div>
+                <div id="rolepanels-list"></div>
+            </div>
+            <div id="tab-activity" class="tab-content">
+                <div style="display:flex;justify-content:space-between;align-items:center;margin-bottom:15px;">
+                    <div class="section-title" style="margin:0;">Server Activity (UTC)</div>
+                    <select id="activity-days" onchange="loadActivity()">
+                        <option value="7">7 days</option>
+                        <option value="30" selected>30 days</option>
+                        <option value="90">90 days</option>
+                    </select>
+                </div>
+                <div class="chart-container"><h4>Messages per Day</h4><div class="chart-wrapper"><canvas id="activity-daily-chart"></canvas></div></div>
+                <div class="chart-container"><h4>Joins and Leaves</h4><div class="chart-wrapper"><canvas id="activity-members-chart"></canvas></div></div>
+                <div class="chart-container"><h4>Messages by Hour</h4><div class="chart-wrapper"><canvas id="activity-hours-chart"></canvas></div></div>
+                <div class="chart-container"><h4>Busiest Channels</h4><div class="chart-wrapper"><canvas id="activity-channels-chart"></canvas></div></div>
             </div>
             <div id="tab-commands" class="tab-content">
                 <div class="section-title">Command Categories</div>
@@ -1461,12 +2411,12 @@ const dashboardHTML = `<!DOCTYPE html>
             } catch (err) { console.error('Failed to fetch channels/roles:', err); }
 
             // Populate channel selects
-            ['setting-modlog', 'setting-welcome-channel', 'logging-channel', 'antiraid-alertchannel', 'autoclean-channel', 'ticket-channel'].forEach(id => {
+            ['setting-modlog', 'setting-welcome-channel', 'setting-leave-channel', 'logging-channel', 'logging-message-channel', 'logging-member-channel', 'logging-voice-channel', 'logging-mod-channel', 'logging-automod-channel', 'antiraid-alertchannel', 'autoclean-channel', 'ticket-channel', 'ticket-transcript-channel', 'xpexclude-channel'].forEach(id => {
                 populateSelect(id, channels, 'id', 'name', null);
             });
 
             // Populate role selects
-            ['antiraid-silentrole', 'antispam-silentrole', 'rank-role'].forEach(id => {
+            ['antiraid-silentrole', 'antispam-silentrole', 'rank-role', 'xpexclude-role'].forEach(id => {
                 populateSelect(id, roles.filter(r => r.name !== '@everyone'), 'id', 'name', null);
             });
 
@@ -1477,8 +2427,9 @@ const dashboardHTML = `<!DOCTYPE html>
 
         async function loadAllSettings() {
             try {
-                const [basic, logging, antiraid, antispam, spamfilter, voicexp, ticket, filters, ranks, autoclean, commands] = await Promise.all([
+                const [basic, greetings, logging, antiraid, antispam, spamfilter, voicexp, ticket, filters, ranks, autoclean, commands] = await Promise.all([
                     fetch('/api/guild/settings/' + currentGuildId).then(r => r.json()),
+                    fetch('/api/guild/greetings/' + currentGuildId).then(r => r.json()),
                     fetch('/api/guild/logging/' + currentGuildId).then(r => r.json()),
                     fetch('/api/guild/antiraid/' + currentGuildId).then(r => r.json()),
                     fetch('/api/guild/antispam/' + currentGuildId).then(r => r.json()),
@@ -1498,15 +2449,37 @@ const dashboardHTML = `<!DOCTYPE html>
                 document.getElementById('setting-welcome-message').value = basic.WelcomeMessage || '';
                 document.getElementById('setting-joindm-title').value = basic.JoinDMTitle || '';
                 document.getElementById('setting-joindm-message').value = basic.JoinDMMessage || '';
+                document.getElementById('setting-welcome-embed').value = prettyEmbed(greetings.welcome.embed);
+                document.getElementById('setting-leave-channel').value = greetings.leave.channel_id || '';
+                document.getElementById('setting-leave-message').value = greetings.leave.content || '';
+                document.getElementById('setting-leave-embed').value = prettyEmbed(greetings.leave.embed);
+                document.getElementById('setting-leave-kick-message').value = greetings.leave_kick.content || '';
+                document.getElementById('setting-leave-ban-message').value = greetings.leave_ban.content || '';
+                // Kick and ban embeds are only edited from Discord, keep them when saving
+                greetingEmbeds = {leave_kick: greetings.leave_kick.embed || '', leave_ban: greetings.leave_ban.embed || ''};
+                document.getElementById('setting-joindm-embed').value = prettyEmbed(greetings.joindm.embed);
 
                 // Logging
                 document.getElementById('logging-channel').value = logging.LogChannelID || '';
+                document.getElementById('logging-message-channel').value = logging.MessageLogChannelID || '';
+                document.getElementById('logging-member-channel').value = logging.MemberLogChannelID || '';
+                document.getElementById('logging-voice-channel').value = logging.VoiceLogChannelID || '';
+                document.getElementById('logging-mod-channel').value = logging.ModLogChannelID || '';
+                document.getElementById('logging-automod-channel').value = logging.AutomodLogChannelID || '';
                 setToggle('logging-enabled', logging.Enabled);
                 setToggle('logging-delete', logging.MessageDelete);
                 setToggle('logging-edit', logging.MessageEdit);
                 setToggle('logging-voicejoin', logging.VoiceJoin);
                 setToggle('logging-voiceleave', logging.VoiceLeave);
+                setToggle('logging-voicemove', logging.VoiceMove);
+                setToggle('logging-voicemute', logging.VoiceMute);
+                setToggle('logging-voicestream', logging.VoiceStream);
+                setToggle('logging-voicesession', logging.VoiceSession);
                 setToggle('logging-nickname', logging.NicknameChange);
+                setToggle('logging-rolechange', logging.RoleChange);
+                setToggle('logging-moderation', logging.Moderation);
+                setToggle('logging-attachments', logging.ArchiveAttachments);
+                setToggle('logging-webhooks', logging.UseWebhooks);
 
                 // Anti-Raid
                 setToggle('antiraid-enabled', antiraid.Enabled);
@@ -1537,10 +2510,28 @@ const dashboardHTML = `<!DOCTYPE html>
                 document.getElementById('voicexp-rate').value = voicexp.XPRate || 10;
                 document.getElementById('voicexp-interval').value = voicexp.IntervalMins || 5;
                 setToggle('voicexp-ignoreafk', voicexp.IgnoreAFK);
+                setToggle('voicexp-ignorealone', voicexp.IgnoreAlone);
+                setToggle('voicexp-ignoredeafened', voicexp.IgnoreDeafened);
+                document.getElementById('voicexp-dailycap').value = voicexp.DailyCap || 0;
+                const voiceChannels = await fetch('/api/channels/' + currentGuildId + '?type=voice').then(r => r.json());
+                const excludedSelect = document.getElementById('voicexp-excluded');
+                excludedSelect.innerHTML = '';
+                (voiceChannels || []).forEach(c => {
+                    const opt = document.createElement('option');
+                    opt.value = c.id;
+                    opt.textContent = c.name;
+                    opt.selected = (voicexp.ExcludedChannels || []).includes(c.id);
+                    excludedSelect.appendChild(opt);
+                });
 
                 // Ticket
                 setToggle('ticket-enabled', ticket.enabled || ticket.Enabled);
                 document.getElementById('ticket-channel').value = ticket.channel_id || ticket.ChannelID || '';
+                document.getElementById('ticket-mode').value = ticket.Mode || 'thread';
+                document.getElementById('ticket-transcript-channel').value = ticket.TranscriptChannelID || '';
+                document.getElementById('ticket-inactive-hours').value = ticket.InactiveHours || 0;
+                document.getElementById('ticket-inactive-grace').value = ticket.InactiveGraceHours || 24;
+                loadTickets();
 
                 // Filters
                 renderFilters(filters || []);
@@ -1551,6 +2542,43 @@ const dashboardHTML = `<!DOCTYPE html>
                 // Auto-Clean
                 renderAutoClean(autoclean || []);
 
+                // No-XP channels and roles
+                const xpExclusions = await fetch('/api/guild/xpexclusions/' + currentGuildId).then(r => r.json());
+                renderXPExclusions(xpExclusions || []);
+
+                // Economy
+                const economy = await fetch('/api/guild/economy/' + currentGuildId).then(r => r.json());
+                setToggle('economy-enabled', economy.Enabled);
+                document.getElementById('economy-name').value = economy.CurrencyName || 'coins';
+                document.getElementById('economy-symbol').value = economy.CurrencySymbol || '';
+                document.getElementById('economy-daily').value = economy.DailyAmount;
+                document.getElementById('economy-workmin').value = economy.WorkMin;
+                document.getElementById('economy-workmax').value = economy.WorkMax;
+                document.getElementById('economy-workcooldown').value = economy.WorkCooldownMins;
+                document.getElementById('economy-chat').value = economy.ChatAmount;
+                document.getElementById('economy-chatcooldown').value = economy.ChatCooldownSecs;
+
+                // Music
+                const music = await fetch('/api/guild/music/' + currentGuildId).then(r => r.json());
+                setToggle('music-normalize', music.Normalize);
+                document.getElementById('music-maxqueue').value = music.MaxQueueLength;
+                document.getElementById('music-maxduration').value = Math.round(music.MaxTrackDuration / 60);
+                document.getElementById('music-maxuser').value = music.MaxUserTracks;
+                document.getElementById('music-playlists').value = music.PlaylistLimit;
+                document.getElementById('music-playlisttracks').value = music.PlaylistTrackLimit;
+                const musicSelect = document.getElementById('music-channels');
+                musicSelect.innerHTML = '';
+                channels.forEach(c => {
+                    const opt = document.createElement('option');
+                    opt.value = c.id;
+                    opt.textContent = c.name;
+                    opt.selected = (music.TextChannels || []).includes(c.id);
+                    musicSelect.appendChild(opt);
+                });
+
+                // Role Panels
+                await loadRolePanels();
+
                 // Commands
                 disabledCommands = commands.disabled_commands || [];
                 disabledCategories = commands.disabled_categories || [];
@@ -1573,6 +2601,54 @@ const dashboardHTML = `<!DOCTYPE html>
             document.querySelectorAll('.tab-content').forEach(t => t.classList.remove('active'));
             document.querySelector(` + "`" + `.tab[data-tab="${tabName}"]` + "`" + `).classList.add('active');
             document.getElementById('tab-' + tabName).classList.add('active');
+            if (tabName === 'activity') loadActivity();
+        }
+
+        let activityCharts = [];
+
+        async function loadActivity() {
+            if (!chartJsLoaded) await loadChartJs();
+            try {
+                const days = document.getElementById('activity-days').value;
+                const data = await fetch('/api/guild/activity/' + currentGuildId + '?days=' + days).then(r => r.json());
+
+                activityCharts.forEach(c => c.destroy());
+                const options = {
+                    responsive: true,
+                    maintainAspectRatio: false,
+                    plugins: { legend: { labels: { color: '#a0a0a0' } } },
+                    scales: {
+                        x: { ticks: { color: '#a0a0a0' }, grid: { color: '#2a2a4e' } },
+                        y: { beginAtZero: true, ticks: { color: '#a0a0a0' }, grid: { color: '#2a2a4e' } }
+                    }
+                };
+                const labels = data.days.map(d => d.day.slice(5));
+                activityCharts = [
+                    new Chart(document.getElementById('activity-daily-chart'), {
+                        type: 'line',
+                        data: { labels, datasets: [{ label: 'Messages', data: data.days.map(d => d.messages), borderColor: '#5865F2', backgroundColor: 'rgba(88, 101, 242, 0.1)', fill: true, tension: 0.3 }] },
+                        options
+                    }),
+                    new Chart(document.getElementById('activity-members-chart'), {
+                        type: 'bar',
+                        data: { labels, datasets: [
+                            { label: 'Joins', data: data.days.map(d => d.joins), backgroundColor: '#57F287' },
+                            { label: 'Leaves', data: data.days.map(d => d.leaves), backgroundColor: '#e94560' }
+                        ] },
+                        options
+                    }),
+                    new Chart(document.getElementById('activity-hours-chart'), {
+                        type: 'bar',
+                        data: { labels: data.hours.map((_, h) => String(h).padStart(2, '0') + ':00'), datasets: [{ label: 'Messages', data: data.hours, backgroundColor: '#5865F2' }] },
+                        options
+                    }),
+                    new Chart(document.getElementById('activity-channels-chart'), {
+                        type: 'bar',
+                        data: { labels: data.channels.map(c => c.name), datasets: [{ label: 'Messages', data: data.channels.map(c => c.messages), backgroundColor: '#FEE75C' }] },
+                        options: { ...options, indexAxis: 'y' }
+                    })
+                ];
+            } catch (err) { showToast('Failed to load activity', true); }
         }
 
         document.querySelectorAll('.tab').forEach(tab => {
@@ -1595,25 +2671,60 @@ const dashboardHTML = `<!DOCTYPE html>
                 JoinDMTitle: document.getElementById('setting-joindm-title').value || null,
                 JoinDMMessage: document.getElementById('setting-joindm-message').value || null
             };
+            const greetings = {
+                welcome: {embed: document.getElementById('setting-welcome-embed').value.trim()},
+                leave: {
+                    channel_id: document.getElementById('setting-leave-channel').value,
+                    content: document.getElementById('setting-leave-message').value,
+                    embed: document.getElementById('setting-leave-embed').value.trim()
+                },
+                leave_kick: {content: document.getElementById('setting-leave-kick-message').value, embed: greetingEmbeds.leave_kick},
+                leave_ban: {content: document.getElementById('setting-leave-ban-message').value, embed: greetingEmbeds.leave_ban},
+                joindm: {embed: document.getElementById('setting-joindm-embed').value.trim()}
+            };
             try {
                 const res = await fetch('/api/guild/settings/' + currentGuildId, {
                     method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(settings)
                 });
-                if (res.ok) showToast('Basic settings saved!');
-                else showToast('Failed to save', true);
+                if (!res.ok) { showToast('Failed to save', true); return; }
+                const greetRes = await fetch('/api/guild/greetings/' + currentGuildId, {
+                    method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(greetings)
+                });
+                if (greetRes.ok) showToast('Basic settings saved!');
+                else showToast(await greetRes.text(), true);
             } catch (err) { showToast('Error saving settings', true); }
         }
 
+        let greetingEmbeds = {};
+
+        function prettyEmbed(raw) {
+            if (!raw) return '';
+            try { return JSON.stringify(JSON.parse(raw), null, 2); } catch (err) { return raw; }
+        }
+
         async function saveModerationSettings() {
             const loggingCh = document.getElementById('logging-channel').value;
             const logging = {
                 LogChannelID: loggingCh || null,
+                MessageLogChannelID: document.getElementById('logging-message-channel').value || null,
+                MemberLogChannelID: document.getElementById('logging-member-channel').value || null,
+                VoiceLogChannelID: document.getElementById('logging-voice-channel').value || null,
+                ModLogChannelID: document.getElementById('logging-mod-channel').value || null,
+                AutomodLogChannelID: document.getElementById('logging-automod-channel').value || null,
                 Enabled: getToggle('logging-enabled'),
                 MessageDelete: getToggle('logging-delete'),
                 MessageEdit: getToggle('logging-edit'),
                 VoiceJoin: getToggle('logging-voicejoin'),
                 VoiceLeave: getToggle('logging-voiceleave'),
+                VoiceMove: getToggle('logging-voicemove'),
+                VoiceMute: getToggle('logging-voicemute'),
+                VoiceStream: getToggle('logging-voicestream'),
+                VoiceSession: getToggle('logging-voicesession'),
                 NicknameChange: getToggle('logging-nickname'),
+                RoleChange: getToggle('logging-rolechange'),
+                Moderation: getToggle('logging-moderation'),
+                ArchiveAttachments: getToggle('logging-attachments'),
+                UseWebhooks: getToggle('logging-webhooks'),
                 AvatarChange: false,
                 PresenceChange: false,
                 PresenceBatchMins: 5
@@ -1661,7 +2772,11 @@ const dashboardHTML = `<!DOCTYPE html>
                 Enabled: getToggle('voicexp-enabled'),
                 XPRate: parseInt(document.getElementById('voicexp-rate').value),
                 IntervalMins: parseInt(document.getElementById('voicexp-interval').value),
-                IgnoreAFK: getToggle('voicexp-ignoreafk')
+                IgnoreAFK: getToggle('voicexp-ignoreafk'),
+                IgnoreAlone: getToggle('voicexp-ignorealone'),
+                IgnoreDeafened: getToggle('voicexp-ignoredeafened'),
+                DailyCap: parseInt(document.getElementById('voicexp-dailycap').value) || 0,
+                ExcludedChannels: Array.from(document.getElementById('voicexp-excluded').selectedOptions).map(o => o.value)
             };
             try {
                 const res = await fetch('/api/guild/voicexp/' + currentGuildId, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(config)});
@@ -1670,8 +2785,44 @@ const dashboardHTML = `<!DOCTYPE html>
             } catch (err) { showToast('Error saving', true); }
         }
 
+        async function saveMusicSettings() {
+            const config = {
+                Normalize: getToggle('music-normalize'),
+                MaxQueueLength: parseInt(document.getElementById('music-maxqueue').value) || 0,
+                MaxTrackDuration: (parseInt(document.getElementById('music-maxduration').value) || 0) * 60,
+                MaxUserTracks: parseInt(document.getElementById('music-maxuser').value) || 0,
+                PlaylistLimit: parseInt(document.getElementById('music-playlists').value) || 10,
+                PlaylistTrackLimit: parseInt(document.getElementById('music-playlisttracks').value) || 100,
+                TextChannels: Array.from(document.getElementById('music-channels').selectedOptions).map(o => o.value)
+            };
+            try {
+                const res = await fetch('/api/guild/music/' + currentGuildId, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(config)});
+                if (res.ok) showToast('Music settings saved!');
+                else showToast('Failed to save', true);
+            } catch (err) { showToast('Error saving', true); }
+        }
+
+        async function saveEconomySettings() {
+            const config = {
+                Enabled: getToggle('economy-enabled'),
+                CurrencyName: document.getElementById('economy-name').value,
+                CurrencySymbol: document.getElementById('economy-symbol').value,
+                DailyAmount: parseInt(document.getElementById('economy-daily').value),
+                WorkMin: parseInt(document.getElementById('economy-workmin').value),
+                WorkMax: parseInt(document.getElementById('economy-workmax').value),
+                WorkCooldownMins: parseInt(document.getElementById('economy-workcooldown').value),
+                ChatAmount: parseInt(document.getElementById('economy-chat').value),
+                ChatCooldownSecs: parseInt(document.getElementById('economy-chatcooldown').value)
+            };
+            try {
+                const res = await fetch('/api/guild/economy/' + currentGuildId, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(config)});
+                if (res.ok) showToast('Economy settings saved!');
+                else showToast('Failed to save', true);
+            } catch (err) { showToast('Error saving', true); }
+        }
+
         async function saveTicketSettings() {
-            const config = { enabled: getToggle('ticket-enabled'), channel_id: document.getElementById('ticket-channel').value };
+            const config = { enabled: getToggle('ticket-enabled'), channel_id: document.getElementById('ticket-channel').value, mode: document.getElementById('ticket-mode').value, transcript_channel_id: document.getElementById('ticket-transcript-channel').value, inactive_hours: parseInt(document.getElementById('ticket-inactive-hours').value) || 0, inactive_grace_hours: parseInt(document.getElementById('ticket-inactive-grace').value) || 24 };
             try {
                 const res = await fetch('/api/guild/ticket/' + currentGuildId, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(config)});
                 if (res.ok) showToast('Ticket settings saved!');
@@ -1748,6 +2899,148 @@ const dashboardHTML = `<!DOCTYPE html>
             } catch (err) { showToast('Error removing rank', true); }
         }
 
+        function renderXPExclusions(exclusions) {
+            const container = document.getElementById('xpexclusions-list');
+            if (!exclusions || exclusions.length === 0) { container.innerHTML = '<p style="color:var(--text-secondary)">Every channel and role earns XP</p>'; return; }
+            container.innerHTML = exclusions.map(e => {
+                const item = e.TargetType === 'role' ? roles.find(r => r.id === e.TargetID) : channels.find(c => c.id === e.TargetID);
+                const label = item ? (e.TargetType === 'role' ? '@' : '#') + item.name : e.TargetID;
+                return ` + "`" + `<div class="list-item"><span>${e.TargetType === 'role' ? 'Role' : 'Channel'}</span><span>${escapeHtml(label)}</span><button class="btn btn-danger btn-sm" onclick="removeXPExclusion('${e.TargetType}', '${e.TargetID}')">Remove</button></div>` + "`" + `;
+            }).join('');
+        }
+
+        async function addXPExclusion(type) {
+            const id = document.getElementById('xpexclude-' + type).value;
+            if (!id) { showToast('Select a ' + type + ' first', true); return; }
+            try {
+                const res = await fetch('/api/guild/xpexclusions/' + currentGuildId, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify({type, id})});
+                if (res.ok) {
+                    const exclusions = await fetch('/api/guild/xpexclusions/' + currentGuildId).then(r => r.json());
+                    renderXPExclusions(exclusions);
+                    showToast('Exclusion added!');
+                }
+            } catch (err) { showToast('Error adding exclusion', true); }
+        }
+
+        async function removeXPExclusion(type, id) {
+            try {
+                const res = await fetch('/api/guild/xpexclusions/' + currentGuildId, {method: 'DELETE', headers: {'Content-Type': 'application/json'}, body: JSON.stringify({type, id})});
+                if (res.ok) {
+                    const exclusions = await fetch('/api/guild/xpexclusions/' + currentGuildId).then(r => r.json());
+                    renderXPExclusions(exclusions);
+                    showToast('Exclusion removed!');
+                }
+            } catch (err) { showToast('Error removing exclusion', true); }
+        }
+
+        function escapeHtml(text) {
+            const div = document.createElement('div');
+            div.textContent = text || '';
+            return div.innerHTML;
+        }
+
+        function exportModStats(format) {
+            const days = document.getElementById('modstats-days').value;
+            window.location = '/api/guild/modstats/' + currentGuildId + '?format=' + format + '&days=' + days;
+        }
+
+        async function loadTickets() {
+            const data = await fetch('/api/guild/tickets/' + currentGuildId).then(r => r.json());
+            const container = document.getElementById('tickets-list');
+            const tickets = data.tickets || [];
+            if (tickets.length === 0) { container.innerHTML = '<p style="color:var(--text-secondary)">No tickets yet</p>'; return; }
+            container.innerHTML = tickets.map(t => {
+                const transcript = t.TranscriptURL ? ` + "`" + `<a href="${t.TranscriptURL}" target="_blank">Transcript</a>` + "`" + ` : '-';
+                return ` + "`" + `<div class="list-item"><span>#${t.Number} ${escapeHtml(t.Subject)}</span><span>${t.Status}</span><span>${transcript}</span></div>` + "`" + `;
+            }).join('');
+        }
+
+        async function loadRolePanels() {
+            const panels = await fetch('/api/guild/rolepanels/' + currentGuildId).then(r => r.json());
+            renderRolePanels(panels || []);
+        }
+
+        function renderRolePanels(panels) {
+            const container = document.getElementById('rolepanels-list');
+            if (!panels || panels.length === 0) { container.innerHTML = '<p style="color:var(--text-secondary)">No role panels configured</p>'; return; }
+            const roleOptions = roles.filter(r => r.name !== '@everyone').map(r => ` + "`" + `<option value="${r.id}">${escapeHtml(r.name)}</option>` + "`" + `).join('');
+            const channelOptions = channels.map(c => ` + "`" + `<option value="${c.id}">#${escapeHtml(c.name)}</option>` + "`" + `).join('');
+            container.innerHTML = panels.map(p => {
+                const panelRoles = (p.Roles || []).map(pr => {
+                    const role = roles.find(ro => ro.id === pr.RoleID);
+                    return ` + "`" + `<div class="list-item"><span>${escapeHtml(pr.Label || (role ? role.name : pr.RoleID))}</span><button class="btn btn-danger btn-sm" onclick="removeRolePanelRole(${p.ID}, '${pr.RoleID}')">Remove</button></div>` + "`" + `;
+                }).join('');
+                const posted = p.MessageID ? ('Posted in #' + escapeHtml((channels.find(c => c.id === p.ChannelID) || {}).name || p.ChannelID)) : 'Not posted';
+                return ` + "`" + `<div class="list-item" style="flex-direction:column;align-items:stretch;gap:8px;">
+                    <div style="display:flex;justify-content:space-between;"><strong>#${p.ID} ${escapeHtml(p.Title)}</strong><span>${p.Style} &middot; max ${p.MaxRoles || 'unlimited'} &middot; ${posted}</span></div>
+                    ${panelRoles}
+                    <div class="add-form">
+                        <select id="rolepanel-role-${p.ID}"><option value="">Select Role</option>${roleOptions}</select>
+                        <input type="text" id="rolepanel-label-${p.ID}" placeholder="Label (optional)" maxlength="80">
+                        <button class="btn btn-primary btn-sm" onclick="addRolePanelRole(${p.ID})">Add Role</button>
+                    </div>
+                    <div class="add-form">
+                        <select id="rolepanel-channel-${p.ID}"><option value="">${p.MessageID ? 'Current channel' : 'Select Channel'}</option>${channelOptions}</select>
+                        <button class="btn btn-primary btn-sm" onclick="publishRolePanel(${p.ID})">Post / Refresh</button>
+                        <button class="btn btn-danger btn-sm" onclick="deleteRolePanel(${p.ID})">Delete</button>
+                    </div>
+                </div>` + "`" + `;
+            }).join('');
+            window.rolePanels = panels;
+        }
+
+        async function createRolePanel() {
+            const title = document.getElementById('rolepanel-title').value.trim();
+            if (!title) { showToast('Title required', true); return; }
+            const body = {Title: title, Style: document.getElementById('rolepanel-style').value, MaxRoles: parseInt(document.getElementById('rolepanel-max').value) || 0};
+            try {
+                const res = await fetch('/api/guild/rolepanels/' + currentGuildId, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(body)});
+                if (res.ok) {
+                    document.getElementById('rolepanel-title').value = '';
+                    await loadRolePanels();
+                    showToast('Role panel created!');
+                } else showToast('Failed to create panel', true);
+            } catch (err) { showToast('Error creating panel', true); }
+        }
+
+        async function addRolePanelRole(panelId) {
+            const roleId = document.getElementById('rolepanel-role-' + panelId).value;
+            const label = document.getElementById('rolepanel-label-' + panelId).value.trim();
+            if (!roleId) { showToast('Select a role', true); return; }
+            try {
+                const res = await fetch('/api/guild/rolepanelroles/' + currentGuildId, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify({PanelID: panelId, RoleID: roleId, Label: label})});
+                if (res.ok) { await loadRolePanels(); showToast('Role added!'); }
+                else showToast('Failed to add role', true);
+            } catch (err) { showToast('Error adding role', true); }
+        }
+
+        async function removeRolePanelRole(panelId, roleId) {
+            try {
+                const res = await fetch('/api/guild/rolepanelroles/' + currentGuildId, {method: 'DELETE', headers: {'Content-Type': 'application/json'}, body: JSON.stringify({PanelID: panelId, RoleID: roleId})});
+                if (res.ok) { await loadRolePanels(); showToast('Role removed!'); }
+            } catch (err) { showToast('Error removing role', true); }
+        }
+
+        async function publishRolePanel(panelId) {
+            const panel = (window.rolePanels || []).find(p => p.ID === panelId);
+            if (!panel) return;
+            const channelId = document.getElementById('rolepanel-channel-' + panelId).value;
+            if (!channelId && !panel.MessageID) { showToast('Select a channel', true); return; }
+            try {
+                const res = await fetch('/api/guild/rolepanels/' + currentGuildId, {method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(Object.assign({}, panel, {publish: true, channel_id: channelId}))});
+                if (res.ok) { await loadRolePanels(); showToast('Role panel posted!'); }
+                else showToast(await res.text(), true);
+            } catch (err) { showToast('Error posting panel', true); }
+        }
+
+        async function deleteRolePanel(panelId) {
+            if (!confirm('Delete this role panel and its message?')) return;
+            try {
+                const res = await fetch('/api/guild/rolepanels/' + currentGuildId, {method: 'DELETE', headers: {'Content-Type': 'application/json'}, body: JSON.stringify({id: panelId})});
+                if (res.ok) { await loadRolePanels(); showToast('Role panel deleted!'); }
+            } catch (err) { showToast('Error deleting panel', true); }
+        }
+
         function renderAutoClean(list) {
             const container = document.getElementById('autoclean-list');
             if (!list || list.length === 0) { container.innerHTML = '<p style="color:var(--text-secondary)">No auto-clean channels configured</p>'; return; }
@@ -1865,6 +3158,9 @@ const dashboardHTML = `<!DOCTYPE html>
             document.querySelector(` + "`" + `.main-tab[onclick="switchMainTab('${tab}')"]` + "`" + `).classList.add('active');
             document.getElementById(tab + '-content').classList.add('active');
 
+            if (tab === 'usage') {
+                fetchCommandUsage();
+            }
             if (tab === 'stats') {
                 initStatsTab();
             } else {
@@ -2092,6 +3388,36 @@ const dashboardHTML = `<!DOCTYPE html>
             }
         }
 
+        function formatMs(ms) {
+            if (!ms) return '-';
+            return ms < 1000 ? ms + 'ms' : (ms / 1000).toFixed(1) + 's';
+        }
+
+        async function fetchCommandUsage() {
+            const rows = document.getElementById('usage-rows');
+            try {
+                const days = document.getElementById('usage-days').value;
+                const res = await fetch('/api/stats/commands?days=' + days);
+                const data = await res.json();
+
+                let uses = 0, failed = 0;
+                let html = '';
+                for (const c of data.commands) {
+                    uses += c.uses;
+                    failed += c.failures;
+                    // Highlight commands failing more than 5% of the time
+                    const cls = c.error_rate > 0.05 ? ' class="failing"' : '';
+                    html += ` + "`" + `<tr${cls}><td>/${escapeHtml(c.command.replace(/_/g, ' '))}</td><td>${c.uses.toLocaleString()}</td><td>${c.failures.toLocaleString()}</td><td>${(c.error_rate * 100).toFixed(1)}%</td><td>${formatMs(c.avg_ms)}</td><td>${formatMs(c.p95_ms)}</td><td>${new Date(c.last_used).toLocaleString()}</td></tr>` + "`" + `;
+                }
+                rows.innerHTML = html || '<tr><td colspan="7" style="color:var(--text-secondary)">No commands used in this period</td></tr>';
+                document.getElementById('usage-total').textContent = uses.toLocaleString();
+                document.getElementById('usage-failed').textContent = failed.toLocaleString();
+                document.getElementById('usage-count').textContent = data.commands.length.toLocaleString();
+            } catch (err) {
+                rows.innerHTML = '<tr><td colspan="7" style="color:var(--text-secondary)">Failed to load command usage</td></tr>';
+            }
+        }
+
         fetchStatus();
         fetchStats();
         fetchGuilds();